@@ -0,0 +1,68 @@
+// Package accountmail writes and sends the emails about a user's own
+// account: the link that confirms their address and the password reset
+// link. Messages go out through the digest's SMTP mailer in the same
+// languages as the digest.
+package accountmail
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"solar-system-explorer/backend/digest"
+)
+
+// ResetPath is the SPA page password reset links open, with ?token=
+const ResetPath = "/reset-password"
+
+// strings per language; sr-Cyrl is transliterated from sr
+var text = map[string]map[string]string{
+	"sr": {
+		"verifySubject": "Potvrdite adresu e-pošte",
+		"verifyText":    "Zdravo, %s!\n\nPotvrdite da je ova adresa vaša:\n%s\n\nLink važi 48 sati. Ako niste otvarali nalog, zanemarite ovu poruku.",
+		"resetSubject":  "Nova lozinka za vaš nalog",
+		"resetText":     "Zdravo, %s!\n\nZatražena je nova lozinka za vaš nalog. Postavite je ovde:\n%s\n\nLink važi jedan sat i može da se iskoristi samo jednom. Posle promene lozinke bićete odjavljeni sa svih uređaja. Ako niste tražili novu lozinku, zanemarite ovu poruku.",
+	},
+	"en": {
+		"verifySubject": "Confirm your Solar System Explorer email address",
+		"verifyText":    "Hello %s,\n\nConfirm that this address is yours:\n%s\n\nThe link works for 48 hours. If you didn't create an account, ignore this message.",
+		"resetSubject":  "Reset your Solar System Explorer password",
+		"resetText":     "Hello %s,\n\nSomeone asked for a new password for your account. Set it here:\n%s\n\nThe link works for one hour and only once. Changing the password signs you out on every device. If you didn't ask for this, ignore this message.",
+	},
+}
+
+// Sender mails account links pointing at publicURL
+type Sender struct {
+	mailer    *digest.Mailer
+	publicURL string
+}
+
+// New returns a sender, or nil when mailer is nil (mail not configured)
+func New(mailer *digest.Mailer, publicURL string) *Sender {
+	if mailer == nil {
+		return nil
+	}
+	return &Sender{mailer: mailer, publicURL: strings.TrimRight(publicURL, "/")}
+}
+
+// Verify mails the link confirming the address to
+func (s *Sender) Verify(to, name, locale, token string) error {
+	link := s.publicURL + "/api/auth/verify?token=" + url.QueryEscape(token)
+	return s.send(to, locale, "verify", name, link)
+}
+
+// Reset mails the password reset link to
+func (s *Sender) Reset(to, name, locale, token string) error {
+	link := s.publicURL + ResetPath + "?token=" + url.QueryEscape(token)
+	return s.send(to, locale, "reset", name, link)
+}
+
+func (s *Sender) send(to, locale, kind, name, link string) error {
+	locale = digest.Locale(locale)
+	lang := locale
+	if lang == "sr-Cyrl" {
+		lang = "sr"
+	}
+	body := fmt.Sprintf(text[lang][kind+"Text"], name, link) + "\n"
+	return s.mailer.Send(to, digest.NewMessage(locale, text[lang][kind+"Subject"], body), nil)
+}
@@ -0,0 +1,291 @@
+// Package achievements awards badges for progress. Each badge has a rule
+// over a user's progress; rules are checked whenever progress changes, and
+// a badge once earned is kept even if its rule or the progress later
+// change. Curators define badges through the admin API; a new file starts
+// with the built-in ones.
+package achievements
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+	"solar-system-explorer/backend/progress"
+)
+
+// ErrInvalid wraps validation failures from Put. The message after it
+// starts with the JSON path of the field at fault.
+var ErrInvalid = errors.New("invalid badge")
+
+var badgeID = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Rule is what earns a badge. Every condition set must hold; at least one
+// must be set.
+type Rule struct {
+	Bodies    []string `json:"bodies,omitempty"`     // explore all of these (English names)
+	Tours     []string `json:"tours,omitempty"`      // complete all of these
+	MinBodies int      `json:"min_bodies,omitempty"` // explore at least this many bodies
+	MinTours  int      `json:"min_tours,omitempty"`  // complete at least this many tours
+}
+
+// Met reports whether p satisfies the rule
+func (r Rule) Met(p progress.Progress) bool {
+	for _, b := range r.Bodies {
+		if _, ok := p.Bodies[b]; !ok {
+			return false
+		}
+	}
+	completed := 0
+	for _, t := range p.Tours {
+		if t.CompletedAt != nil {
+			completed++
+		}
+	}
+	for _, id := range r.Tours {
+		if p.Tours[id].CompletedAt == nil {
+			return false
+		}
+	}
+	return len(p.Bodies) >= r.MinBodies && completed >= r.MinTours
+}
+
+// Badge is an achievement users can earn
+type Badge struct {
+	ID          string            `json:"id"`
+	Title       map[string]string `json:"title"` // locale → text, "sr" required
+	Description map[string]string `json:"description,omitempty"`
+	Icon        string            `json:"icon,omitempty"` // name of the SPA's icon
+	Rule        Rule              `json:"rule"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// file is the persisted state
+type file struct {
+	Badges []Badge                         `json:"badges"`
+	Earned map[string]map[string]time.Time `json:"earned"` // user → badge → when
+}
+
+// Achievements holds the badges and who has earned them, persisted to a
+// JSON file when a path is set
+type Achievements struct {
+	mu     sync.RWMutex
+	path   string
+	badges map[string]Badge
+	earned map[string]map[string]time.Time
+}
+
+// Open loads the achievements file at path, which may not exist yet. An
+// empty path keeps everything in memory only.
+func Open(path string) (*Achievements, error) {
+	a := &Achievements{path: path, badges: make(map[string]Badge), earned: make(map[string]map[string]time.Time)}
+	for _, b := range builtIn() {
+		a.badges[b.ID] = b
+	}
+	if path == "" {
+		return a, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	a.badges = make(map[string]Badge, len(f.Badges))
+	for _, b := range f.Badges {
+		a.badges[b.ID] = b
+	}
+	if f.Earned != nil {
+		a.earned = f.Earned
+	}
+	return a, nil
+}
+
+// List returns all badges sorted by ID
+func (a *Achievements) List() []Badge {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.sorted()
+}
+
+// sorted returns the badges by ID. Callers hold a.mu.
+func (a *Achievements) sorted() []Badge {
+	out := make([]Badge, 0, len(a.badges))
+	for _, b := range a.badges {
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Put validates and creates or replaces a badge, returning the stored
+// badge and the one it replaced (nil on create). Rule bodies and tours
+// are expected to be checked by the caller.
+func (a *Achievements) Put(b Badge) (Badge, *Badge, error) {
+	if err := validate(b); err != nil {
+		return b, nil, err
+	}
+	b.UpdatedAt = time.Now().UTC()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	prev, had := a.badges[b.ID]
+	a.badges[b.ID] = b
+	if err := a.save(); err != nil {
+		if had {
+			a.badges[b.ID] = prev
+		} else {
+			delete(a.badges, b.ID)
+		}
+		return b, nil, err
+	}
+	if !had {
+		return b, nil, nil
+	}
+	return b, &prev, nil
+}
+
+// Delete removes a badge, returning it, or nil if there was none. It no
+// longer shows for users who earned it.
+func (a *Achievements) Delete(id string) (*Badge, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	prev, had := a.badges[id]
+	if !had {
+		return nil, nil
+	}
+	delete(a.badges, id)
+	if err := a.save(); err != nil {
+		a.badges[id] = prev
+		return nil, err
+	}
+	return &prev, nil
+}
+
+// Evaluate awards p's user every badge whose rule p now meets and returns
+// the ones newly earned
+func (a *Achievements) Evaluate(p progress.Progress, now time.Time) ([]Badge, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var awarded []Badge
+	mine := a.earned[p.UserID]
+	for _, b := range a.sorted() {
+		if _, ok := mine[b.ID]; ok || !b.Rule.Met(p) {
+			continue
+		}
+		awarded = append(awarded, b)
+	}
+	if len(awarded) == 0 {
+		return nil, nil
+	}
+	if mine == nil {
+		mine = make(map[string]time.Time)
+		a.earned[p.UserID] = mine
+	}
+	for _, b := range awarded {
+		mine[b.ID] = now.UTC()
+	}
+	if err := a.save(); err != nil {
+		for _, b := range awarded {
+			delete(mine, b.ID)
+		}
+		return nil, err
+	}
+	return awarded, nil
+}
+
+// Earned returns when userID earned each badge that still exists
+func (a *Achievements) Earned(userID string) map[string]time.Time {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	out := make(map[string]time.Time)
+	for id, at := range a.earned[userID] {
+		if _, ok := a.badges[id]; ok {
+			out[id] = at
+		}
+	}
+	return out
+}
+
+// save writes badges and awards via a temp file and rename. Callers hold
+// a.mu.
+func (a *Achievements) save() error {
+	if a.path == "" {
+		return nil
+	}
+	return jsonfile.Save(a.path, file{Badges: a.sorted(), Earned: a.earned}, 0o644)
+}
+
+func validate(b Badge) error {
+	r := b.Rule
+	switch {
+	case len(b.ID) > 64 || !badgeID.MatchString(b.ID):
+		return fmt.Errorf("%w: id must be a lowercase slug of at most 64 characters", ErrInvalid)
+	case b.Title["sr"] == "":
+		return fmt.Errorf("%w: title needs Serbian (sr) text", ErrInvalid)
+	case r.MinBodies < 0 || r.MinTours < 0:
+		return fmt.Errorf("%w: rule minimums must not be negative", ErrInvalid)
+	case len(r.Bodies) == 0 && len(r.Tours) == 0 && r.MinBodies == 0 && r.MinTours == 0:
+		return fmt.Errorf("%w: rule needs bodies, tours, min_bodies or min_tours", ErrInvalid)
+	}
+	return nil
+}
+
+// builtIn are the badges a new achievements file starts with
+func builtIn() []Badge {
+	return []Badge{
+		{
+			ID:          "first-steps",
+			Title:       map[string]string{"sr": "Prvi korak", "en": "First steps"},
+			Description: map[string]string{"sr": "Prvo istraženo nebesko telo.", "en": "You explored your first celestial body."},
+			Icon:        "footprints",
+			Rule:        Rule{MinBodies: 1},
+		},
+		{
+			ID:          "rocky-planets",
+			Title:       map[string]string{"sr": "Stenovite planete", "en": "Rocky planets"},
+			Description: map[string]string{"sr": "Istraženi Merkur, Venera, Zemlja i Mars.", "en": "You explored Mercury, Venus, Earth and Mars."},
+			Icon:        "rock",
+			Rule:        Rule{Bodies: []string{"Mercury", "Venus", "Earth", "Mars"}},
+		},
+		{
+			ID:          "gas-giants",
+			Title:       map[string]string{"sr": "Gasoviti džinovi", "en": "Gas giants"},
+			Description: map[string]string{"sr": "Istraženi Jupiter i Saturn.", "en": "You explored Jupiter and Saturn."},
+			Icon:        "jupiter",
+			Rule:        Rule{Bodies: []string{"Jupiter", "Saturn"}},
+		},
+		{
+			ID:          "ice-giants",
+			Title:       map[string]string{"sr": "Ledeni džinovi", "en": "Ice giants"},
+			Description: map[string]string{"sr": "Istraženi Uran i Neptun.", "en": "You explored Uranus and Neptune."},
+			Icon:        "snowflake",
+			Rule:        Rule{Bodies: []string{"Uranus", "Neptune"}},
+		},
+		{
+			ID:          "explorer",
+			Title:       map[string]string{"sr": "Istraživač", "en": "Explorer"},
+			Description: map[string]string{"sr": "Deset istraženih nebeskih tela.", "en": "You explored ten celestial bodies."},
+			Icon:        "telescope",
+			Rule:        Rule{MinBodies: 10},
+		},
+		{
+			ID:          "grand-tourist",
+			Title:       map[string]string{"sr": "Veliki obilazak", "en": "Grand tourist"},
+			Description: map[string]string{"sr": "Završen Veliki obilazak Sunčevog sistema.", "en": "You finished the Grand Tour of the Solar System."},
+			Icon:        "rocket",
+			Rule:        Rule{Tours: []string{"grand-tour"}},
+		},
+	}
+}
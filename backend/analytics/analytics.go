@@ -0,0 +1,338 @@
+// Package analytics stores the usage events the frontend reports — page
+// and body views, tours started and finished, quizzes — without anything
+// that identifies a visitor: no account, address or user agent is kept,
+// only a hash of them keyed with a salt that changes every day and is
+// never stored, so the same visitor can be counted once per day but not
+// followed from one day to the next. Events older than the retention are
+// purged.
+package analytics
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	TypePageView     = "page_view"
+	TypeBodyView     = "body_view"
+	TypeTourStart    = "tour_start"
+	TypeTourComplete = "tour_complete"
+	TypeQuizComplete = "quiz_complete"
+	TypeSearch       = "search"
+	// TypeRequests holds the server's own API request counts per route
+	// since the previous one; clients can't send it
+	TypeRequests = "requests"
+	// TypeExposure and TypeConversion are recorded by package experiments
+	TypeExposure   = "experiment_exposure"
+	TypeConversion = "experiment_conversion"
+)
+
+// Types lists the event types clients may send
+var Types = []string{TypePageView, TypeBodyView, TypeTourStart, TypeTourComplete, TypeQuizComplete, TypeSearch}
+
+// Event is one thing a visitor did
+type Event struct {
+	Type    string         `json:"type"`
+	At      time.Time      `json:"at"`
+	Visitor string         `json:"visitor"` // salted hash, changes daily
+	Path    string         `json:"path,omitempty"`
+	Body    string         `json:"body,omitempty"` // English name
+	Tour    string         `json:"tour,omitempty"`
+	Quiz    string         `json:"quiz,omitempty"`
+	Query   string         `json:"query,omitempty"`  // search
+	Passed  *bool          `json:"passed,omitempty"` // quiz_complete
+	Locale  string         `json:"locale,omitempty"`
+	Counts  map[string]int `json:"counts,omitempty"` // requests: per "METHOD /route"
+
+	Experiment string `json:"experiment,omitempty"`
+	Variant    string `json:"variant,omitempty"`
+	Goal       string `json:"goal,omitempty"` // experiment_conversion
+}
+
+// Repository keeps events
+type Repository interface {
+	// Insert stores events, all or none of them
+	Insert(ctx context.Context, events []Event) error
+	// Query returns the events at or after from and before to, oldest first
+	Query(ctx context.Context, from, to time.Time) ([]Event, error)
+	// Purge deletes the events before t and returns how many
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	Close() error
+}
+
+// Collector stamps incoming events with the visitor's daily hash and
+// stores them
+type Collector struct {
+	repo      Repository
+	retention time.Duration
+
+	mu        sync.Mutex
+	salt      []byte
+	saltSince time.Time
+	requests  map[string]int // since the last Flush
+}
+
+// NewCollector stores events in repo and keeps them for retention
+func NewCollector(repo Repository, retention time.Duration) *Collector {
+	return &Collector{repo: repo, retention: retention}
+}
+
+// Record stores events from the visitor identified by who (address and
+// user agent). Timestamps the client sent are kept when they lie within
+// the last day; others are replaced by now.
+func (c *Collector) Record(ctx context.Context, who string, events []Event, now time.Time) error {
+	visitor := c.visitor(who, now)
+	for i := range events {
+		e := &events[i]
+		e.Visitor = visitor
+		if e.At.IsZero() || e.At.After(now) || now.Sub(e.At) > 24*time.Hour {
+			e.At = now
+		}
+		e.At = e.At.UTC().Truncate(time.Second)
+	}
+	return c.repo.Insert(ctx, events)
+}
+
+// visitor hashes who with the day's salt
+func (c *Collector) visitor(who string, now time.Time) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	day := now.UTC().Truncate(24 * time.Hour)
+	if c.salt == nil || !c.saltSince.Equal(day) {
+		c.salt = make([]byte, 32)
+		rand.Read(c.salt)
+		c.saltSince = day
+	}
+	mac := hmac.New(sha256.New, c.salt)
+	mac.Write([]byte(who))
+	return hex.EncodeToString(mac.Sum(nil)[:12])
+}
+
+// CountRequest counts a request to route, for instance "GET /api/planets/:name"
+func (c *Collector) CountRequest(route string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.requests == nil {
+		c.requests = make(map[string]int)
+	}
+	c.requests[route]++
+}
+
+// Flush stores the request counts since the previous flush as one
+// requests event. It is meant to run periodically and at shutdown; counts
+// that fail to store are kept for the next try.
+func (c *Collector) Flush(ctx context.Context, now time.Time) error {
+	c.mu.Lock()
+	counts := c.requests
+	c.requests = nil
+	c.mu.Unlock()
+	if len(counts) == 0 {
+		return nil
+	}
+	err := c.repo.Insert(ctx, []Event{{Type: TypeRequests, At: now.UTC().Truncate(time.Second), Counts: counts}})
+	if err != nil {
+		c.mu.Lock()
+		for route, n := range counts {
+			if c.requests == nil {
+				c.requests = make(map[string]int)
+			}
+			c.requests[route] += n
+		}
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// Query returns the events between from and to
+func (c *Collector) Query(ctx context.Context, from, to time.Time) ([]Event, error) {
+	return c.repo.Query(ctx, from, to)
+}
+
+// Purge deletes events past the retention
+func (c *Collector) Purge(ctx context.Context, now time.Time) (int64, error) {
+	return c.repo.Purge(ctx, now.Add(-c.retention))
+}
+
+// Retention is how long events are kept
+func (c *Collector) Retention() time.Duration { return c.retention }
+
+// Count is a name and how often it came up
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Rate is how many of Total succeeded
+type Rate struct {
+	Name   string  `json:"name"`
+	Total  int     `json:"total"`
+	Passed int     `json:"passed"`
+	Rate   float64 `json:"rate"` // 0–1
+}
+
+// Day is one UTC day of activity
+type Day struct {
+	Date     string `json:"date"` // 2006-01-02
+	Events   int    `json:"events"`
+	Visitors int    `json:"visitors"`
+}
+
+// Summary aggregates events over a period
+type Summary struct {
+	From      time.Time      `json:"from"`
+	To        time.Time      `json:"to"`
+	Events    int            `json:"events"`
+	ByType    map[string]int `json:"by_type"`
+	Bodies    []Count        `json:"top_bodies"`
+	Pages     []Count        `json:"top_pages"`
+	Searches  []Count        `json:"top_searches,omitempty"`
+	Tours     []Rate         `json:"tours"`   // completed of started
+	Quizzes   []Rate         `json:"quizzes"` // passed of completed
+	Days      []Day          `json:"days"`
+	Locales   []Count        `json:"locales"`
+	PeakDaily int            `json:"peak_daily_visitors"`
+	Requests  []Count        `json:"top_requests"`
+	// RequestTotal counts all API requests, not only the top routes
+	RequestTotal int `json:"request_total"`
+}
+
+// Summarize aggregates events, listing the top entries of each ranking.
+// Request counts are summed apart from the visitors' events.
+func Summarize(events []Event, from, to time.Time, top int) Summary {
+	s := Summary{From: from, To: to, ByType: make(map[string]int)}
+	bodies, pages, searches, locales, requests := map[string]int{}, map[string]int{}, map[string]int{}, map[string]int{}, map[string]int{}
+	tours, quizzes := map[string]*Rate{}, map[string]*Rate{}
+	rate := func(m map[string]*Rate, name string) *Rate {
+		if m[name] == nil {
+			m[name] = &Rate{Name: name}
+		}
+		return m[name]
+	}
+	days := map[string]*Day{}
+	visitors := map[string]map[string]bool{}
+	for _, e := range events {
+		if e.Type == TypeRequests {
+			for route, n := range e.Counts {
+				requests[route] += n
+				s.RequestTotal += n
+			}
+			continue
+		}
+		s.Events++
+		s.ByType[e.Type]++
+		switch e.Type {
+		case TypeBodyView:
+			bodies[e.Body]++
+		case TypePageView:
+			pages[e.Path]++
+		case TypeSearch:
+			searches[e.Query]++
+		case TypeTourStart:
+			rate(tours, e.Tour).Total++
+		case TypeTourComplete:
+			rate(tours, e.Tour).Passed++
+		case TypeQuizComplete:
+			r := rate(quizzes, e.Quiz)
+			r.Total++
+			if e.Passed != nil && *e.Passed {
+				r.Passed++
+			}
+		}
+		if e.Locale != "" {
+			locales[e.Locale]++
+		}
+		date := e.At.UTC().Format(time.DateOnly)
+		if days[date] == nil {
+			days[date] = &Day{Date: date}
+			visitors[date] = map[string]bool{}
+		}
+		days[date].Events++
+		visitors[date][e.Visitor] = true
+	}
+	s.Bodies, s.Pages, s.Searches, s.Locales = ranked(bodies, top), ranked(pages, top), ranked(searches, top), ranked(locales, top)
+	s.Requests = ranked(requests, top)
+	s.Tours, s.Quizzes = rates(tours), rates(quizzes)
+	s.Days = []Day{}
+	for date, d := range days {
+		d.Visitors = len(visitors[date])
+		s.PeakDaily = max(s.PeakDaily, d.Visitors)
+		s.Days = append(s.Days, *d)
+	}
+	sort.Slice(s.Days, func(i, j int) bool { return s.Days[i].Date < s.Days[j].Date })
+	return s
+}
+
+func ranked(m map[string]int, top int) []Count {
+	out := make([]Count, 0, len(m))
+	for name, n := range m {
+		if name != "" {
+			out = append(out, Count{Name: name, Count: n})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	if len(out) > top {
+		out = out[:top]
+	}
+	return out
+}
+
+func rates(m map[string]*Rate) []Rate {
+	out := make([]Rate, 0, len(m))
+	for _, r := range m {
+		if r.Total > 0 {
+			// A tour started before the period may finish in it
+			r.Rate = math.Round(min(1, float64(r.Passed)/float64(r.Total))*1000) / 1000
+		}
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// Active is visitor activity over the last Days days up to some time
+type Active struct {
+	Days int `json:"days"`
+	// VisitorDays counts each visitor once per day they were active: the
+	// daily salt keeps days apart, so no visitor can be followed across
+	VisitorDays  int     `json:"visitor_days"`
+	DailyAverage float64 `json:"daily_average"`
+	Peak         int     `json:"peak"`
+}
+
+// ActiveOver sums the daily visitors of days (as in Summary.Days) over
+// each window of the last n days up to and including the day of to
+func ActiveOver(days []Day, to time.Time, windows ...int) []Active {
+	out := make([]Active, 0, len(windows))
+	last := to.UTC().Truncate(24 * time.Hour)
+	for _, n := range windows {
+		a := Active{Days: n}
+		first := last.AddDate(0, 0, 1-n).Format(time.DateOnly)
+		for _, d := range days {
+			if d.Date >= first && d.Date <= last.Format(time.DateOnly) {
+				a.VisitorDays += d.Visitors
+				a.Peak = max(a.Peak, d.Visitors)
+			}
+		}
+		a.DailyAverage = math.Round(float64(a.VisitorDays)/float64(n)*100) / 100
+		out = append(out, a)
+	}
+	return out
+}
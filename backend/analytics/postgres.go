@@ -0,0 +1,72 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Postgres keeps events in the analytics_events table (migration 0002),
+// shared by every replica
+type Postgres struct {
+	db *sql.DB
+}
+
+// NewPostgres stores events in db, whose schema is already migrated
+func NewPostgres(db *sql.DB) *Postgres { return &Postgres{db: db} }
+
+func (p *Postgres) Insert(ctx context.Context, events []Event) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO analytics_events (at, type, data) VALUES ($1, $2, $3)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.ExecContext(ctx, e.At, e.Type, data); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (p *Postgres) Query(ctx context.Context, from, to time.Time) ([]Event, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT data FROM analytics_events WHERE at >= $1 AND at < $2 ORDER BY at, id`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Event
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var e Event
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (p *Postgres) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM analytics_events WHERE at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Close leaves the connection pool to its owner
+func (p *Postgres) Close() error { return nil }
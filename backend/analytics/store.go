@@ -0,0 +1,135 @@
+package analytics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+)
+
+// Memory keeps events in memory, optionally appending each to a JSON
+// lines file it reloads at startup. At most max events are kept; the
+// oldest go first.
+type Memory struct {
+	mu     sync.Mutex
+	path   string
+	max    int
+	events []Event // oldest first
+}
+
+// OpenFile loads the events in path, which may not exist yet. An empty
+// path keeps events in memory only.
+func OpenFile(path string, max int) (*Memory, error) {
+	m := &Memory{path: path, max: max}
+	if path == "" {
+		return m, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		var e Event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filepath.Base(path), line, err)
+		}
+		m.events = append(m.events, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(m.events, func(i, j int) bool { return m.events[i].At.Before(m.events[j].At) })
+	m.trim()
+	return m, nil
+}
+
+func (m *Memory) Insert(_ context.Context, events []Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.path != "" {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, e := range events {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		f, err := os.OpenFile(m.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(buf.Bytes())
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, e := range events {
+		// Events arrive nearly in order; keep the slice sorted
+		i := sort.Search(len(m.events), func(i int) bool { return m.events[i].At.After(e.At) })
+		m.events = append(m.events, Event{})
+		copy(m.events[i+1:], m.events[i:])
+		m.events[i] = e
+	}
+	m.trim()
+	return nil
+}
+
+// trim drops the oldest events beyond max. Callers hold m.mu or own m.
+func (m *Memory) trim() {
+	if m.max > 0 && len(m.events) > m.max {
+		m.events = append([]Event(nil), m.events[len(m.events)-m.max:]...)
+	}
+}
+
+func (m *Memory) Query(_ context.Context, from, to time.Time) ([]Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := sort.Search(len(m.events), func(i int) bool { return !m.events[i].At.Before(from) })
+	j := sort.Search(len(m.events), func(i int) bool { return !m.events[i].At.Before(to) })
+	return append([]Event(nil), m.events[i:j]...), nil
+}
+
+// Purge drops the events before t and rewrites the file without them
+func (m *Memory) Purge(_ context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := sort.Search(len(m.events), func(i int) bool { return !m.events[i].At.Before(before) })
+	if i == 0 {
+		return 0, nil
+	}
+	kept := append([]Event(nil), m.events[i:]...)
+	if m.path != "" {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, e := range kept {
+			if err := enc.Encode(e); err != nil {
+				return 0, err
+			}
+		}
+		if err := jsonfile.Write(m.path, buf.Bytes(), 0o600); err != nil {
+			return 0, err
+		}
+	}
+	m.events = kept
+	return int64(i), nil
+}
+
+func (m *Memory) Close() error { return nil }
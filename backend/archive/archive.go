@@ -0,0 +1,225 @@
+// Package archive writes and reads dataset archives: a gzipped tar of the
+// bodies, translations, tours and asset manifest, for moving content
+// between environments. The archive's manifest lists the SHA-256 of every
+// file and is signed with HMAC-SHA256, so an archive is only accepted by
+// an environment sharing the key and can't be altered in transit.
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"solar-system-explorer/backend/assets"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/store"
+)
+
+// Format is the archive layout version written by Write. Read refuses
+// other versions rather than guess at their meaning.
+const Format = 1
+
+// Names of the files in an archive
+const (
+	ManifestFile     = "manifest.json"
+	SignatureFile    = "manifest.sig"
+	BodiesFile       = "bodies.json"
+	TranslationsFile = "translations.json"
+	ToursFile        = "tours.json"
+	AssetsFile       = "assets.json"
+)
+
+// MaxFileSize caps each file read from an archive
+const MaxFileSize = 64 << 20
+
+// Errors from Read
+var (
+	ErrInvalid   = errors.New("invalid archive")
+	ErrSignature = errors.New("archive signature does not match")
+	ErrFormat    = errors.New("unsupported archive format")
+)
+
+// Manifest describes an archive
+type Manifest struct {
+	Format    int               `json:"format"`
+	CreatedAt time.Time         `json:"created_at"`
+	Source    string            `json:"source,omitempty"` // dataset ETag when exported
+	Counts    map[string]int    `json:"counts"`
+	Files     map[string]string `json:"files"` // name → hex SHA-256
+}
+
+// Contents is what an archive carries. Assets is the manifest only; the
+// files themselves are deployed with the asset directory.
+type Contents struct {
+	Bodies       []models.Planet          `json:"bodies"`
+	Translations []store.TranslationEntry `json:"translations"`
+	Tours        []models.Tour            `json:"tours"`
+	Assets       []assets.Asset           `json:"assets"`
+}
+
+// Write writes contents to w as a signed archive and returns its manifest
+func Write(w io.Writer, key []byte, contents Contents, source string, now time.Time) (Manifest, error) {
+	m := Manifest{
+		Format:    Format,
+		CreatedAt: now.UTC(),
+		Source:    source,
+		Counts: map[string]int{
+			"bodies":       len(contents.Bodies),
+			"translations": len(contents.Translations),
+			"tours":        len(contents.Tours),
+			"assets":       len(contents.Assets),
+		},
+		Files: make(map[string]string),
+	}
+	files := []struct {
+		name string
+		v    any
+	}{
+		{BodiesFile, contents.Bodies},
+		{TranslationsFile, contents.Translations},
+		{ToursFile, contents.Tours},
+		{AssetsFile, contents.Assets},
+	}
+	data := make(map[string][]byte, len(files)+2)
+	for _, f := range files {
+		b, err := json.MarshalIndent(f.v, "", "  ")
+		if err != nil {
+			return m, fmt.Errorf("%s: %w", f.name, err)
+		}
+		sum := sha256.Sum256(b)
+		m.Files[f.name] = hex.EncodeToString(sum[:])
+		data[f.name] = b
+	}
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return m, err
+	}
+	data[ManifestFile] = manifest
+	data[SignatureFile] = []byte(sign(key, manifest) + "\n")
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	names := []string{ManifestFile, SignatureFile}
+	for _, f := range files {
+		names = append(names, f.name)
+	}
+	for _, name := range names {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data[name])), ModTime: m.CreatedAt}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return m, err
+		}
+		if _, err := tw.Write(data[name]); err != nil {
+			return m, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return m, err
+	}
+	return m, gz.Close()
+}
+
+// Read reads an archive from r, checking the manifest signature with key
+// and every file against its hash before decoding anything
+func Read(r io.Reader, key []byte) (Manifest, Contents, error) {
+	var m Manifest
+	var contents Contents
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return m, contents, fmt.Errorf("%w: not gzip: %v", ErrInvalid, err)
+	}
+	defer gz.Close()
+
+	data := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, contents, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if _, dup := data[hdr.Name]; dup {
+			return m, contents, fmt.Errorf("%w: %s appears twice", ErrInvalid, hdr.Name)
+		}
+		b, err := io.ReadAll(io.LimitReader(tr, MaxFileSize+1))
+		if err != nil {
+			return m, contents, fmt.Errorf("%w: %s: %v", ErrInvalid, hdr.Name, err)
+		}
+		if len(b) > MaxFileSize {
+			return m, contents, fmt.Errorf("%w: %s is larger than %d MiB", ErrInvalid, hdr.Name, MaxFileSize>>20)
+		}
+		data[hdr.Name] = b
+	}
+
+	manifest, sig := data[ManifestFile], data[SignatureFile]
+	if manifest == nil || sig == nil {
+		return m, contents, fmt.Errorf("%w: %s and %s are required", ErrInvalid, ManifestFile, SignatureFile)
+	}
+	if !hmac.Equal(bytes.TrimSpace(sig), []byte(sign(key, manifest))) {
+		return m, contents, ErrSignature
+	}
+	if err := json.Unmarshal(manifest, &m); err != nil {
+		return m, contents, fmt.Errorf("%w: %s: %v", ErrInvalid, ManifestFile, err)
+	}
+	if m.Format != Format {
+		return m, contents, fmt.Errorf("%w: %d, this server reads %d", ErrFormat, m.Format, Format)
+	}
+
+	var names []string
+	for name := range data {
+		if name != ManifestFile && name != SignatureFile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, listed := m.Files[name]; !listed {
+			return m, contents, fmt.Errorf("%w: %s is not in the manifest", ErrInvalid, name)
+		}
+	}
+	targets := map[string]any{
+		BodiesFile:       &contents.Bodies,
+		TranslationsFile: &contents.Translations,
+		ToursFile:        &contents.Tours,
+		AssetsFile:       &contents.Assets,
+	}
+	for name, want := range m.Files {
+		b, ok := data[name]
+		if !ok {
+			return m, Contents{}, fmt.Errorf("%w: %s is missing", ErrInvalid, name)
+		}
+		sum := sha256.Sum256(b)
+		if hex.EncodeToString(sum[:]) != want {
+			return m, Contents{}, fmt.Errorf("%w: %s does not match its hash", ErrInvalid, name)
+		}
+		if _, known := targets[name]; !known {
+			return m, Contents{}, fmt.Errorf("%w: unknown file %s", ErrInvalid, name)
+		}
+	}
+	for name := range m.Files {
+		if err := json.Unmarshal(data[name], targets[name]); err != nil {
+			return m, Contents{}, fmt.Errorf("%w: %s: %v", ErrInvalid, name, err)
+		}
+	}
+	return m, contents, nil
+}
+
+// sign returns the hex HMAC-SHA256 of manifest under key
+func sign(key, manifest []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(manifest)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,159 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/store"
+)
+
+var testKey = []byte("archive-test-key")
+
+func testContents() Contents {
+	return Contents{
+		Bodies:       models.GetSolarSystemBodies()[:3],
+		Translations: []store.TranslationEntry{{Body: "Mercury", Locale: "de", Field: "name", Text: "Merkur", Status: "published"}},
+		Tours:        []models.Tour{},
+	}
+}
+
+// rewrite unpacks archive, lets edit change its files (name → contents,
+// in order) and packs it again
+func rewrite(t *testing.T, archive []byte, edit func(names []string, files map[string][]byte) []string) []byte {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	files := make(map[string][]byte)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+		files[hdr.Name] = data
+	}
+	names = edit(names, files)
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name]))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	created := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	written, err := Write(&buf, testKey, testContents(), `"etag"`, created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, contents, err := Read(&buf, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.CreatedAt.Equal(created) || m.Source != written.Source || m.Counts["bodies"] != 3 {
+		t.Errorf("manifest = %+v, want %+v", m, written)
+	}
+	if len(contents.Bodies) != 3 || contents.Bodies[2].Name != "Venus" || len(contents.Translations) != 1 {
+		t.Errorf("contents = %d bodies, %d translations", len(contents.Bodies), len(contents.Translations))
+	}
+}
+
+func TestReadRejectsTampering(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := Write(&buf, testKey, testContents(), "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	good := buf.Bytes()
+
+	tests := []struct {
+		name string
+		key  []byte
+		edit func(names []string, files map[string][]byte) []string
+		want error
+	}{
+		{"wrong key", []byte("another-key"), nil, ErrSignature},
+		{"changed body", testKey, func(names []string, files map[string][]byte) []string {
+			files[BodiesFile] = bytes.Replace(files[BodiesFile], []byte(`"Venus"`), []byte(`"Vulcan"`), 1)
+			return names
+		}, ErrInvalid},
+		{"changed manifest", testKey, func(names []string, files map[string][]byte) []string {
+			files[ManifestFile] = bytes.Replace(files[ManifestFile], []byte(`"bodies": 3`), []byte(`"bodies": 4`), 1)
+			return names
+		}, ErrSignature},
+		{"missing signature", testKey, func(names []string, files map[string][]byte) []string {
+			return without(names, SignatureFile)
+		}, ErrInvalid},
+		{"missing file", testKey, func(names []string, files map[string][]byte) []string {
+			return without(names, ToursFile)
+		}, ErrInvalid},
+		{"extra file", testKey, func(names []string, files map[string][]byte) []string {
+			files["extra.json"] = []byte("[]")
+			return append(names, "extra.json")
+		}, ErrInvalid},
+		{"file twice", testKey, func(names []string, files map[string][]byte) []string {
+			return append(names, BodiesFile)
+		}, ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := good
+			if tt.edit != nil {
+				data = rewrite(t, good, tt.edit)
+			}
+			_, contents, err := Read(bytes.NewReader(data), tt.key)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			if len(contents.Bodies) != 0 {
+				t.Errorf("returned %d bodies from a rejected archive", len(contents.Bodies))
+			}
+		})
+	}
+
+	if _, _, err := Read(strings.NewReader("not an archive"), testKey); !errors.Is(err, ErrInvalid) {
+		t.Errorf("plain text: err = %v, want ErrInvalid", err)
+	}
+}
+
+func without(names []string, drop string) []string {
+	var out []string
+	for _, n := range names {
+		if n != drop {
+			out = append(out, n)
+		}
+	}
+	return out
+}
@@ -0,0 +1,418 @@
+// Package assets keeps the large binary files the 3D view loads — glTF
+// models, textures — and the recorded pronunciations of body names in a directory or an S3 bucket (backend.go),
+// described by a manifest. Only files listed in the manifest are served,
+// with their license and attribution. Hot files are kept in an in-memory
+// cache (cache.go).
+package assets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ManifestFile lists the assets in the assets directory
+const ManifestFile = "assets.json"
+
+// Asset kinds
+const (
+	KindModel   = "model"
+	KindTexture = "texture"
+	KindPhoto   = "photo"
+	KindAudio   = "audio" // a body's name spoken in one locale
+)
+
+// ErrNoStorage is returned by Put and Delete on a store without a backend
+var ErrNoStorage = errors.New("assets: no storage configured")
+
+// Asset is one file and what is known about it. Size and ModTime are read
+// from the backend, the rest from the manifest.
+type Asset struct {
+	Path        string    `json:"path"` // slash-separated, relative to the directory
+	Body        string    `json:"body"` // English name of the body it depicts
+	Kind        string    `json:"kind"`
+	LOD         int       `json:"lod"` // level of detail, 0 is the finest
+	Triangles   int       `json:"triangles,omitempty"`
+	Width       int       `json:"width,omitempty"` // pixels, for textures and photos
+	Height      int       `json:"height,omitempty"`
+	Locale      string    `json:"locale,omitempty"`     // language spoken, for audio
+	VariantOf   string    `json:"variant_of,omitempty"` // path of the uploaded original
+	License     string    `json:"license"`
+	Attribution string    `json:"attribution,omitempty"`
+	Source      string    `json:"source,omitempty"`   // URL the file came from
+	Prefetch    bool      `json:"prefetch,omitempty"` // read into the cache at startup
+	Private     bool      `json:"private,omitempty"`  // served only through signed URLs
+	Size        int64     `json:"size"`               // bytes
+	ModTime     time.Time `json:"-"`
+}
+
+// ETag is a strong validator built from the size and modification time,
+// so If-Range can resume a download only while the file is unchanged
+func (a Asset) ETag() string {
+	return fmt.Sprintf(`"%x-%x"`, a.Size, a.ModTime.UnixNano())
+}
+
+// Store is a loaded asset directory. A nil *Store is empty.
+type Store struct {
+	backend Backend // nil when no storage is configured
+	cache   *Cache  // nil when disabled
+
+	mu     sync.RWMutex
+	byPath map[string]Asset
+	order  []string // manifest order, for prefetching
+
+	writeMu sync.Mutex // serializes Put and Delete, which rewrite the manifest
+}
+
+// File is an open asset, in the backend or in the cache
+type File interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+// Open reads the manifest in dir and checks every listed file exists. An
+// empty dir gives an empty store. cacheBytes is the memory budget of the
+// content cache; 0 disables it.
+func Open(dir string, cacheBytes int64) (*Store, error) {
+	if dir == "" {
+		return OpenBackend(context.Background(), nil, cacheBytes)
+	}
+	return OpenBackend(context.Background(), Disk(dir), cacheBytes)
+}
+
+// OpenBackend is Open for any backend; nil gives an empty store that
+// can't take uploads. A bucket without a manifest yet starts empty, while
+// a directory needs one, so a mistyped path isn't taken for a new store.
+func OpenBackend(ctx context.Context, b Backend, cacheBytes int64) (*Store, error) {
+	s := &Store{backend: b, byPath: make(map[string]Asset)}
+	if cacheBytes > 0 {
+		s.cache = NewCache(cacheBytes)
+	}
+	if b == nil {
+		return s, nil
+	}
+	f, _, err := b.Open(ctx, ManifestFile)
+	if _, isBucket := b.(Bucket); isBucket && errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	var list []Asset
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", ManifestFile, err)
+	}
+	var errs []error
+	for i, a := range list {
+		if err := check(a); err != nil {
+			errs = append(errs, fmt.Errorf("asset %d: %w", i, err))
+			continue
+		}
+		info, err := b.Stat(ctx, a.Path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", a.Path, err))
+			continue
+		}
+		a.Size, a.ModTime = info.Size, info.ModTime
+		if _, dup := s.byPath[a.Path]; !dup {
+			s.order = append(s.order, a.Path)
+		}
+		s.byPath[a.Path] = a
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("%s: %w", ManifestFile, err)
+	}
+	return s, nil
+}
+
+// ValidPath reports whether p can name an asset: relative, clean, inside
+// the store and not the manifest
+func ValidPath(p string) bool {
+	clean := path.Clean(p)
+	return p != "" && clean == p && !path.IsAbs(clean) && clean != ".." && !strings.HasPrefix(clean, "../") && clean != ManifestFile
+}
+
+// check validates a manifest entry
+func check(a Asset) error {
+	switch {
+	case !ValidPath(a.Path):
+		return fmt.Errorf("invalid path %q", a.Path)
+	case a.Kind != KindModel && a.Kind != KindTexture && a.Kind != KindPhoto && a.Kind != KindAudio:
+		return fmt.Errorf("%s: kind must be %s, %s, %s or %s", a.Path, KindModel, KindTexture, KindPhoto, KindAudio)
+	case a.Kind == KindAudio && a.Locale == "":
+		return fmt.Errorf("%s: audio needs a locale", a.Path)
+	case a.License == "":
+		return fmt.Errorf("%s: license is required", a.Path)
+	}
+	return nil
+}
+
+// Storage names where the files are kept, empty when nowhere
+func (s *Store) Storage() string {
+	if s == nil || s.backend == nil {
+		return ""
+	}
+	return s.backend.String()
+}
+
+// Len returns the number of assets
+func (s *Store) Len() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.byPath)
+}
+
+// List returns the assets in manifest order
+func (s *Store) List() []Asset {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Asset, 0, len(s.order))
+	for _, p := range s.order {
+		out = append(out, s.byPath[p])
+	}
+	return out
+}
+
+// HasPrivate reports whether any asset needs a signed URL
+func (s *Store) HasPrivate() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, a := range s.byPath {
+		if a.Private {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the asset at path
+func (s *Store) Get(p string) (Asset, bool) {
+	if s == nil {
+		return Asset{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, ok := s.byPath[p]
+	return a, ok
+}
+
+// ForBody returns the assets of kind depicting body, finest first
+func (s *Store) ForBody(body, kind string) []Asset {
+	var out []Asset
+	if s == nil {
+		return out
+	}
+	s.mu.RLock()
+	for _, a := range s.byPath {
+		if strings.EqualFold(a.Body, body) && a.Kind == kind {
+			out = append(out, a)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].LOD != out[j].LOD {
+			return out[i].LOD < out[j].LOD
+		}
+		return out[i].Path < out[j].Path
+	})
+	return out
+}
+
+// Open opens the asset at path for reading. Size and ModTime are those of
+// the file now, in case it was replaced since the manifest was loaded.
+// Contents come from the cache when it holds this version of the file;
+// otherwise the file is opened and, if it fits, read into the cache in
+// the background for the next request.
+func (s *Store) Open(ctx context.Context, p string) (File, Asset, error) {
+	a, ok := s.Get(p)
+	if !ok || s.backend == nil {
+		return nil, Asset{}, fs.ErrNotExist
+	}
+	info, err := s.backend.Stat(ctx, a.Path)
+	if err != nil {
+		return nil, Asset{}, err
+	}
+	a.Size, a.ModTime = info.Size, info.ModTime
+	if s.cache != nil {
+		if data, ok := s.cache.get(a.Path, a.Size, a.ModTime); ok {
+			return memFile{bytes.NewReader(data)}, a, nil
+		}
+	}
+	f, info, err := s.backend.Open(ctx, a.Path)
+	if err != nil {
+		return nil, Asset{}, err
+	}
+	a.Size, a.ModTime = info.Size, info.ModTime
+	if s.cache != nil && s.cache.fits(a.Size) {
+		go func() {
+			if err := s.cache.load(context.Background(), s.backend, a.Path); err != nil {
+				log.Printf("assets: caching %s: %v", a.Path, err)
+			}
+		}()
+	}
+	return f, a, nil
+}
+
+// Put stores size bytes from r as asset a, replacing any at a.Path, and
+// saves the manifest. The file is written before the manifest lists it,
+// so a failed upload never leaves an entry without a file.
+func (s *Store) Put(ctx context.Context, a Asset, r io.Reader, size int64) (Asset, error) {
+	if s == nil || s.backend == nil {
+		return Asset{}, ErrNoStorage
+	}
+	if err := check(a); err != nil {
+		return Asset{}, err
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	info, err := s.backend.Put(ctx, a.Path, r, size)
+	if err != nil {
+		return Asset{}, err
+	}
+	a.Size, a.ModTime = info.Size, info.ModTime
+
+	s.mu.Lock()
+	before, existed := s.byPath[a.Path]
+	order := s.order
+	s.byPath[a.Path] = a
+	if !existed {
+		s.order = append(slices.Clone(order), a.Path)
+	}
+	s.mu.Unlock()
+	if err := s.saveManifest(ctx); err != nil {
+		s.mu.Lock()
+		if existed {
+			s.byPath[a.Path] = before
+		} else {
+			delete(s.byPath, a.Path)
+		}
+		s.order = order
+		s.mu.Unlock()
+		return Asset{}, err
+	}
+	return a, nil
+}
+
+// Delete takes the asset at p out of the manifest, then removes its file
+func (s *Store) Delete(ctx context.Context, p string) error {
+	if s == nil || s.backend == nil {
+		return ErrNoStorage
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.mu.Lock()
+	a, ok := s.byPath[p]
+	if !ok {
+		s.mu.Unlock()
+		return fs.ErrNotExist
+	}
+	order := s.order
+	delete(s.byPath, p)
+	s.order = slices.DeleteFunc(slices.Clone(order), func(q string) bool { return q == p })
+	s.mu.Unlock()
+	if err := s.saveManifest(ctx); err != nil {
+		s.mu.Lock()
+		s.byPath[p], s.order = a, order
+		s.mu.Unlock()
+		return err
+	}
+	if err := s.backend.Delete(ctx, p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("assets: %s is out of the manifest but its file remains: %v", p, err)
+	}
+	return nil
+}
+
+// saveManifest writes the manifest as the store lists it now. Callers
+// hold s.writeMu.
+func (s *Store) saveManifest(ctx context.Context) error {
+	data, err := json.MarshalIndent(s.List(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = s.backend.Put(ctx, ManifestFile, bytes.NewReader(data), int64(len(data)))
+	return err
+}
+
+// Prefetch reads the assets marked prefetch into the cache, in manifest
+// order, until ctx is done. Assets beyond the budget evict earlier ones,
+// so list the most popular first.
+func (s *Store) Prefetch(ctx context.Context) {
+	if s == nil || s.cache == nil {
+		return
+	}
+	started := time.Now()
+	n := 0
+	for _, a := range s.List() {
+		if ctx.Err() != nil {
+			return
+		}
+		if !a.Prefetch || !s.cache.fits(a.Size) {
+			continue
+		}
+		if err := s.cache.load(ctx, s.backend, a.Path); err != nil {
+			log.Printf("assets: prefetching %s: %v", a.Path, err)
+			continue
+		}
+		n++
+	}
+	st := s.cache.Stats()
+	log.Printf("Asset cache warmed: %d files, %d of %d MB (%s)", n, st.Bytes>>20, st.BudgetBytes>>20, time.Since(started).Round(time.Millisecond))
+}
+
+// CacheStats reports on the content cache; ok is false when it is disabled
+func (s *Store) CacheStats() (stats CacheStats, ok bool) {
+	if s == nil || s.cache == nil {
+		return CacheStats{}, false
+	}
+	return s.cache.Stats(), true
+}
+
+// ContentType returns the media type for an asset file name
+func ContentType(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".glb":
+		return "model/gltf-binary"
+	case ".gltf":
+		return "model/gltf+json"
+	case ".ktx2":
+		return "image/ktx2"
+	case ".webp":
+		return "image/webp"
+	case ".avif":
+		return "image/avif"
+	case ".mp3":
+		return "audio/mpeg"
+	case ".ogg", ".opus":
+		return "audio/ogg"
+	case ".m4a":
+		return "audio/mp4"
+	case ".wav":
+		return "audio/wav"
+	}
+	return "" // let http.ServeContent sniff it
+}
@@ -0,0 +1,193 @@
+package assets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"solar-system-explorer/backend/s3"
+)
+
+// Backend holds the asset files and the manifest, under slash-separated
+// paths. Missing files are reported as fs.ErrNotExist.
+type Backend interface {
+	Stat(ctx context.Context, p string) (FileInfo, error)
+	// Open opens the file at p; reads and seeks after ctx is done fail
+	Open(ctx context.Context, p string) (File, FileInfo, error)
+	// Put stores size bytes from r at p, replacing any file there
+	Put(ctx context.Context, p string, r io.Reader, size int64) (FileInfo, error)
+	Delete(ctx context.Context, p string) error
+	// String names where the files are, for the log
+	String() string
+}
+
+// FileInfo is what a Backend knows about a file
+type FileInfo struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// Disk keeps assets in a local directory
+type Disk string
+
+func (d Disk) path(p string) string { return filepath.Join(string(d), filepath.FromSlash(p)) }
+
+func (d Disk) Stat(_ context.Context, p string) (FileInfo, error) {
+	info, err := os.Stat(d.path(p))
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return FileInfo{Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+func (d Disk) Open(_ context.Context, p string) (File, FileInfo, error) {
+	f, err := os.Open(d.path(p))
+	if err != nil {
+		return nil, FileInfo{}, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, FileInfo{}, err
+	}
+	return f, FileInfo{Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// Put writes to a temporary file beside p and renames it over p, so
+// readers never see a partial file
+func (d Disk) Put(ctx context.Context, p string, r io.Reader, size int64) (FileInfo, error) {
+	dst := d.path(p)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return FileInfo{}, err
+	}
+	f, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*")
+	if err != nil {
+		return FileInfo{}, err
+	}
+	n, err := io.Copy(f, r)
+	if err == nil && n != size {
+		err = fmt.Errorf("got %d of %d bytes", n, size)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), dst)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return FileInfo{}, err
+	}
+	return d.Stat(ctx, p)
+}
+
+func (d Disk) Delete(_ context.Context, p string) error {
+	return os.Remove(d.path(p))
+}
+
+func (d Disk) String() string { return string(d) }
+
+// Bucket keeps assets in an S3 bucket under a key prefix. Files are
+// streamed from the bucket with range requests, so only the parts a
+// client asks for are fetched.
+type Bucket struct {
+	Client *s3.Client
+	Prefix string
+}
+
+func (b Bucket) Stat(ctx context.Context, p string) (FileInfo, error) {
+	o, err := b.Client.Head(ctx, b.Prefix+p)
+	if errors.Is(err, s3.ErrNotFound) {
+		return FileInfo{}, fs.ErrNotExist
+	}
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return FileInfo{Size: o.Size, ModTime: o.LastModified}, nil
+}
+
+func (b Bucket) Open(ctx context.Context, p string) (File, FileInfo, error) {
+	info, err := b.Stat(ctx, p)
+	if err != nil {
+		return nil, FileInfo{}, err
+	}
+	return &objectFile{ctx: ctx, client: b.Client, key: b.Prefix + p, size: info.Size}, info, nil
+}
+
+func (b Bucket) Put(ctx context.Context, p string, r io.Reader, size int64) (FileInfo, error) {
+	if err := b.Client.Put(ctx, b.Prefix+p, r, size, ContentType(p)); err != nil {
+		return FileInfo{}, err
+	}
+	return b.Stat(ctx, p)
+}
+
+func (b Bucket) Delete(ctx context.Context, p string) error {
+	return b.Client.Delete(ctx, b.Prefix+p)
+}
+
+func (b Bucket) String() string { return "s3://" + b.Client.Bucket() + "/" + b.Prefix }
+
+// objectFile reads an object from the current offset to its end with one
+// range request, reissued after a seek
+type objectFile struct {
+	ctx       context.Context
+	client    *s3.Client
+	key       string
+	size, off int64
+	body      io.ReadCloser // nil until the first read after a seek
+}
+
+func (f *objectFile) Read(p []byte) (int, error) {
+	if f.off >= f.size {
+		return 0, io.EOF
+	}
+	if f.body == nil {
+		resp, err := f.client.Get(f.ctx, f.key, fmt.Sprintf("bytes=%d-", f.off))
+		if err != nil {
+			return 0, err
+		}
+		f.body = resp.Body
+	}
+	n, err := f.body.Read(p)
+	f.off += int64(n)
+	if err == io.EOF && f.off < f.size {
+		err = io.ErrUnexpectedEOF // replaced while reading
+	}
+	return n, err
+}
+
+func (f *objectFile) Seek(offset int64, whence int) (int64, error) {
+	pos := offset
+	switch whence {
+	case io.SeekCurrent:
+		pos += f.off
+	case io.SeekEnd:
+		pos += f.size
+	}
+	if pos < 0 {
+		return f.off, errors.New("assets: seek before start")
+	}
+	if pos != f.off && f.body != nil {
+		f.body.Close()
+		f.body = nil
+	}
+	f.off = pos
+	return pos, nil
+}
+
+func (f *objectFile) Close() error {
+	if f.body == nil {
+		return nil
+	}
+	err := f.body.Close()
+	f.body = nil
+	return err
+}
@@ -0,0 +1,151 @@
+package assets
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"sync"
+	"time"
+)
+
+// Cache keeps asset contents in memory, keyed by their SHA-256, so files
+// read often stay off the (possibly network-mounted) disk. Identical
+// files listed under several paths share one entry. The least recently
+// used entries are evicted once the contents exceed the budget.
+type Cache struct {
+	mu      sync.Mutex
+	budget  int64
+	used    int64
+	lru     *list.List               // of *cacheEntry, most recent first
+	byHash  map[string]*list.Element // content hash → entry
+	byPath  map[string]fileVersion   // asset path → the version cached
+	loading map[string]bool          // paths being read in the background
+	hits    int64
+	misses  int64
+}
+
+type cacheEntry struct {
+	hash string
+	data []byte
+}
+
+// fileVersion ties a path to the contents it had when cached; a different
+// size or modification time means the file was replaced
+type fileVersion struct {
+	hash    string
+	size    int64
+	modTime time.Time
+}
+
+// CacheStats describe the cache for the admin API
+type CacheStats struct {
+	Entries     int   `json:"entries"`
+	Bytes       int64 `json:"bytes"`
+	BudgetBytes int64 `json:"budget_bytes"`
+	Hits        int64 `json:"hits"`
+	Misses      int64 `json:"misses"`
+}
+
+// NewCache returns a cache holding up to budget bytes of contents
+func NewCache(budget int64) *Cache {
+	return &Cache{
+		budget:  budget,
+		lru:     list.New(),
+		byHash:  make(map[string]*list.Element),
+		byPath:  make(map[string]fileVersion),
+		loading: make(map[string]bool),
+	}
+}
+
+// get returns the cached contents of path if they are of the given
+// version, and counts the lookup
+func (c *Cache) get(path string, size int64, modTime time.Time) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.byPath[path]
+	if ok && v.size == size && v.modTime.Equal(modTime) {
+		if el, ok := c.byHash[v.hash]; ok {
+			c.lru.MoveToFront(el)
+			c.hits++
+			return el.Value.(*cacheEntry).data, true
+		}
+	}
+	c.misses++
+	return nil, false
+}
+
+// fits reports whether a file of size bytes can be cached at all
+func (c *Cache) fits(size int64) bool { return size > 0 && size <= c.budget }
+
+// load reads path from b into the cache unless the same load is already
+// running. The version is checked again after reading so a file replaced
+// mid-read is not cached under the old version.
+func (c *Cache) load(ctx context.Context, b Backend, path string) error {
+	c.mu.Lock()
+	if c.loading[path] {
+		c.mu.Unlock()
+		return nil
+	}
+	c.loading[path] = true
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.loading, path)
+		c.mu.Unlock()
+	}()
+
+	f, before, err := b.Open(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if !c.fits(before.Size) {
+		return nil
+	}
+	var buf bytes.Buffer
+	buf.Grow(int(before.Size))
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(&buf, h), f); err != nil {
+		return err
+	}
+	after, err := b.Stat(ctx, path)
+	if err != nil || after.Size != before.Size || !after.ModTime.Equal(before.ModTime) || int64(buf.Len()) != before.Size {
+		return err // changed while reading; the next request tries again
+	}
+	c.put(path, fileVersion{hash: hex.EncodeToString(h.Sum(nil)), size: before.Size, modTime: before.ModTime}, buf.Bytes())
+	return nil
+}
+
+func (c *Cache) put(path string, v fileVersion, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.byPath[path] = v
+	if el, ok := c.byHash[v.hash]; ok {
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.byHash[v.hash] = c.lru.PushFront(&cacheEntry{hash: v.hash, data: data})
+	c.used += int64(len(data))
+	for c.used > c.budget {
+		el := c.lru.Back()
+		e := el.Value.(*cacheEntry)
+		c.lru.Remove(el)
+		delete(c.byHash, e.hash)
+		c.used -= int64(len(e.data))
+	}
+}
+
+// Stats returns the current size and hit counts
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Entries: c.lru.Len(), Bytes: c.used, BudgetBytes: c.budget, Hits: c.hits, Misses: c.misses}
+}
+
+// memFile serves cached contents like an open file
+type memFile struct{ *bytes.Reader }
+
+func (memFile) Close() error { return nil }
@@ -0,0 +1,104 @@
+package assets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// cacheDisk writes files (path → contents) to a temporary directory
+func cacheDisk(t *testing.T, files map[string]string) Disk {
+	t.Helper()
+	dir := t.TempDir()
+	for p, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, p), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return Disk(dir)
+}
+
+// cached loads path and reports whether its current version is served
+// from the cache
+func cached(t *testing.T, c *Cache, d Disk, path string) bool {
+	t.Helper()
+	ctx := context.Background()
+	if err := c.load(ctx, d, path); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := d.Stat(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ok := c.get(path, fi.Size, fi.ModTime)
+	return ok
+}
+
+func TestCacheSharesIdenticalFiles(t *testing.T) {
+	d := cacheDisk(t, map[string]string{"a.png": "0123456789", "b.png": "0123456789"})
+	c := NewCache(15)
+	if !cached(t, c, d, "a.png") || !cached(t, c, d, "b.png") {
+		t.Fatal("files under budget weren't cached")
+	}
+	if s := c.Stats(); s.Entries != 1 || s.Bytes != 10 {
+		t.Errorf("stats = %+v, want one shared entry of 10 bytes", s)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	d := cacheDisk(t, map[string]string{"a.png": "aaaaaaaaaa", "b.png": "bbbbbbbbbb", "c.png": "cccccccccc", "big.png": "0123456789abcdef"})
+	c := NewCache(25)
+	cached(t, c, d, "a.png")
+	cached(t, c, d, "b.png")
+	cached(t, c, d, "a.png") // a is now more recent than b
+	cached(t, c, d, "c.png")
+
+	ctx := context.Background()
+	fi, _ := d.Stat(ctx, "b.png")
+	if _, ok := c.get("b.png", fi.Size, fi.ModTime); ok {
+		t.Error("b.png, the least recently used, is still cached")
+	}
+	fi, _ = d.Stat(ctx, "a.png")
+	if _, ok := c.get("a.png", fi.Size, fi.ModTime); !ok {
+		t.Error("a.png was evicted")
+	}
+	if !cached(t, c, d, "big.png") {
+		t.Error("big.png fits the budget but wasn't cached")
+	}
+	if s := c.Stats(); s.Bytes > 25 || s.Entries != 1 {
+		t.Errorf("stats = %+v, want big.png alone within the budget of 25", s)
+	}
+	if c.fits(26) || c.fits(0) {
+		t.Error("files larger than the budget, or empty, would be cached")
+	}
+}
+
+func TestCacheMissesReplacedFile(t *testing.T) {
+	d := cacheDisk(t, map[string]string{"a.png": "old contents"})
+	c := NewCache(1 << 10)
+	if !cached(t, c, d, "a.png") {
+		t.Fatal("not cached")
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.WriteFile(filepath.Join(string(d), "a.png"), []byte("new contents"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(string(d), "a.png"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := d.Stat(context.Background(), "a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.get("a.png", fi.Size, fi.ModTime); ok {
+		t.Error("the replaced file's old contents were served")
+	}
+	if !cached(t, c, d, "a.png") {
+		t.Error("the new version wasn't cached")
+	}
+	if s := c.Stats(); s.Hits == 0 || s.Misses == 0 {
+		t.Errorf("stats = %+v", s)
+	}
+}
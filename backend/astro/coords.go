@@ -0,0 +1,88 @@
+package astro
+
+import "math"
+
+// Ecliptic coordinates, degrees
+type Ecliptic struct {
+	Lon float64 `json:"lon"`
+	Lat float64 `json:"lat"`
+}
+
+// Equatorial coordinates, degrees; RAHours is the right ascension in hours
+// as catalogues give it
+type Equatorial struct {
+	RA      float64 `json:"ra"`
+	RAHours float64 `json:"ra_hours"`
+	Dec     float64 `json:"dec"`
+}
+
+// Horizontal coordinates, degrees: altitude above the horizon and azimuth
+// from north through east
+type Horizontal struct {
+	Alt float64 `json:"alt"`
+	Az  float64 `json:"az"`
+}
+
+// Observer is a place on Earth, degrees, longitude positive east
+type Observer struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// FromJ2000 carries coordinates on the J2000 ecliptic to the mean
+// ecliptic and equinox of jd by the general precession in longitude
+// (Meeus 21.6), leaving the latitude, which the ecliptic's own slow tilt
+// changes by under an arcsecond a century
+func (e Ecliptic) FromJ2000(jd float64) Ecliptic {
+	t := JulianCenturies(jd)
+	p := (5029.0966*t + 1.11113*t*t - 0.000006*t*t*t) / 3600
+	return Ecliptic{Lon: normDeg(e.Lon + p), Lat: e.Lat}
+}
+
+// LocalSiderealTime returns the mean sidereal time at the observer's
+// longitude, degrees
+func LocalSiderealTime(jd float64, o Observer) float64 {
+	return normDeg(GreenwichSiderealTime(jd) + o.Lon)
+}
+
+// ToEquatorial rotates ecliptic coordinates to equatorial ones about the
+// equinox, for an ecliptic of obliquity eps (radians; see MeanObliquity)
+func (e Ecliptic) ToEquatorial(eps float64) Equatorial {
+	lon, lat := e.Lon*deg, e.Lat*deg
+	ra := math.Atan2(math.Sin(lon)*math.Cos(eps)-math.Tan(lat)*math.Sin(eps), math.Cos(lon))
+	dec := math.Asin(math.Sin(lat)*math.Cos(eps) + math.Cos(lat)*math.Sin(eps)*math.Sin(lon))
+	return NewEquatorial(ra*rad, dec*rad)
+}
+
+// ToEcliptic is the inverse of Ecliptic.ToEquatorial (Meeus eq. 13.1)
+func (q Equatorial) ToEcliptic(eps float64) Ecliptic {
+	ra, dec := q.RA*deg, q.Dec*deg
+	lon := math.Atan2(math.Sin(ra)*math.Cos(eps)+math.Tan(dec)*math.Sin(eps), math.Cos(ra))
+	lat := math.Asin(math.Sin(dec)*math.Cos(eps) - math.Cos(dec)*math.Sin(eps)*math.Sin(ra))
+	return Ecliptic{Lon: normDeg(lon * rad), Lat: lat * rad}
+}
+
+// ToHorizontal places equatorial coordinates in the observer's sky at the
+// UTC Julian Day jd (Meeus eq. 13.5–13.6, azimuth from north)
+func (q Equatorial) ToHorizontal(jd float64, o Observer) Horizontal {
+	h := (LocalSiderealTime(jd, o) - q.RA) * deg
+	dec, lat := q.Dec*deg, o.Lat*deg
+	az := math.Atan2(math.Sin(h), math.Cos(h)*math.Sin(lat)-math.Tan(dec)*math.Cos(lat))
+	alt := math.Asin(math.Sin(lat)*math.Sin(dec) + math.Cos(lat)*math.Cos(dec)*math.Cos(h))
+	return Horizontal{Alt: alt * rad, Az: normDeg(az*rad + 180)}
+}
+
+// ToEquatorial is the inverse of Equatorial.ToHorizontal
+func (hz Horizontal) ToEquatorial(jd float64, o Observer) Equatorial {
+	alt, az, lat := hz.Alt*deg, (hz.Az-180)*deg, o.Lat*deg
+	h := math.Atan2(math.Sin(az), math.Cos(az)*math.Sin(lat)+math.Tan(alt)*math.Cos(lat))
+	dec := math.Asin(math.Sin(lat)*math.Sin(alt) - math.Cos(lat)*math.Cos(alt)*math.Cos(az))
+	return NewEquatorial(LocalSiderealTime(jd, o)-h*rad, dec*rad)
+}
+
+// NewEquatorial returns the equatorial coordinates of right ascension ra
+// and declination dec, degrees, with ra reduced to [0, 360)
+func NewEquatorial(ra, dec float64) Equatorial {
+	ra = normDeg(ra)
+	return Equatorial{RA: ra, RAHours: ra / 15, Dec: dec}
+}
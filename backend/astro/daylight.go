@@ -0,0 +1,34 @@
+package astro
+
+import "math"
+
+// SolarDay returns the length of a solar day (noon to noon) in Earth days
+// from the sidereal rotation and orbital periods, both in Earth days.
+// Retrograde rotators have a negative rotation period.
+func SolarDay(rotation, orbital float64) float64 {
+	if orbital == 0 || rotation == 0 {
+		return math.Abs(rotation)
+	}
+	return math.Abs(1 / (1/rotation - 1/orbital))
+}
+
+// DaylightFraction returns the fraction of a solar day the Sun's centre
+// spends above the horizon at latitude lat for a solar declination decl
+// (both degrees), ignoring refraction and the size of the disk. It is 1
+// under the midnight sun and 0 in polar night.
+func DaylightFraction(lat, decl float64) float64 {
+	x := -math.Tan(lat*deg) * math.Tan(decl*deg)
+	switch {
+	case x <= -1:
+		return 1
+	case x >= 1:
+		return 0
+	}
+	return math.Acos(x) / math.Pi
+}
+
+// MarsSolarDeclination returns the declination of the Sun seen from Mars
+// in degrees, from the areocentric solar longitude Ls (Mars24)
+func MarsSolarDeclination(ls float64) float64 {
+	return math.Asin(0.42565*math.Sin(ls*deg))*rad + 0.25*math.Sin(ls*deg)
+}
@@ -0,0 +1,46 @@
+package astro
+
+import "math"
+
+// GeoPoint is a geographic coordinate in degrees
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// SubsolarPoint returns the place on Earth where the Sun is at the zenith
+func SubsolarPoint(jd float64) GeoPoint {
+	sun := Sun(jd)
+	lon := sun.RightAscension - GreenwichSiderealTime(jd)
+	return GeoPoint{Lat: sun.Declination, Lon: wrapLon(lon)}
+}
+
+// Terminator returns the day/night boundary as a polyline sampled every
+// step degrees of longitude from −180° to 180°
+func Terminator(jd float64, step float64) []GeoPoint {
+	sub := SubsolarPoint(jd)
+	dec := sub.Lat
+	// At the equinoxes tan(δ) → 0 and the terminator becomes a meridian
+	// pair; nudge δ so the formula stays finite.
+	if math.Abs(dec) < 1e-6 {
+		dec = 1e-6
+	}
+	tanDec := math.Tan(dec * deg)
+
+	points := make([]GeoPoint, 0, int(360/step)+1)
+	for lon := -180.0; lon <= 180.0+1e-9; lon += step {
+		ha := (lon - sub.Lon) * deg
+		lat := math.Atan(-math.Cos(ha)/tanDec) * rad
+		points = append(points, GeoPoint{Lat: lat, Lon: lon})
+	}
+	return points
+}
+
+// wrapLon maps a longitude to (−180, 180]
+func wrapLon(lon float64) float64 {
+	lon = normDeg(lon)
+	if lon > 180 {
+		lon -= 360
+	}
+	return lon
+}
@@ -0,0 +1,25 @@
+package astro
+
+import "math"
+
+// JupiterCentralMeridian returns the longitudes of the central meridian of
+// Jupiter's disc seen from the Earth at the Julian Day jd, in System I
+// (equatorial clouds) and System II (the rest, including the Great Red
+// Spot), degrees. Meeus ch. 43, low accuracy (about 0.1°), light-time
+// included.
+func JupiterCentralMeridian(jd float64) (system1, system2 float64) {
+	d := jd - J2000
+	v := (172.74 + 0.00111588*d) * deg
+	m := (357.529 + 0.9856003*d) * deg
+	n := (20.020 + 0.0830853*d + 0.329*math.Sin(v)) * deg
+	j := 66.115 + 0.9025179*d - 0.329*math.Sin(v)
+	a := 1.915*math.Sin(m) + 0.020*math.Sin(2*m)
+	b := 5.555*math.Sin(n) + 0.168*math.Sin(2*n)
+	k := (j + a - b) * deg
+	earth := 1.00014 - 0.01671*math.Cos(m) - 0.00014*math.Cos(2*m)
+	r := 5.20872 - 0.25208*math.Cos(n) - 0.00611*math.Cos(2*n)
+	delta := math.Sqrt(r*r + earth*earth - 2*r*earth*math.Cos(k))
+	psi := math.Asin(earth/delta*math.Sin(k)) * rad
+	dt := d - delta/173
+	return normDeg(210.98 + 877.8169088*dt + psi - b), normDeg(187.23 + 870.1869088*dt + psi - b)
+}
@@ -0,0 +1,63 @@
+package astro
+
+import "math"
+
+// auKm is the astronomical unit in km
+const auKm = 149597870.7
+
+// planetMagnitudes give each planet's visual magnitude at 1 AU from both
+// the Sun and the Earth, by phase angle i in degrees (the Astronomical
+// Almanac's formulas, Meeus ch. 41). Saturn's is the globe's alone; its
+// rings can add almost a magnitude, see SaturnMagnitude.
+var planetMagnitudes = map[string]func(i float64) float64{
+	"Mercury": func(i float64) float64 { return -0.42 + 0.0380*i - 0.000273*i*i + 0.000002*i*i*i },
+	"Venus":   func(i float64) float64 { return -4.40 + 0.0009*i + 0.000239*i*i - 0.00000065*i*i*i },
+	"Mars":    func(i float64) float64 { return -1.52 + 0.016*i },
+	"Jupiter": func(i float64) float64 { return -9.40 + 0.005*i },
+	"Saturn":  func(i float64) float64 { return -8.88 + 0.044*i },
+	"Uranus":  func(i float64) float64 { return -7.19 },
+	"Neptune": func(i float64) float64 { return -6.87 },
+}
+
+// sunMagnitude is the Sun's visual magnitude at 1 AU
+const sunMagnitude = -26.74
+
+// Magnitude returns the visual magnitude of the named body r AU from the
+// Sun and delta AU from the observer at phase angle i (degrees). It
+// reports false for bodies without a magnitude model.
+func Magnitude(name string, r, delta, i float64) (float64, bool) {
+	if name == "Sun" {
+		return sunMagnitude + 5*math.Log10(delta), true
+	}
+	m, ok := planetMagnitudes[name]
+	if !ok || r <= 0 || delta <= 0 {
+		return 0, false
+	}
+	return m(i) + 5*math.Log10(r*delta), true
+}
+
+// PhaseAngle returns the Sun–body–observer angle in degrees for a body r
+// AU from the Sun and delta AU from an observer who is sunDist AU from the
+// Sun: 0 when the body is seen full, 180 when it is seen new
+func PhaseAngle(r, delta, sunDist float64) float64 {
+	if r <= 0 || delta <= 0 {
+		return 0
+	}
+	cos := (r*r + delta*delta - sunDist*sunDist) / (2 * r * delta)
+	return math.Acos(max(-1, min(1, cos))) * rad
+}
+
+// Illuminated returns the fraction of a body's disc lit by the Sun at
+// phase angle i (degrees), 0 to 1
+func Illuminated(i float64) float64 {
+	return (1 + math.Cos(i*deg)) / 2
+}
+
+// AngularDiameter returns the apparent diameter, in arcseconds, of a body
+// of the given radius (km) seen from delta AU
+func AngularDiameter(radius, delta float64) float64 {
+	if delta <= 0 {
+		return 0
+	}
+	return 2 * math.Atan(radius/(delta*auKm)) * rad * 3600
+}
@@ -0,0 +1,111 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// MoonPhase describes the Moon's illumination as seen from Earth
+type MoonPhase struct {
+	Phase        string  `json:"phase"`
+	PhaseSR      string  `json:"phase_sr"`
+	Illumination float64 `json:"illumination"` // 0–1, illuminated fraction of the disk
+	PhaseAngle   float64 `json:"phase_angle"`  // degrees, Sun–Moon–Earth angle
+	Elongation   float64 `json:"elongation"`   // degrees, 0 = new, 180 = full
+	Waxing       bool    `json:"waxing"`
+	AgeDays      float64 `json:"age_days"` // approximate days since new moon
+}
+
+// SynodicMonth is the mean length of a lunation in days
+const SynodicMonth = 29.530588853
+
+var moonPhaseNames = []struct{ en, sr string }{
+	{"new_moon", "Mlad mesec"},
+	{"waxing_crescent", "Rastući srp"},
+	{"first_quarter", "Prva četvrt"},
+	{"waxing_gibbous", "Rastući ispupčeni mesec"},
+	{"full_moon", "Pun mesec"},
+	{"waning_gibbous", "Opadajući ispupčeni mesec"},
+	{"last_quarter", "Poslednja četvrt"},
+	{"waning_crescent", "Opadajući srp"},
+}
+
+// Moon computes the lunar phase (Meeus ch. 48, low-accuracy phase angle)
+func Moon(jd float64) MoonPhase {
+	t := JulianCenturies(jd)
+	d := normDeg(297.8501921 + 445267.1114034*t - 0.0018819*t*t)
+	m := normDeg(357.5291092 + 35999.0502909*t - 0.0001536*t*t)
+	mp := normDeg(134.9633964 + 477198.8675055*t + 0.0087414*t*t)
+
+	i := 180 - d -
+		6.289*math.Sin(mp*deg) +
+		2.100*math.Sin(m*deg) -
+		1.274*math.Sin((2*d-mp)*deg) -
+		0.658*math.Sin(2*d*deg) -
+		0.214*math.Sin(2*mp*deg) -
+		0.110*math.Sin(d*deg)
+	i = normDeg(i)
+
+	// The phase angle i runs 180→0→180 over a lunation; elongation is its
+	// supplement unwrapped over the full cycle
+	elong := normDeg(180 - i)
+	illum := (1 + math.Cos(i*deg)) / 2
+	if i > 180 {
+		i = 360 - i
+	}
+
+	idx := int(math.Floor((elong+22.5)/45)) % 8
+	name := moonPhaseNames[idx]
+
+	return MoonPhase{
+		Phase:        name.en,
+		PhaseSR:      name.sr,
+		Illumination: illum,
+		PhaseAngle:   i,
+		Elongation:   elong,
+		Waxing:       elong < 180,
+		AgeDays:      elong / 360 * SynodicMonth,
+	}
+}
+
+// PhaseEvent is the instant the Moon reaches a principal phase
+type PhaseEvent struct {
+	Phase   string    `json:"phase"`
+	PhaseSR string    `json:"phase_sr"`
+	Time    time.Time `json:"time"`
+}
+
+// MoonPhases finds new moons, quarters and full moons between from and to
+// by sampling the elongation every 6 hours and bisecting each crossing of
+// 0°, 90°, 180° and 270°. With the low-accuracy phase model times are
+// good to about half an hour, plenty for notices and calendars.
+func MoonPhases(from, to time.Time) []PhaseEvent {
+	const step = 0.25 // days
+	var events []PhaseEvent
+	jd, end := JulianDay(from), JulianDay(to)
+	prev := Moon(jd).Elongation
+	for ; jd < end; jd += step {
+		cur := Moon(jd + step).Elongation
+		for q := 0; q < 4; q++ {
+			target := float64(q) * 90
+			if !crosses(prev, cur, target) {
+				continue
+			}
+			lo, hi := jd, jd+step
+			for i := 0; i < 30; i++ {
+				mid := (lo + hi) / 2
+				if crosses(Moon(lo).Elongation, Moon(mid).Elongation, target) {
+					hi = mid
+				} else {
+					lo = mid
+				}
+			}
+			if t := TimeFromJulianDay((lo + hi) / 2); !t.Before(from) && t.Before(to) {
+				name := moonPhaseNames[q*2]
+				events = append(events, PhaseEvent{Phase: name.en, PhaseSR: name.sr, Time: t})
+			}
+		}
+		prev = cur
+	}
+	return events
+}
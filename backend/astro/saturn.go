@@ -0,0 +1,59 @@
+package astro
+
+import "math"
+
+// SaturnRings is the aspect of Saturn's rings (Meeus ch. 45)
+type SaturnRings struct {
+	// EarthTilt is the Saturnicentric latitude of the Earth referred to
+	// the ring plane, degrees: how far the rings are opened towards us,
+	// positive when their north face is seen. Near 0 they are edge-on and
+	// all but vanish.
+	EarthTilt float64 `json:"earth_tilt"`
+	// SunTilt is the same for the Sun: the lit face, and how steeply
+	SunTilt float64 `json:"sun_tilt"`
+	// DeltaU is the difference between the Saturnicentric longitudes of
+	// the Sun and the Earth in the ring plane, degrees
+	DeltaU float64 `json:"delta_u"`
+	// MajorAxis and MinorAxis are those of the outer edge of ring A, in
+	// arcseconds
+	MajorAxis float64 `json:"major_axis"`
+	MinorAxis float64 `json:"minor_axis"`
+}
+
+// SaturnRingAspect returns the rings' aspect at the Julian Day jd for
+// Saturn at helio on the ecliptic of date, r AU from the Sun, and at geo
+// on it from the Earth, delta AU away; both as the light left Saturn.
+func SaturnRingAspect(jd float64, helio Ecliptic, r float64, geo Ecliptic, delta float64) SaturnRings {
+	t := (jd - J2000) / 36525
+	incl := (28.075216 - 0.012998*t + 0.000004*t*t) * deg
+	node := (169.508470 + 1.394681*t + 0.000412*t*t) * deg
+
+	lam, beta := geo.Lon*deg, geo.Lat*deg
+	earth := math.Asin(math.Sin(incl)*math.Cos(beta)*math.Sin(lam-node) - math.Cos(incl)*math.Sin(beta))
+
+	// The Sun as seen from Saturn, its own aberration removed
+	l := (helio.Lon - 0.01759/r) * deg
+	b := (helio.Lat - 0.000764*math.Cos(helio.Lon*deg-node)/r) * deg
+	sun := math.Asin(math.Sin(incl)*math.Cos(b)*math.Sin(l-node) - math.Cos(incl)*math.Sin(b))
+
+	u1 := math.Atan2(math.Sin(incl)*math.Sin(b)+math.Cos(incl)*math.Cos(b)*math.Sin(l-node), math.Cos(b)*math.Cos(l-node))
+	u2 := math.Atan2(math.Sin(incl)*math.Sin(beta)+math.Cos(incl)*math.Cos(beta)*math.Sin(lam-node), math.Cos(beta)*math.Cos(lam-node))
+	du := math.Abs(normDeg((u1-u2)*rad+180) - 180)
+
+	major := 375.35 / delta
+	return SaturnRings{
+		EarthTilt: earth * rad,
+		SunTilt:   sun * rad,
+		DeltaU:    du,
+		MajorAxis: major,
+		MinorAxis: major * math.Abs(math.Sin(earth)),
+	}
+}
+
+// SaturnMagnitude returns Saturn's visual magnitude, rings included, r AU
+// from the Sun and delta AU from the Earth (Meeus ch. 41). Wide open rings
+// brighten it by up to about 0.9 magnitude over the globe's alone.
+func SaturnMagnitude(r, delta float64, rings SaturnRings) float64 {
+	sinB := math.Sin(rings.EarthTilt * deg)
+	return -8.88 + 5*math.Log10(r*delta) + 0.044*rings.DeltaU - 2.60*math.Abs(sinB) + 1.25*sinB*sinB
+}
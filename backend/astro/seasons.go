@@ -0,0 +1,152 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// SeasonEvent is an equinox or solstice instant
+type SeasonEvent struct {
+	Type           string    `json:"type"`
+	NameSR         string    `json:"name_sr"`
+	Time           time.Time `json:"time"`
+	SolarLongitude float64   `json:"solar_longitude"` // degrees (Ls for Mars)
+}
+
+// earthSeasonMeta lists Earth's four events in calendar order
+var earthSeasonMeta = []struct {
+	kind   string
+	nameSR string
+	ls     float64
+	coef   [5]float64
+}{
+	{"march_equinox", "Martovska ravnodnevica", 0, [5]float64{2451623.80984, 365242.37404, 0.05169, -0.00411, -0.00057}},
+	{"june_solstice", "Junska dugodnevica", 90, [5]float64{2451716.56767, 365241.62603, 0.00325, 0.00888, -0.00030}},
+	{"september_equinox", "Septembarska ravnodnevica", 180, [5]float64{2451810.21715, 365242.01767, -0.11575, 0.00337, 0.00078}},
+	{"december_solstice", "Decembarska kratkodnevica", 270, [5]float64{2451900.05952, 365242.74049, -0.06223, -0.00823, 0.00032}},
+}
+
+// Periodic terms of Meeus table 27.C (A, B, C)
+var seasonTerms = [][3]float64{
+	{485, 324.96, 1934.136}, {203, 337.23, 32964.467}, {199, 342.08, 20.186},
+	{182, 27.85, 445267.112}, {156, 73.14, 45036.886}, {136, 171.52, 22518.443},
+	{77, 222.54, 65928.934}, {74, 296.72, 3034.906}, {70, 243.58, 9037.513},
+	{58, 119.81, 33718.147}, {52, 297.17, 150.678}, {50, 21.02, 2281.226},
+	{45, 247.54, 29929.562}, {44, 325.15, 31555.956}, {29, 60.93, 4443.417},
+	{18, 155.12, 67555.328}, {17, 288.79, 4562.452}, {16, 198.04, 62894.029},
+	{14, 199.76, 31436.921}, {12, 95.39, 14577.848}, {12, 287.11, 31931.756},
+	{12, 320.81, 34777.259}, {9, 227.73, 1222.114}, {8, 15.45, 16859.074},
+}
+
+// EarthSeasons returns the equinoxes and solstices of the given year using
+// Meeus ch. 27 (valid 1000–3000, accurate to about a minute)
+func EarthSeasons(year int) []SeasonEvent {
+	y := float64(year-2000) / 1000
+	events := make([]SeasonEvent, 0, 4)
+	for _, m := range earthSeasonMeta {
+		c := m.coef
+		jde0 := c[0] + c[1]*y + c[2]*y*y + c[3]*y*y*y + c[4]*y*y*y*y
+		t := JulianCenturies(jde0)
+		w := (35999.373*t - 2.47) * deg
+		dl := 1 + 0.0334*math.Cos(w) + 0.0007*math.Cos(2*w)
+		s := 0.0
+		for _, term := range seasonTerms {
+			s += term[0] * math.Cos((term[1]+term[2]*t)*deg)
+		}
+		jde := jde0 + 0.00001*s/dl
+		events = append(events, SeasonEvent{
+			Type:           m.kind,
+			NameSR:         m.nameSR,
+			Time:           TimeFromJulianDay(jde),
+			SolarLongitude: m.ls,
+		})
+	}
+	return events
+}
+
+// MarsSolarLongitude returns the areocentric solar longitude Ls in degrees
+// (Allison & McEwen 2000, the Mars24 algorithm)
+func MarsSolarLongitude(jd float64) float64 {
+	dt := jd - J2000
+	m := (19.3871 + 0.52402073*dt) * deg
+	alphaFMS := 270.3871 + 0.524038496*dt
+
+	pbs := 0.0
+	for _, p := range marsPerturbations {
+		pbs += p[0] * math.Cos((0.985626*dt/p[1]+p[2])*deg)
+	}
+	eoc := (10.691+3.0e-7*dt)*math.Sin(m) + 0.623*math.Sin(2*m) +
+		0.050*math.Sin(3*m) + 0.005*math.Sin(4*m) + 0.0005*math.Sin(5*m) + pbs
+	return normDeg(alphaFMS + eoc)
+}
+
+// Planetary perturbation terms (A, τ, φ) for MarsSolarLongitude
+var marsPerturbations = [][3]float64{
+	{0.0071, 2.2353, 49.409}, {0.0057, 2.7543, 168.173}, {0.0039, 1.1177, 191.837},
+	{0.0037, 15.7866, 21.736}, {0.0021, 2.1354, 15.704}, {0.0020, 2.4694, 95.528},
+	{0.0018, 32.8493, 49.095},
+}
+
+var marsSeasonMeta = []struct {
+	kind   string
+	nameSR string
+	ls     float64
+}{
+	{"northern_spring_equinox", "Prolećna ravnodnevica (severna hemisfera)", 0},
+	{"northern_summer_solstice", "Letnja dugodnevica (severna hemisfera)", 90},
+	{"northern_autumn_equinox", "Jesenja ravnodnevica (severna hemisfera)", 180},
+	{"northern_winter_solstice", "Zimska kratkodnevica (severna hemisfera)", 270},
+}
+
+// MarsSeasons returns the Martian equinoxes and solstices that fall within
+// the given Earth calendar year. A Martian year lasts ~687 days, so a single
+// Earth year contains between two and three of them.
+func MarsSeasons(year int) []SeasonEvent {
+	start := JulianDay(time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC))
+	end := JulianDay(time.Date(year+1, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	var events []SeasonEvent
+	prev := MarsSolarLongitude(start)
+	for jd := start + 1; jd <= end; jd++ {
+		cur := MarsSolarLongitude(jd)
+		for _, m := range marsSeasonMeta {
+			if !crosses(prev, cur, m.ls) {
+				continue
+			}
+			root := bisect(jd-1, jd, m.ls)
+			if root < start || root >= end {
+				continue
+			}
+			events = append(events, SeasonEvent{
+				Type:           m.kind,
+				NameSR:         m.nameSR,
+				Time:           TimeFromJulianDay(root),
+				SolarLongitude: m.ls,
+			})
+		}
+		prev = cur
+	}
+	return events
+}
+
+// crosses reports whether an angle (Ls, lunar elongation) passed target
+// between two consecutive samples, handling the 360° → 0° wrap
+func crosses(a, b, target float64) bool {
+	if b < a { // wrapped past 360
+		return target >= a || target < b
+	}
+	return target >= a && target < b
+}
+
+// bisect narrows the instant at which Mars reaches solar longitude target
+func bisect(lo, hi, target float64) float64 {
+	for i := 0; i < 40; i++ {
+		mid := (lo + hi) / 2
+		if crosses(MarsSolarLongitude(lo), MarsSolarLongitude(mid), target) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return (lo + hi) / 2
+}
@@ -0,0 +1,55 @@
+package astro
+
+import "math"
+
+// SunPosition is the Sun's apparent geocentric position
+type SunPosition struct {
+	Longitude      float64 `json:"longitude"`       // degrees, apparent ecliptic longitude
+	RightAscension float64 `json:"right_ascension"` // degrees
+	Declination    float64 `json:"declination"`     // degrees
+	Distance       float64 `json:"distance"`        // AU
+}
+
+// Sun computes the Sun's apparent position (Meeus ch. 25, low accuracy,
+// about 0.01°)
+func Sun(jd float64) SunPosition {
+	t := JulianCenturies(jd)
+	l0 := 280.46646 + 36000.76983*t + 0.0003032*t*t
+	m := (357.52911 + 35999.05029*t - 0.0001537*t*t) * deg
+	e := 0.016708634 - 0.000042037*t - 0.0000001267*t*t
+	c := (1.914602-0.004817*t-0.000014*t*t)*math.Sin(m) +
+		(0.019993-0.000101*t)*math.Sin(2*m) +
+		0.000289*math.Sin(3*m)
+
+	trueLong := l0 + c
+	v := m + c*deg
+	r := 1.000001018 * (1 - e*e) / (1 + e*math.Cos(v))
+
+	omega := (125.04 - 1934.136*t) * deg
+	lambda := (trueLong - 0.00569 - 0.00478*math.Sin(omega)) * deg
+	eps := MeanObliquity(jd) + 0.00256*math.Cos(omega)*deg
+
+	ra := math.Atan2(math.Cos(eps)*math.Sin(lambda), math.Cos(lambda))
+	dec := math.Asin(math.Sin(eps) * math.Sin(lambda))
+
+	return SunPosition{
+		Longitude:      normDeg(lambda * rad),
+		RightAscension: normDeg(ra * rad),
+		Declination:    dec * rad,
+		Distance:       r,
+	}
+}
+
+// MeanObliquity returns the mean obliquity of the ecliptic in radians
+func MeanObliquity(jd float64) float64 {
+	t := JulianCenturies(jd)
+	return (23.439291 - 0.0130042*t - 1.64e-7*t*t + 5.04e-7*t*t*t) * deg
+}
+
+// GreenwichSiderealTime returns mean sidereal time at Greenwich in degrees
+// (Meeus eq. 12.4)
+func GreenwichSiderealTime(jd float64) float64 {
+	t := JulianCenturies(jd)
+	return normDeg(280.46061837 + 360.98564736629*(jd-J2000) +
+		0.000387933*t*t - t*t*t/38710000)
+}
@@ -0,0 +1,59 @@
+// Package astro contains astronomical algorithms (mostly after Jean Meeus,
+// "Astronomical Algorithms", 2nd ed.) used by the API's derived endpoints.
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// J2000 is the Julian Day of the J2000.0 epoch (2000-01-01 12:00 TT)
+const J2000 = 2451545.0
+
+// MJD0 is the Julian Day the Modified Julian Date counts from
+// (1858-11-17 00:00)
+const MJD0 = 2400000.5
+
+const (
+	deg = math.Pi / 180
+	rad = 180 / math.Pi
+)
+
+// JulianDay converts a UTC time to a Julian Day number
+func JulianDay(t time.Time) float64 {
+	if y := t.Year(); y <= 1678 || y >= 2262 {
+		// Beyond what UnixNano holds
+		return float64(t.Unix())/86400 + float64(t.Nanosecond())/86400e9 + 2440587.5
+	}
+	return float64(t.UTC().UnixNano())/86400e9 + 2440587.5
+}
+
+// TimeFromJulianDay converts a Julian Day number back to UTC
+func TimeFromJulianDay(jd float64) time.Time {
+	return timeFromJulianDay(jd).Round(time.Second)
+}
+
+// timeFromJulianDay converts a Julian Day number to a UTC time, unrounded
+func timeFromJulianDay(jd float64) time.Time {
+	ns := (jd - 2440587.5) * 86400e9
+	if math.Abs(ns) >= math.MaxInt64 {
+		days := math.Floor(jd - 2440587.5)
+		sec := (jd - 2440587.5 - days) * 86400
+		return time.Unix(int64(days)*86400+int64(sec), int64((sec-math.Floor(sec))*1e9)).UTC()
+	}
+	return time.Unix(0, int64(ns)).UTC()
+}
+
+// JulianCenturies returns Julian centuries elapsed since J2000
+func JulianCenturies(jd float64) float64 {
+	return (jd - J2000) / 36525
+}
+
+// normDeg reduces an angle to the range [0, 360)
+func normDeg(a float64) float64 {
+	a = math.Mod(a, 360)
+	if a < 0 {
+		a += 360
+	}
+	return a
+}
@@ -0,0 +1,171 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// Time scales a Julian Day can be counted in
+const (
+	ScaleUTC = "utc" // civil time, what clocks and the rest of the API use
+	ScaleTT  = "tt"  // Terrestrial Time, the uniform time of ephemerides
+	ScaleTDB = "tdb" // Barycentric Dynamical Time, TT seen from the barycentre
+)
+
+// ttMinusTAI is TT − TAI, in seconds
+const ttMinusTAI = 32.184
+
+// leapSeconds lists TAI − UTC from each date on (IERS Bulletin C). No leap
+// second has been announced after 2016; UTC is taken to keep 37 s until
+// leapSecondsUntil, after which ΔT is extrapolated.
+var leapSeconds = []struct {
+	from time.Time
+	tai  float64
+}{
+	{time.Date(1972, 1, 1, 0, 0, 0, 0, time.UTC), 10},
+	{time.Date(1972, 7, 1, 0, 0, 0, 0, time.UTC), 11},
+	{time.Date(1973, 1, 1, 0, 0, 0, 0, time.UTC), 12},
+	{time.Date(1974, 1, 1, 0, 0, 0, 0, time.UTC), 13},
+	{time.Date(1975, 1, 1, 0, 0, 0, 0, time.UTC), 14},
+	{time.Date(1976, 1, 1, 0, 0, 0, 0, time.UTC), 15},
+	{time.Date(1977, 1, 1, 0, 0, 0, 0, time.UTC), 16},
+	{time.Date(1978, 1, 1, 0, 0, 0, 0, time.UTC), 17},
+	{time.Date(1979, 1, 1, 0, 0, 0, 0, time.UTC), 18},
+	{time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC), 19},
+	{time.Date(1981, 7, 1, 0, 0, 0, 0, time.UTC), 20},
+	{time.Date(1982, 7, 1, 0, 0, 0, 0, time.UTC), 21},
+	{time.Date(1983, 7, 1, 0, 0, 0, 0, time.UTC), 22},
+	{time.Date(1985, 7, 1, 0, 0, 0, 0, time.UTC), 23},
+	{time.Date(1988, 1, 1, 0, 0, 0, 0, time.UTC), 24},
+	{time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), 25},
+	{time.Date(1991, 1, 1, 0, 0, 0, 0, time.UTC), 26},
+	{time.Date(1992, 7, 1, 0, 0, 0, 0, time.UTC), 27},
+	{time.Date(1993, 7, 1, 0, 0, 0, 0, time.UTC), 28},
+	{time.Date(1994, 7, 1, 0, 0, 0, 0, time.UTC), 29},
+	{time.Date(1996, 1, 1, 0, 0, 0, 0, time.UTC), 30},
+	{time.Date(1997, 7, 1, 0, 0, 0, 0, time.UTC), 31},
+	{time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC), 32},
+	{time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC), 33},
+	{time.Date(2009, 1, 1, 0, 0, 0, 0, time.UTC), 34},
+	{time.Date(2012, 7, 1, 0, 0, 0, 0, time.UTC), 35},
+	{time.Date(2015, 7, 1, 0, 0, 0, 0, time.UTC), 36},
+	{time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), 37},
+}
+
+// leapSecondsUntil is how far the leap second table is trusted
+var leapSecondsUntil = time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// DeltaT returns ΔT = TT − UT in seconds at the UTC Julian Day jd, and
+// whether it is exact. From 1972 until leapSecondsUntil it is TT − UTC
+// from the leap seconds (UT1 stays within 0.9 s of UTC); otherwise it comes
+// from the polynomials of Espenak & Meeus (NASA, 2006), good to seconds
+// over the last centuries and to minutes or hours in antiquity and the far
+// future, where Earth's rotation can only be extrapolated.
+func DeltaT(jd float64) (float64, bool) {
+	t := timeFromJulianDay(jd)
+	if !t.Before(leapSeconds[0].from) && t.Before(leapSecondsUntil) {
+		tai := 0.0
+		for _, l := range leapSeconds {
+			if t.Before(l.from) {
+				break
+			}
+			tai = l.tai
+		}
+		return ttMinusTAI + tai, true
+	}
+	return deltaTPolynomial(2000 + (jd-J2000)/365.2425), false
+}
+
+// deltaTPolynomial is the Espenak & Meeus ΔT at the decimal year y
+func deltaTPolynomial(y float64) float64 {
+	poly := func(t float64, c ...float64) float64 {
+		s := 0.0
+		for i := len(c) - 1; i >= 0; i-- {
+			s = s*t + c[i]
+		}
+		return s
+	}
+	longTerm := func(y float64) float64 {
+		u := (y - 1820) / 100
+		return -20 + 32*u*u
+	}
+	switch {
+	case y < -500:
+		return longTerm(y)
+	case y < 500:
+		return poly(y/100, 10583.6, -1014.41, 33.78311, -5.952053, -0.1798452, 0.022174192, 0.0090316521)
+	case y < 1600:
+		return poly((y-1000)/100, 1574.2, -556.01, 71.23472, 0.319781, -0.8503463, -0.005050998, 0.0083572073)
+	case y < 1700:
+		return poly(y-1600, 120, -0.9808, -0.01532, 1.0/7129)
+	case y < 1800:
+		return poly(y-1700, 8.83, 0.1603, -0.0059285, 0.00013336, -1.0/1174000)
+	case y < 1860:
+		return poly(y-1800, 13.72, -0.332447, 0.0068612, 0.0041116, -0.00037436, 0.0000121272, -0.0000001699, 0.000000000875)
+	case y < 1900:
+		return poly(y-1860, 7.62, 0.5737, -0.251754, 0.01680668, -0.0004473624, 1.0/233174)
+	case y < 1920:
+		return poly(y-1900, -2.79, 1.494119, -0.0598939, 0.0061966, -0.000197)
+	case y < 1941:
+		return poly(y-1920, 21.20, 0.84493, -0.076100, 0.0020936)
+	case y < 1961:
+		return poly(y-1950, 29.07, 0.407, -1.0/233, 1.0/2547)
+	case y < 1986:
+		return poly(y-1975, 45.45, 1.067, -1.0/260, -1.0/718)
+	case y < 2005:
+		return poly(y-2000, 63.86, 0.3345, -0.060374, 0.0017275, 0.000651814, 0.00002373599)
+	case y < 2050:
+		return poly(y-2000, 62.92, 0.32217, 0.005589)
+	case y < 2150:
+		return longTerm(y) - 0.5628*(2150-y)
+	default:
+		return longTerm(y)
+	}
+}
+
+// TDBMinusTT returns TDB − TT in seconds at the TT Julian Day jd, the
+// periodic term of Earth's orbit (USNO Circular 179), good to 10 µs
+func TDBMinusTT(jd float64) float64 {
+	g := (357.53 + 0.98560028*(jd-J2000)) * deg
+	return 0.001657*math.Sin(g) + 0.000014*math.Sin(2*g)
+}
+
+// TimeScales is one instant as a Julian Day in each time scale
+type TimeScales struct {
+	UTC, TT, TDB float64
+	DeltaT       float64 // TT − UT, seconds
+	Exact        bool    // whether ΔT is TT − UTC from the leap seconds
+}
+
+// InScales takes the Julian Day jd of the given scale to every scale.
+// Going from TT or TDB to UTC, the corrections are evaluated at the
+// instant they give, which one iteration settles to well under a
+// millisecond.
+func InScales(jd float64, scale string) TimeScales {
+	var tt float64
+	switch scale {
+	case ScaleTT:
+		tt = jd
+	case ScaleTDB:
+		tt = jd - TDBMinusTT(jd)/86400
+		tt = jd - TDBMinusTT(tt)/86400
+	default:
+		dt, _ := DeltaT(jd)
+		tt = jd + dt/86400
+	}
+	utc := jd
+	if scale != ScaleUTC {
+		dt, _ := DeltaT(tt)
+		utc = tt - dt/86400
+		dt, _ = DeltaT(utc)
+		utc = tt - dt/86400
+	}
+	dt, exact := DeltaT(utc)
+	return TimeScales{UTC: utc, TT: tt, TDB: tt + TDBMinusTT(tt)/86400, DeltaT: dt, Exact: exact}
+}
+
+// CalendarTime is the calendar date and time of a Julian Day in its own
+// scale, proleptic Gregorian, to the millisecond
+func CalendarTime(jd float64) time.Time {
+	return timeFromJulianDay(jd).Round(time.Millisecond)
+}
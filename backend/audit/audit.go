@@ -0,0 +1,186 @@
+// Package audit records admin mutations in an append-only log: who changed
+// what, when, and the field-level diff. Entries are kept in memory for
+// queries and, with a path configured, appended to a JSON-lines file that
+// is replayed at startup.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Actions recorded in Entry.Action
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Change is one field's value before and after a mutation
+type Change struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// Entry is one recorded mutation
+type Entry struct {
+	ID       int64             `json:"id"`
+	Time     time.Time         `json:"time"`
+	Actor    string            `json:"actor"`
+	Action   string            `json:"action"`
+	Resource string            `json:"resource"` // e.g. "translation"
+	Key      string            `json:"key"`      // identifies the record within the resource
+	Diff     map[string]Change `json:"diff,omitempty"`
+}
+
+// Log is the audit log. It has no update or delete methods on purpose.
+type Log struct {
+	mu      sync.RWMutex
+	entries []Entry
+	file    *os.File
+}
+
+// Open replays the log at path and opens it for appending. An empty path
+// keeps the log in memory only.
+func Open(path string) (*Log, error) {
+	l := &Log{}
+	if path == "" {
+		return l, nil
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		l.entries = append(l.entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	l.file = f
+	return l, nil
+}
+
+// Record appends e, assigning its ID and time. The entry is only kept if
+// it reached the file, so the log never claims more than was persisted.
+func (l *Log) Record(e Entry) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e.ID = 1
+	if n := len(l.entries); n > 0 {
+		e.ID = l.entries[n-1].ID + 1
+	}
+	e.Time = time.Now().UTC()
+	if l.file != nil {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return e, err
+		}
+		if _, err := l.file.Write(append(data, '\n')); err != nil {
+			return e, err
+		}
+	}
+	l.entries = append(l.entries, e)
+	return e, nil
+}
+
+// Filter narrows Query; zero fields match everything
+type Filter struct {
+	Actor    string
+	Action   string
+	Resource string
+	Key      string
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
+// Query returns matching entries, newest first, at most f.Limit of them
+func (l *Log) Query(f Filter) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := []Entry{}
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		e := l.entries[i]
+		switch {
+		case f.Actor != "" && e.Actor != f.Actor,
+			f.Action != "" && e.Action != f.Action,
+			f.Resource != "" && e.Resource != f.Resource,
+			f.Key != "" && e.Key != f.Key,
+			!f.Since.IsZero() && e.Time.Before(f.Since),
+			!f.Until.IsZero() && !e.Time.Before(f.Until):
+			continue
+		}
+		out = append(out, e)
+		if f.Limit > 0 && len(out) == f.Limit {
+			break
+		}
+	}
+	return out
+}
+
+// Close closes the log file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// Diff compares the JSON forms of before and after field by field. Either
+// may be nil, for creates and deletes.
+func Diff(before, after any) (map[string]Change, error) {
+	b, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+	diff := make(map[string]Change)
+	for k, v := range b {
+		if w, ok := a[k]; !ok || !reflect.DeepEqual(v, w) {
+			diff[k] = Change{From: v, To: a[k]}
+		}
+	}
+	for k, w := range a {
+		if _, ok := b[k]; !ok {
+			diff[k] = Change{To: w}
+		}
+	}
+	return diff, nil
+}
+
+func fields(v any) (map[string]any, error) {
+	m := make(map[string]any)
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil() {
+		return m, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, errors.New("audit: only objects can be diffed")
+	}
+	return m, nil
+}
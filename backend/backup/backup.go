@@ -0,0 +1,284 @@
+// Package backup copies the user data the server keeps (accounts,
+// sessions, progress, classes, comments and the rest of the JSON files,
+// plus imported bodies from the database) into a gzipped tar, on a
+// schedule or on demand, and keeps the latest few in a directory or an S3
+// bucket. The manifest in each backup records the SHA-256 of every file
+// and where the server keeps it, which is where solarctl restore puts it
+// back.
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Format is the backup layout version written by Write. Read refuses
+// other versions.
+const Format = 1
+
+// ManifestFile is the name of the manifest inside a backup
+const ManifestFile = "manifest.json"
+
+// MaxFileSize caps each file read from a backup
+const MaxFileSize = 1 << 30
+
+// ErrInvalid is returned by Read for backups that are damaged or not
+// backups at all
+var ErrInvalid = errors.New("invalid backup")
+
+// Source is one piece of user data to back up
+type Source struct {
+	// Name is the file name inside the backup
+	Name string
+	// Path is the file the server keeps the data in
+	Path string
+	// Read returns the data when it isn't a file, such as imported bodies
+	// in the database; Path is empty then
+	Read func(ctx context.Context) ([]byte, error)
+}
+
+// File is one file in a backup
+type File struct {
+	Name   string `json:"name"`
+	Path   string `json:"path,omitempty"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// Database files are restored into the database rather than to a path
+	Database bool `json:"database,omitempty"`
+}
+
+// Manifest describes a backup
+type Manifest struct {
+	Format    int       `json:"format"`
+	CreatedAt time.Time `json:"created_at"`
+	Files     []File    `json:"files"`
+	// Missing are configured files that didn't exist yet, so there was
+	// nothing to back up
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Write reads every source and writes them to w with their manifest
+func Write(ctx context.Context, w io.Writer, sources []Source, now time.Time) (Manifest, error) {
+	m := Manifest{Format: Format, CreatedAt: now.UTC(), Files: []File{}}
+	data := make(map[string][]byte, len(sources))
+	for _, src := range sources {
+		var (
+			b   []byte
+			err error
+		)
+		if src.Read != nil {
+			b, err = src.Read(ctx)
+		} else {
+			b, err = os.ReadFile(src.Path)
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			m.Missing = append(m.Missing, src.Path)
+			continue
+		}
+		if err != nil {
+			return m, fmt.Errorf("%s: %w", src.Name, err)
+		}
+		if _, dup := data[src.Name]; dup {
+			return m, fmt.Errorf("%s is backed up twice", src.Name)
+		}
+		sum := sha256.Sum256(b)
+		m.Files = append(m.Files, File{Name: src.Name, Path: src.Path, Size: int64(len(b)), SHA256: hex.EncodeToString(sum[:]), Database: src.Read != nil})
+		data[src.Name] = b
+	}
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return m, err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	write := func(name string, b []byte) error {
+		// Everything backed up here is personal data
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(b)), ModTime: m.CreatedAt}); err != nil {
+			return err
+		}
+		_, err := tw.Write(b)
+		return err
+	}
+	if err := write(ManifestFile, manifest); err != nil {
+		return m, err
+	}
+	for _, f := range m.Files {
+		if err := write(f.Name, data[f.Name]); err != nil {
+			return m, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return m, err
+	}
+	return m, gz.Close()
+}
+
+// Read reads a backup from r and checks every file against its hash
+func Read(r io.Reader) (Manifest, map[string][]byte, error) {
+	var m Manifest
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return m, nil, fmt.Errorf("%w: not gzip: %v", ErrInvalid, err)
+	}
+	defer gz.Close()
+
+	data := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		b, err := io.ReadAll(io.LimitReader(tr, MaxFileSize+1))
+		if err != nil {
+			return m, nil, fmt.Errorf("%w: %s: %v", ErrInvalid, hdr.Name, err)
+		}
+		if len(b) > MaxFileSize {
+			return m, nil, fmt.Errorf("%w: %s is larger than %d MiB", ErrInvalid, hdr.Name, MaxFileSize>>20)
+		}
+		data[hdr.Name] = b
+	}
+	manifest, ok := data[ManifestFile]
+	if !ok {
+		return m, nil, fmt.Errorf("%w: no %s", ErrInvalid, ManifestFile)
+	}
+	delete(data, ManifestFile)
+	if err := json.Unmarshal(manifest, &m); err != nil {
+		return m, nil, fmt.Errorf("%w: %s: %v", ErrInvalid, ManifestFile, err)
+	}
+	if m.Format != Format {
+		return m, nil, fmt.Errorf("%w: format %d, this version reads %d", ErrInvalid, m.Format, Format)
+	}
+	for _, f := range m.Files {
+		b, ok := data[f.Name]
+		if !ok {
+			return m, nil, fmt.Errorf("%w: %s is missing", ErrInvalid, f.Name)
+		}
+		sum := sha256.Sum256(b)
+		if hex.EncodeToString(sum[:]) != f.SHA256 {
+			return m, nil, fmt.Errorf("%w: %s does not match its hash", ErrInvalid, f.Name)
+		}
+	}
+	if len(data) != len(m.Files) {
+		return m, nil, fmt.Errorf("%w: files not in the manifest", ErrInvalid)
+	}
+	return m, data, nil
+}
+
+// namePrefix and nameSuffix frame the backups a Target lists, around the
+// creation time
+const (
+	namePrefix = "solar-backup-"
+	nameSuffix = ".tar.gz"
+	nameLayout = "20060102T150405Z"
+)
+
+// Name is the file name of a backup made at t
+func Name(t time.Time) string {
+	return namePrefix + t.UTC().Format(nameLayout) + nameSuffix
+}
+
+// parseName returns when the backup called name was made
+func parseName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, namePrefix) || !strings.HasSuffix(name, nameSuffix) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(nameLayout, strings.TrimSuffix(strings.TrimPrefix(name, namePrefix), nameSuffix))
+	return t, err == nil
+}
+
+// Info describes a stored backup
+type Info struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+	// Location is the path or s3:// URL to restore from
+	Location string `json:"location"`
+	// Files and Missing are only known for a backup just made
+	Files   []File   `json:"files,omitempty"`
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Backups makes backups of a fixed set of sources into a target and
+// prunes old ones. Runs never overlap.
+type Backups struct {
+	mu      sync.Mutex
+	target  Target
+	keep    int
+	sources []Source
+}
+
+// New returns backups of sources into target, keeping the latest keep of
+// them (all if keep is 0)
+func New(target Target, keep int, sources []Source) *Backups {
+	return &Backups{target: target, keep: keep, sources: sources}
+}
+
+// Run makes a backup now, then deletes the ones beyond the kept number
+func (b *Backups) Run(ctx context.Context, now time.Time) (Info, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var buf bytes.Buffer
+	m, err := Write(ctx, &buf, b.sources, now)
+	if err != nil {
+		return Info{}, err
+	}
+	name := Name(m.CreatedAt)
+	size := int64(buf.Len())
+	location, err := b.target.Put(ctx, name, &buf, size)
+	if err != nil {
+		return Info{}, fmt.Errorf("storing %s: %w", name, err)
+	}
+	info := Info{Name: name, CreatedAt: m.CreatedAt, Size: size, Location: location, Files: m.Files, Missing: m.Missing}
+	if b.keep > 0 {
+		if err := b.prune(ctx); err != nil {
+			return info, fmt.Errorf("pruning old backups: %w", err)
+		}
+	}
+	return info, nil
+}
+
+// List returns the stored backups, newest first
+func (b *Backups) List(ctx context.Context) ([]Info, error) {
+	list, err := b.target.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	return list, nil
+}
+
+// prune deletes all but the newest b.keep backups. Callers hold b.mu.
+func (b *Backups) prune(ctx context.Context) error {
+	list, err := b.List(ctx)
+	if err != nil {
+		return err
+	}
+	for i := b.keep; i < len(list); i++ {
+		if err := b.target.Delete(ctx, list[i].Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testSources are two files, one that doesn't exist yet and one read
+// from the database
+func testSources(t *testing.T) []Source {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range map[string]string{"users.json": `[{"id":"u1"}]`, "progress.json": `{}`} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return []Source{
+		{Name: "users.json", Path: filepath.Join(dir, "users.json")},
+		{Name: "progress.json", Path: filepath.Join(dir, "progress.json")},
+		{Name: "comments.json", Path: filepath.Join(dir, "comments.json")},
+		{Name: "imports.json", Read: func(context.Context) ([]byte, error) { return []byte(`[]`), nil }},
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	var buf bytes.Buffer
+	written, err := Write(context.Background(), &buf, testSources(t), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written.Files) != 3 || len(written.Missing) != 1 {
+		t.Fatalf("wrote %d files, %d missing; want 3 and 1", len(written.Files), len(written.Missing))
+	}
+	m, data, err := Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data["users.json"]) != `[{"id":"u1"}]` || len(data) != 3 {
+		t.Errorf("read %d files, users.json = %q", len(data), data["users.json"])
+	}
+	for _, f := range m.Files {
+		if f.Database != (f.Name == "imports.json") {
+			t.Errorf("%s: database = %v", f.Name, f.Database)
+		}
+	}
+}
+
+func TestReadRejectsDamage(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := Write(context.Background(), &buf, testSources(t), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	damaged := bytes.Clone(buf.Bytes())
+	damaged[len(damaged)/2] ^= 0xff
+	for name, data := range map[string][]byte{
+		"damaged":   damaged,
+		"truncated": buf.Bytes()[:buf.Len()/2],
+		"not gzip":  []byte("users.json"),
+		"no backup": {},
+	} {
+		if _, _, err := Read(bytes.NewReader(data)); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: err = %v, want ErrInvalid", name, err)
+		}
+	}
+}
+
+func TestRunKeepsLatest(t *testing.T) {
+	dir := Dir(filepath.Join(t.TempDir(), "backups"))
+	b := New(dir, 2, testSources(t))
+	start := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		if _, err := b.Run(context.Background(), start.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := b.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || !list[0].CreatedAt.Equal(start.Add(3*time.Hour)) || !list[1].CreatedAt.Equal(start.Add(2*time.Hour)) {
+		t.Fatalf("kept %+v, want the 2 newest", list)
+	}
+	f, err := os.Open(list[0].Location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, _, err := Read(f); err != nil {
+		t.Errorf("reading back the stored backup: %v", err)
+	}
+}
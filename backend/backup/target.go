@@ -0,0 +1,117 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"solar-system-explorer/backend/s3"
+)
+
+// Target is where backups are stored
+type Target interface {
+	// Put stores a backup and returns where it went
+	Put(ctx context.Context, name string, r io.Reader, size int64) (string, error)
+	// List returns the stored backups, in no particular order
+	List(ctx context.Context) ([]Info, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// Dir stores backups as files in a directory, created if needed
+type Dir string
+
+func (d Dir) Put(_ context.Context, name string, r io.Reader, _ int64) (string, error) {
+	if err := os.MkdirAll(string(d), 0o700); err != nil {
+		return "", err
+	}
+	dst := filepath.Join(string(d), name)
+	tmp := dst + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return dst, os.Rename(tmp, dst)
+}
+
+func (d Dir) List(context.Context) ([]Info, error) {
+	entries, err := os.ReadDir(string(d))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []Info
+	for _, e := range entries {
+		created, ok := parseName(e.Name())
+		if !ok || !e.Type().IsRegular() {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue // removed since ReadDir
+		}
+		out = append(out, Info{Name: e.Name(), CreatedAt: created, Size: fi.Size(), Location: filepath.Join(string(d), e.Name())})
+	}
+	return out, nil
+}
+
+func (d Dir) Delete(_ context.Context, name string) error {
+	err := os.Remove(filepath.Join(string(d), name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Bucket stores backups in an S3 bucket under a key prefix
+type Bucket struct {
+	Client *s3.Client
+	Prefix string
+}
+
+func (b Bucket) Put(ctx context.Context, name string, r io.Reader, size int64) (string, error) {
+	key := b.Prefix + name
+	if err := b.Client.Put(ctx, key, r, size, "application/gzip"); err != nil {
+		return "", err
+	}
+	return "s3://" + path.Join(b.Client.Bucket(), key), nil
+}
+
+func (b Bucket) List(ctx context.Context) ([]Info, error) {
+	objects, err := b.Client.List(ctx, b.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	var out []Info
+	for _, o := range objects {
+		name := strings.TrimPrefix(o.Key, b.Prefix)
+		created, ok := parseName(name)
+		if !ok || strings.Contains(name, "/") {
+			continue
+		}
+		out = append(out, Info{Name: name, CreatedAt: created, Size: o.Size, Location: "s3://" + path.Join(b.Client.Bucket(), o.Key)})
+	}
+	return out, nil
+}
+
+func (b Bucket) Delete(ctx context.Context, name string) error {
+	return b.Client.Delete(ctx, b.Prefix+name)
+}
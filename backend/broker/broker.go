@@ -0,0 +1,179 @@
+// Package broker fans events out to the clients streaming them. A single
+// server uses the in-memory broker; replicas behind a load balancer share
+// one through Redis pub/sub, so an event published on any replica reaches
+// clients connected to every one.
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/redis"
+)
+
+// bufferSize is how many messages a subscriber may fall behind before
+// further ones are dropped for it
+const bufferSize = 16
+
+// Message is one published event
+type Message struct {
+	Topic string          `json:"topic"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Broker publishes messages to the subscribers of their topic
+type Broker interface {
+	Publish(ctx context.Context, topic string, data any) error
+	// Subscribe returns a subscription to topics, or to every topic when
+	// none are given
+	Subscribe(topics ...string) *Subscription
+}
+
+// Subscription receives messages on C until Close
+type Subscription struct {
+	C <-chan Message
+
+	c      chan Message
+	topics map[string]bool
+	closed bool
+	parent *Memory
+}
+
+// Close ends the subscription and closes C
+func (s *Subscription) Close() {
+	s.parent.remove(s)
+}
+
+// wants reports whether s subscribed to topic
+func (s *Subscription) wants(topic string) bool {
+	return len(s.topics) == 0 || s.topics[topic]
+}
+
+// Memory delivers messages within this process
+type Memory struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+// NewMemory returns an in-memory broker
+func NewMemory() *Memory {
+	return &Memory{subs: make(map[*Subscription]struct{})}
+}
+
+// Publish delivers data, encoded as JSON, to the subscribers of topic.
+// A subscriber whose buffer is full misses the message rather than
+// holding up the others.
+func (m *Memory) Publish(_ context.Context, topic string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	m.deliver(Message{Topic: topic, Data: raw})
+	return nil
+}
+
+func (m *Memory) deliver(msg Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for s := range m.subs {
+		if !s.wants(msg.Topic) {
+			continue
+		}
+		select {
+		case s.c <- msg:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a subscription to topics, or to all of them
+func (m *Memory) Subscribe(topics ...string) *Subscription {
+	c := make(chan Message, bufferSize)
+	s := &Subscription{C: c, c: c, topics: make(map[string]bool), parent: m}
+	for _, t := range topics {
+		s.topics[t] = true
+	}
+	m.mu.Lock()
+	m.subs[s] = struct{}{}
+	m.mu.Unlock()
+	return s
+}
+
+func (m *Memory) remove(s *Subscription) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	delete(m.subs, s)
+	close(s.c)
+}
+
+// Subscribers returns how many subscriptions are open
+func (m *Memory) Subscribers() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.subs)
+}
+
+// channel is the Redis pub/sub channel carrying every topic
+const channel = "broker"
+
+// Redis publishes through Redis and delivers what arrives there to the
+// subscribers in this process, including messages published here
+type Redis struct {
+	*Memory
+	client *redis.Client
+}
+
+// NewRedis returns a broker on client. It listens until ctx is done,
+// resubscribing after a lost connection; messages published meanwhile
+// are missed.
+func NewRedis(ctx context.Context, client *redis.Client) *Redis {
+	b := &Redis{Memory: NewMemory(), client: client}
+	go b.listen(ctx)
+	return b
+}
+
+// Publish sends the message to every replica
+func (b *Redis) Publish(ctx context.Context, topic string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(Message{Topic: topic, Data: raw})
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, channel, payload)
+}
+
+func (b *Redis) listen(ctx context.Context) {
+	backoff := time.Second
+	for ctx.Err() == nil {
+		start := time.Now()
+		err := b.client.Subscribe(ctx, channel, func(payload []byte) {
+			var msg Message
+			if err := json.Unmarshal(payload, &msg); err == nil {
+				b.deliver(msg)
+			}
+		})
+		if err == nil {
+			return
+		}
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		log.Printf("broker: redis subscription: %v; retrying in %s", err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, time.Minute)
+	}
+}
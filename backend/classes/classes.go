@@ -0,0 +1,373 @@
+// Package classes keeps teacher-managed classes. A teacher creates a
+// class, students join it with its code, and the teacher assigns tours
+// for the class to take.
+package classes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+	"solar-system-explorer/backend/internal/random"
+)
+
+// Limits on classes
+const (
+	MaxPerTeacher  = 50
+	MaxMembers     = 200
+	MaxAssignments = 100
+	CodeLength     = 8
+)
+
+// Assignment kinds
+const (
+	KindTour = "tour"
+)
+
+// codeAlphabet leaves out characters that are easy to misread on a board:
+// 0/O and 1/I
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Errors returned by Classes
+var (
+	ErrInvalid   = errors.New("invalid class")
+	ErrNotFound  = errors.New("class not found")
+	ErrForbidden = errors.New("not the class teacher")
+	ErrFull      = errors.New("limit reached")
+)
+
+// Member is a student in a class
+type Member struct {
+	UserID   string    `json:"user_id"`
+	Name     string    `json:"name"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// Assignment is something the class is asked to do
+type Assignment struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`
+	Target     string     `json:"target"` // e.g. the tour ID
+	Due        *time.Time `json:"due,omitempty"`
+	AssignedAt time.Time  `json:"assigned_at"`
+}
+
+// Class is a teacher's group of students
+type Class struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	TeacherID   string       `json:"teacher_id"`
+	TeacherName string       `json:"teacher_name"`
+	Code        string       `json:"code"` // join code
+	Members     []Member     `json:"members"`
+	Assignments []Assignment `json:"assignments"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// HasMember reports whether userID is a student in the class
+func (c Class) HasMember(userID string) bool {
+	for _, m := range c.Members {
+		if m.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Classes holds all classes, persisted to a JSON file when a path is set
+type Classes struct {
+	mu   sync.RWMutex
+	path string
+	byID map[string]*Class
+}
+
+// Open loads the classes file at path, which may not exist yet. An empty
+// path keeps classes in memory only.
+func Open(path string) (*Classes, error) {
+	cs := &Classes{path: path, byID: make(map[string]*Class)}
+	if path == "" {
+		return cs, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*Class
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	for _, cl := range list {
+		cs.byID[cl.ID] = cl
+	}
+	return cs, nil
+}
+
+// Create starts a class taught by teacherID
+func (cs *Classes) Create(teacherID, teacherName, name string, now time.Time) (Class, error) {
+	name = strings.TrimSpace(name)
+	if n := utf8.RuneCountInString(name); n < 1 || n > 80 {
+		return Class{}, fmt.Errorf("%w: name must be 1 to 80 characters", ErrInvalid)
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	taught := 0
+	for _, cl := range cs.byID {
+		if cl.TeacherID == teacherID {
+			taught++
+		}
+	}
+	if taught >= MaxPerTeacher {
+		return Class{}, fmt.Errorf("%w: at most %d classes per teacher", ErrFull, MaxPerTeacher)
+	}
+	cl := &Class{
+		ID:          random.Hex(8),
+		Name:        name,
+		TeacherID:   teacherID,
+		TeacherName: teacherName,
+		Code:        cs.newCode(),
+		Members:     []Member{},
+		Assignments: []Assignment{},
+		CreatedAt:   now.UTC(),
+	}
+	cs.byID[cl.ID] = cl
+	if err := cs.save(); err != nil {
+		delete(cs.byID, cl.ID)
+		return Class{}, err
+	}
+	return clone(cl), nil
+}
+
+// Get returns a class by ID
+func (cs *Classes) Get(id string) (Class, error) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	cl, ok := cs.byID[id]
+	if !ok {
+		return Class{}, ErrNotFound
+	}
+	return clone(cl), nil
+}
+
+// ForUser returns the classes userID teaches or belongs to, oldest first
+func (cs *Classes) ForUser(userID string) []Class {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	out := []Class{}
+	for _, cl := range cs.byID {
+		if cl.TeacherID == userID || cl.HasMember(userID) {
+			out = append(out, clone(cl))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// Delete removes a class taught by teacherID
+func (cs *Classes) Delete(id, teacherID string) (Class, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cl, err := cs.owned(id, teacherID)
+	if err != nil {
+		return Class{}, err
+	}
+	delete(cs.byID, id)
+	if err := cs.save(); err != nil {
+		cs.byID[id] = cl
+		return Class{}, err
+	}
+	return clone(cl), nil
+}
+
+// Join adds userID to the class with code. Joining twice is not an error.
+func (cs *Classes) Join(code, userID, name string, now time.Time) (Class, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	var cl *Class
+	for _, c := range cs.byID {
+		if c.Code == code {
+			cl = c
+			break
+		}
+	}
+	if cl == nil || code == "" {
+		return Class{}, ErrNotFound
+	}
+	if cl.TeacherID == userID {
+		return Class{}, fmt.Errorf("%w: teachers can't join their own class", ErrInvalid)
+	}
+	if cl.HasMember(userID) {
+		return clone(cl), nil
+	}
+	if len(cl.Members) >= MaxMembers {
+		return Class{}, fmt.Errorf("%w: a class has at most %d students", ErrFull, MaxMembers)
+	}
+	prev := cl.Members
+	cl.Members = append(cl.Members[:len(cl.Members):len(cl.Members)], Member{UserID: userID, Name: name, JoinedAt: now.UTC()})
+	if err := cs.save(); err != nil {
+		cl.Members = prev
+		return Class{}, err
+	}
+	return clone(cl), nil
+}
+
+// RemoveMember takes userID out of a class. The teacher may remove anyone;
+// students may only leave themselves (byID == userID).
+func (cs *Classes) RemoveMember(id, byID, userID string) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cl, ok := cs.byID[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if byID != cl.TeacherID && byID != userID {
+		return ErrForbidden
+	}
+	prev := cl.Members
+	kept := make([]Member, 0, len(prev))
+	for _, m := range prev {
+		if m.UserID != userID {
+			kept = append(kept, m)
+		}
+	}
+	if len(kept) == len(prev) {
+		return ErrNotFound
+	}
+	cl.Members = kept
+	if err := cs.save(); err != nil {
+		cl.Members = prev
+		return err
+	}
+	return nil
+}
+
+// RotateCode gives a class a new join code; the old one stops working
+func (cs *Classes) RotateCode(id, teacherID string) (Class, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cl, err := cs.owned(id, teacherID)
+	if err != nil {
+		return Class{}, err
+	}
+	prev := cl.Code
+	cl.Code = cs.newCode()
+	if err := cs.save(); err != nil {
+		cl.Code = prev
+		return Class{}, err
+	}
+	return clone(cl), nil
+}
+
+// Assign adds an assignment to a class. The caller checks that the target
+// exists.
+func (cs *Classes) Assign(id, teacherID string, a Assignment, now time.Time) (Assignment, error) {
+	if a.Kind != KindTour {
+		return Assignment{}, fmt.Errorf("%w: kind must be %s", ErrInvalid, KindTour)
+	}
+	if a.Due != nil && !a.Due.After(now) {
+		return Assignment{}, fmt.Errorf("%w: due must be in the future", ErrInvalid)
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cl, err := cs.owned(id, teacherID)
+	if err != nil {
+		return Assignment{}, err
+	}
+	if len(cl.Assignments) >= MaxAssignments {
+		return Assignment{}, fmt.Errorf("%w: a class has at most %d assignments", ErrFull, MaxAssignments)
+	}
+	a.ID, a.AssignedAt = random.Hex(4), now.UTC()
+	if a.Due != nil {
+		due := a.Due.UTC()
+		a.Due = &due
+	}
+	prev := cl.Assignments
+	cl.Assignments = append(cl.Assignments[:len(prev):len(prev)], a)
+	if err := cs.save(); err != nil {
+		cl.Assignments = prev
+		return Assignment{}, err
+	}
+	return a, nil
+}
+
+// Unassign removes an assignment from a class
+func (cs *Classes) Unassign(id, teacherID, assignmentID string) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cl, err := cs.owned(id, teacherID)
+	if err != nil {
+		return err
+	}
+	prev := cl.Assignments
+	kept := make([]Assignment, 0, len(prev))
+	for _, a := range prev {
+		if a.ID != assignmentID {
+			kept = append(kept, a)
+		}
+	}
+	if len(kept) == len(prev) {
+		return ErrNotFound
+	}
+	cl.Assignments = kept
+	if err := cs.save(); err != nil {
+		cl.Assignments = prev
+		return err
+	}
+	return nil
+}
+
+// owned returns class id if teacherID teaches it. Callers hold cs.mu.
+func (cs *Classes) owned(id, teacherID string) (*Class, error) {
+	cl, ok := cs.byID[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if cl.TeacherID != teacherID {
+		return nil, ErrForbidden
+	}
+	return cl, nil
+}
+
+// newCode returns a join code no class uses. Callers hold cs.mu.
+func (cs *Classes) newCode() string {
+	for {
+		code, taken := random.String(codeAlphabet, CodeLength), false
+		for _, cl := range cs.byID {
+			taken = taken || cl.Code == code
+		}
+		if !taken {
+			return code
+		}
+	}
+}
+
+func (cs *Classes) save() error {
+	if cs.path == "" {
+		return nil
+	}
+	list := make([]*Class, 0, len(cs.byID))
+	for _, cl := range cs.byID {
+		list = append(list, cl)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return jsonfile.Save(cs.path, list, 0o644)
+}
+
+// clone copies a class so callers can't reach the stored slices
+func clone(cl *Class) Class {
+	out := *cl
+	out.Members = append([]Member{}, cl.Members...)
+	out.Assignments = append([]Assignment{}, cl.Assignments...)
+	return out
+}
@@ -0,0 +1,42 @@
+// Package clock abstracts the current time for the computations that
+// depend on it, so tests can pin it and get the same sky every run, and a
+// request can ask for the sky at another moment
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the wall clock
+type System struct{}
+
+// Now returns time.Now()
+func (System) Now() time.Time { return time.Now() }
+
+type simulatedKey struct{}
+
+// WithSimulated returns ctx carrying t as the time its request asked to
+// be answered for
+func WithSimulated(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, simulatedKey{}, t)
+}
+
+// Simulated returns the time ctx carries from WithSimulated
+func Simulated(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(simulatedKey{}).(time.Time)
+	return t, ok
+}
+
+// Now returns the simulated time ctx carries, or else clk's
+func Now(ctx context.Context, clk Clock) time.Time {
+	if t, ok := Simulated(ctx); ok {
+		return t
+	}
+	return clk.Now()
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// client calls the server's API
+type client struct {
+	base    string
+	token   string
+	timeout time.Duration
+	http    *http.Client
+}
+
+func newClient(base, token string, timeout time.Duration) *client {
+	return &client{base: strings.TrimRight(base, "/"), token: token, timeout: timeout, http: &http.Client{}}
+}
+
+// apiError is an error response from the server
+type apiError struct {
+	Status int
+	Msg    string `json:"error"`
+	Fields []struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"fields"`
+}
+
+func (e *apiError) Error() string {
+	msg := fmt.Sprintf("%d %s", e.Status, e.Msg)
+	for _, f := range e.Fields {
+		msg += fmt.Sprintf("; %s: %s", f.Field, f.Message)
+	}
+	return msg
+}
+
+// do sends a request to path (with query) and returns the response body.
+// Anything but a 2xx answer is returned as an *apiError. A body is sent
+// as JSON unless it is an io.Reader, which is sent as is.
+func (c *client) do(method, path string, query url.Values, body any) ([]byte, http.Header, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	var r io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case io.Reader:
+		r, contentType = b, "application/octet-stream"
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		r, contentType = bytes.NewReader(data), "application/json"
+	}
+	target := c.base + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" && strings.HasPrefix(path, "/api/admin/") {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		e := &apiError{Status: resp.StatusCode}
+		if json.Unmarshal(data, e) != nil || e.Msg == "" {
+			e.Msg = http.StatusText(resp.StatusCode)
+		}
+		return nil, nil, e
+	}
+	return data, resp.Header, nil
+}
+
+// getJSON decodes the "data" member of a GET response into out
+func (c *client) getJSON(path string, query url.Values, out any) error {
+	data, _, err := c.do(http.MethodGet, path, query, nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &struct {
+		Data any `json:"data"`
+	}{out})
+}
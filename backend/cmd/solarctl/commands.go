@@ -0,0 +1,447 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"solar-system-explorer/backend/backup"
+	"solar-system-explorer/backend/internal/jsonfile"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/orbits"
+	"solar-system-explorer/backend/s3"
+	"solar-system-explorer/backend/seeds"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/validation"
+)
+
+// runSeed validates a seed scenario or the bodies in a JSON file and
+// saves them to the imports repository, where the server picks them up
+// when it starts
+func runSeed(_ *client, args []string) error {
+	fs := subcommand("seed")
+	scenario := fs.String("scenario", "", "curated scenario: "+strings.Join(seeds.Scenarios(), ", "))
+	file := fs.String("file", "", "JSON file with an array of bodies")
+	repo := repositoryFlags(fs)
+	force := fs.Bool("force", false, "save even when validation finds errors")
+	fs.Parse(args)
+
+	var (
+		bodies []models.Planet
+		err    error
+	)
+	switch {
+	case *scenario != "" && *file == "":
+		bodies, err = seeds.Load(*scenario)
+	case *file != "" && *scenario == "":
+		bodies, err = store.LoadFile(*file)
+	default:
+		fs.Usage()
+		return errFailed
+	}
+	if err != nil {
+		return err
+	}
+	return seed(repo, bodies, *force)
+}
+
+// seed saves bodies to the repository after checking them
+func seed(repo *repositoryOptions, bodies []models.Planet, force bool) error {
+	report := validation.Check(bodies, time.Now())
+	printIssues(report)
+	if !report.OK() && !force {
+		return fmt.Errorf("%s; fix them or pass -force", report)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	r, err := repo.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := r.Save(ctx, bodies); err != nil {
+		return err
+	}
+	fmt.Printf("Saved %d bodies to %s; restart the server to load them\n", len(bodies), repo)
+	return nil
+}
+
+// repositoryOptions picks the imports repository like the server does:
+// Postgres with a DSN, otherwise the imports file
+type repositoryOptions struct {
+	dsn, file string
+}
+
+func repositoryFlags(fs *flag.FlagSet) *repositoryOptions {
+	var o repositoryOptions
+	fs.StringVar(&o.dsn, "dsn", os.Getenv("DB_DSN"), "Postgres connection string (DB_DSN)")
+	fs.StringVar(&o.file, "imports-file", os.Getenv("IMPORTS_FILE"), "imports JSON file when there is no database (IMPORTS_FILE)")
+	return &o
+}
+
+func (o *repositoryOptions) open(ctx context.Context) (store.BodyRepository, error) {
+	switch {
+	case o.dsn != "":
+		return store.OpenPostgres(ctx, o.dsn, store.Pool{MaxConns: 2, MaxIdleConns: 1}, true)
+	case o.file != "":
+		return store.NewFileRepository(o.file), nil
+	}
+	return nil, errors.New("no repository: set -dsn or -imports-file")
+}
+
+func (o *repositoryOptions) String() string {
+	if o.dsn != "" {
+		return "the database"
+	}
+	return o.file
+}
+
+// runImportSBDB asks the server to import objects from JPL SBDB
+func runImportSBDB(c *client, args []string) error {
+	fs := subcommand("import-sbdb")
+	dryRun := fs.Bool("dry-run", false, "report what would change without applying it")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errFailed
+	}
+	data, _, err := c.do(http.MethodPost, "/api/admin/import/sbdb", nil, map[string]any{
+		"designations": fs.Args(),
+		"dry_run":      *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Data []struct {
+			Designation string `json:"designation"`
+			Action      string `json:"action"`
+			Body        *struct {
+				Name string `json:"name"`
+			} `json:"body"`
+			Diff  map[string]any `json:"diff"`
+			Error string         `json:"error"`
+		} `json:"data"`
+		Applied bool `json:"applied"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "DESIGNATION\tBODY\tRESULT")
+	failed := false
+	for _, r := range resp.Data {
+		switch {
+		case r.Error != "":
+			failed = true
+			fmt.Fprintf(w, "%s\t\terror: %s\n", r.Designation, r.Error)
+		case r.Action == "update":
+			fmt.Fprintf(w, "%s\t%s\tupdate (%d fields)\n", r.Designation, r.Body.Name, len(r.Diff))
+		default:
+			fmt.Fprintf(w, "%s\t%s\t%s\n", r.Designation, r.Body.Name, r.Action)
+		}
+	}
+	w.Flush()
+	if *dryRun {
+		fmt.Println("Dry run, nothing applied")
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+// runExport downloads a dataset archive
+func runExport(c *client, args []string) error {
+	fs := subcommand("export")
+	out := fs.String("o", "", "file to write, default the name the server suggests; - for stdout")
+	fs.Parse(args)
+	data, header, err := c.do(http.MethodGet, "/api/admin/export", nil, nil)
+	if err != nil {
+		return err
+	}
+	name := *out
+	if name == "" {
+		if _, params, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil {
+			name = params["filename"]
+		}
+		if name == "" {
+			name = "solar-export.tar.gz"
+		}
+	}
+	if name == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s (%d bytes)\n", name, len(data))
+	return nil
+}
+
+// runImport uploads an archive written by export
+func runImport(c *client, args []string) error {
+	fs := subcommand("import")
+	dryRun := fs.Bool("dry-run", false, "check the archive and report without applying it")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errFailed
+	}
+	archive, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	query := url.Values{}
+	if *dryRun {
+		query.Set("dry_run", "true")
+	}
+	data, _, err := c.do(http.MethodPost, "/api/admin/import", query, bytes.NewReader(archive))
+	if err != nil {
+		return err
+	}
+	return printJSON(data)
+}
+
+// runPositions prints where bodies are at a moment
+func runPositions(c *client, args []string) error {
+	fs := subcommand("positions")
+	at := fs.String("time", "", "RFC 3339 time, default now")
+	fs.Parse(args)
+	query := url.Values{}
+	if *at != "" {
+		if _, err := time.Parse(time.RFC3339, *at); err != nil {
+			return fmt.Errorf("-time: want RFC 3339, e.g. 2030-01-01T00:00:00Z")
+		}
+		query.Set("time", *at)
+	}
+	if fs.NArg() > 0 {
+		query.Set("bodies", strings.Join(fs.Args(), ","))
+	}
+	var positions []orbits.Position
+	if err := c.getJSON("/api/positions", query, &positions); err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "BODY\tX (AU)\tY (AU)\tZ (AU)\tDISTANCE (AU)\tLONGITUDE (°)\tLATITUDE (°)\t")
+	for _, p := range positions {
+		fmt.Fprintf(w, "%s\t%.5f\t%.5f\t%.5f\t%.5f\t%.3f\t%.3f\t\n", p.Name, p.X, p.Y, p.Z, p.Distance, p.EclipticLon, p.EclipticLat)
+	}
+	w.Flush()
+	if len(positions) > 0 {
+		fmt.Printf("at %s\n", positions[0].Time.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// runValidate prints the server's validation report, or with -data-dir
+// checks body files locally without a server
+func runValidate(c *client, args []string) error {
+	fs := subcommand("validate")
+	dir := fs.String("data-dir", "", "check the *.json body files in this directory instead of asking the server")
+	fs.Parse(args)
+	var report validation.Report
+	if *dir != "" {
+		bodies, err := store.LoadDir(*dir)
+		if err != nil {
+			return err
+		}
+		report = validation.Check(bodies, time.Now())
+	} else if err := c.getJSON("/api/admin/validation", nil, &report); err != nil {
+		return err
+	}
+	printIssues(report)
+	fmt.Println(report)
+	if !report.OK() {
+		return errFailed
+	}
+	return nil
+}
+
+// runBackup asks the server for a backup, or lists the stored ones
+func runBackup(c *client, args []string) error {
+	fs := subcommand("backup")
+	list := fs.Bool("list", false, "list the stored backups instead of making one")
+	fs.Parse(args)
+	if *list {
+		var backups []backup.Info
+		if err := c.getJSON("/api/admin/backups", nil, &backups); err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "CREATED\tSIZE\tLOCATION")
+		for _, b := range backups {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", b.CreatedAt.Format(time.RFC3339), b.Size, b.Location)
+		}
+		return w.Flush()
+	}
+	data, _, err := c.do(http.MethodPost, "/api/admin/backup", nil, nil)
+	if err != nil {
+		return err
+	}
+	return printJSON(data)
+}
+
+// runRestore writes the files of a backup back where the server keeps
+// them, and imported bodies back into the database. Nothing is written
+// if any file is already there, unless -force.
+func runRestore(_ *client, args []string) error {
+	fs := subcommand("restore")
+	dryRun := fs.Bool("dry-run", false, "list what would be restored without writing anything")
+	force := fs.Bool("force", false, "overwrite files that exist")
+	to := fs.String("to", "", "write the files into this directory instead of their recorded paths")
+	dsn := fs.String("dsn", os.Getenv("DB_DSN"), "Postgres connection string for imported bodies backed up from the database (DB_DSN)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errFailed
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	r, err := openBackup(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	m, files, err := backup.Read(r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+
+	// Where each file goes, checked before anything is written
+	dest := make(map[string]string, len(m.Files))
+	var exist []string
+	for _, f := range m.Files {
+		switch {
+		case f.Database:
+			continue
+		case *to != "":
+			dest[f.Name] = filepath.Join(*to, f.Name)
+		case f.Path == "":
+			return fmt.Errorf("%s has no recorded path; pass -to", f.Name)
+		default:
+			dest[f.Name] = f.Path
+		}
+		if _, err := os.Stat(dest[f.Name]); err == nil {
+			exist = append(exist, dest[f.Name])
+		}
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Backup of %s\n\nFILE\tSIZE\tRESTORE TO\n", m.CreatedAt.Format(time.RFC3339))
+	for _, f := range m.Files {
+		target := dest[f.Name]
+		if f.Database {
+			target = "the database"
+			if *dsn == "" {
+				target = "skipped, no -dsn"
+			}
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\n", f.Name, f.Size, target)
+	}
+	w.Flush()
+	if *dryRun {
+		fmt.Println("Dry run, nothing restored")
+		return nil
+	}
+	if len(exist) > 0 && !*force {
+		return fmt.Errorf("files already exist: %s; pass -force to overwrite", strings.Join(exist, ", "))
+	}
+
+	for _, f := range m.Files {
+		if f.Database {
+			if *dsn == "" {
+				continue
+			}
+			var bodies []models.Planet
+			if err := json.Unmarshal(files[f.Name], &bodies); err != nil {
+				return fmt.Errorf("%s: %w", f.Name, err)
+			}
+			repo, err := store.OpenPostgres(ctx, *dsn, store.Pool{MaxConns: 2, MaxIdleConns: 1}, true)
+			if err != nil {
+				return err
+			}
+			err = repo.Save(ctx, bodies)
+			repo.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.Name, err)
+			}
+			continue
+		}
+		if err := writeFile(dest[f.Name], files[f.Name]); err != nil {
+			return err
+		}
+	}
+	fmt.Println("Restored; start the server to load it")
+	return nil
+}
+
+// openBackup opens a backup file, or an object in S3 with the account in
+// the S3_* environment variables
+func openBackup(ctx context.Context, location string) (io.ReadCloser, error) {
+	rest, ok := strings.CutPrefix(location, "s3://")
+	if !ok {
+		return os.Open(location)
+	}
+	bucket, key, ok := strings.Cut(rest, "/")
+	if !ok || bucket == "" || key == "" {
+		return nil, fmt.Errorf("%s: want s3://BUCKET/KEY", location)
+	}
+	client, err := s3.New(s3.Config{
+		Endpoint:        os.Getenv("S3_ENDPOINT"),
+		Region:          os.Getenv("S3_REGION"),
+		Bucket:          bucket,
+		AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Get(ctx, key, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// writeFile replaces path with data in one step, creating its directory
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return jsonfile.Write(path, data, 0o600)
+}
+
+func printIssues(r validation.Report) {
+	if len(r.Issues) == 0 {
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SEVERITY\tBODY\tFIELD\tMESSAGE")
+	for _, is := range r.Issues {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", is.Severity, is.Body, is.Field, is.Message)
+	}
+	w.Flush()
+}
+
+func printJSON(data []byte) error {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err := buf.WriteTo(os.Stdout)
+	return err
+}
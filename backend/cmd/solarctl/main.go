@@ -0,0 +1,107 @@
+// Command solarctl runs the everyday operations tasks against a Solar
+// System Explorer deployment: it calls the admin API for imports, exports,
+// positions and validation, and writes seed data straight to the imports
+// repository (the database or imports file) for environments with no
+// server running yet.
+//
+//	solarctl [-url URL] [-token TOKEN] <command> [flags] [args]
+//
+// URL and TOKEN default to SOLAR_URL and ADMIN_TOKEN.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// command is one solarctl subcommand
+type command struct {
+	usage string // arguments, after the command name
+	help  string
+	run   func(c *client, args []string) error
+}
+
+// commands is filled in init, as the commands refer back to it for
+// their usage
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"seed":        {"-scenario NAME | -file bodies.json [-dsn DSN | -imports-file PATH]", "write bodies to the imports repository directly", runSeed},
+		"import-sbdb": {"[-dry-run] DESIGNATION...", "import asteroids and comets from JPL SBDB through the server", runImportSBDB},
+		"export":      {"[-o FILE]", "download a signed dataset archive", runExport},
+		"import":      {"[-dry-run] FILE", "upload a dataset archive from export", runImport},
+		"positions":   {"[-time RFC3339] [BODY...]", "print heliocentric positions", runPositions},
+		"validate":    {"[-data-dir DIR]", "print the dataset validation report; exits 1 on errors", runValidate},
+		"backup":      {"[-list]", "back up user data now, or list the stored backups", runBackup},
+		"restore":     {"[-dry-run] [-force] [-to DIR] [-dsn DSN] FILE|s3://BUCKET/KEY", "put the files of a backup back in place; stop the server first", runRestore},
+	}
+}
+
+// errFailed makes main exit 1 after a command already reported why
+var errFailed = errors.New("failed")
+
+func main() {
+	fs := flag.NewFlagSet("solarctl", flag.ExitOnError)
+	baseURL := fs.String("url", envOr("SOLAR_URL", "http://localhost:8080"), "server base URL")
+	token := fs.String("token", os.Getenv("ADMIN_TOKEN"), "admin token for /api/admin")
+	timeout := fs.Duration("timeout", 60*time.Second, "timeout for each API request")
+	fs.Usage = usage(fs)
+	fs.Parse(os.Args[1:])
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "solarctl: unknown command %q\n", fs.Arg(0))
+		fs.Usage()
+		os.Exit(2)
+	}
+	c := newClient(*baseURL, *token, *timeout)
+	if err := cmd.run(c, fs.Args()[1:]); err != nil {
+		if !errors.Is(err, errFailed) {
+			fmt.Fprintf(os.Stderr, "solarctl %s: %v\n", fs.Arg(0), err)
+		}
+		os.Exit(1)
+	}
+}
+
+func usage(fs *flag.FlagSet) func() {
+	return func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: solarctl [flags] <command> [command flags]\n\nCommands:\n")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(out, "  %-12s %s\n  %-12s   %s %s\n", name, commands[name].help, "", name, commands[name].usage)
+		}
+		fmt.Fprintf(out, "\nFlags:\n")
+		fs.PrintDefaults()
+	}
+}
+
+// subcommand returns a flag set for the named command that prints its
+// usage line on errors
+func subcommand(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: solarctl %s %s\n", name, commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,338 @@
+// Package comments keeps the discussion threads on body pages. Comments
+// containing blocked words wait for a moderator; everything else is
+// visible at once, subject to per-user rate limits.
+package comments
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+	"solar-system-explorer/backend/internal/random"
+	"solar-system-explorer/backend/translit"
+)
+
+// Comment states
+const (
+	StatusVisible = "visible"
+	StatusPending = "pending" // held for moderation
+	StatusHidden  = "hidden"  // removed by a moderator
+	StatusDeleted = "deleted" // removed by its author; replies stay
+)
+
+// Limits on comments
+const (
+	MaxLength = 2000 // characters
+	MaxDepth  = 5    // reply nesting, top level is 1
+)
+
+// Errors returned by Comments
+var (
+	ErrInvalid     = errors.New("invalid comment")
+	ErrNotFound    = errors.New("comment not found")
+	ErrRateLimited = errors.New("too many comments")
+	ErrForbidden   = errors.New("not the author")
+)
+
+// Comment is one post in a body's thread
+type Comment struct {
+	ID         string    `json:"id"`
+	Body       string    `json:"body"` // English name of the body
+	ParentID   string    `json:"parent_id,omitempty"`
+	AuthorID   string    `json:"author_id"`
+	AuthorName string    `json:"author_name"`
+	Text       string    `json:"text"`
+	Status     string    `json:"status"`
+	Flagged    []string  `json:"flagged,omitempty"` // blocked words that held it
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Limits are the rate limits and spam filter
+type Limits struct {
+	PerMinute    int      // comments per user per minute
+	PerDay       int      // comments per user per day
+	BlockedWords []string // words or phrases, matched whole, ignoring case, diacritics and script
+	MaxLinks     int      // more links than this holds a comment
+}
+
+// Comments holds all comments, persisted to a JSON file when a path is set
+type Comments struct {
+	mu      sync.RWMutex
+	path    string
+	limits  Limits
+	blocked map[string]string // folded word → as configured
+	byID    map[string]*Comment
+}
+
+// Open loads the comments file at path, which may not exist yet. An empty
+// path keeps comments in memory only.
+func Open(path string, limits Limits) (*Comments, error) {
+	c := &Comments{path: path, limits: limits, blocked: make(map[string]string), byID: make(map[string]*Comment)}
+	for _, w := range limits.BlockedWords {
+		if w = strings.TrimSpace(w); w != "" {
+			c.blocked[translit.Fold(w)] = w
+		}
+	}
+	if path == "" {
+		return c, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*Comment
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	for _, cm := range list {
+		c.byID[cm.ID] = cm
+	}
+	return c, nil
+}
+
+// Post adds a comment on body by the given author, in reply to parentID
+// if set. It is held for moderation when it trips the spam filter.
+func (c *Comments) Post(body, parentID, authorID, authorName, text string, now time.Time) (Comment, error) {
+	text = strings.TrimSpace(text)
+	if n := utf8.RuneCountInString(text); n == 0 || n > MaxLength {
+		return Comment{}, fmt.Errorf("%w: text must be 1 to %d characters", ErrInvalid, MaxLength)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if parentID != "" {
+		parent, ok := c.byID[parentID]
+		if !ok || parent.Body != body || parent.Status == StatusHidden {
+			return Comment{}, fmt.Errorf("%w: parent comment not found on this body", ErrInvalid)
+		}
+		if c.depth(parent) >= MaxDepth {
+			return Comment{}, fmt.Errorf("%w: replies nest at most %d deep", ErrInvalid, MaxDepth)
+		}
+	}
+	if err := c.checkRate(authorID, now); err != nil {
+		return Comment{}, err
+	}
+	now = now.UTC()
+	cm := &Comment{
+		ID:         random.Hex(8),
+		Body:       body,
+		ParentID:   parentID,
+		AuthorID:   authorID,
+		AuthorName: authorName,
+		Text:       text,
+		Status:     StatusVisible,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if cm.Flagged = c.spam(text); len(cm.Flagged) > 0 {
+		cm.Status = StatusPending
+	}
+	c.byID[cm.ID] = cm
+	if err := c.save(); err != nil {
+		delete(c.byID, cm.ID)
+		return Comment{}, err
+	}
+	return *cm, nil
+}
+
+// Thread returns body's comments in posting order: visible ones, plus
+// deleted ones that still have replies (with their text removed), plus
+// viewerID's own pending comments
+func (c *Comments) Thread(body, viewerID string) []Comment {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	hasReplies := make(map[string]bool)
+	for _, cm := range c.byID {
+		if cm.Body == body && cm.Status == StatusVisible && cm.ParentID != "" {
+			hasReplies[cm.ParentID] = true
+		}
+	}
+	out := []Comment{}
+	for _, cm := range c.byID {
+		if cm.Body != body {
+			continue
+		}
+		switch {
+		case cm.Status == StatusVisible, cm.Status == StatusPending && viewerID != "" && cm.AuthorID == viewerID:
+			shown := *cm
+			shown.Flagged = nil
+			out = append(out, shown)
+		case cm.Status == StatusDeleted && hasReplies[cm.ID]:
+			out = append(out, Comment{ID: cm.ID, Body: cm.Body, ParentID: cm.ParentID, Status: cm.Status, CreatedAt: cm.CreatedAt, UpdatedAt: cm.UpdatedAt})
+		}
+	}
+	sortByTime(out)
+	return out
+}
+
+// Get returns a comment by ID, whatever its status
+func (c *Comments) Get(id string) (Comment, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	cm, ok := c.byID[id]
+	if !ok {
+		return Comment{}, ErrNotFound
+	}
+	return *cm, nil
+}
+
+// Delete lets an author remove their own comment
+func (c *Comments) Delete(id, authorID string, now time.Time) (Comment, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cm, ok := c.byID[id]
+	if !ok || cm.Status == StatusDeleted {
+		return Comment{}, ErrNotFound
+	}
+	if cm.AuthorID != authorID {
+		return Comment{}, ErrForbidden
+	}
+	return c.setStatus(cm, StatusDeleted, now)
+}
+
+// List returns comments with status (all when empty) for moderators,
+// oldest first
+func (c *Comments) List(status string) []Comment {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := []Comment{}
+	for _, cm := range c.byID {
+		if status == "" || cm.Status == status {
+			out = append(out, *cm)
+		}
+	}
+	sortByTime(out)
+	return out
+}
+
+// Moderate sets a comment's status to visible (approve) or hidden and
+// returns it before and after
+func (c *Comments) Moderate(id, status string, now time.Time) (before, after Comment, err error) {
+	if status != StatusVisible && status != StatusHidden {
+		return Comment{}, Comment{}, fmt.Errorf("%w: status must be %s or %s", ErrInvalid, StatusVisible, StatusHidden)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cm, ok := c.byID[id]
+	if !ok || cm.Status == StatusDeleted {
+		return Comment{}, Comment{}, ErrNotFound
+	}
+	before = *cm
+	after, err = c.setStatus(cm, status, now)
+	return before, after, err
+}
+
+// Purge removes a comment for good; replies to it lose their parent
+func (c *Comments) Purge(id string) (*Comment, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cm, ok := c.byID[id]
+	if !ok {
+		return nil, nil
+	}
+	delete(c.byID, id)
+	if err := c.save(); err != nil {
+		c.byID[id] = cm
+		return nil, err
+	}
+	return cm, nil
+}
+
+// setStatus changes and persists cm's status. Callers hold c.mu.
+func (c *Comments) setStatus(cm *Comment, status string, now time.Time) (Comment, error) {
+	prev := *cm
+	cm.Status, cm.UpdatedAt = status, now.UTC()
+	if err := c.save(); err != nil {
+		*cm = prev
+		return Comment{}, err
+	}
+	return *cm, nil
+}
+
+// depth returns how deep cm is nested, top level being 1. Callers hold
+// c.mu.
+func (c *Comments) depth(cm *Comment) int {
+	d := 1
+	for cm.ParentID != "" && d <= MaxDepth {
+		parent, ok := c.byID[cm.ParentID]
+		if !ok {
+			break
+		}
+		cm, d = parent, d+1
+	}
+	return d
+}
+
+// checkRate enforces the per-minute and per-day limits. Callers hold c.mu.
+func (c *Comments) checkRate(authorID string, now time.Time) error {
+	minute, day := 0, 0
+	for _, cm := range c.byID {
+		if cm.AuthorID != authorID {
+			continue
+		}
+		age := now.Sub(cm.CreatedAt)
+		if age < time.Minute {
+			minute++
+		}
+		if age < 24*time.Hour {
+			day++
+		}
+	}
+	switch {
+	case minute >= c.limits.PerMinute:
+		return fmt.Errorf("%w: at most %d per minute", ErrRateLimited, c.limits.PerMinute)
+	case day >= c.limits.PerDay:
+		return fmt.Errorf("%w: at most %d per day", ErrRateLimited, c.limits.PerDay)
+	}
+	return nil
+}
+
+// spam returns the blocked words in text, plus "links" when it has more
+// than MaxLinks URLs
+func (c *Comments) spam(text string) []string {
+	var found []string
+	folded := " " + translit.Fold(text) + " "
+	for w, orig := range c.blocked {
+		if strings.Contains(folded, " "+w+" ") {
+			found = append(found, orig)
+		}
+	}
+	sort.Strings(found)
+	lower := strings.ToLower(text)
+	if strings.Count(lower, "http://")+strings.Count(lower, "https://")+strings.Count(lower, "www.") > c.limits.MaxLinks {
+		found = append(found, "links")
+	}
+	return found
+}
+
+func (c *Comments) save() error {
+	if c.path == "" {
+		return nil
+	}
+	list := make([]*Comment, 0, len(c.byID))
+	for _, cm := range c.byID {
+		list = append(list, cm)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return jsonfile.Save(c.path, list, 0o644)
+}
+
+func sortByTime(list []Comment) {
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.Before(list[j].CreatedAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+}
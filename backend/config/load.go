@@ -0,0 +1,210 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Sources records where each effective value came from, keyed by its
+// dotted YAML path: "default", "file", "env" or "flag"
+type Sources map[string]string
+
+// setting is one leaf field discovered on Config by reflection
+type setting struct {
+	path   string
+	value  reflect.Value
+	env    string
+	flag   string
+	usage  string
+	secret bool
+}
+
+// Load builds the configuration from defaults, the YAML file named by
+// --config or CONFIG_FILE, environment variables and flags in args
+// (typically os.Args[1:]), then validates it.
+func Load(args []string) (*Config, Sources, error) {
+	cfg := Default()
+	settings := collect(&cfg)
+	sources := make(Sources, len(settings))
+	for _, s := range settings {
+		sources[s.path] = "default"
+	}
+
+	fs := flag.NewFlagSet("solar-api", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file")
+	migrate := fs.Bool("migrate", false, "apply pending database migrations and exit")
+	rollback := fs.Bool("rollback", false, "roll back the last applied database migration and exit")
+	flagValues := make(map[string]*string)
+	for _, s := range settings {
+		if s.flag != "" {
+			flagValues[s.flag] = fs.String(s.flag, "", s.usage)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case *migrate && *rollback:
+		return nil, nil, fmt.Errorf("--migrate and --rollback are exclusive")
+	case *migrate:
+		cfg.Task = TaskMigrate
+	case *rollback:
+		cfg.Task = TaskRollback
+	}
+
+	if *configFile != "" {
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read config file: %w", err)
+		}
+		before := snapshot(settings)
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, nil, fmt.Errorf("parse config file %s: %w", *configFile, err)
+		}
+		for i, s := range settings {
+			if !reflect.DeepEqual(before[i], s.value.Interface()) {
+				sources[s.path] = "file"
+			}
+		}
+	}
+
+	for _, s := range settings {
+		if s.env == "" {
+			continue
+		}
+		if v, ok := os.LookupEnv(s.env); ok && v != "" {
+			if err := setFromString(s.value, v); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", s.env, err)
+			}
+			sources[s.path] = "env"
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && flagErr == nil {
+				if err := setFromString(s.value, *flagValues[f.Name]); err != nil {
+					flagErr = fmt.Errorf("--%s: %w", f.Name, err)
+				}
+				sources[s.path] = "flag"
+			}
+		}
+	})
+	if flagErr != nil {
+		return nil, nil, flagErr
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return &cfg, sources, nil
+}
+
+// Effective returns the configuration as a nested map keyed like the YAML
+// file, with secrets masked, for the admin endpoint
+func (c *Config) Effective() map[string]any {
+	out := make(map[string]any)
+	for _, s := range collect(c) {
+		section, key, _ := strings.Cut(s.path, ".")
+		m, ok := out[section].(map[string]any)
+		if !ok {
+			m = make(map[string]any)
+			out[section] = m
+		}
+		v := s.value.Interface()
+		switch {
+		case s.secret && !s.value.IsZero():
+			v = "********"
+		case s.value.Type() == reflect.TypeOf(time.Duration(0)):
+			v = v.(time.Duration).String()
+		}
+		m[key] = v
+	}
+	return out
+}
+
+// collect walks the two-level Config struct and returns its leaf settings
+func collect(cfg *Config) []setting {
+	var out []setting
+	root := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Type().Field(i)
+		sv := root.Field(i)
+		if sv.Kind() != reflect.Struct {
+			continue // not a section, e.g. Task
+		}
+		for j := 0; j < sv.NumField(); j++ {
+			f := sv.Type().Field(j)
+			out = append(out, setting{
+				path:   yamlName(section) + "." + yamlName(f),
+				value:  sv.Field(j),
+				env:    f.Tag.Get("env"),
+				flag:   f.Tag.Get("flag"),
+				usage:  f.Tag.Get("usage"),
+				secret: f.Tag.Get("secret") == "true",
+			})
+		}
+	}
+	return out
+}
+
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
+
+func snapshot(settings []setting) []any {
+	vals := make([]any, len(settings))
+	for i, s := range settings {
+		vals[i] = s.value.Interface()
+	}
+	return vals
+}
+
+// setFromString parses raw into the field according to its kind
+func setFromString(v reflect.Value, raw string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package digest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/events"
+	"solar-system-explorer/backend/i18n"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/translit"
+)
+
+// Locales the digest is written in; others fall back along their i18n
+// chain and finally to Serbian
+var Locales = []string{"sr", "sr-Cyrl", "en"}
+
+// Message is a rendered digest
+type Message struct {
+	Locale  string `json:"locale"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+}
+
+// strings per language; sr-Cyrl is transliterated from sr
+var text = map[string]map[string]string{
+	"sr": {
+		"subject":        "Nebo ove nedelje: %s – %s",
+		"intro":          "Pregled neba za period %s – %s (vremena su u UTC).",
+		"planets":        "Planete na noćnom nebu",
+		"evening":        "večernje nebo",
+		"morning":        "jutarnje nebo",
+		"noPlanets":      "Nijedna planeta nije dovoljno daleko od Sunca da bi se videla.",
+		"events":         "Događaji",
+		"noEvents":       "Ove nedelje nema posebnih događaja.",
+		"zhr":            "do %d meteora na sat",
+		"footer":         "Ovu poruku primate jer ste se prijavili na nedeljni pregled neba.\nOdjava: %s",
+		"confirmSubject": "Potvrdite prijavu na nedeljni pregled neba",
+		"confirmText":    "Da biste primali nedeljni pregled neba, potvrdite prijavu:\n%s\n\nAko se niste prijavili, zanemarite ovu poruku.",
+	},
+	"en": {
+		"subject":        "This week's sky: %s – %s",
+		"intro":          "Sky overview for %s – %s (times are UTC).",
+		"planets":        "Planets in the night sky",
+		"evening":        "evening sky",
+		"morning":        "morning sky",
+		"noPlanets":      "No planet is far enough from the Sun to be seen.",
+		"events":         "Events",
+		"noEvents":       "No notable events this week.",
+		"zhr":            "up to %d meteors an hour",
+		"footer":         "You receive this because you signed up for the Solar System Explorer weekly sky digest.\nUnsubscribe: %s",
+		"confirmSubject": "Confirm your weekly sky digest subscription",
+		"confirmText":    "To receive the weekly sky digest, confirm your subscription:\n%s\n\nIf you didn't sign up, ignore this message.",
+	},
+}
+
+// Locale maps a requested language tag to one the digest is written in
+func Locale(tag string) string {
+	for _, t := range i18n.Chain(i18n.Canonical(tag)) {
+		for _, l := range Locales {
+			if t == l {
+				return l
+			}
+		}
+	}
+	return "sr"
+}
+
+// tr returns the string for key in locale, formatted with args
+func tr(locale, key string, args ...any) string {
+	lang := locale
+	if lang == "sr-Cyrl" {
+		lang = "sr"
+	}
+	s := text[lang][key]
+	if len(args) > 0 {
+		s = fmt.Sprintf(s, args...)
+	}
+	return s
+}
+
+// Compose renders the digest for the week starting at from. unsubscribeURL
+// goes in the footer; it is left out of previews.
+func Compose(locale string, bodies []models.Planet, from time.Time, unsubscribeURL string) Message {
+	locale = Locale(locale)
+	from = from.UTC()
+	to := from.AddDate(0, 0, 7)
+	var b strings.Builder
+
+	b.WriteString(tr(locale, "intro", date(locale, from), date(locale, to)) + "\n\n")
+
+	b.WriteString(tr(locale, "planets") + "\n")
+	visible := events.VisiblePlanets(bodies, from.Add(3*24*time.Hour+12*time.Hour)) // midweek
+	if len(visible) == 0 {
+		b.WriteString("  " + tr(locale, "noPlanets") + "\n")
+	}
+	for _, v := range visible {
+		name := v.NameSR
+		if locale == "en" {
+			name = v.Body
+		}
+		fmt.Fprintf(&b, "  • %s – %s (%.0f°)\n", name, tr(locale, v.Sky), v.Elongation)
+	}
+
+	b.WriteString("\n" + tr(locale, "events") + "\n")
+	upcoming := events.Upcoming(from, to)
+	if len(upcoming) == 0 {
+		b.WriteString("  " + tr(locale, "noEvents") + "\n")
+	}
+	for _, e := range upcoming {
+		line := fmt.Sprintf("  • %s – %s", dateTime(locale, e), eventName(locale, e))
+		if e.ZHR > 0 {
+			line += " (" + tr(locale, "zhr", e.ZHR) + ")"
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if unsubscribeURL != "" {
+		b.WriteString("\n--\n" + tr(locale, "footer", unsubscribeURL) + "\n")
+	}
+	return finish(locale, tr(locale, "subject", date(locale, from), date(locale, to)), b.String())
+}
+
+// confirmation renders the opt-in message with the confirm link
+func confirmation(locale, confirmURL string) Message {
+	return finish(locale, tr(locale, "confirmSubject"), tr(locale, "confirmText", confirmURL)+"\n")
+}
+
+// NewMessage builds a message in one of Locales for other mail sent
+// through Mailer. As with the digest, sr-Cyrl text is written in Latin
+// script and transliterated, leaving links alone.
+func NewMessage(locale, subject, body string) Message {
+	return finish(Locale(locale), subject, body)
+}
+
+// finish transliterates Cyrillic messages
+func finish(locale, subject, body string) Message {
+	if locale == "sr-Cyrl" {
+		subject, body = translit.ToCyrillic(subject), cyrillicKeepURLs(body)
+	}
+	return Message{Locale: locale, Subject: subject, Text: body}
+}
+
+// cyrillicKeepURLs transliterates text but leaves URLs alone
+func cyrillicKeepURLs(s string) string {
+	words := strings.Split(s, " ")
+	for i, w := range words {
+		lines := strings.Split(w, "\n")
+		for j, l := range lines {
+			if !strings.HasPrefix(l, "http://") && !strings.HasPrefix(l, "https://") {
+				lines[j] = translit.ToCyrillic(l)
+			}
+		}
+		words[i] = strings.Join(lines, "\n")
+	}
+	return strings.Join(words, " ")
+}
+
+func eventName(locale string, e events.Event) string {
+	if locale != "en" {
+		if e.Type == events.TypeSeason && e.Body == "Mars" {
+			return "Mars: " + e.NameSR
+		}
+		return e.NameSR
+	}
+	words := strings.Split(e.Name, "_")
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	name := strings.Join(words, " ")
+	if e.Type == events.TypeSeason {
+		name = e.Body + ": " + name
+	}
+	return name
+}
+
+func date(locale string, t time.Time) string {
+	if locale == "en" {
+		return t.Format("2 Jan 2006")
+	}
+	return t.Format("2. 1. 2006.")
+}
+
+// dateTime shows the time of day except for meteor showers, which peak
+// over a whole night
+func dateTime(locale string, e events.Event) string {
+	if e.Type == events.TypeShower {
+		if locale == "en" {
+			return e.Time.Format("Mon 2 Jan")
+		}
+		return e.Time.Format("2. 1.")
+	}
+	if locale == "en" {
+		return e.Time.Format("Mon 2 Jan 15:04")
+	}
+	return e.Time.Format("2. 1. u 15:04")
+}
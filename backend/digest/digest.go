@@ -0,0 +1,109 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/models"
+)
+
+// ErrInvalidEmail is returned by Subscribe for malformed addresses
+var ErrInvalidEmail = errors.New("invalid email address")
+
+// resendGuard keeps a manual trigger right after the scheduled run from
+// mailing everyone twice
+const resendGuard = 24 * time.Hour
+
+// Digest ties subscribers to the mailer
+type Digest struct {
+	subs      *subscribers
+	mailer    *Mailer
+	publicURL string // scheme://host the confirm and unsubscribe links point to
+}
+
+// Open loads the subscribers file at path (empty keeps them in memory)
+func Open(path string, mailer *Mailer, publicURL string) (*Digest, error) {
+	subs, err := openSubscribers(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Digest{subs: subs, mailer: mailer, publicURL: strings.TrimRight(publicURL, "/")}, nil
+}
+
+// Subscribe registers email in the requested language and mails it a
+// confirmation link. Subscribing again resends the link (or, once
+// confirmed, only updates the language) so the response never reveals
+// whether an address is known.
+func (d *Digest) Subscribe(email, locale string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != strings.TrimSpace(email) || len(email) > 254 {
+		return ErrInvalidEmail
+	}
+	sub, err := d.subs.add(addr.Address, Locale(locale))
+	if err != nil {
+		return err
+	}
+	if sub.Confirmed {
+		return nil
+	}
+	return d.mailer.Send(sub.Email, confirmation(sub.Locale, d.link("confirm", sub.Token)), nil)
+}
+
+// Confirm completes the opt-in for token
+func (d *Digest) Confirm(token string) (Subscriber, error) { return d.subs.confirm(token) }
+
+// Unsubscribe forgets the subscriber owning token
+func (d *Digest) Unsubscribe(token string) (Subscriber, error) { return d.subs.remove(token) }
+
+// Counts returns the number of confirmed and pending subscribers
+func (d *Digest) Counts() (confirmed, pending int) { return d.subs.count() }
+
+// Preview renders the digest for locale without an unsubscribe link
+func (d *Digest) Preview(locale string, bodies []models.Planet, now time.Time) Message {
+	return Compose(locale, bodies, now, "")
+}
+
+// Send mails this week's digest to every confirmed subscriber not mailed
+// in the last day. Failures don't stop the run; they are joined into the
+// returned error.
+func (d *Digest) Send(ctx context.Context, bodies []models.Planet, now time.Time) error {
+	var errs []error
+	sent := 0
+	for _, sub := range d.subs.confirmed() {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		if sub.LastSent != nil && now.Sub(*sub.LastSent) < resendGuard {
+			continue
+		}
+		unsubscribe := d.link("unsubscribe", sub.Token)
+		msg := Compose(sub.Locale, bodies, now, unsubscribe)
+		err := d.mailer.Send(sub.Email, msg, map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribe + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		})
+		if err == nil {
+			err = d.subs.markSent(sub.Email, now.UTC())
+			sent++
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	log.Printf("Email digest: sent %d, failed %d", sent, len(errs))
+	if len(errs) > 0 {
+		return fmt.Errorf("digest: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (d *Digest) link(action, token string) string {
+	return d.publicURL + "/api/digest/" + action + "?token=" + url.QueryEscape(token)
+}
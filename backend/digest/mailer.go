@@ -0,0 +1,79 @@
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/internal/random"
+)
+
+// Mailer sends plain-text UTF-8 mail through an SMTP server. net/smtp
+// upgrades to STARTTLS when the server offers it and refuses to send
+// credentials over an unencrypted connection to a remote host.
+type Mailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Send delivers one message. extra headers (List-Unsubscribe) are added
+// as given.
+func (m *Mailer) Send(to string, msg Message, extra map[string]string) error {
+	var buf bytes.Buffer
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return fmt.Errorf("mail: bad sender %q: %w", m.From, err)
+	}
+	headers := map[string]string{
+		"From":                      from.String(),
+		"To":                        to,
+		"Subject":                   mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date":                      time.Now().Format(time.RFC1123Z),
+		"Message-ID":                "<" + random.Hex(16) + "@" + domain(from.Address) + ">",
+		"MIME-Version":              "1.0",
+		"Content-Type":              "text/plain; charset=utf-8",
+		"Content-Transfer-Encoding": "quoted-printable",
+		"Content-Language":          msg.Locale,
+	}
+	for k, v := range extra {
+		headers[k] = v
+	}
+	for _, k := range []string{"From", "To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type", "Content-Transfer-Encoding", "Content-Language"} {
+		fmt.Fprintf(&buf, "%s: %s\r\n", k, headers[k])
+		delete(headers, k)
+	}
+	for k, v := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", k, v)
+	}
+	buf.WriteString("\r\n")
+	qp := quotedprintable.NewWriter(&buf)
+	qp.Write([]byte(strings.ReplaceAll(msg.Text, "\n", "\r\n")))
+	qp.Close()
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	if err := smtp.SendMail(addr, auth, from.Address, []string{to}, buf.Bytes()); err != nil {
+		return fmt.Errorf("mail: sending to %s: %w", to, err)
+	}
+	return nil
+}
+
+func domain(addr string) string {
+	if _, d, ok := strings.Cut(addr, "@"); ok {
+		return d
+	}
+	return "localhost"
+}
@@ -0,0 +1,188 @@
+// Package digest runs the opt-in weekly email digest: subscribers confirm
+// their address, receive a localized summary of the coming week's sky
+// (visible planets, Moon phases, meteor showers, seasons) and can leave
+// through the unsubscribe link in every message.
+package digest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+	"solar-system-explorer/backend/internal/random"
+)
+
+// ErrUnknownToken is returned for confirm and unsubscribe tokens that
+// don't belong to a subscriber
+var ErrUnknownToken = errors.New("unknown token")
+
+// Subscriber is one email address. Token authorizes the confirm and
+// unsubscribe links sent to it, so it never leaves the server otherwise.
+type Subscriber struct {
+	Email     string     `json:"email"`
+	Locale    string     `json:"locale"`
+	Token     string     `json:"token"`
+	Confirmed bool       `json:"confirmed"`
+	CreatedAt time.Time  `json:"created_at"`
+	LastSent  *time.Time `json:"last_sent,omitempty"`
+}
+
+// subscribers holds subscribers by lowercased email, persisted to a JSON
+// file when a path is set
+type subscribers struct {
+	mu   sync.Mutex
+	path string
+	byID map[string]*Subscriber
+}
+
+func openSubscribers(path string) (*subscribers, error) {
+	s := &subscribers{path: path, byID: make(map[string]*Subscriber)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*Subscriber
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	for _, sub := range list {
+		s.byID[strings.ToLower(sub.Email)] = sub
+	}
+	return s, nil
+}
+
+// add registers email, or updates the locale of an existing subscriber,
+// and returns a copy
+func (s *subscribers) add(email, locale string) (Subscriber, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strings.ToLower(email)
+	sub, ok := s.byID[key]
+	if !ok {
+		sub = &Subscriber{Email: email, Token: random.Hex(24), CreatedAt: time.Now().UTC()}
+		s.byID[key] = sub
+	}
+	prev := *sub
+	sub.Locale = locale
+	if err := s.save(); err != nil {
+		if ok {
+			*sub = prev
+		} else {
+			delete(s.byID, key)
+		}
+		return Subscriber{}, err
+	}
+	return *sub, nil
+}
+
+// confirm marks the token's subscriber as confirmed
+func (s *subscribers) confirm(token string) (Subscriber, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub := s.byToken(token)
+	if sub == nil {
+		return Subscriber{}, ErrUnknownToken
+	}
+	if !sub.Confirmed {
+		sub.Confirmed = true
+		if err := s.save(); err != nil {
+			sub.Confirmed = false
+			return Subscriber{}, err
+		}
+	}
+	return *sub, nil
+}
+
+// remove deletes the token's subscriber
+func (s *subscribers) remove(token string) (Subscriber, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub := s.byToken(token)
+	if sub == nil {
+		return Subscriber{}, ErrUnknownToken
+	}
+	key := strings.ToLower(sub.Email)
+	delete(s.byID, key)
+	if err := s.save(); err != nil {
+		s.byID[key] = sub
+		return Subscriber{}, err
+	}
+	return *sub, nil
+}
+
+// confirmed returns copies of the confirmed subscribers
+func (s *subscribers) confirmed() []Subscriber {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Subscriber
+	for _, sub := range s.byID {
+		if sub.Confirmed {
+			out = append(out, *sub)
+		}
+	}
+	return out
+}
+
+// markSent records a delivered digest
+func (s *subscribers) markSent(email string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sub, ok := s.byID[strings.ToLower(email)]; ok {
+		sub.LastSent = &at
+	}
+	return s.save()
+}
+
+// count returns the number of confirmed and pending subscribers
+func (s *subscribers) count() (confirmed, pending int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.byID {
+		if sub.Confirmed {
+			confirmed++
+		} else {
+			pending++
+		}
+	}
+	return confirmed, pending
+}
+
+// byToken finds a subscriber by token. Callers hold s.mu.
+func (s *subscribers) byToken(token string) *Subscriber {
+	if token == "" {
+		return nil
+	}
+	for _, sub := range s.byID {
+		if sub.Token == token {
+			return sub
+		}
+	}
+	return nil
+}
+
+// save writes all subscribers via a temp file and rename. Callers hold
+// s.mu.
+func (s *subscribers) save() error {
+	if s.path == "" {
+		return nil
+	}
+	list := make([]*Subscriber, 0, len(s.byID))
+	for _, sub := range s.byID {
+		list = append(list, sub)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return jsonfile.Save(s.path, list, 0o600) // addresses and tokens
+}
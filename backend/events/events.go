@@ -0,0 +1,84 @@
+// Package events lists upcoming astronomical events — Earth's and Mars's
+// equinoxes and solstices, the Moon's principal phases and meteor shower
+// peaks — and which planets are in the night sky, for notifications,
+// calendars and digests.
+package events
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/astro"
+)
+
+// Event types
+const (
+	TypeSeason    = "season"
+	TypeMoonPhase = "moon_phase"
+	TypeShower    = "meteor_shower"
+)
+
+// Event is one dated occurrence. ID is stable across calls, so callers can
+// remember which events they have already handled.
+type Event struct {
+	ID     string    `json:"id"`
+	Type   string    `json:"type"`
+	Name   string    `json:"name"`
+	NameSR string    `json:"name_sr"`
+	Body   string    `json:"body"`
+	Time   time.Time `json:"time"`
+	ZHR    int       `json:"zhr,omitempty"` // meteor showers: peak rate per hour
+}
+
+// Upcoming returns the events in [from, to), in time order
+func Upcoming(from, to time.Time) []Event {
+	var out []Event
+	for year := from.UTC().Year(); year <= to.UTC().Year(); year++ {
+		for _, s := range astro.EarthSeasons(year) {
+			out = append(out, season("Earth", s))
+		}
+		for _, s := range astro.MarsSeasons(year) {
+			out = append(out, season("Mars", s))
+		}
+	}
+	out = append(out, showers(from, to)...)
+	for _, p := range astro.MoonPhases(from, to) {
+		out = append(out, Event{
+			ID:     id(TypeMoonPhase, p.Phase, p.Time),
+			Type:   TypeMoonPhase,
+			Name:   p.Phase,
+			NameSR: p.PhaseSR,
+			Body:   "Moon",
+			Time:   p.Time,
+		})
+	}
+
+	n := 0
+	for _, e := range out {
+		if !e.Time.Before(from) && e.Time.Before(to) {
+			out[n] = e
+			n++
+		}
+	}
+	out = out[:n]
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out
+}
+
+func season(body string, s astro.SeasonEvent) Event {
+	return Event{
+		ID:     id(TypeSeason, strings.ToLower(body)+"_"+s.Type, s.Time),
+		Type:   TypeSeason,
+		Name:   s.Type,
+		NameSR: s.NameSR,
+		Body:   body,
+		Time:   s.Time,
+	}
+}
+
+// id builds "moon_phase:full_moon:2025-09-07"; two events of one kind
+// never fall on the same day
+func id(typ, name string, t time.Time) string {
+	return typ + ":" + name + ":" + t.UTC().Format("2006-01-02")
+}
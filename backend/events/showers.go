@@ -0,0 +1,43 @@
+package events
+
+import "time"
+
+// meteorShowers are the major annual showers (IMO calendar). Peaks drift
+// by a day or so between years; the date given is the usual peak night.
+var meteorShowers = []struct {
+	name, nameSR string
+	month        time.Month
+	day, zhr     int
+}{
+	{"quadrantids", "Kvadrantidi", time.January, 3, 110},
+	{"lyrids", "Liridi", time.April, 22, 18},
+	{"eta_aquariids", "Eta Akvaridi", time.May, 6, 50},
+	{"southern_delta_aquariids", "Južni delta Akvaridi", time.July, 30, 25},
+	{"perseids", "Perseidi", time.August, 12, 100},
+	{"draconids", "Drakonidi", time.October, 8, 10},
+	{"orionids", "Orionidi", time.October, 21, 20},
+	{"leonids", "Leonidi", time.November, 17, 15},
+	{"geminids", "Geminidi", time.December, 14, 150},
+	{"ursids", "Ursidi", time.December, 22, 10},
+}
+
+// showers returns the shower peaks in [from, to), timed at midnight UTC
+// of the peak night's date
+func showers(from, to time.Time) []Event {
+	var out []Event
+	for year := from.UTC().Year(); year <= to.UTC().Year(); year++ {
+		for _, m := range meteorShowers {
+			t := time.Date(year, m.month, m.day, 0, 0, 0, 0, time.UTC)
+			out = append(out, Event{
+				ID:     id(TypeShower, m.name, t),
+				Type:   TypeShower,
+				Name:   m.name,
+				NameSR: m.nameSR,
+				Body:   "Earth",
+				Time:   t,
+				ZHR:    m.zhr,
+			})
+		}
+	}
+	return out
+}
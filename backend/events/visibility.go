@@ -0,0 +1,60 @@
+package events
+
+import (
+	"math"
+	"time"
+
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/orbits"
+)
+
+// minElongation is how far from the Sun a planet must be to stand out of
+// the twilight
+const minElongation = 15.0
+
+// nakedEye are the planets visible without a telescope
+var nakedEye = map[string]bool{"Mercury": true, "Venus": true, "Mars": true, "Jupiter": true, "Saturn": true}
+
+// Visibility describes where a planet can be seen
+type Visibility struct {
+	Body       string  `json:"body"`
+	NameSR     string  `json:"name_sr"`
+	Elongation float64 `json:"elongation"` // degrees from the Sun
+	Sky        string  `json:"sky"`        // "evening" (east of the Sun) or "morning"
+}
+
+// VisiblePlanets returns the naked-eye planets far enough from the Sun to
+// be seen at t, from the heliocentric positions of the bodies and Earth
+func VisiblePlanets(bodies []models.Planet, t time.Time) []Visibility {
+	var earth *models.Planet
+	for i := range bodies {
+		if bodies[i].Name == "Earth" {
+			earth = &bodies[i]
+		}
+	}
+	if earth == nil {
+		return nil
+	}
+	e := orbits.Compute(*earth, t)
+
+	var out []Visibility
+	for _, b := range bodies {
+		if !nakedEye[b.Name] {
+			continue
+		}
+		p := orbits.Compute(b, t)
+		gx, gy, gz := p.X-e.X, p.Y-e.Y, p.Z-e.Z // Earth → planet
+		sx, sy, sz := -e.X, -e.Y, -e.Z          // Earth → Sun
+		cos := (gx*sx + gy*sy + gz*sz) / (math.Sqrt(gx*gx+gy*gy+gz*gz) * math.Sqrt(sx*sx+sy*sy+sz*sz))
+		elong := math.Acos(math.Max(-1, math.Min(1, cos))) * 180 / math.Pi
+		if elong < minElongation {
+			continue
+		}
+		sky := "morning"
+		if sx*gy-sy*gx > 0 { // planet counterclockwise (east) of the Sun
+			sky = "evening"
+		}
+		out = append(out, Visibility{Body: b.Name, NameSR: b.NameSR, Elongation: math.Round(elong*10) / 10, Sky: sky})
+	}
+	return out
+}
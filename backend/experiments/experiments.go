@@ -0,0 +1,245 @@
+// Package experiments assigns visitors to the variants of A/B experiments
+// and records who saw which variant and who reached its goals. A visitor
+// (a signed-in user's ID, otherwise a random cookie) always lands in the
+// same variant: assignment hashes the visitor with the experiment name,
+// so nothing needs to be stored for it and each experiment splits
+// visitors independently of the others.
+package experiments
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/analytics"
+)
+
+// ErrUnknown is returned for an experiment or goal that isn't configured
+var ErrUnknown = errors.New("unknown experiment or goal")
+
+// Variant is one arm of an experiment, chosen for Weight out of the
+// total of its experiment's weights
+type Variant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+// Experiment is a test of variants against the goals they should improve
+type Experiment struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Variants    []Variant `json:"variants"` // the first is the control
+	Goals       []string  `json:"goals"`
+	// Inactive experiments assign everyone the control and record nothing
+	Inactive bool `json:"inactive,omitempty"`
+}
+
+// Defaults are the experiments run without an experiments file
+func Defaults() []Experiment {
+	return []Experiment{{
+		Name:        "onboarding_3d",
+		Description: "Guided 3D onboarding flow instead of the static welcome panel",
+		Variants:    []Variant{{Name: "control", Weight: 50}, {Name: "3d", Weight: 50}},
+		Goals:       []string{"onboarding_complete", "tour_start"},
+	}}
+}
+
+// Load reads the experiments in the JSON file at path, or returns the
+// defaults for an empty path
+func Load(path string) ([]Experiment, error) {
+	if path == "" {
+		return Defaults(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var list []Experiment
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if err := check(list); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return list, nil
+}
+
+func check(list []Experiment) error {
+	names := map[string]bool{}
+	for _, e := range list {
+		if e.Name == "" || names[e.Name] {
+			return fmt.Errorf("experiment %q: names must be unique and not empty", e.Name)
+		}
+		names[e.Name] = true
+		if len(e.Variants) < 2 {
+			return fmt.Errorf("experiment %q: needs at least two variants", e.Name)
+		}
+		variants := map[string]bool{}
+		for _, v := range e.Variants {
+			if v.Name == "" || variants[v.Name] || v.Weight < 1 {
+				return fmt.Errorf("experiment %q: variant %q needs a unique name and a weight of at least 1", e.Name, v.Name)
+			}
+			variants[v.Name] = true
+		}
+		if len(e.Goals) == 0 {
+			return fmt.Errorf("experiment %q: needs at least one goal", e.Name)
+		}
+	}
+	return nil
+}
+
+// Assign returns the variant of e for the visitor unit
+func (e Experiment) Assign(unit string) string {
+	if e.Inactive {
+		return e.Variants[0].Name
+	}
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+	sum := sha256.Sum256([]byte(e.Name + "\x00" + unit))
+	n := int(binary.BigEndian.Uint64(sum[:8]) % uint64(total))
+	for _, v := range e.Variants {
+		if n < v.Weight {
+			return v.Name
+		}
+		n -= v.Weight
+	}
+	return e.Variants[0].Name // unreachable
+}
+
+// Service assigns visitors and records exposures and conversions as
+// analytics events, where they fall under the same anonymity and
+// retention as the rest
+type Service struct {
+	list []Experiment
+	col  *analytics.Collector
+
+	mu       sync.Mutex
+	exposed  map[string]bool // unit and experiment exposed today
+	exposedD time.Time
+}
+
+// exposedMax caps the exposures remembered in a day, so a flood can't
+// grow memory; beyond it repeated exposures are recorded again
+const exposedMax = 100_000
+
+// New runs the experiments in list, recording to col
+func New(list []Experiment, col *analytics.Collector) *Service {
+	return &Service{list: list, col: col, exposed: make(map[string]bool)}
+}
+
+// List returns the configured experiments
+func (s *Service) List() []Experiment { return s.list }
+
+// Assignments returns the variant of every experiment for unit
+func (s *Service) Assignments(unit string) map[string]string {
+	out := make(map[string]string, len(s.list))
+	for _, e := range s.list {
+		out[e.Name] = e.Assign(unit)
+	}
+	return out
+}
+
+// Expose records that unit was shown its variants, once a day per
+// active experiment
+func (s *Service) Expose(ctx context.Context, unit string, now time.Time) error {
+	var events []analytics.Event
+	s.mu.Lock()
+	if day := now.UTC().Truncate(24 * time.Hour); !s.exposedD.Equal(day) {
+		clear(s.exposed)
+		s.exposedD = day
+	}
+	for _, e := range s.list {
+		key := e.Name + "\x00" + unit
+		if e.Inactive || s.exposed[key] {
+			continue
+		}
+		if len(s.exposed) < exposedMax {
+			s.exposed[key] = true
+		}
+		events = append(events, analytics.Event{Type: analytics.TypeExposure, Experiment: e.Name, Variant: e.Assign(unit)})
+	}
+	s.mu.Unlock()
+	if len(events) == 0 {
+		return nil
+	}
+	return s.col.Record(ctx, unit, events, now)
+}
+
+// Convert records that unit reached goal of the named experiment and
+// returns its variant
+func (s *Service) Convert(ctx context.Context, unit, name, goal string, now time.Time) (string, error) {
+	i := slices.IndexFunc(s.list, func(e Experiment) bool { return e.Name == name })
+	if i < 0 || !slices.Contains(s.list[i].Goals, goal) {
+		return "", ErrUnknown
+	}
+	e := s.list[i]
+	variant := e.Assign(unit)
+	if e.Inactive {
+		return variant, nil
+	}
+	ev := analytics.Event{Type: analytics.TypeConversion, Experiment: name, Variant: variant, Goal: goal}
+	return variant, s.col.Record(ctx, unit, []analytics.Event{ev}, now)
+}
+
+// VariantResult is how a variant did: visitors exposed and, per goal,
+// how many of them converted. Visitors count once per day (see package
+// analytics).
+type VariantResult struct {
+	Name        string             `json:"name"`
+	Exposures   int                `json:"exposures"`
+	Conversions map[string]int     `json:"conversions"`
+	Rates       map[string]float64 `json:"rates"` // conversions per exposure
+}
+
+// Result is how an experiment did
+type Result struct {
+	Name     string          `json:"name"`
+	Inactive bool            `json:"inactive,omitempty"`
+	Variants []VariantResult `json:"variants"`
+}
+
+// Results tallies the exposure and conversion events among events
+func (s *Service) Results(events []analytics.Event) []Result {
+	type key struct{ experiment, variant, goal, visitor string }
+	seen := map[key]bool{}
+	count := map[key]int{}
+	for _, ev := range events {
+		if ev.Type != analytics.TypeExposure && ev.Type != analytics.TypeConversion {
+			continue
+		}
+		k := key{ev.Experiment, ev.Variant, ev.Goal, ev.Visitor + ev.At.Format(time.DateOnly)}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		k.visitor = ""
+		count[k]++
+	}
+	out := make([]Result, 0, len(s.list))
+	for _, e := range s.list {
+		r := Result{Name: e.Name, Inactive: e.Inactive}
+		for _, v := range e.Variants {
+			vr := VariantResult{Name: v.Name, Exposures: count[key{e.Name, v.Name, "", ""}], Conversions: map[string]int{}, Rates: map[string]float64{}}
+			for _, g := range e.Goals {
+				vr.Conversions[g] = count[key{e.Name, v.Name, g, ""}]
+				if vr.Exposures > 0 {
+					vr.Rates[g] = math.Round(float64(vr.Conversions[g])/float64(vr.Exposures)*1000) / 1000
+				}
+			}
+			r.Variants = append(r.Variants, vr)
+		}
+		out = append(out, r)
+	}
+	return out
+}
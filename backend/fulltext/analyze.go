@@ -0,0 +1,118 @@
+package fulltext
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"solar-system-explorer/backend/translit"
+)
+
+// Languages with an analyzer; text in any other is analyzed as English
+const (
+	LangSR = "sr"
+	LangEN = "en"
+)
+
+// Token is a term and where the word it came from sits in the text
+type Token struct {
+	Term       string
+	Start, End int // byte offsets into the analyzed text
+}
+
+// Analyze splits text into words and reduces each to the term it is
+// indexed under: folded to lowercase ASCII (Cyrillic transliterated,
+// diacritics stripped), stop words dropped and common inflections cut off
+// by a light stemmer for the language
+func Analyze(lang, text string) []Token {
+	var out []Token
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		if term := normalize(lang, text[start:end]); term != "" {
+			out = append(out, Token{Term: term, Start: start, End: end})
+		}
+		start = -1
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+	return out
+}
+
+// normalize turns one word into its term, empty for a stop word
+func normalize(lang, word string) string {
+	w := strings.ReplaceAll(translit.Fold(word), " ", "")
+	if w == "" {
+		return ""
+	}
+	if lang == LangSR {
+		if stopSR[w] {
+			return ""
+		}
+		return stemSR(w)
+	}
+	if stopEN[w] {
+		return ""
+	}
+	return stemEN(w)
+}
+
+// suffixesSR are Serbian case and number endings, longest first
+var suffixesSR = []string{
+	"ovima", "evima",
+	"ama", "ima", "ove", "eve", "ova", "eva",
+	"om", "em", "og", "oj", "ih", "im",
+	"a", "e", "i", "o", "u",
+}
+
+// stemSR cuts the longest case ending that leaves a stem of three letters
+// or more, so "planete", "planetom" and "planetama" all index as "planet"
+func stemSR(w string) string {
+	for _, s := range suffixesSR {
+		if strings.HasSuffix(w, s) && utf8.RuneCountInString(w)-len(s) >= 3 {
+			return w[:len(w)-len(s)]
+		}
+	}
+	return w
+}
+
+// stemEN cuts plural and verb endings: "moons" → "moon", "volcanoes" →
+// "volcano", "orbiting" → "orbit"
+func stemEN(w string) string {
+	n := len(w)
+	switch {
+	case n > 4 && strings.HasSuffix(w, "ies"):
+		return w[:n-3] + "y"
+	case n > 4 && (strings.HasSuffix(w, "sses") || strings.HasSuffix(w, "shes") || strings.HasSuffix(w, "ches") || strings.HasSuffix(w, "xes") || strings.HasSuffix(w, "oes")):
+		return w[:n-2]
+	case n > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") && !strings.HasSuffix(w, "us") && !strings.HasSuffix(w, "is"):
+		return w[:n-1]
+	case n > 5 && strings.HasSuffix(w, "ing"):
+		return w[:n-3]
+	case n > 4 && strings.HasSuffix(w, "ed"):
+		return w[:n-2]
+	}
+	return w
+}
+
+var stopSR = words("a ako ali bi bio bila bilo da do dok duz gde i iz ili im ja je jer jos ka kad kao koja koje koji kroz li na nad ne ni niti o od oko pa po pod pri sa se si su sve ta te ti to u uz vec za zbog")
+
+var stopEN = words("a about an and are as at be been but by for from has have in into is it its no not of on or so than that the their there these they this to was were which while with")
+
+func words(s string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
@@ -0,0 +1,298 @@
+// Package fulltext is a small in-memory inverted index for the text the
+// site shows: descriptions, narration, feature names. Words are analyzed
+// per language (analyze.go), hits are ranked with BM25 weighted by field,
+// and matched words are highlighted in a snippet of each field.
+//
+// The dataset is a few hundred documents, so the index is rebuilt whole
+// when it changes rather than updated in place.
+package fulltext
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+)
+
+// BM25 parameters: term frequency saturation and length normalization
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Field is one searchable text of a document
+type Field struct {
+	Name  string  // reported in highlights, e.g. "description"
+	Lang  string  // LangSR or LangEN
+	Text  string  // plain text
+	Boost float64 // weight of a match here; 0 counts as 1
+}
+
+// Doc is a searchable document. Ref is the caller's, returned with hits.
+type Doc struct {
+	Ref    any
+	Fields []Field
+}
+
+// Hit is a matching document with its score and, per matching field, a
+// snippet of HTML-escaped text with the matched words in <mark>
+type Hit struct {
+	Ref        any
+	Score      float64
+	Highlights map[string]string
+}
+
+type posting struct {
+	doc, field int
+	tf         int
+}
+
+// Index is built once by Build and safe for concurrent searches
+type Index struct {
+	docs     []Doc
+	tokens   [][][]Token // doc, field
+	postings map[string][]posting
+	terms    []string           // sorted, for prefix expansion
+	avgLen   map[string]float64 // per field name
+}
+
+// Build analyzes and indexes docs
+func Build(docs []Doc) *Index {
+	ix := &Index{
+		docs:     docs,
+		tokens:   make([][][]Token, len(docs)),
+		postings: make(map[string][]posting),
+		avgLen:   make(map[string]float64),
+	}
+	count := make(map[string]int)
+	for d, doc := range docs {
+		ix.tokens[d] = make([][]Token, len(doc.Fields))
+		for f, field := range doc.Fields {
+			toks := Analyze(field.Lang, field.Text)
+			ix.tokens[d][f] = toks
+			ix.avgLen[field.Name] += float64(len(toks))
+			count[field.Name]++
+			tf := make(map[string]int)
+			for _, t := range toks {
+				tf[t.Term]++
+			}
+			for term, n := range tf {
+				ix.postings[term] = append(ix.postings[term], posting{doc: d, field: f, tf: n})
+			}
+		}
+	}
+	for name, n := range count {
+		ix.avgLen[name] /= float64(n)
+	}
+	ix.terms = make([]string, 0, len(ix.postings))
+	for term := range ix.postings {
+		ix.terms = append(ix.terms, term)
+	}
+	sort.Strings(ix.terms)
+	return ix
+}
+
+// Len returns the number of documents
+func (ix *Index) Len() int { return len(ix.docs) }
+
+// queryTerm is an analyzed query word for one language; prefix terms
+// stand for the last word while it is still being typed
+type queryTerm struct {
+	word   int // position in the query
+	term   string
+	weight float64
+}
+
+// Search returns the documents matching q, best first, at most limit (0
+// for all). The query is analyzed in both languages and each field is
+// matched with the terms of its own; the last word also matches as a
+// prefix, so results come up while typing, and a word that isn't indexed
+// matches the terms within MaxEdits typos of it. Documents matching more
+// of the query's words rank higher.
+func (ix *Index) Search(q string, limit int) []Hit {
+	queries := make(map[string][]queryTerm, 2)
+	words := 0
+	for _, lang := range []string{LangSR, LangEN} {
+		toks := Analyze(lang, q)
+		words = max(words, len(toks))
+		var qt []queryTerm
+		for i, t := range toks {
+			qt = append(qt, queryTerm{word: i, term: t.Term, weight: 1})
+			if _, known := ix.postings[t.Term]; !known {
+				for _, term := range ix.near(t.Term) {
+					qt = append(qt, queryTerm{word: i, term: term, weight: 0.6})
+				}
+			}
+			if i == len(toks)-1 && len(t.Term) >= 3 {
+				for _, term := range ix.withPrefix(t.Term) {
+					if term != t.Term {
+						qt = append(qt, queryTerm{word: i, term: term, weight: 0.5})
+					}
+				}
+			}
+		}
+		queries[lang] = qt
+	}
+	if words == 0 {
+		return nil
+	}
+
+	type acc struct {
+		score   float64
+		words   map[int]bool
+		matched map[int]map[string]bool // field → terms
+	}
+	found := make(map[int]*acc)
+	n := float64(len(ix.docs))
+	for _, lang := range []string{LangSR, LangEN} {
+		for _, qt := range queries[lang] {
+			list := ix.postings[qt.term]
+			if len(list) == 0 {
+				continue
+			}
+			df := make(map[int]bool)
+			for _, p := range list {
+				df[p.doc] = true
+			}
+			idf := math.Log(1 + (n-float64(len(df))+0.5)/(float64(len(df))+0.5))
+			for _, p := range list {
+				field := ix.docs[p.doc].Fields[p.field]
+				if fieldLang(field.Lang) != lang {
+					continue
+				}
+				boost := field.Boost
+				if boost == 0 {
+					boost = 1
+				}
+				l := float64(len(ix.tokens[p.doc][p.field]))
+				tf := float64(p.tf)
+				s := idf * tf * (k1 + 1) / (tf + k1*(1-b+b*l/ix.avgLen[field.Name])) * boost * qt.weight
+				a := found[p.doc]
+				if a == nil {
+					a = &acc{words: make(map[int]bool), matched: make(map[int]map[string]bool)}
+					found[p.doc] = a
+				}
+				a.score += s
+				a.words[qt.word] = true
+				if a.matched[p.field] == nil {
+					a.matched[p.field] = make(map[string]bool)
+				}
+				a.matched[p.field][qt.term] = true
+			}
+		}
+	}
+
+	order := make([]int, 0, len(found))
+	for d := range found {
+		order = append(order, d)
+	}
+	sort.Ints(order) // ties keep document order
+	hits := make([]Hit, 0, len(found))
+	for _, d := range order {
+		a := found[d]
+		h := Hit{
+			Ref:        ix.docs[d].Ref,
+			Score:      math.Round(a.score*float64(len(a.words))/float64(words)*1000) / 1000,
+			Highlights: make(map[string]string, len(a.matched)),
+		}
+		for f, terms := range a.matched {
+			field := ix.docs[d].Fields[f]
+			h.Highlights[field.Name] = highlight(field.Text, ix.tokens[d][f], terms)
+		}
+		hits = append(hits, h)
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// near returns the indexed terms within MaxEdits typos of term
+func (ix *Index) near(term string) []string {
+	edits := MaxEdits(len(term))
+	if edits == 0 {
+		return nil
+	}
+	var out []string
+	for _, t := range ix.terms {
+		if d := len(t) - len(term); d <= edits && d >= -edits && Distance(term, t) <= edits {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// withPrefix returns the indexed terms starting with prefix
+func (ix *Index) withPrefix(prefix string) []string {
+	i := sort.SearchStrings(ix.terms, prefix)
+	var out []string
+	for ; i < len(ix.terms) && strings.HasPrefix(ix.terms[i], prefix); i++ {
+		out = append(out, ix.terms[i])
+	}
+	return out
+}
+
+func fieldLang(lang string) string {
+	if lang == LangSR {
+		return LangSR
+	}
+	return LangEN
+}
+
+// snippetBytes is about how much text a highlight shows
+const snippetBytes = 180
+
+// highlight cuts a snippet of text around the first matched word, HTML
+// escapes it and wraps the matched words in <mark>
+func highlight(text string, toks []Token, terms map[string]bool) string {
+	first := -1
+	for i, t := range toks {
+		if terms[t.Term] {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+	from, to := 0, len(text)
+	if len(text) > snippetBytes {
+		from = max(0, toks[first].Start-snippetBytes/3)
+		to = min(len(text), from+snippetBytes)
+		// Snap to word boundaries
+		for _, t := range toks {
+			if t.Start >= from {
+				from = t.Start
+				break
+			}
+		}
+		for i := len(toks) - 1; i >= 0; i-- {
+			if toks[i].End <= to {
+				to = toks[i].End
+				break
+			}
+		}
+		to = max(to, from)
+	}
+	var sb strings.Builder
+	if from > 0 {
+		sb.WriteString("…")
+	}
+	pos := from
+	for _, t := range toks {
+		if t.Start < from || t.End > to || !terms[t.Term] {
+			continue
+		}
+		sb.WriteString(html.EscapeString(text[pos:t.Start]))
+		sb.WriteString("<mark>")
+		sb.WriteString(html.EscapeString(text[t.Start:t.End]))
+		sb.WriteString("</mark>")
+		pos = t.End
+	}
+	sb.WriteString(html.EscapeString(text[pos:to]))
+	if to < len(text) {
+		sb.WriteString("…")
+	}
+	return sb.String()
+}
@@ -0,0 +1,173 @@
+// Package grs tracks the Great Red Spot's longitude in Jupiter's System II
+// and predicts when it crosses the central meridian. The spot drifts
+// against System II by a degree or two a month, so its longitude is a
+// measurement observers publish (JUPOS, the BAA) and staff keep current
+// through the admin API; in between it is extrapolated by its drift.
+package grs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/astro"
+	"solar-system-explorer/backend/internal/jsonfile"
+)
+
+// ErrInvalid wraps validation failures from Set. The message after it
+// starts with the JSON path of the field at fault.
+var ErrInvalid = errors.New("invalid Great Red Spot tracking")
+
+// Tracking is the spot's measured longitude and drift
+type Tracking struct {
+	Longitude float64   `json:"longitude"` // System II, degrees, at Epoch
+	Epoch     time.Time `json:"epoch"`
+	Drift     float64   `json:"drift"` // degrees/day; observers quote ~1.5–2° a month
+	Source    string    `json:"source,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// Default is the tracking a new file starts with, until staff enter a
+// current measurement
+var Default = Tracking{
+	Longitude: 55,
+	Epoch:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	Drift:     0.06,
+	Source:    "JUPOS, early 2024",
+}
+
+// LongitudeAt returns the spot's System II longitude at t
+func (tr Tracking) LongitudeAt(t time.Time) float64 {
+	l := math.Mod(tr.Longitude+tr.Drift*t.Sub(tr.Epoch).Hours()/24, 360)
+	if l < 0 {
+		l += 360
+	}
+	return l
+}
+
+// Age is how long ago the longitude was measured, as of t. Predictions
+// degrade with it: the drift itself wanders.
+func (tr Tracking) Age(t time.Time) time.Duration {
+	return t.Sub(tr.Epoch)
+}
+
+// Tracker holds the current tracking, persisted to a JSON file when a path
+// is set
+type Tracker struct {
+	mu   sync.RWMutex
+	path string
+	cur  Tracking
+}
+
+// Open loads the tracking file at path, which may not exist yet. An empty
+// path keeps the tracking in memory only.
+func Open(path string) (*Tracker, error) {
+	t := &Tracker{path: path, cur: Default}
+	if path == "" {
+		return t, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &t.cur); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return t, nil
+}
+
+// Get returns the current tracking
+func (t *Tracker) Get() Tracking {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.cur
+}
+
+// Set validates and stores a new measurement, returning the one it
+// replaced
+func (t *Tracker) Set(tr Tracking) (Tracking, error) {
+	switch {
+	case tr.Longitude < 0 || tr.Longitude >= 360:
+		return tr, fmt.Errorf("%w: longitude must be at least 0 and below 360", ErrInvalid)
+	case tr.Epoch.IsZero():
+		return tr, fmt.Errorf("%w: epoch is required", ErrInvalid)
+	case math.Abs(tr.Drift) > 1:
+		return tr, fmt.Errorf("%w: drift must be within ±1 degree a day", ErrInvalid)
+	case len(tr.Source) > 200:
+		return tr, fmt.Errorf("%w: source must be at most 200 characters", ErrInvalid)
+	}
+	tr.Epoch = tr.Epoch.UTC()
+	tr.UpdatedAt = time.Now().UTC()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prev := t.cur
+	t.cur = tr
+	if err := t.save(); err != nil {
+		t.cur = prev
+		return prev, err
+	}
+	return prev, nil
+}
+
+// save writes the file. Callers hold t.mu.
+func (t *Tracker) save() error {
+	if t.path == "" {
+		return nil
+	}
+	return jsonfile.Save(t.path, t.cur, 0o644)
+}
+
+// transitStep is how often the central meridian is sampled while looking
+// for transits; the spot crosses once per rotation, every 9 h 56 min
+const transitStep = 20 * time.Minute
+
+// Transits returns the instants in [from, to) when the spot, per tr, is on
+// the central meridian of Jupiter's disc as seen from the Earth, to the
+// second
+func Transits(tr Tracking, from, to time.Time) []time.Time {
+	// How far the central meridian is past the spot, in (−180, 180]
+	offset := func(t time.Time) float64 {
+		_, cm := astro.JupiterCentralMeridian(astro.JulianDay(t))
+		d := math.Mod(cm-tr.LongitudeAt(t), 360)
+		switch {
+		case d > 180:
+			d -= 360
+		case d <= -180:
+			d += 360
+		}
+		return d
+	}
+	var out []time.Time
+	prev := offset(from)
+	for a := from; a.Before(to); a = a.Add(transitStep) {
+		b := a.Add(transitStep)
+		next := offset(b)
+		// The meridian's longitude grows ~12° per step, so a crossing is a
+		// small negative turning non-negative
+		if prev < 0 && next >= 0 && next-prev < 90 {
+			lo, hi := a, b
+			for hi.Sub(lo) > time.Second {
+				mid := lo.Add(hi.Sub(lo) / 2)
+				if offset(mid) < 0 {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			if t := hi.Round(time.Second); !t.Before(from) && t.Before(to) {
+				out = append(out, t)
+			}
+		}
+		prev = next
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/accountmail"
+	"solar-system-explorer/backend/middleware"
+	"solar-system-explorer/backend/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sendVerification issues a verification token for user and mails it in
+// the request's language
+func sendVerification(c *gin.Context, tokens *users.EmailTokens, sender *accountmail.Sender, user users.User) error {
+	token, err := tokens.Issue(user.ID, users.PurposeVerify, time.Now())
+	if err != nil {
+		return err
+	}
+	return sender.Verify(user.Email, user.Name, c.GetHeader("Accept-Language"), token)
+}
+
+// SendVerification mails the signed-in user a new link confirming their
+// email address. sender is nil when mail isn't configured.
+func SendVerification(us *users.Users, tokens *users.EmailTokens, sender *accountmail.Sender) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if sender == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Email is not enabled"})
+			return
+		}
+		claims, _ := middleware.CurrentUser(c)
+		user, err := us.Get(claims.Subject)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+			return
+		}
+		if user.EmailVerified {
+			c.JSON(http.StatusConflict, gin.H{"error": "Email address is already confirmed"})
+			return
+		}
+		err = sendVerification(c, tokens, sender, user)
+		switch {
+		case errors.Is(err, users.ErrTooSoon):
+			c.Header("Retry-After", "60")
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "A link was sent moments ago; check your inbox"})
+		case err != nil:
+			log.Printf("accountmail: verify: %v", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Could not send the confirmation email"})
+		default:
+			c.JSON(http.StatusAccepted, gin.H{"data": gin.H{"status": "verification_sent"}})
+		}
+	}
+}
+
+// VerifyEmail confirms the address from the emailed link (?token=)
+func VerifyEmail(us *users.Users, tokens *users.EmailTokens) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := tokens.Consume(c.Query("token"), users.PurposeVerify, time.Now())
+		if errors.Is(err, users.ErrToken) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown or expired link"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		user, err := us.MarkVerified(userID)
+		switch {
+		case errors.Is(err, users.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusOK, gin.H{"data": user})
+		}
+	}
+}
+
+// ForgotPassword mails a password reset link: {"email", "lang"}. The
+// answer is the same whether or not an account has the address, and the
+// mail goes out after responding so timing doesn't tell either.
+func ForgotPassword(us *users.Users, tokens *users.EmailTokens, sender *accountmail.Sender) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if sender == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Email is not enabled"})
+			return
+		}
+		var req struct {
+			Email string `json:"email" binding:"required"`
+			Lang  string `json:"lang"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		if req.Lang == "" {
+			req.Lang = c.GetHeader("Accept-Language")
+		}
+		c.JSON(http.StatusAccepted, gin.H{"data": gin.H{"status": "reset_sent"}})
+
+		user, err := us.ByEmail(req.Email)
+		if err != nil {
+			return
+		}
+		go func() {
+			token, err := tokens.Issue(user.ID, users.PurposeReset, time.Now())
+			if errors.Is(err, users.ErrTooSoon) {
+				return
+			}
+			if err == nil {
+				err = sender.Reset(user.Email, user.Name, req.Lang, token)
+			}
+			if err != nil {
+				log.Printf("accountmail: reset: %v", err)
+			}
+		}()
+	}
+}
+
+// ResetPassword sets a new password with the emailed token: {"token",
+// "password"}. It signs the account out everywhere, and since the link
+// reached the inbox, also confirms the address.
+func ResetPassword(us *users.Users, tokens *users.EmailTokens, sessions *users.Sessions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Token string `json:"token" binding:"required"`
+			// Checked here so a too short password doesn't use up the link
+			Password string `json:"password" binding:"required,min=10,max=72"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		userID, err := tokens.Consume(req.Token, users.PurposeReset, time.Now())
+		if errors.Is(err, users.ErrToken) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown or expired link"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if _, err := us.SetPassword(userID, req.Password); err != nil {
+			if errors.Is(err, users.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		user, err := us.MarkVerified(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		n, err := sessions.Logins().RevokeAll(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"user": user, "revoked": n}})
+	}
+}
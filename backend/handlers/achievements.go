@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/achievements"
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/middleware"
+	"solar-system-explorer/backend/progress"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// badgeView is a badge in the reader's locale, with whether they have it
+type badgeView struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Icon        string     `json:"icon,omitempty"`
+	Earned      bool       `json:"earned"`
+	EarnedAt    *time.Time `json:"earned_at,omitempty"`
+}
+
+// GetAchievements lists every badge for the signed-in user, earned ones
+// with the time they were earned. Rules are checked again first, so
+// badges defined since the user's last progress count straight away.
+func GetAchievements(ach *achievements.Achievements, ps *progress.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := middleware.CurrentUser(c)
+		if _, err := ach.Evaluate(ps.Get(user.Subject), time.Now()); err != nil {
+			log.Printf("achievements: %s: %v", user.Subject, err)
+		}
+		earned := ach.Earned(user.Subject)
+		requested, chain := requestLocales(c)
+		list := ach.List()
+		out := make([]badgeView, len(list))
+		for i, b := range list {
+			out[i] = badgeView{ID: b.ID, Icon: b.Icon}
+			out[i].Title, _ = localText(b.Title, chain)
+			out[i].Description, _ = localText(b.Description, chain)
+			if at, ok := earned[b.ID]; ok {
+				out[i].Earned, out[i].EarnedAt = true, &at
+			}
+		}
+		c.Header("Cache-Control", "private, no-cache")
+		c.JSON(http.StatusOK, gin.H{
+			"data":  out,
+			"count": len(out),
+			"meta":  gin.H{"earned": len(earned), "requested": requested, "fallbacks": chain},
+		})
+	}
+}
+
+// ListBadges returns the badges with their rules and all their text
+func ListBadges(ach *achievements.Achievements) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list := ach.List()
+		c.JSON(http.StatusOK, gin.H{"data": list, "count": len(list)})
+	}
+}
+
+// PutBadge creates or replaces a badge: {"title": {"sr": …, "en": …},
+// "description", "icon", "rule": {"bodies", "tours", "min_bodies",
+// "min_tours"}}
+func PutBadge(st *store.Store, tours *store.Tours, ach *achievements.Achievements, auditLog *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var b achievements.Badge
+		if !bindJSON(c, &b) {
+			return
+		}
+		b.ID = c.Param("id")
+		var ok bool
+		if b.Title, ok = canonicalText(b.Title); !ok {
+			invalid(c, FieldError{Field: "title", Message: "has an invalid locale"})
+			return
+		}
+		if b.Description, ok = canonicalText(b.Description); !ok {
+			invalid(c, FieldError{Field: "description", Message: "has an invalid locale"})
+			return
+		}
+		for i, name := range b.Rule.Bodies {
+			if b.Rule.Bodies[i], ok = surfaceBody(c, st, name); !ok {
+				invalid(c, FieldError{Field: fmt.Sprintf("rule.bodies[%d]", i), Message: "is not a known body: " + name})
+				return
+			}
+		}
+		for i, id := range b.Rule.Tours {
+			if _, ok := tours.Get(id); !ok {
+				invalid(c, FieldError{Field: fmt.Sprintf("rule.tours[%d]", i), Message: "is not a known tour: " + id})
+				return
+			}
+		}
+
+		stored, prev, err := ach.Put(b)
+		switch {
+		case errors.Is(err, achievements.ErrInvalid):
+			invalid(c, fieldErrors(achievements.ErrInvalid, err)...)
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			action, status := audit.ActionCreate, http.StatusCreated
+			if prev != nil {
+				action, status = audit.ActionUpdate, http.StatusOK
+			}
+			recordAudit(c, auditLog, action, "badge", stored.ID, prev, stored)
+			c.JSON(status, gin.H{"data": stored})
+		}
+	}
+}
+
+// DeleteBadge removes a badge, also from the users who earned it
+func DeleteBadge(ach *achievements.Achievements, auditLog *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deleted, err := ach.Delete(c.Param("id"))
+		switch {
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		case deleted == nil:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Badge not found"})
+		default:
+			recordAudit(c, auditLog, audit.ActionDelete, "badge", deleted.ID, deleted, nil)
+			c.Status(http.StatusNoContent)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/config"
+	"solar-system-explorer/backend/middleware"
+	"solar-system-explorer/backend/upstream"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAdminConfig shows the effective configuration (secrets masked) and
+// where each value came from
+func GetAdminConfig(cfg *config.Config, sources config.Sources) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"config":  cfg.Effective(),
+				"sources": sources,
+			},
+		})
+	}
+}
+
+// GetModes shows whether maintenance or read-only mode is on
+func GetModes(modes *middleware.Modes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": modes.State()})
+	}
+}
+
+// SetModes switches maintenance and read-only mode, for instance around a
+// dataset migration. The switch lasts until the next one or a restart,
+// which goes back to the configured modes.
+func SetModes(modes *middleware.Modes, auditLog *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Maintenance *bool   `json:"maintenance" binding:"required"`
+			ReadOnly    *bool   `json:"read_only" binding:"required"`
+			Message     *string `json:"message" binding:"omitempty,max=500"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		before := modes.State()
+		next := middleware.ModeState{Maintenance: *req.Maintenance, ReadOnly: *req.ReadOnly}
+		if req.Message != nil {
+			next.Message = *req.Message
+		}
+		after := modes.Set(next, time.Now())
+		recordAudit(c, auditLog, "update", "modes", "service", before, after)
+		c.JSON(http.StatusOK, gin.H{"data": after})
+	}
+}
+
+// GetUpstreams shows, per external API, the circuit state and the counts
+// of calls, failures, retries and calls failed fast
+func GetUpstreams(c *gin.Context) {
+	list := upstream.All()
+	c.JSON(http.StatusOK, gin.H{"data": list, "count": len(list)})
+}
+
+// GetResponseCache shows the response cache's counters
+func GetResponseCache(rc *middleware.ResponseCache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rc == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Response cache is disabled"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": rc.Stats()})
+	}
+}
+
+// PurgeResponseCache drops every cached response, for changes the data
+// layer doesn't announce
+func PurgeResponseCache(rc *middleware.ResponseCache, auditLog *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rc == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Response cache is disabled"})
+			return
+		}
+		rc.Invalidate()
+		recordAudit(c, auditLog, "delete", "response_cache", "*", nil, nil)
+		c.Status(http.StatusNoContent)
+	}
+}
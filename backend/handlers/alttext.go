@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/physics"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/translit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Kinds of alt-text section
+const (
+	altAppearance = "appearance"
+	altOrbit      = "orbit"
+)
+
+// AltText is the long description of a body for screen-reader users, in
+// the place of the 3D view
+type AltText struct {
+	Name        string           `json:"name"` // English name
+	DisplayName string           `json:"display_name"`
+	Sections    []AltTextSection `json:"sections"`
+}
+
+// AltTextSection is one part of an AltText, resolved along the locale
+// chain on its own so a locale may describe just the appearance
+type AltTextSection struct {
+	Kind   string `json:"kind"` // appearance or orbit
+	Text   string `json:"text"`
+	Locale string `json:"locale"`
+	// Generated text is written from the orbital data, for English and
+	// Serbian, until a translator writes one
+	Generated bool `json:"generated,omitempty"`
+}
+
+// GetPlanetAltText describes a body (or one of the scene's moons) in
+// words: what it looks like and how it moves, in the locale negotiated
+// from ?lang= or Accept-Language. Text is edited as the "appearance" and
+// "orbit" translation fields; sections no locale in the chain has are
+// left out.
+func GetPlanetAltText(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requested, chain := requestLocales(c)
+		ctx := c.Request.Context()
+		var (
+			body     models.Planet
+			generate func(lang string) string
+		)
+		if p, ok := findPlanet(ctx, st, c.Param("name")); ok {
+			body = p
+			generate = func(lang string) string { return orbitText(p, lang) }
+		} else if m, ok := findMoon(c.Param("name")); ok {
+			body = models.Planet{Name: m.Name, NameSR: m.NameSR, Description: m.Description, Translations: m.Translations}
+			parent, _ := findPlanet(ctx, st, m.Parent)
+			generate = func(lang string) string { return moonOrbitText(m, bodyText(parent, lang).Name, lang) }
+		} else {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+
+		out := AltText{Name: body.Name, DisplayName: localize(body, chain).DisplayName, Sections: []AltTextSection{}}
+		for _, tag := range chain {
+			if text := bodyText(body, tag).Appearance; text != "" {
+				out.Sections = append(out.Sections, AltTextSection{Kind: altAppearance, Text: text, Locale: tag})
+				break
+			}
+		}
+		for _, tag := range chain {
+			if text := bodyText(body, tag).Orbit; text != "" {
+				out.Sections = append(out.Sections, AltTextSection{Kind: altOrbit, Text: text, Locale: tag})
+				break
+			}
+			if text := generatedText(generate, tag); text != "" {
+				out.Sections = append(out.Sections, AltTextSection{Kind: altOrbit, Text: text, Locale: tag, Generated: true})
+				break
+			}
+		}
+
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.JSON(http.StatusOK, gin.H{
+			"data": out,
+			"meta": gin.H{"requested": requested, "fallbacks": chain},
+		})
+	}
+}
+
+// generatedText runs generate for the language of tag, if it writes that
+// language: English, or Serbian in either script
+func generatedText(generate func(lang string) string, tag string) string {
+	switch tag {
+	case "en":
+		return generate("en")
+	case baseLocale, "sr-Latn":
+		return generate(baseLocale)
+	case "sr-Cyrl":
+		return translit.ToCyrillic(generate(baseLocale))
+	}
+	return ""
+}
+
+// orbitText describes a body's orbit and spin in lang, "en" or "sr", or
+// returns "" for the Sun and bodies without orbital data
+func orbitText(p models.Planet, lang string) string {
+	if p.IsStar || p.DistanceFromSun <= 0 || p.OrbitalPeriod <= 0 {
+		return ""
+	}
+	name := bodyText(p, lang).Name
+	au := spell(p.DistanceFromSun, 2, lang)
+	km := spell(p.DistanceFromSun*physics.AU/1e9, 0, lang)
+	var b strings.Builder
+	if lang == "en" {
+		fmt.Fprintf(&b, "%s circles the Sun at an average distance of %s AU, about %s million km, taking %s for one orbit. ", name, au, km, span(p.OrbitalPeriod, lang))
+		shape := "elongated"
+		switch {
+		case p.Eccentricity < 0.02:
+			shape = "nearly circular"
+		case p.Eccentricity < 0.1:
+			shape = "slightly elongated"
+		}
+		if p.Inclination < 0.01 {
+			fmt.Fprintf(&b, "The orbit is %s (eccentricity %s) and defines the ecliptic, the plane the other orbits are measured from.", shape, spell(p.Eccentricity, 3, lang))
+		} else {
+			fmt.Fprintf(&b, "The orbit is %s (eccentricity %s) and tilted %s° to the ecliptic, the plane of Earth's orbit.", shape, spell(p.Eccentricity, 3, lang), spell(p.Inclination, 1, lang))
+		}
+		if p.RotationPeriod != 0 {
+			fmt.Fprintf(&b, " It turns once on its axis every %s", span(math.Abs(p.RotationPeriod), lang))
+			if p.RotationPeriod < 0 {
+				b.WriteString(", backwards compared with most planets")
+			}
+			fmt.Fprintf(&b, ", and its axis is tilted %s°.", spell(p.AxialTilt, 1, lang))
+		}
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%s obilazi Sunce na prosečnoj udaljenosti od %s AJ, oko %s miliona km, a jedan obilazak traje %s. ", name, au, km, span(p.OrbitalPeriod, lang))
+	shape := "izdužena"
+	switch {
+	case p.Eccentricity < 0.02:
+		shape = "gotovo kružna"
+	case p.Eccentricity < 0.1:
+		shape = "blago izdužena"
+	}
+	if p.Inclination < 0.01 {
+		fmt.Fprintf(&b, "Putanja je %s (ekscentricitet %s) i određuje ekliptiku, ravan od koje se mere nagibi ostalih putanja.", shape, spell(p.Eccentricity, 3, lang))
+	} else {
+		fmt.Fprintf(&b, "Putanja je %s (ekscentricitet %s) i nagnuta %s° prema ekliptici, ravni Zemljine putanje.", shape, spell(p.Eccentricity, 3, lang), spell(p.Inclination, 1, lang))
+	}
+	if p.RotationPeriod != 0 {
+		fmt.Fprintf(&b, " Oko svoje ose se okrene za %s", span(math.Abs(p.RotationPeriod), lang))
+		if p.RotationPeriod < 0 {
+			b.WriteString(", u smeru suprotnom od većine planeta")
+		}
+		fmt.Fprintf(&b, ", a nagib ose je %s°.", spell(p.AxialTilt, 1, lang))
+	}
+	return b.String()
+}
+
+// moonOrbitText describes the orbit of moon m around the planet named
+// parent in lang, "en" or "sr"
+func moonOrbitText(m models.Moon, parent, lang string) string {
+	if parent == "" {
+		parent = m.Parent
+	}
+	name := bodyText(models.Planet{Name: m.Name, NameSR: m.NameSR, Translations: m.Translations}, lang).Name
+	km := spell(m.SemiMajorAxis, 0, lang)
+	if lang == "en" {
+		text := fmt.Sprintf("%s circles %s at an average distance of %s km from its centre, taking %s for one orbit", name, parent, km, span(math.Abs(m.OrbitalPeriod), lang))
+		if m.OrbitalPeriod < 0 {
+			text += ", in the opposite direction to the planet's rotation"
+		}
+		return text + "."
+	}
+	text := fmt.Sprintf("%s obilazi planetu %s na prosečnoj udaljenosti od %s km od njenog središta, a jedan obilazak traje %s", name, parent, km, span(math.Abs(m.OrbitalPeriod), lang))
+	if m.OrbitalPeriod < 0 {
+		text += ", u smeru suprotnom od rotacije planete"
+	}
+	return text + "."
+}
+
+// span reads a duration in Earth days as hours, days or years, whichever
+// gives the friendliest number
+func span(days float64, lang string) string {
+	hours, daysUnit, yearsUnit := "hours", "Earth days", "Earth years"
+	if lang != "en" {
+		hours, daysUnit, yearsUnit = "sati", "dana", "godina"
+	}
+	switch {
+	case days < 2:
+		return spell(days*24, 1, lang) + " " + hours
+	case days < 1000:
+		return spell(days, 1, lang) + " " + daysUnit
+	}
+	return spell(days/365.25, 1, lang) + " " + yearsUnit
+}
+
+// spell formats v with places decimals the way lang writes numbers:
+// 1,234.5 in English, 1.234,5 in Serbian. A trailing ".0" is dropped.
+func spell(v float64, places int, lang string) string {
+	s := strconv.FormatFloat(v, 'f', places, 64)
+	s = strings.TrimSuffix(s, ".0")
+	whole, frac, _ := strings.Cut(s, ".")
+	sign := ""
+	if strings.HasPrefix(whole, "-") {
+		sign, whole = "-", whole[1:]
+	}
+	group, point := ",", "."
+	if lang != "en" {
+		group, point = ".", ","
+	}
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + group + whole[i:]
+	}
+	if frac != "" {
+		return sign + whole + point + frac
+	}
+	return sign + whole
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/analytics"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// analyticsEvent is one event as the frontend reports it
+type analyticsEvent struct {
+	Type   string    `json:"type" binding:"required,oneof=page_view body_view tour_start tour_complete quiz_complete search"`
+	At     time.Time `json:"at"`
+	Path   string    `json:"path" binding:"max=200"`
+	Body   string    `json:"body" binding:"max=100"`
+	Tour   string    `json:"tour" binding:"max=100"`
+	Quiz   string    `json:"quiz" binding:"max=100"`
+	Query  string    `json:"query" binding:"max=100"`
+	Passed *bool     `json:"passed"`
+	Locale string    `json:"locale" binding:"omitempty,oneof=sr en"`
+}
+
+// PostAnalyticsEvents stores a batch of up to 50 anonymous usage events.
+// Nothing identifying the visitor is kept (see package analytics); the
+// query string is cut from paths and bodies are resolved to their English
+// names, unknown ones dropped. Visitors who send Do Not Track or Global
+// Privacy Control get 204 and nothing is stored.
+func PostAnalyticsEvents(st *store.Store, col *analytics.Collector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Events []analyticsEvent `json:"events" binding:"required,min=1,max=50,dive"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		if !tracking(c) {
+			c.Status(http.StatusNoContent)
+			return
+		}
+
+		events := make([]analytics.Event, len(req.Events))
+		for i, e := range req.Events {
+			path, _, _ := strings.Cut(e.Path, "?")
+			path, _, _ = strings.Cut(path, "#")
+			body, _ := surfaceBody(c, st, e.Body)
+			events[i] = analytics.Event{
+				Type:   e.Type,
+				At:     e.At,
+				Path:   path,
+				Body:   body,
+				Tour:   e.Tour,
+				Quiz:   e.Quiz,
+				Query:  strings.ToLower(strings.TrimSpace(e.Query)),
+				Passed: e.Passed,
+				Locale: e.Locale,
+			}
+		}
+		if err := col.Record(c.Request.Context(), c.ClientIP()+"\x00"+c.Request.UserAgent(), events, time.Now()); err != nil {
+			log.Printf("analytics: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Events could not be stored"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"data": gin.H{"accepted": len(events)}})
+	}
+}
+
+// GetAnalytics aggregates the usage events between ?from= and ?to=
+// (default the last 7 days): events by type, daily visitors, the most
+// viewed bodies and pages, top searches, tour completion and quiz pass
+// rates. ?top= caps each ranking (default 10, max 100).
+func GetAnalytics(col *analytics.Collector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			From time.Time `form:"from"`
+			To   time.Time `form:"to"`
+			Top  int       `form:"top" binding:"omitempty,min=1,max=100"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		if req.To.IsZero() {
+			req.To = time.Now().UTC()
+		}
+		if req.From.IsZero() {
+			req.From = req.To.Add(-7 * 24 * time.Hour)
+		}
+		if !req.From.Before(req.To) {
+			invalid(c, FieldError{Field: "from", Message: "must be before to"})
+			return
+		}
+		if req.Top == 0 {
+			req.Top = 10
+		}
+
+		events, err := col.Query(c.Request.Context(), req.From, req.To)
+		if err != nil {
+			log.Printf("analytics: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Events could not be read"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"data": analytics.Summarize(events, req.From, req.To, req.Top),
+			"meta": gin.H{"retention": col.Retention().String()},
+		})
+	}
+}
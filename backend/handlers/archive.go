@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/archive"
+	"solar-system-explorer/backend/assets"
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/i18n"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxArchiveSize caps an uploaded archive
+const maxArchiveSize = 256 << 20
+
+// ExportArchive downloads the content managed through the API as a signed
+// archive (see package archive): bodies as loaded and imported, all
+// translations, tours and the asset manifest
+func ExportArchive(st *store.Store, tr *store.Translations, tours *store.Tours, assetStore *assets.Store, key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key == "" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Archives are not enabled"})
+			return
+		}
+		contents := archive.Contents{
+			Bodies:       st.Sources(),
+			Translations: tr.List("", ""),
+			Tours:        tours.List(),
+			Assets:       assetStore.List(),
+		}
+		var buf bytes.Buffer
+		m, err := archive.Write(&buf, []byte(key), contents, st.ETag(), time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		name := "solar-system-explorer-" + m.CreatedAt.Format("20060102T150405Z") + ".tar.gz"
+		c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+		c.Data(http.StatusOK, "application/gzip", buf.Bytes())
+	}
+}
+
+// importCounts tallies what an import does, or would do in a dry run, to
+// one kind of content
+type importCounts struct {
+	Create    int `json:"create"`
+	Update    int `json:"update"`
+	Unchanged int `json:"unchanged"`
+}
+
+func (n *importCounts) add(action string) {
+	switch action {
+	case audit.ActionCreate:
+		n.Create++
+	case audit.ActionUpdate:
+		n.Update++
+	default:
+		n.Unchanged++
+	}
+}
+
+// importChange is one item an import creates or updates
+type importChange struct {
+	action, resource, key string
+	before, after         any
+}
+
+// ImportArchive applies an archive made by ExportArchive, sent as the
+// request body. Archived bodies, translations and tours are merged into
+// the current ones: items in the archive are created or replaced, others
+// are kept. Everything is validated before anything changes, bodies as
+// validation.Check does at boot, and if persisting one part fails the
+// parts already applied are restored. The asset manifest isn't applied,
+// since the files travel with the asset directory; assets it lists that
+// this server lacks or has in another size are reported. ?dry_run=true
+// returns the report without applying.
+func ImportArchive(st *store.Store, tr *store.Translations, tours *store.Tours, assetStore *assets.Store, auditLog *audit.Log, imports store.BodyRepository, key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key == "" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Archives are not enabled"})
+			return
+		}
+		var q struct {
+			DryRun bool `form:"dry_run"`
+		}
+		if !bindQuery(c, &q) {
+			return
+		}
+		body := http.MaxBytesReader(c.Writer, c.Request.Body, maxArchiveSize)
+		m, contents, err := archive.Read(body, []byte(key))
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Archive is larger than %d MiB", maxArchiveSize>>20)})
+			return
+		case errors.Is(err, archive.ErrSignature):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Archive was not signed with this server's archive key"})
+			return
+		case err != nil:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+
+		var problems []FieldError
+		var changes []importChange
+		var counts struct {
+			Bodies       importCounts `json:"bodies"`
+			Translations importCounts `json:"translations"`
+			Tours        importCounts `json:"tours"`
+		}
+
+		sources := st.Sources()
+		names := make(map[string]bool, len(sources)+len(contents.Bodies))
+		current := make(map[string]models.Planet, len(sources))
+		for _, b := range sources {
+			names[strings.ToLower(b.Name)] = true
+			current[strings.ToLower(b.Name)] = b
+		}
+		for _, moon := range models.GetMoons() {
+			names[strings.ToLower(moon.Name)] = true
+		}
+		var bodies []models.Planet
+		seen := make(map[string]bool, len(contents.Bodies))
+		changedAt := make(map[string]int, len(contents.Bodies)) // index in the archive
+
+		for i, b := range contents.Bodies {
+			field := fmt.Sprintf("bodies[%d]", i)
+			k := strings.ToLower(b.Name)
+			switch {
+			case b.Name == "":
+				problems = append(problems, FieldError{Field: field, Message: "name is required"})
+				continue
+			case seen[k]:
+				problems = append(problems, FieldError{Field: field, Message: b.Name + " appears twice"})
+				continue
+			}
+			seen[k], names[k] = true, true
+			prev, exists := current[k]
+			action := audit.ActionCreate
+			if exists {
+				action = audit.ActionUpdate
+				if diff, _ := audit.Diff(prev, b); len(diff) == 0 {
+					action = "unchanged"
+				}
+			}
+			counts.Bodies.add(action)
+			if action != "unchanged" {
+				bodies = append(bodies, b)
+				changedAt[k] = i
+				change := importChange{action: action, resource: "body", key: b.Name, after: b}
+				if exists {
+					change.before = prev
+				}
+				changes = append(changes, change)
+			}
+		}
+
+		// The dataset as the import would leave it must pass the checks it
+		// passes at boot; errors in the bodies it changes are the archive's
+		if len(bodies) > 0 {
+			merged := make([]models.Planet, 0, len(sources)+len(bodies))
+			for _, b := range sources {
+				if _, ok := changedAt[strings.ToLower(b.Name)]; !ok {
+					merged = append(merged, b)
+				}
+			}
+			merged = append(merged, bodies...)
+			for _, is := range validation.Check(merged, time.Now()).Issues {
+				i, ok := changedAt[strings.ToLower(is.Body)]
+				if ok && is.Severity == validation.SeverityError {
+					problems = append(problems, FieldError{Field: fmt.Sprintf("bodies[%d].%s", i, is.Field), Message: is.Message})
+				}
+			}
+		}
+
+		prevTranslations := tr.List("", "")
+		entries := make(map[string]store.TranslationEntry, len(prevTranslations)+len(contents.Translations))
+		for _, e := range prevTranslations {
+			entries[translationKey(e)] = e
+		}
+		for i, e := range contents.Translations {
+			field := fmt.Sprintf("translations[%d]", i)
+			if err := store.ValidateTranslation(e); err != nil {
+				problems = append(problems, FieldError{Field: field, Message: err.Error()})
+				continue
+			}
+			if !names[strings.ToLower(e.Body)] {
+				problems = append(problems, FieldError{Field: field, Message: "unknown body " + e.Body})
+				continue
+			}
+			if i18n.Canonical(e.Locale) != e.Locale {
+				problems = append(problems, FieldError{Field: field, Message: "invalid locale " + e.Locale})
+				continue
+			}
+			k := translationKey(e)
+			prev, exists := entries[k]
+			action := audit.ActionCreate
+			if exists {
+				action = audit.ActionUpdate
+				if prev.Text == e.Text && prev.Status == e.Status {
+					action = "unchanged"
+				}
+			}
+			counts.Translations.add(action)
+			if action != "unchanged" {
+				entries[k] = e
+				change := importChange{action: action, resource: "translation", key: k, after: e}
+				if exists {
+					change.before = prev
+				}
+				changes = append(changes, change)
+			}
+		}
+
+		prevTours := tours.List()
+		byID := make(map[string]models.Tour, len(prevTours)+len(contents.Tours))
+		for _, t := range prevTours {
+			byID[t.ID] = t
+		}
+		for i, t := range contents.Tours {
+			field := fmt.Sprintf("tours[%d]", i)
+			if err := store.ValidateTour(t); err != nil {
+				problems = append(problems, FieldError{Field: field, Message: err.Error()})
+				continue
+			}
+			unknown := ""
+			for _, s := range t.Steps {
+				if !names[strings.ToLower(s.Body)] {
+					unknown = s.Body
+					break
+				}
+			}
+			if unknown != "" {
+				problems = append(problems, FieldError{Field: field, Message: "unknown body " + unknown})
+				continue
+			}
+			prev, exists := byID[t.ID]
+			action := audit.ActionCreate
+			if exists {
+				action = audit.ActionUpdate
+				cmp := t
+				cmp.UpdatedAt = prev.UpdatedAt
+				if diff, _ := audit.Diff(prev, cmp); len(diff) == 0 {
+					action = "unchanged"
+				}
+			}
+			counts.Tours.add(action)
+			if action != "unchanged" {
+				byID[t.ID] = t
+				change := importChange{action: action, resource: "tour", key: t.ID, after: t}
+				if exists {
+					change.before = prev
+				}
+				changes = append(changes, change)
+			}
+		}
+
+		if len(problems) > 0 {
+			invalid(c, problems...)
+			return
+		}
+
+		missing, differ := []string{}, []string{}
+		for _, a := range contents.Assets {
+			local, ok := assetStore.Get(a.Path)
+			switch {
+			case !ok:
+				missing = append(missing, a.Path)
+			case local.Size != a.Size:
+				differ = append(differ, a.Path)
+			}
+		}
+
+		applied := false
+		if !q.DryRun && len(changes) > 0 {
+			if err := applyArchive(c.Request.Context(), st, tr, tours, imports, bodies, entries, byID, prevTranslations, prevTours); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Import was rolled back: " + err.Error()})
+				return
+			}
+			for _, ch := range changes {
+				recordAudit(c, auditLog, ch.action, ch.resource, ch.key, ch.before, ch.after)
+			}
+			applied = true
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"manifest":     m,
+				"bodies":       counts.Bodies,
+				"translations": counts.Translations,
+				"tours":        counts.Tours,
+				"assets":       gin.H{"missing": missing, "size_differs": differ},
+			},
+			"dry_run": q.DryRun,
+			"applied": applied,
+		})
+	}
+}
+
+// applyArchive installs validated import results: translations, then
+// tours, then bodies. When a step fails to persist, the earlier ones are
+// put back as they were.
+func applyArchive(ctx context.Context, st *store.Store, tr *store.Translations, tours *store.Tours, imports store.BodyRepository,
+	bodies []models.Planet, entries map[string]store.TranslationEntry, byID map[string]models.Tour,
+	prevTranslations []store.TranslationEntry, prevTours []models.Tour) error {
+	nextTranslations := make([]store.TranslationEntry, 0, len(entries))
+	for _, e := range entries {
+		nextTranslations = append(nextTranslations, e)
+	}
+	if err := tr.Replace(nextTranslations); err != nil {
+		return err
+	}
+	nextTours := make([]models.Tour, 0, len(byID))
+	for _, t := range byID {
+		nextTours = append(nextTours, t)
+	}
+	if err := tours.Replace(nextTours); err != nil {
+		restore(tr.Replace(prevTranslations), "translations")
+		return err
+	}
+	if imports != nil && len(bodies) > 0 {
+		if err := imports.Save(ctx, bodies); err != nil {
+			restore(tours.Replace(prevTours), "tours")
+			restore(tr.Replace(prevTranslations), "translations")
+			return err
+		}
+	}
+	st.Upsert(bodies...)
+	return nil
+}
+
+// restore logs a failure to undo part of an import
+func restore(err error, what string) {
+	if err != nil {
+		log.Printf("archive import: restoring %s: %v", what, err)
+	}
+}
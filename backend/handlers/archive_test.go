@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/archive"
+	"solar-system-explorer/backend/assets"
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testArchiveKey = "archive-test-key"
+
+// failingRepository refuses to save, like a database that went away
+// halfway through an import
+type failingRepository struct{}
+
+func (failingRepository) Load(context.Context) ([]models.Planet, error) { return nil, nil }
+func (failingRepository) Save(context.Context, []models.Planet) error {
+	return errors.New("database is down")
+}
+func (failingRepository) Close() error { return nil }
+
+// newArchiveServer routes export and import over the built-in bodies,
+// saving imported bodies to imports
+func newArchiveServer(t *testing.T, imports store.BodyRepository) (*gin.Engine, *store.Store, *store.Translations, *store.Tours) {
+	t.Helper()
+	st := store.New(models.GetSolarSystemBodies())
+	tr, err := store.OpenTranslations("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tours, err := store.OpenTours("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, assets.ManifestFile), []byte("[]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	as, err := assets.Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditLog, err := audit.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/admin/export", ExportArchive(st, tr, tours, as, testArchiveKey))
+	r.POST("/api/admin/import", ImportArchive(st, tr, tours, as, auditLog, imports, testArchiveKey))
+	return r, st, tr, tours
+}
+
+// editedArchive is an archive that changes Mars's mass and adds a German
+// name for it
+func editedArchive(t *testing.T, key string) []byte {
+	t.Helper()
+	bodies := models.GetSolarSystemBodies()
+	mars, _ := findBody(bodies, "Mars")
+	mars.Mass *= 2
+	contents := archive.Contents{
+		Bodies:       []models.Planet{mars},
+		Translations: []store.TranslationEntry{{Body: "Mars", Locale: "de", Field: "name", Text: "Mars (de)", Status: store.StatusPublished}},
+	}
+	var buf bytes.Buffer
+	if _, err := archive.Write(&buf, []byte(key), contents, "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// gzipped marks a request body as an archive
+var gzipped = map[string]string{"Content-Type": "application/gzip"}
+
+// marsMass is Mars's mass as the store serves it
+func marsMass(t *testing.T, st *store.Store) float64 {
+	t.Helper()
+	mars, ok := findBody(st.Bodies(), "Mars")
+	if !ok {
+		t.Fatal("Mars is gone")
+	}
+	return mars.Mass
+}
+
+func TestArchiveExportImport(t *testing.T) {
+	r, st, tr, _ := newArchiveServer(t, nil)
+	before := marsMass(t, st)
+
+	export := testutil.Do(r, http.MethodGet, "/api/admin/export", nil)
+	if export.Code != http.StatusOK {
+		t.Fatalf("export = %d: %s", export.Code, export.Body)
+	}
+	w := testutil.Send(r, http.MethodPost, "/api/admin/import", gzipped, export.Body.String())
+	var resp struct {
+		Data struct {
+			Bodies importCounts `json:"bodies"`
+		} `json:"data"`
+		Applied bool `json:"applied"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("re-import = %d: %s", w.Code, w.Body)
+	}
+	if resp.Data.Bodies.Create+resp.Data.Bodies.Update != 0 || resp.Applied {
+		t.Errorf("re-importing an export changed something: %s", w.Body)
+	}
+
+	if w := testutil.Send(r, http.MethodPost, "/api/admin/import?dry_run=true", gzipped, string(editedArchive(t, testArchiveKey))); w.Code != http.StatusOK {
+		t.Fatalf("dry run = %d: %s", w.Code, w.Body)
+	}
+	if marsMass(t, st) != before || len(tr.List("", "")) != 0 {
+		t.Fatal("a dry run changed the dataset")
+	}
+	if w := testutil.Send(r, http.MethodPost, "/api/admin/import", gzipped, string(editedArchive(t, testArchiveKey))); w.Code != http.StatusOK {
+		t.Fatalf("import = %d: %s", w.Code, w.Body)
+	}
+	if marsMass(t, st) != 2*before || len(tr.List("", "")) != 1 {
+		t.Errorf("import was not applied: mass %g, %d translations", marsMass(t, st), len(tr.List("", "")))
+	}
+}
+
+func TestArchiveImportRejectsTampering(t *testing.T) {
+	r, st, tr, _ := newArchiveServer(t, nil)
+	before := marsMass(t, st)
+
+	tampered := editedArchive(t, testArchiveKey)
+	tampered[len(tampered)/2] ^= 0xff
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"other key", editedArchive(t, "another-key")},
+		{"corrupted", tampered},
+		{"not an archive", []byte("bodies, honest")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := testutil.Send(r, http.MethodPost, "/api/admin/import", gzipped, string(tt.data)); w.Code != http.StatusUnprocessableEntity {
+				t.Errorf("status = %d, want 422: %s", w.Code, w.Body)
+			}
+		})
+	}
+	if marsMass(t, st) != before || len(tr.List("", "")) != 0 {
+		t.Error("a rejected archive changed the dataset")
+	}
+}
+
+// Bodies are checked as the dataset is at boot, and every error is
+// reported against the archived body before anything is applied
+func TestArchiveImportValidatesBodies(t *testing.T) {
+	r, st, tr, _ := newArchiveServer(t, nil)
+	before := marsMass(t, st)
+
+	bodies := models.GetSolarSystemBodies()
+	mars, _ := findBody(bodies, "Mars")
+	mars.Mass *= 2
+	venus, _ := findBody(bodies, "Venus")
+	venus.Radius = 0
+	venus.Eccentricity = 1.2
+	contents := archive.Contents{
+		Bodies:       []models.Planet{mars, venus},
+		Translations: []store.TranslationEntry{{Body: "Mars", Locale: "de", Field: "name", Text: "Mars (de)", Status: store.StatusPublished}},
+	}
+	var buf bytes.Buffer
+	if _, err := archive.Write(&buf, []byte(testArchiveKey), contents, "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	w := testutil.Send(r, http.MethodPost, "/api/admin/import", gzipped, buf.String())
+	var resp struct {
+		Fields []FieldError `json:"fields"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422: %s", w.Code, w.Body)
+	}
+	got := map[string]bool{}
+	for _, f := range resp.Fields {
+		got[f.Field] = true
+	}
+	if len(got) != 2 || !got["bodies[1].radius"] || !got["bodies[1].eccentricity"] {
+		t.Errorf("fields = %+v, want Venus's radius and eccentricity", resp.Fields)
+	}
+	if marsMass(t, st) != before || len(tr.List("", "")) != 0 {
+		t.Error("an invalid archive changed the dataset")
+	}
+}
+
+// When saving the bodies fails, the translations and tours already
+// replaced are put back and the served dataset doesn't change
+func TestArchiveImportRollsBack(t *testing.T) {
+	r, st, tr, tours := newArchiveServer(t, failingRepository{})
+	before := marsMass(t, st)
+	toursBefore := len(tours.List())
+
+	w := testutil.Send(r, http.MethodPost, "/api/admin/import", gzipped, string(editedArchive(t, testArchiveKey)))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500: %s", w.Code, w.Body)
+	}
+	if got := marsMass(t, st); got != before {
+		t.Errorf("Mars's mass = %g after a failed import, want %g", got, before)
+	}
+	if n := len(tr.List("", "")); n != 0 {
+		t.Errorf("%d translations after a failed import, want 0", n)
+	}
+	if n := len(tours.List()); n != toursBefore {
+		t.Errorf("%d tours after a failed import, want %d", n, toursBefore)
+	}
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/assets"
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/i18n"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ModelAsset is a downloadable 3D model of a body at one level of detail
+type ModelAsset struct {
+	LOD         int    `json:"lod"`
+	URL         string `json:"url"`
+	Format      string `json:"format"` // glb or gltf
+	MediaType   string `json:"media_type"`
+	Size        int64  `json:"size_bytes"`
+	Triangles   int    `json:"triangles,omitempty"`
+	License     string `json:"license"`
+	Attribution string `json:"attribution,omitempty"`
+	Source      string `json:"source,omitempty"`
+}
+
+// GetPlanetModels lists the glTF models of a body (or one of the scene's
+// moons), finest level of detail first, so the WebGL view can pick one
+// that suits the device and connection. Private models are left out;
+// staff get their URLs from GetSignedAssetURL.
+func GetPlanetModels(st *store.Store, as *assets.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, ok := surfaceBody(c, st, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+		list := as.ForBody(body, assets.KindModel)
+		out := make([]ModelAsset, 0, len(list))
+		for _, a := range list {
+			if a.Private {
+				continue
+			}
+			out = append(out, ModelAsset{
+				LOD:         a.LOD,
+				URL:         "/assets/" + a.Path,
+				Format:      strings.TrimPrefix(strings.ToLower(path.Ext(a.Path)), "."),
+				MediaType:   assets.ContentType(a.Path),
+				Size:        a.Size,
+				Triangles:   a.Triangles,
+				License:     a.License,
+				Attribution: a.Attribution,
+				Source:      a.Source,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"data": out, "count": len(out)})
+	}
+}
+
+// ServeAsset streams a file listed in the asset manifest. http.ServeContent
+// answers Range requests and checks If-Range against the ETag or
+// Last-Modified, so large models and textures can be fetched in parts and
+// a download resumed only while the file is unchanged.
+func ServeAsset(as *assets.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, a, err := as.Open(c.Request.Context(), strings.TrimPrefix(c.Param("path"), "/"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+			return
+		}
+		defer f.Close()
+		if ct := assets.ContentType(a.Path); ct != "" {
+			c.Header("Content-Type", ct)
+		}
+		c.Header("ETag", a.ETag())
+		c.Header("Accept-Ranges", "bytes")                // also on 416, which ServeContent leaves bare
+		if c.Writer.Header().Get("Cache-Control") == "" { // SignedURL caps private assets at their expiry
+			c.Header("Cache-Control", "public, max-age=86400")
+		}
+		http.ServeContent(c.Writer, c.Request, path.Base(a.Path), a.ModTime, f)
+	}
+}
+
+// GetSignedAssetURL issues a signed URL for ?path= (as listed in the
+// manifest), valid for about ?ttl= (default and maximum: the configured
+// ttl). Public assets get their plain URL. Anyone holding a signed URL can
+// fetch the asset, so the route sits behind AdminAuth.
+func GetSignedAssetURL(as *assets.Store, signer *assets.Signer, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		a, ok := as.Get(strings.TrimPrefix(c.Query("path"), "/assets/"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+			return
+		}
+		want := ttl
+		if v := c.Query("ttl"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < time.Minute || d > ttl {
+				invalid(c, FieldError{Field: "ttl", Message: "must be a duration between 1m and " + ttl.String()})
+				return
+			}
+			want = d
+		}
+		u := "/assets/" + a.Path
+		if !a.Private {
+			c.JSON(http.StatusOK, gin.H{"data": gin.H{"url": u, "private": false}})
+			return
+		}
+		if signer == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Signed URLs are not enabled"})
+			return
+		}
+		u, expires := signer.URL(u, want, time.Now())
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"url": u, "private": true, "expires_at": expires}})
+	}
+}
+
+// assetUpload is the manifest entry sent with an uploaded file
+type assetUpload struct {
+	Body        string `form:"body" binding:"required"`
+	Kind        string `form:"kind" binding:"required,oneof=model texture audio"`
+	Locale      string `form:"locale"` // required for audio
+	LOD         int    `form:"lod" binding:"min=0,max=10"`
+	Triangles   int    `form:"triangles" binding:"min=0"`
+	License     string `form:"license" binding:"required,max=200"`
+	Attribution string `form:"attribution" binding:"max=500"`
+	Source      string `form:"source" binding:"omitempty,url"`
+	Prefetch    bool   `form:"prefetch"`
+	Private     bool   `form:"private"`
+}
+
+// PutAsset stores the request body as the asset at the path after
+// /api/admin/assets/, replacing any file there, and lists it in the
+// manifest with the metadata in the query. The body is streamed to the
+// storage, so it must come with a Content-Length of at most maxBytes.
+// Audio is a body's name spoken in ?locale=, served by
+// GetPlanetPronunciation.
+func PutAsset(st *store.Store, as *assets.Store, signer *assets.Signer, auditLog *audit.Log, maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req assetUpload
+		if !bindQuery(c, &req) {
+			return
+		}
+		p := strings.TrimPrefix(c.Param("path"), "/")
+		if !assets.ValidPath(p) {
+			invalid(c, FieldError{Field: "path", Message: "must be a relative path without .. and not " + assets.ManifestFile})
+			return
+		}
+		body, ok := surfaceBody(c, st, req.Body)
+		if !ok {
+			invalid(c, FieldError{Field: "body", Message: "unknown body"})
+			return
+		}
+		if req.Private && signer == nil {
+			invalid(c, FieldError{Field: "private", Message: "signed URLs are not enabled"})
+			return
+		}
+		var locale string
+		if req.Kind == assets.KindAudio {
+			if locale = i18n.Canonical(req.Locale); locale == "" {
+				invalid(c, FieldError{Field: "locale", Message: "audio needs the locale it is spoken in"})
+				return
+			}
+			if !strings.HasPrefix(assets.ContentType(p), "audio/") {
+				invalid(c, FieldError{Field: "path", Message: "audio must be .mp3, .ogg, .opus, .m4a or .wav"})
+				return
+			}
+		}
+		switch size := c.Request.ContentLength; {
+		case size < 0:
+			c.JSON(http.StatusLengthRequired, gin.H{"error": "Content-Length is required"})
+			return
+		case size == 0:
+			invalid(c, FieldError{Field: "body", Message: "the file is empty"})
+			return
+		case size > maxBytes:
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Assets are limited to %d MiB", maxBytes>>20)})
+			return
+		}
+		before, existed := as.Get(p)
+		a, err := as.Put(c.Request.Context(), assets.Asset{
+			Path:        p,
+			Body:        body,
+			Kind:        req.Kind,
+			LOD:         req.LOD,
+			Triangles:   req.Triangles,
+			Locale:      locale,
+			License:     req.License,
+			Attribution: req.Attribution,
+			Source:      req.Source,
+			Prefetch:    req.Prefetch,
+			Private:     req.Private,
+		}, c.Request.Body, c.Request.ContentLength)
+		if errors.Is(err, assets.ErrNoStorage) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Asset storage is not configured"})
+			return
+		}
+		if err != nil {
+			log.Printf("Storing asset %s: %v", p, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store the asset"})
+			return
+		}
+		status, action := http.StatusCreated, audit.ActionCreate
+		var prev any
+		if existed {
+			status, action, prev = http.StatusOK, audit.ActionUpdate, before
+		}
+		recordAudit(c, auditLog, action, "asset", p, prev, a)
+		c.JSON(status, gin.H{"data": a})
+	}
+}
+
+// DeleteAsset takes an asset out of the manifest and removes its file
+func DeleteAsset(as *assets.Store, auditLog *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := strings.TrimPrefix(c.Param("path"), "/")
+		before, _ := as.Get(p)
+		switch err := as.Delete(c.Request.Context(), p); {
+		case errors.Is(err, assets.ErrNoStorage):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Asset storage is not configured"})
+			return
+		case errors.Is(err, fs.ErrNotExist):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+			return
+		case err != nil:
+			log.Printf("Deleting asset %s: %v", p, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete the asset"})
+			return
+		}
+		recordAudit(c, auditLog, audit.ActionDelete, "asset", p, before, nil)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// GetAssetCache reports how full the asset cache is and how often it hits
+func GetAssetCache(as *assets.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats, ok := as.CacheStats()
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Asset cache is disabled"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": stats})
+	}
+}
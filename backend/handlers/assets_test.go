@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/assets"
+	"solar-system-explorer/backend/middleware"
+	"solar-system-explorer/backend/testutil"
+	"solar-system-explorer/backend/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+var assetModTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newAssetServer serves a manifest with one 64 KiB model, with a content
+// cache of cacheBytes, and returns the router, the file's contents and its
+// path on disk
+func newAssetServer(t *testing.T, cacheBytes int64) (*gin.Engine, []byte, string) {
+	t.Helper()
+	dir := t.TempDir()
+	content := make([]byte, 64<<10)
+	for i := range content {
+		content[i] = byte(i * 7)
+	}
+	file := filepath.Join(dir, "models", "mars.glb")
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(file, assetModTime, assetModTime); err != nil {
+		t.Fatal(err)
+	}
+	manifest := `[{"path": "models/mars.glb", "body": "Mars", "kind": "model", "license": "Public domain"}]`
+	if err := os.WriteFile(filepath.Join(dir, assets.ManifestFile), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	as, err := assets.Open(dir, cacheBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/assets/*path", ServeAsset(as))
+	r.HEAD("/assets/*path", ServeAsset(as))
+	return r, content, file
+}
+
+func TestServeAssetRange(t *testing.T) {
+	for _, cacheBytes := range []int64{0, 1 << 20} {
+		t.Run(fmt.Sprintf("cache %d", cacheBytes), func(t *testing.T) {
+			testServeAssetRange(t, cacheBytes)
+		})
+	}
+}
+
+func testServeAssetRange(t *testing.T, cacheBytes int64) {
+	r, content, _ := newAssetServer(t, cacheBytes)
+	size := len(content)
+	etag := testutil.Do(r, http.MethodGet, "/assets/models/mars.glb", nil).Header().Get("ETag")
+	lastModified := assetModTime.Format(http.TimeFormat)
+
+	tests := []struct {
+		name         string
+		header       map[string]string
+		status       int
+		contentRange string
+		body         []byte
+	}{
+		{"whole file", nil, http.StatusOK, "", content},
+		{"first bytes", map[string]string{"Range": "bytes=0-99"}, http.StatusPartialContent, fmt.Sprintf("bytes 0-99/%d", size), content[:100]},
+		{"middle", map[string]string{"Range": "bytes=1000-1999"}, http.StatusPartialContent, fmt.Sprintf("bytes 1000-1999/%d", size), content[1000:2000]},
+		{"resume to end", map[string]string{"Range": "bytes=60000-"}, http.StatusPartialContent, fmt.Sprintf("bytes 60000-%d/%d", size-1, size), content[60000:]},
+		{"suffix", map[string]string{"Range": "bytes=-10"}, http.StatusPartialContent, fmt.Sprintf("bytes %d-%d/%d", size-10, size-1, size), content[size-10:]},
+		{"end past size is clamped", map[string]string{"Range": fmt.Sprintf("bytes=%d-%d", size-5, size+100)}, http.StatusPartialContent, fmt.Sprintf("bytes %d-%d/%d", size-5, size-1, size), content[size-5:]},
+		{"unsatisfiable", map[string]string{"Range": fmt.Sprintf("bytes=%d-", size)}, http.StatusRequestedRangeNotSatisfiable, fmt.Sprintf("bytes */%d", size), nil},
+		{"If-Range with current ETag", map[string]string{"Range": "bytes=0-9", "If-Range": etag}, http.StatusPartialContent, fmt.Sprintf("bytes 0-9/%d", size), content[:10]},
+		{"If-Range with stale ETag", map[string]string{"Range": "bytes=0-9", "If-Range": `"stale"`}, http.StatusOK, "", content},
+		{"If-Range with weak ETag", map[string]string{"Range": "bytes=0-9", "If-Range": "W/" + etag}, http.StatusOK, "", content},
+		{"If-Range with Last-Modified", map[string]string{"Range": "bytes=0-9", "If-Range": lastModified}, http.StatusPartialContent, fmt.Sprintf("bytes 0-9/%d", size), content[:10]},
+		{"If-Range with older date", map[string]string{"Range": "bytes=0-9", "If-Range": assetModTime.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK, "", content},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutil.Do(r, http.MethodGet, "/assets/models/mars.glb", tt.header)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Content-Range"); got != tt.contentRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.contentRange)
+			}
+			if got := w.Header().Get("Accept-Ranges"); got != "bytes" {
+				t.Errorf("Accept-Ranges = %q, want bytes", got)
+			}
+			if tt.body != nil && !bytes.Equal(w.Body.Bytes(), tt.body) {
+				t.Errorf("body is %d bytes, want %d bytes of the file", w.Body.Len(), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestServeAssetMultipleRanges(t *testing.T) {
+	r, content, _ := newAssetServer(t, 0)
+	w := testutil.Do(r, http.MethodGet, "/assets/models/mars.glb", map[string]string{"Range": "bytes=0-9,100-109"})
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("status = %d, want 206", w.Code)
+	}
+	mediaType, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("Content-Type = %q, want multipart/byteranges", w.Header().Get("Content-Type"))
+	}
+	mr := multipart.NewReader(w.Body, params["boundary"])
+	for _, want := range [][]byte{content[0:10], content[100:110]} {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ct := part.Header.Get("Content-Type"); ct != "model/gltf-binary" {
+			t.Errorf("part Content-Type = %q, want model/gltf-binary", ct)
+		}
+		got, _ := io.ReadAll(part)
+		if !bytes.Equal(got, want) {
+			t.Errorf("part = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestServeAssetHeaders(t *testing.T) {
+	r, content, _ := newAssetServer(t, 0)
+	w := testutil.Do(r, http.MethodHead, "/assets/models/mars.glb", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("HEAD status = %d, want 200", w.Code)
+	}
+	if got, want := w.Header().Get("Content-Length"), fmt.Sprint(len(content)); got != want {
+		t.Errorf("Content-Length = %q, want %q", got, want)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("HEAD returned %d body bytes", w.Body.Len())
+	}
+	if got := w.Header().Get("Content-Type"); got != "model/gltf-binary" {
+		t.Errorf("Content-Type = %q, want model/gltf-binary", got)
+	}
+	if got := w.Header().Get("Last-Modified"); got != assetModTime.Format(http.TimeFormat) {
+		t.Errorf("Last-Modified = %q", got)
+	}
+
+	etag := w.Header().Get("ETag")
+	if w := testutil.Do(r, http.MethodGet, "/assets/models/mars.glb", map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match status = %d, want 304", w.Code)
+	}
+	for _, target := range []string{"/assets/assets.json", "/assets/models/other.glb", "/assets/../assets.json"} {
+		if w := testutil.Do(r, http.MethodGet, target, nil); w.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want 404", target, w.Code)
+		}
+	}
+}
+
+// A download resumed after the file was replaced must restart from the
+// beginning instead of splicing two versions together
+func TestServeAssetResumeAfterChange(t *testing.T) {
+	for _, cacheBytes := range []int64{0, 1 << 20} {
+		t.Run(fmt.Sprintf("cache %d", cacheBytes), func(t *testing.T) {
+			testResumeAfterChange(t, cacheBytes)
+		})
+	}
+}
+
+func testResumeAfterChange(t *testing.T, cacheBytes int64) {
+	r, content, file := newAssetServer(t, cacheBytes)
+	first := testutil.Do(r, http.MethodGet, "/assets/models/mars.glb", map[string]string{"Range": "bytes=0-999"})
+	etag := first.Header().Get("ETag")
+
+	changed := bytes.Repeat([]byte{0xAB}, len(content)+10)
+	if err := os.WriteFile(file, changed, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := assetModTime.Add(time.Minute)
+	if err := os.Chtimes(file, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	w := testutil.Do(r, http.MethodGet, "/assets/models/mars.glb", map[string]string{"Range": "bytes=1000-", "If-Range": etag})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 with the new file", w.Code)
+	}
+	if !bytes.Equal(w.Body.Bytes(), changed) {
+		t.Errorf("body is %d bytes, want the %d-byte new file", w.Body.Len(), len(changed))
+	}
+	if w.Header().Get("ETag") == etag {
+		t.Error("ETag did not change with the file")
+	}
+}
+
+// newAccounts returns an in-memory account store and sessions backed by it
+func newAccounts(t *testing.T) (*users.Users, *users.Sessions) {
+	t.Helper()
+	us, err := users.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logins, err := users.OpenLogins("", 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return us, users.NewSessions([]byte("test-session-key-0123456789abcdef"), 15*time.Minute, logins)
+}
+
+// signIn registers email with role and returns an Authorization header
+// for a fresh session
+func signIn(t *testing.T, us *users.Users, sessions *users.Sessions, email, role string) map[string]string {
+	t.Helper()
+	u, err := us.Register(email, "Test", "correct horse battery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, u, err = us.SetRole(u.ID, role); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := sessions.Start(u, "test", "192.0.2.1", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]string{"Authorization": "Bearer " + tokens.Token}
+}
+
+// Signed URLs open private assets to whoever holds them, so only staff
+// may mint them
+func TestSignedAssetURLNeedsStaff(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "models"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "models", "probe.glb"), []byte("glTF"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	manifest := `[{"path": "models/probe.glb", "body": "Mars", "kind": "model", "license": "Licensed", "private": true}]`
+	if err := os.WriteFile(filepath.Join(dir, assets.ManifestFile), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	as, err := assets.Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	us, sessions := newAccounts(t)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/assets/signed-url", middleware.AdminAuth("static-admin-token", sessions, us), middleware.Require(users.PermContent),
+		GetSignedAssetURL(as, assets.NewSigner("test-signing-key-0123456789abcdef"), time.Hour))
+
+	target := "/api/assets/signed-url?path=models/probe.glb"
+	tests := []struct {
+		name   string
+		header map[string]string
+		status int
+	}{
+		{"anonymous", nil, http.StatusUnauthorized},
+		{"bad token", map[string]string{"Authorization": "Bearer forged"}, http.StatusUnauthorized},
+		{"viewer", signIn(t, us, sessions, "viewer@example.com", users.RoleViewer), http.StatusForbidden},
+		{"curator", signIn(t, us, sessions, "curator@example.com", users.RoleCurator), http.StatusOK},
+		{"admin token", map[string]string{"Authorization": "Bearer static-admin-token"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutil.Do(r, http.MethodGet, target, tt.header)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if signed := bytes.Contains(w.Body.Bytes(), []byte("sig=")); signed != (tt.status == http.StatusOK) {
+				t.Errorf("signed URL in response = %v: %s", signed, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/translit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Classes of small body /api/asteroids lists and filters by
+const (
+	classAsteroid = "asteroid"
+	classComet    = "comet"
+	classTNO      = "tno"
+)
+
+// neptuneAxis is Neptune's semi-major axis (AU); small bodies orbiting
+// beyond it are trans-Neptunian objects
+const neptuneAxis = 30.07
+
+// cometDesignation matches comet names as SBDB gives them: "1P/Halley",
+// "C/2020 F3 (NEOWISE)"
+var cometDesignation = regexp.MustCompile(`^(\d+[PDI]|[PCDXI])/`)
+
+// SmallBody is an asteroid, comet or trans-Neptunian object of the
+// dataset with its orbit, as the small-body listing and export serve it
+type SmallBody struct {
+	Name            string            `json:"name"`
+	NameSR          string            `json:"name_sr"`
+	Class           string            `json:"class"`             // asteroid, comet, tno
+	DistanceFromSun float64           `json:"distance_from_sun"` // AU, semi-major axis
+	Eccentricity    float64           `json:"eccentricity"`
+	Inclination     float64           `json:"inclination"`    // degrees
+	OrbitalPeriod   float64           `json:"orbital_period"` // Earth days
+	Radius          float64           `json:"radius,omitempty"`
+	Discovery       *models.Discovery `json:"discovery,omitempty"`
+	Link            string            `json:"link"`
+
+	folded string // the name as the listing sorts it
+}
+
+// newSmallBody summarizes p for the listing and export
+func newSmallBody(p models.Planet) SmallBody {
+	return SmallBody{
+		Name:            p.Name,
+		NameSR:          p.NameSR,
+		Class:           smallBodyClass(p),
+		DistanceFromSun: p.DistanceFromSun,
+		Eccentricity:    p.Eccentricity,
+		Inclination:     p.Inclination,
+		OrbitalPeriod:   p.OrbitalPeriod,
+		Radius:          p.Radius,
+		Discovery:       p.Discovery,
+		Link:            "/api/planets/" + url.PathEscape(p.Name),
+		folded:          translit.Fold(p.Name),
+	}
+}
+
+// builtinPlanets names the built-in bodies, which aren't small bodies
+// even when the dataset replaces their data
+func builtinPlanets() map[string]bool {
+	builtin := make(map[string]bool)
+	for _, p := range models.GetSolarSystemBodies() {
+		builtin[p.Name] = true
+	}
+	return builtin
+}
+
+// smallBodyClass tells an imported comet or TNO from an asteroid
+func smallBodyClass(p models.Planet) string {
+	switch {
+	case cometDesignation.MatchString(p.Name):
+		return classComet
+	case p.DistanceFromSun > neptuneAxis:
+		return classTNO
+	default:
+		return classAsteroid
+	}
+}
+
+// Orders the small bodies can be listed in
+const (
+	sortName     = "name"
+	sortDistance = "distance"
+)
+
+// smallBodyIndex keeps the dataset's small bodies sorted each way, and
+// per class, for the current snapshot, so a page is found by binary
+// search rather than by sorting or skipping rows on every request
+type smallBodyIndex struct {
+	mu     sync.Mutex
+	etag   string
+	sorted map[string][]SmallBody // by order + "/" + class ("" for all)
+}
+
+// get returns the small bodies of class in order, for snap
+func (x *smallBodyIndex) get(snap *store.Snapshot, order, class string) []SmallBody {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.sorted == nil || x.etag != snap.ETag {
+		x.etag, x.sorted = snap.ETag, smallBodyOrders(snap.Bodies)
+	}
+	return x.sorted[order+"/"+class]
+}
+
+// smallBodyOrders lists the bodies that aren't the Sun or a built-in
+// planet, sorted by each order, all together and per class. Ties are
+// broken by name, so every order is total and stable while the dataset
+// changes.
+func smallBodyOrders(bodies []models.Planet) map[string][]SmallBody {
+	builtin := builtinPlanets()
+	var list []SmallBody
+	for _, p := range bodies {
+		if !p.IsStar && !builtin[p.Name] {
+			list = append(list, newSmallBody(p))
+		}
+	}
+	out := make(map[string][]SmallBody)
+	for _, order := range []string{sortName, sortDistance} {
+		sorted := append([]SmallBody(nil), list...)
+		sort.Slice(sorted, func(i, j int) bool { return cursorAt(sorted[i], order).before(sorted[j]) })
+		for _, class := range []string{"", classAsteroid, classComet, classTNO} {
+			of := []SmallBody{}
+			for _, b := range sorted {
+				if class == "" || b.Class == class {
+					of = append(of, b)
+				}
+			}
+			out[order+"/"+class] = of
+		}
+	}
+	return out
+}
+
+// smallBodyCursor is the position after the last body of a page. Clients
+// get it encoded and pass it back unchanged.
+type smallBodyCursor struct {
+	Sort     string  `json:"s"`
+	Name     string  `json:"n"`
+	Distance float64 `json:"d,omitempty"`
+
+	folded string
+}
+
+// cursorAt is the cursor just after b in order
+func cursorAt(b SmallBody, order string) smallBodyCursor {
+	cur := smallBodyCursor{Sort: order, Name: b.Name, folded: b.folded}
+	if order == sortDistance {
+		cur.Distance = b.DistanceFromSun
+	}
+	return cur
+}
+
+// before reports whether the cursor's position sorts before b. Names
+// compare folded, so the order doesn't depend on case or script, with the
+// exact name as the last resort.
+func (cur smallBodyCursor) before(b SmallBody) bool {
+	if cur.Sort == sortDistance && cur.Distance != b.DistanceFromSun {
+		return cur.Distance < b.DistanceFromSun
+	}
+	if cur.folded != b.folded {
+		return cur.folded < b.folded
+	}
+	return cur.Name < b.Name
+}
+
+func (cur smallBodyCursor) encode() string {
+	data, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeSmallBodyCursor reads a cursor given back by a client
+func decodeSmallBodyCursor(s string) (smallBodyCursor, bool) {
+	var cur smallBodyCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(data, &cur) != nil || cur.Name == "" {
+		return smallBodyCursor{}, false
+	}
+	cur.folded = translit.Fold(cur.Name)
+	return cur, cur.Sort == sortName || cur.Sort == sortDistance
+}
+
+// GetSmallBodies lists the asteroids, comets and trans-Neptunian objects
+// of the dataset, ?limit= (default 100, max 1000) at a time, optionally
+// only of ?class= or discovered between ?discovered_after= and
+// ?discovered_before=, ordered by ?sort=name (default) or distance. The
+// response's meta.next_cursor, passed back as ?cursor=, continues after
+// the last body of the page: pages stay cheap however deep they go, and
+// bodies imported or removed meanwhile don't shift later pages.
+func GetSmallBodies(st *store.Store) gin.HandlerFunc {
+	index := &smallBodyIndex{}
+	return func(c *gin.Context) {
+		var req struct {
+			Class  string `form:"class" binding:"omitempty,oneof=asteroid comet tno"`
+			Sort   string `form:"sort" binding:"omitempty,oneof=name distance"`
+			Limit  int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+			Cursor string `form:"cursor" binding:"max=1000"`
+			discoveryFilter
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		if req.Sort == "" {
+			req.Sort = sortName
+		}
+		if req.Limit == 0 {
+			req.Limit = 100
+		}
+		list := index.get(snapshot(c.Request.Context(), st), req.Sort, req.Class)
+		if req.discoveryFilter.set() {
+			list = slices.DeleteFunc(slices.Clone(list), func(b SmallBody) bool { return !req.discoveryFilter.keeps(b.Discovery) })
+		}
+
+		start := 0
+		if req.Cursor != "" {
+			cur, ok := decodeSmallBodyCursor(req.Cursor)
+			if !ok || cur.Sort != req.Sort {
+				invalid(c, FieldError{Field: "cursor", Message: "must be a next_cursor returned for the same sort"})
+				return
+			}
+			start = sort.Search(len(list), func(i int) bool { return cur.before(list[i]) })
+		}
+
+		page := list[start:min(start+req.Limit, len(list))]
+		next := ""
+		if start+len(page) < len(list) {
+			next = cursorAt(page[len(page)-1], req.Sort).encode()
+		}
+
+		meta := gin.H{"sort": req.Sort, "limit": req.Limit, "next_cursor": nil}
+		if next != "" {
+			q := url.Values{"cursor": {next}, "sort": {req.Sort}, "limit": {strconv.Itoa(req.Limit)}}
+			if req.Class != "" {
+				q.Set("class", req.Class)
+			}
+			for name, bound := range map[string]*int{"discovered_after": req.After, "discovered_before": req.Before} {
+				if bound != nil {
+					q.Set(name, strconv.Itoa(*bound))
+				}
+			}
+			meta["next_cursor"], meta["next"] = next, c.Request.URL.Path+"?"+q.Encode()
+		}
+		c.JSON(http.StatusOK, gin.H{"data": page, "count": len(page), "meta": meta})
+	}
+}
+
+// exportChunk is how many rows the export writes between flushes
+const exportChunk = 500
+
+// ExportSmallBodies streams every small body of the dataset (see
+// GetSmallBodies), or those of ?class=, as newline-delimited JSON, one
+// body per line in dataset order. Rows are written straight to the
+// connection and flushed every exportChunk, so the response is never
+// held in memory and a slow reader slows the export rather than piling
+// it up. The whole export comes from one dataset snapshot.
+func ExportSmallBodies(st *store.Store) gin.HandlerFunc {
+	builtin := builtinPlanets()
+	return func(c *gin.Context) {
+		var req struct {
+			Class string `form:"class" binding:"omitempty,oneof=asteroid comet tno"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		ctx := c.Request.Context()
+		snap := snapshot(ctx, st)
+		if notModified(c, snap.ETag) {
+			return
+		}
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", `attachment; filename="small-bodies.ndjson"`)
+		c.Header("X-Dataset-Version", strconv.FormatInt(snap.Version, 10))
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+
+		enc := json.NewEncoder(c.Writer)
+		rows := 0
+		for _, p := range snap.Bodies {
+			if p.IsStar || builtin[p.Name] {
+				continue
+			}
+			if req.Class != "" && smallBodyClass(p) != req.Class {
+				continue
+			}
+			if err := enc.Encode(newSmallBody(p)); err != nil || ctx.Err() != nil {
+				return // the client went away
+			}
+			if rows++; rows%exportChunk == 0 {
+				c.Writer.Flush()
+			}
+		}
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAudit queries the admin audit log, newest first. Filters: ?actor=,
+// ?action=create|update|delete, ?resource=, ?key=, ?since= and ?until=
+// (RFC 3339) and ?limit= (default 100, max 1000).
+func GetAudit(auditLog *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Actor    string    `form:"actor"`
+			Action   string    `form:"action" binding:"omitempty,oneof=create update delete"`
+			Resource string    `form:"resource"`
+			Key      string    `form:"key"`
+			Since    time.Time `form:"since"`
+			Until    time.Time `form:"until"`
+			Limit    int       `form:"limit" binding:"omitempty,min=1,max=1000"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		f := audit.Filter{Actor: req.Actor, Action: req.Action, Resource: req.Resource, Key: req.Key, Since: req.Since, Until: req.Until, Limit: req.Limit}
+		if f.Limit == 0 {
+			f.Limit = 100
+		}
+
+		entries := auditLog.Query(f)
+		c.JSON(http.StatusOK, gin.H{"data": entries, "count": len(entries)})
+	}
+}
+
+// recordAudit logs an admin mutation of resource/key with the diff between
+// before and after (nil for creates and deletes). The mutation has already
+// happened, so a failed write is logged rather than reported to the client.
+func recordAudit(c *gin.Context, auditLog *audit.Log, action, resource, key string, before, after any) {
+	diff, err := audit.Diff(before, after)
+	if err == nil {
+		_, err = auditLog.Record(audit.Entry{
+			Actor:    middleware.AdminActor(c),
+			Action:   action,
+			Resource: resource,
+			Key:      key,
+			Diff:     diff,
+		})
+	}
+	if err != nil {
+		log.Printf("audit: %s %s %s: %v", action, resource, key, err)
+	}
+}
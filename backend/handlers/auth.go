@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/accountmail"
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/middleware"
+	"solar-system-explorer/backend/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Register creates an account and signs it in: {"email", "name",
+// "password"}. When mail is configured the address gets a confirmation
+// link; failing to send it doesn't fail the registration.
+func Register(us *users.Users, sessions *users.Sessions, tokens *users.EmailTokens, sender *accountmail.Sender) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Email    string `json:"email" binding:"required"`
+			Name     string `json:"name" binding:"required"`
+			Password string `json:"password" binding:"required"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		user, err := us.Register(req.Email, req.Name, req.Password)
+		switch {
+		case errors.Is(err, users.ErrInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, users.ErrExists):
+			c.JSON(http.StatusConflict, gin.H{"error": "An account with this email already exists"})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			if sender != nil {
+				if err := sendVerification(c, tokens, sender, user); err != nil {
+					log.Printf("accountmail: verify %s: %v", user.ID, err)
+				}
+			}
+			startSession(c, sessions, user, http.StatusCreated)
+		}
+	}
+}
+
+type loginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// LoginEmail keys sign-in attempts by the account they are for, read from
+// the body exactly as Login binds it, for a per-account rate limit
+var LoginEmail = middleware.ByJSON(func(req loginRequest) string {
+	if email := users.NormalizeEmail(req.Email); email != "" {
+		return "email:" + email
+	}
+	return ""
+})
+
+// Login exchanges {"email", "password"} for a session token
+func Login(us *users.Users, sessions *users.Sessions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req loginRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		user, err := us.Authenticate(req.Email, req.Password)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Wrong email or password"})
+			return
+		}
+		startSession(c, sessions, user, http.StatusOK)
+	}
+}
+
+// GetMe returns the signed-in user's account and what their role allows
+func GetMe(us *users.Users) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := middleware.CurrentUser(c)
+		user, err := us.Get(claims.Subject)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": user, "meta": gin.H{"permissions": users.PermissionsOf(user.Role)}})
+	}
+}
+
+// startSession signs user in on the requesting device and responds with
+// the account and its tokens
+func startSession(c *gin.Context, sessions *users.Sessions, user users.User, status int) {
+	tokens, err := sessions.Start(user, c.Request.UserAgent(), c.ClientIP(), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, gin.H{"data": gin.H{"user": user, "tokens": tokens}})
+}
+
+// Refresh exchanges {"refresh_token"} for new tokens. Each refresh token
+// works once; reusing an old one signs that device out.
+func Refresh(us *users.Users, sessions *users.Sessions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			RefreshToken string `json:"refresh_token" binding:"required"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		tokens, err := sessions.Refresh(req.RefreshToken, c.ClientIP(), time.Now(), us.Get)
+		switch {
+		case errors.Is(err, users.ErrSession):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session expired; please sign in again"})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusOK, gin.H{"data": gin.H{"tokens": tokens}})
+		}
+	}
+}
+
+// Logout signs the current device out
+func Logout(sessions *users.Sessions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := middleware.CurrentUser(c)
+		if err := sessions.Logins().Revoke(claims.Subject, claims.Session); err != nil && !errors.Is(err, users.ErrNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// LogoutAll signs the user out everywhere, this device included
+func LogoutAll(sessions *users.Sessions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := middleware.CurrentUser(c)
+		n, err := sessions.Logins().RevokeAll(claims.Subject)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"revoked": n}})
+	}
+}
+
+// loginView is a signed-in device, marked if it's the one asking
+type loginView struct {
+	users.Login
+	Current bool `json:"current"`
+}
+
+// ListSessions returns the devices the user is signed in on
+func ListSessions(sessions *users.Sessions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := middleware.CurrentUser(c)
+		list := sessions.Logins().List(claims.Subject, time.Now())
+		out := make([]loginView, len(list))
+		for i, l := range list {
+			out[i] = loginView{Login: l, Current: l.ID == claims.Session}
+		}
+		c.Header("Cache-Control", "private, no-cache")
+		c.JSON(http.StatusOK, gin.H{"data": out, "count": len(out)})
+	}
+}
+
+// RevokeSession signs one of the user's devices out
+func RevokeSession(sessions *users.Sessions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := middleware.CurrentUser(c)
+		err := sessions.Logins().Revoke(claims.Subject, c.Param("id"))
+		switch {
+		case errors.Is(err, users.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.Status(http.StatusNoContent)
+		}
+	}
+}
+
+// ListUsers returns accounts with their roles, optionally only ?role=
+func ListUsers(us *users.Users) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list := us.List(c.Query("role"))
+		c.JSON(http.StatusOK, gin.H{"data": list, "count": len(list), "meta": gin.H{"roles": users.Roles}})
+	}
+}
+
+// SetUserRole assigns an account's role: {"role": "viewer" | "teacher" |
+// "curator" | "admin"}. Staff can't change their own role, so the last
+// admin can't lock everyone out by accident.
+func SetUserRole(us *users.Users, auditLog *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Role string `json:"role" binding:"required"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		if me, ok := middleware.CurrentUser(c); ok && me.Subject == c.Param("id") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can't change your own role"})
+			return
+		}
+		before, after, err := us.SetRole(c.Param("id"), req.Role)
+		switch {
+		case errors.Is(err, users.ErrInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, users.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			recordAudit(c, auditLog, audit.ActionUpdate, "user", after.ID, before, after)
+			c.JSON(http.StatusOK, gin.H{"data": after})
+		}
+	}
+}
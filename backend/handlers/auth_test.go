@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/middleware"
+	"solar-system-explorer/backend/testutil"
+	"solar-system-explorer/backend/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newAuthServer routes the account endpoints as main does, allowing
+// perIP attempts per client address and perEmail sign-ins per account a
+// minute
+func newAuthServer(t *testing.T, perIP, perEmail int) (*gin.Engine, *users.Users, *users.Sessions, *users.EmailTokens) {
+	t.Helper()
+	us, sessions := newAccounts(t)
+	tokens, err := users.OpenEmailTokens("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authPerIP := middleware.NewRateLimit(perIP, time.Minute).Handler(middleware.ByClientIP)
+	authPerEmail := middleware.NewRateLimit(perEmail, time.Minute).Handler(LoginEmail)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/auth/register", authPerIP, Register(us, sessions, tokens, nil))
+	r.POST("/api/auth/login", authPerIP, authPerEmail, Login(us, sessions))
+	r.POST("/api/auth/refresh", Refresh(us, sessions))
+	r.POST("/api/auth/password/reset", authPerIP, ResetPassword(us, tokens, sessions))
+	r.GET("/api/me", middleware.UserAuth(sessions, true), GetMe(us))
+	r.DELETE("/api/me/sessions/:id", middleware.UserAuth(sessions, true), RevokeSession(sessions))
+	admin := r.Group("/api/admin", middleware.AdminAuth("", sessions, us))
+	admin.GET("/planets", middleware.Require(users.PermContent), func(c *gin.Context) { c.Status(http.StatusOK) })
+	admin.GET("/users", middleware.Require(users.PermAdmin), ListUsers(us))
+	return r, us, sessions, tokens
+}
+
+// tokensOf reads the tokens from a sign-in or refresh response
+func tokensOf(t *testing.T, w *httptest.ResponseRecorder) users.Tokens {
+	t.Helper()
+	var resp struct {
+		Data struct {
+			Tokens users.Tokens `json:"tokens"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Data.Tokens.Token == "" {
+		t.Fatalf("no tokens in %d %s", w.Code, w.Body)
+	}
+	return resp.Data.Tokens
+}
+
+func bearer(token string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+func TestLoginWrongPassword(t *testing.T) {
+	r, us, _, _ := newAuthServer(t, 100, 100)
+	if _, err := us.Register("ana@example.com", "Ana", "correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, body string
+		want       int
+	}{
+		{"wrong password", `{"email": "ana@example.com", "password": "wrong horse battery"}`, http.StatusUnauthorized},
+		{"unknown email", `{"email": "ben@example.com", "password": "correct horse battery"}`, http.StatusUnauthorized},
+		{"right password", `{"email": "ANA@example.com", "password": "correct horse battery"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutil.Send(r, http.MethodPost, "/api/auth/login", nil, tt.body)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.want != http.StatusOK && strings.Contains(w.Body.String(), "token") {
+				t.Errorf("failed sign-in returned tokens: %s", w.Body)
+			}
+		})
+	}
+}
+
+// Reusing a rotated refresh token means it leaked, so the device is
+// signed out: neither the old nor the new tokens work afterwards
+func TestRefreshTokenReuse(t *testing.T) {
+	r, _, _, _ := newAuthServer(t, 100, 100)
+	first := tokensOf(t, testutil.Send(r, http.MethodPost, "/api/auth/register", nil, `{"email": "ana@example.com", "name": "Ana", "password": "correct horse battery"}`))
+
+	w := testutil.Send(r, http.MethodPost, "/api/auth/refresh", nil, `{"refresh_token": "`+first.RefreshToken+`"}`)
+	second := tokensOf(t, w)
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+	if w := testutil.Do(r, http.MethodGet, "/api/me", bearer(second.Token)); w.Code != http.StatusOK {
+		t.Fatalf("GET /me with the new token = %d, want 200", w.Code)
+	}
+
+	if w := testutil.Send(r, http.MethodPost, "/api/auth/refresh", nil, `{"refresh_token": "`+first.RefreshToken+`"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("reusing the old refresh token = %d, want 401", w.Code)
+	}
+	if w := testutil.Send(r, http.MethodPost, "/api/auth/refresh", nil, `{"refresh_token": "`+second.RefreshToken+`"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("the new refresh token after reuse = %d, want 401", w.Code)
+	}
+	if w := testutil.Do(r, http.MethodGet, "/api/me", bearer(second.Token)); w.Code != http.StatusUnauthorized {
+		t.Errorf("GET /me after reuse = %d, want 401", w.Code)
+	}
+}
+
+func TestRevokedLogin(t *testing.T) {
+	r, us, sessions, _ := newAuthServer(t, 100, 100)
+	user, err := us.Register("ana@example.com", "Ana", "correct horse battery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	laptop, err := sessions.Start(user, "laptop", "192.0.2.1", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	phone, err := sessions.Start(user, "phone", "192.0.2.2", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w := testutil.Do(r, http.MethodDelete, "/api/me/sessions/"+phone.Session, bearer(laptop.Token)); w.Code != http.StatusNoContent {
+		t.Fatalf("revoke = %d, want 204", w.Code)
+	}
+	if w := testutil.Do(r, http.MethodGet, "/api/me", bearer(phone.Token)); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked access token = %d, want 401", w.Code)
+	}
+	if w := testutil.Send(r, http.MethodPost, "/api/auth/refresh", map[string]string{testutil.RemoteAddr: "192.0.2.2:1"}, `{"refresh_token": "`+phone.RefreshToken+`"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked refresh token = %d, want 401", w.Code)
+	}
+	if w := testutil.Do(r, http.MethodGet, "/api/me", bearer(laptop.Token)); w.Code != http.StatusOK {
+		t.Errorf("the other device = %d, want 200", w.Code)
+	}
+}
+
+func TestPasswordResetSignsOut(t *testing.T) {
+	r, us, sessions, tokens := newAuthServer(t, 100, 100)
+	user, err := us.Register("ana@example.com", "Ana", "correct horse battery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := sessions.Start(user, "laptop", "192.0.2.1", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, err := tokens.Issue(user.ID, users.PurposeReset, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"token": "` + link + `", "password": "staple battery horse"}`
+	if w := testutil.Send(r, http.MethodPost, "/api/auth/password/reset", nil, body); w.Code != http.StatusOK {
+		t.Fatalf("reset = %d, want 200: %s", w.Code, w.Body)
+	}
+	if w := testutil.Do(r, http.MethodGet, "/api/me", bearer(before.Token)); w.Code != http.StatusUnauthorized {
+		t.Errorf("session from before the reset = %d, want 401", w.Code)
+	}
+	if w := testutil.Send(r, http.MethodPost, "/api/auth/password/reset", nil, body); w.Code != http.StatusNotFound {
+		t.Errorf("reusing the link = %d, want 404", w.Code)
+	}
+	if w := testutil.Send(r, http.MethodPost, "/api/auth/login", nil, `{"email": "ana@example.com", "password": "correct horse battery"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("old password = %d, want 401", w.Code)
+	}
+	if w := testutil.Send(r, http.MethodPost, "/api/auth/login", nil, `{"email": "ana@example.com", "password": "staple battery horse"}`); w.Code != http.StatusOK {
+		t.Errorf("new password = %d, want 200", w.Code)
+	}
+}
+
+func TestAdminRoles(t *testing.T) {
+	r, us, sessions, _ := newAuthServer(t, 100, 100)
+	viewer := signIn(t, us, sessions, "viewer@example.com", users.RoleViewer)
+	curator := signIn(t, us, sessions, "curator@example.com", users.RoleCurator)
+	admin := signIn(t, us, sessions, "admin@example.com", users.RoleAdmin)
+
+	tests := []struct {
+		name   string
+		target string
+		header map[string]string
+		want   int
+	}{
+		{"anonymous", "/api/admin/planets", nil, http.StatusUnauthorized},
+		{"viewer, content", "/api/admin/planets", viewer, http.StatusForbidden},
+		{"curator, content", "/api/admin/planets", curator, http.StatusOK},
+		{"curator, users", "/api/admin/users", curator, http.StatusForbidden},
+		{"admin, users", "/api/admin/users", admin, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := testutil.Do(r, http.MethodGet, tt.target, tt.header); w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+
+	// The role is looked up on every request, so a demotion applies to
+	// sessions already signed in
+	u, err := us.ByEmail("curator@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := us.SetRole(u.ID, users.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if w := testutil.Do(r, http.MethodGet, "/api/admin/planets", curator); w.Code != http.StatusForbidden {
+		t.Errorf("demoted curator = %d, want 403", w.Code)
+	}
+}
+
+func TestAuthRateLimit(t *testing.T) {
+	r, us, _, _ := newAuthServer(t, 5, 3)
+	if _, err := us.Register("ana@example.com", "Ana", "correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+	guess := `{"email": "ana@example.com", "password": "wrong horse battery"}`
+
+	// One account guessed at from many addresses
+	for i, addr := range []string{"192.0.2.1:1", "192.0.2.2:1", "192.0.2.3:1"} {
+		if w := testutil.Send(r, http.MethodPost, "/api/auth/login", map[string]string{testutil.RemoteAddr: addr}, guess); w.Code != http.StatusUnauthorized {
+			t.Fatalf("guess %d = %d, want 401", i+1, w.Code)
+		}
+	}
+	w := testutil.Send(r, http.MethodPost, "/api/auth/login", map[string]string{testutil.RemoteAddr: "192.0.2.4:1"}, `{"email": " Ana@Example.com ", "password": "correct horse battery"}`)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("fourth attempt on the account = %d Retry-After %q, want 429 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := testutil.Send(r, http.MethodPost, "/api/auth/login", map[string]string{testutil.RemoteAddr: "192.0.2.4:1"}, `{"email": "ben@example.com", "password": "wrong horse battery"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("another account = %d, want 401", w.Code)
+	}
+
+	// Many accounts tried from one address
+	for i := 0; i < 4; i++ {
+		testutil.Send(r, http.MethodPost, "/api/auth/register", map[string]string{testutil.RemoteAddr: "192.0.2.5:1"}, `{"email": "x@example.com", "name": "Xena", "password": "correct horse battery"}`)
+	}
+	if w := testutil.Send(r, http.MethodPost, "/api/auth/login", map[string]string{testutil.RemoteAddr: "192.0.2.5:1"}, `{"email": "x@example.com", "password": "correct horse battery"}`); w.Code != http.StatusOK {
+		t.Errorf("fifth request from the address = %d, want 200", w.Code)
+	}
+	if w := testutil.Send(r, http.MethodPost, "/api/auth/register", map[string]string{testutil.RemoteAddr: "192.0.2.5:1"}, `{"email": "y@example.com", "name": "Yuri", "password": "correct horse battery"}`); w.Code != http.StatusTooManyRequests {
+		t.Errorf("sixth request from the address = %d, want 429", w.Code)
+	}
+}
+
+// Every body Login accepts for an account counts against it
+func TestAuthRateLimitSpellings(t *testing.T) {
+	r, us, _, _ := newAuthServer(t, 100, 3)
+	if _, err := us.Register("ana@example.com", "Ana", "correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+	padding := strings.Repeat(" ", 70<<10)
+	guesses := []string{
+		`{"Email": "ana@example.com", "password": "wrong horse battery"}`,
+		`{"EMAIL": " ANA@example.com", "password": "wrong horse battery"}`,
+		`{"email": "ana@example.com", "password": "wrong horse battery"} trailing`,
+	}
+	for i, guess := range guesses {
+		if w := testutil.Send(r, http.MethodPost, "/api/auth/login", nil, guess); w.Code != http.StatusUnauthorized {
+			t.Fatalf("guess %d = %d, want 401", i+1, w.Code)
+		}
+	}
+	if w := testutil.Send(r, http.MethodPost, "/api/auth/login", nil, `{"email": "ana@example.com", "password": "correct horse battery"}`); w.Code != http.StatusTooManyRequests {
+		t.Errorf("fourth attempt = %d, want 429", w.Code)
+	}
+
+	// Padding the body past what the limiter reads is refused, not let through
+	padded := `{"email": "ben@example.com",` + padding + `"password": "wrong horse battery"}`
+	if w := testutil.Send(r, http.MethodPost, "/api/auth/login", nil, padded); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("padded body = %d, want 413", w.Code)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/backup"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateBackup makes a backup of the user data now, outside the schedule,
+// and answers with where it was stored. b is nil when backups aren't
+// configured.
+func CreateBackup(b *backup.Backups, auditLog *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if b == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Backups are not configured"})
+			return
+		}
+		info, err := b.Run(c.Request.Context(), time.Now())
+		if info.Name == "" {
+			log.Printf("Backup failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Backup failed"})
+			return
+		}
+		if err != nil {
+			// Stored, only pruning failed
+			log.Printf("Backup %s: %v", info.Name, err)
+		}
+		recordAudit(c, auditLog, audit.ActionCreate, "backup", info.Name, nil, gin.H{"location": info.Location, "size": info.Size})
+		c.JSON(http.StatusCreated, gin.H{"data": info})
+	}
+}
+
+// ListBackups lists the stored backups, newest first
+func ListBackups(b *backup.Backups) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if b == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Backups are not configured"})
+			return
+		}
+		list, err := b.List(c.Request.Context())
+		if err != nil {
+			log.Printf("Listing backups: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list backups"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": list, "count": len(list)})
+	}
+}
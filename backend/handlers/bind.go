@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError is one invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Request structs name their fields with the tag they are bound from
+// (form for the query, uri for path parameters, json for bodies), and
+// validation errors use the same names.
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			for _, tag := range []string{"form", "uri", "json"} {
+				if name, _, _ := strings.Cut(f.Tag.Get(tag), ","); name != "" && name != "-" {
+					return name
+				}
+			}
+			return f.Name
+		})
+	}
+}
+
+// invalid answers 422 listing the invalid fields
+func invalid(c *gin.Context, fields ...FieldError) {
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid request", "fields": fields})
+}
+
+// fieldPath matches the JSON path of a field, like steps[2].narration
+var fieldPath = regexp.MustCompile(`^[a-z_]+(\[\d+\])?(\.[a-z_]+(\[\d+\])?)*$`)
+
+// fieldErrors splits a validation error from a store, sentinel followed
+// by problems separated by "; " that each start with the path of their
+// field, into field errors. A problem that names no field is put on the
+// body.
+func fieldErrors(sentinel, err error) []FieldError {
+	msg := strings.TrimPrefix(err.Error(), sentinel.Error()+": ")
+	var out []FieldError
+	for _, problem := range strings.Split(msg, "; ") {
+		field, rest, ok := strings.Cut(problem, " ")
+		if !ok || !fieldPath.MatchString(field) {
+			field, rest = "body", problem
+		}
+		out = append(out, FieldError{Field: field, Message: rest})
+	}
+	return out
+}
+
+// bindQuery fills req, a pointer to a request struct, from the path
+// parameters (uri tags) and the query string (form tags), converting and
+// validating every field. It answers 422 listing all invalid fields and
+// returns false if any is.
+func bindQuery(c *gin.Context, req any) bool {
+	params := make(map[string][]string, len(c.Params))
+	for _, p := range c.Params {
+		params[p.Key] = []string{p.Value}
+	}
+	query := c.Request.URL.Query()
+	t := reflect.TypeOf(req).Elem()
+	fields := append(checkTypes(t, "uri", params), checkTypes(t, "form", query)...)
+	if len(fields) > 0 {
+		invalid(c, fields...)
+		return false
+	}
+	if err := binding.MapFormWithTag(req, params, "uri"); err != nil {
+		invalid(c, FieldError{Field: "path", Message: err.Error()})
+		return false
+	}
+	if err := binding.MapFormWithTag(req, query, "form"); err != nil {
+		invalid(c, FieldError{Field: "query", Message: err.Error()})
+		return false
+	}
+	return validate(c, binding.Validator.ValidateStruct(req))
+}
+
+// bindJSON decodes the request body into req and validates it. A body
+// that isn't JSON gets 400; fields of the wrong type or failing their
+// binding rules get 422.
+func bindJSON(c *gin.Context, req any) bool {
+	err := c.ShouldBindJSON(req)
+	var typeErr *json.UnmarshalTypeError
+	var verrs validator.ValidationErrors
+	switch {
+	case err == nil:
+		return true
+	case errors.As(err, &typeErr):
+		invalid(c, FieldError{Field: typeErr.Field, Message: "must be " + typeName(typeErr.Type)})
+	case errors.As(err, &verrs):
+		return validate(c, err)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request body must be valid JSON"})
+	}
+	return false
+}
+
+// validate answers 422 for validation errors and returns whether there
+// were none
+func validate(c *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	fields := make([]FieldError, len(verrs))
+	for i, fe := range verrs {
+		fields[i] = FieldError{Field: fe.Field(), Message: ruleMessage(fe)}
+	}
+	invalid(c, fields...)
+	return false
+}
+
+// checkTypes reports values in vals that can't be converted to the type
+// of the field whose tag names them, looking into embedded structs
+func checkTypes(t reflect.Type, tag string, vals map[string][]string) []FieldError {
+	var out []FieldError
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && t.Kind() == reflect.Struct {
+			out = append(out, checkTypes(f.Type, tag, vals)...)
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		v := ""
+		if list := vals[name]; name != "" && len(list) > 0 {
+			v = list[0]
+		}
+		if v == "" {
+			continue
+		}
+		if msg := checkType(f, v); msg != "" {
+			out = append(out, FieldError{Field: name, Message: msg})
+		}
+	}
+	return out
+}
+
+func checkType(f reflect.StructField, v string) string {
+	t := f.Type
+	if t.Kind() == reflect.Pointer { // optional value
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		layout := f.Tag.Get("time_format")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		if _, err := time.Parse(layout, v); err != nil {
+			return "must be a time like " + time.Date(2025, 1, 31, 20, 0, 0, 0, time.UTC).Format(layout)
+		}
+		return ""
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if _, err := strconv.ParseInt(v, 10, t.Bits()); err != nil {
+			return "must be " + typeName(t)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if _, err := strconv.ParseUint(v, 10, t.Bits()); err != nil {
+			return "must be " + typeName(t)
+		}
+	case reflect.Float32, reflect.Float64:
+		if x, err := strconv.ParseFloat(v, t.Bits()); err != nil || math.IsNaN(x) || math.IsInf(x, 0) {
+			return "must be " + typeName(t)
+		}
+	case reflect.Bool:
+		if _, err := strconv.ParseBool(v); err != nil {
+			return "must be " + typeName(t)
+		}
+	}
+	return ""
+}
+
+// typeName describes t for error messages
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a whole number"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return "a " + t.String()
+}
+
+// ruleMessage explains a failed binding rule
+func ruleMessage(fe validator.FieldError) string {
+	unit := ""
+	switch fe.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Map:
+		unit = " items"
+	}
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s%s", fe.Param(), unit)
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s%s", fe.Param(), unit)
+	case "gt":
+		return fmt.Sprintf("must be more than %s%s", fe.Param(), unit)
+	case "lt":
+		return fmt.Sprintf("must be less than %s%s", fe.Param(), unit)
+	case "len":
+		return fmt.Sprintf("must be exactly %s%s", fe.Param(), unit)
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "email":
+		return "must be an email address"
+	}
+	return "is invalid (" + fe.Tag() + ")"
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/classes"
+	"solar-system-explorer/backend/middleware"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+// classView is a class as one user sees it: students don't get the join
+// code or their classmates' names
+type classView struct {
+	ID          string               `json:"id"`
+	Name        string               `json:"name"`
+	TeacherName string               `json:"teacher_name"`
+	Teaching    bool                 `json:"teaching"` // the viewer is the teacher
+	Code        string               `json:"code,omitempty"`
+	Members     []classes.Member     `json:"members,omitempty"`
+	MemberCount int                  `json:"member_count"`
+	Assignments []classes.Assignment `json:"assignments"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+func viewClass(cl classes.Class, viewerID string) classView {
+	v := classView{
+		ID:          cl.ID,
+		Name:        cl.Name,
+		TeacherName: cl.TeacherName,
+		Teaching:    cl.TeacherID == viewerID,
+		MemberCount: len(cl.Members),
+		Assignments: cl.Assignments,
+		CreatedAt:   cl.CreatedAt,
+	}
+	if v.Teaching {
+		v.Code, v.Members = cl.Code, cl.Members
+	}
+	return v
+}
+
+// classError maps classes errors to responses
+func classError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, classes.ErrInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, classes.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
+	case errors.Is(err, classes.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the class teacher can do this"})
+	case errors.Is(err, classes.ErrFull):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetClasses lists the classes the signed-in user teaches or belongs to
+func GetClasses(cs *classes.Classes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := middleware.CurrentUser(c)
+		list := cs.ForUser(user.Subject)
+		data := make([]classView, 0, len(list))
+		for _, cl := range list {
+			data = append(data, viewClass(cl, user.Subject))
+		}
+		c.JSON(http.StatusOK, gin.H{"data": data, "count": len(data)})
+	}
+}
+
+// CreateClass starts a class taught by the signed-in user, who needs a
+// role with the classes permission: {"name"}
+func CreateClass(cs *classes.Classes, us *users.Users) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := middleware.CurrentUser(c)
+		if account, err := us.Get(user.Subject); err != nil || !users.Can(account.Role, users.PermClasses) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only teachers can create classes"})
+			return
+		}
+		var req struct {
+			Name string `json:"name" binding:"required"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		cl, err := cs.Create(user.Subject, user.Name, req.Name, time.Now())
+		if err != nil {
+			classError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"data": viewClass(cl, user.Subject)})
+	}
+}
+
+// GetClass returns a class to its teacher or one of its students
+func GetClass(cs *classes.Classes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := middleware.CurrentUser(c)
+		cl, err := cs.Get(c.Param("id"))
+		if err != nil || (cl.TeacherID != user.Subject && !cl.HasMember(user.Subject)) {
+			classError(c, classes.ErrNotFound)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": viewClass(cl, user.Subject)})
+	}
+}
+
+// DeleteClass ends a class
+func DeleteClass(cs *classes.Classes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := middleware.CurrentUser(c)
+		if _, err := cs.Delete(c.Param("id"), user.Subject); err != nil {
+			classError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// JoinClass adds the signed-in user to the class with {"code"}
+func JoinClass(cs *classes.Classes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Code string `json:"code" binding:"required"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		user, _ := middleware.CurrentUser(c)
+		cl, err := cs.Join(req.Code, user.Subject, user.Name, time.Now())
+		if errors.Is(err, classes.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No class has this code"})
+			return
+		}
+		if err != nil {
+			classError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": viewClass(cl, user.Subject)})
+	}
+}
+
+// RemoveClassMember takes a student out of a class: the teacher removing
+// them, or the student leaving
+func RemoveClassMember(cs *classes.Classes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := middleware.CurrentUser(c)
+		if err := cs.RemoveMember(c.Param("id"), user.Subject, c.Param("user")); err != nil {
+			classError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// RotateClassCode replaces a class's join code, e.g. after it leaked
+func RotateClassCode(cs *classes.Classes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := middleware.CurrentUser(c)
+		cl, err := cs.RotateCode(c.Param("id"), user.Subject)
+		if err != nil {
+			classError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": viewClass(cl, user.Subject)})
+	}
+}
+
+// AssignToClass gives a class a tour to take: {"kind": "tour", "target",
+// "due"}
+func AssignToClass(cs *classes.Classes, tours *store.Tours) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Kind   string     `json:"kind"`
+			Target string     `json:"target" binding:"required"`
+			Due    *time.Time `json:"due"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		if req.Kind == "" {
+			req.Kind = classes.KindTour
+		}
+		if _, ok := tours.Get(req.Target); req.Kind == classes.KindTour && !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tour not found"})
+			return
+		}
+		user, _ := middleware.CurrentUser(c)
+		a, err := cs.Assign(c.Param("id"), user.Subject, classes.Assignment{Kind: req.Kind, Target: req.Target, Due: req.Due}, time.Now())
+		if err != nil {
+			classError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"data": a})
+	}
+}
+
+// UnassignFromClass removes an assignment
+func UnassignFromClass(cs *classes.Classes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := middleware.CurrentUser(c)
+		if err := cs.Unassign(c.Param("id"), user.Subject, c.Param("assignment")); err != nil {
+			classError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/comments"
+	"solar-system-explorer/backend/middleware"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// commentNode is a comment with its replies
+type commentNode struct {
+	comments.Comment
+	Replies []*commentNode `json:"replies"`
+}
+
+// GetComments returns a body's discussion as a tree, oldest first at
+// every level. Signed-in users also see their own comments awaiting
+// moderation.
+func GetComments(st *store.Store, cs *comments.Comments) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, ok := surfaceBody(c, st, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+		viewer, _ := middleware.CurrentUser(c)
+		list := cs.Thread(body, viewer.Subject)
+		nodes := make(map[string]*commentNode, len(list))
+		for _, cm := range list {
+			nodes[cm.ID] = &commentNode{Comment: cm, Replies: []*commentNode{}}
+		}
+		roots := []*commentNode{}
+		for _, cm := range list { // in posting order, so replies stay sorted
+			n := nodes[cm.ID]
+			switch parent, ok := nodes[cm.ParentID]; {
+			case cm.ParentID == "":
+				roots = append(roots, n)
+			case ok:
+				parent.Replies = append(parent.Replies, n)
+			} // replies to removed comments are dropped with them
+		}
+		c.Header("Cache-Control", "private, no-cache")
+		c.JSON(http.StatusOK, gin.H{"data": roots, "count": len(list), "meta": gin.H{"body": body}})
+	}
+}
+
+// PostComment adds a comment by the signed-in user: {"text", "parent_id"}.
+// Comments tripping the spam filter are stored as pending and only shown
+// to their author until a moderator approves them.
+func PostComment(st *store.Store, cs *comments.Comments) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, ok := surfaceBody(c, st, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+		var req struct {
+			Text     string `json:"text" binding:"required"`
+			ParentID string `json:"parent_id"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		user, _ := middleware.CurrentUser(c)
+		cm, err := cs.Post(body, req.ParentID, user.Subject, user.Name, req.Text, time.Now())
+		switch {
+		case errors.Is(err, comments.ErrInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, comments.ErrRateLimited):
+			c.Header("Retry-After", "60")
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			cm.Flagged = nil // don't teach spammers the word list
+			c.JSON(http.StatusCreated, gin.H{"data": cm})
+		}
+	}
+}
+
+// DeleteComment lets the signed-in user remove their own comment. Its
+// replies stay, under a placeholder.
+func DeleteComment(cs *comments.Comments) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := middleware.CurrentUser(c)
+		_, err := cs.Delete(c.Param("id"), user.Subject, time.Now())
+		switch {
+		case errors.Is(err, comments.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		case errors.Is(err, comments.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the author can delete a comment"})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.Status(http.StatusNoContent)
+		}
+	}
+}
+
+// ListComments is the moderation queue: comments by ?status= (default
+// pending), oldest first, with the words that flagged them
+func ListComments(cs *comments.Comments) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list := cs.List(c.DefaultQuery("status", comments.StatusPending))
+		c.JSON(http.StatusOK, gin.H{"data": list, "count": len(list)})
+	}
+}
+
+// ModerateComment approves or hides a comment: {"status": "visible" |
+// "hidden"}
+func ModerateComment(cs *comments.Comments, auditLog *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Status string `json:"status" binding:"required"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		before, after, err := cs.Moderate(c.Param("id"), req.Status, time.Now())
+		switch {
+		case errors.Is(err, comments.ErrInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, comments.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			recordAudit(c, auditLog, audit.ActionUpdate, "comment", after.ID, before, after)
+			c.JSON(http.StatusOK, gin.H{"data": after})
+		}
+	}
+}
+
+// PurgeComment removes a comment for good
+func PurgeComment(cs *comments.Comments, auditLog *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deleted, err := cs.Purge(c.Param("id"))
+		switch {
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		case deleted == nil:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		default:
+			recordAudit(c, auditLog, audit.ActionDelete, "comment", deleted.ID, deleted, nil)
+			c.Status(http.StatusNoContent)
+		}
+	}
+}
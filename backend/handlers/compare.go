@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/physics"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/translit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCompared caps how many bodies one comparison lines up
+const maxCompared = 6
+
+// ComparedBody is one column of a comparison. Values a body's data
+// doesn't have are left out.
+type ComparedBody struct {
+	Name            string            `json:"name"`
+	DisplayName     string            `json:"display_name"`
+	Type            string            `json:"type"`                        // star, planet, small_body or moon
+	Parent          string            `json:"parent,omitempty"`            // the planet a moon orbits
+	Radius          float64           `json:"radius"`                      // km
+	Mass            float64           `json:"mass,omitempty"`              // kg
+	Gravity         float64           `json:"gravity,omitempty"`           // m/s²
+	EscapeVelocity  float64           `json:"escape_velocity,omitempty"`   // km/s
+	DistanceFromSun float64           `json:"distance_from_sun,omitempty"` // AU
+	SemiMajorAxis   float64           `json:"semi_major_axis,omitempty"`   // km from a moon's planet
+	OrbitalPeriod   float64           `json:"orbital_period,omitempty"`    // Earth days
+	RotationPeriod  float64           `json:"rotation_period,omitempty"`   // Earth days
+	Satellites      int               `json:"satellites,omitempty"`
+	Discovery       *models.Discovery `json:"discovery"` // null when known since antiquity
+	// RelativeRadius is the radius over the first body's
+	RelativeRadius float64 `json:"relative_radius"`
+}
+
+// GetCompare lines up the bodies in ?bodies= (2 to 6 comma-separated
+// names, English or Serbian, of bodies in the dataset or moons) side by
+// side: size, mass and surface gravity, orbit, rotation and who
+// discovered them when. Names are localized like GetPlanets.
+func GetCompare(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Bodies string `form:"bodies" binding:"required,max=400"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		names := strings.Split(req.Bodies, ",")
+		if len(names) < 2 || len(names) > maxCompared {
+			invalid(c, FieldError{Field: "bodies", Message: "must name 2 to 6 bodies"})
+			return
+		}
+		_, chain := requestLocales(c)
+		bodies := solarSystemBodies(c.Request.Context(), st)
+		builtin := builtinPlanets()
+
+		out := make([]ComparedBody, 0, len(names))
+		for _, name := range names {
+			cb, ok := comparedBody(strings.TrimSpace(name), bodies, builtin, chain)
+			if !ok {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Body not found: " + strings.TrimSpace(name)})
+				return
+			}
+			out = append(out, cb)
+		}
+		for i := range out {
+			out[i].RelativeRadius = round(out[i].Radius/out[0].Radius, 3)
+		}
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.JSON(http.StatusOK, gin.H{"data": out, "count": len(out), "meta": gin.H{"fallbacks": chain}})
+	}
+}
+
+// comparedBody finds name among the bodies, then the moons
+func comparedBody(name string, bodies []models.Planet, builtin map[string]bool, chain []string) (ComparedBody, bool) {
+	if p, ok := findBody(bodies, name); ok {
+		cb := ComparedBody{
+			Name:            p.Name,
+			DisplayName:     localize(p, chain).DisplayName,
+			Type:            randomSmallBody,
+			Radius:          p.Radius,
+			Mass:            bodyMass(p),
+			DistanceFromSun: p.DistanceFromSun,
+			OrbitalPeriod:   p.OrbitalPeriod,
+			RotationPeriod:  p.RotationPeriod,
+			Satellites:      p.Satellites,
+			Discovery:       p.Discovery,
+		}
+		switch {
+		case p.IsStar:
+			cb.Type = "star"
+		case builtin[p.Name]:
+			cb.Type = randomPlanet
+		}
+		if cb.Mass > 0 && cb.Radius > 0 {
+			cb.Gravity = round(physics.SurfaceGravity(cb.Mass, cb.Radius*1000), 2)
+			cb.EscapeVelocity = round(physics.EscapeVelocity(cb.Mass, cb.Radius*1000)/1000, 3)
+		}
+		return cb, cb.Radius > 0
+	}
+	folded := translit.Fold(name)
+	for _, m := range models.GetMoons() {
+		if translit.Fold(m.Name) != folded && translit.Fold(m.NameSR) != folded {
+			continue
+		}
+		body := models.Planet{Name: m.Name, NameSR: m.NameSR, Translations: m.Translations}
+		return ComparedBody{
+			Name:          m.Name,
+			DisplayName:   localize(body, chain).DisplayName,
+			Type:          randomMoon,
+			Parent:        m.Parent,
+			Radius:        m.Radius,
+			SemiMajorAxis: m.SemiMajorAxis,
+			OrbitalPeriod: m.OrbitalPeriod,
+			Discovery:     m.Discovery,
+		}, true
+	}
+	return ComparedBody{}, false
+}
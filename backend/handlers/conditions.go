@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+
+	"solar-system-explorer/backend/astro"
+	"solar-system-explorer/backend/clock"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/orbits"
+	"solar-system-explorer/backend/physics"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Conditions are derived values describing a body's surface (for the gas
+// giants, the 1-bar level)
+type Conditions struct {
+	Body     string       `json:"body"`
+	Latitude float64      `json:"latitude"`
+	Day      DayLength    `json:"day"`
+	Sunlight Sunlight     `json:"sunlight"`
+	Sun      ApparentSun  `json:"sun"`
+	Gravity  *SurfaceGrav `json:"gravity"` // nil when the mass is unknown
+}
+
+// DayLength covers the solar day and how much of it is daylight at the
+// requested latitude
+type DayLength struct {
+	SiderealHours float64 `json:"sidereal_rotation_hours"`
+	SolarHours    float64 `json:"solar_day_hours"`
+	Retrograde    bool    `json:"retrograde"`
+	// Daylight hours at the latitude: now (Earth and Mars, whose seasons
+	// we model), at the equinoxes and on the longest and shortest days
+	DaylightNow      *float64 `json:"daylight_hours_now,omitempty"`
+	SolarDeclination *float64 `json:"solar_declination,omitempty"` // degrees, now
+	DaylightEquinox  float64  `json:"daylight_hours_equinox"`
+	DaylightLongest  float64  `json:"daylight_hours_longest"`
+	DaylightShortest float64  `json:"daylight_hours_shortest"`
+}
+
+// Sunlight is the solar irradiance at the top of the atmosphere
+type Sunlight struct {
+	DistanceAU      float64 `json:"distance_au"` // now
+	Irradiance      float64 `json:"irradiance_w_m2"`
+	RelativeToEarth float64 `json:"relative_to_earth"`      // now, Earth at 1 AU = 1
+	RelativeMean    float64 `json:"relative_to_earth_mean"` // at the semi-major axis
+}
+
+// ApparentSun is how big the Sun looks from the body
+type ApparentSun struct {
+	DiameterArcmin  float64 `json:"diameter_arcmin"`
+	RelativeToEarth float64 `json:"relative_to_earth"`
+}
+
+// SurfaceGrav is surface gravity and what it does to a jump
+type SurfaceGrav struct {
+	Acceleration    float64 `json:"acceleration_m_s2"`
+	RelativeToEarth float64 `json:"relative_to_earth"`
+	EarthJump       float64 `json:"earth_jump_m"`
+	JumpHeight      float64 `json:"jump_height_m"` // same take-off speed as EarthJump on Earth
+	HangTime        float64 `json:"hang_time_s"`
+}
+
+// GetPlanetConditions returns derived surface conditions: day length and
+// daylight at ?lat= (degrees, default 0), solar irradiance and apparent Sun
+// size at ?time= (RFC 3339, default now), and surface gravity with the
+// height of a jump that reaches ?jump= metres on Earth (default 0.5).
+func GetPlanetConditions(st *store.Store, cache *orbits.Cache, clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		planet, ok := findPlanet(c.Request.Context(), st, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+		if planet.IsStar || planet.OrbitalPeriod == 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Surface conditions are only computed for bodies orbiting the Sun"})
+			return
+		}
+		lat, ok := queryFloat(c, "lat", 0, -90, 90)
+		if !ok {
+			return
+		}
+		jump, ok := queryFloat(c, "jump", 0.5, 0.01, 5)
+		if !ok {
+			return
+		}
+		t, ok := queryTime(c, clk)
+		if !ok {
+			return
+		}
+
+		pos := cache.Position(c.Request.Context(), planet, t)
+		cond := Conditions{
+			Body:     planet.Name,
+			Latitude: lat,
+			Day:      dayLength(planet, lat, astro.JulianDay(t)),
+			Sunlight: Sunlight{
+				DistanceAU:      round(pos.Distance, 4),
+				Irradiance:      round(physics.SolarConstant/(pos.Distance*pos.Distance), 1),
+				RelativeToEarth: round(1/(pos.Distance*pos.Distance), 4),
+				RelativeMean:    round(1/(planet.DistanceFromSun*planet.DistanceFromSun), 4),
+			},
+			Sun: ApparentSun{
+				DiameterArcmin:  round(2*math.Atan(physics.SunRadius/(pos.Distance*physics.AU))*180/math.Pi*60, 2),
+				RelativeToEarth: round(1/pos.Distance, 4),
+			},
+		}
+		if mass := bodyMass(planet); mass > 0 && planet.Radius > 0 {
+			g := physics.SurfaceGravity(mass, planet.Radius*1000)
+			height, hang := physics.JumpHeight(jump, g)
+			cond.Gravity = &SurfaceGrav{
+				Acceleration:    round(g, 2),
+				RelativeToEarth: round(g/physics.EarthGravity, 3),
+				EarthJump:       jump,
+				JumpHeight:      round(height, 2),
+				HangTime:        round(hang, 2),
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"data": cond})
+	}
+}
+
+// dayLength works out the solar day and daylight at lat. Obliquities over
+// 90° (Venus, Uranus) mean retrograde rotation; their seasons follow the
+// supplementary angle.
+func dayLength(p models.Planet, lat, jd float64) DayLength {
+	solar := astro.SolarDay(p.RotationPeriod, p.OrbitalPeriod) * 24
+	tilt := p.AxialTilt
+	if tilt > 90 {
+		tilt = 180 - tilt
+	}
+	d := DayLength{
+		SiderealHours:    round(math.Abs(p.RotationPeriod)*24, 3),
+		SolarHours:       round(solar, 3),
+		Retrograde:       p.RotationPeriod < 0,
+		DaylightEquinox:  round(solar*astro.DaylightFraction(lat, 0), 2),
+		DaylightLongest:  round(solar*math.Max(astro.DaylightFraction(lat, tilt), astro.DaylightFraction(lat, -tilt)), 2),
+		DaylightShortest: round(solar*math.Min(astro.DaylightFraction(lat, tilt), astro.DaylightFraction(lat, -tilt)), 2),
+	}
+	var decl float64
+	switch p.Name {
+	case "Earth":
+		decl = astro.Sun(jd).Declination
+	case "Mars":
+		decl = astro.MarsSolarDeclination(astro.MarsSolarLongitude(jd))
+	default:
+		return d
+	}
+	now := round(solar*astro.DaylightFraction(lat, decl), 2)
+	decl = round(decl, 2)
+	d.DaylightNow, d.SolarDeclination = &now, &decl
+	return d
+}
+
+// bodyMass returns the body's mass in kg, falling back to one gathered
+// from Wikidata
+func bodyMass(p models.Planet) float64 {
+	if p.Mass > 0 {
+		return p.Mass
+	}
+	if f, ok := p.Supplementary["mass_kg"]; ok {
+		if kg, ok := f.Value.(float64); ok {
+			return kg
+		}
+	}
+	return 0
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,679 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/achievements"
+	"solar-system-explorer/backend/assets"
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/classes"
+	"solar-system-explorer/backend/comments"
+	"solar-system-explorer/backend/grs"
+	"solar-system-explorer/backend/launches"
+	"solar-system-explorer/backend/marsphotos"
+	"solar-system-explorer/backend/middleware"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/orbits"
+	"solar-system-explorer/backend/progress"
+	"solar-system-explorer/backend/reports"
+	"solar-system-explorer/backend/sandbox"
+	"solar-system-explorer/backend/satellites"
+	"solar-system-explorer/backend/scenes"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/swpc"
+	"solar-system-explorer/backend/testutil"
+	"solar-system-explorer/backend/upstream"
+	"solar-system-explorer/backend/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+// issTLE is an element set for the ISS at testutil.Epoch, served by the
+// contract server's stand-in for CelesTrak
+const issTLE = `ISS (ZARYA)
+1 25544U 98067A   24080.50000000  .00016717  00000-0  30221-3 0  9992
+2 25544  51.6416 247.4627 0006703 130.5360 325.0288 15.49815311444447
+`
+
+// swpcFeeds stand in for NOAA SWPC's feeds at testutil.Epoch
+var swpcFeeds = map[string]string{
+	"/products/noaa-planetary-k-index.json": `[["time_tag","Kp","a_running","station_count"],
+		["2024-03-19 09:00:00.000","2.33","9","8"],["2024-03-19 21:00:00.000","4.67","39","8"],
+		["2024-03-20 06:00:00.000","3.67","22","8"],["2024-03-20 09:00:00.000","4.00","27","8"]]`,
+	"/products/noaa-planetary-k-index-forecast.json": `[["time_tag","kp","observed","noaa_scale"],
+		["2024-03-20 09:00:00","4.00","observed",null],["2024-03-20 12:00:00","4.33","estimated",null],
+		["2024-03-20 15:00:00","5.33","predicted","G1"],["2024-03-20 21:00:00","4.67","predicted","G1"],
+		["2024-03-21 06:00:00","3.00","predicted",null]]`,
+	"/products/solar-wind/plasma-1-day.json": `[["time_tag","density","speed","temperature"],
+		["2024-03-20 11:58:00.000","6.21","548.3","187000"],["2024-03-20 11:59:00.000",null,null,null]]`,
+	"/products/solar-wind/mag-1-day.json": `[["time_tag","bx_gsm","by_gsm","bz_gsm","lon_gsm","lat_gsm","bt"],
+		["2024-03-20 11:59:00.000","2.1","-3.4","-8.7","301.2","-64.9","9.6"]]`,
+	"/json/ovation_aurora_latest.json": `{"Observation Time":"2024-03-20T11:55:00Z","Forecast Time":"2024-03-20T12:35:00Z",
+		"Data Format":"[Longitude, Latitude, Aurora]",
+		"coordinates":[[212,65,34],[212,60,8],[20,65,12],[20,45,0],[150,-66,21]]}`,
+}
+
+// upcomingLaunches stands in for Launch Library 2's upcoming launches
+const upcomingLaunches = `{"count":3,"next":null,"results":[
+	{"id":"e3df2ecd-c239-472f-95e4-2b89b4f75800","name":"Falcon Heavy | Europa Clipper","net":"2024-10-10T16:06:00Z",
+	 "window_start":"2024-10-10T16:06:00Z","window_end":"2024-10-10T16:06:00Z",
+	 "status":{"id":1,"name":"Go for Launch","abbrev":"Go"},
+	 "launch_service_provider":{"id":121,"name":"SpaceX","type":"Commercial"},
+	 "rocket":{"configuration":{"name":"Falcon Heavy","full_name":"Falcon Heavy"}},
+	 "mission":{"name":"Europa Clipper","type":"Planetary Science","orbit":{"name":"Heliocentric N/A","abbrev":"Helio-N/A"},
+	  "description":"Europa Clipper will conduct detailed reconnaissance of Jupiter's moon Europa, with a Mars gravity assist on the way.",
+	  "agencies":[{"id":44,"name":"National Aeronautics and Space Administration","abbrev":"NASA"}]},
+	 "pad":{"name":"Launch Complex 39A","location":{"name":"Kennedy Space Center, FL, USA"}},
+	 "image":"https://example.org/europa-clipper.jpg"},
+	{"id":"4ae6b7b2-7d1c-4d1e-9b9d-51c4a8fb7f01","name":"Falcon 9 Block 5 | Starlink Group 10-9","net":"2024-10-11T04:00:00Z",
+	 "status":{"name":"Go for Launch","abbrev":"Go"},
+	 "launch_service_provider":{"name":"SpaceX"},
+	 "rocket":{"configuration":{"name":"Falcon 9","full_name":"Falcon 9 Block 5"}},
+	 "mission":{"name":"Starlink Group 10-9","type":"Communications","description":"A batch of satellites for the Starlink mega-constellation around Earth.","agencies":[]},
+	 "pad":{"name":"Space Launch Complex 40","location":{"name":"Cape Canaveral, FL, USA"}}},
+	{"id":"a1c2d3e4-0000-4000-8000-000000000003","name":"H3 | MMX","net":"2026-10-01T00:00:00Z",
+	 "status":{"name":"To Be Confirmed","abbrev":"TBC"},
+	 "launch_service_provider":{"name":"Mitsubishi Heavy Industries","abbrev":"MHI"},
+	 "rocket":{"configuration":{"name":"H3-24L","full_name":"H3-24L"}},
+	 "mission":{"name":"Martian Moons eXploration","type":"Planetary Science","orbit":null,
+	  "description":"MMX will survey Phobos and Deimos and return a sample of Phobos to Earth.",
+	  "agencies":[{"name":"Japan Aerospace Exploration Agency","abbrev":"JAXA"}]},
+	 "pad":{"name":"Yoshinobu Launch Complex LP-2","location":{"name":"Tanegashima, Japan"}}}
+]}`
+
+// perseveranceManifest stands in for the Mars Rover Photos API's manifest
+const perseveranceManifest = `{"photo_manifest":{"name":"Perseverance","landing_date":"2021-02-18","launch_date":"2020-07-30",
+	"status":"active","max_sol":1100,"max_date":"2024-03-19","total_photos":218950,"photos":[
+	{"sol":1099,"earth_date":"2024-03-18","total_photos":12,"cameras":["NAVCAM_LEFT","MCZ_RIGHT"]},
+	{"sol":1100,"earth_date":"2024-03-19","total_photos":30,"cameras":["NAVCAM_LEFT","MCZ_RIGHT","SHERLOC_WATSON"]}]}}`
+
+// roverPhotos stands in for the Mars Rover Photos API's photos of
+// Perseverance's sol 1100: 20 NAVCAM_LEFT and 10 MCZ_RIGHT images, 25 a
+// page
+func roverPhotos(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("sol") != "1100" {
+		fmt.Fprint(w, `{"photos":[]}`)
+		return
+	}
+	type photo struct {
+		ID        int               `json:"id"`
+		Sol       int               `json:"sol"`
+		Camera    map[string]string `json:"camera"`
+		ImgSrc    string            `json:"img_src"`
+		EarthDate string            `json:"earth_date"`
+	}
+	var all []photo
+	for i := range 30 {
+		cam := map[string]string{"name": "NAVCAM_LEFT", "full_name": "Navigation Camera - Left"}
+		if i >= 20 {
+			cam = map[string]string{"name": "MCZ_RIGHT", "full_name": "Mast Camera Zoom - Right"}
+		}
+		if q.Get("camera") == "" || strings.EqualFold(q.Get("camera"), cam["name"]) {
+			all = append(all, photo{ID: 1200000 + i, Sol: 1100, Camera: cam, EarthDate: "2024-03-19",
+				ImgSrc: fmt.Sprintf("http://mars.nasa.gov/mars2020-raw-images/%d.png", 1200000+i)})
+		}
+	}
+	n, _ := strconv.Atoi(q.Get("page"))
+	from := min(len(all), max(0, n-1)*25)
+	json.NewEncoder(w).Encode(map[string]any{"photos": all[from:min(len(all), from+25)]})
+}
+
+// newContractServer routes the public read endpoints over the built-in
+// dataset, with the clock stopped at testutil.Epoch and X-Simulated-Time
+// honoured as in dev mode
+func newContractServer(t testing.TB) *gin.Engine {
+	t.Helper()
+	st := store.New(models.GetSolarSystemBodies())
+	tours, err := store.OpenTours("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker, err := grs.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk := testutil.NewClock(testutil.Epoch)
+	celestrak := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("CATNR") != "25544" && q.Get("GROUP") != "stations" {
+			fmt.Fprintln(w, "No GP data found")
+			return
+		}
+		fmt.Fprint(w, issTLE)
+	}))
+	t.Cleanup(celestrak.Close)
+	noaa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		feed, ok := swpcFeeds[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, feed)
+	}))
+	t.Cleanup(noaa.Close)
+	weather := swpc.NewClient(noaa.URL, time.Minute, upstream.DefaultPolicy())
+	weather.Clock = clk
+	ll2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/launch/upcoming/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, upcomingLaunches)
+	}))
+	t.Cleanup(ll2.Close)
+	schedule := launches.NewClient(ll2.URL, "", []string{"NASA", "JAXA"}, time.Hour, upstream.DefaultPolicy())
+	schedule.Clock = clk
+	nasa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/manifests/perseverance":
+			fmt.Fprint(w, perseveranceManifest)
+		case "/rovers/perseverance/photos":
+			roverPhotos(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(nasa.Close)
+	photos, err := marsphotos.NewClient(nasa.URL, "DEMO_KEY", "", time.Hour, upstream.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	photos.Clock = clk
+	tles := satellites.NewClient(celestrak.URL, time.Hour, upstream.DefaultPolicy())
+	tles.Clock = clk
+	catalog, err := satellites.OpenCatalog("", tles, []string{"stations", "science"}, 72*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalog.Refresh(context.Background()) // science fails, as CelesTrak being down would
+	cache := orbits.NewCache(time.Hour, time.Minute, 1000)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api := r.Group("/api", middleware.SimulatedTime())
+	api.GET("/planets", GetPlanets(st))
+	api.GET("/planets/:name", GetPlanetByName(st))
+	api.GET("/planets/:name/temperature", GetPlanetTemperature(st))
+	api.GET("/planets/:name/features", GetPlanetFeatures(st))
+	api.GET("/planets/:name/pronunciation", GetPlanetPronunciation(st, nil))
+	api.GET("/planets/:name/alt-text", GetPlanetAltText(st))
+	api.GET("/search", GetSearch(st, nil))
+	api.GET("/random", GetRandom(st, clk))
+	api.GET("/kepler3", GetKepler3(st))
+	api.GET("/compare", GetCompare(st))
+	api.GET("/physics/roche", GetRoche(st))
+	api.GET("/physics/escape", GetEscape(st))
+	api.GET("/stars", GetStars)
+	api.GET("/stars/:name/habitable-zone", GetHabitableZone(st))
+	api.GET("/constellations", GetConstellations)
+	api.GET("/tours", GetTours(tours))
+	api.GET("/tours/:id", GetTour(st, tours))
+	api.GET("/planets/:name/seasons", GetPlanetSeasons(st, clk))
+	api.GET("/planets/:name/position", GetPlanetPosition(st, cache, clk))
+	api.GET("/planets/:name/conditions", GetPlanetConditions(st, cache, clk))
+	api.GET("/positions", GetPositions(st, cache, clk))
+	api.GET("/earth/now", GetEarthNow(clk))
+	api.GET("/sky", GetSky(st, clk))
+	api.GET("/satellites", GetSatellites(catalog, clk))
+	api.GET("/space-weather", GetSpaceWeather(weather))
+	api.GET("/launches", GetLaunches(schedule, st))
+	api.GET("/mars/rover-photos", GetRoverPhotos(photos))
+	api.GET("/history/today", GetHistoryToday(clk))
+	api.GET("/glossary", GetGlossary(st))
+	api.GET("/glossary/annotations", GetGlossaryAnnotations(st))
+	api.GET("/glossary/:id", GetGlossaryTerm(st))
+	api.GET("/satellites/:id/passes", GetSatellitePasses(catalog, clk))
+	api.GET("/saturn/ring-angle", GetSaturnRingAngle(st, clk))
+	api.GET("/jupiter/moons/events", GetMoonEvents(st, clk))
+	api.GET("/jupiter/grs", GetGRSTransits(tracker, st, clk))
+	api.GET("/time/convert", GetTimeConvert(clk))
+	api.GET("/coords/convert", GetCoordsConvert(clk))
+	return r
+}
+
+// TestContract pins the responses of the public read endpoints to the
+// golden files in testdata/golden. A deliberate change to a response is
+// accepted with go test ./handlers -run TestContract -update.
+func TestContract(t *testing.T) {
+	r := newContractServer(t)
+	tests := []struct {
+		golden string
+		path   string
+		header map[string]string
+		status int
+	}{
+		{"planets", "/api/planets", nil, http.StatusOK},
+		{"planets_discovered_after", "/api/planets?discovered_after=1800&lang=en", nil, http.StatusOK},
+		{"planets_bad_discovered_after", "/api/planets?discovered_after=soon", nil, http.StatusUnprocessableEntity},
+		{"compare", "/api/compare?bodies=earth,Uran,titan&lang=en", nil, http.StatusOK},
+		{"compare_one", "/api/compare?bodies=earth", nil, http.StatusUnprocessableEntity},
+		{"compare_unknown", "/api/compare?bodies=earth,vulcan", nil, http.StatusNotFound},
+		{"planets_sr_cyrl", "/api/planets", map[string]string{"Accept-Language": "sr-Cyrl"}, http.StatusOK},
+		{"planet", "/api/planets/mars", nil, http.StatusOK},
+		{"planet_not_found", "/api/planets/vulcan", nil, http.StatusNotFound},
+		{"planet_kids", "/api/planets/mars?audience=kids&lang=en", nil, http.StatusOK},
+		{"planet_kids_sr_cyrl", "/api/planets/saturn?audience=kids", map[string]string{"Accept-Language": "sr-Cyrl"}, http.StatusOK},
+		{"planet_bad_audience", "/api/planets/mars?audience=toddlers", nil, http.StatusUnprocessableEntity},
+		{"temperature", "/api/planets/venus/temperature", nil, http.StatusOK},
+		{"features", "/api/planets/mars/features?type=mons", nil, http.StatusOK},
+		{"pronunciation", "/api/planets/jupiter/pronunciation?lang=en", nil, http.StatusOK},
+		{"pronunciation_moon", "/api/planets/Фобос/pronunciation", map[string]string{"Accept-Language": "sr-Cyrl"}, http.StatusOK},
+		{"pronunciation_not_found", "/api/planets/vulcan/pronunciation", nil, http.StatusNotFound},
+		{"alt_text", "/api/planets/saturn/alt-text?lang=en", nil, http.StatusOK},
+		{"alt_text_sr_cyrl", "/api/planets/venus/alt-text", map[string]string{"Accept-Language": "sr-Cyrl"}, http.StatusOK},
+		{"alt_text_moon", "/api/planets/europa/alt-text?lang=de", nil, http.StatusOK},
+		{"search", "/api/search?q=jupiter", nil, http.StatusOK},
+		{"search_cyrillic", "/api/search?q=Земља", nil, http.StatusOK},
+		{"random", "/api/random?seed=contract", nil, http.StatusOK},
+		{"random_invalid", "/api/random?type=comet", nil, http.StatusUnprocessableEntity},
+		{"kepler3", "/api/kepler3?a=5.2", nil, http.StatusOK},
+		{"roche", "/api/physics/roche?primary=saturn", nil, http.StatusOK},
+		{"escape", "/api/physics/escape?body=earth&altitude=400", nil, http.StatusOK},
+		{"stars", "/api/stars?max_mag=0.5", nil, http.StatusOK},
+		{"habitable_zone", "/api/stars/sun/habitable-zone", nil, http.StatusOK},
+		{"tours", "/api/tours", nil, http.StatusOK},
+		{"tour", "/api/tours/grand-tour", nil, http.StatusOK},
+		{"tour_not_found", "/api/tours/nowhere", nil, http.StatusNotFound},
+		{"constellations", "/api/constellations", nil, http.StatusOK},
+		{"seasons", "/api/planets/earth/seasons", nil, http.StatusOK},
+		{"position", "/api/planets/mars/position", nil, http.StatusOK},
+		{"position_at", "/api/planets/mars/position?time=2030-01-01T00:00:00Z", nil, http.StatusOK},
+		{"position_simulated", "/api/planets/mars/position", map[string]string{"X-Simulated-Time": "2030-01-01T00:00:00Z"}, http.StatusOK},
+		{"position_invalid_simulated", "/api/planets/mars/position", map[string]string{"X-Simulated-Time": "2030"}, http.StatusUnprocessableEntity},
+		{"position_invalid_time", "/api/planets/mars/position?time=tomorrow", nil, http.StatusUnprocessableEntity},
+		{"conditions", "/api/planets/mars/conditions?lat=45", nil, http.StatusOK},
+		{"positions", "/api/positions?bodies=earth,mars,jupiter", nil, http.StatusOK},
+		{"earth_now", "/api/earth/now", nil, http.StatusOK},
+		{"seasons_tz", "/api/planets/earth/seasons?year=2025&tz=Europe/Belgrade", nil, http.StatusOK},
+		{"seasons_invalid_tz", "/api/planets/earth/seasons?tz=Mars/Olympus_Mons", nil, http.StatusUnprocessableEntity},
+		{"positions_geocentric", "/api/positions?bodies=sun,earth,mars&frame=geocentric", nil, http.StatusOK},
+		{"positions_barycentric", "/api/positions?bodies=sun,jupiter&frame=barycentric", nil, http.StatusOK},
+		{"positions_apparent", "/api/positions?bodies=sun,mars,jupiter&frame=geocentric&apparent=true", nil, http.StatusOK},
+		{"position_geocentric", "/api/planets/venus/position?frame=geocentric&time=2026-08-15T00:00:00Z", nil, http.StatusOK},
+		{"position_invalid_frame", "/api/planets/mars/position?frame=galactic", nil, http.StatusUnprocessableEntity},
+		{"coords_equatorial", "/api/coords/convert?from=equatorial&ra=101.287&dec=-16.716&observer_lat=44.82&observer_lon=20.46", nil, http.StatusOK},
+		{"coords_horizontal", "/api/coords/convert?from=horizontal&az=180&alt=30&observer_lat=44.82&observer_lon=20.46", nil, http.StatusOK},
+		{"coords_invalid", "/api/coords/convert?from=horizontal&az=180", nil, http.StatusUnprocessableEntity},
+		{"sky", "/api/sky?sort=magnitude&observer_lat=44.82&observer_lon=20.46", nil, http.StatusOK},
+		{"satellites_stations", "/api/satellites?group=stations", nil, http.StatusOK},
+		{"satellites_unknown_group", "/api/satellites?group=weather", nil, http.StatusUnprocessableEntity},
+		{"space_weather", "/api/space-weather?lat=64.84&lon=-147.72", nil, http.StatusOK},
+		{"space_weather_half_location", "/api/space-weather?lat=64.84", nil, http.StatusUnprocessableEntity},
+		{"launches", "/api/launches", nil, http.StatusOK},
+		{"launches_mars", "/api/launches?body=mars", nil, http.StatusOK},
+		{"launches_unknown_body", "/api/launches?body=vulcan", nil, http.StatusUnprocessableEntity},
+		{"rover_photos", "/api/mars/rover-photos?rover=perseverance&sol=latest&limit=20&cursor=10", nil, http.StatusOK},
+		{"rover_photos_camera", "/api/mars/rover-photos?camera=navcam_left&limit=10", nil, http.StatusOK},
+		{"rover_photos_no_camera_that_sol", "/api/mars/rover-photos?sol=1099&camera=sherloc_watson", nil, http.StatusOK},
+		{"rover_photos_bad_camera", "/api/mars/rover-photos?camera=hazcam", nil, http.StatusUnprocessableEntity},
+		{"rover_photos_future_sol", "/api/mars/rover-photos?sol=1101", nil, http.StatusUnprocessableEntity},
+		{"history_today", "/api/history/today?date=2024-07-20&lang=en", nil, http.StatusOK},
+		{"history_week", "/api/history/today?days=14&kind=discovery", nil, http.StatusOK},
+		{"history_bad_date", "/api/history/today?date=07-20", nil, http.StatusUnprocessableEntity},
+		{"glossary_letter", "/api/glossary?letter=p&lang=en", nil, http.StatusOK},
+		{"glossary_sr_cyrl", "/api/glossary?letter=р", map[string]string{"Accept-Language": "sr-Cyrl"}, http.StatusOK},
+		{"glossary_bad_letter", "/api/glossary?letter=pe", nil, http.StatusUnprocessableEntity},
+		{"glossary_term", "/api/glossary/perihelion?lang=en", nil, http.StatusOK},
+		{"glossary_term_not_found", "/api/glossary/wormhole", nil, http.StatusNotFound},
+		{"glossary_annotations", "/api/glossary/annotations?body=deimos", map[string]string{"Accept-Language": "sr-Cyrl"}, http.StatusOK},
+		{"glossary_annotations_en", "/api/glossary/annotations?body=venus&lang=en", nil, http.StatusOK},
+		{"satellite_passes", "/api/satellites/25544/passes?lat=44.82&lon=20.46&days=2&all=true&tz=Europe/Belgrade", nil, http.StatusOK},
+		{"satellite_passes_unknown", "/api/satellites/99999/passes?lat=44.82&lon=20.46", nil, http.StatusNotFound},
+		{"satellite_passes_no_location", "/api/satellites/25544/passes?lat=44.82", nil, http.StatusUnprocessableEntity},
+		{"saturn_ring_angle", "/api/saturn/ring-angle?from=2024-09-01T00:00:00Z&to=2025-12-01T00:00:00Z&step=30", nil, http.StatusOK},
+		{"saturn_ring_angle_reversed", "/api/saturn/ring-angle?from=2025-01-01T00:00:00Z&to=2024-01-01T00:00:00Z", nil, http.StatusUnprocessableEntity},
+		{"moon_events", "/api/jupiter/moons/events?date=2015-01-23&tz=America/New_York&observer_lat=40.71&observer_lon=-74.01", nil, http.StatusOK},
+		{"grs_transits", "/api/jupiter/grs?date=2025-01-10&tz=Europe/Belgrade&observer_lat=44.82&observer_lon=20.46", nil, http.StatusOK},
+		{"grs_invalid_date", "/api/jupiter/grs?date=2025-13-01", nil, http.StatusUnprocessableEntity},
+		{"time_convert", "/api/time/convert?time=2025-03-20T09:01:00Z", nil, http.StatusOK},
+		{"time_convert_tdb", "/api/time/convert?jd=2451545&scale=tdb", nil, http.StatusOK},
+		{"time_convert_ancient", "/api/time/convert?mjd=-500000", nil, http.StatusOK},
+		{"time_convert_two_times", "/api/time/convert?jd=2451545&mjd=51544", nil, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			w := testutil.Do(r, http.MethodGet, tt.path, tt.header)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Errorf("Content-Type = %q, want JSON", ct)
+			}
+			testutil.Golden(t, tt.golden, w.Body.Bytes())
+		})
+	}
+}
+
+// TestContractClock checks that time-dependent endpoints follow the
+// injected clock rather than the wall clock, and X-Simulated-Time over both
+func TestContractClock(t *testing.T) {
+	r := newContractServer(t)
+	at := testutil.Do(r, http.MethodGet, "/api/earth/now?time="+testutil.Epoch.Format(time.RFC3339), nil)
+	now := testutil.Do(r, http.MethodGet, "/api/earth/now", nil)
+	if at.Body.String() != now.Body.String() {
+		t.Errorf("/api/earth/now without ?time= doesn't answer for the clock's time")
+	}
+
+	const future = "2030-01-01T00:00:00Z"
+	// Each path, and the same request with the time given explicitly
+	for path, explicit := range map[string]string{
+		"/api/earth/now":             "/api/earth/now?time=" + future,
+		"/api/positions":             "/api/positions?time=" + future,
+		"/api/planets/earth/seasons": "/api/planets/earth/seasons?year=2030",
+		"/api/random":                "/api/random?seed=2030-01-01",
+	} {
+		want := testutil.Do(r, http.MethodGet, explicit, nil)
+		got := testutil.Do(r, http.MethodGet, path, map[string]string{"X-Simulated-Time": future})
+		if got.Body.String() != want.Body.String() {
+			t.Errorf("%s with X-Simulated-Time doesn't answer for %s", path, future)
+		}
+		if h := got.Header().Get("X-Simulated-Time"); h != future {
+			t.Errorf("%s echoed X-Simulated-Time %q, want %q", path, h, future)
+		}
+	}
+}
+
+// benchmarkGet measures path on the contract server, in parallel as under
+// load. go test ./handlers -run XXX -bench . -benchmem
+func benchmarkGet(b *testing.B, path string, header map[string]string) {
+	r := newContractServer(b)
+	if w := testutil.Do(r, http.MethodGet, path, header); w.Code != http.StatusOK {
+		b.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			testutil.Do(r, http.MethodGet, path, header)
+		}
+	})
+}
+
+func BenchmarkPlanets(b *testing.B) { benchmarkGet(b, "/api/planets", nil) }
+
+func BenchmarkPlanetsSerbian(b *testing.B) {
+	benchmarkGet(b, "/api/planets", map[string]string{"Accept-Language": "sr-Cyrl"})
+}
+
+func BenchmarkPlanetByName(b *testing.B) { benchmarkGet(b, "/api/planets/Марс", nil) }
+
+func BenchmarkPositions(b *testing.B) {
+	benchmarkGet(b, "/api/positions?time=2025-03-20T09:01:00Z", nil)
+}
+
+// positionsAllocs is the most allocations /api/positions may make once
+// its cache is warm, whatever the number of bodies: what gin and the query
+// parser need. Animations ask for it every frame.
+const positionsAllocs = 8
+
+// discardWriter is a reusable ResponseWriter, so AllocsPerRun counts only
+// what serving the request allocates
+type discardWriter struct {
+	header http.Header
+	n      int
+}
+
+func (w *discardWriter) Header() http.Header { return w.header }
+
+func (w *discardWriter) Write(b []byte) (int, error) { w.n += len(b); return len(b), nil }
+
+func (w *discardWriter) WriteHeader(int) {}
+
+// TestPositionsAllocs is the benchmark gate for the animation hot path
+func TestPositionsAllocs(t *testing.T) {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "-race" && s.Value == "true" {
+				t.Skip("the race detector empties sync.Pools at random")
+			}
+		}
+	}
+	r := newContractServer(t)
+	req, _ := http.NewRequest(http.MethodGet, "/api/positions?time=2025-03-20T09:01:00Z", nil)
+	w := &discardWriter{header: make(http.Header)}
+	r.ServeHTTP(w, req)
+	if w.n == 0 {
+		t.Fatal("empty response")
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		clear(w.header)
+		r.ServeHTTP(w, req)
+	})
+	if allocs > positionsAllocs {
+		t.Errorf("/api/positions made %v allocations per request, want at most %d", allocs, positionsAllocs)
+	}
+}
+
+// TestPositionJSON checks the hand-written encoding against encoding/json
+func TestPositionJSON(t *testing.T) {
+	for _, p := range []orbits.Position{
+		{},
+		orbits.Compute(models.GetSolarSystemBodies()[3], testutil.Epoch),
+		{Name: "<Tom & \"Jerry\">\n\x01", NameSR: "Čeres\u2028\xff", Time: testutil.Epoch.Add(123456789), X: 1e-7, Y: -2.5e21, Z: 1e20, Distance: 123456.789},
+	} {
+		want, err := json.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := p.AppendJSON(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("AppendJSON =\n%s\nwant\n%s", got, want)
+		}
+	}
+	for _, a := range []orbits.Accuracy{
+		{},
+		{Extrapolated: true},
+		orbits.AccuracyOf(models.GetSolarSystemBodies()[4], time.Date(1500, 1, 1, 0, 0, 0, 0, time.UTC)),
+	} {
+		want, _ := json.Marshal(a)
+		if got := a.AppendJSON(nil); string(got) != string(want) {
+			t.Errorf("Accuracy.AppendJSON = %s, want %s", got, want)
+		}
+	}
+}
+
+// contractAdminToken is the contract server's static admin token
+const contractAdminToken = "contract-admin-token"
+
+// scrubbed are the fields of account responses that differ between runs
+var scrubbed = []string{
+	"id", "author_id", "reporter_id", "parent_id", "target", "class", "code", "url",
+	"token", "refresh_token", "session",
+	"created_at", "updated_at", "expires_at", "refresh_expires_at", "first_at", "last_at",
+	"earned_at", "checked_at",
+}
+
+// newAccountContractServer routes the endpoints of signed-in users and
+// staff as main does, with every store in memory. signIn registers an
+// account with a role and returns its Authorization header.
+func newAccountContractServer(t *testing.T) (*gin.Engine, func(email, role string) map[string]string) {
+	t.Helper()
+	st := store.New(models.GetSolarSystemBodies())
+	tours, err := store.OpenTours("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	translations, err := store.OpenTranslations("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, assets.ManifestFile), []byte("[]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	as, err := assets.Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	us, sessions := newAccounts(t)
+	emailTokens, err := users.OpenEmailTokens("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	discussion, err := comments.Open("", comments.Limits{PerMinute: 10, PerDay: 100, MaxLinks: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	complaints, err := reports.Open("", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classrooms, err := classes.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	userProgress, err := progress.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	badges, err := achievements.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sharedScenes, err := scenes.Open("", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sbs, err := sandbox.Open("", sandbox.Limits{PerUser: 2, Total: 100, MaxEdits: 10, TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditLog, err := audit.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api := r.Group("/api")
+	api.GET("/sync", GetSync(st))
+	offlineBundle := NewOfflineBundle(st, translations, tours, as)
+	api.GET("/offline-bundle/manifest", GetOfflineManifest(offlineBundle))
+	api.POST("/auth/register", Register(us, sessions, emailTokens, nil))
+	api.POST("/auth/login", Login(us, sessions))
+	api.GET("/me", middleware.UserAuth(sessions, true), GetMe(us))
+	api.GET("/planets/:name/comments", middleware.UserAuth(sessions, false), GetComments(st, discussion))
+	api.POST("/planets/:name/comments", middleware.UserAuth(sessions, true), PostComment(st, discussion))
+	api.POST("/reports", middleware.UserAuth(sessions, true), PostReport(complaints, discussion))
+	api.POST("/scenes", CreateScene(sharedScenes, time.Hour, 24*time.Hour))
+	api.GET("/scenes/:id", GetScene(sharedScenes))
+	api.POST("/sandboxes", middleware.UserAuth(sessions, true), CreateSandbox(sbs))
+	api.GET("/sandboxes/:id/planets", middleware.UserAuth(sessions, false), GetSandboxPlanets(st, sbs))
+	api.PATCH("/sandboxes/:id/planets/:name", middleware.UserAuth(sessions, true), EditSandboxPlanet(st, sbs))
+	signedIn := api.Group("", middleware.UserAuth(sessions, true))
+	signedIn.GET("/me/progress", GetProgress(st, tours, userProgress))
+	signedIn.PUT("/me/progress/bodies/:name", ExploreBody(st, tours, userProgress))
+	signedIn.GET("/me/achievements", GetAchievements(badges, userProgress))
+	signedIn.GET("/classes", GetClasses(classrooms))
+	signedIn.POST("/classes", CreateClass(classrooms, us))
+	signedIn.POST("/classes/join", JoinClass(classrooms))
+	signedIn.GET("/classes/:id/progress", GetClassProgress(classrooms, userProgress))
+	admin := api.Group("/admin", middleware.AdminAuth(contractAdminToken, sessions, us))
+	admin.GET("/comments", middleware.Require(users.PermModerate), ListComments(discussion))
+	admin.GET("/reports", middleware.Require(users.PermModerate), ListReports(complaints, discussion))
+	admin.PUT("/badges/:id", middleware.Require(users.PermContent), PutBadge(st, tours, badges, auditLog))
+	admin.GET("/validation", middleware.Require(users.PermAdmin), GetValidation(st))
+	admin.GET("/users", middleware.Require(users.PermAdmin), ListUsers(us))
+	return r, func(email, role string) map[string]string { return signIn(t, us, sessions, email, role) }
+}
+
+// TestContractAccounts pins the responses of the endpoints for signed-in
+// users and staff, in a session that builds on itself: a path or body
+// may name, in braces, a value an earlier step saved. IDs, tokens and
+// times differ from run to run and are scrubbed before comparing.
+func TestContractAccounts(t *testing.T) {
+	r, signIn := newAccountContractServer(t)
+	ana, tess := signIn("ana@example.com", users.RoleViewer), signIn("tess@example.com", users.RoleTeacher)
+	admin := map[string]string{"Authorization": "Bearer " + contractAdminToken, "X-Admin-User": "mira"}
+
+	steps := []struct {
+		golden string
+		method string
+		path   string
+		header map[string]string
+		body   string
+		status int
+		save   string // name=path of a value in the response to save, like comment=data.id
+	}{
+		{"sync_full", "GET", "/api/sync", nil, "", http.StatusOK, ""},
+		{"sync_since_current", "GET", "/api/sync?since=1", nil, "", http.StatusOK, ""},
+		{"offline_manifest", "GET", "/api/offline-bundle/manifest", nil, "", http.StatusOK, ""},
+		{"auth_register", "POST", "/api/auth/register", nil, `{"email": "vera@example.com", "name": "Vera", "password": "correct horse battery"}`, http.StatusCreated, ""},
+		{"auth_register_taken", "POST", "/api/auth/register", nil, `{"email": "vera@example.com", "name": "Vera", "password": "correct horse battery"}`, http.StatusConflict, ""},
+		{"auth_register_invalid", "POST", "/api/auth/register", nil, `{"email": "vera", "password": "short"}`, http.StatusUnprocessableEntity, ""},
+		{"auth_login_wrong_password", "POST", "/api/auth/login", nil, `{"email": "vera@example.com", "password": "wrong horse battery"}`, http.StatusUnauthorized, ""},
+		{"me", "GET", "/api/me", ana, "", http.StatusOK, ""},
+		{"me_anonymous", "GET", "/api/me", nil, "", http.StatusUnauthorized, ""},
+		{"comments_empty", "GET", "/api/planets/mars/comments", nil, "", http.StatusOK, ""},
+		{"comment_anonymous", "POST", "/api/planets/mars/comments", nil, `{"text": "Olympus Mons!"}`, http.StatusUnauthorized, ""},
+		{"comment_post", "POST", "/api/planets/mars/comments", ana, `{"text": "Olympus Mons is three times the height of Everest."}`, http.StatusCreated, "comment=data.id"},
+		{"comment_reply", "POST", "/api/planets/mars/comments", tess, `{"text": "And wider than Serbia.", "parent_id": "{comment}"}`, http.StatusCreated, ""},
+		{"comments_thread", "GET", "/api/planets/mars/comments", nil, "", http.StatusOK, ""},
+		{"report_post", "POST", "/api/reports", tess, `{"target": "{comment}", "reason": "spam"}`, http.StatusCreated, ""},
+		{"report_unknown_comment", "POST", "/api/reports", tess, `{"target": "nope", "reason": "spam"}`, http.StatusNotFound, ""},
+		{"admin_reports", "GET", "/api/admin/reports", admin, "", http.StatusOK, ""},
+		{"admin_comments", "GET", "/api/admin/comments", admin, "", http.StatusOK, ""},
+		{"admin_reports_viewer", "GET", "/api/admin/reports", ana, "", http.StatusForbidden, ""},
+		{"scene_create", "POST", "/api/scenes", nil, `{"camera": {"position": [0, 0, 50], "target": [0, 0, 0], "fov": 45}, "time": "2024-03-20T12:00:00Z", "selected": ["Mars"], "speed": 1}`, http.StatusCreated, "scene=data.id"},
+		{"scene_get", "GET", "/api/scenes/{scene}", nil, "", http.StatusOK, ""},
+		{"scene_not_found", "GET", "/api/scenes/nowhere", nil, "", http.StatusNotFound, ""},
+		{"sandbox_create", "POST", "/api/sandboxes", ana, `{"name": "Heavy Mars"}`, http.StatusCreated, "sandbox=data.sandbox.id"},
+		{"sandbox_edit", "PATCH", "/api/sandboxes/{sandbox}/planets/mars", ana, `{"mass": 1.28e24}`, http.StatusOK, ""},
+		{"sandbox_edit_invalid", "PATCH", "/api/sandboxes/{sandbox}/planets/mars", ana, `{"radius": 0, "name": "Ares"}`, http.StatusUnprocessableEntity, ""},
+		{"sandbox_planets_other_user", "GET", "/api/sandboxes/{sandbox}/planets", tess, "", http.StatusForbidden, ""},
+		{"progress_empty", "GET", "/api/me/progress", ana, "", http.StatusOK, ""},
+		{"progress_explore", "PUT", "/api/me/progress/bodies/mars", ana, "", http.StatusOK, ""},
+		{"achievements", "GET", "/api/me/achievements", ana, "", http.StatusOK, ""},
+		{"badge_put", "PUT", "/api/admin/badges/red-planet", admin, `{"title": {"sr": "Crvena planeta", "en": "Red planet"}, "rule": {"bodies": ["mars"]}}`, http.StatusCreated, ""},
+		{"badge_put_invalid", "PUT", "/api/admin/badges/nowhere", admin, `{"title": {"en": "Nowhere"}, "rule": {"bodies": ["vulcan"]}}`, http.StatusUnprocessableEntity, ""},
+		{"classes_create_viewer", "POST", "/api/classes", ana, `{"name": "7b"}`, http.StatusForbidden, ""},
+		{"classes_create", "POST", "/api/classes", tess, `{"name": "7b"}`, http.StatusCreated, "class=data.id code=data.code"},
+		{"classes_join", "POST", "/api/classes/join", ana, `{"code": "{code}"}`, http.StatusOK, ""},
+		{"classes_join_unknown", "POST", "/api/classes/join", ana, `{"code": "NOPE"}`, http.StatusNotFound, ""},
+		{"classes_list", "GET", "/api/classes", ana, "", http.StatusOK, ""},
+		{"class_progress", "GET", "/api/classes/{class}/progress", tess, "", http.StatusOK, ""},
+		{"admin_validation", "GET", "/api/admin/validation", admin, "", http.StatusOK, ""},
+		{"admin_users", "GET", "/api/admin/users", admin, "", http.StatusOK, ""},
+	}
+	saved := map[string]string{}
+	fill := func(s string) string {
+		for k, v := range saved {
+			s = strings.ReplaceAll(s, "{"+k+"}", v)
+		}
+		return s
+	}
+	for _, st := range steps {
+		w := testutil.Send(r, st.method, fill(st.path), st.header, fill(st.body))
+		if w.Code != st.status {
+			t.Fatalf("%s: status = %d, want %d: %s", st.golden, w.Code, st.status, w.Body.String())
+		}
+		for _, s := range strings.Fields(st.save) {
+			name, path, _ := strings.Cut(s, "=")
+			var v any
+			json.Unmarshal(w.Body.Bytes(), &v)
+			for _, k := range strings.Split(path, ".") {
+				v, _ = v.(map[string]any)[k]
+			}
+			if saved[name], _ = v.(string); saved[name] == "" {
+				t.Fatalf("%s: no %s in %s", st.golden, path, w.Body.String())
+			}
+		}
+		t.Run(st.golden, func(t *testing.T) {
+			testutil.Golden(t, st.golden, testutil.Scrub(w.Body.Bytes(), scrubbed...))
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+
+	"solar-system-explorer/backend/astro"
+	"solar-system-explorer/backend/clock"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Coordinate systems /api/coords/convert converts between, and the query
+// parameters each is given with
+var coordParams = map[string][2]string{
+	"ecliptic":   {"lon", "lat"},
+	"equatorial": {"ra", "dec"},
+	"horizontal": {"az", "alt"},
+}
+
+// GetCoordsConvert converts a direction on the sky between ecliptic
+// (?lon=&lat=), equatorial (?ra=&dec=, degrees) and horizontal (?az=&alt=)
+// coordinates, the steps the sky view takes from the ephemeris to the
+// screen. ?from= names the given system. The ecliptic and equator are the
+// mean ones at ?time= (default now), without nutation; horizontal
+// coordinates need an observer at ?observer_lat= and ?observer_lon=
+// (degrees, east positive) and are geometric, without refraction.
+func GetCoordsConvert(clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			From string `form:"from" binding:"required,oneof=ecliptic equatorial horizontal"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		t, ok := queryTime(c, clk)
+		if !ok {
+			return
+		}
+
+		names := coordParams[req.From]
+		var missing []FieldError
+		for _, name := range names {
+			if c.Query(name) == "" {
+				missing = append(missing, FieldError{Field: name, Message: "is required"})
+			}
+		}
+		hasObserver := c.Query("observer_lat") != "" || c.Query("observer_lon") != ""
+		if req.From == "horizontal" && !hasObserver {
+			missing = append(missing, FieldError{Field: "observer_lat", Message: "is required for horizontal coordinates"})
+		}
+		if len(missing) > 0 {
+			invalid(c, missing...)
+			return
+		}
+		lo := map[string]float64{"lon": 0, "ra": 0, "az": 0, "lat": -90, "dec": -90, "alt": -90}
+		hi := map[string]float64{"lon": 360, "ra": 360, "az": 360, "lat": 90, "dec": 90, "alt": 90}
+		a, ok := queryFloat(c, names[0], 0, lo[names[0]], hi[names[0]])
+		if !ok {
+			return
+		}
+		b, ok := queryFloat(c, names[1], 0, lo[names[1]], hi[names[1]])
+		if !ok {
+			return
+		}
+		var obs astro.Observer
+		if hasObserver {
+			if obs.Lat, ok = queryFloat(c, "observer_lat", 0, -90, 90); !ok {
+				return
+			}
+			if obs.Lon, ok = queryFloat(c, "observer_lon", 0, -180, 180); !ok {
+				return
+			}
+		}
+
+		jd := astro.JulianDay(t)
+		eps := astro.MeanObliquity(jd)
+		// Everything goes through the equator; the given system is
+		// returned as given
+		var (
+			ecl astro.Ecliptic
+			eq  astro.Equatorial
+			hz  astro.Horizontal
+		)
+		switch req.From {
+		case "ecliptic":
+			ecl = astro.Ecliptic{Lon: math.Mod(a, 360), Lat: b}
+			eq = ecl.ToEquatorial(eps)
+		case "equatorial":
+			eq = astro.NewEquatorial(a, b)
+			ecl = eq.ToEcliptic(eps)
+		case "horizontal":
+			hz = astro.Horizontal{Az: math.Mod(a, 360), Alt: b}
+			eq = hz.ToEquatorial(jd, obs)
+			ecl = eq.ToEcliptic(eps)
+		}
+		data := gin.H{
+			"time":       t,
+			"obliquity":  eps * 180 / math.Pi,
+			"ecliptic":   ecl,
+			"equatorial": eq,
+		}
+		if hasObserver {
+			if req.From != "horizontal" {
+				hz = eq.ToHorizontal(jd, obs)
+			}
+			data["observer"] = obs
+			data["local_sidereal_time"] = astro.LocalSiderealTime(jd, obs)
+			data["horizontal"] = hz
+		}
+		c.JSON(http.StatusOK, gin.H{"data": data, "meta": gin.H{"from": req.From}})
+	}
+}
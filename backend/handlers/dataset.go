@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDatasetVersion returns the current dataset version and ETag so clients
+// can cheaply check whether their cached bodies are stale
+func GetDatasetVersion(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := st.Version()
+		if notModified(c, v.ETag) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": v})
+	}
+}
+
+// GetDatasetChangelog lists dataset versions newer than ?since= (a version
+// number, default 0 for the whole retained history) with per-body diffs
+func GetDatasetChangelog(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var since int64
+		if v := c.Query("since"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				invalid(c, FieldError{Field: "since", Message: "must be a non-negative version number"})
+				return
+			}
+			since = n
+		}
+		versions := st.Changelog(since)
+		c.JSON(http.StatusOK, gin.H{
+			"data":    versions,
+			"count":   len(versions),
+			"current": st.Version().Version,
+		})
+	}
+}
+
+// GetSync brings an offline copy of the dataset up to date. ?since= is
+// the version the client last synced (meta.version of that response) and
+// ?etag= its meta.etag; an ETag alone, such as an offline bundle's
+// dataset, also works while the server remembers it. The response lists the bodies added or modified
+// since, in full, and the names of those removed. When the server can't
+// tell what changed — the version predates the retained history or the
+// last restart, or the ETag doesn't match — meta.full is true and
+// changed holds every body: the client replaces its copy.
+func GetSync(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Since int64  `form:"since" binding:"min=0"`
+			ETag  string `form:"etag" binding:"max=100"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		snap := snapshot(c.Request.Context(), st)
+		changed, removed, ok := st.Delta(req.Since, req.ETag, snap)
+		if !ok {
+			changed, removed = snap.Bodies, []string{}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{"changed": changed, "removed": removed},
+			"meta": gin.H{
+				"since":   req.Since,
+				"version": snap.Version,
+				"etag":    snap.ETag,
+				"full":    !ok,
+				"changed": len(changed),
+				"removed": len(removed),
+			},
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"solar-system-explorer/backend/clock"
+	"solar-system-explorer/backend/digest"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubscribeDigest starts the opt-in for the weekly email digest: {"email",
+// "lang"}. The answer is the same whether or not the address was already
+// subscribed. d is nil when mail isn't configured.
+func SubscribeDigest(d *digest.Digest) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Email digest is not enabled"})
+			return
+		}
+		var req struct {
+			Email string `json:"email" binding:"required"`
+			Lang  string `json:"lang"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		if req.Lang == "" {
+			req.Lang = c.GetHeader("Accept-Language")
+		}
+		err := d.Subscribe(req.Email, req.Lang)
+		switch {
+		case errors.Is(err, digest.ErrInvalidEmail):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+		case err != nil:
+			log.Printf("digest: subscribe: %v", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Could not send the confirmation email"})
+		default:
+			c.JSON(http.StatusAccepted, gin.H{"data": gin.H{"status": "confirmation_sent"}})
+		}
+	}
+}
+
+// ConfirmDigest completes the opt-in from the emailed link (?token=)
+func ConfirmDigest(d *digest.Digest) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Email digest is not enabled"})
+			return
+		}
+		sub, err := d.Confirm(c.Query("token"))
+		switch {
+		case errors.Is(err, digest.ErrUnknownToken):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown or expired link"})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusOK, gin.H{"data": gin.H{"email": sub.Email, "subscribed": true}})
+		}
+	}
+}
+
+// UnsubscribeDigest removes the subscriber owning ?token=. It answers GET
+// (the link in the footer) and POST (one-click List-Unsubscribe).
+func UnsubscribeDigest(d *digest.Digest) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Email digest is not enabled"})
+			return
+		}
+		sub, err := d.Unsubscribe(c.Query("token"))
+		switch {
+		case errors.Is(err, digest.ErrUnknownToken):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown link or already unsubscribed"})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusOK, gin.H{"data": gin.H{"email": sub.Email, "subscribed": false}})
+		}
+	}
+}
+
+// PreviewDigest renders this week's digest for ?lang= (default sr) along
+// with subscriber counts, for curators checking the content
+func PreviewDigest(d *digest.Digest, st *store.Store, clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Email digest is not enabled"})
+			return
+		}
+		lang := c.DefaultQuery("lang", "sr")
+		confirmed, pending := d.Counts()
+		c.JSON(http.StatusOK, gin.H{
+			"data": d.Preview(lang, st.Bodies(), clock.Now(c.Request.Context(), clk)),
+			"meta": gin.H{"subscribers": confirmed, "pending": pending},
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"solar-system-explorer/backend/astro"
+	"solar-system-explorer/backend/clock"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetEarthNow aggregates the live Earth view: solar declination, subsolar
+// point, day/night terminator and Moon phase. ?time= (RFC 3339) overrides
+// now; the time is returned in ?tz= (default UTC).
+func GetEarthNow(clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t, ok := queryTime(c, clk)
+		if !ok {
+			return
+		}
+		loc, ok := queryZone(c)
+		if !ok {
+			return
+		}
+
+		jd := astro.JulianDay(t)
+		sun := astro.Sun(jd)
+
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"time":              t.In(loc),
+				"julian_day":        jd,
+				"solar_declination": sun.Declination,
+				"sun":               sun,
+				"subsolar_point":    astro.SubsolarPoint(jd),
+				"terminator":        astro.Terminator(jd, 2),
+				"moon":              astro.Moon(jd),
+			},
+			"meta": zoneMeta(loc, t),
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+
+	"solar-system-explorer/backend/physics"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EscapeBudget is the speed and delta-v picture for leaving one body
+type EscapeBudget struct {
+	Body             string        `json:"body"`
+	AltitudeKm       float64       `json:"altitude_km"`
+	RadiusKm         float64       `json:"radius_km"`
+	EscapeVelocity   float64       `json:"escape_velocity_km_s"`  // at the altitude
+	OrbitalVelocity  float64       `json:"orbital_velocity_km_s"` // circular orbit at the altitude
+	OrbitalPeriodMin float64       `json:"orbital_period_minutes"`
+	DeltaV           DeltaV        `json:"delta_v"`
+	Comparison       []EscapeEntry `json:"comparison"`
+}
+
+// DeltaV is the ideal budget from the surface, without gravity or drag
+// losses; RotationAssist is what launching east from the equator saves
+type DeltaV struct {
+	ToOrbit        float64 `json:"to_orbit_km_s"`
+	ToEscape       float64 `json:"to_escape_km_s"`
+	RotationAssist float64 `json:"rotation_assist_km_s"`
+}
+
+// EscapeEntry lines bodies up for comparison, at their surfaces
+type EscapeEntry struct {
+	Body            string  `json:"body"`
+	EscapeVelocity  float64 `json:"escape_velocity_km_s"`
+	OrbitalVelocity float64 `json:"orbital_velocity_km_s"`
+	RelativeToEarth float64 `json:"relative_to_earth"`
+}
+
+// GetEscape returns escape and low-orbit velocities for ?body= at
+// ?altitude= km above the surface (the 1-bar level for the giants;
+// default 0), the ideal delta-v to reach them from the surface, and the
+// surface escape velocities of every body with a known mass.
+func GetEscape(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		body, ok := findPlanet(ctx, st, c.Query("body"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+		mass := bodyMass(body)
+		if mass == 0 || body.Radius == 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "The mass of " + body.Name + " is unknown"})
+			return
+		}
+		alt, ok := queryFloat(c, "altitude", 0, 0, 1e7)
+		if !ok {
+			return
+		}
+
+		surface := body.Radius * 1000
+		r := surface + alt*1000
+		orbital := physics.CircularVelocity(mass, r)
+		rotation := 0.0
+		if body.RotationPeriod != 0 {
+			rotation = 2 * math.Pi * surface / (body.RotationPeriod * physics.Day) // negative when retrograde
+		}
+		res := EscapeBudget{
+			Body:             body.Name,
+			AltitudeKm:       alt,
+			RadiusKm:         body.Radius,
+			EscapeVelocity:   round(physics.EscapeVelocity(mass, r)/1000, 3),
+			OrbitalVelocity:  round(orbital/1000, 3),
+			OrbitalPeriodMin: round(2*math.Pi*r/orbital/60, 1),
+			DeltaV: DeltaV{
+				ToOrbit:        round(physics.DeltaVToOrbit(mass, surface, r)/1000, 3),
+				ToEscape:       round(physics.EscapeVelocity(mass, surface)/1000, 3),
+				RotationAssist: round(rotation/1000, 3),
+			},
+			Comparison: []EscapeEntry{},
+		}
+
+		earthEscape := 0.0
+		for _, b := range solarSystemBodies(ctx, st) {
+			if b.Name == "Earth" && bodyMass(b) > 0 {
+				earthEscape = physics.EscapeVelocity(bodyMass(b), b.Radius*1000)
+			}
+		}
+		for _, b := range solarSystemBodies(ctx, st) {
+			m := bodyMass(b)
+			if m == 0 || b.Radius == 0 {
+				continue
+			}
+			v := physics.EscapeVelocity(m, b.Radius*1000)
+			e := EscapeEntry{
+				Body:            b.Name,
+				EscapeVelocity:  round(v/1000, 3),
+				OrbitalVelocity: round(physics.CircularVelocity(m, b.Radius*1000)/1000, 3),
+			}
+			if earthEscape > 0 {
+				e.RelativeToEarth = round(v/earthEscape, 3)
+			}
+			res.Comparison = append(res.Comparison, e)
+		}
+		c.JSON(http.StatusOK, gin.H{"data": res})
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/analytics"
+	"solar-system-explorer/backend/experiments"
+	"solar-system-explorer/backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// visitorCookie holds the random ID anonymous visitors are assigned
+// experiment variants by
+const visitorCookie = "visitor_id"
+
+// experimentUnit returns who experiments are assigned by: the signed-in
+// user, otherwise the visitor cookie, which is issued on first use
+func experimentUnit(c *gin.Context) string {
+	if claims, ok := middleware.CurrentUser(c); ok {
+		return "user:" + claims.Subject
+	}
+	if id, err := c.Cookie(visitorCookie); err == nil && len(id) == 32 {
+		if _, err := hex.DecodeString(id); err == nil {
+			return "visitor:" + id
+		}
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	id := hex.EncodeToString(b)
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(visitorCookie, id, int((365 * 24 * time.Hour).Seconds()), "/", "", secure, true)
+	return "visitor:" + id
+}
+
+// tracking reports whether the visitor allows usage to be recorded
+func tracking(c *gin.Context) bool {
+	return c.GetHeader("DNT") != "1" && c.GetHeader("Sec-GPC") != "1"
+}
+
+// GetClientConfig is the configuration the SPA loads at startup: the
+// visitor's variant of every experiment and, while maintenance or
+// read-only mode is on, the banner to show (null otherwise). Serving it
+// counts as an exposure, unless the visitor sends Do Not Track or Global
+// Privacy Control.
+func GetClientConfig(exp *experiments.Service, modes *middleware.Modes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		unit := experimentUnit(c)
+		if tracking(c) {
+			if err := exp.Expose(c.Request.Context(), unit, time.Now()); err != nil {
+				log.Printf("experiments: %v", err)
+			}
+		}
+		c.Header("Cache-Control", "private, no-store")
+		var banner *middleware.ModeState
+		if s := modes.State(); s.Active() {
+			banner = &s
+		}
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"experiments": exp.Assignments(unit), "banner": banner}})
+	}
+}
+
+// PostConversion records that the visitor reached a goal of an experiment
+// and answers with their variant. With Do Not Track or Global Privacy
+// Control nothing is recorded (204).
+func PostConversion(exp *experiments.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Goal string `json:"goal" binding:"required,max=100"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		unit := experimentUnit(c)
+		if !tracking(c) {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		variant, err := exp.Convert(c.Request.Context(), unit, c.Param("name"), req.Goal, time.Now())
+		switch {
+		case errors.Is(err, experiments.ErrUnknown):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Experiment or goal not found"})
+		case err != nil:
+			log.Printf("experiments: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Conversion could not be stored"})
+		default:
+			c.JSON(http.StatusAccepted, gin.H{"data": gin.H{"experiment": c.Param("name"), "variant": variant, "goal": req.Goal}})
+		}
+	}
+}
+
+// GetExperimentResults reports, for every experiment and variant over the
+// last ?days= days (default 30, max 365), the visitors exposed and the
+// conversions and conversion rate per goal
+func GetExperimentResults(exp *experiments.Service, col *analytics.Collector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Days int `form:"days" binding:"omitempty,min=1,max=365"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		if req.Days == 0 {
+			req.Days = 30
+		}
+		now := time.Now().UTC()
+		from := now.AddDate(0, 0, -req.Days)
+		events, err := col.Query(c.Request.Context(), from, now.Add(time.Second))
+		if err != nil {
+			log.Printf("analytics: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Events could not be read"})
+			return
+		}
+		results := exp.Results(events)
+		c.JSON(http.StatusOK, gin.H{
+			"data":  results,
+			"count": len(results),
+			"meta":  gin.H{"from": from, "to": now},
+		})
+	}
+}
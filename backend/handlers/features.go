@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/translit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPlanetFeatures returns the named surface features of a body or one of
+// the scene's moons as a GeoJSON FeatureCollection of points, optionally
+// limited to ?type= (crater, mons, mare, …). Feature diameters let the
+// map scale markers and pick which labels to show at each zoom level.
+func GetPlanetFeatures(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, ok := surfaceBody(c, st, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+		typ := c.Query("type")
+		features := make([]gin.H, 0)
+		for _, f := range models.GetSurfaceFeatures() {
+			if f.Body != body || (typ != "" && f.Type != typ) {
+				continue
+			}
+			features = append(features, gin.H{
+				"type": "Feature",
+				"id":   f.Name,
+				"geometry": gin.H{
+					"type":        "Point",
+					"coordinates": []float64{f.Lon, f.Lat},
+				},
+				"properties": gin.H{"name": f.Name, "name_sr": f.NameSR, "type": f.Type, "diameter_km": f.DiameterKm},
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"type": "FeatureCollection", "body": body, "features": features})
+	}
+}
+
+// surfaceBody resolves name to the English name of a dataset body or moon
+func surfaceBody(c *gin.Context, st *store.Store, name string) (string, bool) {
+	if p, ok := findPlanet(c.Request.Context(), st, name); ok {
+		return p.Name, true
+	}
+	if m, ok := findMoon(name); ok {
+		return m.Name, true
+	}
+	return "", false
+}
+
+// findMoon looks one of the scene's moons up by English or Serbian name
+func findMoon(name string) (models.Moon, bool) {
+	for _, m := range models.GetMoons() {
+		if translit.Equal(m.Name, name) || translit.Equal(m.NameSR, name) {
+			return m, true
+		}
+	}
+	return models.Moon{}, false
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/translit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GlossaryEntry is a glossary term with its text resolved for the
+// request's locale chain
+type GlossaryEntry struct {
+	ID         string            `json:"id"`
+	Term       string            `json:"term"`
+	Definition string            `json:"definition"`
+	Locale     string            `json:"locale"` // of the definition
+	Bodies     []GlossaryBody    `json:"bodies"`
+	Related    []GlossaryRelated `json:"related"`
+}
+
+// GlossaryBody links a term to a body page
+type GlossaryBody struct {
+	Name        string `json:"name"` // English name, for /api/planets/:name
+	DisplayName string `json:"display_name"`
+}
+
+// GlossaryRelated links a term to another one
+type GlossaryRelated struct {
+	ID   string `json:"id"`
+	Term string `json:"term"`
+}
+
+// GlossarySegment is a run of annotated text; Term is the ID of the
+// glossary term the run is an occurrence of, if any
+type GlossarySegment struct {
+	Text string `json:"text"`
+	Term string `json:"term,omitempty"`
+}
+
+// glossaryEntry resolves t along chain, linking the bodies found in
+// bodies or among the scene's moons and leaving out the others
+func glossaryEntry(t models.GlossaryTerm, bodies []models.Planet, chain []string) GlossaryEntry {
+	term, _ := localText(t.Term, chain)
+	def, locale := localText(t.Definition, chain)
+	e := GlossaryEntry{ID: t.ID, Term: term, Definition: def, Locale: locale, Bodies: []GlossaryBody{}, Related: []GlossaryRelated{}}
+	for _, name := range t.Bodies {
+		body, ok := findBody(bodies, name)
+		if !ok {
+			m, isMoon := findMoon(name)
+			if !isMoon {
+				continue
+			}
+			body = models.Planet{Name: m.Name, NameSR: m.NameSR, Translations: m.Translations}
+		}
+		e.Bodies = append(e.Bodies, GlossaryBody{Name: body.Name, DisplayName: localize(body, chain).DisplayName})
+	}
+	for _, id := range t.Related {
+		if r, ok := findTerm(id); ok {
+			term, _ := localText(r.Term, chain)
+			e.Related = append(e.Related, GlossaryRelated{ID: r.ID, Term: term})
+		}
+	}
+	return e
+}
+
+// findTerm looks a glossary term up by ID
+func findTerm(id string) (models.GlossaryTerm, bool) {
+	for _, t := range models.GetGlossary() {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return models.GlossaryTerm{}, false
+}
+
+// initial is the first letter of s, upper case
+func initial(s string) string {
+	r, _ := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r))
+}
+
+// GetGlossary lists the glossary in alphabetical order of the terms in
+// the locale negotiated from ?lang= or Accept-Language, each with its
+// definition and links to bodies and related terms. ?letter= keeps the
+// terms starting with that letter, compared without diacritics or
+// script, so ?letter=p also finds "Перихел". meta.letters are the
+// initials of all terms, for an A–Z index.
+func GetGlossary(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Letter string `form:"letter"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		if req.Letter != "" {
+			if r, _ := utf8.DecodeRuneInString(req.Letter); utf8.RuneCountInString(req.Letter) != 1 || !unicode.IsLetter(r) {
+				invalid(c, FieldError{Field: "letter", Message: "must be a single letter"})
+				return
+			}
+		}
+		requested, chain := requestLocales(c)
+		bodies := solarSystemBodies(c.Request.Context(), st)
+
+		out := []GlossaryEntry{}
+		seen := make(map[string]bool)
+		letters := []string{}
+		for _, t := range models.GetGlossary() {
+			e := glossaryEntry(t, bodies, chain)
+			if l := initial(e.Term); !seen[l] {
+				seen[l] = true
+				letters = append(letters, l)
+			}
+			if req.Letter == "" || translit.Fold(initial(e.Term)) == translit.Fold(req.Letter) {
+				out = append(out, e)
+			}
+		}
+		sort.SliceStable(out, func(i, j int) bool { return translit.Fold(out[i].Term) < translit.Fold(out[j].Term) })
+		sort.SliceStable(letters, func(i, j int) bool { return translit.Fold(letters[i]) < translit.Fold(letters[j]) })
+
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.JSON(http.StatusOK, gin.H{
+			"data":  out,
+			"count": len(out),
+			"meta":  gin.H{"letters": letters, "requested": requested, "fallbacks": chain},
+		})
+	}
+}
+
+// GetGlossaryTerm returns one glossary term by ID, localized like
+// GetGlossary
+func GetGlossaryTerm(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t, ok := findTerm(c.Param("id"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Term not found"})
+			return
+		}
+		requested, chain := requestLocales(c)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.JSON(http.StatusOK, gin.H{
+			"data": glossaryEntry(t, solarSystemBodies(c.Request.Context(), st), chain),
+			"meta": gin.H{"requested": requested, "fallbacks": chain},
+		})
+	}
+}
+
+// GetGlossaryAnnotations splits the description of ?body= (a body or one
+// of the scene's moons), localized like /api/planets/:name and for the
+// same ?audience=, into segments, marking the first occurrence of each
+// glossary term so the frontend can wrap it in a tooltip. The terms found
+// come with their definitions.
+func GetGlossaryAnnotations(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Body string `form:"body" binding:"required"`
+			audienceQuery
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		body, ok := findTextBody(c, st, req.Body)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+		requested, chain := requestLocales(c)
+		text := req.localize(body, chain)
+		segments, found := annotate(text.Description, text.Locale)
+
+		bodies := solarSystemBodies(c.Request.Context(), st)
+		terms := make([]GlossaryEntry, 0, len(found))
+		for _, t := range found {
+			terms = append(terms, glossaryEntry(t, bodies, chain))
+		}
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"body":     body.Name,
+				"locale":   text.Locale,
+				"segments": segments,
+				"terms":    terms,
+			},
+			"meta": gin.H{"requested": requested, "fallbacks": chain},
+		})
+	}
+}
+
+// termForms returns the spellings of t in text written in locale: the
+// term and its aliases, transliterated for sr-Cyrl
+func termForms(t models.GlossaryTerm, locale string) []string {
+	lang, cyrillic := locale, false
+	switch locale {
+	case "sr-Latn":
+		lang = baseLocale
+	case "sr-Cyrl":
+		lang, cyrillic = baseLocale, true
+	}
+	if t.Term[lang] == "" {
+		lang, _, _ = strings.Cut(lang, "-")
+	}
+	var forms []string
+	for _, f := range append([]string{t.Term[lang]}, t.Aliases[lang]...) {
+		if f == "" {
+			continue
+		}
+		if cyrillic {
+			f = translit.ToCyrillic(f)
+		}
+		forms = append(forms, f)
+	}
+	return forms
+}
+
+// annotate splits text into segments at the first whole-word occurrence
+// of each glossary term, ignoring case, and returns the terms found in
+// the order they occur. Longer forms win, so "gas giant" is one match.
+func annotate(text, locale string) ([]GlossarySegment, []models.GlossaryTerm) {
+	type form struct {
+		runes []rune
+		term  int
+	}
+	glossary := models.GetGlossary()
+	var forms []form
+	for i, t := range glossary {
+		for _, f := range termForms(t, locale) {
+			forms = append(forms, form{[]rune(f), i})
+		}
+	}
+	sort.SliceStable(forms, func(i, j int) bool { return len(forms[i].runes) > len(forms[j].runes) })
+
+	rs := []rune(text)
+	isLetter := func(i int) bool { return i >= 0 && i < len(rs) && (unicode.IsLetter(rs[i]) || unicode.IsDigit(rs[i])) }
+	segments := []GlossarySegment{}
+	var found []models.GlossaryTerm
+	used := make(map[int]bool)
+	start := 0
+	for i := 0; i < len(rs); i++ {
+		if isLetter(i - 1) {
+			continue
+		}
+		for _, f := range forms {
+			end := i + len(f.runes)
+			if used[f.term] || end > len(rs) || isLetter(end) || !strings.EqualFold(string(rs[i:end]), string(f.runes)) {
+				continue
+			}
+			if start < i {
+				segments = append(segments, GlossarySegment{Text: string(rs[start:i])})
+			}
+			segments = append(segments, GlossarySegment{Text: string(rs[i:end]), Term: glossary[f.term].ID})
+			found = append(found, glossary[f.term])
+			used[f.term] = true
+			start, i = end, end-1
+			break
+		}
+	}
+	if start < len(rs) {
+		segments = append(segments, GlossarySegment{Text: string(rs[start:])})
+	}
+	return segments, found
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strings"
+
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/physics"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/translit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Habitable-zone placement of a body
+const (
+	zoneHot          = "too_hot"
+	zoneOptimistic   = "optimistic"
+	zoneConservative = "conservative"
+	zoneCold         = "too_cold"
+)
+
+// HabitableZone is a star's habitable zone and where its planets sit in it
+type HabitableZone struct {
+	Star   HZStar   `json:"star"`
+	Zone   HZBounds `json:"zone"`
+	Bodies []HZBody `json:"bodies"`
+}
+
+// HZStar is the star the zone is computed for
+type HZStar struct {
+	Name         string  `json:"name"`
+	NameSR       string  `json:"name_sr"`
+	SpectralType string  `json:"spectral_type"`
+	Luminosity   float64 `json:"luminosity"`  // solar luminosities
+	Temperature  float64 `json:"temperature"` // K
+	DistanceLy   float64 `json:"distance_ly,omitempty"`
+}
+
+// HZBounds are the zone's edges in AU. Liquid surface water is likely
+// between the conservative edges and possible between the optimistic ones.
+type HZBounds struct {
+	OptimisticInner   float64 `json:"optimistic_inner_au"`
+	ConservativeInner float64 `json:"conservative_inner_au"`
+	ConservativeOuter float64 `json:"conservative_outer_au"`
+	OptimisticOuter   float64 `json:"optimistic_outer_au"`
+}
+
+// HZBody is one planet of the system. SunEquivalent is the distance from
+// the Sun that gets the same flux, for drawing systems on a common scale.
+type HZBody struct {
+	Name          string  `json:"name"`
+	NameSR        string  `json:"name_sr,omitempty"`
+	DistanceAU    float64 `json:"distance_au"`
+	Insolation    float64 `json:"insolation"` // relative to Earth
+	SunEquivalent float64 `json:"sun_equivalent_au"`
+	Zone          string  `json:"zone"`
+	InZone        bool    `json:"in_zone"` // inside the optimistic zone
+}
+
+// GetHabitableZone computes the habitable zone of the Sun or an exoplanet
+// host star from its luminosity and temperature (Kopparapu et al. 2014)
+// and places each of the star's planets in it
+func GetHabitableZone(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		star, bodies, ok := hzSystem(c, st, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Star not found; use the Sun or one of: " + strings.Join(hostStarNames(), ", ")})
+			return
+		}
+		zone := HZBounds{
+			OptimisticInner:   round(physics.RecentVenus.Distance(star.Luminosity, star.Temperature), 4),
+			ConservativeInner: round(physics.RunawayGreenhouse.Distance(star.Luminosity, star.Temperature), 4),
+			ConservativeOuter: round(physics.MaximumGreenhouse.Distance(star.Luminosity, star.Temperature), 4),
+			OptimisticOuter:   round(physics.EarlyMars.Distance(star.Luminosity, star.Temperature), 4),
+		}
+		for i, b := range bodies {
+			flux := physics.Insolation(star.Luminosity, b.DistanceAU)
+			b.Insolation = significant(flux, 3)
+			b.SunEquivalent = significant(1/math.Sqrt(flux), 3)
+			switch {
+			case flux > physics.RecentVenus.Flux(star.Temperature):
+				b.Zone = zoneHot
+			case flux > physics.RunawayGreenhouse.Flux(star.Temperature):
+				b.Zone = zoneOptimistic
+			case flux >= physics.MaximumGreenhouse.Flux(star.Temperature):
+				b.Zone = zoneConservative
+			case flux >= physics.EarlyMars.Flux(star.Temperature):
+				b.Zone = zoneOptimistic
+			default:
+				b.Zone = zoneCold
+			}
+			b.InZone = b.Zone == zoneOptimistic || b.Zone == zoneConservative
+			bodies[i] = b
+		}
+		c.JSON(http.StatusOK, gin.H{"data": HabitableZone{Star: star, Zone: zone, Bodies: bodies}})
+	}
+}
+
+// hzSystem resolves name to the Sun, with the dataset's bodies, or to a
+// host star with its exoplanets
+func hzSystem(c *gin.Context, st *store.Store, name string) (HZStar, []HZBody, bool) {
+	ctx := c.Request.Context()
+	if sun, ok := findPlanet(ctx, st, name); ok && sun.IsStar {
+		star := HZStar{Name: sun.Name, NameSR: sun.NameSR, SpectralType: "G2V", Luminosity: 1, Temperature: physics.SunTemperature}
+		var bodies []HZBody
+		for _, p := range solarSystemBodies(ctx, st) {
+			if !p.IsStar && p.DistanceFromSun > 0 {
+				bodies = append(bodies, HZBody{Name: p.Name, NameSR: p.NameSR, DistanceAU: p.DistanceFromSun})
+			}
+		}
+		return star, bodies, true
+	}
+	for _, h := range models.GetHostStars() {
+		if !translit.Equal(h.Name, name) && !translit.Equal(h.NameSR, name) {
+			continue
+		}
+		star := HZStar{Name: h.Name, NameSR: h.NameSR, SpectralType: h.SpectralType, Luminosity: h.Luminosity, Temperature: h.Temperature, DistanceLy: h.DistanceLy}
+		bodies := make([]HZBody, 0, len(h.Planets))
+		for _, p := range h.Planets {
+			bodies = append(bodies, HZBody{Name: p.Name, DistanceAU: p.SemiMajorAxis})
+		}
+		return star, bodies, true
+	}
+	return HZStar{}, nil, false
+}
+
+func hostStarNames() []string {
+	var names []string
+	for _, h := range models.GetHostStars() {
+		names = append(names, h.Name)
+	}
+	return names
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/clock"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/translit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HistoryEvent is an anniversary of a historical event, its text resolved
+// for the request's locale chain
+type HistoryEvent struct {
+	Date     string `json:"date"` // the anniversary, YYYY-MM-DD
+	Year     int    `json:"year"` // when it happened
+	YearsAgo int    `json:"years_ago"`
+	Kind     string `json:"kind"`
+	Body     string `json:"body,omitempty"`
+	Text     string `json:"text"`
+	Locale   string `json:"locale"`
+}
+
+// historyFeedDays is how many days back the RSS feed reaches, so a reader
+// polling weekly misses nothing
+const historyFeedDays = 7
+
+// anniversaries returns the events whose anniversary falls in the days
+// days from midnight from, in date order and, within a day, oldest
+// first. Only events of kind are kept unless it is empty.
+func anniversaries(from time.Time, days int, kind string, chain []string) []HistoryEvent {
+	out := []HistoryEvent{}
+	for i := range days {
+		day := from.AddDate(0, 0, i)
+		for _, e := range models.GetHistoricalEvents() {
+			if e.Month != day.Month() || e.Day != day.Day() || e.Year > day.Year() || kind != "" && e.Kind != kind {
+				continue
+			}
+			text, locale := localText(e.Text, chain)
+			out = append(out, HistoryEvent{
+				Date:     day.Format(time.DateOnly),
+				Year:     e.Year,
+				YearsAgo: day.Year() - e.Year,
+				Kind:     e.Kind,
+				Body:     e.Body,
+				Text:     text,
+				Locale:   locale,
+			})
+		}
+	}
+	slices.SortStableFunc(out, func(a, b HistoryEvent) int {
+		if a.Date != b.Date {
+			return strings.Compare(a.Date, b.Date)
+		}
+		return a.Year - b.Year
+	})
+	return out
+}
+
+// GetHistoryToday lists the "on this day" anniversaries in the history of
+// astronomy and spaceflight for the homepage ticker: launches, landings,
+// flybys and discoveries that happened on ?date= (YYYY-MM-DD, default
+// today in ?tz=) in an earlier year, and on the ?days= days (default 1,
+// at most 31) from it. ?kind= keeps one kind of event. Text follows ?lang=
+// and Accept-Language like /api/planets.
+func GetHistoryToday(clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Days int    `form:"days" binding:"omitempty,min=1,max=31"`
+			Kind string `form:"kind" binding:"omitempty,oneof=discovery launch landing flyby orbit crewed milestone"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		if req.Days == 0 {
+			req.Days = 1
+		}
+		loc, ok := queryZone(c)
+		if !ok {
+			return
+		}
+		from, ok := queryDate(c, clk, loc)
+		if !ok {
+			return
+		}
+		requested, chain := requestLocales(c)
+		list := anniversaries(from, req.Days, req.Kind, chain)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.JSON(http.StatusOK, gin.H{
+			"data":  list,
+			"count": len(list),
+			"meta": gin.H{
+				"date":      from.Format(time.DateOnly),
+				"days":      req.Days,
+				"requested": requested,
+				"fallbacks": chain,
+			},
+		})
+	}
+}
+
+// rss is an RSS 2.0 document
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Language    string    `xml:"language"`
+	TTL         int       `xml:"ttl"` // minutes
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title    string  `xml:"title"`
+	Link     string  `xml:"link"`
+	GUID     rssGUID `xml:"guid"`
+	PubDate  string  `xml:"pubDate"`
+	Category string  `xml:"category"`
+}
+
+type rssGUID struct {
+	Value     string `xml:",chardata"`
+	PermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// historyFeedText is the feed's channel text per language
+var historyFeedText = map[string][2]string{
+	"sr": {"Na današnji dan u istoriji svemira", "Godišnjice lansiranja, sletanja, preleta i otkrića u Sunčevom sistemu"},
+	"en": {"On this day in space history", "Anniversaries of launches, landings, flybys and discoveries in the solar system"},
+}
+
+// GetHistoryFeed is GetHistoryToday as an RSS 2.0 feed: the anniversaries
+// of the week up to ?date= (default today, UTC), newest first, each
+// published on its anniversary. Links point at /api/history/today under publicURL, or the
+// host the request came to when it is empty.
+func GetHistoryFeed(clk clock.Clock, publicURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		today, ok := queryDate(c, clk, time.UTC)
+		if !ok {
+			return
+		}
+		_, chain := requestLocales(c)
+		lang := factLocale(chain)
+		text := historyFeedText[strings.TrimSuffix(lang, "-Cyrl")]
+		if lang == "sr-Cyrl" {
+			text = [2]string{translit.ToCyrillic(text[0]), translit.ToCyrillic(text[1])}
+		}
+		base := strings.TrimSuffix(publicURL, "/")
+		if base == "" {
+			scheme := "http"
+			if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+				scheme = "https"
+			}
+			base = scheme + "://" + c.Request.Host
+		}
+
+		from := today.AddDate(0, 0, 1-historyFeedDays)
+		feed := rss{Version: "2.0", Channel: rssChannel{
+			Title:       text[0],
+			Link:        base + "/api/history/today",
+			Description: text[1],
+			Language:    lang,
+			TTL:         60,
+			Items:       []rssItem{},
+		}}
+		list := anniversaries(from, historyFeedDays, "", chain)
+		for i := len(list) - 1; i >= 0; i-- {
+			e := list[i]
+			day, _ := time.Parse(time.DateOnly, e.Date)
+			feed.Channel.Items = append(feed.Channel.Items, rssItem{
+				Title:    fmt.Sprintf("%d: %s", e.Year, e.Text),
+				Link:     base + "/api/history/today?date=" + e.Date,
+				GUID:     rssGUID{Value: fmt.Sprintf("history:%s:%d:%s:%s", e.Date, e.Year, e.Kind, e.Body)},
+				PubDate:  day.Format(time.RFC1123Z),
+				Category: e.Kind,
+			})
+		}
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Header("Content-Language", lang)
+		body, err := xml.MarshalIndent(feed, "", "  ")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not encode the feed"})
+			return
+		}
+		c.Data(http.StatusOK, "application/rss+xml; charset=utf-8", append([]byte(xml.Header), body...))
+	}
+}
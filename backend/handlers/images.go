@@ -0,0 +1,402 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"log"
+	"math"
+	"net/http"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"solar-system-explorer/backend/assets"
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/imaging"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/translit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// thumbnailWidths are the downscaled copies made of every uploaded image,
+// as levels of detail 1 and 2
+var thumbnailWidths = []int{1024, 256}
+
+// imageUpload is the metadata sent with an uploaded texture or photo
+type imageUpload struct {
+	Body        string `form:"body" binding:"required"`
+	Kind        string `form:"kind" binding:"required,oneof=texture photo"`
+	Name        string `form:"name" binding:"max=64"` // defaults to the file name
+	License     string `form:"license" binding:"required,max=200"`
+	Attribution string `form:"attribution" binding:"max=500"`
+	Source      string `form:"source" binding:"omitempty,url"`
+}
+
+// ImageVariant is one encoding of an image at one size
+type ImageVariant struct {
+	LOD       int    `json:"lod"`
+	URL       string `json:"url"`
+	MediaType string `json:"media_type"`
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+	Size      int64  `json:"size_bytes"`
+}
+
+// BodyImage is an uploaded texture or photo of a body with the thumbnails
+// and re-encodings made from it, finest first
+type BodyImage struct {
+	ImageVariant
+	Kind        string         `json:"kind"`
+	License     string         `json:"license"`
+	Attribution string         `json:"attribution,omitempty"`
+	Source      string         `json:"source,omitempty"`
+	Variants    []ImageVariant `json:"variants"`
+}
+
+// slug turns a name into a lowercase ASCII path segment
+func slug(s string) string {
+	var b strings.Builder
+	for _, r := range translit.Fold(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '_':
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "-") {
+				b.WriteByte('-')
+			}
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// PostAsset takes a texture or photo of a body as the multipart field
+// "file", with its metadata in the query. The image is checked against
+// limits before it is decoded; textures must be equirectangular (twice as
+// wide as tall). The original is stored under <kind>s/<body>/ together
+// with thumbnails and, when the server is built with those encoders, WebP
+// and AVIF copies, all listed in the manifest as variants of the original.
+// Uploading the same name again replaces the image and drops variants
+// that weren't made again.
+func PostAsset(st *store.Store, as *assets.Store, auditLog *audit.Log, maxBytes int64, limits imaging.Limits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req imageUpload
+		if !bindQuery(c, &req) {
+			return
+		}
+		body, ok := surfaceBody(c, st, req.Body)
+		if !ok {
+			invalid(c, FieldError{Field: "body", Message: "unknown body"})
+			return
+		}
+		if as.Storage() == "" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Asset storage is not configured"})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		fh, err := c.FormFile("file")
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Assets are limited to %d MiB", maxBytes>>20)})
+			return
+		case err != nil:
+			invalid(c, FieldError{Field: "file", Message: "a multipart/form-data file field is required"})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			log.Printf("Reading uploaded image: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read the upload"})
+			return
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			log.Printf("Reading uploaded image: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read the upload"})
+			return
+		}
+		img, format, err := imaging.Decode(data, limits)
+		if err != nil {
+			invalid(c, FieldError{Field: "file", Message: err.Error()})
+			return
+		}
+		w, h := img.Bounds().Dx(), img.Bounds().Dy()
+		if req.Kind == assets.KindTexture && w != 2*h {
+			invalid(c, FieldError{Field: "file", Message: fmt.Sprintf("textures must be equirectangular, twice as wide as tall; got %d×%d", w, h)})
+			return
+		}
+		name := req.Name
+		if name == "" {
+			name = strings.TrimSuffix(fh.Filename, path.Ext(fh.Filename))
+		}
+		if name = slug(name); name == "" {
+			invalid(c, FieldError{Field: "name", Message: "must contain letters or digits"})
+			return
+		}
+
+		base := path.Join(req.Kind+"s", slug(body), name)
+		orig := base + "." + strings.Replace(format, "jpeg", "jpg", 1)
+		meta := assets.Asset{
+			Body:        body,
+			Kind:        req.Kind,
+			License:     req.License,
+			Attribution: req.Attribution,
+			Source:      req.Source,
+		}
+		type upload struct {
+			a    assets.Asset
+			data []byte
+		}
+		a := meta
+		a.Path, a.Width, a.Height = orig, w, h
+		uploads := []upload{{a, data}}
+		add := func(lod int, suffix string, m image.Image, enc imaging.Encoder) error {
+			var buf bytes.Buffer
+			if err := enc.Encode(&buf, m); err != nil {
+				return fmt.Errorf("%s: %w", enc.Format, err)
+			}
+			a := meta
+			a.Path, a.LOD, a.VariantOf = base+suffix+enc.Ext, lod, orig
+			a.Width, a.Height = m.Bounds().Dx(), m.Bounds().Dy()
+			uploads = append(uploads, upload{a, buf.Bytes()})
+			return nil
+		}
+		opaque := imaging.Opaque(img)
+		fallback, _ := imaging.Lookup("jpeg")
+		if !opaque {
+			fallback, _ = imaging.Lookup("png")
+		}
+		var encErr error
+		for _, enc := range imaging.Modern() {
+			if opaque || enc.KeepsAlpha {
+				encErr = errors.Join(encErr, add(0, "", img, enc))
+			}
+		}
+		for i, tw := range thumbnailWidths {
+			if tw >= w {
+				continue
+			}
+			thumb := imaging.Resize(img, tw)
+			suffix := fmt.Sprintf("-%d", tw)
+			encErr = errors.Join(encErr, add(i+1, suffix, thumb, fallback))
+			for _, enc := range imaging.Modern() {
+				if opaque || enc.KeepsAlpha {
+					encErr = errors.Join(encErr, add(i+1, suffix, thumb, enc))
+				}
+			}
+		}
+		if encErr != nil {
+			log.Printf("Encoding variants of %s: %v", orig, encErr)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process the image"})
+			return
+		}
+
+		ctx := c.Request.Context()
+		before, existed := as.Get(orig)
+		stale := make(map[string]bool)
+		for _, v := range as.ForBody(body, req.Kind) {
+			if v.VariantOf == orig {
+				stale[v.Path] = true
+			}
+		}
+		created := make([]assets.Asset, 0, len(uploads))
+		var added []string // paths that weren't listed before this upload
+		for _, u := range uploads {
+			if _, ok := as.Get(u.a.Path); !ok {
+				added = append(added, u.a.Path)
+			}
+			a, err := as.Put(ctx, u.a, bytes.NewReader(u.data), int64(len(u.data)))
+			if err != nil {
+				log.Printf("Storing asset %s: %v", u.a.Path, err)
+				// Take back what this upload added; replaced files stay replaced
+				for _, p := range added {
+					if err := as.Delete(ctx, p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+						log.Printf("Removing partial upload %s: %v", p, err)
+					}
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store the asset"})
+				return
+			}
+			delete(stale, a.Path)
+			created = append(created, a)
+		}
+		for p := range stale {
+			if err := as.Delete(ctx, p); err != nil {
+				log.Printf("Removing stale variant %s: %v", p, err)
+			}
+		}
+
+		status, action := http.StatusCreated, audit.ActionCreate
+		var prev any
+		if existed {
+			status, action, prev = http.StatusOK, audit.ActionUpdate, before
+		}
+		recordAudit(c, auditLog, action, "asset", orig, prev, created)
+		c.JSON(status, gin.H{"data": created, "count": len(created)})
+	}
+}
+
+// GetPlanetImages lists the textures and photos of a body (or one of the
+// scene's moons) with their variants, so the client can pick a size and
+// the best format the browser accepts. Private images are left out.
+func GetPlanetImages(st *store.Store, as *assets.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Kind string `form:"kind" binding:"omitempty,oneof=texture photo"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		body, ok := surfaceBody(c, st, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+		kinds := []string{assets.KindTexture, assets.KindPhoto}
+		if req.Kind != "" {
+			kinds = []string{req.Kind}
+		}
+		out := []BodyImage{}
+		for _, kind := range kinds {
+			list := as.ForBody(body, kind)
+			byPath := make(map[string]int)
+			for _, a := range list {
+				if a.VariantOf != "" || a.Private {
+					continue
+				}
+				byPath[a.Path] = len(out)
+				out = append(out, BodyImage{
+					ImageVariant: imageVariant(a),
+					Kind:         a.Kind,
+					License:      a.License,
+					Attribution:  a.Attribution,
+					Source:       a.Source,
+					Variants:     []ImageVariant{},
+				})
+			}
+			for _, a := range list {
+				if i, ok := byPath[a.VariantOf]; ok && !a.Private {
+					out[i].Variants = append(out[i].Variants, imageVariant(a))
+				}
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"data": out, "count": len(out)})
+	}
+}
+
+// ServeImage serves a JPEG or PNG texture or photo from the asset
+// manifest in the smallest format the browser accepts (AVIF, then WebP,
+// when the server is built with those encoders), scaled down to ?w= if
+// given. Transcoded copies are kept in cache, keyed by the file version,
+// so a replaced image is transcoded again. With nothing to gain the
+// original is served as /assets/ would. Private images aren't proxied.
+func ServeImage(as *assets.Store, cache *imaging.Cache) gin.HandlerFunc {
+	serveOriginal := ServeAsset(as)
+	slots := make(chan struct{}, runtime.NumCPU()) // decoded textures take hundreds of MB
+	return func(c *gin.Context) {
+		var req struct {
+			W int `form:"w" binding:"omitempty,oneof=256 512 1024 2048"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		p := strings.TrimPrefix(c.Param("path"), "/")
+		a, ok := as.Get(p)
+		ext := strings.ToLower(path.Ext(p))
+		if !ok || a.Private || (a.Kind != assets.KindTexture && a.Kind != assets.KindPhoto) || (ext != ".jpg" && ext != ".jpeg" && ext != ".png") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+			return
+		}
+		c.Header("Vary", "Accept")
+		enc, modern := imaging.Negotiate(c.GetHeader("Accept"), ext == ".png")
+		if !modern && (req.W == 0 || (a.Width > 0 && req.W >= a.Width)) {
+			serveOriginal(c)
+			return
+		}
+		if !modern {
+			enc, _ = imaging.Lookup(strings.TrimPrefix(strings.Replace(ext, "jpg", "jpeg", 1), "."))
+		}
+
+		key := imaging.Key(a.Path, a.ETag(), enc.Format, strconv.Itoa(req.W))
+		data, hit := cache.Get(key)
+		if !hit {
+			select {
+			case slots <- struct{}{}:
+			case <-c.Request.Context().Done():
+				return
+			}
+			var err error
+			data, err = transcode(c.Request.Context(), as, p, req.W, enc)
+			<-slots
+			if errors.Is(err, fs.ErrNotExist) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+				return
+			}
+			if err != nil {
+				log.Printf("Transcoding %s to %s: %v", p, enc.Format, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process the image"})
+				return
+			}
+			if err := cache.Put(key, data); err != nil {
+				log.Printf("Caching %s as %s: %v", p, enc.Format, err)
+			}
+		}
+		c.Header("Content-Type", enc.MediaType)
+		c.Header("ETag", fmt.Sprintf(`"%s-%s-%d"`, strings.Trim(a.ETag(), `"`), enc.Format, req.W))
+		c.Header("Cache-Control", "public, max-age=86400")
+		http.ServeContent(c.Writer, c.Request, "", a.ModTime, bytes.NewReader(data))
+	}
+}
+
+// transcode reads the asset at p, scales it down to width if that is
+// narrower, and encodes it with enc
+func transcode(ctx context.Context, as *assets.Store, p string, width int, enc imaging.Encoder) ([]byte, error) {
+	f, _, err := as.Open(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	src, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := imaging.Decode(src, imaging.Limits{MinSide: 1, MaxSide: math.MaxInt})
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, imaging.Resize(img, width)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func imageVariant(a assets.Asset) ImageVariant {
+	mt := assets.ContentType(a.Path)
+	if mt == "" {
+		mt = mediaTypeByExt[strings.ToLower(path.Ext(a.Path))]
+	}
+	return ImageVariant{
+		LOD:       a.LOD,
+		URL:       "/assets/" + a.Path,
+		MediaType: mt,
+		Width:     a.Width,
+		Height:    a.Height,
+		Size:      a.Size,
+	}
+}
+
+var mediaTypeByExt = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+}
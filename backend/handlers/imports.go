@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/sbdb"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// importResult reports what an import did, or would do in a dry run, for
+// one requested designation
+type importResult struct {
+	Designation string                  `json:"designation"`
+	Action      string                  `json:"action,omitempty"` // create, update, unchanged
+	Body        *models.Planet          `json:"body,omitempty"`
+	Diff        map[string]audit.Change `json:"diff,omitempty"`
+	Error       string                  `json:"error,omitempty"`
+	Stale       bool                    `json:"stale,omitempty"` // SBDB was down; from an earlier fetch
+
+	before *models.Planet
+}
+
+// ImportSBDB pulls objects from the JPL Small-Body Database and upserts
+// them into the dataset. The body is {"designations": ["433", "Ceres"],
+// "dry_run": true}; a dry run returns the same report without applying it.
+// With a repository set, imported bodies are persisted there.
+func ImportSBDB(st *store.Store, client *sbdb.Client, auditLog *audit.Log, imports store.BodyRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Designations []string `json:"designations" binding:"required,min=1,max=50"`
+			DryRun       bool     `json:"dry_run"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+
+		ctx := c.Request.Context()
+		results := make([]importResult, 0, len(req.Designations))
+		var apply []models.Planet
+		for _, des := range req.Designations {
+			res := importResult{Designation: strings.TrimSpace(des)}
+			obj, err := client.Fetch(ctx, res.Designation)
+			var body models.Planet
+			if err == nil {
+				body, err = obj.Body()
+			}
+			if err != nil {
+				if !errors.Is(err, sbdb.ErrNotFound) {
+					log.Printf("sbdb import %q: %v", res.Designation, err)
+				}
+				res.Error = err.Error()
+				results = append(results, res)
+				continue
+			}
+
+			existing, exists := findPlanet(ctx, st, body.Name)
+			res.Body = &body
+			res.Stale = obj.Stale
+			switch {
+			case !exists:
+				res.Action = audit.ActionCreate
+				res.Diff, _ = audit.Diff(nil, body)
+			default:
+				res.before = &existing
+				res.Diff, _ = audit.Diff(existing, body)
+				res.Action = audit.ActionUpdate
+				if len(res.Diff) == 0 {
+					res.Action = "unchanged"
+				}
+			}
+			if res.Action != "unchanged" {
+				apply = append(apply, body)
+			}
+			results = append(results, res)
+		}
+
+		if !req.DryRun && len(apply) > 0 {
+			st.Upsert(apply...)
+			if imports != nil {
+				if err := imports.Save(ctx, apply); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Imported but failed to persist: " + err.Error()})
+					return
+				}
+			}
+			for _, res := range results {
+				if res.Body != nil && res.Action != "unchanged" {
+					recordAudit(c, auditLog, res.Action, "body", res.Body.Name, res.before, res.Body)
+				}
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data":    results,
+			"dry_run": req.DryRun,
+			"applied": !req.DryRun && len(apply) > 0,
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"solar-system-explorer/backend/jobs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJobs lists background jobs with their schedule and last run
+func GetJobs(scheduler *jobs.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := scheduler.Status()
+		c.JSON(http.StatusOK, gin.H{"data": status, "count": len(status)})
+	}
+}
+
+// RunJob triggers a job outside its schedule. It answers 202 at once; the
+// outcome shows up in GetJobs.
+func RunJob(scheduler *jobs.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := scheduler.Trigger(c.Param("name")); errors.Is(err, jobs.ErrUnknownJob) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"data": gin.H{"job": c.Param("name"), "triggered": true}})
+	}
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/astro"
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/clock"
+	"solar-system-explorer/backend/grs"
+	"solar-system-explorer/backend/orbits"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Altitudes a transit is worth observing at: Jupiter clear of the horizon
+// haze and the Sun below civil twilight
+const (
+	minJupiterAltitude = 10
+	maxSunAltitude     = -6
+)
+
+// GRSTransit is one crossing of the central meridian by the Great Red Spot
+type GRSTransit struct {
+	Time      time.Time `json:"time"`
+	Longitude float64   `json:"longitude"` // the spot's System II longitude
+	*Visibility
+}
+
+// Visibility is how well an observer can see Jupiter at some moment
+type Visibility struct {
+	JupiterAltitude float64 `json:"jupiter_altitude"`
+	SunAltitude     float64 `json:"sun_altitude"`
+	Observable      bool    `json:"observable"`
+}
+
+// jupiterSky returns Jupiter's visibility from obs over time. Without an
+// Earth and a Jupiter in the dataset it responds 422 and reports false.
+func jupiterSky(c *gin.Context, st *store.Store, obs astro.Observer) (func(time.Time) *Visibility, bool) {
+	bodies := solarSystemBodies(c.Request.Context(), st)
+	earth, hasEarth := findBody(bodies, "Earth")
+	jupiter, hasJupiter := findBody(bodies, "Jupiter")
+	if !hasEarth || !hasJupiter {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "The dataset has no Earth or Jupiter to place it in the sky"})
+		return nil, false
+	}
+	return func(t time.Time) *Visibility {
+		jd := astro.JulianDay(t)
+		_, ecl := seenFromEarth(jupiter, t, orbits.EarthOrigin(earth, t), jd)
+		jAlt := ecl.ToEquatorial(astro.MeanObliquity(jd)).ToHorizontal(jd, obs).Alt
+		sun := astro.Sun(jd)
+		sAlt := astro.NewEquatorial(sun.RightAscension, sun.Declination).ToHorizontal(jd, obs).Alt
+		return &Visibility{
+			JupiterAltitude: jAlt,
+			SunAltitude:     sAlt,
+			Observable:      jAlt >= minJupiterAltitude && sAlt <= maxSunAltitude,
+		}
+	}, true
+}
+
+// queryDate reads ?date= (YYYY-MM-DD, default today) as the midnight
+// starting it in loc
+func queryDate(c *gin.Context, clk clock.Clock, loc *time.Location) (time.Time, bool) {
+	day := clock.Now(c.Request.Context(), clk).In(loc)
+	if v := c.Query("date"); v != "" {
+		d, err := time.ParseInLocation(time.DateOnly, v, loc)
+		if err != nil || d.Year() < 1800 || d.Year() > 2200 {
+			invalid(c, FieldError{Field: "date", Message: "must be a date between 1800-01-01 and 2200-12-31 as YYYY-MM-DD"})
+			return time.Time{}, false
+		}
+		day = d
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc), true
+}
+
+// GetGRSTransits lists when the Great Red Spot crosses the central meridian
+// of Jupiter's disc on ?date= (YYYY-MM-DD, default today), the day and the
+// times being in ?tz= (default UTC). The spot's System II longitude comes
+// from the tracking staff keep current (see package grs); meta has it and
+// how old it is. With ?observer_lat= and ?observer_lon= every transit also
+// has Jupiter's and the Sun's altitude and whether it is observable.
+func GetGRSTransits(tracker *grs.Tracker, st *store.Store, clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		loc, ok := queryZone(c)
+		if !ok {
+			return
+		}
+		from, ok := queryDate(c, clk, loc)
+		if !ok {
+			return
+		}
+		obs, ok := queryObserver(c)
+		if !ok {
+			return
+		}
+
+		to := from.AddDate(0, 0, 1)
+		tr := tracker.Get()
+		transits := []GRSTransit{}
+		for _, t := range grs.Transits(tr, from.UTC(), to.UTC()) {
+			transits = append(transits, GRSTransit{Time: t.In(loc), Longitude: tr.LongitudeAt(t)})
+		}
+
+		if obs != nil {
+			sky, ok := jupiterSky(c, st, *obs)
+			if !ok {
+				return
+			}
+			for i := range transits {
+				transits[i].Visibility = sky(transits[i].Time)
+			}
+		}
+
+		meta := zoneMeta(loc, from)
+		meta["date"] = from.Format(time.DateOnly)
+		meta["tracking"] = tr
+		meta["tracking_age_days"] = tr.Age(from).Hours() / 24
+		if obs != nil {
+			meta["observer"] = obs
+		}
+		c.JSON(http.StatusOK, gin.H{"data": transits, "count": len(transits), "meta": meta})
+	}
+}
+
+// MoonEvent is a Galilean moon event as listed for an observer
+type MoonEvent struct {
+	astro.MoonEvent
+	*Visibility
+}
+
+// GetMoonEvents lists the Galilean moons' events over the night of ?date=
+// (YYYY-MM-DD, default today), noon to noon in ?tz= (default UTC): the
+// starts and ends of transits across Jupiter's disc, of their shadows'
+// transits, of occultations behind the disc and of eclipses in Jupiter's
+// shadow. meta.positions is where each moon stands at midnight, in
+// Jupiter radii. With ?observer_lat= and ?observer_lon= every event also
+// has Jupiter's and the Sun's altitude and whether it is observable.
+func GetMoonEvents(st *store.Store, clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		loc, ok := queryZone(c)
+		if !ok {
+			return
+		}
+		day, ok := queryDate(c, clk, loc)
+		if !ok {
+			return
+		}
+		obs, ok := queryObserver(c)
+		if !ok {
+			return
+		}
+
+		from := day.Add(12 * time.Hour)
+		to := from.AddDate(0, 0, 1)
+		midnight := day.AddDate(0, 0, 1)
+		events := []MoonEvent{}
+		for _, e := range astro.GalileanEvents(from.UTC(), to.UTC()) {
+			e.Time = e.Time.In(loc)
+			events = append(events, MoonEvent{MoonEvent: e})
+		}
+		if obs != nil {
+			sky, ok := jupiterSky(c, st, *obs)
+			if !ok {
+				return
+			}
+			for i := range events {
+				events[i].Visibility = sky(events[i].Time)
+			}
+		}
+
+		positions, _ := astro.GalileanPositions(astro.JulianDay(midnight))
+		meta := zoneMeta(loc, from)
+		meta["date"] = day.Format(time.DateOnly)
+		meta["from"] = from
+		meta["to"] = to
+		meta["positions"] = gin.H{"time": midnight, "moons": positions}
+		if obs != nil {
+			meta["observer"] = obs
+		}
+		c.JSON(http.StatusOK, gin.H{"data": events, "count": len(events), "meta": meta})
+	}
+}
+
+// GetGRSTracking returns the Great Red Spot's current tracking
+func GetGRSTracking(tracker *grs.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": tracker.Get()})
+	}
+}
+
+// PutGRSTracking records a new measurement of the Great Red Spot:
+// {"longitude": System II degrees, "epoch": RFC 3339, "drift": degrees a
+// day, "source"}
+func PutGRSTracking(tracker *grs.Tracker, auditLog *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var tr grs.Tracking
+		if !bindJSON(c, &tr) {
+			return
+		}
+		prev, err := tracker.Set(tr)
+		switch {
+		case errors.Is(err, grs.ErrInvalid):
+			invalid(c, fieldErrors(grs.ErrInvalid, err)...)
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			cur := tracker.Get()
+			recordAudit(c, auditLog, audit.ActionUpdate, "grs", "jupiter", prev, cur)
+			c.JSON(http.StatusOK, gin.H{"data": cur})
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/physics"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeplerResult is both sides of Kepler's third law for one orbit
+type KeplerResult struct {
+	SolvedFor     string            `json:"solved_for"` // "period" or "semi_major_axis"
+	Central       string            `json:"central"`
+	CentralMass   float64           `json:"central_mass_kg"`
+	SemiMajorAxis float64           `json:"semi_major_axis_au"`
+	AxisKm        float64           `json:"semi_major_axis_km"`
+	PeriodDays    float64           `json:"period_days"`
+	PeriodYears   float64           `json:"period_years"`
+	MeanSpeed     float64           `json:"mean_orbital_speed_km_s"`
+	Comparison    *KeplerComparison `json:"comparison,omitempty"`
+}
+
+// KeplerComparison checks the result against the dataset body whose orbit
+// is closest, so the law can be seen to hold for real planets
+type KeplerComparison struct {
+	Body              string  `json:"body"`
+	SemiMajorAxis     float64 `json:"semi_major_axis_au"`
+	OrbitalPeriod     float64 `json:"orbital_period_days"` // as stored
+	KeplerPeriod      float64 `json:"kepler_period_days"`  // from its semi-major axis
+	DifferencePercent float64 `json:"difference_percent"`
+}
+
+// comparisonTolerance is how close (relative) a body's orbit must be to the
+// requested one to be compared against
+const comparisonTolerance = 0.05
+
+// GetKepler3 solves Kepler's third law either way: give ?a= (AU) or ?a_km=
+// for the period, or ?period= (days) for the semi-major axis. The central
+// body is ?central= (a body name, default the Sun) or an explicit
+// ?central_mass= in kg.
+func GetKepler3(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given := 0
+		for _, p := range []string{"a", "a_km", "period"} {
+			if c.Query(p) != "" {
+				given++
+			}
+		}
+		if given != 1 {
+			invalid(c, FieldError{Field: "query", Message: "must give exactly one of a (AU), a_km or period (days)"})
+			return
+		}
+
+		central, mass := "Sun", physics.SolarMass
+		if v := c.Query("central"); v != "" {
+			body, ok := findPlanet(c.Request.Context(), st, v)
+			if !ok {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Central body not found"})
+				return
+			}
+			if mass = bodyMass(body); mass == 0 {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "The mass of " + body.Name + " is unknown; pass central_mass"})
+				return
+			}
+			central = body.Name
+		}
+		if c.Query("central_mass") != "" {
+			m, ok := queryFloat(c, "central_mass", 0, 1, 1e40)
+			if !ok {
+				return
+			}
+			central, mass = "custom", m
+		}
+
+		res := KeplerResult{Central: central, CentralMass: mass}
+		var a, period float64 // m, s
+		switch {
+		case c.Query("period") != "":
+			days, ok := queryFloat(c, "period", 0, 1e-3, 1e9)
+			if !ok {
+				return
+			}
+			period = days * physics.Day
+			a = physics.SemiMajorAxis(period, mass)
+			res.SolvedFor = "semi_major_axis"
+		default:
+			if c.Query("a_km") != "" {
+				km, ok := queryFloat(c, "a_km", 0, 1, 1e15)
+				if !ok {
+					return
+				}
+				a = km * 1000
+			} else {
+				au, ok := queryFloat(c, "a", 0, 1e-6, 1e6)
+				if !ok {
+					return
+				}
+				a = au * physics.AU
+			}
+			period = physics.OrbitalPeriod(a, mass)
+			res.SolvedFor = "period"
+		}
+
+		res.SemiMajorAxis = significant(a/physics.AU, 6)
+		res.AxisKm = significant(a/1000, 6)
+		res.PeriodDays = significant(period/physics.Day, 6)
+		res.PeriodYears = significant(period/physics.JulianYear, 6)
+		res.MeanSpeed = significant(physics.MeanOrbitalSpeed(a, period)/1000, 4)
+		if central == "Sun" {
+			res.Comparison = compareOrbit(solarSystemBodies(c.Request.Context(), st), a/physics.AU)
+		}
+		c.JSON(http.StatusOK, gin.H{"data": res})
+	}
+}
+
+// compareOrbit finds the Sun-orbiting body with the semi-major axis nearest
+// to au, if one is within comparisonTolerance
+func compareOrbit(bodies []models.Planet, au float64) *KeplerComparison {
+	var best *models.Planet
+	for i, b := range bodies {
+		if b.IsStar || b.DistanceFromSun == 0 || b.OrbitalPeriod == 0 {
+			continue
+		}
+		if math.Abs(b.DistanceFromSun-au)/au > comparisonTolerance {
+			continue
+		}
+		if best == nil || math.Abs(b.DistanceFromSun-au) < math.Abs(best.DistanceFromSun-au) {
+			best = &bodies[i]
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	kepler := physics.OrbitalPeriod(best.DistanceFromSun*physics.AU, physics.SolarMass) / physics.Day
+	return &KeplerComparison{
+		Body:              best.Name,
+		SemiMajorAxis:     best.DistanceFromSun,
+		OrbitalPeriod:     best.OrbitalPeriod,
+		KeplerPeriod:      significant(kepler, 6),
+		DifferencePercent: round((kepler-best.OrbitalPeriod)/best.OrbitalPeriod*100, 3),
+	}
+}
+
+// significant rounds v to n significant digits
+func significant(v float64, n int) float64 {
+	if v == 0 {
+		return 0
+	}
+	return round(v, n-1-int(math.Floor(math.Log10(math.Abs(v)))))
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"solar-system-explorer/backend/launches"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/translit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bodyAdjectives are the adjectives mission descriptions use instead of a
+// body's name
+var bodyAdjectives = map[string]string{
+	"lunar":     "Moon",
+	"martian":   "Mars",
+	"jovian":    "Jupiter",
+	"venusian":  "Venus",
+	"mercurian": "Mercury",
+	"saturnian": "Saturn",
+}
+
+// bodyMissionTypes are the bodies nearly every mission mentions ("Earth
+// orbit", "Sun-synchronous") and the only mission type that counts for
+// each
+var bodyMissionTypes = map[string]string{
+	"Earth": "Earth Science",
+	"Sun":   "Heliophysics",
+}
+
+// wordPattern matches the words of a mission's name and description
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// launchBodies lists the bodies, by English name, a launch's mission is
+// about: those its name or description mentions by name, capitalised
+// (the Moon, not "Jupiter's moon Europa"), or adjective.
+// Earth and the Sun count only for missions of their type.
+func launchBodies(l launches.Launch, names map[string]string) []string {
+	if l.Mission == nil {
+		return nil
+	}
+	var out []string
+	seen := map[string]bool{}
+	for _, w := range wordPattern.FindAllString(l.Mission.Name+" "+l.Mission.Description, -1) {
+		proper := unicode.IsUpper([]rune(w)[0])
+		w = translit.Fold(w)
+		body, ok := names[w]
+		if !ok || !proper {
+			body, ok = bodyAdjectives[w]
+		}
+		if !ok || seen[body] {
+			continue
+		}
+		if typ, only := bodyMissionTypes[body]; only && !strings.EqualFold(l.Mission.Type, typ) {
+			continue
+		}
+		seen[body] = true
+		out = append(out, body)
+	}
+	return out
+}
+
+// GetLaunches lists upcoming launches of the agencies we follow (see
+// LAUNCHES_AGENCIES) from Launch Library 2, soonest first, each with the
+// bodies its mission is about so a planet page can show the launches
+// headed its way: ?body= keeps those of one body (a planet, dwarf planet
+// or moon, in English or Serbian), ?limit= caps the list (default 20).
+// The schedule is cached for LAUNCHES_CACHE_TTL; while Launch Library is
+// down the last one is served with meta.stale.
+func GetLaunches(client *launches.Client, st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Body  string `form:"body" binding:"max=64"`
+			Limit int    `form:"limit" binding:"omitempty,min=1,max=100"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		if req.Limit == 0 {
+			req.Limit = 20
+		}
+		names := map[string]string{}
+		for _, b := range solarSystemBodies(c.Request.Context(), st) {
+			names[translit.Fold(b.Name)], names[translit.Fold(b.NameSR)] = b.Name, b.Name
+		}
+		for _, m := range models.GetMoons() {
+			names[translit.Fold(m.Name)], names[translit.Fold(m.NameSR)] = m.Name, m.Name
+		}
+		delete(names, "")
+		body := ""
+		if req.Body != "" {
+			var ok bool
+			if body, ok = names[translit.Fold(req.Body)]; !ok {
+				invalid(c, FieldError{Field: "body", Message: "unknown body"})
+				return
+			}
+		}
+
+		up, err := client.Upcoming(c.Request.Context())
+		if err != nil {
+			log.Printf("launches: %v", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Launch schedule is unavailable"})
+			return
+		}
+		list := []launches.Launch{}
+		for _, l := range up.Launches {
+			l.Bodies = launchBodies(l, names)
+			if body != "" && !slices.Contains(l.Bodies, body) {
+				continue
+			}
+			if list = append(list, l); len(list) == req.Limit {
+				break
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"data": list, "count": len(list), "meta": gin.H{
+			"fetched_at": up.FetchedAt,
+			"stale":      up.Stale,
+			"agencies":   client.Agencies,
+			"source":     "Launch Library 2 (The Space Devs)",
+		}})
+	}
+}
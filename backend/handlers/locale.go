@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"cmp"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"solar-system-explorer/backend/i18n"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/translit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// baseLocale is the language of Name_sr and Description in the dataset.
+// It is tried after i18n.Default, so every body always has some text.
+const baseLocale = "sr"
+
+// localizedPlanet is a body with its name and description resolved for the
+// request's locale chain. Locale is where the description came from.
+type localizedPlanet struct {
+	models.Planet
+	DisplayName string   `json:"display_name"`
+	Locale      string   `json:"locale"`
+	Audience    string   `json:"audience,omitempty"` // kids when the text is simplified
+	Facts       []string `json:"facts,omitempty"`    // for kids
+}
+
+// audienceQuery picks the audience text is written for with ?audience=
+type audienceQuery struct {
+	Audience string `form:"audience" binding:"omitempty,oneof=standard kids"`
+}
+
+// kids reports whether the simplified text is asked for
+func (q audienceQuery) kids() bool { return q.Audience == models.AudienceKids }
+
+// localize is the package localize, or localizeKids for kids
+func (q audienceQuery) localize(p models.Planet, chain []string) localizedPlanet {
+	if q.kids() {
+		return localizeKids(p, chain)
+	}
+	return localize(p, chain)
+}
+
+// etag makes a localeETag specific to the audience
+func (q audienceQuery) etag(etag string) string {
+	if q.kids() {
+		return strings.TrimSuffix(etag, `"`) + `-kids"`
+	}
+	return etag
+}
+
+// requestLocales returns the fallback chain for the request: ?lang= first,
+// then Accept-Language, then i18n.Default and the base locale
+func requestLocales(c *gin.Context) (requested string, chain []string) {
+	prefs := i18n.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	if lang := i18n.Canonical(c.Query("lang")); lang != "" {
+		prefs = append([]string{lang}, prefs...)
+	}
+	if len(prefs) > 0 {
+		requested = prefs[0]
+	}
+	chain = i18n.Fallbacks(prefs)
+	for _, tag := range chain {
+		if tag == baseLocale {
+			return requested, chain
+		}
+	}
+	return requested, append(chain, baseLocale)
+}
+
+// localize resolves each text field independently along chain, so a
+// locale that only translates names still gets a description
+func localize(p models.Planet, chain []string) localizedPlanet {
+	out := localizedPlanet{Planet: p}
+	out.Translations = nil
+	for _, tag := range chain {
+		t := bodyText(p, tag)
+		if out.DisplayName == "" {
+			out.DisplayName = t.Name
+		}
+		if out.Locale == "" && t.Description != "" {
+			out.Description, out.Locale = t.Description, tag
+		}
+	}
+	if out.DisplayName == "" {
+		out.DisplayName = p.Name
+	}
+	if out.Locale == "" {
+		out.Locale = baseLocale
+	}
+	return out
+}
+
+// localizeKids is localize with the simplified description and facts of
+// the kids audience, each resolved along chain on its own. Where no locale
+// has a kids description the standard one stays.
+func localizeKids(p models.Planet, chain []string) localizedPlanet {
+	out := localize(p, chain)
+	out.Audience = models.AudienceKids
+	described := false
+	for _, tag := range chain {
+		k := bodyText(p, tag).Kids
+		if k == nil {
+			continue
+		}
+		if !described && k.Description != "" {
+			out.Description, out.Locale, described = k.Description, tag, true
+		}
+		if out.Facts == nil && len(k.Facts) > 0 {
+			out.Facts = k.Facts
+		}
+	}
+	return out
+}
+
+// bodyText returns p's text in exactly one locale, with no fallback.
+// Explicit translations win; the base Serbian text also serves sr-Latn and,
+// transliterated, sr-Cyrl.
+func bodyText(p models.Planet, tag string) models.Translation {
+	t := p.Translations[tag]
+	var derived models.Translation
+	switch tag {
+	case baseLocale:
+		derived = models.Translation{Name: p.NameSR, Description: p.Description}
+	case "sr-Latn":
+		derived = bodyText(p, baseLocale)
+	case "sr-Cyrl":
+		sr := bodyText(p, baseLocale)
+		derived = models.Translation{
+			Name:        translit.ToCyrillic(sr.Name),
+			Description: translit.ToCyrillic(sr.Description),
+			IPA:         sr.IPA,
+			Respelling:  translit.ToCyrillic(sr.Respelling),
+			Appearance:  translit.ToCyrillic(sr.Appearance),
+			Orbit:       translit.ToCyrillic(sr.Orbit),
+		}
+		if sr.Kids != nil {
+			kids := models.KidsText{Description: translit.ToCyrillic(sr.Kids.Description)}
+			for _, f := range sr.Kids.Facts {
+				kids.Facts = append(kids.Facts, translit.ToCyrillic(f))
+			}
+			derived.Kids = &kids
+		}
+	case "en":
+		derived = models.Translation{Name: p.Name}
+	}
+	if t.Name == "" {
+		t.Name = derived.Name
+	}
+	if t.Description == "" {
+		t.Description = derived.Description
+	}
+	if t.IPA == "" {
+		t.IPA = derived.IPA
+	}
+	if t.Respelling == "" {
+		t.Respelling = derived.Respelling
+	}
+	if t.Appearance == "" {
+		t.Appearance = derived.Appearance
+	}
+	if t.Orbit == "" {
+		t.Orbit = derived.Orbit
+	}
+	if derived.Kids != nil {
+		kids := *derived.Kids
+		if t.Kids != nil {
+			kids.Description = cmp.Or(t.Kids.Description, kids.Description)
+			if len(t.Kids.Facts) > 0 {
+				kids.Facts = t.Kids.Facts
+			}
+		}
+		t.Kids = &kids
+	}
+	return t
+}
+
+// localeETag makes the dataset ETag specific to the locale chain and marks
+// the response as varying by language, so caches keep one copy per chain
+func localeETag(c *gin.Context, etag string, chain []string) string {
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	h := fnv.New32a()
+	h.Write([]byte(strings.Join(chain, ",")))
+	return fmt.Sprintf(`%s-%08x"`, strings.TrimSuffix(etag, `"`), h.Sum32())
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"solar-system-explorer/backend/marsphotos"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRoverPhotos lists raw images from NASA's Mars Rover Photos API for
+// the Mars page gallery: those of ?rover= (curiosity, perseverance,
+// opportunity or spirit; default perseverance) on ?sol= (a number or
+// latest, the default), only of ?camera= if given (one of meta.cameras).
+// Upstream pages of 25 are regrouped into ?limit= photos (default 25, at
+// most 100), continued with meta.next_cursor as ?cursor=. meta.rover is
+// the mission manifest: status, latest sol and photo totals. Manifests and
+// pages are cached on disk (MARS_PHOTOS_CACHE_DIR) for
+// MARS_PHOTOS_CACHE_TTL, sols a month behind the latest for good; while the
+// API is down expired copies are served with meta.stale.
+func GetRoverPhotos(client *marsphotos.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Rover  string `form:"rover" binding:"omitempty,oneof=curiosity perseverance opportunity spirit"`
+			Sol    string `form:"sol" binding:"max=10"`
+			Camera string `form:"camera" binding:"max=32"`
+			Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+			Cursor int    `form:"cursor" binding:"min=0,max=100000"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		if req.Rover == "" {
+			req.Rover = "perseverance"
+		}
+		if req.Limit == 0 {
+			req.Limit = 25
+		}
+		ctx := c.Request.Context()
+		m, err := client.Manifest(ctx, req.Rover)
+		if err != nil {
+			unavailablePhotos(c, err)
+			return
+		}
+		sol := m.MaxSol
+		if req.Sol != "" && req.Sol != "latest" {
+			if sol, err = strconv.Atoi(req.Sol); err != nil || sol < 0 || sol > m.MaxSol {
+				invalid(c, FieldError{Field: "sol", Message: "must be latest or a sol from 0 to " + strconv.Itoa(m.MaxSol)})
+				return
+			}
+		}
+		day := m.Sols[sol]
+		if day.Cameras == nil {
+			day.Cameras = []string{}
+		}
+		camera := strings.ToUpper(req.Camera)
+		if camera != "" && !slices.Contains(m.Cameras, camera) {
+			invalid(c, FieldError{Field: "camera", Message: "must be one of " + strings.Join(m.Cameras, ", ")})
+			return
+		}
+
+		photos, more, stale := []marsphotos.Photo{}, false, m.Stale
+		// A sol without photos, or without the camera's, isn't asked for
+		if day.TotalPhotos > 0 && (camera == "" || slices.Contains(day.Cameras, camera)) {
+			var pstale bool
+			photos, more, pstale, err = client.Photos(ctx, m, sol, camera, req.Cursor, req.Limit)
+			if err != nil {
+				unavailablePhotos(c, err)
+				return
+			}
+			stale = stale || pstale
+		}
+
+		meta := gin.H{
+			"rover": gin.H{
+				"name":         m.Rover,
+				"status":       m.Status,
+				"landing_date": m.LandingDate,
+				"max_sol":      m.MaxSol,
+				"max_date":     m.MaxDate,
+				"total_photos": m.TotalPhotos,
+				"fetched_at":   m.FetchedAt,
+			},
+			"sol":         sol,
+			"earth_date":  day.EarthDate,
+			"cameras":     day.Cameras,
+			"limit":       req.Limit,
+			"next_cursor": nil,
+			"stale":       stale,
+			"source":      "NASA Mars Rover Photos API",
+		}
+		if camera == "" {
+			meta["total"] = day.TotalPhotos
+		}
+		if more {
+			next := req.Cursor + len(photos)
+			q := c.Request.URL.Query()
+			q.Set("sol", strconv.Itoa(sol)) // latest moves on
+			q.Set("cursor", strconv.Itoa(next))
+			meta["next_cursor"], meta["next"] = next, c.Request.URL.Path+"?"+q.Encode()
+		}
+		c.JSON(http.StatusOK, gin.H{"data": photos, "count": len(photos), "meta": meta})
+	}
+}
+
+func unavailablePhotos(c *gin.Context, err error) {
+	log.Printf("mars photos: %v", err)
+	c.JSON(http.StatusBadGateway, gin.H{"error": "Rover photos are unavailable"})
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMigrations lists the database migrations and which are applied.
+// m is nil when the server runs without a database.
+func GetMigrations(m *store.Migrator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No database is configured"})
+			return
+		}
+		status, err := m.Status(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read the migration status"})
+			return
+		}
+		pending := 0
+		for _, mig := range status {
+			if !mig.Applied {
+				pending++
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"data": status, "count": len(status), "meta": gin.H{"pending": pending}})
+	}
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/middleware"
+	"solar-system-explorer/backend/oauth"
+	"solar-system-explorer/backend/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oauthCookie carries the sealed sign-in state between the redirect to the
+// provider and the callback
+const oauthCookie = "oauth_state"
+
+// GetOAuthProviders lists the providers this server can sign in with, for
+// the SPA's sign-in buttons
+func GetOAuthProviders(providers map[string]*oauth.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		names := make([]string, 0, len(providers))
+		for name := range providers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		c.JSON(http.StatusOK, gin.H{"data": names, "count": len(names)})
+	}
+}
+
+// StartOAuth sends the browser to the provider to sign in. ?redirect= is
+// the SPA path to come back to.
+func StartOAuth(providers map[string]*oauth.Provider, states *oauth.States) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := providers[c.Param("provider")]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown sign-in provider"})
+			return
+		}
+		c.Redirect(http.StatusFound, beginOAuth(c, p, states, "", c.Query("redirect")))
+	}
+}
+
+// LinkOAuth starts linking a provider to the signed-in account. It
+// returns the URL to send the browser to, since the browser can't carry
+// the session header through a redirect: {"redirect"}.
+func LinkOAuth(providers map[string]*oauth.Provider, states *oauth.States) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := providers[c.Param("provider")]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown sign-in provider"})
+			return
+		}
+		var req struct {
+			Redirect string `json:"redirect"`
+		}
+		_ = c.ShouldBindJSON(&req) // the body is optional
+		user, _ := middleware.CurrentUser(c)
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"url": beginOAuth(c, p, states, user.Subject, req.Redirect)}})
+	}
+}
+
+// beginOAuth sets the state cookie and returns the provider's sign-in URL
+func beginOAuth(c *gin.Context, p *oauth.Provider, states *oauth.States, linkUser, redirect string) string {
+	st := oauth.State{
+		Nonce:    oauth.NewVerifier()[:22],
+		Provider: p.Name,
+		Verifier: oauth.NewVerifier(),
+		LinkUser: linkUser,
+		Return:   spaPath(redirect),
+	}
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode) // must come back on the provider's top-level redirect
+	c.SetCookie(oauthCookie, states.Seal(st, time.Now()), int(oauth.StateTTL.Seconds()), "/api/auth/oauth", "", secure, true)
+	return p.AuthCodeURL(st.Nonce, st.Verifier)
+}
+
+// OAuthCallback is where the provider sends the browser back. It signs the
+// user in (creating or joining an account) or links the identity, then
+// returns to the SPA with the outcome in the URL fragment: the tokens,
+// linked, or error.
+func OAuthCallback(providers map[string]*oauth.Provider, states *oauth.States, us *users.Users, sessions *users.Sessions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := providers[c.Param("provider")]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown sign-in provider"})
+			return
+		}
+		sealed, _ := c.Cookie(oauthCookie)
+		c.SetCookie(oauthCookie, "", -1, "/api/auth/oauth", "", false, true)
+		st, err := states.Open(sealed, time.Now())
+		if err != nil || st.Provider != p.Name || c.Query("state") != st.Nonce {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Sign-in expired or was started elsewhere; please try again"})
+			return
+		}
+		back := func(v url.Values) { c.Redirect(http.StatusFound, st.Return+"#"+v.Encode()) }
+		if e := c.Query("error"); e != "" { // e.g. the user cancelled
+			back(url.Values{"error": {e}})
+			return
+		}
+		id, err := p.Identify(c.Request.Context(), c.Query("code"), st.Verifier)
+		if err != nil {
+			log.Printf("oauth: %s: %v", p.Name, err)
+			back(url.Values{"error": {"provider_error"}})
+			return
+		}
+		ident := users.Identity{Provider: id.Provider, Subject: id.Subject, Email: id.Email}
+
+		if st.LinkUser != "" {
+			_, err := us.Link(st.LinkUser, ident)
+			switch {
+			case errors.Is(err, users.ErrLinked):
+				back(url.Values{"error": {"already_linked"}})
+			case err != nil:
+				log.Printf("oauth: linking %s to %s: %v", p.Name, st.LinkUser, err)
+				back(url.Values{"error": {"link_failed"}})
+			default:
+				back(url.Values{"linked": {p.Name}})
+			}
+			return
+		}
+
+		user, _, claimed, err := us.SignInExternal(ident, id.EmailVerified, id.Name)
+		if err == nil && claimed {
+			// Whoever registered the address before its owner is signed out
+			if _, err = sessions.Logins().RevokeAll(user.ID); err != nil {
+				err = fmt.Errorf("ending the sessions of claimed account %s: %w", user.ID, err)
+			}
+		}
+		switch {
+		case errors.Is(err, users.ErrExists):
+			back(url.Values{"error": {"email_exists"}})
+		case errors.Is(err, users.ErrInvalid):
+			back(url.Values{"error": {"no_email"}})
+		case err != nil:
+			log.Printf("oauth: %s sign-in: %v", p.Name, err)
+			back(url.Values{"error": {"sign_in_failed"}})
+		default:
+			t, err := sessions.Start(user, c.Request.UserAgent(), c.ClientIP(), time.Now())
+			if err != nil {
+				log.Printf("oauth: %s sign-in: %v", p.Name, err)
+				back(url.Values{"error": {"sign_in_failed"}})
+				return
+			}
+			back(url.Values{
+				"token":              {t.Token},
+				"expires_at":         {t.ExpiresAt.UTC().Format(time.RFC3339)},
+				"refresh_token":      {t.RefreshToken},
+				"refresh_expires_at": {t.RefreshExpiresAt.UTC().Format(time.RFC3339)},
+			})
+		}
+	}
+}
+
+// UnlinkOAuth removes a provider from the signed-in account
+func UnlinkOAuth(us *users.Users) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := middleware.CurrentUser(c)
+		updated, err := us.Unlink(user.Subject, c.Param("provider"))
+		switch {
+		case errors.Is(err, users.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "This provider is not linked"})
+		case errors.Is(err, users.ErrInvalid):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusOK, gin.H{"data": updated})
+		}
+	}
+}
+
+// spaPath accepts only local paths to return to, so the flow can't be
+// used to bounce users to another site
+func spaPath(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.ContainsAny(p, "\\#\r\n") {
+		return "/"
+	}
+	return p
+}
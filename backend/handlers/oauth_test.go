@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/middleware"
+	"solar-system-explorer/backend/oauth"
+	"solar-system-explorer/backend/testutil"
+	"solar-system-explorer/backend/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeProvider is an OAuth provider that accepts the code "good-code"
+// when the verifier matches the challenge the browser brought, and
+// answers userinfo with profile
+type fakeProvider struct {
+	mu        sync.Mutex
+	challenge string
+	profile   map[string]any
+}
+
+func (f *fakeProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.URL.Path {
+	case "/authorize":
+		f.challenge = r.URL.Query().Get("code_challenge")
+		w.WriteHeader(http.StatusOK)
+	case "/token":
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("code") != "good-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != f.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"provider-token"}`))
+	case "/userinfo":
+		if r.Header.Get("Authorization") != "Bearer provider-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(f.profile)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// newOAuthServer routes sign-in with the fake provider under "google",
+// and with a second one under "other" to mix their states up
+func newOAuthServer(t *testing.T, profile map[string]any) (*gin.Engine, *users.Users, *users.Sessions) {
+	t.Helper()
+	fake := &fakeProvider{profile: profile}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	provider := func(name string) *oauth.Provider {
+		p := oauth.Google("client", "secret", "http://example.test/api/auth/oauth/"+name+"/callback")
+		p.Name, p.AuthURL, p.TokenURL, p.UserURL, p.HTTP = name, srv.URL+"/authorize", srv.URL+"/token", srv.URL+"/userinfo", srv.Client()
+		return p
+	}
+	providers := map[string]*oauth.Provider{"google": provider("google"), "other": provider("other")}
+	states := oauth.NewStates([]byte("test-oauth-key"))
+	us, sessions := newAccounts(t)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/auth/oauth/:provider", StartOAuth(providers, states))
+	r.GET("/api/auth/oauth/:provider/callback", OAuthCallback(providers, states, us, sessions))
+	r.GET("/api/me", middleware.UserAuth(sessions, true), GetMe(us))
+	return r, us, sessions
+}
+
+// startOAuth begins signing in with provider, follows the redirect to the
+// fake provider so it sees the challenge, and returns the state cookie
+// and the state parameter to come back with
+func startOAuth(t *testing.T, r http.Handler, provider, redirect string) (*http.Cookie, string) {
+	t.Helper()
+	w := testutil.Do(r, http.MethodGet, "/api/auth/oauth/"+provider+"?redirect="+url.QueryEscape(redirect), nil)
+	if w.Code != http.StatusFound {
+		t.Fatalf("start = %d: %s", w.Code, w.Body)
+	}
+	to, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := to.Query(); q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("no PKCE challenge in %s", to)
+	}
+	resp, err := http.Get(to.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly {
+		t.Fatalf("state cookies = %+v", cookies)
+	}
+	return cookies[0], to.Query().Get("state")
+}
+
+// finishOAuth comes back from the provider and returns the SPA path and
+// the outcome the callback put in the fragment
+func finishOAuth(t *testing.T, r http.Handler, provider string, cookie *http.Cookie, query url.Values) (string, url.Values) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/oauth/"+provider+"/callback?"+query.Encode(), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusFound {
+		return "", url.Values{"status": {http.StatusText(w.Code)}}
+	}
+	path, fragment, _ := strings.Cut(w.Header().Get("Location"), "#")
+	outcome, err := url.ParseQuery(fragment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, outcome
+}
+
+func TestOAuthSignIn(t *testing.T) {
+	r, us, _ := newOAuthServer(t, map[string]any{"sub": "g-1", "email": "ada@example.com", "email_verified": true, "name": "Ada"})
+	cookie, state := startOAuth(t, r, "google", "/profile")
+	path, outcome := finishOAuth(t, r, "google", cookie, url.Values{"state": {state}, "code": {"good-code"}})
+	if path != "/profile" || outcome.Get("token") == "" || outcome.Get("refresh_token") == "" {
+		t.Fatalf("came back to %s with %v", path, outcome)
+	}
+	if w := testutil.Do(r, http.MethodGet, "/api/me", bearer(outcome.Get("token"))); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "ada@example.com") {
+		t.Errorf("/api/me with the new session = %d: %s", w.Code, w.Body)
+	}
+
+	// Signing in again finds the same account
+	first, _ := us.ByEmail("ada@example.com")
+	cookie, state = startOAuth(t, r, "google", "/")
+	finishOAuth(t, r, "google", cookie, url.Values{"state": {state}, "code": {"good-code"}})
+	if again, _ := us.ByEmail("ada@example.com"); again.ID != first.ID || len(us.List("")) != 1 {
+		t.Errorf("a second sign-in made another account")
+	}
+}
+
+func TestOAuthRejectsForgedState(t *testing.T) {
+	r, us, _ := newOAuthServer(t, map[string]any{"sub": "g-1", "email": "ada@example.com", "email_verified": true})
+	cookie, state := startOAuth(t, r, "google", "/")
+	other, otherState := startOAuth(t, r, "other", "/")
+	forged := *cookie
+	forged.Value = strings.Replace(forged.Value, ".", "x.", 1)
+
+	tests := []struct {
+		name     string
+		provider string
+		cookie   *http.Cookie
+		query    url.Values
+		want     string
+	}{
+		{"no cookie", "google", nil, url.Values{"state": {state}, "code": {"good-code"}}, "Bad Request"},
+		{"forged cookie", "google", &forged, url.Values{"state": {state}, "code": {"good-code"}}, "Bad Request"},
+		{"another sign-in's state", "google", cookie, url.Values{"state": {otherState}, "code": {"good-code"}}, "Bad Request"},
+		{"another provider's cookie", "google", other, url.Values{"state": {otherState}, "code": {"good-code"}}, "Bad Request"},
+		{"cancelled", "google", cookie, url.Values{"state": {state}, "error": {"access_denied"}}, "access_denied"},
+		{"bad code", "google", cookie, url.Values{"state": {state}, "code": {"stolen-code"}}, "provider_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, outcome := finishOAuth(t, r, tt.provider, tt.cookie, tt.query)
+			if got := outcome.Get("status") + outcome.Get("error"); got != tt.want || outcome.Get("token") != "" {
+				t.Errorf("outcome = %v, want %s", outcome, tt.want)
+			}
+		})
+	}
+
+	// The verifier is in the sealed cookie: a code redeemed with another
+	// sign-in's verifier is refused by the provider
+	startOAuth(t, r, "google", "/") // the provider now expects this one's challenge
+	_, outcome := finishOAuth(t, r, "google", cookie, url.Values{"state": {state}, "code": {"good-code"}})
+	if outcome.Get("error") != "provider_error" {
+		t.Errorf("mismatched verifier: outcome = %v, want provider_error", outcome)
+	}
+	if n := len(us.List("")); n != 0 {
+		t.Errorf("%d accounts made by refused sign-ins", n)
+	}
+}
+
+func TestOAuthEmailTaken(t *testing.T) {
+	for _, verified := range []bool{false, true} {
+		r, us, _ := newOAuthServer(t, map[string]any{"sub": "g-1", "email": "ada@example.com", "email_verified": verified})
+		owner, err := us.Register("ada@example.com", "Ada", "correct horse battery")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cookie, state := startOAuth(t, r, "google", "//evil.example/")
+		path, outcome := finishOAuth(t, r, "google", cookie, url.Values{"state": {state}, "code": {"good-code"}})
+		if path != "/" {
+			t.Errorf("came back to %q, want a local path", path)
+		}
+		switch {
+		case !verified && outcome.Get("error") != "email_exists":
+			t.Errorf("unverified email of an existing account: outcome = %v, want email_exists", outcome)
+		case verified && outcome.Get("token") == "":
+			t.Errorf("verified email of an existing account: outcome = %v, want a session", outcome)
+		}
+		if u, _ := us.Get(owner.ID); verified != (len(u.Identities) == 1) {
+			t.Errorf("verified = %v: account has %d identities", verified, len(u.Identities))
+		}
+	}
+}
+
+// Someone who registered an address before its owner loses the account
+// once the owner signs in with a provider that verified it
+func TestOAuthClaimsUnverifiedAccount(t *testing.T) {
+	for _, localVerified := range []bool{false, true} {
+		r, us, sessions := newOAuthServer(t, map[string]any{"sub": "g-1", "email": "ada@example.com", "email_verified": true})
+		early, err := us.Register("ada@example.com", "Ada", "correct horse battery")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if localVerified {
+			if early, err = us.MarkVerified(early.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		held, err := sessions.Start(early, "test", "192.0.2.1", time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cookie, state := startOAuth(t, r, "google", "/")
+		if _, outcome := finishOAuth(t, r, "google", cookie, url.Values{"state": {state}, "code": {"good-code"}}); outcome.Get("token") == "" {
+			t.Fatalf("local verified = %v: outcome = %v, want a session", localVerified, outcome)
+		}
+		_, pwErr := us.Authenticate("ada@example.com", "correct horse battery")
+		_, refreshErr := sessions.Refresh(held.RefreshToken, "192.0.2.1", time.Now(), us.Get)
+		me := testutil.Do(r, http.MethodGet, "/api/me", map[string]string{"Authorization": "Bearer " + held.Token})
+		if localVerified {
+			if pwErr != nil || refreshErr != nil || me.Code != http.StatusOK {
+				t.Errorf("verified account: password err %v, refresh err %v, /api/me %d; want them untouched", pwErr, refreshErr, me.Code)
+			}
+			continue
+		}
+		if pwErr == nil {
+			t.Error("the password set before the owner signed in still works")
+		}
+		if refreshErr == nil || me.Code != http.StatusUnauthorized {
+			t.Errorf("the earlier session survived: refresh err %v, /api/me %d", refreshErr, me.Code)
+		}
+		if u, _ := us.Get(early.ID); !u.EmailVerified || len(u.Identities) != 1 {
+			t.Errorf("claimed account = %+v, want verified with the identity linked", u)
+		}
+	}
+}
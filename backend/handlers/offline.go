@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/assets"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/offline"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// offlineTextureBudget caps the textures of the offline bundle, which
+// phones download whole
+const offlineTextureBudget = 24 << 20
+
+// offlineFormats are the image formats every browser the PWA supports
+// can decode
+var offlineFormats = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".webp": true}
+
+// OfflineBundle builds the bundle for offline use (see package offline)
+// and keeps the last one, rebuilding it on the first request after the
+// dataset, tours, translations or textures change
+type OfflineBundle struct {
+	st    *store.Store
+	tr    *store.Translations
+	tours *store.Tours
+	as    *assets.Store
+
+	mu       sync.Mutex
+	key      string
+	data     []byte
+	manifest offline.Manifest
+}
+
+// NewOfflineBundle returns a builder over the given stores
+func NewOfflineBundle(st *store.Store, tr *store.Translations, tours *store.Tours, as *assets.Store) *OfflineBundle {
+	return &OfflineBundle{st: st, tr: tr, tours: tours, as: as}
+}
+
+// get returns the current bundle and its manifest
+func (b *OfflineBundle) get(ctx context.Context) ([]byte, offline.Manifest, error) {
+	snap := snapshot(ctx, b.st)
+	tours := b.tours.List()
+	translations := b.tr.Published()
+	textures := offlineTextures(b.as)
+
+	// Everything the bundle depends on, cheaply: versions rather than content
+	var key strings.Builder
+	key.WriteString(snap.ETag)
+	for _, t := range tours {
+		fmt.Fprintf(&key, "|%s@%d", t.ID, t.UpdatedAt.UnixNano())
+	}
+	tj, _ := json.Marshal(translations)
+	sum := sha256.Sum256(tj)
+	key.WriteString("|" + hex.EncodeToString(sum[:8]))
+	for _, a := range textures {
+		key.WriteString("|" + a.Path + a.ETag())
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.data != nil && b.key == key.String() {
+		return b.data, b.manifest, nil
+	}
+	contents := offline.Contents{
+		Dataset:      snap.ETag,
+		Bodies:       snap.Bodies,
+		Moons:        models.GetMoons(),
+		Tours:        tours,
+		Translations: translations,
+	}
+	for _, a := range textures {
+		data, err := readAsset(ctx, b.as, a.Path)
+		if err != nil {
+			return nil, offline.Manifest{}, fmt.Errorf("reading %s: %w", a.Path, err)
+		}
+		contents.Textures = append(contents.Textures, offline.Texture{Path: a.Path, Body: a.Body, Data: data})
+	}
+	data, m, err := offline.Build(contents)
+	if err != nil {
+		return nil, offline.Manifest{}, err
+	}
+	b.key, b.data, b.manifest = key.String(), data, m
+	return data, m, nil
+}
+
+// offlineTextures picks the smallest public copy of every texture, in a
+// format every browser decodes, as long as they fit the budget
+func offlineTextures(as *assets.Store) []assets.Asset {
+	smallest := make(map[string]assets.Asset) // by the uploaded original
+	for _, a := range as.List() {
+		if a.Kind != assets.KindTexture || a.Private || !offlineFormats[strings.ToLower(path.Ext(a.Path))] {
+			continue
+		}
+		orig := a.VariantOf
+		if orig == "" {
+			orig = a.Path
+		}
+		if cur, ok := smallest[orig]; !ok || a.LOD > cur.LOD || (a.LOD == cur.LOD && a.Size < cur.Size) {
+			smallest[orig] = a
+		}
+	}
+	list := make([]assets.Asset, 0, len(smallest))
+	for _, a := range smallest {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+
+	var out []assets.Asset
+	var total int64
+	for _, a := range list {
+		if total+a.Size > offlineTextureBudget {
+			continue
+		}
+		total += a.Size
+		out = append(out, a)
+	}
+	return out
+}
+
+// readAsset reads a whole asset file
+func readAsset(ctx context.Context, as *assets.Store, p string) ([]byte, error) {
+	f, _, err := as.Open(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// bundleETag is the validator of a bundle and of its manifest
+func bundleETag(m offline.Manifest) string {
+	return `"` + m.Version + `"`
+}
+
+// GetOfflineManifest returns the manifest of the current offline bundle:
+// its version, the dataset ETag it was built from and the SHA-256 and
+// size of every file. The service worker checks it, usually with
+// If-None-Match, and downloads the bundle only when the version changed.
+func GetOfflineManifest(b *OfflineBundle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, m, err := b.get(c.Request.Context())
+		if err != nil {
+			log.Printf("offline bundle: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not build the offline bundle"})
+			return
+		}
+		if notModified(c, bundleETag(m)) {
+			return
+		}
+		c.Header("Cache-Control", "no-cache")
+		c.JSON(http.StatusOK, gin.H{
+			"data": m,
+			"meta": gin.H{"size": len(data), "url": "/api/offline-bundle"},
+		})
+	}
+}
+
+// GetOfflineBundle downloads the current offline bundle, a gzipped tar
+// starting with its manifest. Range requests are honoured, so a download
+// cut off on a mobile connection can be resumed while the version is
+// unchanged.
+func GetOfflineBundle(b *OfflineBundle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, m, err := b.get(c.Request.Context())
+		if err != nil {
+			log.Printf("offline bundle: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not build the offline bundle"})
+			return
+		}
+		c.Header("ETag", bundleETag(m))
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Content-Type", "application/gzip")
+		c.Header("Content-Disposition", `attachment; filename="offline-`+m.Version+`.tar.gz"`)
+		http.ServeContent(c.Writer, c.Request, "", time.Time{}, bytes.NewReader(data))
+	}
+}
@@ -1,34 +1,169 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 
 	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/tracing"
+	"solar-system-explorer/backend/translit"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// GetPlanets returns all solar system bodies
-func GetPlanets(c *gin.Context) {
-	planets := models.GetSolarSystemBodies()
-	c.JSON(http.StatusOK, gin.H{
-		"data":  planets,
-		"count": len(planets),
-	})
+// discoveryFilter keeps bodies discovered in the years between
+// ?discovered_after= and ?discovered_before=, both exclusive
+type discoveryFilter struct {
+	After  *int `form:"discovered_after" binding:"omitempty,min=-3000,max=3000"`
+	Before *int `form:"discovered_before" binding:"omitempty,min=-3000,max=3000"`
 }
 
-// GetPlanetByName returns a single planet by name
-func GetPlanetByName(c *gin.Context) {
-	name := strings.ToLower(c.Param("name"))
-	planets := models.GetSolarSystemBodies()
+// set reports whether any bound is given
+func (f discoveryFilter) set() bool { return f.After != nil || f.Before != nil }
 
-	for _, planet := range planets {
-		if strings.ToLower(planet.Name) == name || strings.ToLower(planet.NameSR) == name {
-			c.JSON(http.StatusOK, gin.H{"data": planet})
+// keeps reports whether a body with discovery d passes; bodies known
+// since antiquity pass only when no bound is given
+func (f discoveryFilter) keeps(d *models.Discovery) bool {
+	if !f.set() {
+		return true
+	}
+	return d != nil && (f.After == nil || d.Year > *f.After) && (f.Before == nil || d.Year < *f.Before)
+}
+
+// key tells filters apart in cache keys
+func (f discoveryFilter) key() string {
+	bound := func(p *int) string {
+		if p == nil {
+			return ""
+		}
+		return strconv.Itoa(*p)
+	}
+	return bound(f.After) + ".." + bound(f.Before)
+}
+
+// GetPlanets returns all solar system bodies, with names and descriptions
+// in the locale negotiated from ?lang= or Accept-Language. With
+// ?discovered_after= and/or ?discovered_before= (years, exclusive) only
+// bodies discovered in between are listed. ?audience=kids gives the
+// simplified descriptions and facts for children.
+func GetPlanets(st *store.Store) gin.HandlerFunc {
+	rendered := newRenderedCache(64)
+	return func(c *gin.Context) {
+		var req struct {
+			discoveryFilter
+			audienceQuery
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		filter := req.discoveryFilter
+		requested, chain := requestLocales(c)
+		snap := snapshot(c.Request.Context(), st)
+		if notModified(c, req.etag(localeETag(c, snap.ETag, chain))) {
+			return
+		}
+		r, err := rendered.get(snap.ETag, requested+"\x00"+strings.Join(chain, ",")+"\x00"+filter.key()+"\x00"+req.Audience, func() (any, string) {
+			bodies := slices.DeleteFunc(slices.Clone(snap.Bodies), func(b models.Planet) bool { return !filter.keeps(b.Discovery) })
+			planets := make([]localizedPlanet, len(bodies))
+			// The list resolves to the most specific locale any body has text in
+			locale := chain[len(chain)-1]
+			rank := len(chain)
+			for i, b := range bodies {
+				planets[i] = req.localize(b, chain)
+				for r, tag := range chain[:rank] {
+					if tag == planets[i].Locale {
+						locale, rank = tag, r
+						break
+					}
+				}
+			}
+			return gin.H{
+				"data":  planets,
+				"count": len(planets),
+				"meta":  gin.H{"locale": locale, "requested": requested, "fallbacks": chain},
+			}, locale
+		})
+		r.write(c, http.StatusOK, err)
+	}
+}
+
+// GetPlanetByName returns a single planet by name, localized like
+// GetPlanets and for the same ?audience=
+func GetPlanetByName(st *store.Store) gin.HandlerFunc {
+	rendered := newRenderedCache(1024)
+	return func(c *gin.Context) {
+		var req audienceQuery
+		if !bindQuery(c, &req) {
+			return
+		}
+		requested, chain := requestLocales(c)
+		snap := snapshot(c.Request.Context(), st)
+		if notModified(c, req.etag(localeETag(c, snap.ETag, chain))) {
+			return
+		}
+		planet, ok := findBody(snap.Bodies, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
 			return
 		}
+		r, err := rendered.get(snap.ETag, planet.Name+"\x00"+requested+"\x00"+strings.Join(chain, ",")+"\x00"+req.Audience, func() (any, string) {
+			body := req.localize(planet, chain)
+			return gin.H{
+				"data": body,
+				"meta": gin.H{"locale": body.Locale, "requested": requested, "fallbacks": chain},
+			}, body.Locale
+		})
+		r.write(c, http.StatusOK, err)
+	}
+}
+
+// findPlanet looks a body up by its English or Serbian name, ignoring case,
+// diacritics and script ("Меркур", "merkur" and "Mercury" all match)
+func findPlanet(ctx context.Context, st *store.Store, name string) (models.Planet, bool) {
+	return findBody(solarSystemBodies(ctx, st), name)
+}
+
+// findBody is findPlanet within bodies, for handlers holding a snapshot
+func findBody(bodies []models.Planet, name string) (models.Planet, bool) {
+	name = translit.Fold(name)
+	for _, planet := range bodies {
+		if translit.Fold(planet.Name) == name || translit.Fold(planet.NameSR) == name {
+			return planet, true
+		}
+	}
+	return models.Planet{}, false
+}
+
+// solarSystemBodies reads the current dataset inside a data-layer span
+func solarSystemBodies(ctx context.Context, st *store.Store) []models.Planet {
+	return snapshot(ctx, st).Bodies
+}
+
+// snapshot takes the current dataset version inside a data-layer span.
+// Handlers that use more than the bodies, such as the ETag, take one
+// snapshot and read everything from it.
+func snapshot(ctx context.Context, st *store.Store) *store.Snapshot {
+	_, span := tracing.Start(ctx, "store.Bodies")
+	defer span.End()
+	snap := st.Snapshot()
+	if span.IsRecording() {
+		span.SetAttributes(attribute.Int("bodies.count", len(snap.Bodies)), attribute.Int64("dataset.version", snap.Version))
 	}
+	return snap
+}
 
-	c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+// notModified sets the ETag header and, when the client's If-None-Match
+// already matches it, answers 304 and reports true
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	if match := c.GetHeader("If-None-Match"); match != "" && (match == etag || match == "*") {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
 }
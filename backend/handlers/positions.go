@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"solar-system-explorer/backend/astro"
+	"solar-system-explorer/backend/clock"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/orbits"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/translit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// positionsPool recycles the slices GetPositions collects positions in
+var positionsPool = sync.Pool{New: func() any { return new([]orbits.Position) }}
+
+// GetPositions returns heliocentric positions of all bodies at ?time=
+// (RFC 3339, default now), optionally limited with ?bodies=earth,mars,
+// with the time given in ?tz= (default UTC), in the reference frame
+// ?frame=heliocentric (default), barycentric or geocentric. With
+// ?apparent=true they are apparent positions, corrected for light-time and
+// aberration as an observer at the frame's origin sees them (see
+// orbits.Apparent). meta.accuracy tells, by body, how far each position
+// can be trusted (see orbits.AccuracyOf).
+// The 3D view asks for every frame of an animation, so the common request
+// is answered without allocating per body: the orbits come prepared from
+// the cache and the response is encoded by hand into pooled buffers.
+func GetPositions(st *store.Store, cache *orbits.Cache, clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, ok := positionsQuery(c, clk)
+		if !ok {
+			return
+		}
+		t := req.Time
+		loc, ok := queryZone(c)
+		if !ok {
+			return
+		}
+
+		var wanted []string
+		if req.Bodies != "" {
+			for _, name := range strings.Split(req.Bodies, ",") {
+				wanted = append(wanted, translit.Fold(name))
+			}
+		}
+
+		ctx := c.Request.Context()
+		bodyList := solarSystemBodies(ctx, st)
+		origin, ok := frameOrigin(c, bodyList, cache, t, req.Frame)
+		if !ok {
+			return
+		}
+		pp := positionsPool.Get().(*[]orbits.Position)
+		defer func() {
+			*pp = (*pp)[:0]
+			positionsPool.Put(pp)
+		}()
+		positions := (*pp)[:0]
+		for _, planet := range bodyList {
+			if ctx.Err() != nil {
+				return // the work pool answers with 503
+			}
+			if wanted != nil && !slices.Contains(wanted, translit.Fold(planet.Name)) && !slices.Contains(wanted, translit.Fold(planet.NameSR)) {
+				continue
+			}
+			var pos orbits.Position
+			if req.Apparent {
+				pos = orbits.Apparent(planet, t, origin)
+			} else {
+				pos = cache.Position(ctx, planet, t).From(origin)
+			}
+			pos.Time = pos.Time.In(loc)
+			positions = append(positions, pos)
+		}
+		*pp = positions
+
+		buf := bufferPool.Get().(*bytes.Buffer)
+		defer func() {
+			if buf.Cap() <= maxPooledBuffer {
+				buf.Reset()
+				bufferPool.Put(buf)
+			}
+		}()
+		// The same document c.JSON would send for {"data", "count", "meta"}
+		b := append(buf.AvailableBuffer(), `{"count":`...)
+		b = strconv.AppendInt(b, int64(len(positions)), 10)
+		b = append(b, `,"data":[`...)
+		for i, pos := range positions {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			var err error
+			if b, err = pos.AppendJSON(b); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not encode the response"})
+				return
+			}
+		}
+		b = append(b, `],"meta":{"accuracy":{`...)
+		i := 0
+		for _, planet := range bodyList {
+			if i == len(positions) {
+				break
+			}
+			if planet.Name != positions[i].Name {
+				continue
+			}
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = orbits.AppendJSONString(b, planet.Name)
+			b = append(b, ':')
+			b = orbits.AccuracyOf(planet, t).AppendJSON(b)
+			i++
+		}
+		b = append(b, `},"apparent":`...)
+		b = strconv.AppendBool(b, req.Apparent)
+		b = append(b, `,"frame":"`...)
+		b = append(b, req.Frame...)
+		b = append(b, `"}}`...)
+		buf.Write(b)
+		c.Data(http.StatusOK, jsonContentType, buf.Bytes())
+	}
+}
+
+// positionsRequest is the query of GetPositions
+type positionsRequest struct {
+	timeQuery
+	Bodies string `form:"bodies" binding:"max=2000"` // comma-separated names
+	frameQuery
+}
+
+// positionsQuery reads the query for GetPositions. Well-formed values are
+// taken directly; anything else goes through bindQuery, which answers 422
+// with the same errors as the other endpoints.
+func positionsQuery(c *gin.Context, clk clock.Clock) (positionsRequest, bool) {
+	var req positionsRequest
+	raw, apparent := c.Query("time"), c.Query("apparent")
+	t, err := time.Parse(time.RFC3339, raw)
+	req.Time, req.Bodies, req.Frame = t, c.Query("bodies"), c.Query("frame")
+	req.Apparent = apparent == "true"
+	if (raw != "" && err != nil) || utf8.RuneCountInString(req.Bodies) > 2000 || !validFrame(req.Frame) ||
+		(apparent != "" && apparent != "true" && apparent != "false") {
+		req = positionsRequest{}
+		if !bindQuery(c, &req) {
+			return req, false
+		}
+	}
+	if req.Frame == "" {
+		req.Frame = orbits.FrameHeliocentric
+	}
+	if req.Time.IsZero() {
+		req.Time = clock.Now(c.Request.Context(), clk)
+	}
+	req.Time = req.Time.UTC()
+	return req, true
+}
+
+// frameQuery is the ?frame= positions are given in, and whether they are
+// apparent
+type frameQuery struct {
+	Frame    string `form:"frame" binding:"omitempty,oneof=heliocentric barycentric geocentric"`
+	Apparent bool   `form:"apparent"`
+}
+
+func validFrame(frame string) bool {
+	switch frame {
+	case "", orbits.FrameHeliocentric, orbits.FrameBarycentric, orbits.FrameGeocentric:
+		return true
+	}
+	return false
+}
+
+// frameOrigin finds the origin of frame at t among bodies, in heliocentric
+// coordinates. A geocentric frame over a dataset without the Earth answers
+// 422 and returns false.
+func frameOrigin(c *gin.Context, bodies []models.Planet, cache *orbits.Cache, t time.Time, frame string) (orbits.Origin, bool) {
+	ctx := c.Request.Context()
+	switch frame {
+	case orbits.FrameBarycentric:
+		positions := make([]orbits.Position, len(bodies))
+		for i, b := range bodies {
+			positions[i] = cache.Position(ctx, b, t)
+		}
+		return orbits.Barycentre(bodies, positions), true
+	case orbits.FrameGeocentric:
+		earth, ok := findBody(bodies, "Earth")
+		if !ok {
+			invalid(c, FieldError{Field: "frame", Message: "needs the Earth, which the dataset doesn't have"})
+			return orbits.Origin{}, false
+		}
+		return orbits.EarthOrigin(earth, t), true
+	}
+	return orbits.Origin{}, true
+}
+
+// GetPlanetPosition returns a single body's position at ?time=, with the
+// time given in ?tz=, in ?frame= and ?apparent= as GetPositions. In the
+// geocentric frame meta also has the phase angle and illuminated percent.
+func GetPlanetPosition(st *store.Store, cache *orbits.Cache, clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		snap := snapshot(ctx, st)
+		planet, ok := findBody(snap.Bodies, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+		var req frameQuery
+		if !bindQuery(c, &req) {
+			return
+		}
+		if req.Frame == "" {
+			req.Frame = orbits.FrameHeliocentric
+		}
+		t, ok := queryTime(c, clk)
+		if !ok {
+			return
+		}
+		loc, ok := queryZone(c)
+		if !ok {
+			return
+		}
+		origin, ok := frameOrigin(c, snap.Bodies, cache, t, req.Frame)
+		if !ok {
+			return
+		}
+		var pos orbits.Position
+		if req.Apparent {
+			pos = orbits.Apparent(planet, t, origin)
+		} else {
+			pos = cache.Position(ctx, planet, t).From(origin)
+		}
+		pos.Time = pos.Time.In(loc)
+		meta := gin.H{
+			"accuracy": orbits.AccuracyOf(planet, t),
+			"frame":    req.Frame,
+			"apparent": req.Apparent,
+		}
+		// Seen from the Earth a body shows a phase
+		if req.Frame == orbits.FrameGeocentric && !planet.IsStar && pos.Distance > 0 {
+			hx, hy, hz := pos.X+origin.X, pos.Y+origin.Y, pos.Z+origin.Z
+			r := math.Sqrt(hx*hx + hy*hy + hz*hz)
+			phase := astro.PhaseAngle(r, pos.Distance, math.Sqrt(origin.X*origin.X+origin.Y*origin.Y+origin.Z*origin.Z))
+			meta["phase_angle"], meta["illumination"] = phase, astro.Illuminated(phase)*100
+		}
+		c.JSON(http.StatusOK, gin.H{"data": pos, "meta": meta})
+	}
+}
+
+// timeQuery is the ?time= most computations take
+type timeQuery struct {
+	Time time.Time `form:"time"` // RFC 3339, default now
+}
+
+// queryTime binds ?time=, defaulting to the request's simulated time or
+// clk's now. On a bad value it answers 422 and returns false.
+func queryTime(c *gin.Context, clk clock.Clock) (time.Time, bool) {
+	var q timeQuery
+	if !bindQuery(c, &q) {
+		return time.Time{}, false
+	}
+	if q.Time.IsZero() {
+		return clock.Now(c.Request.Context(), clk).UTC(), true
+	}
+	return q.Time.UTC(), true
+}
+
+// queryFloat parses the float query parameter name, defaulting to def when
+// absent, for parameters that depend on each other and don't fit a
+// request struct. Values outside [lo, hi] or unparsable answer 422 and
+// return false.
+func queryFloat(c *gin.Context, name string, def, lo, hi float64) (float64, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return def, true
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || math.IsNaN(f) || f < lo || f > hi {
+		invalid(c, FieldError{Field: name, Message: fmt.Sprintf("must be a number between %g and %g", lo, hi)})
+		return 0, false
+	}
+	return f, true
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"solar-system-explorer/backend/classes"
+	"solar-system-explorer/backend/middleware"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/progress"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tourProgress is one started tour in a progress summary
+type tourProgress struct {
+	ID string `json:"id"`
+	progress.Tour
+}
+
+// progressSummary is a user's progress with the totals the SPA needs for
+// completion percentages
+func progressSummary(c *gin.Context, st *store.Store, tours *store.Tours, p progress.Progress) gin.H {
+	explored := make([]string, 0, len(p.Bodies))
+	for name := range p.Bodies {
+		explored = append(explored, name)
+	}
+	sort.Strings(explored)
+	bodies := len(solarSystemBodies(c.Request.Context(), st)) + len(models.GetMoons())
+
+	completed, started := []string{}, []tourProgress{}
+	for id, t := range p.Tours {
+		if _, ok := tours.Get(id); !ok {
+			continue // deleted since
+		}
+		if t.CompletedAt != nil {
+			completed = append(completed, id)
+		} else {
+			started = append(started, tourProgress{ID: id, Tour: t})
+		}
+	}
+	sort.Strings(completed)
+	sort.Slice(started, func(i, j int) bool { return started[i].ID < started[j].ID })
+	total := len(tours.List())
+
+	return gin.H{
+		"bodies":     gin.H{"explored": explored, "count": len(explored), "total": bodies, "percent": percent(len(explored), bodies)},
+		"tours":      gin.H{"completed": completed, "in_progress": started, "count": len(completed), "total": total, "percent": percent(len(completed), total)},
+		"updated_at": p.UpdatedAt,
+	}
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(n)*1000/float64(total)) / 10
+}
+
+// GetProgress returns the signed-in user's explored bodies and tours
+func GetProgress(st *store.Store, tours *store.Tours, ps *progress.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := middleware.CurrentUser(c)
+		c.Header("Cache-Control", "private, no-cache")
+		c.JSON(http.StatusOK, gin.H{"data": progressSummary(c, st, tours, ps.Get(user.Subject))})
+	}
+}
+
+// ExploreBody marks a body as explored by the signed-in user
+func ExploreBody(st *store.Store, tours *store.Tours, ps *progress.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, ok := surfaceBody(c, st, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+		user, _ := middleware.CurrentUser(c)
+		p, err := ps.ExploreBody(user.Subject, body, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": progressSummary(c, st, tours, p)})
+	}
+}
+
+// PutTourProgress records the furthest step the signed-in user has seen in
+// a tour: {"step"}, counting from 1. Seeing the last step completes it.
+func PutTourProgress(tours *store.Tours, ps *progress.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tour, ok := tours.Get(c.Param("id"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tour not found"})
+			return
+		}
+		var req struct {
+			Step int `json:"step" binding:"required"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		user, _ := middleware.CurrentUser(c)
+		t, err := ps.TourStep(user.Subject, tour.ID, req.Step, len(tour.Steps), time.Now())
+		switch {
+		case errors.Is(err, progress.ErrInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusOK, gin.H{"data": tourProgress{ID: tour.ID, Tour: t}})
+		}
+	}
+}
+
+// ResetProgress forgets the signed-in user's progress
+func ResetProgress(ps *progress.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := middleware.CurrentUser(c)
+		if err := ps.Reset(user.Subject); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// studentProgress is one student's state on one assignment
+type studentProgress struct {
+	UserID      string     `json:"user_id"`
+	Name        string     `json:"name"`
+	Step        int        `json:"step"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Late        bool       `json:"late,omitempty"` // completed after the due date
+}
+
+// GetClassProgress shows the class teacher how each assignment is going:
+// how many students completed it, are on it and haven't started, and
+// where each student is
+func GetClassProgress(cs *classes.Classes, ps *progress.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := middleware.CurrentUser(c)
+		cl, err := cs.Get(c.Param("id"))
+		if err != nil {
+			classError(c, err)
+			return
+		}
+		if cl.TeacherID != user.Subject {
+			classError(c, classes.ErrForbidden)
+			return
+		}
+		records := make(map[string]progress.Progress, len(cl.Members))
+		for _, m := range cl.Members {
+			records[m.UserID] = ps.Get(m.UserID)
+		}
+		data := make([]gin.H, 0, len(cl.Assignments))
+		for _, a := range cl.Assignments {
+			done, started := 0, 0
+			students := make([]studentProgress, 0, len(cl.Members))
+			for _, m := range cl.Members {
+				t := records[m.UserID].Tours[a.Target]
+				sp := studentProgress{UserID: m.UserID, Name: m.Name, Step: t.Step, CompletedAt: t.CompletedAt}
+				switch {
+				case t.CompletedAt != nil:
+					done++
+					sp.Late = a.Due != nil && t.CompletedAt.After(*a.Due)
+				case t.Step > 0:
+					started++
+				}
+				students = append(students, sp)
+			}
+			data = append(data, gin.H{
+				"assignment":  a,
+				"completed":   done,
+				"in_progress": started,
+				"not_started": len(cl.Members) - done - started,
+				"percent":     percent(done, len(cl.Members)),
+				"students":    students,
+			})
+		}
+		c.Header("Cache-Control", "private, no-cache")
+		c.JSON(http.StatusOK, gin.H{"data": data, "count": len(data), "meta": gin.H{"class": cl.ID, "students": len(cl.Members)}})
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"solar-system-explorer/backend/assets"
+	"solar-system-explorer/backend/i18n"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pronunciation is how a body's name is said in one locale
+type Pronunciation struct {
+	Locale     string              `json:"locale"`
+	Name       string              `json:"name"`
+	IPA        string              `json:"ipa,omitempty"`
+	Respelling string              `json:"respelling,omitempty"`
+	Audio      []PronunciationClip `json:"audio"`
+}
+
+// PronunciationClip is a recording of the name
+type PronunciationClip struct {
+	URL         string `json:"url"`
+	MediaType   string `json:"media_type"`
+	Size        int64  `json:"size_bytes"`
+	License     string `json:"license"`
+	Attribution string `json:"attribution,omitempty"`
+	Source      string `json:"source,omitempty"`
+}
+
+// GetPlanetPronunciation returns how the name of a body (or one of the
+// scene's moons) is pronounced: IPA, a respelling and recordings. The
+// first entry is for the name the request's locale chain displays, so
+// the accessibility mode can read data[0]; other locales with anything
+// to offer follow in tag order. Phonetics are edited as the "ipa" and
+// "respelling" translation fields, recordings uploaded as audio assets.
+func GetPlanetPronunciation(st *store.Store, as *assets.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requested, chain := requestLocales(c)
+		body, ok := findTextBody(c, st, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+		clips := as.ForBody(body.Name, assets.KindAudio)
+
+		shown := i18n.Default
+		for _, tag := range chain {
+			if bodyText(body, tag).Name != "" {
+				shown = tag
+				break
+			}
+		}
+		out := []Pronunciation{pronunciation(body, shown, clips)}
+
+		locales := map[string]bool{baseLocale: true, i18n.Default: true}
+		for tag := range body.Translations {
+			locales[tag] = true
+		}
+		for _, a := range clips {
+			locales[a.Locale] = true
+		}
+		delete(locales, shown)
+		others := make([]string, 0, len(locales))
+		for tag := range locales {
+			others = append(others, tag)
+		}
+		sort.Strings(others)
+		for _, tag := range others {
+			if p := pronunciation(body, tag, clips); p.IPA != "" || p.Respelling != "" || len(p.Audio) > 0 {
+				out = append(out, p)
+			}
+		}
+
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Header("Content-Language", shown)
+		c.JSON(http.StatusOK, gin.H{
+			"data":  out,
+			"count": len(out),
+			"meta":  gin.H{"locale": shown, "requested": requested, "fallbacks": chain},
+		})
+	}
+}
+
+// pronunciation puts together the phonetics and public recordings of
+// body's name in tag. Serbian recordings serve both scripts.
+func pronunciation(body models.Planet, tag string, clips []assets.Asset) Pronunciation {
+	t := bodyText(body, tag)
+	p := Pronunciation{Locale: tag, Name: t.Name, IPA: t.IPA, Respelling: t.Respelling, Audio: []PronunciationClip{}}
+	if p.Name == "" {
+		p.Name = body.Name
+	}
+	for _, want := range []string{tag, baseLocale} {
+		for _, a := range clips {
+			if a.Locale != want || a.Private {
+				continue
+			}
+			p.Audio = append(p.Audio, PronunciationClip{
+				URL:         "/assets/" + a.Path,
+				MediaType:   assets.ContentType(a.Path),
+				Size:        a.Size,
+				License:     a.License,
+				Attribution: a.Attribution,
+				Source:      a.Source,
+			})
+		}
+		if len(p.Audio) > 0 || (tag != "sr-Latn" && tag != "sr-Cyrl") {
+			break
+		}
+	}
+	return p
+}
+
+// findTextBody looks up a body or one of the scene's moons, as a Planet
+// carrying just what bodyText reads for a moon
+func findTextBody(c *gin.Context, st *store.Store, name string) (models.Planet, bool) {
+	if p, ok := findPlanet(c.Request.Context(), st, name); ok {
+		return p, true
+	}
+	if m, ok := findMoon(name); ok {
+		return models.Planet{Name: m.Name, NameSR: m.NameSR, Description: m.Description, Translations: m.Translations}, true
+	}
+	return models.Planet{}, false
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/astro"
+	"solar-system-explorer/backend/clock"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/physics"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/translit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Kinds of body /api/random picks from; ?type= is validated against the
+// same list
+const (
+	randomPlanet    = "planet"
+	randomMoon      = "moon"
+	randomSmallBody = "small_body"
+	randomExoplanet = "exoplanet"
+)
+
+// Discovery is a randomly picked body with one fact about it
+type Discovery struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Parent      string `json:"parent,omitempty"` // planet or host star
+	Fact        string `json:"fact"`
+	Locale      string `json:"locale"`
+	Link        string `json:"link"` // API path with more about the body
+}
+
+// factText holds the fact templates; numbers are formatted per language
+// and sr-Cyrl is transliterated from sr
+var factText = map[string]map[string]string{
+	"sr": {
+		"year":      "Jedna godina traje %s zemaljskih dana.",
+		"dayYear":   "Dan traje duže od godine: %s naspram %s zemaljskih dana.",
+		"light":     "Sunčevoj svetlosti treba %s minuta da stigne dovde.",
+		"weight":    "Osoba od 70 kg ovde bi bila teška kao %s kg na Zemlji.",
+		"moons":     "Broj poznatih meseca: %s.",
+		"temp":      "Srednja temperatura na površini je %s °C.",
+		"distance":  "Od Sunca je u proseku udaljeno %s AJ.",
+		"orbit":     "Jedan obilazak oko matične planete (%s) traje %s dana.",
+		"diameter":  "Prečnik: %s km.",
+		"lightYear": "Svetlosti sa ove planete treba %s godina da stigne do nas.",
+		"hz":        "Nalazi se u nastanjivoj zoni svoje zvezde (%s).",
+		"found":     "Otkrivena je %s. godine.",
+	},
+	"en": {
+		"year":      "One year lasts %s Earth days.",
+		"dayYear":   "A day lasts longer than a year: %s versus %s Earth days.",
+		"light":     "Sunlight takes %s minutes to get here.",
+		"weight":    "A 70 kg person would weigh here what %s kg weighs on Earth.",
+		"moons":     "Known moons: %s.",
+		"temp":      "The mean surface temperature is %s °C.",
+		"distance":  "Its mean distance from the Sun is %s AU.",
+		"orbit":     "One orbit of its planet (%s) takes %s days.",
+		"diameter":  "It is %s km across.",
+		"lightYear": "Light from this planet takes %s years to reach us.",
+		"hz":        "It lies in the habitable zone of its star (%s).",
+		"found":     "It was discovered in %s.",
+	},
+}
+
+// fact is one template key with its arguments; an empty key stands for
+// the body's localized description
+type fact struct {
+	key  string
+	args []any // float64 numbers, names or strings
+}
+
+// names is a name argument given in English and Serbian
+type names struct{ en, sr string }
+
+type candidate struct {
+	typ, parent, link string
+	body              models.Planet // names and description, for localize
+	facts             []fact
+}
+
+// GetRandom picks a body of ?type= (planet, moon, small_body, exoplanet;
+// default any) together with a fact about it. The pick is deterministic
+// for ?seed=, so a link can be shared; without one the seed is today's
+// UTC date and everyone gets the same body of the day.
+func GetRandom(st *store.Store, clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Type string `form:"type" binding:"omitempty,oneof=planet moon small_body exoplanet"`
+			Seed string `form:"seed" binding:"max=64"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		typ, seed := req.Type, req.Seed
+		if seed == "" {
+			seed = clock.Now(c.Request.Context(), clk).UTC().Format(time.DateOnly)
+		}
+		_, chain := requestLocales(c)
+
+		var pool []candidate
+		for _, cand := range randomCandidates(solarSystemBodies(c.Request.Context(), st)) {
+			if typ == "" || cand.typ == typ {
+				pool = append(pool, cand)
+			}
+		}
+		if len(pool) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No bodies of type " + typ})
+			return
+		}
+
+		h := fnv.New64a()
+		h.Write([]byte(typ + "\x00" + seed))
+		r := rand.New(rand.NewPCG(h.Sum64(), 0x5eed))
+		cand := pool[r.IntN(len(pool))]
+		f := cand.facts[r.IntN(len(cand.facts))]
+
+		body := localize(cand.body, chain)
+		lang := factLocale(chain)
+		text := body.Description
+		if f.key != "" {
+			text = factSentence(lang, f)
+		}
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Header("Content-Language", lang)
+		share := url.Values{"seed": {seed}}
+		if typ != "" {
+			share.Set("type", typ)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"data": Discovery{
+				Type:        cand.typ,
+				Name:        cand.body.Name,
+				DisplayName: body.DisplayName,
+				Parent:      cand.parent,
+				Fact:        text,
+				Locale:      lang,
+				Link:        cand.link,
+			},
+			"meta": gin.H{"seed": seed, "candidates": len(pool), "share": "/api/random?" + share.Encode()},
+		})
+	}
+}
+
+// randomCandidates lists everything /api/random can pick, with the facts
+// the data supports for each. The order is fixed so seeds stay stable
+// while the dataset does.
+func randomCandidates(bodies []models.Planet) []candidate {
+	builtin := make(map[string]bool)
+	for _, p := range models.GetSolarSystemBodies() {
+		builtin[p.Name] = true
+	}
+	nameSR := make(map[string]string)
+	for _, p := range bodies {
+		nameSR[p.Name] = p.NameSR
+	}
+
+	var out []candidate
+	for _, p := range bodies {
+		if p.IsStar {
+			continue
+		}
+		cand := candidate{typ: randomSmallBody, body: p, link: "/api/planets/" + url.PathEscape(p.Name)}
+		if builtin[p.Name] {
+			cand.typ = randomPlanet
+			cand.facts = append(cand.facts, fact{})
+		}
+		if p.OrbitalPeriod > 0 {
+			cand.facts = append(cand.facts, fact{"year", []any{round(p.OrbitalPeriod, 0)}})
+			if day := astro.SolarDay(p.RotationPeriod, p.OrbitalPeriod); day > p.OrbitalPeriod {
+				cand.facts = append(cand.facts, fact{"dayYear", []any{round(day, 0), round(p.OrbitalPeriod, 0)}})
+			}
+		}
+		if p.DistanceFromSun > 0 {
+			minutes := p.DistanceFromSun * physics.AU / physics.SpeedOfLight / 60
+			cand.facts = append(cand.facts, fact{"light", []any{round(minutes, 1)}})
+			if cand.typ == randomSmallBody {
+				cand.facts = append(cand.facts, fact{"distance", []any{round(p.DistanceFromSun, 2)}})
+			}
+		}
+		if mass := bodyMass(p); mass > 0 && p.Radius > 0 {
+			g := physics.SurfaceGravity(mass, p.Radius*1000)
+			cand.facts = append(cand.facts, fact{"weight", []any{round(70*g/physics.EarthGravity, 1)}})
+		}
+		if p.Satellites > 0 {
+			cand.facts = append(cand.facts, fact{"moons", []any{float64(p.Satellites)}})
+		}
+		if t := p.SurfaceTemperature; t != nil {
+			cand.facts = append(cand.facts, fact{"temp", []any{round(t.Mean-zeroCelsius, 0)}})
+		}
+		if len(cand.facts) > 0 {
+			out = append(out, cand)
+		}
+	}
+
+	for _, m := range models.GetMoons() {
+		out = append(out, candidate{
+			typ:    randomMoon,
+			parent: m.Parent,
+			link:   "/api/planets/" + url.PathEscape(m.Parent),
+			body:   models.Planet{Name: m.Name, NameSR: m.NameSR, Description: m.Description, Translations: m.Translations},
+			facts: []fact{
+				{},
+				{"orbit", []any{names{m.Parent, nameSR[m.Parent]}, round(math.Abs(m.OrbitalPeriod), 2)}},
+				{"diameter", []any{round(2*m.Radius, 0)}},
+			},
+		})
+	}
+
+	for _, h := range models.GetHostStars() {
+		inner := physics.RecentVenus.Distance(h.Luminosity, h.Temperature)
+		outer := physics.EarlyMars.Distance(h.Luminosity, h.Temperature)
+		for _, p := range h.Planets {
+			facts := []fact{
+				{"lightYear", []any{round(h.DistanceLy, 1)}},
+				{"year", []any{round(p.OrbitalPeriod, 1)}},
+				{"found", []any{strconv.Itoa(p.Discovered)}},
+			}
+			if p.SemiMajorAxis >= inner && p.SemiMajorAxis <= outer {
+				facts = append(facts, fact{"hz", []any{h.Name}})
+			}
+			out = append(out, candidate{
+				typ:    randomExoplanet,
+				parent: h.Name,
+				link:   "/api/stars/" + url.PathEscape(h.Name) + "/habitable-zone",
+				body:   models.Planet{Name: p.Name, NameSR: p.Name},
+				facts:  facts,
+			})
+		}
+	}
+	return out
+}
+
+// factLocale picks the fact language from the request's chain
+func factLocale(chain []string) string {
+	for _, tag := range chain {
+		switch tag {
+		case "sr", "sr-Latn":
+			return "sr"
+		case "sr-Cyrl", "en":
+			return tag
+		}
+	}
+	return baseLocale
+}
+
+// factSentence renders f in lang, with decimal commas in Serbian
+func factSentence(lang string, f fact) string {
+	base := lang
+	if lang == "sr-Cyrl" {
+		base = "sr"
+	}
+	args := make([]any, len(f.args))
+	for i, a := range f.args {
+		switch v := a.(type) {
+		case float64:
+			s := strconv.FormatFloat(v, 'f', -1, 64)
+			if base == "sr" {
+				s = strings.Replace(s, ".", ",", 1)
+			}
+			args[i] = s
+		case names:
+			args[i] = v.en
+			if base == "sr" && v.sr != "" {
+				args[i] = v.sr
+			}
+		default:
+			args[i] = v
+		}
+	}
+	s := fmt.Sprintf(factText[base][f.key], args...)
+	if lang == "sr-Cyrl" {
+		s = translit.ToCyrillic(s)
+	}
+	return s
+}
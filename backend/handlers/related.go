@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/physics"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/views"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Body types used to compare bodies
+const (
+	typeStar        = "star"
+	typeTerrestrial = "terrestrial"
+	typeGasGiant    = "gas_giant"
+	typeIceGiant    = "ice_giant"
+	typeSmallBody   = "small_body"
+	typeMoon        = "moon"
+)
+
+// RelatedBody is a body suggested for another, with what they share
+type RelatedBody struct {
+	Name    string   `json:"name"`
+	NameSR  string   `json:"name_sr"`
+	Type    string   `json:"type"`
+	Score   float64  `json:"score"`   // 0–1
+	Reasons []string `json:"reasons"` // same_type, similar_size, similar_composition
+}
+
+// AlsoViewedBody is a body opened by visitors who opened another
+type AlsoViewedBody struct {
+	Name   string  `json:"name"`
+	NameSR string  `json:"name_sr"`
+	Count  int64   `json:"count"`
+	Share  float64 `json:"share"` // of the requested body's views
+}
+
+// profile is what bodies are compared by: type, radius and a rough mix of
+// metal, rock, ice and gas
+type profile struct {
+	name, nameSR string
+	typ          string
+	radius       float64 // km
+	mix          [4]float64
+}
+
+// bodyProfile classifies a body of the dataset. The mix is estimated from
+// the mean density: iron-rich worlds approach 7.9 g/cm³, rock sits near
+// 3.3 and water ice near 0.9, while the giants are mostly gas or ices.
+func bodyProfile(p models.Planet) profile {
+	pr := profile{name: p.Name, nameSR: p.NameSR, radius: p.Radius}
+	density := 0.0
+	if p.Mass > 0 && p.Radius > 0 {
+		density = physics.Density(p.Mass, p.Radius*1000)
+	}
+	switch {
+	case p.IsStar:
+		pr.typ, pr.mix = typeStar, [4]float64{0, 0, 0, 1}
+	case p.Radius > 50000 && density > 0 && density < 1500:
+		pr.typ, pr.mix = typeGasGiant, [4]float64{0, 0.05, 0.1, 0.85}
+	case p.Radius > 15000:
+		pr.typ, pr.mix = typeIceGiant, [4]float64{0, 0.15, 0.65, 0.2}
+	case p.Radius < 1500:
+		pr.typ, pr.mix = typeSmallBody, solidMix(density)
+	default:
+		pr.typ, pr.mix = typeTerrestrial, solidMix(density)
+	}
+	return pr
+}
+
+// moonProfile classifies a moon of the scene. Moons carry no mass, so
+// those of the outer planets are taken as ice and rock and the rest as
+// rock.
+func moonProfile(m models.Moon, parent models.Planet) profile {
+	mix := [4]float64{0.1, 0.9, 0, 0}
+	if parent.DistanceFromSun > 4 {
+		mix = [4]float64{0, 0.5, 0.5, 0}
+	}
+	return profile{name: m.Name, nameSR: m.NameSR, typ: typeMoon, radius: m.Radius, mix: mix}
+}
+
+// solidMix splits a solid body of the given density (kg/m³, 0 when
+// unknown) into metal, rock and ice
+func solidMix(density float64) [4]float64 {
+	if density == 0 {
+		return [4]float64{0, 0.5, 0.5, 0}
+	}
+	clamp := func(x float64) float64 { return math.Max(0, math.Min(1, x)) }
+	metal := clamp((density - 3300) / (7870 - 3300))
+	ice := clamp((3000 - density) / (3000 - 920))
+	return [4]float64{metal, 1 - metal - ice, ice, 0}
+}
+
+// similarity scores how alike two profiles are, 0–1: the same type counts
+// 0.4, radii within a factor of ten up to 0.3, and the composition mix
+// (cosine) up to 0.3
+func similarity(a, b profile) (float64, []string) {
+	var score float64
+	var reasons []string
+	if a.typ == b.typ {
+		score += 0.4
+		reasons = append(reasons, "same_type")
+	}
+	if a.radius > 0 && b.radius > 0 {
+		size := math.Max(0, 1-math.Abs(math.Log10(a.radius/b.radius)))
+		score += 0.3 * size
+		if size >= 0.7 {
+			reasons = append(reasons, "similar_size")
+		}
+	}
+	var dot, na, nb float64
+	for i := range a.mix {
+		dot += a.mix[i] * b.mix[i]
+		na += a.mix[i] * a.mix[i]
+		nb += b.mix[i] * b.mix[i]
+	}
+	if na > 0 && nb > 0 {
+		mix := dot / math.Sqrt(na*nb)
+		score += 0.3 * mix
+		if mix >= 0.9 {
+			reasons = append(reasons, "similar_composition")
+		}
+	}
+	if reasons == nil {
+		reasons = []string{}
+	}
+	return score, reasons
+}
+
+// GetRelatedBodies suggests bodies like a body or moon, by type, size and
+// composition, and those most often viewed with it, for the detail page
+// footer. ?limit= caps each list (default 5, max 20).
+func GetRelatedBodies(st *store.Store, vs *views.Stats) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Limit int `form:"limit" binding:"omitempty,min=1,max=20"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		limit := req.Limit
+		if limit == 0 {
+			limit = 5
+		}
+		name, ok := surfaceBody(c, st, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+
+		bodies := solarSystemBodies(c.Request.Context(), st)
+		byName := make(map[string]models.Planet, len(bodies))
+		var all []profile
+		for _, b := range bodies {
+			byName[b.Name] = b
+			all = append(all, bodyProfile(b))
+		}
+		for _, m := range models.GetMoons() {
+			all = append(all, moonProfile(m, byName[m.Parent]))
+		}
+		var self profile
+		nameSR := make(map[string]string, len(all))
+		for _, p := range all {
+			nameSR[p.name] = p.nameSR
+			if p.name == name {
+				self = p
+			}
+		}
+
+		similar := make([]RelatedBody, 0, len(all))
+		for _, p := range all {
+			if p.name == name {
+				continue
+			}
+			score, reasons := similarity(self, p)
+			similar = append(similar, RelatedBody{Name: p.name, NameSR: p.nameSR, Type: p.typ, Score: round(score, 3), Reasons: reasons})
+		}
+		sort.SliceStable(similar, func(i, j int) bool { return similar[i].Score > similar[j].Score })
+		similar = similar[:min(limit, len(similar))]
+
+		also := []AlsoViewedBody{}
+		if vs != nil {
+			for _, cv := range vs.AlsoViewed(name, limit) {
+				also = append(also, AlsoViewedBody{Name: cv.Body, NameSR: nameSR[cv.Body], Count: cv.Count, Share: round(cv.Share, 3)})
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{"name": name, "type": self.typ, "similar": similar, "also_viewed": also},
+		})
+	}
+}
+
+// RecordView counts a successful view of the body in the :name path
+// parameter for "people also viewed". Visitors who send Do Not Track or
+// Global Privacy Control aren't counted.
+func RecordView(st *store.Store, vs *views.Stats) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if vs == nil || c.Writer.Status() != http.StatusOK || !tracking(c) {
+			return
+		}
+		if name, ok := surfaceBody(c, st, c.Param("name")); ok {
+			vs.Record(c.ClientIP()+"\x00"+c.Request.UserAgent(), name, time.Now())
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jsonContentType is what gin's c.JSON sends
+const jsonContentType = "application/json; charset=utf-8"
+
+// bufferPool recycles the buffers responses are encoded into
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// maxPooledBuffer keeps an unusually large response's buffer from
+// staying in the pool
+const maxPooledBuffer = 1 << 20
+
+// marshalJSON encodes v as json.Marshal does, into a pooled buffer.
+// The returned bytes are a copy the caller may keep.
+func marshalJSON(v any) ([]byte, error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		if buf.Cap() <= maxPooledBuffer {
+			buf.Reset()
+			bufferPool.Put(buf)
+		}
+	}()
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), nil
+}
+
+// renderedCache holds responses already encoded for the current dataset,
+// so the common requests are answered by writing bytes. All entries are
+// dropped when the dataset's ETag changes. Keys include client input
+// such as the requested locale, so at most max are kept; others are
+// encoded each time.
+type renderedCache struct {
+	max int
+
+	mu      sync.RWMutex
+	etag    string
+	entries map[string]rendered
+}
+
+// rendered is an encoded response and its Content-Language
+type rendered struct {
+	body     []byte
+	language string
+}
+
+func newRenderedCache(max int) *renderedCache {
+	return &renderedCache{max: max, entries: make(map[string]rendered)}
+}
+
+// get returns the encoding of what build returns for key under etag,
+// encoding and keeping it on first use. build also names the response's
+// language.
+func (rc *renderedCache) get(etag, key string, build func() (any, string)) (rendered, error) {
+	rc.mu.RLock()
+	r, ok := rc.entries[key]
+	current := rc.etag == etag
+	rc.mu.RUnlock()
+	if ok && current {
+		return r, nil
+	}
+	v, language := build()
+	body, err := marshalJSON(v)
+	if err != nil {
+		return rendered{}, err
+	}
+	r = rendered{body: body, language: language}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.etag != etag {
+		rc.etag = etag
+		clear(rc.entries)
+	}
+	if len(rc.entries) < rc.max {
+		rc.entries[key] = r
+	}
+	return r, nil
+}
+
+// write sends r as c.JSON would, or a 500 if encoding failed
+func (r rendered) write(c *gin.Context, status int, err error) {
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not encode the response"})
+		return
+	}
+	if r.language != "" {
+		c.Header("Content-Language", r.language)
+	}
+	c.Data(status, jsonContentType, r.body)
+}
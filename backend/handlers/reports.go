@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/comments"
+	"solar-system-explorer/backend/middleware"
+	"solar-system-explorer/backend/reports"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PostReport lets a signed-in user flag content: {"kind": "comment",
+// "target", "reason", "detail"}. The report that brings a visible comment
+// to the threshold hides it until a moderator decides.
+func PostReport(rs *reports.Reports, cs *comments.Comments) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Kind   string `json:"kind"`
+			Target string `json:"target" binding:"required"`
+			Reason string `json:"reason" binding:"required"`
+			Detail string `json:"detail"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		if req.Kind == "" {
+			req.Kind = reports.KindComment
+		}
+		user, _ := middleware.CurrentUser(c)
+		if req.Kind == reports.KindComment {
+			cm, err := cs.Get(req.Target)
+			if err != nil || cm.Status == comments.StatusDeleted || cm.Status == comments.StatusHidden {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+				return
+			}
+			if cm.AuthorID == user.Subject {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "You can't report your own comment"})
+				return
+			}
+		}
+		rep, hide, err := rs.File(req.Kind, req.Target, user.Subject, req.Reason, req.Detail, time.Now())
+		switch {
+		case errors.Is(err, reports.ErrInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, reports.ErrDuplicate):
+			c.JSON(http.StatusConflict, gin.H{"error": "You have already reported this"})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if hide {
+			if cm, err := cs.Get(rep.Target); err == nil && cm.Status == comments.StatusVisible {
+				if _, _, err := cs.Moderate(rep.Target, comments.StatusHidden, time.Now()); err != nil {
+					log.Printf("reports: hiding comment %s: %v", rep.Target, err)
+				}
+			}
+		}
+		c.JSON(http.StatusCreated, gin.H{"data": rep})
+	}
+}
+
+// reportCase is a moderation case with the reported content
+type reportCase struct {
+	reports.Case
+	Comment *comments.Comment `json:"comment,omitempty"`
+}
+
+// ListReports is the report queue: content with open reports, most
+// reported first
+func ListReports(rs *reports.Reports, cs *comments.Comments) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		queue := rs.Queue()
+		data := make([]reportCase, 0, len(queue))
+		for _, rc := range queue {
+			item := reportCase{Case: rc}
+			if rc.Kind == reports.KindComment {
+				if cm, err := cs.Get(rc.Target); err == nil {
+					item.Comment = &cm
+				}
+			}
+			data = append(data, item)
+		}
+		c.JSON(http.StatusOK, gin.H{"data": data, "count": len(data)})
+	}
+}
+
+// ResolveReports closes the open reports against a piece of content:
+// {"status": "upheld" | "dismissed"}. Upheld hides the content; dismissed
+// brings back content the reports had hidden.
+func ResolveReports(rs *reports.Reports, cs *comments.Comments, auditLog *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Status string `json:"status" binding:"required"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		kind, target := c.Param("kind"), c.Param("id")
+		resolved, err := rs.Resolve(kind, target, req.Status, time.Now())
+		switch {
+		case errors.Is(err, reports.ErrInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, reports.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "No open reports for this content"})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		recordAudit(c, auditLog, audit.ActionUpdate, "report", kind+"/"+target,
+			gin.H{"status": reports.StatusOpen, "count": resolved.Count}, gin.H{"status": req.Status, "count": resolved.Count})
+		if kind == reports.KindComment {
+			status := ""
+			cm, err := cs.Get(target)
+			switch {
+			case err != nil:
+			case req.Status == reports.StatusUpheld && cm.Status == comments.StatusVisible:
+				status = comments.StatusHidden
+			case req.Status == reports.StatusDismissed && cm.Status == comments.StatusHidden && resolved.AutoHidden:
+				status = comments.StatusVisible
+			}
+			if status != "" {
+				before, after, err := cs.Moderate(target, status, time.Now())
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+				recordAudit(c, auditLog, audit.ActionUpdate, "comment", target, before, after)
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"data": resolved})
+	}
+}
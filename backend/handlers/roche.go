@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"solar-system-explorer/backend/physics"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// materialDensity are typical densities (kg/m³) a secondary may be given as
+var materialDensity = map[string]float64{
+	"ice":    920,  // water ice, like Saturn's ring particles
+	"comet":  550,  // porous ice and dust
+	"rubble": 2000, // rubble-pile asteroid
+	"rock":   3000,
+	"iron":   7870,
+}
+
+// RocheBody is one side of the calculation
+type RocheBody struct {
+	Name     string  `json:"name"`
+	Mass     float64 `json:"mass_kg,omitempty"`
+	RadiusKm float64 `json:"radius_km,omitempty"`
+	Density  float64 `json:"density_kg_m3"`
+}
+
+// RocheLimit is the distance inside which the secondary is torn apart
+type RocheLimit struct {
+	RigidKm    float64 `json:"rigid_km"`
+	FluidKm    float64 `json:"fluid_km"`
+	RigidRadii float64 `json:"rigid_radii"` // in primary radii
+	FluidRadii float64 `json:"fluid_radii"`
+}
+
+// TidalPoint compares the tidal stretch on the secondary with its own
+// gravity at one distance. A ratio of 1 or more pulls apart a rigid rubble
+// pile; a fluid body deforms and fails further out, at the fluid limit.
+type TidalPoint struct {
+	Label       string  `json:"label"`
+	DistanceKm  float64 `json:"distance_km"`
+	Radii       float64 `json:"distance_radii"`
+	Tidal       float64 `json:"tidal_acceleration_m_s2"`
+	SelfGravity float64 `json:"self_gravity_m_s2"`
+	Ratio       float64 `json:"ratio"`
+	TornApart   bool    `json:"torn_apart"`
+}
+
+// GetRoche computes the Roche limits of ?secondary= (a material — ice,
+// comet, rubble, rock, iron — or a body name) around ?primary= (a body
+// with known mass). It compares tidal and self-gravity for a secondary of
+// radius ?size= km (default 1, or the body's radius) at the primary's
+// surface, both limits, twice the fluid limit and an optional ?distance=
+// (km from the centre).
+func GetRoche(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		pb, ok := findPlanet(ctx, st, c.Query("primary"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Primary body not found"})
+			return
+		}
+		mass := bodyMass(pb)
+		if mass == 0 || pb.Radius == 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "The mass of " + pb.Name + " is unknown"})
+			return
+		}
+		r := pb.Radius * 1000
+		primary := RocheBody{Name: pb.Name, Mass: mass, RadiusKm: pb.Radius, Density: round(physics.Density(mass, r), 0)}
+
+		name := strings.ToLower(strings.TrimSpace(c.DefaultQuery("secondary", "ice")))
+		secondary := RocheBody{Name: name, Density: materialDensity[name]}
+		if secondary.Density == 0 {
+			sb, ok := findPlanet(ctx, st, name)
+			if !ok {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Secondary must be a body or one of: " + strings.Join(materials(), ", ")})
+				return
+			}
+			sm := bodyMass(sb)
+			if sm == 0 || sb.Radius == 0 {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "The mass of " + sb.Name + " is unknown"})
+				return
+			}
+			secondary = RocheBody{Name: sb.Name, Mass: sm, RadiusKm: sb.Radius, Density: round(physics.Density(sm, sb.Radius*1000), 0)}
+		}
+		defSize := 1.0
+		if secondary.RadiusKm > 0 {
+			defSize = secondary.RadiusKm
+		}
+		size, ok := queryFloat(c, "size", defSize, 0.001, 100000)
+		if !ok {
+			return
+		}
+		distance, ok := queryFloat(c, "distance", 0, 0, 1e10)
+		if !ok {
+			return
+		}
+
+		rigid := physics.RocheRigid(r, primary.Density, secondary.Density)
+		fluid := physics.RocheFluid(r, primary.Density, secondary.Density)
+		type at struct {
+			label string
+			d     float64 // m
+		}
+		points := []at{{"surface", r}, {"rigid_limit", rigid}, {"fluid_limit", fluid}, {"twice_fluid_limit", 2 * fluid}}
+		if distance > 0 {
+			points = append(points, at{"requested", distance * 1000})
+		}
+		satR := size * 1000
+		self := physics.SurfaceGravity(secondary.Density*4/3*math.Pi*satR*satR*satR, satR)
+		tidal := make([]TidalPoint, 0, len(points))
+		for _, p := range points {
+			t := physics.TidalAcceleration(mass, satR, p.d)
+			tidal = append(tidal, TidalPoint{
+				Label:       p.label,
+				DistanceKm:  round(p.d/1000, 0),
+				Radii:       round(p.d/r, 3),
+				Tidal:       significant(t, 4),
+				SelfGravity: significant(self, 4),
+				Ratio:       significant(t/self, 4),
+				TornApart:   t >= self,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"primary":   primary,
+				"secondary": secondary,
+				"roche_limit": RocheLimit{
+					RigidKm:    round(rigid/1000, 0),
+					FluidKm:    round(fluid/1000, 0),
+					RigidRadii: round(rigid/r, 3),
+					FluidRadii: round(fluid/r, 3),
+				},
+				"secondary_radius_km": size,
+				"tidal":               tidal,
+			},
+		})
+	}
+}
+
+func materials() []string {
+	out := make([]string, 0, len(materialDensity))
+	for m := range materialDensity {
+		out = append(out, m)
+	}
+	sort.Strings(out)
+	return out
+}
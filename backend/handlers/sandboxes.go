@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"solar-system-explorer/backend/middleware"
+	"solar-system-explorer/backend/sandbox"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateSandbox makes an empty sandbox owned by the signed-in user:
+// {"name", "shared"} (both optional). It runs behind a required UserAuth.
+func CreateSandbox(sbs *sandbox.Sandboxes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Name   string `json:"name"`
+			Shared bool   `json:"shared"`
+		}
+		if c.Request.ContentLength != 0 {
+			if !bindJSON(c, &req) {
+				return
+			}
+		}
+		sb, err := sbs.Create(req.Name, sandboxUser(c), req.Shared, time.Now())
+		if err != nil {
+			sandboxError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"data": gin.H{"sandbox": sb}})
+	}
+}
+
+// GetSandbox returns a sandbox and its overrides
+func GetSandbox(sbs *sandbox.Sandboxes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sb, err := sbs.Get(c.Param("id"), sandboxUser(c), time.Now())
+		if err != nil {
+			sandboxError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": sb})
+	}
+}
+
+// DeleteSandbox removes a sandbox
+func DeleteSandbox(sbs *sandbox.Sandboxes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := sbs.Delete(c.Param("id"), sandboxUser(c), time.Now()); err != nil {
+			sandboxError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// GetSandboxPlanets returns the dataset as the sandbox changes it, shaped
+// and localized like GetPlanets, with the changed bodies listed in meta
+func GetSandboxPlanets(st *store.Store, sbs *sandbox.Sandboxes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sb, err := sbs.Get(c.Param("id"), sandboxUser(c), time.Now())
+		if err != nil {
+			sandboxError(c, err)
+			return
+		}
+		requested, chain := requestLocales(c)
+		bodies := sb.Apply(solarSystemBodies(c.Request.Context(), st))
+		planets := make([]localizedPlanet, len(bodies))
+		for i, b := range bodies {
+			planets[i] = localize(b, chain)
+		}
+		changed := make([]string, 0, len(sb.Edits))
+		for name := range sb.Edits {
+			changed = append(changed, name)
+		}
+		sort.Strings(changed)
+		c.Header("Cache-Control", "private, no-cache")
+		c.JSON(http.StatusOK, gin.H{
+			"data":  planets,
+			"count": len(planets),
+			"meta":  gin.H{"sandbox": sb.ID, "changed": changed, "requested": requested, "fallbacks": chain},
+		})
+	}
+}
+
+// EditSandboxPlanet overrides fields of one body in a sandbox. The body is
+// a JSON object of field → value, e.g. {"mass": 3.8e27} or {"rings":
+// {"inner_radius": 5000, "outer_radius": 9000}}; "rings": null removes
+// rings. Sending a field's real value drops the override.
+func EditSandboxPlanet(st *store.Store, sbs *sandbox.Sandboxes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		planet, ok := findPlanet(c.Request.Context(), st, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+		var fields map[string]json.RawMessage
+		if !bindJSON(c, &fields) {
+			return
+		}
+		if len(fields) == 0 {
+			invalid(c, FieldError{Field: "body", Message: "must name at least one field to change"})
+			return
+		}
+		_, body, err := sbs.Edit(c.Param("id"), sandboxUser(c), planet, fields, time.Now())
+		if err != nil {
+			sandboxError(c, err)
+			return
+		}
+		_, chain := requestLocales(c)
+		c.JSON(http.StatusOK, gin.H{"data": localize(body, chain)})
+	}
+}
+
+// ResetSandboxPlanet drops a sandbox's overrides of one body
+func ResetSandboxPlanet(st *store.Store, sbs *sandbox.Sandboxes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		planet, ok := findPlanet(c.Request.Context(), st, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+		if _, err := sbs.Reset(c.Param("id"), sandboxUser(c), planet.Name, time.Now()); err != nil {
+			sandboxError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// sandboxUser is the user UserAuth signed in, or "" for an anonymous
+// reader
+func sandboxUser(c *gin.Context) string {
+	claims, _ := middleware.CurrentUser(c)
+	return claims.Subject
+}
+
+func sandboxError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, sandbox.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sandbox not found"})
+	case errors.Is(err, sandbox.ErrUnauthorized) && sandboxUser(c) != "":
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the sandbox's owner can do this"})
+	case errors.Is(err, sandbox.ErrUnauthorized):
+		c.Header("WWW-Authenticate", `Bearer realm="user"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	case errors.Is(err, sandbox.ErrQuota):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, sandbox.ErrInvalid):
+		invalid(c, fieldErrors(sandbox.ErrInvalid, err)...)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
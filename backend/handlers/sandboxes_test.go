@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/middleware"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/sandbox"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newSandboxServer routes the sandbox endpoints as main does
+func newSandboxServer(t *testing.T) (*gin.Engine, func(email string) map[string]string) {
+	t.Helper()
+	sbs, err := sandbox.Open("", sandbox.Limits{PerUser: 2, Total: 100, MaxEdits: 10, TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	us, sessions := newAccounts(t)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/sandboxes", middleware.UserAuth(sessions, true), CreateSandbox(sbs))
+	r.GET("/api/sandboxes/:id", middleware.UserAuth(sessions, false), GetSandbox(sbs))
+	r.DELETE("/api/sandboxes/:id", middleware.UserAuth(sessions, true), DeleteSandbox(sbs))
+	r.PATCH("/api/sandboxes/:id/planets/:name", middleware.UserAuth(sessions, true),
+		EditSandboxPlanet(store.New(models.GetSolarSystemBodies()), sbs))
+	return r, func(email string) map[string]string { return signIn(t, us, sessions, email, "viewer") }
+}
+
+// createSandbox returns the new sandbox's ID, failing unless the answer
+// is want
+func createSandbox(t *testing.T, r http.Handler, header map[string]string, shared bool, want int) string {
+	t.Helper()
+	w := testutil.Send(r, http.MethodPost, "/api/sandboxes", header, fmt.Sprintf(`{"shared": %t}`, shared))
+	if w.Code != want {
+		t.Fatalf("create = %d, want %d: %s", w.Code, want, w.Body)
+	}
+	var resp struct {
+		Data struct {
+			Sandbox sandbox.Sandbox `json:"sandbox"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp.Data.Sandbox.ID
+}
+
+// Only signed-in users make and change sandboxes, each within their own
+// quota; anyone may read a shared one
+func TestSandboxOwnedByUser(t *testing.T) {
+	r, signIn := newSandboxServer(t)
+	ana, ben := signIn("ana@example.com"), signIn("ben@example.com")
+
+	createSandbox(t, r, nil, false, http.StatusUnauthorized)
+	id := createSandbox(t, r, ana, false, http.StatusCreated)
+	shared := createSandbox(t, r, ana, true, http.StatusCreated)
+	createSandbox(t, r, ana, false, http.StatusTooManyRequests) // per-user limit of 2
+	createSandbox(t, r, ben, false, http.StatusCreated)
+
+	tests := []struct {
+		name   string
+		method string
+		id     string
+		header map[string]string
+		want   int
+	}{
+		{"owner", http.MethodGet, id, ana, http.StatusOK},
+		{"other user", http.MethodGet, id, ben, http.StatusForbidden},
+		{"anonymous", http.MethodGet, id, nil, http.StatusUnauthorized},
+		{"shared, other user", http.MethodGet, shared, ben, http.StatusOK},
+		{"shared, anonymous", http.MethodGet, shared, nil, http.StatusOK},
+		{"delete shared, other user", http.MethodDelete, shared, ben, http.StatusForbidden},
+		{"delete shared, anonymous", http.MethodDelete, shared, nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := testutil.Do(r, tt.method, "/api/sandboxes/"+tt.id, tt.header); w.Code != tt.want {
+				t.Errorf("%s = %d, want %d", tt.method, w.Code, tt.want)
+			}
+		})
+	}
+
+	if w := testutil.Do(r, http.MethodDelete, "/api/sandboxes/"+id, ana); w.Code != http.StatusNoContent {
+		t.Errorf("DELETE by the owner = %d, want 204", w.Code)
+	}
+	createSandbox(t, r, ana, false, http.StatusCreated) // the deleted one no longer counts
+}
+
+// Changes the sandbox won't take answer 422 naming the fields at fault
+func TestSandboxEditFields(t *testing.T) {
+	r, signIn := newSandboxServer(t)
+	ana := signIn("ana@example.com")
+	id := createSandbox(t, r, ana, false, http.StatusCreated)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		fields []FieldError
+	}{
+		{"not JSON", `{"radius":`, http.StatusBadRequest, nil},
+		{"nothing to change", `{}`, http.StatusUnprocessableEntity, []FieldError{
+			{"body", "must name at least one field to change"}}},
+		{"unphysical", `{"radius": -1, "albedo": 2}`, http.StatusUnprocessableEntity, []FieldError{
+			{"radius", "must be positive"}, {"albedo", "must be between 0 and 1"}}},
+		{"wrong type", `{"mass": "heavy"}`, http.StatusUnprocessableEntity, []FieldError{
+			{"mass", "must be a number"}}},
+		{"removed", `{"mass": null}`, http.StatusUnprocessableEntity, []FieldError{
+			{"mass", "can't be removed, only rings can"}}},
+		{"changed", `{"albedo": 0.3}`, http.StatusOK, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutil.Send(r, http.MethodPatch, "/api/sandboxes/"+id+"/planets/mars", ana, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("PATCH = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			var resp struct {
+				Fields []FieldError `json:"fields"`
+			}
+			_ = json.Unmarshal(w.Body.Bytes(), &resp)
+			if !reflect.DeepEqual(resp.Fields, tt.fields) {
+				t.Errorf("fields %+v, want %+v", resp.Fields, tt.fields)
+			}
+		})
+	}
+}
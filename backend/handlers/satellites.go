@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/clock"
+	"solar-system-explorer/backend/satellites"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSatellites lists the satellite catalogue, or the satellites of
+// ?group= (stations, science, starlink or as configured), by NORAD
+// catalogue number, ?limit= (default 100, max 1000) at a time; ?q= keeps
+// those whose name contains it. meta.next_cursor, passed back as
+// ?cursor=, continues after the page. Every satellite has its TLE, its
+// age and whether it is stale; meta.groups has each group's last
+// download.
+func GetSatellites(catalog *satellites.Catalog, clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Group  string `form:"group" binding:"max=64"`
+			Q      string `form:"q" binding:"max=100"`
+			Limit  int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+			Cursor int    `form:"cursor" binding:"min=0"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		if req.Limit == 0 {
+			req.Limit = 100
+		}
+		now := clock.Now(c.Request.Context(), clk)
+		groups := catalog.Groups(now)
+		list, err := catalog.List(req.Group, now)
+		if err != nil {
+			names := make([]string, len(groups))
+			for i, g := range groups {
+				names[i] = g.Name
+			}
+			invalid(c, FieldError{Field: "group", Message: "must be one of " + strings.Join(names, ", ")})
+			return
+		}
+		if q := strings.ToLower(strings.TrimSpace(req.Q)); q != "" {
+			list = slices.DeleteFunc(list, func(e satellites.Entry) bool { return !strings.Contains(strings.ToLower(e.Name), q) })
+		}
+
+		start := sort.Search(len(list), func(i int) bool { return list[i].NoradID > req.Cursor })
+		page := list[start:min(start+req.Limit, len(list))]
+		meta := gin.H{"groups": groups, "limit": req.Limit, "next_cursor": nil}
+		if start+len(page) < len(list) {
+			next := page[len(page)-1].NoradID
+			q := c.Request.URL.Query()
+			q.Set("cursor", strconv.Itoa(next))
+			meta["next_cursor"], meta["next"] = next, c.Request.URL.Path+"?"+q.Encode()
+		}
+		c.JSON(http.StatusOK, gin.H{"data": page, "count": len(page), "meta": meta})
+	}
+}
+
+// GetSatellitePasses predicts the passes of the satellite with NORAD
+// catalogue number :id over ?lat= and ?lon= (?height= metres) in the next
+// ?days= days (default 3, at most 10): rise, culmination and set with
+// altitude, azimuth and range, when it is sunlit against a dark sky and,
+// for satellites whose standard magnitude is known, how bright. Passes
+// rising above ?min_alt= degrees (default 10) count; only visible ones
+// are listed unless ?all=true. Times are in ?tz= (default UTC). The TLE
+// comes from the catalogue or else CelesTrak and is refreshed when older
+// than TLE_MAX_AGE; meta has its epoch, since predictions grow kilometres
+// off for every day the elements age.
+func GetSatellitePasses(catalog *satellites.Catalog, clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Lat    *float64 `form:"lat" binding:"required,min=-90,max=90"`
+			Lon    *float64 `form:"lon" binding:"required,min=-180,max=180"`
+			Height float64  `form:"height" binding:"min=-500,max=9000"`
+			Days   int      `form:"days" binding:"omitempty,min=1,max=10"`
+			MinAlt *float64 `form:"min_alt" binding:"omitempty,min=0,max=80"`
+			All    bool     `form:"all"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil || id < 1 || id > 999999999 {
+			invalid(c, FieldError{Field: "id", Message: "must be a NORAD catalogue number"})
+			return
+		}
+		loc, ok := queryZone(c)
+		if !ok {
+			return
+		}
+		if req.Days == 0 {
+			req.Days = 3
+		}
+		minAlt := 10.0
+		if req.MinAlt != nil {
+			minAlt = *req.MinAlt
+		}
+
+		tle, err := catalog.TLE(c.Request.Context(), id)
+		switch {
+		case errors.Is(err, satellites.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "CelesTrak has no elements for this satellite"})
+			return
+		case err != nil:
+			log.Printf("celestrak %d: %v", id, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Satellite elements could not be fetched"})
+			return
+		}
+		prop, err := satellites.NewPropagator(tle.TLE)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+
+		obs := satellites.Observer{Lat: *req.Lat, Lon: *req.Lon, Height: req.Height}
+		from := clock.Now(c.Request.Context(), clk).UTC().Truncate(time.Second)
+		to := from.AddDate(0, 0, req.Days)
+		stdMag := satellites.StandardMagnitudes[id]
+		found, err := prop.Passes(obs, from, to, minAlt, stdMag)
+		if err != nil && !errors.Is(err, satellites.ErrDecayed) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		passes := []satellites.Pass{}
+		for _, p := range found {
+			if !req.All && !p.Visible {
+				continue
+			}
+			p.Start.Time, p.Max.Time, p.End.Time = p.Start.Time.In(loc), p.Max.Time.In(loc), p.End.Time.In(loc)
+			if p.Visible {
+				vf, vt := p.VisibleFrom.In(loc), p.VisibleTo.In(loc)
+				p.VisibleFrom, p.VisibleTo = &vf, &vt
+			}
+			passes = append(passes, p)
+		}
+
+		meta := zoneMeta(loc, from)
+		sat := gin.H{
+			"name":       tle.Name,
+			"norad_id":   tle.NoradID,
+			"tle_epoch":  tle.Epoch,
+			"tle_age":    tle.Age(from).Round(time.Minute).String(),
+			"fetched_at": tle.FetchedAt,
+			"stale":      tle.Stale,
+			"period":     tle.Period().Round(time.Second).String(),
+		}
+		if stdMag != 0 {
+			sat["standard_magnitude"] = stdMag
+		}
+		meta["satellite"] = sat
+		meta["observer"] = obs
+		meta["from"], meta["to"] = from.In(loc), to.In(loc)
+		meta["min_alt"] = minAlt
+		// A decay part way through ends the predictions there
+		meta["decayed"] = errors.Is(err, satellites.ErrDecayed)
+		c.JSON(http.StatusOK, gin.H{"data": passes, "count": len(passes), "meta": meta})
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/astro"
+	"solar-system-explorer/backend/clock"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/orbits"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ring angle series limits: the span covers a few of Saturn's 29.5 year
+// cycles, and the points keep the response chartable
+const (
+	maxRingSpan   = 100 * 365 * 24 * time.Hour
+	maxRingPoints = 5000
+)
+
+// RingAngle is the aspect of Saturn's rings at a time
+type RingAngle struct {
+	Time time.Time `json:"time"`
+	astro.SaturnRings
+	Magnitude float64 `json:"magnitude"` // rings included
+}
+
+// RingPlaneCrossing is when the Earth or the Sun passes through the plane
+// of Saturn's rings: seen from the Earth they turn edge-on and all but
+// disappear; seen from the Sun they go dark as the lit face changes
+type RingPlaneCrossing struct {
+	Time time.Time `json:"time"`
+	Body string    `json:"body"` // "Earth" or "Sun"
+}
+
+// ringAspect returns the aspect of Saturn's rings for its apparent
+// position pos from the Earth, at ecl on the ecliptic of date, with origin
+// from orbits.EarthOrigin
+func ringAspect(pos orbits.Position, ecl astro.Ecliptic, origin orbits.Origin, jd float64) (astro.SaturnRings, float64) {
+	helio := pos.From(orbits.Origin{X: -origin.X, Y: -origin.Y, Z: -origin.Z})
+	h := astro.Ecliptic{Lon: helio.EclipticLon, Lat: helio.EclipticLat}.FromJ2000(jd)
+	return astro.SaturnRingAspect(jd, h, helio.Distance, ecl, pos.Distance), helio.Distance
+}
+
+// saturnAt returns the rings' aspect and Saturn's magnitude at t
+func saturnAt(saturn, earth models.Planet, t time.Time) RingAngle {
+	jd := astro.JulianDay(t)
+	origin := orbits.EarthOrigin(earth, t)
+	pos, ecl := seenFromEarth(saturn, t, origin, jd)
+	rings, r := ringAspect(pos, ecl, origin, jd)
+	return RingAngle{Time: t, SaturnRings: rings, Magnitude: astro.SaturnMagnitude(r, pos.Distance, rings)}
+}
+
+// GetSaturnRingAngle charts how far Saturn's rings are opened towards the
+// Earth and the Sun between ?from= and ?to= (RFC 3339, default the year
+// from now) every ?step= days (default about 365 points), with the
+// apparent size of the rings and Saturn's magnitude. meta.crossings lists
+// the ring-plane crossings in the range, found day by day whatever the
+// step: every 13 to 16 years the rings turn edge-on, as in March 2025.
+func GetSaturnRingAngle(st *store.Store, clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			From time.Time `form:"from"`
+			To   time.Time `form:"to"`
+			Step int       `form:"step" binding:"omitempty,min=1,max=3650"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		if req.From.IsZero() {
+			req.From = clock.Now(c.Request.Context(), clk).UTC()
+		}
+		if req.To.IsZero() {
+			req.To = req.From.AddDate(1, 0, 0)
+		}
+		span := req.To.Sub(req.From)
+		switch {
+		case span <= 0:
+			invalid(c, FieldError{Field: "from", Message: "must be before to"})
+			return
+		case span > maxRingSpan:
+			invalid(c, FieldError{Field: "to", Message: "must be within 100 years of from"})
+			return
+		}
+		days := int(math.Ceil(span.Hours() / 24))
+		if req.Step == 0 {
+			req.Step = max(1, (days+364)/365)
+		}
+		if days/req.Step+1 > maxRingPoints {
+			invalid(c, FieldError{Field: "step", Message: "gives too many points, the most is 5000"})
+			return
+		}
+
+		bodies := solarSystemBodies(c.Request.Context(), st)
+		earth, hasEarth := findBody(bodies, "Earth")
+		saturn, hasSaturn := findBody(bodies, "Saturn")
+		if !hasEarth || !hasSaturn {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "The dataset has no Earth or Saturn to see the rings from"})
+			return
+		}
+
+		series := []RingAngle{}
+		for t := req.From; !t.After(req.To); t = t.AddDate(0, 0, req.Step) {
+			series = append(series, saturnAt(saturn, earth, t))
+		}
+		meta := gin.H{
+			"from":      req.From,
+			"to":        req.To,
+			"step":      req.Step,
+			"crossings": ringPlaneCrossings(saturn, earth, req.From, req.To),
+		}
+		c.JSON(http.StatusOK, gin.H{"data": series, "count": len(series), "meta": meta})
+	}
+}
+
+// ringPlaneCrossings finds when the Earth and the Sun cross Saturn's ring
+// plane between from and to, scanning a day at a time and bisecting each
+// sign change to the minute
+func ringPlaneCrossings(saturn, earth models.Planet, from, to time.Time) []RingPlaneCrossing {
+	tilts := func(t time.Time) [2]float64 {
+		a := saturnAt(saturn, earth, t)
+		return [2]float64{a.EarthTilt, a.SunTilt}
+	}
+	crossings := []RingPlaneCrossing{}
+	prevT, prev := from, tilts(from)
+	for prevT.Before(to) {
+		t := prevT.Add(24 * time.Hour)
+		if t.After(to) {
+			t = to
+		}
+		cur := tilts(t)
+		for i, body := range [2]string{"Earth", "Sun"} {
+			if (prev[i] < 0) == (cur[i] < 0) {
+				continue
+			}
+			lo, hi := prevT, t
+			for hi.Sub(lo) > time.Minute {
+				mid := lo.Add(hi.Sub(lo) / 2)
+				if (tilts(mid)[i] < 0) == (prev[i] < 0) {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			crossings = append(crossings, RingPlaneCrossing{Time: hi.Truncate(time.Minute), Body: body})
+		}
+		prevT, prev = t, cur
+	}
+	return crossings
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"solar-system-explorer/backend/scenes"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sharedScene is a scene with the path of its share link
+type sharedScene struct {
+	scenes.Scene
+	URL string `json:"url"`
+}
+
+// CreateScene stores a view state for sharing. The body is the state —
+// {"camera": {"position", "target", "fov"}, "time", "selected", "speed"} —
+// plus an optional "expires_in" duration up to maxTTL (default ttl).
+func CreateScene(sc *scenes.Scenes, ttl, maxTTL time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			scenes.State
+			ExpiresIn string `json:"expires_in"`
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 16<<10)
+		if !bindJSON(c, &req) {
+			return
+		}
+		expires := ttl
+		if req.ExpiresIn != "" {
+			d, err := time.ParseDuration(req.ExpiresIn)
+			if err != nil || d < time.Hour || d > maxTTL {
+				invalid(c, FieldError{Field: "expires_in", Message: "must be a duration between 1h and " + maxTTL.String()})
+				return
+			}
+			expires = d
+		}
+		scene, err := sc.Create(req.State, expires, time.Now())
+		switch {
+		case errors.Is(err, scenes.ErrInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, scenes.ErrFull):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Scene storage is full, try again later"})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.Header("Location", "/api/scenes/"+scene.ID)
+			c.JSON(http.StatusCreated, gin.H{"data": sharedScene{scene, "/s/" + scene.ID}})
+		}
+	}
+}
+
+// GetScene returns a stored scene; reading it through the API does not
+// count as a view
+func GetScene(sc *scenes.Scenes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scene, err := sc.Get(c.Param("id"), time.Now())
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Scene not found or expired"})
+			return
+		}
+		c.Header("Cache-Control", "no-cache")
+		c.JSON(http.StatusOK, gin.H{"data": sharedScene{scene, "/s/" + scene.ID}})
+	}
+}
+
+// OpenSharedScene serves the /s/:id share links: it counts the view and
+// redirects into the app, which loads the scene from ?scene=
+func OpenSharedScene(sc *scenes.Scenes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scene, err := sc.View(c.Param("id"), time.Now())
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Scene not found or expired"})
+			return
+		}
+		c.Header("Cache-Control", "no-store") // every open is a view
+		c.Redirect(http.StatusFound, "/?scene="+url.QueryEscape(scene.ID))
+	}
+}
@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"solar-system-explorer/backend/fulltext"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/translit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchResult is one hit from GetSearch
+type SearchResult struct {
+	Type   string  `json:"type"` // body, star, constellation; with full=true also moon, feature, tour
+	Name   string  `json:"name"`
+	NameSR string  `json:"name_sr"`
+	Field  string  `json:"field"` // which field matched
+	Score  float64 `json:"score"`
+	// Full-text results only
+	Body       string            `json:"body,omitempty"` // of a feature or moon
+	ID         string            `json:"id,omitempty"`   // of a tour
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// GetSearch finds bodies, stars and constellations matching ?q=. Matching
+// is script- and diacritic-insensitive, so Cyrillic and Latin Serbian as
+// well as English names all resolve. ?limit= caps the result count (max 50).
+// With ?full=true it searches the text instead (see searchFullText), and
+// with ?grouped=true it returns both kinds of match sectioned by entity
+// type, ?limit= per section (see searchGrouped).
+func GetSearch(st *store.Store, tours *store.Tours) gin.HandlerFunc {
+	index := &searchIndex{st: st, tours: tours}
+	return func(c *gin.Context) {
+		var req struct {
+			Q       string `form:"q" binding:"required,max=200"`
+			Limit   int    `form:"limit" binding:"omitempty,min=1,max=50"`
+			Full    bool   `form:"full"`
+			Grouped bool   `form:"grouped"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		q := translit.Fold(req.Q)
+		if len([]rune(q)) < 2 {
+			invalid(c, FieldError{Field: "q", Message: "must be at least 2 characters"})
+			return
+		}
+		limit := req.Limit
+		if limit == 0 {
+			limit = 10
+		}
+		if req.Grouped {
+			if req.Limit == 0 {
+				limit = 5
+			}
+			groups, total := searchGrouped(c.Request.Context(), st, index.get(), req.Q, q, limit)
+			c.JSON(http.StatusOK, gin.H{"data": groups, "count": total, "query": q})
+			return
+		}
+		if req.Full {
+			results := searchFullText(index.get(), req.Q, limit)
+			c.JSON(http.StatusOK, gin.H{"data": results, "count": len(results), "query": q})
+			return
+		}
+
+		var results []SearchResult
+		add := func(typ, name, nameSR string, extra map[string]string) {
+			best := SearchResult{Type: typ, Name: name, NameSR: nameSR}
+			// In order, so a tie reports the English name every time
+			for _, f := range [...]struct{ field, value string }{{"name", name}, {"name_sr", nameSR}} {
+				if s := matchScore(q, f.value); s > best.Score {
+					best.Score, best.Field = s, f.field
+				}
+			}
+			for field, value := range extra {
+				// Free-text fields only count when no name matched
+				if best.Score == 0 && strings.Contains(translit.Fold(value), q) {
+					best.Score, best.Field = 10, field
+				}
+			}
+			if best.Score > 0 {
+				results = append(results, best)
+			}
+		}
+
+		for _, b := range solarSystemBodies(c.Request.Context(), st) {
+			add("body", b.Name, b.NameSR, map[string]string{"description": b.Description})
+		}
+		for _, s := range models.GetBrightStars() {
+			add("star", s.Name, s.NameSR, nil)
+		}
+		for _, con := range models.GetConstellations() {
+			add("constellation", con.Name, con.NameSR, nil)
+		}
+
+		sort.SliceStable(results, func(i, j int) bool {
+			if results[i].Score != results[j].Score {
+				return results[i].Score > results[j].Score
+			}
+			return results[i].Name < results[j].Name
+		})
+		if len(results) > limit {
+			results = results[:limit]
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data":  results,
+			"count": len(results),
+			"query": q,
+		})
+	}
+}
+
+// matchScore ranks how well the folded query q matches a name
+func matchScore(q, name string) float64 {
+	n := translit.Fold(name)
+	switch {
+	case n == "":
+		return 0
+	case n == q:
+		return 100
+	case strings.HasPrefix(n, q):
+		return 80
+	case strings.Contains(" "+n, " "+q):
+		return 60 // start of a later word, e.g. "kasiopeje" in "gama kasiopeje"
+	case strings.Contains(n, q):
+		return 40
+	}
+	return 0
+}
+
+// searchIndex is the full-text index of the dataset and the tours, rebuilt
+// on the first search after either changes
+type searchIndex struct {
+	st    *store.Store
+	tours *store.Tours
+
+	mu      sync.Mutex
+	version string
+	index   *fulltext.Index
+}
+
+func (s *searchIndex) get() *fulltext.Index {
+	var tours []models.Tour
+	if s.tours != nil {
+		tours = s.tours.List()
+	}
+	snap := s.st.Snapshot()
+	var v strings.Builder
+	v.WriteString(snap.ETag)
+	for _, t := range tours {
+		fmt.Fprintf(&v, "|%s@%d", t.ID, t.UpdatedAt.UnixNano())
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index == nil || s.version != v.String() {
+		s.index, s.version = fulltext.Build(searchDocs(snap.Bodies, tours)), v.String()
+	}
+	return s.index
+}
+
+// searchDocs lists what full-text search covers: names and descriptions
+// of the bodies and moons in Serbian and English, the bodies' sourced
+// facts, surface feature names and the tours' titles and narration
+func searchDocs(bodies []models.Planet, tours []models.Tour) []fulltext.Doc {
+	names := func(name, nameSR string) []fulltext.Field {
+		return []fulltext.Field{
+			{Name: "name", Lang: fulltext.LangEN, Text: name, Boost: 3},
+			{Name: "name_sr", Lang: fulltext.LangSR, Text: nameSR, Boost: 3},
+		}
+	}
+	descriptions := func(sr string, tr map[string]models.Translation) []fulltext.Field {
+		return []fulltext.Field{
+			{Name: "description", Lang: fulltext.LangSR, Text: sr},
+			{Name: "description_en", Lang: fulltext.LangEN, Text: tr["en"].Description},
+		}
+	}
+	var docs []fulltext.Doc
+	for _, b := range bodies {
+		fields := append(names(b.Name, b.NameSR), descriptions(b.Description, b.Translations)...)
+		keys := make([]string, 0, len(b.Supplementary))
+		for k := range b.Supplementary {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var facts []string
+		for _, k := range keys {
+			if s, ok := b.Supplementary[k].Value.(string); ok {
+				facts = append(facts, strings.ReplaceAll(k, "_", " ")+": "+s)
+			}
+		}
+		if len(facts) > 0 {
+			fields = append(fields, fulltext.Field{Name: "facts", Lang: fulltext.LangEN, Text: strings.Join(facts, "; "), Boost: 0.5})
+		}
+		docs = append(docs, fulltext.Doc{Ref: SearchResult{Type: "body", Name: b.Name, NameSR: b.NameSR}, Fields: fields})
+	}
+	for _, m := range models.GetMoons() {
+		docs = append(docs, fulltext.Doc{
+			Ref:    SearchResult{Type: "moon", Name: m.Name, NameSR: m.NameSR, Body: m.Parent},
+			Fields: append(names(m.Name, m.NameSR), descriptions(m.Description, m.Translations)...),
+		})
+	}
+	for _, f := range models.GetSurfaceFeatures() {
+		docs = append(docs, fulltext.Doc{
+			Ref:    SearchResult{Type: "feature", Name: f.Name, NameSR: f.NameSR, Body: f.Body},
+			Fields: names(f.Name, f.NameSR),
+		})
+	}
+	for _, t := range tours {
+		narration := map[string][]string{}
+		for _, step := range t.Steps {
+			for lang, text := range step.Narration {
+				narration[lang] = append(narration[lang], text)
+			}
+		}
+		docs = append(docs, fulltext.Doc{
+			Ref: SearchResult{Type: "tour", Name: t.Title["en"], NameSR: t.Title["sr"], ID: t.ID},
+			Fields: []fulltext.Field{
+				{Name: "title", Lang: fulltext.LangEN, Text: t.Title["en"], Boost: 2},
+				{Name: "title_sr", Lang: fulltext.LangSR, Text: t.Title["sr"], Boost: 2},
+				{Name: "narration", Lang: fulltext.LangSR, Text: strings.Join(narration["sr"], " ")},
+				{Name: "narration_en", Lang: fulltext.LangEN, Text: strings.Join(narration["en"], " ")},
+			},
+		})
+	}
+	return docs
+}
+
+// fieldOrder decides which matched field a full-text result reports
+var fieldOrder = []string{"name", "name_sr", "title", "title_sr", "description", "description_en", "narration", "narration_en", "facts"}
+
+// searchFullText runs q against the index and returns the hits as search
+// results, with highlighted snippets of the fields that matched
+func searchFullText(ix *fulltext.Index, q string, limit int) []SearchResult {
+	hits := ix.Search(q, limit)
+	out := make([]SearchResult, 0, len(hits))
+	for _, h := range hits {
+		r := h.Ref.(SearchResult)
+		r.Score, r.Highlights = h.Score, h.Highlights
+		for _, f := range fieldOrder {
+			if _, ok := h.Highlights[f]; ok {
+				r.Field = f
+				break
+			}
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// SearchGroup is one section of grouped search results
+type SearchGroup struct {
+	Type    string         `json:"type"`  // planets, moons, features, tours, stars, constellations
+	Count   int            `json:"count"` // all matches in the section, before the limit
+	Results []SearchResult `json:"results"`
+}
+
+// searchGroups maps result types to their section, in the order sections
+// with equally good best results are listed
+var searchGroups = []struct{ typ, group string }{
+	{"body", "planets"},
+	{"moon", "moons"},
+	{"feature", "features"},
+	{"tour", "tours"},
+	{"star", "stars"},
+	{"constellation", "constellations"},
+}
+
+// searchGrouped matches names, tolerating typos, and text, and returns
+// the results sectioned by type with the total match count. Scores are on
+// one scale from 0 to 1 across sections: a name match counts most (1 for
+// the exact name, down to 0.2 for two typos), a text match at most 0.3
+// relative to the best text hit, and an entity matching both gets a
+// little of the text score on top of its name score.
+func searchGrouped(ctx context.Context, st *store.Store, ix *fulltext.Index, raw, q string, limit int) ([]SearchGroup, int) {
+	found := make(map[string]*SearchResult)
+	key := func(typ, name string) string { return typ + "\x00" + name }
+	byName := func(r SearchResult) {
+		for _, f := range [...]struct{ field, value string }{{"name", r.Name}, {"name_sr", r.NameSR}} {
+			s := matchScore(q, f.value) / 100
+			if s == 0 {
+				s = fuzzyScore(q, f.value)
+			}
+			if s > r.Score {
+				r.Score, r.Field = s, f.field
+			}
+		}
+		if r.Score > 0 {
+			found[key(r.Type, r.Name)] = &r
+		}
+	}
+	for _, b := range solarSystemBodies(ctx, st) {
+		byName(SearchResult{Type: "body", Name: b.Name, NameSR: b.NameSR})
+	}
+	for _, m := range models.GetMoons() {
+		byName(SearchResult{Type: "moon", Name: m.Name, NameSR: m.NameSR, Body: m.Parent})
+	}
+	for _, f := range models.GetSurfaceFeatures() {
+		byName(SearchResult{Type: "feature", Name: f.Name, NameSR: f.NameSR, Body: f.Body})
+	}
+	for _, s := range models.GetBrightStars() {
+		byName(SearchResult{Type: "star", Name: s.Name, NameSR: s.NameSR})
+	}
+	for _, con := range models.GetConstellations() {
+		byName(SearchResult{Type: "constellation", Name: con.Name, NameSR: con.NameSR})
+	}
+
+	text := searchFullText(ix, raw, 0)
+	if len(text) > 0 {
+		top := text[0].Score
+		for _, r := range text {
+			s := 0.3 * r.Score / top
+			if prev, ok := found[key(r.Type, r.Name)]; ok {
+				prev.Score = min(1, prev.Score+s/3)
+				prev.Highlights = r.Highlights
+				continue
+			}
+			r.Score = s
+			found[key(r.Type, r.Name)] = &r
+		}
+	}
+
+	sections := make(map[string][]SearchResult)
+	for _, r := range found {
+		r.Score = math.Round(r.Score*1000) / 1000
+		sections[r.Type] = append(sections[r.Type], *r)
+	}
+	groups := []SearchGroup{}
+	total := 0
+	for _, g := range searchGroups {
+		list := sections[g.typ]
+		if len(list) == 0 {
+			continue
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Score != list[j].Score {
+				return list[i].Score > list[j].Score
+			}
+			return list[i].Name < list[j].Name
+		})
+		total += len(list)
+		groups = append(groups, SearchGroup{Type: g.group, Count: len(list), Results: list[:min(limit, len(list))]})
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Results[0].Score > groups[j].Results[0].Score })
+	return groups, total
+}
+
+// fuzzyScore ranks a name the folded query q matches only with typos:
+// the whole name or one of its words within fulltext.MaxEdits of q, or,
+// while the query is being typed, the start of the name
+func fuzzyScore(q, name string) float64 {
+	n := translit.Fold(name)
+	edits := fulltext.MaxEdits(len([]rune(q)))
+	if n == "" || edits == 0 {
+		return 0
+	}
+	best := edits + 1
+	for _, w := range append(strings.Fields(n), n) {
+		best = min(best, fulltext.Distance(q, w))
+	}
+	if best <= edits {
+		return 0.5 - 0.15*float64(best)
+	}
+	if r := []rune(n); len(r) > len([]rune(q)) {
+		if d := fulltext.Distance(q, string(r[:len([]rune(q))])); d <= edits {
+			return 0.45 - 0.15*float64(d)
+		}
+	}
+	return 0
+}
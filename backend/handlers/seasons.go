@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+
+	"solar-system-explorer/backend/astro"
+	"solar-system-explorer/backend/clock"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPlanetSeasons returns equinox and solstice dates for ?year= (default:
+// current year), in ?tz= (default UTC). Earth uses Meeus' method; Mars
+// uses the Mars24 Ls model.
+func GetPlanetSeasons(st *store.Store, clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		planet, ok := findPlanet(c.Request.Context(), st, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+
+		var req struct {
+			Year int `form:"year" binding:"omitempty,min=1000,max=3000"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		loc, ok := queryZone(c)
+		if !ok {
+			return
+		}
+		year := req.Year
+		if year == 0 {
+			year = clock.Now(c.Request.Context(), clk).UTC().Year()
+		}
+
+		var (
+			events []astro.SeasonEvent
+			method string
+		)
+		switch planet.Name {
+		case "Earth":
+			events, method = astro.EarthSeasons(year), "meeus"
+		case "Mars":
+			events, method = astro.MarsSeasons(year), "mars24"
+		default:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Seasons are only computed for Earth and Mars"})
+			return
+		}
+		for i := range events {
+			events[i].Time = events[i].Time.In(loc)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"body":       planet.Name,
+				"year":       year,
+				"axial_tilt": planet.AxialTilt,
+				"method":     method,
+				"events":     events,
+			},
+			"meta": gin.H{"timezone": loc.String()},
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"solar-system-explorer/backend/astro"
+	"solar-system-explorer/backend/clock"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/orbits"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SkyBody is a body as seen in the sky from the Earth
+type SkyBody struct {
+	Name       string            `json:"name"`
+	NameSR     string            `json:"name_sr"`
+	Distance   float64           `json:"distance"` // AU from the Earth
+	Ecliptic   astro.Ecliptic    `json:"ecliptic"` // of date
+	Equatorial astro.Equatorial  `json:"equatorial"`
+	Horizontal *astro.Horizontal `json:"horizontal,omitempty"`
+	// Magnitude is the visual magnitude, null for bodies without a model
+	Magnitude *float64 `json:"magnitude"`
+	// AngularDiameter is in arcseconds
+	AngularDiameter float64 `json:"angular_diameter"`
+	// PhaseAngle is the Sun–body–Earth angle in degrees; Illumination is
+	// the lit share of the disc in percent, as a crescent Venus shows
+	PhaseAngle   float64 `json:"phase_angle"`
+	Illumination float64 `json:"illumination"`
+	// Rings is the aspect of Saturn's rings, which its magnitude includes
+	Rings *astro.SaturnRings `json:"rings,omitempty"`
+}
+
+// seenFromEarth returns p's apparent geocentric position at t, with
+// origin from orbits.EarthOrigin, and its direction on the ecliptic of date
+func seenFromEarth(p models.Planet, t time.Time, origin orbits.Origin, jd float64) (orbits.Position, astro.Ecliptic) {
+	pos := orbits.Apparent(p, t, origin)
+	return pos, astro.Ecliptic{Lon: pos.EclipticLon, Lat: pos.EclipticLat}.FromJ2000(jd)
+}
+
+// queryObserver reads the optional ?observer_lat= and ?observer_lon=,
+// nil without either
+func queryObserver(c *gin.Context) (*astro.Observer, bool) {
+	if c.Query("observer_lat") == "" && c.Query("observer_lon") == "" {
+		return nil, true
+	}
+	var obs astro.Observer
+	var ok bool
+	if obs.Lat, ok = queryFloat(c, "observer_lat", 0, -90, 90); !ok {
+		return nil, false
+	}
+	if obs.Lon, ok = queryFloat(c, "observer_lon", 0, -180, 180); !ok {
+		return nil, false
+	}
+	return &obs, true
+}
+
+// GetSky lists the bodies as the sky view shows them from the Earth at
+// ?time= (default now): apparent positions on the ecliptic and equator of
+// date, visual magnitude and angular diameter, so the frontend can scale
+// sprites and rank what is brightest tonight, and the phase from the
+// Sun–body–Earth geometry. With ?observer_lat= and
+// ?observer_lon= each also gets its altitude and azimuth. ?sort=magnitude
+// lists the brightest first, bodies without a magnitude last.
+func GetSky(st *store.Store, clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Sort string `form:"sort" binding:"omitempty,oneof=magnitude"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		t, ok := queryTime(c, clk)
+		if !ok {
+			return
+		}
+		obs, ok := queryObserver(c)
+		if !ok {
+			return
+		}
+
+		bodies := solarSystemBodies(c.Request.Context(), st)
+		earth, ok := findBody(bodies, "Earth")
+		if !ok {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "The dataset has no Earth to see the sky from"})
+			return
+		}
+		origin := orbits.EarthOrigin(earth, t)
+		sunDist := math.Sqrt(origin.X*origin.X + origin.Y*origin.Y + origin.Z*origin.Z)
+		jd := astro.JulianDay(t)
+		eps := astro.MeanObliquity(jd)
+
+		sky := []SkyBody{}
+		for _, p := range bodies {
+			if p.Name == earth.Name {
+				continue
+			}
+			pos, ecl := seenFromEarth(p, t, origin, jd)
+			// From the Sun, at the instant the light left
+			hx, hy, hz := pos.X+origin.X, pos.Y+origin.Y, pos.Z+origin.Z
+			r := math.Sqrt(hx*hx + hy*hy + hz*hz)
+
+			phase := 0.0
+			if !p.IsStar {
+				phase = astro.PhaseAngle(r, pos.Distance, sunDist)
+			}
+			b := SkyBody{
+				Name:            p.Name,
+				NameSR:          p.NameSR,
+				Distance:        pos.Distance,
+				Ecliptic:        ecl,
+				Equatorial:      ecl.ToEquatorial(eps),
+				AngularDiameter: astro.AngularDiameter(p.Radius, pos.Distance),
+				PhaseAngle:      phase,
+				Illumination:    astro.Illuminated(phase) * 100,
+			}
+			if m, ok := astro.Magnitude(p.Name, r, pos.Distance, phase); ok {
+				b.Magnitude = &m
+			}
+			if p.Name == "Saturn" {
+				rings, _ := ringAspect(pos, ecl, origin, jd)
+				m := astro.SaturnMagnitude(r, pos.Distance, rings)
+				b.Magnitude, b.Rings = &m, &rings
+			}
+			if obs != nil {
+				hz := b.Equatorial.ToHorizontal(jd, *obs)
+				b.Horizontal = &hz
+			}
+			sky = append(sky, b)
+		}
+		if req.Sort == "magnitude" {
+			sort.SliceStable(sky, func(i, j int) bool {
+				a, b := sky[i].Magnitude, sky[j].Magnitude
+				return a != nil && (b == nil || *a < *b)
+			})
+		}
+
+		meta := gin.H{"time": t}
+		if obs != nil {
+			meta["observer"] = obs
+		}
+		c.JSON(http.StatusOK, gin.H{"data": sky, "count": len(sky), "meta": meta})
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"solar-system-explorer/backend/swpc"
+
+	"github.com/gin-gonic/gin"
+)
+
+// auroraLikely is the OVATION probability, percent, from which aurora
+// overhead is worth going out for
+const auroraLikely = 10
+
+// SpaceWeather is the /api/space-weather response: the SWPC report and
+// what it means for the banner
+type SpaceWeather struct {
+	swpc.Report
+	Outlook Outlook `json:"outlook"`
+}
+
+// Outlook sums the report up
+type Outlook struct {
+	// Level is quiet (Kp under 4), active (4), storm (5–6, G1–G2) or
+	// severe (7 and up, G3–G5), by the highest Kp now or in the next 12
+	// hours
+	Level string  `json:"level"`
+	MaxKp float64 `json:"max_kp"`
+	// With ?lat= and ?lon=: the OVATION probability of visible aurora
+	// overhead there and whether that's worth a look
+	Probability  *int  `json:"probability,omitempty"`
+	AuroraLikely *bool `json:"aurora_likely,omitempty"`
+}
+
+// GetSpaceWeather reports the space weather from NOAA SWPC: the Kp index
+// with its NOAA G scale and 3-day forecast, the latest solar wind (speed,
+// density, Bz) and the OVATION aurora forecast's hemisphere maxima, plus
+// an outlook for an "aurora tonight?" banner. ?lat= and ?lon= add the
+// aurora probability at that place. Reports are cached (see
+// SPACE_WEATHER_CACHE_TTL); a section whose feed failed is null with the
+// reason in errors.
+func GetSpaceWeather(client *swpc.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Lat *float64 `form:"lat" binding:"omitempty,min=-90,max=90"`
+			Lon *float64 `form:"lon" binding:"omitempty,min=-180,max=180"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		if (req.Lat == nil) != (req.Lon == nil) {
+			invalid(c, FieldError{Field: "lat", Message: "lat and lon go together"})
+			return
+		}
+
+		r, err := client.Report(c.Request.Context())
+		if err != nil {
+			log.Printf("space weather: %v", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Space weather is unavailable"})
+			return
+		}
+		sw := SpaceWeather{Report: r, Outlook: Outlook{Level: "quiet"}}
+		if r.Kp != nil {
+			sw.Outlook.MaxKp = r.Kp.Value
+			for _, p := range r.Kp.Forecast {
+				if p.Time.Sub(r.FetchedAt).Hours() < 12 {
+					sw.Outlook.MaxKp = max(sw.Outlook.MaxKp, p.Value)
+				}
+			}
+			switch kp := sw.Outlook.MaxKp; {
+			case kp >= 6.5:
+				sw.Outlook.Level = "severe"
+			case kp >= 4.5:
+				sw.Outlook.Level = "storm"
+			case kp >= 3.5:
+				sw.Outlook.Level = "active"
+			}
+		}
+		if req.Lat != nil && r.Aurora != nil {
+			p := r.Aurora.At(*req.Lat, *req.Lon)
+			likely := p >= auroraLikely
+			sw.Outlook.Probability, sw.Outlook.AuroraLikely = &p, &likely
+		}
+
+		meta := gin.H{"source": "NOAA Space Weather Prediction Center"}
+		if req.Lat != nil {
+			meta["location"] = gin.H{"lat": *req.Lat, "lon": *req.Lon}
+		}
+		c.JSON(http.StatusOK, gin.H{"data": sw, "meta": meta})
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+
+	"solar-system-explorer/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetStars returns the bright-star catalogue, optionally limited by
+// ?max_mag= and rendered as GeoJSON with ?format=geojson
+func GetStars(c *gin.Context) {
+	var req struct {
+		MaxMag *float64 `form:"max_mag" binding:"omitempty,min=-30,max=30"`
+		Format string   `form:"format" binding:"omitempty,oneof=json geojson"`
+	}
+	if !bindQuery(c, &req) {
+		return
+	}
+	stars := models.GetBrightStars()
+
+	if req.MaxMag != nil {
+		filtered := stars[:0]
+		for _, s := range stars {
+			if s.Magnitude <= *req.MaxMag {
+				filtered = append(filtered, s)
+			}
+		}
+		stars = filtered
+	}
+
+	if req.Format == "geojson" {
+		features := make([]gin.H, 0, len(stars))
+		for _, s := range stars {
+			features = append(features, gin.H{
+				"type": "Feature",
+				"id":   s.HIP,
+				"geometry": gin.H{
+					"type":        "Point",
+					"coordinates": []float64{raToLon(s.RA), s.Dec},
+				},
+				"properties": s,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"type": "FeatureCollection", "features": features})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  stars,
+		"count": len(stars),
+	})
+}
+
+// GetConstellations returns constellation stick figures. Plain JSON lists
+// Hipparcos numbers; ?format=geojson resolves them to MultiLineStrings.
+func GetConstellations(c *gin.Context) {
+	var req struct {
+		Format string `form:"format" binding:"omitempty,oneof=json geojson"`
+	}
+	if !bindQuery(c, &req) {
+		return
+	}
+	constellations := models.GetConstellations()
+
+	if req.Format == "geojson" {
+		byHIP := make(map[int]models.Star)
+		for _, s := range models.GetBrightStars() {
+			byHIP[s.HIP] = s
+		}
+
+		features := make([]gin.H, 0, len(constellations))
+		for _, con := range constellations {
+			lines := make([][][]float64, 0, len(con.Lines))
+			for _, line := range con.Lines {
+				coords := make([][]float64, 0, len(line))
+				for _, hip := range line {
+					if s, ok := byHIP[hip]; ok {
+						coords = append(coords, []float64{raToLon(s.RA), s.Dec})
+					}
+				}
+				lines = append(lines, coords)
+			}
+			features = append(features, gin.H{
+				"type": "Feature",
+				"id":   con.ID,
+				"geometry": gin.H{
+					"type":        "MultiLineString",
+					"coordinates": lines,
+				},
+				"properties": gin.H{"name": con.Name, "name_sr": con.NameSR},
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"type": "FeatureCollection", "features": features})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  constellations,
+		"count": len(constellations),
+	})
+}
+
+// raToLon maps right ascension (0–360°) onto GeoJSON longitude (−180–180°)
+func raToLon(ra float64) float64 {
+	if ra > 180 {
+		return ra - 360
+	}
+	return ra
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/analytics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// activeWindows are the spans, in days, active visitors are reported over
+var activeWindows = []int{1, 7, 30}
+
+// GetStats is the admin dashboard overview over the last ?days= days
+// (default 30, max 365): API requests per route, the most viewed bodies,
+// quiz pass rates and active visitors over the last 1, 7 and 30 days,
+// all from the analytics store. ?top= caps each ranking (default 10, max
+// 100).
+func GetStats(col *analytics.Collector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Days int `form:"days" binding:"omitempty,min=1,max=365"`
+			Top  int `form:"top" binding:"omitempty,min=1,max=100"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		if req.Days == 0 {
+			req.Days = 30
+		}
+		if req.Top == 0 {
+			req.Top = 10
+		}
+
+		// Windows run in whole UTC days, the period from now back
+		now := time.Now().UTC()
+		start := func(days int) time.Time { return now.Truncate(24*time.Hour).AddDate(0, 0, 1-days) }
+		from := start(req.Days)
+		events, err := col.Query(c.Request.Context(), start(max(req.Days, activeWindows[len(activeWindows)-1])), now.Add(time.Second))
+		if err != nil {
+			log.Printf("analytics: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Events could not be read"})
+			return
+		}
+		period := events
+		for len(period) > 0 && period[0].At.Before(from) {
+			period = period[1:]
+		}
+		s := analytics.Summarize(period, from, now, req.Top)
+		all := analytics.Summarize(events, from, now, 0)
+
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"from":          from,
+				"to":            now,
+				"requests":      s.Requests,
+				"request_total": s.RequestTotal,
+				"top_bodies":    s.Bodies,
+				"quizzes":       s.Quizzes,
+				"active":        analytics.ActiveOver(all.Days, now, activeWindows...),
+				"daily":         s.Days,
+			},
+			"meta": gin.H{"retention": col.Retention().String()},
+		})
+	}
+}
+
+// CountRequests counts every request to a known route for the stats
+// dashboard, by method and route pattern
+func CountRequests(col *analytics.Collector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if route := c.FullPath(); route != "" {
+			col.CountRequest(c.Request.Method + " " + route)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/broker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// keepAlive is how often an idle stream gets a comment, so proxies don't
+// close it
+const keepAlive = 25 * time.Second
+
+// StreamEvents streams published events as server-sent events, named by
+// topic with the JSON payload as data. ?topics= (comma-separated) limits
+// the stream to those topics. Streams end when done is cancelled, so
+// shutdown doesn't wait for clients to hang up.
+func StreamEvents(done context.Context, b broker.Broker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var topics []string
+		for _, t := range strings.Split(c.Query("topics"), ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				topics = append(topics, t)
+			}
+		}
+		sub := b.Subscribe(topics...)
+		defer sub.Close()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no") // nginx would otherwise buffer the stream
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+
+		ticker := time.NewTicker(keepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case <-done.Done():
+				return
+			case msg, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				c.SSEvent(msg.Topic, string(msg.Data))
+			case <-ticker.C:
+				io.WriteString(c.Writer, ": keep-alive\n\n")
+			}
+			c.Writer.Flush()
+		}
+	}
+}
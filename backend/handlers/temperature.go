@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+
+	"solar-system-explorer/backend/physics"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// zeroCelsius converts kelvin to degrees Celsius
+const zeroCelsius = 273.15
+
+// Temperature compares the radiative-balance model with measurements
+type Temperature struct {
+	Body             string  `json:"body"`
+	Albedo           float64 `json:"albedo"`
+	GreenhouseFactor float64 `json:"greenhouse_factor"`
+	// Equilibrium is the bare black-body temperature at aphelion (min),
+	// the mean distance and perihelion (max); Estimated scales it by the
+	// greenhouse factor
+	Equilibrium TempSpread  `json:"equilibrium"`
+	Estimated   TempSpread  `json:"estimated_surface"`
+	Measured    *TempSpread `json:"measured,omitempty"`
+}
+
+// TempSpread is a min/mean/max temperature in kelvin and degrees Celsius.
+// Min and max are nil where unknown.
+type TempSpread struct {
+	MinK  *float64 `json:"min_k"`
+	MeanK float64  `json:"mean_k"`
+	MaxK  *float64 `json:"max_k"`
+	MinC  *float64 `json:"min_c"`
+	MeanC float64  `json:"mean_c"`
+	MaxC  *float64 `json:"max_c"`
+}
+
+// GetPlanetTemperature models a body's temperature from its Bond albedo,
+// distance from the Sun and greenhouse factor, next to the measured range
+func GetPlanetTemperature(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := findPlanet(c.Request.Context(), st, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+		if p.IsStar || p.DistanceFromSun == 0 || p.Albedo == 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No temperature model for " + p.Name})
+			return
+		}
+		factor := p.GreenhouseFactor
+		if factor == 0 {
+			factor = 1
+		}
+		at := func(au float64) float64 {
+			return physics.EquilibriumTemperature(physics.SolarConstant/(au*au), p.Albedo)
+		}
+		aphelion := at(p.DistanceFromSun * (1 + p.Eccentricity))
+		mean := at(p.DistanceFromSun)
+		perihelion := at(p.DistanceFromSun * (1 - p.Eccentricity))
+
+		t := Temperature{
+			Body:             p.Name,
+			Albedo:           p.Albedo,
+			GreenhouseFactor: factor,
+			Equilibrium:      tempSpread(aphelion, mean, perihelion),
+			Estimated:        tempSpread(aphelion*factor, mean*factor, perihelion*factor),
+		}
+		if m := p.SurfaceTemperature; m != nil {
+			s := tempSpread(m.Min, m.Mean, m.Max)
+			t.Measured = &s
+		}
+		c.JSON(http.StatusOK, gin.H{"data": t})
+	}
+}
+
+// tempSpread fills a TempSpread from kelvin; zero min or max is unknown
+func tempSpread(min, mean, max float64) TempSpread {
+	s := TempSpread{MeanK: round(mean, 0), MeanC: round(mean-zeroCelsius, 0)}
+	s.MinK, s.MinC = kelvinCelsius(min)
+	s.MaxK, s.MaxC = kelvinCelsius(max)
+	return s
+}
+
+func kelvinCelsius(k float64) (*float64, *float64) {
+	if k == 0 {
+		return nil, nil
+	}
+	kr, cr := round(k, 0), round(k-zeroCelsius, 0)
+	return &kr, &cr
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/astro"
+	"solar-system-explorer/backend/clock"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Julian Days of 0001-01-01 and 10000-01-01, the calendar range
+// conversions are done over
+const (
+	minConvertJD = 1721425.5
+	maxConvertJD = 5373484.5
+)
+
+// calendarLayout writes TT and TDB calendar times, which aren't UTC and so
+// carry no zone
+const calendarLayout = "2006-01-02T15:04:05.000"
+
+// ScaleTimes is an instant in one time scale
+type ScaleTimes struct {
+	JulianDay float64 `json:"jd"`
+	MJD       float64 `json:"mjd"`
+	Calendar  string  `json:"calendar"` // proleptic Gregorian
+}
+
+func scaleTimes(jd float64, layout string) ScaleTimes {
+	return ScaleTimes{JulianDay: jd, MJD: jd - astro.MJD0, Calendar: astro.CalendarTime(jd).Format(layout)}
+}
+
+// GetTimeConvert converts an instant between Julian Date, Modified Julian
+// Date and calendar time in UTC, Terrestrial Time and Barycentric Dynamical
+// Time, as the ephemerides use them. The instant is one of ?time= (RFC
+// 3339), ?jd= or ?mjd=, in ?scale= (utc, the default, tt or tdb); without
+// any, now. ΔT = TT − UT comes from the leap seconds where UTC has them and
+// is approximated elsewhere; meta.delta_t_exact tells which.
+func GetTimeConvert(clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Time  time.Time `form:"time"`
+			Scale string    `form:"scale" binding:"omitempty,oneof=utc tt tdb"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		given := 0
+		for _, p := range []string{"time", "jd", "mjd"} {
+			if c.Query(p) != "" {
+				given++
+			}
+		}
+		if given > 1 {
+			invalid(c, FieldError{Field: "query", Message: "must give at most one of time, jd or mjd"})
+			return
+		}
+		if req.Scale == "" {
+			req.Scale = astro.ScaleUTC
+		}
+
+		var jd float64
+		switch {
+		case c.Query("jd") != "":
+			v, ok := queryFloat(c, "jd", 0, minConvertJD, maxConvertJD)
+			if !ok {
+				return
+			}
+			jd = v
+		case c.Query("mjd") != "":
+			v, ok := queryFloat(c, "mjd", 0, minConvertJD-astro.MJD0, maxConvertJD-astro.MJD0)
+			if !ok {
+				return
+			}
+			jd = v + astro.MJD0
+		default:
+			t := req.Time
+			if t.IsZero() {
+				t = clock.Now(c.Request.Context(), clk)
+			}
+			// A calendar time in TT or TDB is read as written, ignoring its offset
+			if req.Scale != astro.ScaleUTC {
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+			}
+			if jd = astro.JulianDay(t); jd < minConvertJD || jd >= maxConvertJD {
+				invalid(c, FieldError{Field: "time", Message: "must be between years 1 and 9999"})
+				return
+			}
+		}
+
+		ts := astro.InScales(jd, req.Scale)
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"utc":                 scaleTimes(ts.UTC, time.RFC3339Nano),
+				"tt":                  scaleTimes(ts.TT, calendarLayout),
+				"tdb":                 scaleTimes(ts.TDB, calendarLayout),
+				"julian_centuries_tt": astro.JulianCenturies(ts.TT),
+				"delta_t":             ts.DeltaT,
+				"tdb_minus_tt":        astro.TDBMinusTT(ts.TT),
+			},
+			"meta": gin.H{"scale": req.Scale, "delta_t_exact": ts.Exact},
+		})
+	}
+}
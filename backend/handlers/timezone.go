@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// zones caches the time zones requests have named, since loading one
+// parses its tzdata file
+var zones sync.Map // name → *time.Location
+
+// queryZone resolves ?tz=, an IANA time zone such as Europe/Belgrade, in
+// which the endpoint returns its times, offset and daylight saving time
+// included; default UTC. An unknown zone answers 422 and returns false.
+func queryZone(c *gin.Context) (*time.Location, bool) {
+	name := c.Query("tz")
+	if name == "" || name == "UTC" {
+		return time.UTC, true
+	}
+	if loc, ok := zones.Load(name); ok {
+		return loc.(*time.Location), true
+	}
+	// "Local" would be the server's zone, which means nothing to a client
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" || len(name) > 64 || strings.HasPrefix(name, "/") {
+		invalid(c, FieldError{Field: "tz", Message: "must be an IANA time zone such as Europe/Belgrade"})
+		return nil, false
+	}
+	zones.Store(name, loc)
+	return loc, true
+}
+
+// zoneMeta describes loc at t for a response's meta
+func zoneMeta(loc *time.Location, t time.Time) gin.H {
+	abbr, offset := t.In(loc).Zone()
+	return gin.H{"timezone": loc.String(), "abbreviation": abbr, "utc_offset": offset}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/i18n"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/translit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tourSummary is a tour in the list, without its steps
+type tourSummary struct {
+	ID       string  `json:"id"`
+	Title    string  `json:"title"`
+	Steps    int     `json:"steps"`
+	Duration float64 `json:"duration"` // seconds, all steps
+	Locale   string  `json:"locale"`
+}
+
+// localizedTour is a tour with its text resolved for the request's locale
+// chain
+type localizedTour struct {
+	ID     string          `json:"id"`
+	Title  string          `json:"title"`
+	Steps  []localizedStep `json:"steps"`
+	Locale string          `json:"locale"`
+}
+
+type localizedStep struct {
+	Body        string             `json:"body"`
+	DisplayName string             `json:"display_name"`
+	Camera      *models.CameraHint `json:"camera,omitempty"`
+	Narration   string             `json:"narration"`
+	Duration    float64            `json:"duration"`
+}
+
+// GetTours lists the guided tours, titles localized like GetPlanets
+func GetTours(tours *store.Tours) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requested, chain := requestLocales(c)
+		list := tours.List()
+		out := make([]tourSummary, len(list))
+		for i, t := range list {
+			title, locale := localText(t.Title, chain)
+			out[i] = tourSummary{ID: t.ID, Title: title, Steps: len(t.Steps), Locale: locale}
+			for _, s := range t.Steps {
+				out[i].Duration += s.Duration
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"data":  out,
+			"count": len(out),
+			"meta":  gin.H{"requested": requested, "fallbacks": chain},
+		})
+	}
+}
+
+// GetTour returns one tour with its steps, narration localized per step
+// and body names in the same locale
+func GetTour(st *store.Store, tours *store.Tours) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t, ok := tours.Get(c.Param("id"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tour not found"})
+			return
+		}
+		requested, chain := requestLocales(c)
+		out := localizedTour{ID: t.ID, Steps: make([]localizedStep, len(t.Steps))}
+		out.Title, out.Locale = localText(t.Title, chain)
+		for i, s := range t.Steps {
+			narration, _ := localText(s.Narration, chain)
+			out.Steps[i] = localizedStep{
+				Body:        s.Body,
+				DisplayName: s.Body,
+				Camera:      s.Camera,
+				Narration:   narration,
+				Duration:    s.Duration,
+			}
+			if p, ok := findPlanet(c.Request.Context(), st, s.Body); ok {
+				out.Steps[i].DisplayName = localize(p, chain).DisplayName
+			} else if m, ok := findMoon(s.Body); ok {
+				out.Steps[i].DisplayName, _ = localText(map[string]string{baseLocale: m.NameSR, "en": m.Name}, chain)
+			}
+		}
+		c.Header("Content-Language", out.Locale)
+		c.JSON(http.StatusOK, gin.H{
+			"data": out,
+			"meta": gin.H{"locale": out.Locale, "requested": requested, "fallbacks": chain},
+		})
+	}
+}
+
+// PutTour creates or replaces the tour :id. The body is the tour without
+// its id: {"title": {"sr": …, "en": …}, "steps": [{"body", "camera",
+// "narration", "duration"}]}. Step bodies may be any dataset body or moon,
+// by English or Serbian name.
+func PutTour(st *store.Store, tours *store.Tours, auditLog *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var t models.Tour
+		if !bindJSON(c, &t) {
+			return
+		}
+		t.ID = c.Param("id")
+		var ok bool
+		if t.Title, ok = canonicalText(t.Title); !ok {
+			invalid(c, FieldError{Field: "title", Message: "has an invalid locale"})
+			return
+		}
+		for i := range t.Steps {
+			s := &t.Steps[i]
+			if s.Narration, ok = canonicalText(s.Narration); !ok {
+				invalid(c, FieldError{Field: fmt.Sprintf("steps[%d].narration", i), Message: "has an invalid locale"})
+				return
+			}
+			if s.Body != "" {
+				name, ok := surfaceBody(c, st, s.Body)
+				if !ok {
+					invalid(c, FieldError{Field: fmt.Sprintf("steps[%d].body", i), Message: "is not a known body: " + s.Body})
+					return
+				}
+				s.Body = name
+			}
+		}
+
+		stored, prev, err := tours.Put(t)
+		switch {
+		case errors.Is(err, store.ErrInvalidTour):
+			invalid(c, fieldErrors(store.ErrInvalidTour, err)...)
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			action, status := audit.ActionCreate, http.StatusCreated
+			if prev != nil {
+				action, status = audit.ActionUpdate, http.StatusOK
+			}
+			recordAudit(c, auditLog, action, "tour", stored.ID, prev, stored)
+			c.JSON(status, gin.H{"data": stored})
+		}
+	}
+}
+
+// DeleteTour removes a tour
+func DeleteTour(tours *store.Tours, auditLog *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deleted, err := tours.Delete(c.Param("id"))
+		switch {
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		case deleted == nil:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tour not found"})
+		default:
+			recordAudit(c, auditLog, audit.ActionDelete, "tour", deleted.ID, deleted, nil)
+			c.Status(http.StatusNoContent)
+		}
+	}
+}
+
+// localText picks the first locale in chain that text has, and where it
+// was found. Serbian text also serves sr-Latn and, transliterated, sr-Cyrl.
+func localText(text map[string]string, chain []string) (string, string) {
+	for _, tag := range chain {
+		if s := text[tag]; s != "" {
+			return s, tag
+		}
+		switch tag {
+		case "sr-Latn":
+			if s := text[baseLocale]; s != "" {
+				return s, tag
+			}
+		case "sr-Cyrl":
+			if s := text[baseLocale]; s != "" {
+				return translit.ToCyrillic(s), tag
+			}
+		}
+	}
+	return text[baseLocale], baseLocale
+}
+
+// canonicalText rekeys text by canonical locale tags; ok is false when a
+// key isn't a locale
+func canonicalText(text map[string]string) (map[string]string, bool) {
+	out := make(map[string]string, len(text))
+	for k, v := range text {
+		tag := i18n.Canonical(k)
+		if tag == "" {
+			return nil, false
+		}
+		out[tag] = v
+	}
+	return out, true
+}
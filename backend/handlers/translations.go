@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"cmp"
+	"errors"
+	"net/http"
+	"sort"
+
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/i18n"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListTranslations returns submitted translations, optionally filtered by
+// ?locale=, ?status=draft|published and ?audience=standard|kids
+func ListTranslations(tr *store.Translations) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Audience string `form:"audience" binding:"omitempty,oneof=standard kids"`
+		}
+		if !bindQuery(c, &req) {
+			return
+		}
+		entries := tr.ListAudience(i18n.Canonical(c.Query("locale")), c.Query("status"), req.Audience)
+		c.JSON(http.StatusOK, gin.H{"data": entries, "count": len(entries)})
+	}
+}
+
+// missingField is a body field with no text of its own in a locale
+type missingField struct {
+	Body   string `json:"body"`
+	Field  string `json:"field"`
+	Draft  string `json:"draft,omitempty"` // pending draft text, if any
+	Source string `json:"source"`          // base text to translate from
+}
+
+// GetMissingTranslations lists, per locale, the body fields that currently
+// fall back to another locale. Phonetics are left out, and the alt-text
+// fields are reported only when there is text to translate from: the
+// orbit isn't missing where it is generated. ?locale= limits the report to one locale;
+// otherwise every locale that has any translation is reported, plus
+// i18n.Default.
+func GetMissingTranslations(st *store.Store, tr *store.Translations) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bodies := solarSystemBodies(c.Request.Context(), st)
+
+		var locales []string
+		if l := c.Query("locale"); l != "" {
+			if locales = []string{i18n.Canonical(l)}; locales[0] == "" {
+				invalid(c, FieldError{Field: "locale", Message: "is not a valid locale"})
+				return
+			}
+		} else {
+			seen := map[string]bool{i18n.Default: true}
+			for _, b := range bodies {
+				for l := range b.Translations {
+					seen[l] = true
+				}
+			}
+			for _, e := range tr.List("", "") {
+				seen[e.Locale] = true
+			}
+			delete(seen, baseLocale)
+			for l := range seen {
+				locales = append(locales, l)
+			}
+			sort.Strings(locales)
+		}
+
+		drafts := make(map[string]string)
+		for _, e := range tr.ListAudience("", store.StatusDraft, models.AudienceStandard) {
+			drafts[e.Body+"|"+e.Locale+"|"+e.Field] = e.Text
+		}
+
+		report := make(map[string][]missingField, len(locales))
+		for _, locale := range locales {
+			missing := []missingField{}
+			for _, b := range bodies {
+				text := bodyText(b, locale)
+				base := bodyText(b, baseLocale)
+				if text.Name == "" {
+					missing = append(missing, missingField{Body: b.Name, Field: "name", Source: base.Name, Draft: drafts[b.Name+"|"+locale+"|name"]})
+				}
+				if text.Description == "" {
+					missing = append(missing, missingField{Body: b.Name, Field: "description", Source: base.Description, Draft: drafts[b.Name+"|"+locale+"|description"]})
+				}
+				if text.Appearance == "" {
+					if source := cmp.Or(base.Appearance, bodyText(b, i18n.Default).Appearance); source != "" {
+						missing = append(missing, missingField{Body: b.Name, Field: "appearance", Source: source, Draft: drafts[b.Name+"|"+locale+"|appearance"]})
+					}
+				}
+				if text.Orbit == "" && generatedText(func(lang string) string { return orbitText(b, lang) }, locale) == "" {
+					if source := cmp.Or(base.Orbit, orbitText(b, baseLocale)); source != "" {
+						missing = append(missing, missingField{Body: b.Name, Field: "orbit", Source: source, Draft: drafts[b.Name+"|"+locale+"|orbit"]})
+					}
+				}
+			}
+			report[locale] = missing
+		}
+		c.JSON(http.StatusOK, gin.H{"data": report})
+	}
+}
+
+// PutTranslation creates or updates one field of one body in a locale.
+// The body is {"text": "...", "status": "draft"|"published"}; status
+// defaults to draft so nothing goes live by accident.
+func PutTranslation(st *store.Store, tr *store.Translations, auditLog *audit.Log) gin.HandlerFunc {
+	return putTranslation(st, tr, auditLog, false)
+}
+
+// PutContent is PutTranslation for the audience in the path, standard or
+// kids. The kids fields are description and facts, one fact per line.
+func PutContent(st *store.Store, tr *store.Translations, auditLog *audit.Log) gin.HandlerFunc {
+	return putTranslation(st, tr, auditLog, true)
+}
+
+// putTranslation serves PutTranslation and, with byAudience, PutContent
+func putTranslation(st *store.Store, tr *store.Translations, auditLog *audit.Log, byAudience bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var audience string
+		if byAudience {
+			var ok bool
+			if audience, ok = pathAudience(c); !ok {
+				return
+			}
+		}
+		var req struct {
+			Text   string `json:"text" binding:"required"`
+			Status string `json:"status"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		planet, ok := findPlanet(c.Request.Context(), st, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+		locale := i18n.Canonical(c.Param("locale"))
+		if locale == "" {
+			invalid(c, FieldError{Field: "locale", Message: "is not a valid locale"})
+			return
+		}
+		if req.Status == "" {
+			req.Status = store.StatusDraft
+		}
+
+		entry, prev, err := tr.Put(store.TranslationEntry{
+			Body:     planet.Name,
+			Locale:   locale,
+			Audience: audience,
+			Field:    c.Param("field"),
+			Text:     req.Text,
+			Status:   req.Status,
+		})
+		switch {
+		case errors.Is(err, store.ErrInvalidTranslation):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			action := audit.ActionCreate
+			if prev != nil {
+				action = audit.ActionUpdate
+			}
+			recordAudit(c, auditLog, action, "translation", translationKey(entry), prev, entry)
+			c.JSON(http.StatusOK, gin.H{"data": entry})
+		}
+	}
+}
+
+// DeleteTranslation withdraws a submitted translation
+func DeleteTranslation(st *store.Store, tr *store.Translations, auditLog *audit.Log) gin.HandlerFunc {
+	return deleteTranslation(st, tr, auditLog, false)
+}
+
+// DeleteContent is DeleteTranslation for the audience in the path
+func DeleteContent(st *store.Store, tr *store.Translations, auditLog *audit.Log) gin.HandlerFunc {
+	return deleteTranslation(st, tr, auditLog, true)
+}
+
+// deleteTranslation serves DeleteTranslation and, with byAudience,
+// DeleteContent
+func deleteTranslation(st *store.Store, tr *store.Translations, auditLog *audit.Log, byAudience bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var audience string
+		if byAudience {
+			var ok bool
+			if audience, ok = pathAudience(c); !ok {
+				return
+			}
+		}
+		planet, ok := findPlanet(c.Request.Context(), st, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found"})
+			return
+		}
+		deleted, err := tr.Delete(planet.Name, i18n.Canonical(c.Param("locale")), audience, c.Param("field"))
+		switch {
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		case deleted == nil:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Translation not found"})
+		default:
+			recordAudit(c, auditLog, audit.ActionDelete, "translation", translationKey(*deleted), deleted, nil)
+			c.Status(http.StatusNoContent)
+		}
+	}
+}
+
+// pathAudience reads the :audience path parameter as a TranslationEntry
+// audience, answering 422 when it is neither standard nor kids
+func pathAudience(c *gin.Context) (string, bool) {
+	switch a := c.Param("audience"); a {
+	case models.AudienceStandard:
+		return "", true
+	case models.AudienceKids:
+		return a, true
+	}
+	invalid(c, FieldError{Field: "audience", Message: "must be standard or kids"})
+	return "", false
+}
+
+// translationKey identifies a translation in the audit log
+func translationKey(e store.TranslationEntry) string {
+	if e.Audience != "" {
+		return e.Body + "/" + e.Locale + "/" + e.Audience + "/" + e.Field
+	}
+	return e.Body + "/" + e.Locale + "/" + e.Field
+}
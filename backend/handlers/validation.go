@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetValidation checks the dataset as currently served, imports and
+// published translations included, and reports what it finds
+func GetValidation(st *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := validation.Check(solarSystemBodies(c.Request.Context(), st), time.Now())
+		c.JSON(http.StatusOK, gin.H{"data": report, "count": len(report.Issues)})
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWebhook subscribes a URL to event notifications. Body: {"url",
+// "secret" (generated when empty), "events" (empty means all), "days_before"
+// (notice period for astronomical events, default 1)}. The secret is only
+// returned here.
+func CreateWebhook(hooks *webhooks.Hooks, auditLog *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			URL        string   `json:"url" binding:"required"`
+			Secret     string   `json:"secret"`
+			Events     []string `json:"events"`
+			DaysBefore int      `json:"days_before"`
+		}
+		if !bindJSON(c, &req) {
+			return
+		}
+		sub, secret, err := hooks.Create(req.URL, req.Secret, req.Events, req.DaysBefore)
+		switch {
+		case errors.Is(err, webhooks.ErrInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			recordAudit(c, auditLog, audit.ActionCreate, "webhook", sub.ID, nil, sub)
+			c.JSON(http.StatusCreated, gin.H{"data": gin.H{"subscription": sub, "secret": secret}})
+		}
+	}
+}
+
+// ListWebhooks lists subscriptions (without secrets)
+func ListWebhooks(hooks *webhooks.Hooks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subs := hooks.List()
+		c.JSON(http.StatusOK, gin.H{"data": subs, "count": len(subs)})
+	}
+}
+
+// GetWebhook returns one subscription
+func GetWebhook(hooks *webhooks.Hooks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sub, err := hooks.Get(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": sub})
+	}
+}
+
+// DeleteWebhook unsubscribes; pending retries are dropped
+func DeleteWebhook(hooks *webhooks.Hooks, auditLog *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sub, err := hooks.Delete(c.Param("id"))
+		switch {
+		case errors.Is(err, webhooks.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			recordAudit(c, auditLog, audit.ActionDelete, "webhook", sub.ID, sub, nil)
+			c.Status(http.StatusNoContent)
+		}
+	}
+}
+
+// GetWebhookDeliveries returns a subscription's recent delivery attempts,
+// newest first
+func GetWebhookDeliveries(hooks *webhooks.Hooks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deliveries, err := hooks.Deliveries(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": deliveries, "count": len(deliveries)})
+	}
+}
@@ -0,0 +1,110 @@
+// Package i18n negotiates which locale a response is written in. Clients
+// ask for a BCP 47 tag (via ?lang= or Accept-Language); every tag expands
+// to a fallback chain of less specific tags ending in Default, so
+// "sr-Cyrl-RS" tries sr-Cyrl-RS → sr-Cyrl → sr → en and a partial
+// translation degrades one step at a time instead of failing.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is the last locale tried before the dataset's base text
+const Default = "en"
+
+// Canonical normalizes the casing of a language tag: language lower case,
+// four-letter script title case, region upper case ("SR_latn_rs" →
+// "sr-Latn-RS"). Empty or malformed input returns "".
+func Canonical(tag string) string {
+	parts := strings.FieldsFunc(tag, func(r rune) bool { return r == '-' || r == '_' })
+	if len(parts) == 0 {
+		return ""
+	}
+	for i, p := range parts {
+		for _, r := range p {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+				return ""
+			}
+		}
+		switch {
+		case i == 0:
+			if len(p) < 2 || len(p) > 3 {
+				return ""
+			}
+			parts[i] = strings.ToLower(p)
+		case len(p) == 4:
+			parts[i] = strings.ToUpper(p[:1]) + strings.ToLower(p[1:])
+		case len(p) == 2 || len(p) == 3:
+			parts[i] = strings.ToUpper(p)
+		default:
+			parts[i] = strings.ToLower(p)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// Chain returns tag followed by its less specific parents, most specific
+// first: "sr-Cyrl-RS" → [sr-Cyrl-RS sr-Cyrl sr]
+func Chain(tag string) []string {
+	tag = Canonical(tag)
+	var chain []string
+	for tag != "" {
+		chain = append(chain, tag)
+		i := strings.LastIndexByte(tag, '-')
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	return chain
+}
+
+// Fallbacks expands the client's preferred tags, in order, into one
+// de-duplicated chain ending with Default
+func Fallbacks(prefs []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, p := range append(prefs, Default) {
+		for _, tag := range Chain(p) {
+			if !seen[tag] {
+				seen[tag] = true
+				out = append(out, tag)
+			}
+		}
+	}
+	return out
+}
+
+// ParseAcceptLanguage returns the tags of an Accept-Language header ordered
+// by descending quality. Wildcards and q=0 entries are dropped.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		if tag = Canonical(tag); tag == "" || q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag, q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	out := make([]string, len(tags))
+	for i, t := range tags {
+		out[i] = t.tag
+	}
+	return out
+}
@@ -0,0 +1,20 @@
+//go:build avif
+
+package imaging
+
+// The AVIF encoder runs libavif compiled to WebAssembly, so it needs no
+// cgo, but it adds a few megabytes to the binary and is linked in only
+// with the avif build tag. The Docker image is built with the tag.
+
+import (
+	"image"
+	"io"
+
+	"github.com/gen2brain/avif"
+)
+
+func init() {
+	Register(Encoder{Format: "avif", Ext: ".avif", MediaType: "image/avif", KeepsAlpha: true, Encode: func(w io.Writer, img image.Image) error {
+		return avif.Encode(w, img, avif.Options{Quality: 60, QualityAlpha: 60, Speed: 8})
+	}})
+}
@@ -0,0 +1,31 @@
+//go:build avif
+
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestAVIFEncoder(t *testing.T) {
+	e, ok := Lookup("avif")
+	if !ok || e.MediaType != "image/avif" {
+		t.Fatalf("Lookup = %+v, %v; want avif in a build with the avif tag", e, ok)
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 32))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i)
+	}
+	img.Set(0, 0, color.NRGBA{A: 0})
+	var buf bytes.Buffer
+	if err := e.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	// An ISO BMFF file whose ftyp box names the avif brand
+	b := buf.Bytes()
+	if len(b) < 12 || string(b[4:8]) != "ftyp" || string(b[8:12]) != "avif" {
+		t.Fatalf("output is not an AVIF file: % x", b[:min(len(b), 12)])
+	}
+}
@@ -0,0 +1,138 @@
+package imaging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache keeps transcoded images in a directory, evicting the least
+// recently used once the files add up to more than the budget. A nil
+// *Cache caches nothing.
+type Cache struct {
+	dir    string
+	budget int64
+
+	mu   sync.Mutex
+	size int64
+}
+
+// OpenCache creates dir if needed and counts what is already in it
+func OpenCache(dir string, budget int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	c := &Cache{dir: dir, budget: budget}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && info.Mode().IsRegular() {
+			c.size += info.Size()
+		}
+	}
+	return c, nil
+}
+
+// Key names the cached copy of one version of a source in one format and
+// width
+func Key(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Get returns the cached image under key and marks it as used
+func (c *Cache) Get(key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	p := filepath.Join(c.dir, key)
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, false
+	}
+	now := time.Now()
+	os.Chtimes(p, now, now)
+	return data, true
+}
+
+// Put stores data under key, written to a temporary file and renamed so a
+// concurrent Get never reads part of it, then trims the cache to budget
+func (c *Cache) Put(key string, data []byte) error {
+	if c == nil {
+		return nil
+	}
+	f, err := os.CreateTemp(c.dir, "."+key+".*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o644)
+	}
+	var old int64
+	if info, statErr := os.Stat(filepath.Join(c.dir, key)); statErr == nil {
+		old = info.Size()
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(c.dir, key))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	c.mu.Lock()
+	c.size += int64(len(data)) - old
+	over := c.size > c.budget
+	c.mu.Unlock()
+	if over {
+		c.evict()
+	}
+	return nil
+}
+
+// evict removes the least recently used files until the cache is within
+// three quarters of its budget, so it isn't trimmed on every Put
+func (c *Cache) evict() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		log.Printf("imaging: reading cache: %v", err)
+		return
+	}
+	infos := make([]fs.FileInfo, 0, len(entries))
+	var size int64
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue // being written
+		}
+		if info, err := e.Info(); err == nil && info.Mode().IsRegular() {
+			infos = append(infos, info)
+			size += info.Size()
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ModTime().Before(infos[j].ModTime()) })
+	for _, info := range infos {
+		if size <= c.budget*3/4 {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, info.Name())); err != nil && !os.IsNotExist(err) {
+			log.Printf("imaging: evicting %s: %v", info.Name(), err)
+			continue
+		}
+		size -= info.Size()
+	}
+	c.size = size
+}
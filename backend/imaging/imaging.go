@@ -0,0 +1,181 @@
+// Package imaging decodes uploaded textures and photos, checks their
+// dimensions before decoding the pixels, scales them down and encodes
+// them in the formats the browser can pick from. JPEG and PNG are always
+// available; the WebP and AVIF encoders are linked in with the webp and
+// avif build tags (webp.go, avif.go), so the default build needs no cgo
+// or extra dependencies.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	_ "image/gif" // accepted as input
+)
+
+// ErrFormat is returned for data that isn't an image in a format we read
+var ErrFormat = errors.New("not a JPEG, PNG or GIF image")
+
+// Limits bound the images Decode accepts
+type Limits struct {
+	MinSide int // shorter side, pixels
+	MaxSide int // longer side, pixels
+}
+
+// Decode checks the dimensions in the image header against l, then
+// decodes the image. It returns the format name (jpeg, png, gif).
+func Decode(data []byte, l Limits) (image.Image, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrFormat
+	}
+	if short := min(cfg.Width, cfg.Height); short < l.MinSide {
+		return nil, "", fmt.Errorf("%d×%d is too small, the shorter side must be at least %d pixels", cfg.Width, cfg.Height, l.MinSide)
+	}
+	if long := max(cfg.Width, cfg.Height); long > l.MaxSide {
+		return nil, "", fmt.Errorf("%d×%d is too large, the longer side must be at most %d pixels", cfg.Width, cfg.Height, l.MaxSide)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("decoding %s: %w", format, err)
+	}
+	return img, format, nil
+}
+
+// Opaque reports whether every pixel of img is fully opaque, in which
+// case it can be stored as JPEG without losing anything but detail
+func Opaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// Resize scales img down to width pixels wide, keeping the aspect ratio,
+// by averaging the source pixels each destination pixel covers. Images
+// already that narrow are returned as they are.
+func Resize(img image.Image, width int) image.Image {
+	b := img.Bounds()
+	if width <= 0 || width >= b.Dx() {
+		return img
+	}
+	height := max(1, b.Dy()*width/b.Dx())
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	sw, sh := b.Dx(), b.Dy()
+	for dy := 0; dy < height; dy++ {
+		y0, y1 := dy*sh/height, max((dy+1)*sh/height, dy*sh/height+1)
+		for dx := 0; dx < width; dx++ {
+			x0, x1 := dx*sw/width, max((dx+1)*sw/width, dx*sw/width+1)
+			var r, g, bl, a, n uint64
+			for y := y0; y < y1; y++ {
+				row := src.Pix[y*src.Stride+x0*4 : y*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r += uint64(row[i])
+					g += uint64(row[i+1])
+					bl += uint64(row[i+2])
+					a += uint64(row[i+3])
+					n++
+				}
+			}
+			o := dy*dst.Stride + dx*4
+			dst.Pix[o] = uint8((r + n/2) / n)
+			dst.Pix[o+1] = uint8((g + n/2) / n)
+			dst.Pix[o+2] = uint8((bl + n/2) / n)
+			dst.Pix[o+3] = uint8((a + n/2) / n)
+		}
+	}
+	return dst
+}
+
+// Encoder writes images in one format
+type Encoder struct {
+	Format    string // jpeg, png, webp, avif
+	Ext       string // file extension with the dot
+	MediaType string
+	// KeepsAlpha is set for formats that store transparency; the others
+	// are skipped for images that have it
+	KeepsAlpha bool
+	Encode     func(w io.Writer, img image.Image) error
+}
+
+var (
+	encodersMu sync.Mutex
+	encoders   = map[string]Encoder{
+		"jpeg": {Format: "jpeg", Ext: ".jpg", MediaType: "image/jpeg", Encode: func(w io.Writer, img image.Image) error {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+		}},
+		"png": {Format: "png", Ext: ".png", MediaType: "image/png", KeepsAlpha: true, Encode: png.Encode},
+	}
+)
+
+// Register adds an encoder; the build-tagged files call it from init
+func Register(e Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[e.Format] = e
+}
+
+// Lookup returns the encoder for format
+func Lookup(format string) (Encoder, bool) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	e, ok := encoders[format]
+	return e, ok
+}
+
+// Modern returns the registered encoders beyond JPEG and PNG, by format
+// name, for the variants served to browsers that accept them
+func Modern() []Encoder {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	var out []Encoder
+	for f, e := range encoders {
+		if f != "jpeg" && f != "png" {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Format < out[j].Format })
+	return out
+}
+
+// preference orders the modern formats, smallest files first
+var preference = []string{"avif", "webp"}
+
+// Negotiate picks the registered format that makes the smallest file
+// among those the Accept header names explicitly. Wildcards don't count:
+// browsers send image/* whether or not they decode WebP or AVIF. alpha
+// rules out formats that would drop transparency.
+func Negotiate(accept string, alpha bool) (Encoder, bool) {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			if k, v, ok := strings.Cut(strings.TrimSpace(p), "="); ok && k == "q" {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(mt))] = q > 0
+	}
+	for _, f := range preference {
+		e, ok := Lookup(f)
+		if ok && accepted[e.MediaType] && (!alpha || e.KeepsAlpha) {
+			return e, true
+		}
+	}
+	return Encoder{}, false
+}
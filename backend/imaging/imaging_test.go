@@ -0,0 +1,62 @@
+package imaging
+
+import (
+	"image"
+	"io"
+	"testing"
+)
+
+// stubEncoders registers encoders for formats with the given alpha
+// support, standing in for the build-tagged ones, and restores the
+// registry when the test ends
+func stubEncoders(t *testing.T, formats map[string]bool) {
+	t.Helper()
+	encodersMu.Lock()
+	saved := make(map[string]Encoder, len(encoders))
+	for f, e := range encoders {
+		saved[f] = e
+	}
+	encodersMu.Unlock()
+	t.Cleanup(func() {
+		encodersMu.Lock()
+		encoders = saved
+		encodersMu.Unlock()
+	})
+	for f, alpha := range formats {
+		Register(Encoder{Format: f, Ext: "." + f, MediaType: "image/" + f, KeepsAlpha: alpha,
+			Encode: func(io.Writer, image.Image) error { return nil }})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	stubEncoders(t, map[string]bool{"avif": true, "webp": true})
+	tests := []struct {
+		accept string
+		want   string // "" when nothing is negotiated
+	}{
+		{"image/avif,image/webp,image/apng,image/*,*/*;q=0.8", "avif"},
+		{"image/webp,image/avif", "avif"},
+		{"image/avif;q=0,image/webp", "webp"},
+		{"image/webp,image/*;q=0.8", "webp"},
+		{"image/*,*/*;q=0.8", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		e, ok := Negotiate(tt.accept, false)
+		if ok != (tt.want != "") || e.Format != tt.want {
+			t.Errorf("Negotiate(%q) = %q, %v; want %q", tt.accept, e.Format, ok, tt.want)
+		}
+	}
+}
+
+// An encoder that drops transparency is passed over for images that have it
+func TestNegotiateAlpha(t *testing.T) {
+	stubEncoders(t, map[string]bool{"avif": false, "webp": true})
+	accept := "image/avif,image/webp"
+	if e, _ := Negotiate(accept, false); e.Format != "avif" {
+		t.Errorf("opaque image: %q, want avif", e.Format)
+	}
+	if e, _ := Negotiate(accept, true); e.Format != "webp" {
+		t.Errorf("image with alpha: %q, want webp", e.Format)
+	}
+}
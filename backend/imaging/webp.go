@@ -0,0 +1,20 @@
+//go:build webp
+
+package imaging
+
+// The WebP encoder needs cgo (it compiles the bundled libwebp), so it is
+// linked in only with the webp build tag. The Docker image is built with
+// the tag.
+
+import (
+	"image"
+	"io"
+
+	"github.com/chai2010/webp"
+)
+
+func init() {
+	Register(Encoder{Format: "webp", Ext: ".webp", MediaType: "image/webp", KeepsAlpha: true, Encode: func(w io.Writer, img image.Image) error {
+		return webp.Encode(w, img, &webp.Options{Quality: 80})
+	}})
+}
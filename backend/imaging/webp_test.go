@@ -0,0 +1,30 @@
+//go:build webp
+
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestWebPEncoder(t *testing.T) {
+	e, ok := Negotiate("image/webp,image/*;q=0.8", true)
+	if !ok || e.Format != "webp" {
+		t.Fatalf("Negotiate = %q, %v; want webp in a build with the webp tag", e.Format, ok)
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 32))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i)
+	}
+	img.Set(0, 0, color.NRGBA{A: 0})
+	var buf bytes.Buffer
+	if err := e.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	if len(b) < 12 || string(b[:4]) != "RIFF" || string(b[8:12]) != "WEBP" {
+		t.Fatalf("output is not a WebP file: % x", b[:min(len(b), 12)])
+	}
+}
@@ -0,0 +1,62 @@
+package jsonfile
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tours.json")
+	if err := Save(path, []string{"grand-tour"}, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, map[string]int{"steps": 3}, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"steps\": 3\n}"; string(data) != want {
+		t.Errorf("file holds %q, want %q", data, want)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("mode %v, err %v; want 0600", fi.Mode().Perm(), err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temp file left behind: %v", err)
+	}
+
+	// A value that can't be encoded leaves the file as it was
+	if err := Save(path, math.NaN(), 0o600); err == nil {
+		t.Fatal("NaN was saved")
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(data) {
+		t.Errorf("file changed to %q", after)
+	}
+}
+
+// A write that fails doesn't leave its temp file behind
+func TestWriteFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "classes.json")
+	if err := Write(path, []byte("[]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The temp file is written, but renaming it fails: a non-empty
+	// directory stands where the file was
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(path, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(path, []byte("[1]"), 0o644); err == nil {
+		t.Fatal("replacing a directory succeeded")
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temp file left behind: %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+// Package random makes the unguessable IDs, codes and tokens the stores
+// hand out, all from crypto/rand.
+package random
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+)
+
+// Bytes returns n random bytes
+func Bytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err) // crypto/rand never fails on supported platforms
+	}
+	return b
+}
+
+// Hex returns n random bytes, hex-encoded
+func Hex(n int) string {
+	return hex.EncodeToString(Bytes(n))
+}
+
+// String returns n characters drawn uniformly from alphabet
+func String(alphabet string, n int) string {
+	b := make([]byte, n)
+	size := big.NewInt(int64(len(alphabet)))
+	for i := range b {
+		r, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			panic(err) // crypto/rand never fails on supported platforms
+		}
+		b[i] = alphabet[r.Int64()]
+	}
+	return string(b)
+}
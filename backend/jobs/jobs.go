@@ -0,0 +1,244 @@
+// Package jobs runs recurring background tasks (ephemeris precomputation,
+// enrichment, cache cleanup) on interval or cron schedules and keeps the
+// status of each for the admin API. A job never overlaps with itself: a
+// run that comes due while the previous one is still going is skipped.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrUnknownJob is returned by Trigger for names that were never added
+var ErrUnknownJob = errors.New("unknown job")
+
+// Job is a named recurring task
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(ctx context.Context) error
+	// Timeout bounds one run; zero means no limit
+	Timeout time.Duration
+	// RunOnStart runs the job once when the scheduler starts, before its
+	// first scheduled time
+	RunOnStart bool
+}
+
+// Status is a job's schedule and run history
+type Status struct {
+	Name      string     `json:"name"`
+	Schedule  string     `json:"schedule"`
+	Running   bool       `json:"running"`
+	NextRun   *time.Time `json:"next_run"` // nil for manual-only jobs
+	LastStart *time.Time `json:"last_start,omitempty"`
+	LastEnd   *time.Time `json:"last_end,omitempty"`
+	Duration  string     `json:"last_duration,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+	Runs      int        `json:"runs"`
+	Failures  int        `json:"failures"`
+	Skipped   int        `json:"skipped"` // came due while still running
+}
+
+type entry struct {
+	job     Job
+	status  Status
+	trigger chan struct{}
+}
+
+// Scheduler owns a set of jobs
+type Scheduler struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	wg      sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// New creates an empty scheduler
+func New() *Scheduler {
+	return &Scheduler{entries: make(map[string]*entry)}
+}
+
+// Add registers a job. Jobs added after Start begin immediately.
+func (s *Scheduler) Add(j Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, dup := s.entries[j.Name]; dup {
+		panic(fmt.Sprintf("jobs: %q added twice", j.Name))
+	}
+	e := &entry{job: j, status: Status{Name: j.Name, Schedule: j.Schedule.String()}, trigger: make(chan struct{}, 1)}
+	s.entries[j.Name] = e
+	if s.ctx != nil {
+		s.launch(e)
+	}
+}
+
+// Start runs every job's loop until Stop
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx != nil {
+		return
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	for _, e := range s.entries {
+		s.launch(e)
+	}
+}
+
+// launch starts e's loop. Callers hold s.mu.
+func (s *Scheduler) launch(e *entry) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.loop(s.ctx, e)
+	}()
+}
+
+// Stop cancels running jobs and waits for them to return, at most until
+// ctx is done
+func (s *Scheduler) Stop(ctx context.Context) {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// Trigger asks a job to run now, outside its schedule. If it is already
+// running (or already triggered) the request is coalesced.
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	e, ok := s.entries[name]
+	s.mu.Unlock()
+	if !ok {
+		return ErrUnknownJob
+	}
+	select {
+	case e.trigger <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Status returns every job's status, sorted by name
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Status, 0, len(s.entries))
+	for _, e := range s.entries {
+		out = append(out, e.status)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// loop waits for the job's next scheduled time or a trigger and runs it.
+// Runs happen on this goroutine, so they can't overlap; scheduled times
+// that pass during a run are counted as skipped.
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	if e.job.RunOnStart {
+		s.run(ctx, e)
+	}
+	for {
+		next := e.job.Schedule.Next(time.Now())
+		var timer *time.Timer
+		var due <-chan time.Time
+		s.mu.Lock()
+		e.status.NextRun = nil
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			due = timer.C
+			e.status.NextRun = &next
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+		case <-e.trigger:
+		case <-due:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		s.run(ctx, e)
+
+		// Count scheduled times that went by while the job was running
+		missed := 0
+		for t := e.job.Schedule.Next(next); !t.IsZero() && t.Before(time.Now()) && missed < 1000; t = e.job.Schedule.Next(t) {
+			missed++
+		}
+		if missed > 0 {
+			s.mu.Lock()
+			e.status.Skipped += missed
+			s.mu.Unlock()
+		}
+	}
+}
+
+// run executes one run inside a span, recording the outcome
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	start := time.Now().UTC()
+	s.mu.Lock()
+	e.status.Running = true
+	e.status.LastStart = &start
+	s.mu.Unlock()
+
+	runCtx, span := tracing.Start(ctx, "job "+e.job.Name, attribute.String("job.name", e.job.Name))
+	if e.job.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(runCtx, e.job.Timeout)
+		defer cancel()
+	}
+	err := safeRun(runCtx, e.job.Run)
+	tracing.Fail(span, err)
+	span.End()
+
+	end := time.Now().UTC()
+	s.mu.Lock()
+	e.status.Running = false
+	e.status.LastEnd = &end
+	e.status.Duration = end.Sub(start).String()
+	e.status.Runs++
+	e.status.LastError = ""
+	if err != nil {
+		e.status.Failures++
+		e.status.LastError = err.Error()
+	}
+	s.mu.Unlock()
+	if err != nil {
+		log.Printf("jobs: %s failed: %v", e.job.Name, err)
+	}
+}
+
+// safeRun turns a panicking job into a failed run instead of a crash
+func safeRun(ctx context.Context, fn func(context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
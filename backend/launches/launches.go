@@ -0,0 +1,264 @@
+// Package launches is a client for The Space Devs' Launch Library 2
+// (https://ll.thespacedevs.com/docs), the community launch schedule. It
+// fetches upcoming launches, keeps those of the agencies we follow and
+// simplifies them for the API.
+package launches
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/clock"
+	"solar-system-explorer/backend/upstream"
+)
+
+// DefaultBaseURL is Launch Library 2's production API
+const DefaultBaseURL = "https://ll.thespacedevs.com/2.2.0"
+
+// fetchLimit is how many upcoming launches one request asks for; the free
+// tier allows 15 requests an hour, so the list is fetched whole and cached
+const fetchLimit = 100
+
+// Launch is an upcoming launch
+type Launch struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Net         time.Time  `json:"net"` // "no earlier than"
+	WindowStart *time.Time `json:"window_start,omitempty"`
+	WindowEnd   *time.Time `json:"window_end,omitempty"`
+	Status      string     `json:"status"`                 // "Go for Launch", "To Be Determined", …
+	StatusCode  string     `json:"status_code"`            // Go, TBD, TBC, Hold, …
+	Provider    string     `json:"provider"`               // launch service provider
+	Rocket      string     `json:"rocket"`                 // configuration full name
+	Mission     *Mission   `json:"mission"`                // nil when not yet announced
+	Pad         string     `json:"pad"`                    // pad and its location
+	Image       string     `json:"image,omitempty"`        // URL
+	Agencies    []string   `json:"agencies"`               // provider and mission agencies
+	Bodies      []string   `json:"bodies,omitempty"`       // our bodies the mission mentions, set by callers
+	Webcast     bool       `json:"webcast_live,omitempty"` // streaming now
+}
+
+// Mission is what a launch carries
+type Mission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Type        string `json:"type"`  // "Planetary Science", "Communications", …
+	Orbit       string `json:"orbit"` // "Heliocentric N/A", "Low Earth Orbit", …
+}
+
+// Upcoming is the cached launch list
+type Upcoming struct {
+	Launches  []Launch  `json:"launches"`
+	FetchedAt time.Time `json:"fetched_at"`
+	// Stale is set on a list older than the cache TTL served while
+	// Launch Library can't be reached
+	Stale bool `json:"stale"`
+}
+
+// Client fetches the upcoming launches of Agencies (abbreviations or
+// names, matched case-insensitively against the provider and the mission
+// agencies; none keeps every launch) and caches them for CacheTTL.
+type Client struct {
+	BaseURL  string
+	Token    string // optional API key for a higher rate limit
+	Agencies []string
+	HTTP     *http.Client
+	CacheTTL time.Duration
+	Clock    clock.Clock
+
+	refresh sync.Mutex // one fetch at a time
+	mu      sync.Mutex
+	last    *Upcoming
+}
+
+// NewClient returns a traced client for baseURL (DefaultBaseURL if empty)
+// that retries and breaks its circuit as policy says
+func NewClient(baseURL, token string, agencies []string, cacheTTL time.Duration, policy upstream.Policy) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL:  strings.TrimSuffix(baseURL, "/"),
+		Token:    token,
+		Agencies: agencies,
+		HTTP:     upstream.Client("launch-library", 20*time.Second, policy),
+		CacheTTL: cacheTTL,
+		Clock:    clock.System{},
+	}
+}
+
+// Upcoming returns the upcoming launches, from the cache when fresh
+func (c *Client) Upcoming(ctx context.Context) (Upcoming, error) {
+	if u, ok := c.cached(); ok {
+		return u, nil
+	}
+	c.refresh.Lock()
+	defer c.refresh.Unlock()
+	if u, ok := c.cached(); ok {
+		return u, nil
+	}
+	list, err := c.fetch(ctx)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		if c.last != nil && ctx.Err() == nil {
+			stale := *c.last
+			stale.Stale = true
+			return stale, nil
+		}
+		return Upcoming{}, err
+	}
+	c.last = &Upcoming{Launches: list, FetchedAt: c.Clock.Now().UTC()}
+	return *c.last, nil
+}
+
+func (c *Client) cached() (Upcoming, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last == nil || c.Clock.Now().Sub(c.last.FetchedAt) >= c.CacheTTL {
+		return Upcoming{}, false
+	}
+	return *c.last, true
+}
+
+// agency is an agency as Launch Library lists it
+type agency struct {
+	Name   string `json:"name"`
+	Abbrev string `json:"abbrev"`
+}
+
+// raw is the part of a Launch Library launch we read
+type raw struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Net         time.Time  `json:"net"`
+	WindowStart *time.Time `json:"window_start"`
+	WindowEnd   *time.Time `json:"window_end"`
+	Status      struct {
+		Name   string `json:"name"`
+		Abbrev string `json:"abbrev"`
+	} `json:"status"`
+	Provider agency `json:"launch_service_provider"`
+	Rocket   struct {
+		Configuration struct {
+			Name     string `json:"name"`
+			FullName string `json:"full_name"`
+		} `json:"configuration"`
+	} `json:"rocket"`
+	Mission *struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		Type        string   `json:"type"`
+		Agencies    []agency `json:"agencies"`
+		Orbit       *struct {
+			Name string `json:"name"`
+		} `json:"orbit"`
+	} `json:"mission"`
+	Pad struct {
+		Name     string `json:"name"`
+		Location struct {
+			Name string `json:"name"`
+		} `json:"location"`
+	} `json:"pad"`
+	Image       string `json:"image"`
+	WebcastLive bool   `json:"webcast_live"`
+}
+
+func (c *Client) fetch(ctx context.Context) ([]Launch, error) {
+	q := url.Values{"limit": {fmt.Sprint(fetchLimit)}, "mode": {"normal"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/launch/upcoming/?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Token "+c.Token)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("launch library: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("launch library: upstream returned %s", resp.Status)
+	}
+	var body struct {
+		Results []raw `json:"results"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("launch library: decoding response: %w", err)
+	}
+
+	out := []Launch{}
+	for _, r := range body.Results {
+		l := simplify(r)
+		if c.follows(l.Agencies) {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+// follows reports whether any of agencies is one we follow
+func (c *Client) follows(agencies []string) bool {
+	if len(c.Agencies) == 0 {
+		return true
+	}
+	for _, a := range agencies {
+		for _, f := range c.Agencies {
+			if strings.EqualFold(a, f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func simplify(r raw) Launch {
+	l := Launch{
+		ID:          r.ID,
+		Name:        r.Name,
+		Net:         r.Net,
+		WindowStart: r.WindowStart,
+		WindowEnd:   r.WindowEnd,
+		Status:      r.Status.Name,
+		StatusCode:  r.Status.Abbrev,
+		Provider:    r.Provider.Name,
+		Rocket:      r.Rocket.Configuration.FullName,
+		Pad:         r.Pad.Name,
+		Image:       r.Image,
+		Webcast:     r.WebcastLive,
+	}
+	if l.Rocket == "" {
+		l.Rocket = r.Rocket.Configuration.Name
+	}
+	if loc := r.Pad.Location.Name; loc != "" {
+		l.Pad += ", " + loc
+	}
+	names := func(a agency) []string {
+		out := []string{}
+		for _, s := range []string{a.Abbrev, a.Name} {
+			if s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	l.Agencies = names(r.Provider)
+	if m := r.Mission; m != nil {
+		l.Mission = &Mission{Name: m.Name, Description: m.Description, Type: m.Type}
+		if m.Orbit != nil {
+			l.Mission.Orbit = m.Orbit.Name
+		}
+		for _, a := range m.Agencies {
+			l.Agencies = append(l.Agencies, names(a)...)
+		}
+	}
+	return l
+}
@@ -1,42 +1,863 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata" // ?tz= works on images without zoneinfo, such as Alpine
 
+	"solar-system-explorer/backend/accountmail"
+	"solar-system-explorer/backend/achievements"
+	"solar-system-explorer/backend/analytics"
+	"solar-system-explorer/backend/assets"
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/backup"
+	"solar-system-explorer/backend/broker"
+	"solar-system-explorer/backend/classes"
+	"solar-system-explorer/backend/clock"
+	"solar-system-explorer/backend/comments"
+	"solar-system-explorer/backend/config"
+	"solar-system-explorer/backend/digest"
+	"solar-system-explorer/backend/experiments"
+	"solar-system-explorer/backend/grs"
 	"solar-system-explorer/backend/handlers"
+	"solar-system-explorer/backend/imaging"
+	"solar-system-explorer/backend/jobs"
+	"solar-system-explorer/backend/launches"
+	"solar-system-explorer/backend/marsphotos"
+	"solar-system-explorer/backend/middleware"
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/oauth"
+	"solar-system-explorer/backend/orbits"
+	"solar-system-explorer/backend/progress"
+	"solar-system-explorer/backend/redis"
+	"solar-system-explorer/backend/reports"
+	"solar-system-explorer/backend/s3"
+	"solar-system-explorer/backend/sandbox"
+	"solar-system-explorer/backend/satellites"
+	"solar-system-explorer/backend/sbdb"
+	"solar-system-explorer/backend/scenes"
+	"solar-system-explorer/backend/seeds"
+	"solar-system-explorer/backend/store"
+	"solar-system-explorer/backend/swpc"
+	"solar-system-explorer/backend/tracing"
+	"solar-system-explorer/backend/upstream"
+	"solar-system-explorer/backend/users"
+	"solar-system-explorer/backend/validation"
+	"solar-system-explorer/backend/views"
+	"solar-system-explorer/backend/webhooks"
+	"solar-system-explorer/backend/wikidata"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 func main() {
+	cfg, sources, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Task != "" {
+		if err := runTask(cfg); err != nil {
+			log.Fatalf("%s: %v", cfg.Task, err)
+		}
+		return
+	}
+
+	traced, err := tracing.Init(tracing.Options{
+		Endpoint:       cfg.Tracing.Endpoint,
+		TracesEndpoint: cfg.Tracing.TracesEndpoint,
+		Headers:        cfg.Tracing.Headers,
+		ServiceName:    cfg.Tracing.ServiceName,
+		SampleRatio:    cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	if traced {
+		log.Printf("OpenTelemetry tracing enabled")
+	}
+
+	bodies := models.GetSolarSystemBodies()
+	if cfg.Data.Dir != "" {
+		if bodies, err = store.LoadDir(cfg.Data.Dir); err != nil {
+			log.Fatal("Failed to load data directory: ", err)
+		}
+		log.Printf("Loaded %d bodies from %s", len(bodies), cfg.Data.Dir)
+	}
+	dataset := store.New(bodies)
+	translations, err := store.OpenTranslations(cfg.Data.TranslationsFile)
+	if err != nil {
+		log.Fatalf("Failed to load translations: %v", err)
+	}
+	dataset.SetOverlay(translations.Published())
+	translations.OnChange(func() { dataset.SetOverlay(translations.Published()) })
+	tours, err := store.OpenTours(cfg.Data.ToursFile)
+	if err != nil {
+		log.Fatalf("Failed to load tours: %v", err)
+	}
+	grsTracker, err := grs.Open(cfg.Data.GRSFile)
+	if err != nil {
+		log.Fatalf("Failed to load Great Red Spot tracking: %v", err)
+	}
+	var (
+		imports  store.BodyRepository // nil keeps imported bodies in memory
+		migrator *store.Migrator      // nil without a database
+		usage    analytics.Repository // nil keeps usage events in ANALYTICS_FILE
+	)
+	switch {
+	case cfg.DB.Driver == "postgres":
+		pool := store.Pool{
+			MaxConns:        cfg.DB.MaxConns,
+			MaxIdleConns:    cfg.DB.MaxIdleConns,
+			ConnMaxLifetime: cfg.DB.ConnMaxLifetime,
+			ConnMaxIdleTime: cfg.DB.ConnMaxIdleTime,
+		}
+		pg, err := store.OpenPostgres(context.Background(), cfg.DB.DSN, pool, cfg.DB.AutoMigrate)
+		if err != nil {
+			log.Fatalf("Failed to open the database: %v", err)
+		}
+		imports, migrator = pg, pg.Migrator()
+		usage = analytics.NewPostgres(pg.DB())
+	case cfg.Data.ImportsFile != "":
+		imports = store.NewFileRepository(cfg.Data.ImportsFile)
+	}
+	var imported []models.Planet
+	if imports != nil {
+		defer imports.Close()
+		if imported, err = imports.Load(context.Background()); err != nil {
+			log.Fatalf("Failed to load imported bodies: %v", err)
+		}
+	}
+	if len(imported) == 0 && cfg.Data.SeedOnStart != "" {
+		if imported, err = seeds.Load(cfg.Data.SeedOnStart); err != nil {
+			log.Fatal(err)
+		}
+		if imports != nil {
+			if err := imports.Save(context.Background(), imported); err != nil {
+				log.Fatalf("Failed to save seed bodies: %v", err)
+			}
+		}
+		log.Printf("Seeded %d bodies from the %s scenario", len(imported), cfg.Data.SeedOnStart)
+	}
+	dataset.Upsert(imported...)
+	report := validation.Check(dataset.Bodies(), time.Now())
+	for _, is := range report.Issues {
+		log.Printf("validation: %s %s: %s: %s", is.Severity, is.Body, is.Field, is.Message)
+	}
+	if !report.OK() && cfg.Data.Strict {
+		log.Fatalf("Dataset failed validation: %s", report)
+	}
+	log.Printf("Dataset validation: %s", report)
+	auditLog, err := audit.Open(cfg.Admin.AuditFile)
+	if err != nil {
+		log.Fatalf("Failed to open audit log: %v", err)
+	}
+	defer auditLog.Close()
+	hooks, err := webhooks.Open(cfg.Webhooks.File, cfg.Webhooks.MaxAttempts)
+	if err != nil {
+		log.Fatalf("Failed to load webhooks: %v", err)
+	}
+	hooks.Start()
+	var mailer *digest.Mailer // nil unless SMTP is configured
+	var weekly *digest.Digest
+	if cfg.Mail.SMTPHost != "" {
+		mailer = &digest.Mailer{
+			Host:     cfg.Mail.SMTPHost,
+			Port:     cfg.Mail.SMTPPort,
+			Username: cfg.Mail.SMTPUsername,
+			Password: cfg.Mail.SMTPPassword,
+			From:     cfg.Mail.From,
+		}
+		if weekly, err = digest.Open(cfg.Mail.DigestFile, mailer, cfg.Mail.PublicURL); err != nil {
+			log.Fatalf("Failed to load digest subscribers: %v", err)
+		}
+	}
+	var assetBackend assets.Backend
+	switch {
+	case cfg.Assets.S3Bucket != "":
+		client, err := newS3Client(cfg, cfg.Assets.S3Bucket)
+		if err != nil {
+			log.Fatal(err)
+		}
+		assetBackend = assets.Bucket{Client: client, Prefix: cfg.Assets.S3Prefix}
+	case cfg.Assets.Dir != "":
+		assetBackend = assets.Disk(cfg.Assets.Dir)
+	}
+	assetStore, err := assets.OpenBackend(context.Background(), assetBackend, int64(cfg.Assets.CacheMB)<<20)
+	if err != nil {
+		log.Fatalf("Failed to load assets: %v", err)
+	}
+	assetSigner := assets.NewSigner(cfg.Assets.SigningKey)
+	if assetStore.HasPrivate() && assetSigner == nil {
+		log.Fatal("The asset manifest lists private assets but ASSETS_SIGNING_KEY is not set")
+	}
+	if assetBackend != nil {
+		log.Printf("Loaded %d assets from %s", assetStore.Len(), assetBackend)
+		go assetStore.Prefetch(context.Background())
+	}
+	var imageCache *imaging.Cache
+	if cfg.Assets.ImageCacheDir != "" {
+		imageCache, err = imaging.OpenCache(cfg.Assets.ImageCacheDir, int64(cfg.Assets.ImageCacheMB)<<20)
+		if err != nil {
+			log.Fatalf("Failed to open the image cache: %v", err)
+		}
+	}
+	sandboxes, err := sandbox.Open(cfg.Sandboxes.File, sandbox.Limits{
+		PerUser:  cfg.Sandboxes.PerUser,
+		Total:    cfg.Sandboxes.Total,
+		MaxEdits: cfg.Sandboxes.MaxEdits,
+		TTL:      cfg.Sandboxes.TTL,
+	})
+	if err != nil {
+		log.Fatalf("Failed to load sandboxes: %v", err)
+	}
+	sharedScenes, err := scenes.Open(cfg.Scenes.File, cfg.Scenes.Max)
+	if err != nil {
+		log.Fatalf("Failed to load scenes: %v", err)
+	}
+	viewStats, err := views.Open(cfg.Views.File)
+	if err != nil {
+		log.Fatalf("Failed to load view counts: %v", err)
+	}
+	if usage == nil {
+		if usage, err = analytics.OpenFile(cfg.Analytics.File, cfg.Analytics.MaxEvents); err != nil {
+			log.Fatalf("Failed to load usage events: %v", err)
+		}
+	}
+	usageEvents := analytics.NewCollector(usage, cfg.Analytics.Retention)
+	experimentList, err := experiments.Load(cfg.Experiments.File)
+	if err != nil {
+		log.Fatalf("Failed to load experiments: %v", err)
+	}
+	abTests := experiments.New(experimentList, usageEvents)
+	accounts, err := users.Open(cfg.Auth.UsersFile)
+	if err != nil {
+		log.Fatalf("Failed to load user accounts: %v", err)
+	}
+	sessionKey := []byte(cfg.Auth.SessionSecret)
+	if len(sessionKey) == 0 {
+		sessionKey = make([]byte, 32)
+		if _, err := rand.Read(sessionKey); err != nil {
+			log.Fatal(err)
+		}
+		log.Print("AUTH_SESSION_SECRET is not set; sessions end when the server restarts")
+	}
+	var shared *redis.Client // nil unless Redis is configured
+	if cfg.Redis.URL != "" {
+		if shared, err = redis.Open(context.Background(), cfg.Redis.URL, cfg.Redis.PoolSize, cfg.Redis.Prefix); err != nil {
+			log.Fatalf("Failed to connect to Redis: %v", err)
+		}
+		defer shared.Close()
+	}
+	// Event streams end with streams, before the server drains requests
+	streams, stopStreams := context.WithCancel(context.Background())
+	var events broker.Broker = broker.NewMemory()
+	if shared != nil {
+		events = broker.NewRedis(streams, shared)
+	}
+	var logins users.LoginStore
+	if shared != nil {
+		logins = users.NewRedisLogins(shared, cfg.Auth.SessionTTL)
+	} else if logins, err = users.OpenLogins(cfg.Auth.SessionsFile, cfg.Auth.SessionTTL); err != nil {
+		log.Fatalf("Failed to load sessions: %v", err)
+	}
+	sessions := users.NewSessions(sessionKey, cfg.Auth.AccessTTL, logins)
+	emailTokens, err := users.OpenEmailTokens(cfg.Auth.TokensFile)
+	if err != nil {
+		log.Fatalf("Failed to load email tokens: %v", err)
+	}
+	accountMail := accountmail.New(mailer, cfg.Mail.PublicURL)
+	providers := make(map[string]*oauth.Provider)
+	callback := func(name string) string {
+		return strings.TrimSuffix(cfg.Mail.PublicURL, "/") + "/api/auth/oauth/" + name + "/callback"
+	}
+	if cfg.OAuth.GoogleClientID != "" {
+		providers["google"] = oauth.Google(cfg.OAuth.GoogleClientID, cfg.OAuth.GoogleClientSecret, callback("google"))
+	}
+	if cfg.OAuth.GitHubClientID != "" {
+		providers["github"] = oauth.GitHub(cfg.OAuth.GitHubClientID, cfg.OAuth.GitHubClientSecret, callback("github"))
+	}
+	oauthStates := oauth.NewStates(sessionKey)
+	discussion, err := comments.Open(cfg.Comments.File, comments.Limits{
+		PerMinute:    cfg.Comments.PerMinute,
+		PerDay:       cfg.Comments.PerDay,
+		BlockedWords: strings.Split(cfg.Comments.BlockedWords, ","),
+		MaxLinks:     cfg.Comments.MaxLinks,
+	})
+	if err != nil {
+		log.Fatalf("Failed to load comments: %v", err)
+	}
+	complaints, err := reports.Open(cfg.Reports.File, cfg.Reports.HideAfter)
+	if err != nil {
+		log.Fatalf("Failed to load reports: %v", err)
+	}
+	classrooms, err := classes.Open(cfg.Classes.File)
+	if err != nil {
+		log.Fatalf("Failed to load classes: %v", err)
+	}
+	userProgress, err := progress.Open(cfg.Progress.File)
+	if err != nil {
+		log.Fatalf("Failed to load progress: %v", err)
+	}
+	badges, err := achievements.Open(cfg.Progress.AchievementsFile)
+	if err != nil {
+		log.Fatalf("Failed to load achievements: %v", err)
+	}
+	userProgress.OnChange(func(p progress.Progress) {
+		if _, err := badges.Evaluate(p, time.Now()); err != nil {
+			log.Printf("achievements: %s: %v", p.UserID, err)
+		}
+	})
+	if cfg.Data.Watch {
+		if err := dataset.Watch(context.Background(), cfg.Data.Dir); err != nil {
+			log.Fatal("Failed to watch data directory: ", err)
+		}
+	}
+
 	r := gin.Default()
+	r.Use(tracing.Middleware())
+	r.Use(middleware.SecurityHeaders(middleware.SecurityPolicy{
+		CSP:            cfg.Security.CSP,
+		FrameAncestors: cfg.Security.FrameAncestors,
+		HSTSMaxAge:     cfg.Security.HSTSMaxAge,
+		ReferrerPolicy: cfg.Security.ReferrerPolicy,
+	}))
+
+	// Maintenance and read-only switches for dataset migrations. Admins
+	// get through both, wherever they call: the admin API to turn them off
+	// and carry out the migration, webhook subscriptions, signed URLs.
+	modes := middleware.NewModes(middleware.ModeState{
+		Maintenance: cfg.Server.Maintenance,
+		ReadOnly:    cfg.Server.ReadOnly,
+		Message:     cfg.Server.Notice,
+	})
+	r.Use(modes.Handler(middleware.IsAdmin(cfg.Admin.Token, sessions, accounts)))
+
+	// Ephemeris cache — positions are bucketed to the minute and shared by
+	// every client asking for the same instant
+	ephemeris := orbits.NewCache(cfg.Ephemeris.CacheTTL, cfg.Ephemeris.Resolution, cfg.Ephemeris.CacheMax)
+	if shared != nil {
+		ephemeris.SetShared(shared)
+	}
+
+	// Retries and circuit breaking for external APIs, stats at
+	// /api/admin/upstreams
+	upstreamPolicy := upstream.DefaultPolicy()
+	upstreamPolicy.Retries = cfg.Upstream.Retries
+	upstreamPolicy.BaseDelay = cfg.Upstream.RetryDelay
+	upstreamPolicy.Threshold = cfg.Upstream.BreakerThreshold
+	upstreamPolicy.Cooldown = cfg.Upstream.BreakerCooldown
+	celestrak := satellites.NewClient(cfg.Upstream.CelesTrakURL, cfg.Satellites.TLEMaxAge, upstreamPolicy)
+	spaceWeather := swpc.NewClient(cfg.Upstream.SWPCURL, cfg.SpaceWeather.CacheTTL, upstreamPolicy)
+	roverPhotos, err := marsphotos.NewClient(cfg.Upstream.MarsPhotosURL, cfg.MarsPhotos.APIKey, cfg.MarsPhotos.CacheDir, cfg.MarsPhotos.CacheTTL, upstreamPolicy)
+	if err != nil {
+		log.Fatalf("Failed to open the Mars photos cache: %v", err)
+	}
+	launchSchedule := launches.NewClient(cfg.Upstream.LaunchLibraryURL, cfg.Launches.Token, cfg.Launches.AgencyList(), cfg.Launches.CacheTTL, upstreamPolicy)
+	satelliteCatalog, err := satellites.OpenCatalog(cfg.Satellites.File, celestrak, cfg.Satellites.GroupList(), cfg.Satellites.StaleAfter)
+	if err != nil {
+		log.Fatalf("Failed to load the satellite catalogue: %v", err)
+	}
+
+	// Retried POSTs with an Idempotency-Key replay the first response,
+	// on any replica when they share Redis
+	idempotentCaller := middleware.IdempotencyCaller(cfg.Admin.Token, sessions)
+	idempotency := middleware.NewIdempotency(cfg.Idempotency.TTL, cfg.Idempotency.MaxKeys, idempotentCaller)
+	if shared != nil {
+		idempotency = middleware.NewSharedIdempotency(shared, cfg.Idempotency.TTL, idempotentCaller)
+	}
+
+	// Sign-in, sign-up and password resets are limited per client IP, and
+	// sign-in also per email so guessing one account's password is slow
+	// from any number of addresses. With Redis the replicas share counts.
+	ipLimit := middleware.NewRateLimit(cfg.Auth.RatePerIP, cfg.Auth.RateWindow)
+	emailLimit := middleware.NewRateLimit(cfg.Auth.RatePerEmail, cfg.Auth.RateWindow)
+	if shared != nil {
+		ipLimit = middleware.NewSharedRateLimit(shared, "auth-ip", cfg.Auth.RatePerIP, cfg.Auth.RateWindow)
+		emailLimit = middleware.NewSharedRateLimit(shared, "auth-email", cfg.Auth.RatePerEmail, cfg.Auth.RateWindow)
+	}
+	authPerIP := ipLimit.Handler(middleware.ByClientIP)
+	authPerEmail := emailLimit.Handler(handlers.LoginEmail)
+
+	// Recurring background work, status at /api/admin/jobs
+	scheduler := jobs.New()
+	scheduler.Add(jobs.Job{
+		Name:     "ephemeris-cache-cleanup",
+		Schedule: jobs.Every(time.Minute),
+		Run: func(context.Context) error {
+			ephemeris.PurgeExpired()
+			return nil
+		},
+	})
+	if cfg.Ephemeris.Precompute {
+		schedule := jobs.Manual()
+		if cfg.Ephemeris.PrecomputeRefresh > 0 {
+			schedule = jobs.Every(cfg.Ephemeris.PrecomputeRefresh)
+		}
+		scheduler.Add(jobs.Job{
+			Name:       "ephemeris-precompute",
+			Schedule:   schedule,
+			RunOnStart: true,
+			Run: func(context.Context) error {
+				precomputeEphemeris(ephemeris, dataset, cfg.Ephemeris)
+				return nil
+			},
+		})
+	}
+	if cfg.Enrich.Wikidata {
+		client := wikidata.NewClient(cfg.Upstream.WikidataURL, cfg.Enrich.RequestInterval, cfg.Enrich.CacheTTL, upstreamPolicy)
+		schedule, _ := jobs.Parse(cfg.Enrich.Schedule) // checked by cfg.Validate
+		scheduler.Add(jobs.Job{
+			Name:       "wikidata-enrich",
+			Schedule:   schedule,
+			RunOnStart: true,
+			Timeout:    30 * time.Minute,
+			Run: func(ctx context.Context) error {
+				facts, err := wikidata.Enrich(ctx, client, dataset.Bodies())
+				if err != nil {
+					return err
+				}
+				dataset.SetSupplements(facts)
+				log.Printf("Wikidata enrichment: facts for %d bodies", len(facts))
+				return nil
+			},
+		})
+	}
+	satelliteSchedule, _ := jobs.Parse(cfg.Satellites.Schedule) // checked by cfg.Validate
+	scheduler.Add(jobs.Job{
+		Name:       "satellite-catalog",
+		Schedule:   satelliteSchedule,
+		RunOnStart: true,
+		Timeout:    5 * time.Minute,
+		Run:        satelliteCatalog.Refresh,
+	})
+	webhookSchedule, _ := jobs.Parse(cfg.Webhooks.Schedule) // checked by cfg.Validate
+	scheduler.Add(jobs.Job{
+		Name:       "webhook-events",
+		Schedule:   webhookSchedule,
+		RunOnStart: true,
+		Run: func(context.Context) error {
+			return hooks.NotifyUpcoming(time.Now())
+		},
+	})
+	scheduler.Add(jobs.Job{
+		Name:     "sandbox-cleanup",
+		Schedule: jobs.Every(time.Hour),
+		Run: func(context.Context) error {
+			n, err := sandboxes.Purge(time.Now())
+			if n > 0 {
+				log.Printf("Removed %d expired sandboxes", n)
+			}
+			return err
+		},
+	})
+	scheduler.Add(jobs.Job{
+		Name:     "session-cleanup",
+		Schedule: jobs.Every(time.Hour),
+		Run: func(context.Context) error {
+			if _, err := logins.Purge(time.Now()); err != nil {
+				return err
+			}
+			_, err := emailTokens.Purge(time.Now())
+			return err
+		},
+	})
+	scheduler.Add(jobs.Job{
+		Name:     "idempotency-cleanup",
+		Schedule: jobs.Every(10 * time.Minute),
+		Run: func(context.Context) error {
+			idempotency.Purge(time.Now())
+			return nil
+		},
+	})
+	scheduler.Add(jobs.Job{
+		Name:     "auth-rate-cleanup",
+		Schedule: jobs.Every(10 * time.Minute),
+		Run: func(context.Context) error {
+			ipLimit.Purge(time.Now())
+			emailLimit.Purge(time.Now())
+			return nil
+		},
+	})
+	scheduler.Add(jobs.Job{
+		Name:     "scene-views-flush",
+		Schedule: jobs.Every(time.Minute),
+		Run: func(context.Context) error {
+			return sharedScenes.Flush(time.Now())
+		},
+	})
+	scheduler.Add(jobs.Job{
+		Name:     "analytics-retention",
+		Schedule: jobs.Every(time.Hour),
+		Run: func(ctx context.Context) error {
+			n, err := usageEvents.Purge(ctx, time.Now())
+			if n > 0 {
+				log.Printf("Purged %d usage events older than %s", n, cfg.Analytics.Retention)
+			}
+			return err
+		},
+	})
+	scheduler.Add(jobs.Job{
+		Name:     "request-counts-flush",
+		Schedule: jobs.Every(5 * time.Minute),
+		Run: func(ctx context.Context) error {
+			return usageEvents.Flush(ctx, time.Now())
+		},
+	})
+	scheduler.Add(jobs.Job{
+		Name:     "body-views-flush",
+		Schedule: jobs.Every(time.Minute),
+		Run: func(context.Context) error {
+			return viewStats.Flush(time.Now())
+		},
+	})
+	if weekly != nil {
+		digestSchedule, _ := jobs.Parse(cfg.Mail.DigestSchedule) // checked by cfg.Validate
+		scheduler.Add(jobs.Job{
+			Name:     "email-digest",
+			Schedule: digestSchedule,
+			Timeout:  time.Hour,
+			Run: func(ctx context.Context) error {
+				return weekly.Send(ctx, dataset.Bodies(), time.Now())
+			},
+		})
+	}
+	backups, err := openBackups(cfg, imports)
+	if err != nil {
+		log.Fatalf("Failed to set up backups: %v", err)
+	}
+	if backups != nil && cfg.Backup.Schedule != "" {
+		backupSchedule, _ := jobs.Parse(cfg.Backup.Schedule) // checked by cfg.Validate
+		scheduler.Add(jobs.Job{
+			Name:     "backup",
+			Schedule: backupSchedule,
+			Timeout:  time.Hour,
+			Run: func(ctx context.Context) error {
+				info, err := backups.Run(ctx, time.Now())
+				if info.Name != "" {
+					log.Printf("Backup %s: %d files, %d bytes", info.Location, len(info.Files), info.Size)
+				}
+				return err
+			},
+		})
+	}
+	dataset.OnChange(func() {
+		ephemeris.Reset()
+		if cfg.Ephemeris.Precompute {
+			scheduler.Trigger("ephemeris-precompute")
+		}
+		version := dataset.Version()
+		hooks.Publish(webhooks.EventDatasetChanged, version)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := events.Publish(ctx, webhooks.EventDatasetChanged, version); err != nil {
+				log.Printf("broker: %v", err)
+			}
+		}()
+	})
+	scheduler.Start()
+
+	// Heavy simulation/ephemeris routes share a bounded worker pool
+	pool := middleware.NewWorkPool(cfg.Workers.PoolSize, cfg.Workers.QueueSize, cfg.Workers.ComputeTimeout)
+
+	// Their responses are cached, and dropped whenever the dataset changes
+	var responses *middleware.ResponseCache // nil passes requests through
+	if cfg.Responses.Enabled {
+		responses = middleware.NewResponseCache(r, cfg.Responses.MaxEntries)
+	}
+	dataset.OnChange(responses.Invalidate)
+
+	// Time-dependent endpoints read the time from sky; in dev mode a
+	// request can move it with X-Simulated-Time
+	var sky clock.Clock = clock.System{}
 
 	// API routes
-	api := r.Group("/api")
+	api := r.Group("/api", handlers.CountRequests(usageEvents), idempotency.Handler())
+	if cfg.Server.Dev {
+		api.Use(middleware.SimulatedTime())
+		log.Printf("Development mode: X-Simulated-Time is honoured")
+	}
 	{
-		api.GET("/planets", handlers.GetPlanets)
-		api.GET("/planets/:name", handlers.GetPlanetByName)
+		api.GET("/planets", handlers.GetPlanets(dataset))
+		api.GET("/planets/:name", handlers.RecordView(dataset, viewStats), handlers.GetPlanetByName(dataset))
+		api.GET("/planets/:name/related", handlers.GetRelatedBodies(dataset, viewStats))
+		api.GET("/planets/:name/temperature", handlers.GetPlanetTemperature(dataset))
+		api.GET("/planets/:name/features", handlers.GetPlanetFeatures(dataset))
+		api.GET("/planets/:name/models", handlers.GetPlanetModels(dataset, assetStore))
+		api.GET("/planets/:name/images", handlers.GetPlanetImages(dataset, assetStore))
+		api.GET("/planets/:name/pronunciation", handlers.GetPlanetPronunciation(dataset, assetStore))
+		api.GET("/planets/:name/alt-text", handlers.GetPlanetAltText(dataset))
+		api.GET("/asteroids", handlers.GetSmallBodies(dataset))
+		api.GET("/asteroids/export.ndjson", handlers.ExportSmallBodies(dataset))
+		api.GET("/search", handlers.GetSearch(dataset, tours))
+		api.GET("/random", handlers.GetRandom(dataset, sky))
+		api.GET("/kepler3", handlers.GetKepler3(dataset))
+		api.GET("/compare", handlers.GetCompare(dataset))
+		api.GET("/physics/roche", handlers.GetRoche(dataset))
+		api.GET("/physics/escape", handlers.GetEscape(dataset))
+		api.GET("/time/convert", handlers.GetTimeConvert(sky))
+		api.GET("/coords/convert", handlers.GetCoordsConvert(sky))
+		api.GET("/dataset/version", handlers.GetDatasetVersion(dataset))
+		api.GET("/events/stream", handlers.StreamEvents(streams, events))
+		api.GET("/dataset/changelog", handlers.GetDatasetChangelog(dataset))
+		api.GET("/sync", handlers.GetSync(dataset))
+		offlineBundle := handlers.NewOfflineBundle(dataset, translations, tours, assetStore)
+		api.GET("/offline-bundle", handlers.GetOfflineBundle(offlineBundle))
+		api.GET("/offline-bundle/manifest", handlers.GetOfflineManifest(offlineBundle))
+		api.GET("/stars", handlers.GetStars)
+		api.GET("/stars/:name/habitable-zone", handlers.GetHabitableZone(dataset))
+		api.GET("/constellations", handlers.GetConstellations)
+		api.GET("/tours", handlers.GetTours(tours))
+		api.GET("/tours/:id", handlers.GetTour(dataset, tours))
+		api.POST("/digest/subscribe", handlers.SubscribeDigest(weekly))
+		api.GET("/digest/confirm", handlers.ConfirmDigest(weekly))
+		api.GET("/digest/unsubscribe", handlers.UnsubscribeDigest(weekly))
+		api.POST("/digest/unsubscribe", handlers.UnsubscribeDigest(weekly))
+		api.POST("/sandboxes", middleware.UserAuth(sessions, true), handlers.CreateSandbox(sandboxes))
+		api.POST("/analytics/events", handlers.PostAnalyticsEvents(dataset, usageEvents))
+		api.GET("/config", middleware.UserAuth(sessions, false), handlers.GetClientConfig(abTests, modes))
+		api.POST("/experiments/:name/conversions", middleware.UserAuth(sessions, false), handlers.PostConversion(abTests))
+		api.POST("/auth/register", authPerIP, handlers.Register(accounts, sessions, emailTokens, accountMail))
+		api.POST("/auth/login", authPerIP, authPerEmail, handlers.Login(accounts, sessions))
+		api.POST("/auth/refresh", handlers.Refresh(accounts, sessions))
+		api.POST("/auth/logout", middleware.UserAuth(sessions, true), handlers.Logout(sessions))
+		api.POST("/auth/logout-all", middleware.UserAuth(sessions, true), handlers.LogoutAll(sessions))
+		api.POST("/auth/verify", middleware.UserAuth(sessions, true), handlers.SendVerification(accounts, emailTokens, accountMail))
+		api.GET("/auth/verify", handlers.VerifyEmail(accounts, emailTokens))
+		api.POST("/auth/password/forgot", authPerIP, handlers.ForgotPassword(accounts, emailTokens, accountMail))
+		api.POST("/auth/password/reset", authPerIP, handlers.ResetPassword(accounts, emailTokens, sessions))
+		api.GET("/auth/providers", handlers.GetOAuthProviders(providers))
+		api.GET("/auth/oauth/:provider", handlers.StartOAuth(providers, oauthStates))
+		api.GET("/auth/oauth/:provider/callback", handlers.OAuthCallback(providers, oauthStates, accounts, sessions))
+		api.GET("/me", middleware.UserAuth(sessions, true), handlers.GetMe(accounts))
+		api.GET("/planets/:name/comments", middleware.UserAuth(sessions, false), handlers.GetComments(dataset, discussion))
+		api.POST("/planets/:name/comments", middleware.UserAuth(sessions, true), handlers.PostComment(dataset, discussion))
+		api.DELETE("/planets/:name/comments/:id", middleware.UserAuth(sessions, true), handlers.DeleteComment(discussion))
+		api.POST("/reports", middleware.UserAuth(sessions, true), handlers.PostReport(complaints, discussion))
+		signedIn := api.Group("", middleware.UserAuth(sessions, true))
+		signedIn.GET("/me/sessions", handlers.ListSessions(sessions))
+		signedIn.DELETE("/me/sessions/:id", handlers.RevokeSession(sessions))
+		signedIn.POST("/me/identities/:provider", handlers.LinkOAuth(providers, oauthStates))
+		signedIn.DELETE("/me/identities/:provider", handlers.UnlinkOAuth(accounts))
+		signedIn.GET("/me/progress", handlers.GetProgress(dataset, tours, userProgress))
+		signedIn.GET("/me/achievements", handlers.GetAchievements(badges, userProgress))
+		signedIn.DELETE("/me/progress", handlers.ResetProgress(userProgress))
+		signedIn.PUT("/me/progress/bodies/:name", handlers.ExploreBody(dataset, tours, userProgress))
+		signedIn.PUT("/me/progress/tours/:id", handlers.PutTourProgress(tours, userProgress))
+		signedIn.GET("/classes", handlers.GetClasses(classrooms))
+		signedIn.POST("/classes", handlers.CreateClass(classrooms, accounts))
+		signedIn.POST("/classes/join", handlers.JoinClass(classrooms))
+		signedIn.GET("/classes/:id", handlers.GetClass(classrooms))
+		signedIn.DELETE("/classes/:id", handlers.DeleteClass(classrooms))
+		signedIn.POST("/classes/:id/code", handlers.RotateClassCode(classrooms))
+		signedIn.DELETE("/classes/:id/members/:user", handlers.RemoveClassMember(classrooms))
+		signedIn.GET("/classes/:id/progress", handlers.GetClassProgress(classrooms, userProgress))
+		signedIn.POST("/classes/:id/assignments", handlers.AssignToClass(classrooms, tours))
+		signedIn.DELETE("/classes/:id/assignments/:assignment", handlers.UnassignFromClass(classrooms))
+		api.POST("/scenes", handlers.CreateScene(sharedScenes, cfg.Scenes.TTL, cfg.Scenes.MaxTTL))
+		api.GET("/scenes/:id", handlers.GetScene(sharedScenes))
+		// Sandboxes belong to the signed-in user who made them; anyone
+		// may read the shared ones
+		sandboxReads := api.Group("/sandboxes/:id", middleware.UserAuth(sessions, false))
+		sandboxReads.GET("", handlers.GetSandbox(sandboxes))
+		sandboxReads.GET("/planets", handlers.GetSandboxPlanets(dataset, sandboxes))
+		sandboxWrites := api.Group("/sandboxes/:id", middleware.UserAuth(sessions, true))
+		sandboxWrites.DELETE("", handlers.DeleteSandbox(sandboxes))
+		sandboxWrites.PATCH("/planets/:name", handlers.EditSandboxPlanet(dataset, sandboxes))
+		sandboxWrites.DELETE("/planets/:name", handlers.ResetSandboxPlanet(dataset, sandboxes))
+
+		// Cache hits don't wait for a worker. Seasons only depend on the
+		// year; positions and conditions for now age by the minute.
+		compute := pool.Handler()
+		api.GET("/planets/:name/seasons", responses.Handler(time.Hour, 24*time.Hour), compute, handlers.GetPlanetSeasons(dataset, sky))
+		api.GET("/planets/:name/position", responses.Handler(30*time.Second, time.Minute), compute, handlers.GetPlanetPosition(dataset, ephemeris, sky))
+		api.GET("/planets/:name/conditions", responses.Handler(time.Minute, 2*time.Minute), compute, handlers.GetPlanetConditions(dataset, ephemeris, sky))
+		api.GET("/positions", responses.Handler(30*time.Second, time.Minute), compute, handlers.GetPositions(dataset, ephemeris, sky))
+		api.GET("/earth/now", responses.Handler(30*time.Second, time.Minute), compute, handlers.GetEarthNow(sky))
+		api.GET("/sky", responses.Handler(30*time.Second, time.Minute), compute, handlers.GetSky(dataset, sky))
+		api.GET("/saturn/ring-angle", responses.Handler(time.Hour, time.Hour), compute, handlers.GetSaturnRingAngle(dataset, sky))
+		api.GET("/satellites/:id/passes", responses.Handler(5*time.Minute, time.Hour), compute, handlers.GetSatellitePasses(satelliteCatalog, sky))
+		api.GET("/satellites", handlers.GetSatellites(satelliteCatalog, sky))
+		api.GET("/space-weather", handlers.GetSpaceWeather(spaceWeather))
+		api.GET("/launches", handlers.GetLaunches(launchSchedule, dataset))
+		api.GET("/mars/rover-photos", handlers.GetRoverPhotos(roverPhotos))
+		api.GET("/history/today", handlers.GetHistoryToday(sky))
+		api.GET("/history/rss", handlers.GetHistoryFeed(sky, cfg.Mail.PublicURL))
+		api.GET("/glossary", handlers.GetGlossary(dataset))
+		api.GET("/glossary/annotations", handlers.GetGlossaryAnnotations(dataset))
+		api.GET("/glossary/:id", handlers.GetGlossaryTerm(dataset))
+		api.GET("/jupiter/moons/events", responses.Handler(time.Hour, time.Hour), compute, handlers.GetMoonEvents(dataset, sky))
+		api.GET("/jupiter/grs", compute, handlers.GetGRSTransits(grsTracker, dataset, sky))
+
+		// Staff routes: the admin token, or a signed-in account whose role
+		// has the route's permission
+		admin := api.Group("/admin", middleware.AdminAuth(cfg.Admin.Token, sessions, accounts))
+		translate := admin.Group("", middleware.Require(users.PermTranslate))
+		translate.GET("/translations", handlers.ListTranslations(translations))
+		translate.GET("/translations/missing", handlers.GetMissingTranslations(dataset, translations))
+		translate.PUT("/translations/:name/:locale/:field", handlers.PutTranslation(dataset, translations, auditLog))
+		translate.DELETE("/translations/:name/:locale/:field", handlers.DeleteTranslation(dataset, translations, auditLog))
+		content := admin.Group("", middleware.Require(users.PermContent))
+		content.PUT("/tours/:id", handlers.PutTour(dataset, tours, auditLog))
+		content.DELETE("/tours/:id", handlers.DeleteTour(tours, auditLog))
+		content.GET("/badges", handlers.ListBadges(badges))
+		content.PUT("/badges/:id", handlers.PutBadge(dataset, tours, badges, auditLog))
+		content.DELETE("/badges/:id", handlers.DeleteBadge(badges, auditLog))
+		content.GET("/jupiter/grs", handlers.GetGRSTracking(grsTracker))
+		content.PUT("/jupiter/grs", handlers.PutGRSTracking(grsTracker, auditLog))
+		content.POST("/import/sbdb", handlers.ImportSBDB(dataset, sbdb.NewClient(cfg.Upstream.SBDBURL, upstreamPolicy), auditLog, imports))
+		content.GET("/digest/preview", handlers.PreviewDigest(weekly, dataset, sky))
+		content.POST("/assets", handlers.PostAsset(dataset, assetStore, auditLog, int64(cfg.Assets.MaxUploadMB)<<20, imaging.Limits{MinSide: cfg.Assets.ImageMinSide, MaxSide: cfg.Assets.ImageMaxSide}))
+		content.PUT("/assets/*path", handlers.PutAsset(dataset, assetStore, assetSigner, auditLog, int64(cfg.Assets.MaxUploadMB)<<20))
+		content.DELETE("/assets/*path", handlers.DeleteAsset(assetStore, auditLog))
+		content.GET("/content", handlers.ListTranslations(translations))
+		content.PUT("/content/:audience/:name/:locale/:field", handlers.PutContent(dataset, translations, auditLog))
+		content.DELETE("/content/:audience/:name/:locale/:field", handlers.DeleteContent(dataset, translations, auditLog))
+		moderate := admin.Group("", middleware.Require(users.PermModerate))
+		moderate.GET("/comments", handlers.ListComments(discussion))
+		moderate.PUT("/comments/:id/status", handlers.ModerateComment(discussion, auditLog))
+		moderate.DELETE("/comments/:id", handlers.PurgeComment(discussion, auditLog))
+		moderate.GET("/reports", handlers.ListReports(complaints, discussion))
+		moderate.PUT("/reports/:kind/:id", handlers.ResolveReports(complaints, discussion, auditLog))
+		system := admin.Group("", middleware.Require(users.PermAdmin))
+		system.GET("/config", handlers.GetAdminConfig(cfg, sources))
+		system.GET("/modes", handlers.GetModes(modes))
+		system.PUT("/modes", handlers.SetModes(modes, auditLog))
+		system.GET("/users", handlers.ListUsers(accounts))
+		system.PUT("/users/:id/role", handlers.SetUserRole(accounts, auditLog))
+		system.GET("/audit", handlers.GetAudit(auditLog))
+		system.GET("/analytics", handlers.GetAnalytics(usageEvents))
+		system.GET("/stats", handlers.GetStats(usageEvents))
+		system.GET("/experiments", handlers.GetExperimentResults(abTests, usageEvents))
+		system.GET("/jobs", handlers.GetJobs(scheduler))
+		system.GET("/upstreams", handlers.GetUpstreams)
+		system.GET("/cache", handlers.GetResponseCache(responses))
+		system.DELETE("/cache", handlers.PurgeResponseCache(responses, auditLog))
+		system.GET("/assets/cache", handlers.GetAssetCache(assetStore))
+		system.POST("/jobs/:name/run", handlers.RunJob(scheduler))
+		system.GET("/validation", handlers.GetValidation(dataset))
+		system.GET("/migrations", handlers.GetMigrations(migrator))
+		system.GET("/backups", handlers.ListBackups(backups))
+		system.POST("/backup", handlers.CreateBackup(backups, auditLog))
+		system.GET("/export", handlers.ExportArchive(dataset, translations, tours, assetStore, cfg.Admin.ArchiveKey))
+		system.POST("/import", handlers.ImportArchive(dataset, translations, tours, assetStore, auditLog, imports, cfg.Admin.ArchiveKey))
+
+		// Signed URLs for private assets are for staff who manage them
+		api.GET("/assets/signed-url", middleware.AdminAuth(cfg.Admin.Token, sessions, accounts), middleware.Require(users.PermContent), handlers.GetSignedAssetURL(assetStore, assetSigner, cfg.Assets.URLTTL))
+
+		// Webhook subscriptions are managed by admins too
+		hooksAPI := api.Group("/webhooks", middleware.AdminAuth(cfg.Admin.Token, sessions, accounts), middleware.Require(users.PermAdmin))
+		hooksAPI.POST("", handlers.CreateWebhook(hooks, auditLog))
+		hooksAPI.GET("", handlers.ListWebhooks(hooks))
+		hooksAPI.GET("/:id", handlers.GetWebhook(hooks))
+		hooksAPI.DELETE("/:id", handlers.DeleteWebhook(hooks, auditLog))
+		hooksAPI.GET("/:id/deliveries", handlers.GetWebhookDeliveries(hooks))
+	}
+
+	// The texture-heavy routes advertise HTTP/3 when it is served
+	quic := newHTTP3Server(cfg.TLS)
+	var advertiseHTTP3 func(http.Header) error
+	if quic != nil {
+		advertiseHTTP3 = quic.SetQUICHeaders
 	}
 
+	// Models and textures listed in the asset manifest, with range requests;
+	// private ones need a signed URL
+	assetRoutes := r.Group("/assets", middleware.AltSvc(advertiseHTTP3), middleware.SignedURL(assetSigner, func(p string) bool {
+		a, ok := assetStore.Get(strings.TrimPrefix(p, "/assets/"))
+		return ok && a.Private
+	}))
+	assetRoutes.GET("/*path", handlers.ServeAsset(assetStore))
+	assetRoutes.HEAD("/*path", handlers.ServeAsset(assetStore))
+
+	// Textures and photos in the format and width the browser asks for
+	imageRoutes := r.Group("/img", middleware.AltSvc(advertiseHTTP3))
+	imageRoutes.GET("/*path", handlers.ServeImage(assetStore, imageCache))
+	imageRoutes.HEAD("/*path", handlers.ServeImage(assetStore, imageCache))
+
+	// Share links for scenes saved through POST /api/scenes
+	r.GET("/s/:id", handlers.OpenSharedScene(sharedScenes))
+
 	// Serve Angular SPA — try the requested static file; fall back to
 	// index.html so Angular's client-side router handles unknown paths.
-	staticDir := os.Getenv("STATIC_DIR")
-	if staticDir == "" {
-		staticDir = "./frontend/dist/frontend/browser"
+	r.NoRoute(spaHandler(cfg.Server.StaticDir))
+
+	log.Printf("Solar System Explorer running on :%s (static: %s)", cfg.Server.Port, cfg.Server.StaticDir)
+
+	srv := &http.Server{Addr: ":" + cfg.Server.Port, Handler: r}
+	var secure *http.Server // nil unless TLS is configured
+	if cfg.TLS.Enabled() {
+		secure, srv.Handler = tlsServers(cfg.TLS, r)
+		log.Printf("HTTPS on :%s", cfg.TLS.Port)
+		go func() {
+			// With autocert the certificate comes from TLSConfig and both files are empty
+			if err := secure.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile); err != nil && err != http.ErrServerClosed {
+				log.Fatal("Failed to start HTTPS server:", err)
+			}
+		}()
+		if quic != nil {
+			log.Printf("HTTP/3 on udp :%s", cfg.TLS.Port)
+			go func() {
+				if err := serveHTTP3(quic, secure, cfg.TLS, r); err != nil && err != http.ErrServerClosed {
+					log.Fatal("Failed to start HTTP/3 server:", err)
+				}
+			}()
+		}
 	}
-	r.NoRoute(spaHandler(staticDir))
+	if cfg.Server.H2C {
+		srv.Handler = h2c.NewHandler(srv.Handler, &http2.Server{})
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// Drain in-flight requests and flush pending spans on SIGINT/SIGTERM
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	stopStreams()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Shutdown: %v", err)
+	}
+	if secure != nil {
+		if err := secure.Shutdown(ctx); err != nil {
+			log.Printf("Shutdown HTTPS: %v", err)
+		}
+	}
+	if quic != nil && secure != nil {
+		if err := quic.Shutdown(ctx); err != nil {
+			log.Printf("Shutdown HTTP/3: %v", err)
+		}
 	}
-	log.Printf("Solar System Explorer running on :%s (static: %s)", port, staticDir)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	scheduler.Stop(ctx)
+	if err := sharedScenes.Flush(time.Now()); err != nil {
+		log.Printf("Saving scene views: %v", err)
 	}
+	if err := viewStats.Flush(time.Now()); err != nil {
+		log.Printf("Saving body views: %v", err)
+	}
+	if err := usageEvents.Flush(ctx, time.Now()); err != nil {
+		log.Printf("Saving request counts: %v", err)
+	}
+	hooks.Stop(ctx)
+	tracing.Shutdown(ctx)
 }
 
 // spaHandler serves static files from staticDir and falls back to index.html
@@ -60,3 +881,113 @@ func spaHandler(staticDir string) gin.HandlerFunc {
 		fileServer.ServeHTTP(c.Writer, c.Request)
 	}
 }
+
+// newS3Client returns a client for bucket with the configured S3 account
+func newS3Client(cfg *config.Config, bucket string) (*s3.Client, error) {
+	return s3.New(s3.Config{
+		Endpoint:        cfg.S3.Endpoint,
+		Region:          cfg.S3.Region,
+		Bucket:          bucket,
+		AccessKeyID:     cfg.S3.AccessKeyID,
+		SecretAccessKey: cfg.S3.SecretAccessKey,
+	})
+}
+
+// openBackups returns backups of every file of user data configured,
+// and of imported bodies when they are in the database; nil when backups
+// have nowhere to go
+func openBackups(cfg *config.Config, imports store.BodyRepository) (*backup.Backups, error) {
+	if !cfg.Backup.Enabled() {
+		return nil, nil
+	}
+	var target backup.Target = backup.Dir(cfg.Backup.Dir)
+	if cfg.Backup.S3Bucket != "" {
+		client, err := newS3Client(cfg, cfg.Backup.S3Bucket)
+		if err != nil {
+			return nil, err
+		}
+		target = backup.Bucket{Client: client, Prefix: cfg.Backup.S3Prefix}
+	}
+
+	var sources []backup.Source
+	for name, path := range map[string]string{
+		"users.json":        cfg.Auth.UsersFile,
+		"sessions.json":     cfg.Auth.SessionsFile,
+		"email-tokens.json": cfg.Auth.TokensFile,
+		"progress.json":     cfg.Progress.File,
+		"achievements.json": cfg.Progress.AchievementsFile,
+		"classes.json":      cfg.Classes.File,
+		"comments.json":     cfg.Comments.File,
+		"reports.json":      cfg.Reports.File,
+		"sandboxes.json":    cfg.Sandboxes.File,
+		"scenes.json":       cfg.Scenes.File,
+		"views.json":        cfg.Views.File,
+		"analytics.jsonl":   cfg.Analytics.File,
+		"digest.json":       cfg.Mail.DigestFile,
+		"webhooks.json":     cfg.Webhooks.File,
+		"translations.json": cfg.Data.TranslationsFile,
+		"tours.json":        cfg.Data.ToursFile,
+		"grs.json":          cfg.Data.GRSFile,
+		"imports.json":      cfg.Data.ImportsFile,
+		"audit.jsonl":       cfg.Admin.AuditFile,
+	} {
+		if path != "" {
+			sources = append(sources, backup.Source{Name: name, Path: path})
+		}
+	}
+	if cfg.DB.Driver != "" {
+		sources = append(sources, backup.Source{Name: "imports.json", Read: func(ctx context.Context) ([]byte, error) {
+			bodies, err := imports.Load(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return json.MarshalIndent(bodies, "", "  ")
+		}})
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Name < sources[j].Name })
+	if len(sources) == 0 {
+		log.Printf("Backups are on, but all user data is kept in memory; set the *_FILE settings to have anything to back up")
+	}
+	return backup.New(target, cfg.Backup.Keep, sources), nil
+}
+
+// runTask runs the one-off job cfg.Task against the database
+func runTask(cfg *config.Config) error {
+	ctx := context.Background()
+	db, err := store.ConnectPostgres(ctx, cfg.DB.DSN, store.Pool{MaxConns: 1, MaxIdleConns: 1})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	m := store.NewMigrator(db)
+	switch cfg.Task {
+	case config.TaskMigrate:
+		done, err := m.Migrate(ctx)
+		for _, v := range done {
+			log.Printf("Applied migration %s", v)
+		}
+		if err == nil && len(done) == 0 {
+			log.Printf("No pending migrations")
+		}
+		return err
+	case config.TaskRollback:
+		v, err := m.Rollback(ctx)
+		if err == nil {
+			log.Printf("Rolled back migration %s", v)
+		}
+		return err
+	}
+	return fmt.Errorf("unknown task %q", cfg.Task)
+}
+
+// precomputeEphemeris builds a daily position table covering
+// PrecomputePast..PrecomputeFuture around today. The scheduler reruns it
+// every PrecomputeRefresh so the window keeps sliding forward.
+func precomputeEphemeris(cache *orbits.Cache, dataset *store.Store, cfg config.Ephemeris) {
+	started := time.Now()
+	today := started.UTC().Truncate(24 * time.Hour)
+	table := orbits.BuildTable(dataset.Bodies(), today.Add(-cfg.PrecomputePast), today.Add(cfg.PrecomputeFuture), 24*time.Hour)
+	cache.SetTable(table)
+	from, to := table.Range()
+	log.Printf("Ephemeris table ready: %s – %s (%s)", from.Format("2006-01-02"), to.Format("2006-01-02"), time.Since(started).Round(time.Millisecond))
+}
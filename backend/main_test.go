@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/config"
+	"solar-system-explorer/backend/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/quic-go/quic-go/http3"
+)
+
+func TestSPAHandlerRange(t *testing.T) {
+	dir := t.TempDir()
+	texture := bytes.Repeat([]byte("0123456789"), 1000)
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("<app-root></app-root>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "mars.jpg"), texture, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(dir, "mars.jpg"), modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.NoRoute(spaHandler(dir))
+
+	tests := []struct {
+		name   string
+		path   string
+		header map[string]string
+		status int
+		body   []byte
+	}{
+		{"range", "/mars.jpg", map[string]string{"Range": "bytes=5-14"}, http.StatusPartialContent, texture[5:15]},
+		{"resume", "/mars.jpg", map[string]string{"Range": "bytes=9990-"}, http.StatusPartialContent, texture[9990:]},
+		{"If-Range unchanged", "/mars.jpg", map[string]string{"Range": "bytes=0-4", "If-Range": modTime.Format(http.TimeFormat)}, http.StatusPartialContent, texture[:5]},
+		{"If-Range changed", "/mars.jpg", map[string]string{"Range": "bytes=0-4", "If-Range": modTime.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK, texture},
+		{"unsatisfiable", "/mars.jpg", map[string]string{"Range": "bytes=20000-"}, http.StatusRequestedRangeNotSatisfiable, nil},
+		{"client route falls back to index", "/planets/mars", nil, http.StatusOK, []byte("<app-root></app-root>")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.body != nil && !bytes.Equal(w.Body.Bytes(), tt.body) {
+				t.Errorf("body = %q, want %q", truncate(w.Body.Bytes()), truncate(tt.body))
+			}
+		})
+	}
+}
+
+func truncate(b []byte) []byte {
+	if len(b) > 40 {
+		return b[:40]
+	}
+	return b
+}
+
+// writeTestCert writes a self-signed certificate for 127.0.0.1 to dir and
+// returns the file paths and a pool trusting it
+func writeTestCert(t *testing.T, dir string) (string, string, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return certFile, keyFile, pool
+}
+
+// Alt-Svc must only name HTTP/3 once the QUIC listener answers, and the
+// port it names must serve the app over HTTP/3
+func TestHTTP3AltSvc(t *testing.T) {
+	certFile, keyFile, pool := writeTestCert(t, t.TempDir())
+	cfg := config.TLS{Port: "0", CertFile: certFile, KeyFile: keyFile, HTTP3: true}
+	quic := newHTTP3Server(cfg)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/assets/*path", middleware.AltSvc(quic.SetQUICHeaders), func(c *gin.Context) { c.String(http.StatusOK, "texture") })
+
+	overTLS := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/assets/mars.jpg", nil)
+		req.TLS = &tls.ConnectionState{}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if got := overTLS().Header().Get("Alt-Svc"); got != "" {
+		t.Fatalf("Alt-Svc = %q before HTTP/3 listens", got)
+	}
+
+	go serveHTTP3(quic, nil, cfg, r)
+	defer quic.Close()
+	var altSvc string
+	for deadline := time.Now().Add(5 * time.Second); altSvc == "" && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		altSvc = overTLS().Header().Get("Alt-Svc")
+	}
+	_, rest, ok := strings.Cut(altSvc, `h3=":`)
+	port, _, _ := strings.Cut(rest, `"`)
+	if !ok || port == "" || port == "0" {
+		t.Fatalf("Alt-Svc = %q, want h3 on the listening port", altSvc)
+	}
+	plain := httptest.NewRecorder()
+	r.ServeHTTP(plain, httptest.NewRequest(http.MethodGet, "/assets/mars.jpg", nil))
+	if got := plain.Header().Get("Alt-Svc"); got != "" {
+		t.Errorf("Alt-Svc = %q on plain HTTP", got)
+	}
+
+	client := &http.Client{Transport: &http3.RoundTripper{TLSClientConfig: &tls.Config{RootCAs: pool}}, Timeout: 5 * time.Second}
+	resp, err := client.Get("https://127.0.0.1:" + port + "/assets/mars.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.ProtoMajor != 3 || string(body) != "texture" {
+		t.Errorf("got %s %q, want HTTP/3 and the texture", resp.Proto, body)
+	}
+}
@@ -0,0 +1,362 @@
+// Package marsphotos is a client for NASA's Mars Rover Photos API
+// (https://api.nasa.gov, "Mars Rover Photos"). It reads a rover's mission
+// manifest and the raw images of a sol, and keeps both in a directory so
+// the gallery survives restarts and spares the shared API key's hourly
+// quota.
+package marsphotos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/clock"
+	"solar-system-explorer/backend/upstream"
+)
+
+// DefaultBaseURL is the Mars Rover Photos API on api.nasa.gov
+const DefaultBaseURL = "https://api.nasa.gov/mars-photos/api/v1"
+
+// Rovers are the rovers the API has photos of
+var Rovers = []string{"curiosity", "perseverance", "opportunity", "spirit"}
+
+// upstreamPage is how many photos the API returns per page
+const upstreamPage = 25
+
+// settleSols is how far behind the latest sol a sol is taken to have all
+// its images downlinked, after which its pages are kept for good
+const settleSols = 30
+
+// ErrUnavailable is returned when the API can't be reached and nothing is
+// cached to fall back on
+var ErrUnavailable = errors.New("marsphotos: Mars Rover Photos API is unavailable")
+
+// Manifest is a rover's mission summary
+type Manifest struct {
+	Rover       string    `json:"rover"`
+	Status      string    `json:"status"` // active or complete
+	LandingDate string    `json:"landing_date"`
+	MaxSol      int       `json:"max_sol"`
+	MaxDate     string    `json:"max_date"`
+	TotalPhotos int       `json:"total_photos"`
+	Cameras     []string  `json:"cameras"` // every camera with photos, in order of first use
+	FetchedAt   time.Time `json:"fetched_at"`
+	// Sols are the sols with photos, by sol number
+	Sols map[int]Sol `json:"sols"`
+	// Stale is set on a manifest older than the cache TTL served while
+	// the API can't be reached
+	Stale bool `json:"-"`
+}
+
+// Sol is one sol of the manifest
+type Sol struct {
+	EarthDate   string   `json:"earth_date"`
+	TotalPhotos int      `json:"total_photos"`
+	Cameras     []string `json:"cameras"`
+}
+
+// Photo is a raw image
+type Photo struct {
+	ID        int    `json:"id"`
+	Sol       int    `json:"sol"`
+	EarthDate string `json:"earth_date"`
+	Camera    Camera `json:"camera"`
+	ImgSrc    string `json:"img_src"` // https
+}
+
+// Camera names the instrument a photo was taken with
+type Camera struct {
+	Name     string `json:"name"` // e.g. NAVCAM_LEFT
+	FullName string `json:"full_name"`
+}
+
+// page is one cached upstream page
+type page struct {
+	Photos    []Photo   `json:"photos"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Client fetches manifests and photos and caches them in Dir (in memory
+// when empty) for CacheTTL; pages of settled sols are kept for good.
+// While the API is unreachable expired copies are served, marked Stale.
+type Client struct {
+	BaseURL  string
+	APIKey   string
+	HTTP     *http.Client
+	Dir      string
+	CacheTTL time.Duration
+	Clock    clock.Clock
+
+	refresh sync.Mutex // one fetch at a time, sparing the quota
+	mu      sync.Mutex
+	mem     map[string][]byte // when Dir is empty
+}
+
+// NewClient returns a traced client for baseURL (DefaultBaseURL if empty)
+// that retries and breaks its circuit as policy says, caching in dir
+func NewClient(baseURL, apiKey, dir string, cacheTTL time.Duration, policy upstream.Policy) (*Client, error) {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &Client{
+		BaseURL:  strings.TrimSuffix(baseURL, "/"),
+		APIKey:   apiKey,
+		HTTP:     upstream.Client("mars-photos", 20*time.Second, policy),
+		Dir:      dir,
+		CacheTTL: cacheTTL,
+		Clock:    clock.System{},
+		mem:      make(map[string][]byte),
+	}, nil
+}
+
+// Manifest returns rover's mission manifest
+func (c *Client) Manifest(ctx context.Context, rover string) (*Manifest, error) {
+	key := rover + "-manifest.json"
+	var m Manifest
+	fresh, found := c.load(key, &m, func() time.Time { return m.FetchedAt }, false)
+	if fresh {
+		return &m, nil
+	}
+	c.refresh.Lock()
+	defer c.refresh.Unlock()
+	if fresh, found = c.load(key, &m, func() time.Time { return m.FetchedAt }, false); fresh {
+		return &m, nil
+	}
+	fetched, err := c.fetchManifest(ctx, rover)
+	if err != nil {
+		if found && ctx.Err() == nil {
+			m.Stale = true
+			return &m, nil
+		}
+		return nil, err
+	}
+	c.store(key, fetched)
+	return fetched, nil
+}
+
+// Photos returns limit photos of rover's sol from offset on, only those
+// of camera unless it is empty, and whether there are more. m is the
+// rover's manifest, which tells a settled sol. stale is set when any page
+// came from an expired copy.
+func (c *Client) Photos(ctx context.Context, m *Manifest, sol int, camera string, offset, limit int) (photos []Photo, more, stale bool, err error) {
+	settled := sol <= m.MaxSol-settleSols
+	// One photo past the page tells whether there are more
+	for n := offset / upstreamPage; ; n++ {
+		p, pstale, err := c.page(ctx, m.Rover, sol, camera, n+1, settled)
+		if err != nil {
+			return nil, false, false, err
+		}
+		stale = stale || pstale
+		from := max(0, offset-n*upstreamPage)
+		if from < len(p.Photos) {
+			photos = append(photos, p.Photos[from:]...)
+		}
+		if len(photos) > limit {
+			return photos[:limit], true, stale, nil
+		}
+		if len(p.Photos) < upstreamPage {
+			return photos, false, stale, nil
+		}
+	}
+}
+
+// page returns upstream page n (from 1) of a sol
+func (c *Client) page(ctx context.Context, rover string, sol int, camera string, n int, settled bool) (page, bool, error) {
+	key := fmt.Sprintf("%s-sol%d-%s-p%d.json", rover, sol, cameraKey(camera), n)
+	var p page
+	fresh, found := c.load(key, &p, func() time.Time { return p.FetchedAt }, settled)
+	if fresh {
+		return p, false, nil
+	}
+	c.refresh.Lock()
+	defer c.refresh.Unlock()
+	if fresh, found = c.load(key, &p, func() time.Time { return p.FetchedAt }, settled); fresh {
+		return p, false, nil
+	}
+	fetched, err := c.fetchPage(ctx, rover, sol, camera, n)
+	if err != nil {
+		if found && ctx.Err() == nil {
+			return p, true, nil
+		}
+		return page{}, false, err
+	}
+	c.store(key, fetched)
+	return fetched, false, nil
+}
+
+// cameraKey names camera, or all of them, in cache keys
+func cameraKey(camera string) string {
+	if camera == "" {
+		return "all"
+	}
+	return strings.ToLower(camera)
+}
+
+// load decodes the copy under key into v and reports whether it is fresh,
+// by the time fetchedAt reads from v, and whether there was one at all
+func (c *Client) load(key string, v any, fetchedAt func() time.Time, forever bool) (fresh, found bool) {
+	var data []byte
+	if c.Dir == "" {
+		c.mu.Lock()
+		data = c.mem[key]
+		c.mu.Unlock()
+	} else {
+		data, _ = os.ReadFile(filepath.Join(c.Dir, key))
+	}
+	if data == nil || json.Unmarshal(data, v) != nil {
+		return false, false
+	}
+	return forever || c.Clock.Now().Sub(fetchedAt()) < c.CacheTTL, true
+}
+
+// store keeps v under key, written to a temporary file and renamed so a
+// concurrent load never reads part of it. A failed write only costs a
+// refetch.
+func (c *Client) store(key string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if c.Dir == "" {
+		c.mu.Lock()
+		c.mem[key] = data
+		c.mu.Unlock()
+		return
+	}
+	f, err := os.CreateTemp(c.Dir, "."+key+".*")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(c.Dir, key))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+func (c *Client) fetchManifest(ctx context.Context, rover string) (*Manifest, error) {
+	var body struct {
+		Manifest struct {
+			Name        string `json:"name"`
+			Status      string `json:"status"`
+			LandingDate string `json:"landing_date"`
+			MaxSol      int    `json:"max_sol"`
+			MaxDate     string `json:"max_date"`
+			TotalPhotos int    `json:"total_photos"`
+			Photos      []struct {
+				Sol         int      `json:"sol"`
+				EarthDate   string   `json:"earth_date"`
+				TotalPhotos int      `json:"total_photos"`
+				Cameras     []string `json:"cameras"`
+			} `json:"photos"`
+		} `json:"photo_manifest"`
+	}
+	if err := c.get(ctx, "/manifests/"+rover, nil, 32<<20, &body); err != nil {
+		return nil, err
+	}
+	raw := body.Manifest
+	m := &Manifest{
+		Rover:       strings.ToLower(raw.Name),
+		Status:      raw.Status,
+		LandingDate: raw.LandingDate,
+		MaxSol:      raw.MaxSol,
+		MaxDate:     raw.MaxDate,
+		TotalPhotos: raw.TotalPhotos,
+		Cameras:     []string{},
+		FetchedAt:   c.Clock.Now().UTC(),
+		Sols:        make(map[int]Sol, len(raw.Photos)),
+	}
+	if m.Rover == "" {
+		m.Rover = rover
+	}
+	seen := map[string]bool{}
+	for _, s := range raw.Photos {
+		m.Sols[s.Sol] = Sol{EarthDate: s.EarthDate, TotalPhotos: s.TotalPhotos, Cameras: s.Cameras}
+		for _, cam := range s.Cameras {
+			if !seen[cam] {
+				seen[cam] = true
+				m.Cameras = append(m.Cameras, cam)
+			}
+		}
+	}
+	return m, nil
+}
+
+func (c *Client) fetchPage(ctx context.Context, rover string, sol int, camera string, n int) (page, error) {
+	q := url.Values{"sol": {strconv.Itoa(sol)}, "page": {strconv.Itoa(n)}}
+	if camera != "" {
+		q.Set("camera", strings.ToLower(camera))
+	}
+	var body struct {
+		Photos []struct {
+			ID        int    `json:"id"`
+			Sol       int    `json:"sol"`
+			EarthDate string `json:"earth_date"`
+			ImgSrc    string `json:"img_src"`
+			Camera    Camera `json:"camera"`
+		} `json:"photos"`
+	}
+	if err := c.get(ctx, "/rovers/"+rover+"/photos", q, 4<<20, &body); err != nil {
+		return page{}, err
+	}
+	p := page{Photos: make([]Photo, 0, len(body.Photos)), FetchedAt: c.Clock.Now().UTC()}
+	for _, r := range body.Photos {
+		p.Photos = append(p.Photos, Photo{
+			ID:        r.ID,
+			Sol:       r.Sol,
+			EarthDate: r.EarthDate,
+			Camera:    r.Camera,
+			// The older rovers' images are linked over plain http
+			ImgSrc: strings.Replace(r.ImgSrc, "http://", "https://", 1),
+		})
+	}
+	return p, nil
+}
+
+// get decodes the JSON at path with query q, read up to limit bytes,
+// into v
+func (c *Client) get(ctx context.Context, path string, q url.Values, limit int64, v any) error {
+	u := c.BaseURL + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	// api.data.gov takes the key as a header too, which keeps it out of
+	// the traced URL
+	req.Header.Set("X-Api-Key", c.APIKey)
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: upstream returned %s", ErrUnavailable, resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, limit)).Decode(v); err != nil {
+		return fmt.Errorf("marsphotos: decoding response: %w", err)
+	}
+	return nil
+}
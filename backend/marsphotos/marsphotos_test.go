@@ -0,0 +1,135 @@
+package marsphotos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/testutil"
+)
+
+// fakeNASA serves a curiosity manifest with max sol 100 and 30 photos on
+// every sol, counting requests. While down it answers 503.
+type fakeNASA struct {
+	calls atomic.Int32
+	down  atomic.Bool
+}
+
+func (f *fakeNASA) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.calls.Add(1)
+	if f.down.Load() || r.Header.Get("X-Api-Key") != "test-key" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	switch r.URL.Path {
+	case "/manifests/curiosity":
+		fmt.Fprint(w, `{"photo_manifest":{"name":"Curiosity","status":"active","max_sol":100,"photos":[{"sol":1,"cameras":["NAVCAM","MAST"]},{"sol":2,"cameras":["FHAZ","NAVCAM"]}]}}`)
+	case "/rovers/curiosity/photos":
+		sol, _ := strconv.Atoi(r.URL.Query().Get("sol"))
+		n, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		var photos []string
+		for id := (n-1)*upstreamPage + 1; id <= min(n*upstreamPage, 30); id++ {
+			photos = append(photos, fmt.Sprintf(`{"id":%d,"sol":%d,"img_src":"http://mars.example/%d.jpg","camera":{"name":"NAVCAM"}}`, id, sol, id))
+		}
+		fmt.Fprintf(w, `{"photos":[%s]}`, strings.Join(photos, ","))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestClient(t *testing.T, dir string, clk *testutil.Clock) (*Client, *fakeNASA) {
+	t.Helper()
+	nasa := &fakeNASA{}
+	srv := httptest.NewServer(nasa)
+	t.Cleanup(srv.Close)
+	return &Client{BaseURL: srv.URL, APIKey: "test-key", HTTP: srv.Client(), Dir: dir, CacheTTL: time.Hour, Clock: clk, mem: make(map[string][]byte)}, nasa
+}
+
+func TestManifestCache(t *testing.T) {
+	clk := testutil.NewClock(time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC))
+	dir := t.TempDir()
+	c, nasa := newTestClient(t, dir, clk)
+	ctx := context.Background()
+
+	m, err := c.Manifest(ctx, "curiosity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Rover != "curiosity" || m.MaxSol != 100 || len(m.Cameras) != 3 {
+		t.Errorf("manifest = %+v", m)
+	}
+	c.Manifest(ctx, "curiosity")
+	if n := nasa.calls.Load(); n != 1 {
+		t.Errorf("%d upstream calls within the TTL, want 1", n)
+	}
+
+	// The copy on disk outlives the process
+	restarted, restartedNASA := newTestClient(t, dir, clk)
+	if _, err := restarted.Manifest(ctx, "curiosity"); err != nil || restartedNASA.calls.Load() != 0 {
+		t.Errorf("after a restart: err %v, %d upstream calls", err, restartedNASA.calls.Load())
+	}
+
+	// Expired and the API down: the old copy, marked stale
+	clk.Advance(2 * time.Hour)
+	nasa.down.Store(true)
+	if m, err := c.Manifest(ctx, "curiosity"); err != nil || !m.Stale {
+		t.Errorf("API down: manifest %+v, err %v; want the stale copy", m, err)
+	}
+	nasa.down.Store(false)
+	if m, err := c.Manifest(ctx, "curiosity"); err != nil || m.Stale || !m.FetchedAt.Equal(clk.Now()) {
+		t.Errorf("API back: manifest %+v, err %v; want a fresh one", m, err)
+	}
+
+	// Nothing cached and the API down
+	nasa.down.Store(true)
+	if _, err := c.Manifest(ctx, "spirit"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("err = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestPhotosCache(t *testing.T) {
+	clk := testutil.NewClock(time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC))
+	c, nasa := newTestClient(t, "", clk)
+	ctx := context.Background()
+	m, err := c.Manifest(ctx, "curiosity")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	photos, more, _, err := c.Photos(ctx, m, 1, "", 20, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(photos) != 8 || photos[0].ID != 21 || !more || photos[0].ImgSrc != "https://mars.example/21.jpg" {
+		t.Errorf("photos 20-28: %d from %d, more %v", len(photos), photos[0].ID, more)
+	}
+	if photos, more, _, _ := c.Photos(ctx, m, 1, "", 24, 10); len(photos) != 6 || more {
+		t.Errorf("photos 24-: %d, more %v; want the last 6", len(photos), more)
+	}
+
+	// Past the TTL a settled sol's pages are still used; a recent sol's
+	// are fetched again
+	c.Photos(ctx, m, 99, "", 0, 5)
+	before := nasa.calls.Load()
+	clk.Advance(2 * time.Hour)
+	c.Photos(ctx, m, 1, "", 0, 5)
+	if n := nasa.calls.Load() - before; n != 0 {
+		t.Errorf("a settled sol was fetched again (%d calls)", n)
+	}
+	if _, _, stale, _ := c.Photos(ctx, m, 99, "", 0, 5); nasa.calls.Load()-before != 1 || stale {
+		t.Errorf("a recent sol wasn't refreshed: %d calls", nasa.calls.Load()-before)
+	}
+
+	clk.Advance(2 * time.Hour)
+	nasa.down.Store(true)
+	if photos, _, stale, err := c.Photos(ctx, m, 99, "", 0, 5); err != nil || !stale || len(photos) != 5 {
+		t.Errorf("API down: %d photos, stale %v, err %v; want the stale copy", len(photos), stale, err)
+	}
+}
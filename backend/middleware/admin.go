@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Gin context keys set by AdminAuth
+const (
+	adminActorKey = "admin.actor"
+	adminRoleKey  = "admin.role"
+)
+
+// AdminAuth lets staff into the admin API, either with the static bearer
+// token, which acts as an admin, or with a user session, in which case
+// the account's current role applies; Require then checks the route's
+// permission. With an empty token only sessions are accepted. Curators
+// sharing the token name themselves in X-Admin-User, which is what the
+// audit log records; session users are recorded by email.
+func AdminAuth(token string, sessions *users.Sessions, us *users.Users) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, role, claims, ok := adminIdentity(c, token, sessions, us)
+		if !ok {
+			unauthorized(c)
+			return
+		}
+		if claims != nil {
+			c.Set(userKey, *claims)
+		}
+		c.Set(adminActorKey, actor)
+		c.Set(adminRoleKey, role)
+		c.Next()
+	}
+}
+
+// IsAdmin reports whether a request carries admin credentials, checked
+// as AdminAuth does: the static token, or a session whose account is an
+// admin. It is for middleware that runs before the routes' own AdminAuth.
+func IsAdmin(token string, sessions *users.Sessions, us *users.Users) func(*gin.Context) bool {
+	return func(c *gin.Context) bool {
+		_, role, _, ok := adminIdentity(c, token, sessions, us)
+		return ok && role == users.RoleAdmin
+	}
+}
+
+// adminIdentity checks the bearer token against the static token and
+// then the sessions, returning who is asking, their role and, for a
+// session, its claims
+func adminIdentity(c *gin.Context, token string, sessions *users.Sessions, us *users.Users) (actor, role string, claims *users.Claims, ok bool) {
+	got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		return "", "", nil, false
+	}
+	if actor, ok := staticAdmin(c, token); ok {
+		return actor, users.RoleAdmin, nil, true
+	}
+	verified, err := sessions.Verify(got, time.Now())
+	if err != nil {
+		return "", "", nil, false
+	}
+	// Look the role up rather than trusting the token, so demotions
+	// take effect at once
+	user, err := us.Get(verified.Subject)
+	if err != nil {
+		return "", "", nil, false
+	}
+	return user.Email, user.Role, &verified, true
+}
+
+// staticAdmin reports whether the request carries the static token, and
+// the curator it names in X-Admin-User
+func staticAdmin(c *gin.Context, token string) (actor string, ok bool) {
+	got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+		return "", false
+	}
+	actor = strings.TrimSpace(c.GetHeader("X-Admin-User"))
+	if actor == "" || len(actor) > 64 {
+		actor = "admin"
+	}
+	return actor, true
+}
+
+// Require lets a request through only if AdminAuth found a role with
+// permission p
+func Require(p users.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !users.Can(c.GetString(adminRoleKey), p) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Your role does not allow this"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func unauthorized(c *gin.Context) {
+	c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+}
+
+// AdminActor returns who is making an admin request, as set by AdminAuth
+func AdminActor(c *gin.Context) string {
+	if actor := c.GetString(adminActorKey); actor != "" {
+		return actor
+	}
+	return "admin"
+}
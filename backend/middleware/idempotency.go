@@ -0,0 +1,372 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/internal/random"
+	"solar-system-explorer/backend/redis"
+	"solar-system-explorer/backend/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxIdempotentBody caps the request and response bodies Idempotency
+// keeps; larger requests run without replay protection
+const maxIdempotentBody = 1 << 20
+
+// Idempotency lets clients retry a POST safely: a request carrying an
+// Idempotency-Key runs once, and retries with the same key get the
+// stored response instead of creating a second note, scene or sandbox.
+// Keys are scoped to the caller, so one caller can't replay another's
+// response. Only successful and client error answers are kept; after a
+// 5xx or a panic the retry runs again.
+type Idempotency struct {
+	caller  func(*gin.Context) string
+	entries idempotencyStore
+}
+
+// idempotent is one key's request and, once it finishes, its response
+type idempotent struct {
+	fingerprint string        // method, path and body hash
+	done        chan struct{} // closed when the response is stored
+	status      int
+	header      http.Header
+	body        []byte
+	expires     time.Time
+	owner       string // the request that claimed a key kept in Redis
+}
+
+// idempotencyStore keeps the entries by scoped key
+type idempotencyStore interface {
+	// claim returns the entry for id, creating it in progress if there
+	// is none; fresh reports whether it was created
+	claim(ctx context.Context, id, fingerprint string, now time.Time) (e *idempotent, fresh bool, err error)
+	// finish stores the response recorded in e
+	finish(ctx context.Context, id string, e *idempotent) error
+	// release forgets e, so the next retry runs the request again
+	release(ctx context.Context, id string, e *idempotent)
+	purge(now time.Time) int
+}
+
+// NewIdempotency keeps responses in this process for ttl, remembering at
+// most max keys. caller names who sends a request, as IdempotencyCaller
+// does.
+func NewIdempotency(ttl time.Duration, max int, caller func(*gin.Context) string) *Idempotency {
+	return &Idempotency{caller: caller, entries: &memoryIdempotency{ttl: ttl, max: max, entries: make(map[string]*idempotent)}}
+}
+
+// NewSharedIdempotency keeps responses in Redis for ttl, so a retry
+// that reaches another replica is replayed too
+func NewSharedIdempotency(client *redis.Client, ttl time.Duration, caller func(*gin.Context) string) *Idempotency {
+	return &Idempotency{caller: caller, entries: redisIdempotency{client: client, ttl: ttl}}
+}
+
+// IdempotencyCaller names who sends a request the way the routes' own
+// auth will see them, though Idempotency runs before it: the curator the
+// static admin token names in X-Admin-User, the account a session belongs
+// to, or the client IP for anyone else. A signed-in user's key holds
+// across a refreshed token or a second device.
+func IdempotencyCaller(token string, sessions *users.Sessions) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		if actor, ok := staticAdmin(c, token); ok {
+			return "admin:" + actor
+		}
+		if got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+			if claims, err := sessions.Verify(got, time.Now()); err == nil {
+				return "user:" + claims.Subject
+			}
+		}
+		return "ip:" + c.ClientIP()
+	}
+}
+
+// Handler returns the middleware. Requests other than POST, and those
+// without the header, pass straight through.
+func (i *Idempotency) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBody+1))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Could not read the request body"})
+			return
+		}
+		if len(body) > maxIdempotentBody {
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+			c.Next()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(body)
+		fingerprint := c.Request.Method + " " + c.Request.URL.RequestURI() + " " + hex.EncodeToString(sum[:])
+		id := idempotencyScope(i.caller(c), key)
+
+		ctx := c.Request.Context()
+		e, fresh, err := i.entries.claim(ctx, id, fingerprint, time.Now())
+		if err != nil {
+			log.Printf("idempotency: %v", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Try again later"})
+			return
+		}
+		switch {
+		case e.fingerprint != fingerprint:
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+			return
+		case !fresh:
+			select {
+			case <-e.done:
+			default:
+				c.Header("Retry-After", "1")
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still in progress"})
+				return
+			}
+			for k, v := range e.header {
+				c.Writer.Header()[k] = v
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(e.status, e.header.Get("Content-Type"), e.body)
+			c.Abort()
+			return
+		}
+
+		rec := &recorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		defer func() {
+			// A panic unwinds through here before gin.Recovery writes the
+			// 500, so the recorded status would still read 200
+			if p := recover(); p != nil {
+				i.entries.release(ctx, id, e)
+				panic(p)
+			}
+			status := rec.Status()
+			if status >= 500 || rec.overflow {
+				i.entries.release(ctx, id, e)
+				return
+			}
+			e.status, e.header, e.body = status, rec.Header().Clone(), rec.buf.Bytes()
+			if err := i.entries.finish(ctx, id, e); err != nil {
+				log.Printf("idempotency: %v", err)
+			}
+		}()
+		c.Next()
+	}
+}
+
+// idempotencyScope hashes key together with the caller, so callers don't
+// share one key space
+func idempotencyScope(caller, key string) string {
+	sum := sha256.Sum256([]byte(caller + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Purge drops expired keys and returns how many. Keys kept in Redis
+// expire by themselves.
+func (i *Idempotency) Purge(now time.Time) int {
+	return i.entries.purge(now)
+}
+
+// memoryIdempotency keeps the entries in a map
+type memoryIdempotency struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	entries map[string]*idempotent
+}
+
+func (m *memoryIdempotency) claim(_ context.Context, id, fingerprint string, now time.Time) (*idempotent, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[id]; ok && now.Before(e.expires) {
+		return e, false, nil
+	}
+	if len(m.entries) >= m.max {
+		m.purgeLocked(now)
+	}
+	if len(m.entries) >= m.max {
+		m.evictOldest()
+	}
+	e := &idempotent{fingerprint: fingerprint, done: make(chan struct{}), expires: now.Add(m.ttl)}
+	m.entries[id] = e
+	return e, true, nil
+}
+
+func (m *memoryIdempotency) finish(_ context.Context, _ string, e *idempotent) error {
+	close(e.done)
+	return nil
+}
+
+func (m *memoryIdempotency) release(_ context.Context, id string, e *idempotent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entries[id] == e {
+		delete(m.entries, id)
+	}
+	close(e.done)
+}
+
+func (m *memoryIdempotency) purge(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.purgeLocked(now)
+}
+
+// purgeLocked drops expired keys. Callers hold m.mu.
+func (m *memoryIdempotency) purgeLocked(now time.Time) int {
+	n := 0
+	for id, e := range m.entries {
+		if !now.Before(e.expires) {
+			delete(m.entries, id)
+			n++
+		}
+	}
+	return n
+}
+
+// evictOldest drops the key closest to expiring. Callers hold m.mu.
+func (m *memoryIdempotency) evictOldest() {
+	var oldest string
+	for id, e := range m.entries {
+		if oldest == "" || e.expires.Before(m.entries[oldest].expires) {
+			oldest = id
+		}
+	}
+	delete(m.entries, oldest)
+}
+
+// idempotencyLease bounds how long a key kept in Redis stays in progress.
+// It outlasts any request, but a replica that dies mid-request doesn't
+// lock the key out for the whole TTL.
+const idempotencyLease = 5 * time.Minute
+
+// redisIdempotency keeps each entry in a hash: who claimed it, the
+// fingerprint and, once finished, the response
+type redisIdempotency struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// claimScript returns the fingerprint and response of an existing entry,
+// or nil after claiming the key for ARGV[1]
+const claimScript = `local found = redis.call('HMGET', KEYS[1], 'fingerprint', 'response')
+if found[1] then return found end
+redis.call('HSET', KEYS[1], 'owner', ARGV[1], 'fingerprint', ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return false`
+
+// finishScript stores the response if ARGV[1] still holds the key
+const finishScript = `if redis.call('HGET', KEYS[1], 'owner') == ARGV[1] then
+  redis.call('HSET', KEYS[1], 'response', ARGV[2])
+  redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return 0`
+
+// releaseScript deletes the key if ARGV[1] still holds it
+const releaseScript = `if redis.call('HGET', KEYS[1], 'owner') == ARGV[1] then
+  redis.call('DEL', KEYS[1])
+end
+return 0`
+
+// storedResponse is a finished entry's response as kept in Redis
+type storedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+func (r redisIdempotency) claim(ctx context.Context, id, fingerprint string, _ time.Time) (*idempotent, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	owner := random.Hex(16)
+	reply, err := r.client.Eval(ctx, claimScript, []string{"idempotency:" + id}, owner, fingerprint, strconv.FormatInt(idempotencyLease.Milliseconds(), 10))
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return &idempotent{fingerprint: fingerprint, owner: owner}, true, nil
+	}
+	found, _ := reply.([]any)
+	if len(found) != 2 {
+		return nil, false, fmt.Errorf("unexpected reply %v", reply)
+	}
+	e := &idempotent{done: make(chan struct{})}
+	e.fingerprint, _ = found[0].(string)
+	if response, ok := found[1].(string); ok {
+		var stored storedResponse
+		if err := json.Unmarshal([]byte(response), &stored); err != nil {
+			return nil, false, err
+		}
+		e.status, e.header, e.body = stored.Status, stored.Header, stored.Body
+		close(e.done)
+	}
+	return e, false, nil
+}
+
+func (r redisIdempotency) finish(ctx context.Context, id string, e *idempotent) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), redisTimeout)
+	defer cancel()
+	response, err := json.Marshal(storedResponse{Status: e.status, Header: e.header, Body: e.body})
+	if err != nil {
+		return err
+	}
+	_, err = r.client.Eval(ctx, finishScript, []string{"idempotency:" + id}, e.owner, string(response), strconv.FormatInt(r.ttl.Milliseconds(), 10))
+	return err
+}
+
+func (r redisIdempotency) release(ctx context.Context, id string, e *idempotent) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), redisTimeout)
+	defer cancel()
+	if _, err := r.client.Eval(ctx, releaseScript, []string{"idempotency:" + id}, e.owner); err != nil {
+		log.Printf("idempotency: %v", err)
+	}
+}
+
+func (redisIdempotency) purge(time.Time) int { return 0 }
+
+// recorder keeps a copy of the response body as it is written
+type recorder struct {
+	gin.ResponseWriter
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.keep(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) WriteString(s string) (int, error) {
+	r.keep([]byte(s))
+	return r.ResponseWriter.WriteString(s)
+}
+
+func (r *recorder) keep(b []byte) {
+	if r.overflow || r.buf.Len()+len(b) > maxIdempotentBody {
+		r.overflow = true
+		r.buf.Reset()
+		return
+	}
+	r.buf.Write(b)
+}
@@ -0,0 +1,71 @@
+//go:build redis
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/internal/random"
+	"solar-system-explorer/backend/redis"
+	"solar-system-explorer/backend/testutil"
+)
+
+// TestSharedIdempotency runs against REDIS_TEST_URL: a retry that
+// reaches the other replica waits for, and then replays, the first
+// response
+func TestSharedIdempotency(t *testing.T) {
+	url := os.Getenv("REDIS_TEST_URL")
+	if url == "" {
+		t.Skip("REDIS_TEST_URL is not set")
+	}
+	prefix := "solar-test:" + random.Hex(4) + ":"
+	caller, tokens := testCallers(t)
+	release := make(chan struct{})
+	replica := func() (http.Handler, *atomic.Int32) {
+		client, err := redis.Open(context.Background(), url, 2, prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { client.Close() })
+		return routeIdempotent(NewSharedIdempotency(client, time.Hour, caller), release)
+	}
+	a, runsA := replica()
+	b, _ := replica()
+	ana := map[string]string{"Authorization": "Bearer " + tokens["ana phone"]}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- testutil.Send(a, http.MethodPost, "/notes", keyed("k1", "10.0.0.1:1", ana), "slow") }()
+	for runsA.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	w := testutil.Send(b, http.MethodPost, "/notes", keyed("k1", "10.0.0.2:1", ana), "slow")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("retry on the other replica = %d, want 409 while the first runs", w.Code)
+	}
+	close(release)
+	first := <-done
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first status = %d, want 201", first.Code)
+	}
+	w = testutil.Send(b, http.MethodPost, "/notes", keyed("k1", "10.0.0.2:1", ana), "slow")
+	if w.Header().Get("Idempotent-Replayed") != "true" || w.Body.String() != first.Body.String() {
+		t.Errorf("retry = %d %s replayed %q, want the first response", w.Code, w.Body, w.Header().Get("Idempotent-Replayed"))
+	}
+	if w := testutil.Send(b, http.MethodPost, "/notes", keyed("k1", "10.0.0.2:1", ana), "another note"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("other request with the key = %d, want 422", w.Code)
+	}
+
+	// A failure frees the key for the retry, wherever it lands
+	if w := testutil.Send(a, http.MethodPost, "/notes", keyed("k2", "10.0.0.1:1", ana), "fail"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+	if w := testutil.Send(b, http.MethodPost, "/notes", keyed("k2", "10.0.0.1:1", ana), "fail"); w.Code != http.StatusServiceUnavailable || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("retry after a failure = %d replayed %q, want a fresh run", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+}
@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/testutil"
+	"solar-system-explorer/backend/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newIdempotentServer routes POST /notes through an Idempotency kept in
+// memory; see routeIdempotent and testCallers
+func newIdempotentServer(t *testing.T, release chan struct{}) (*gin.Engine, *atomic.Int32, map[string]string) {
+	caller, tokens := testCallers(t)
+	r, runs := routeIdempotent(NewIdempotency(time.Hour, 100, caller), release)
+	return r, runs, tokens
+}
+
+// testCallers returns an IdempotencyCaller and the tokens it knows: two
+// sessions of one user, one of another, and the static admin token
+func testCallers(t *testing.T) (func(*gin.Context) string, map[string]string) {
+	t.Helper()
+	us, err := users.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logins, err := users.OpenLogins("", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := users.NewSessions([]byte("test-session-key-0123456789abcdef"), 15*time.Minute, logins)
+	tokens := map[string]string{"static": "static-admin-token"}
+	for _, name := range []string{"ana", "bob"} {
+		u, err := us.Register(name+"@example.com", name, "correct horse battery")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, device := range []string{"phone", "laptop"} {
+			tk, err := sessions.Start(u, device, "192.0.2.1", time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			tokens[name+" "+device] = tk.Token
+		}
+	}
+	return IdempotencyCaller("static-admin-token", sessions), tokens
+}
+
+// routeIdempotent routes POST /notes through i, counting how often the
+// handler runs. The handler answers 201 with the count, unless the body
+// is "panic" or "fail", or blocks until release is closed when the body
+// is "slow".
+func routeIdempotent(i *Idempotency, release chan struct{}) (*gin.Engine, *atomic.Int32) {
+	gin.SetMode(gin.TestMode)
+	var runs atomic.Int32
+	r := gin.New()
+	r.Use(gin.CustomRecovery(func(c *gin.Context, _ any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	r.Use(i.Handler())
+	r.POST("/notes", func(c *gin.Context) {
+		n := runs.Add(1)
+		body, _ := c.GetRawData()
+		switch string(body) {
+		case "panic":
+			panic("boom")
+		case "fail":
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "down"})
+			return
+		case "slow":
+			<-release
+		}
+		c.JSON(http.StatusCreated, gin.H{"run": n})
+	})
+	return r, &runs
+}
+
+// keyed returns header with an Idempotency-Key and the client address
+// added, for a POST to /notes
+func keyed(key, addr string, header map[string]string) map[string]string {
+	h := map[string]string{"Idempotency-Key": key, testutil.RemoteAddr: addr}
+	for k, v := range header {
+		h[k] = v
+	}
+	return h
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	r, runs, tokens := newIdempotentServer(t, nil)
+	ana := map[string]string{"Authorization": "Bearer " + tokens["ana phone"]}
+	first := testutil.Send(r, http.MethodPost, "/notes", keyed("k1", "10.0.0.1:1", ana), "note")
+	second := testutil.Send(r, http.MethodPost, "/notes", keyed("k1", "10.0.0.2:1", ana), "note")
+	if first.Code != http.StatusCreated || second.Code != http.StatusCreated {
+		t.Fatalf("status = %d, %d, want 201 twice", first.Code, second.Code)
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Errorf("replayed body = %s, want %s", second.Body, first.Body)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("replay is missing Idempotent-Replayed")
+	}
+	if n := runs.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+}
+
+func TestIdempotencyFingerprintConflict(t *testing.T) {
+	r, runs, _ := newIdempotentServer(t, nil)
+	testutil.Send(r, http.MethodPost, "/notes", keyed("k1", "10.0.0.1:1", nil), "note")
+	w := testutil.Send(r, http.MethodPost, "/notes", keyed("k1", "10.0.0.1:1", nil), "another note")
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want 422", w.Code)
+	}
+	if n := runs.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+}
+
+func TestIdempotencyScope(t *testing.T) {
+	as := func(caller, adminUser string) map[string]string {
+		h := map[string]string{}
+		if caller != "" {
+			h["Authorization"] = "Bearer " + caller
+		}
+		if adminUser != "" {
+			h["X-Admin-User"] = adminUser
+		}
+		return h
+	}
+	tests := []struct {
+		name     string
+		caller1  string // key into the tokens, or "" for anonymous
+		admin1   string // X-Admin-User
+		addr1    string
+		caller2  string
+		admin2   string
+		addr2    string
+		replayed bool
+	}{
+		{"same session, other IP", "ana phone", "", "10.0.0.1:1", "ana phone", "", "10.0.0.2:1", true},
+		{"same user, other device", "ana phone", "", "10.0.0.1:1", "ana laptop", "", "10.0.0.2:1", true},
+		{"other user", "ana phone", "", "10.0.0.1:1", "bob phone", "", "10.0.0.1:1", false},
+		{"same curator", "static", "mira", "10.0.0.1:1", "static", "mira", "10.0.0.2:1", true},
+		{"other curator", "static", "mira", "10.0.0.1:1", "static", "luka", "10.0.0.1:1", false},
+		{"curator, then a user", "static", "", "10.0.0.1:1", "ana phone", "", "10.0.0.1:1", false},
+		{"anonymous, same IP", "", "", "10.0.0.1:1", "", "", "10.0.0.1:2", true},
+		{"anonymous, other IP", "", "", "10.0.0.1:1", "", "", "10.0.0.2:1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, runs, tokens := newIdempotentServer(t, nil)
+			testutil.Send(r, http.MethodPost, "/notes", keyed("k1", tt.addr1, as(tokens[tt.caller1], tt.admin1)), "note")
+			w := testutil.Send(r, http.MethodPost, "/notes", keyed("k1", tt.addr2, as(tokens[tt.caller2], tt.admin2)), "note")
+			if got := w.Header().Get("Idempotent-Replayed") == "true"; got != tt.replayed {
+				t.Errorf("replayed = %v, want %v", got, tt.replayed)
+			}
+			if want := map[bool]int32{true: 1, false: 2}[tt.replayed]; runs.Load() != want {
+				t.Errorf("handler ran %d times, want %d", runs.Load(), want)
+			}
+		})
+	}
+}
+
+func TestIdempotencyRetriesAfterFailure(t *testing.T) {
+	for _, body := range []string{"panic", "fail"} {
+		t.Run(body, func(t *testing.T) {
+			r, runs, _ := newIdempotentServer(t, nil)
+			first := testutil.Send(r, http.MethodPost, "/notes", keyed("k1", "10.0.0.1:1", nil), body)
+			if first.Code < 500 {
+				t.Fatalf("first status = %d, want 5xx", first.Code)
+			}
+			second := testutil.Send(r, http.MethodPost, "/notes", keyed("k1", "10.0.0.1:1", nil), body)
+			if second.Code < 500 || second.Header().Get("Idempotent-Replayed") != "" {
+				t.Errorf("retry = %d replayed %q, want a fresh 5xx", second.Code, second.Header().Get("Idempotent-Replayed"))
+			}
+			if n := runs.Load(); n != 2 {
+				t.Errorf("handler ran %d times, want 2", n)
+			}
+		})
+	}
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	release := make(chan struct{})
+	r, runs, _ := newIdempotentServer(t, release)
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- testutil.Send(r, http.MethodPost, "/notes", keyed("k1", "10.0.0.1:1", nil), "slow") }()
+	for runs.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	w := testutil.Send(r, http.MethodPost, "/notes", keyed("k1", "10.0.0.1:1", nil), "slow")
+	if w.Code != http.StatusConflict || w.Header().Get("Retry-After") == "" {
+		t.Errorf("concurrent retry = %d Retry-After %q, want 409 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+	close(release)
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Fatalf("first status = %d, want 201", first.Code)
+	}
+	if w := testutil.Send(r, http.MethodPost, "/notes", keyed("k1", "10.0.0.1:1", nil), "slow"); w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry after completion = %d, want a replay", w.Code)
+	}
+	if n := runs.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+}
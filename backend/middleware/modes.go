@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// modesRetryAfter is what clients are told to wait, in seconds, while a
+// mode is on
+const modesRetryAfter = "300"
+
+// ModeState is the service's current mode. Maintenance turns every
+// request but admins' away; ReadOnly refuses changes but keeps serving
+// reads. Message is shown to visitors in either.
+type ModeState struct {
+	Maintenance bool       `json:"maintenance"`
+	ReadOnly    bool       `json:"read_only"`
+	Message     string     `json:"message,omitempty"`
+	Since       *time.Time `json:"since,omitempty"` // when a mode went on
+}
+
+// Active reports whether either mode is on
+func (s ModeState) Active() bool { return s.Maintenance || s.ReadOnly }
+
+// Modes holds the maintenance and read-only switches, which admins flip
+// at runtime while the dataset is migrated
+type Modes struct {
+	mu    sync.RWMutex
+	state ModeState
+}
+
+// NewModes starts in the given state, usually from the configuration
+func NewModes(s ModeState) *Modes {
+	if s.Active() && s.Since == nil {
+		now := time.Now().UTC()
+		s.Since = &now
+	}
+	return &Modes{state: s}
+}
+
+// State returns the current state
+func (m *Modes) State() ModeState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+// Set switches to s, keeping Since while a mode stays on
+func (m *Modes) Set(s ModeState, now time.Time) ModeState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case !s.Active():
+		s.Since = nil
+	case m.state.Active():
+		s.Since = m.state.Since
+	default:
+		now = now.UTC()
+		s.Since = &now
+	}
+	m.state = s
+	return s
+}
+
+// Handler enforces the modes. In maintenance, requests get 503 — a JSON
+// error on /api, a page in the visitor's language elsewhere. In
+// read-only, changes (anything but GET, HEAD and OPTIONS) get 503.
+// Requests isAdmin accepts pass in either mode, so admins can turn the
+// switch back and carry out the migration, and so do those under the
+// exempt path prefixes.
+func (m *Modes) Handler(isAdmin func(*gin.Context) bool, exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := m.State()
+		if !s.Active() {
+			c.Next()
+			return
+		}
+		path := c.Request.URL.Path
+		for _, p := range exempt {
+			if strings.HasPrefix(path, p) {
+				c.Next()
+				return
+			}
+		}
+		if isAdmin(c) {
+			c.Next()
+			return
+		}
+		if s.Maintenance {
+			c.Header("Retry-After", modesRetryAfter)
+			if strings.HasPrefix(path, "/api/") {
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Down for maintenance", "banner": s})
+				return
+			}
+			c.Header("Cache-Control", "no-store")
+			c.Status(http.StatusServiceUnavailable)
+			c.Header("Content-Type", "text/html; charset=utf-8")
+			maintenancePage.Execute(c.Writer, maintenanceText(c, s.Message))
+			c.Abort()
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		c.Header("Retry-After", modesRetryAfter)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Read-only mode: changes are paused", "banner": s})
+	}
+}
+
+type maintenanceCopy struct {
+	Lang, Title, Text, Message string
+}
+
+// maintenanceText picks the page's language: Serbian when the visitor
+// prefers it (?lang= or Accept-Language), English otherwise
+func maintenanceText(c *gin.Context, message string) maintenanceCopy {
+	prefs := i18n.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	if lang := i18n.Canonical(c.Query("lang")); lang != "" {
+		prefs = append([]string{lang}, prefs...)
+	}
+	english := maintenanceCopy{"en", "Down for maintenance", "Solar System Explorer is being updated. Please check back in a few minutes.", message}
+	for _, tag := range i18n.Fallbacks(prefs) {
+		switch tag {
+		case "sr", "sr-Latn":
+			return maintenanceCopy{"sr", "Održavanje u toku", "Istraživač Sunčevog sistema se trenutno ažurira. Vratite se za nekoliko minuta.", message}
+		case "sr-Cyrl":
+			return maintenanceCopy{"sr-Cyrl", "Одржавање у току", "Истраживач Сунчевог система се тренутно ажурира. Вратите се за неколико минута.", message}
+		case "en":
+			return english
+		}
+	}
+	return english
+}
+
+var maintenancePage = template.Must(template.New("maintenance").Parse(`<!doctype html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>body{margin:0;min-height:100vh;display:grid;place-items:center;background:#05060f;color:#e8eaf6;font-family:system-ui,sans-serif;text-align:center}main{max-width:32rem;padding:2rem}h1{font-weight:500}</style>
+</head>
+<body>
+<main>
+<h1>{{.Title}}</h1>
+<p>{{.Text}}</p>
+{{with .Message}}<p>{{.}}</p>{{end}}
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newModesServer answers 200 on every path behind the returned Modes,
+// with sessions for an admin, a curator and a viewer
+func newModesServer(t *testing.T) (*gin.Engine, *Modes, map[string]string) {
+	t.Helper()
+	us, err := users.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logins, err := users.OpenLogins("", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := users.NewSessions([]byte("test-session-key-0123456789abcdef"), 15*time.Minute, logins)
+	tokens := map[string]string{"static": "static-admin-token", "bogus": "not-a-token"}
+	for _, role := range []string{users.RoleAdmin, users.RoleCurator, users.RoleViewer} {
+		u, err := us.Register(role+"@example.com", "Test", "correct horse battery")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, u, err = us.SetRole(u.ID, role); err != nil {
+			t.Fatal(err)
+		}
+		tk, err := sessions.Start(u, "test", "192.0.2.1", time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[role] = tk.Token
+	}
+
+	gin.SetMode(gin.TestMode)
+	modes := NewModes(ModeState{})
+	r := gin.New()
+	r.Use(modes.Handler(IsAdmin("static-admin-token", sessions, us), "/api/status"))
+	r.NoRoute(func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r, modes, tokens
+}
+
+func TestModesExemptions(t *testing.T) {
+	r, modes, tokens := newModesServer(t)
+	tests := []struct {
+		name   string
+		state  ModeState
+		method string
+		path   string
+		caller string // key into the tokens, or "" for anonymous
+		want   int
+	}{
+		{"maintenance, anonymous page", ModeState{Maintenance: true}, http.MethodGet, "/planets", "", http.StatusServiceUnavailable},
+		{"maintenance, anonymous API", ModeState{Maintenance: true}, http.MethodGet, "/api/planets", "", http.StatusServiceUnavailable},
+		{"maintenance, exempt path", ModeState{Maintenance: true}, http.MethodGet, "/api/status", "", http.StatusOK},
+		{"maintenance, anonymous admin API", ModeState{Maintenance: true}, http.MethodPut, "/api/admin/modes", "", http.StatusServiceUnavailable},
+		{"maintenance, bad token", ModeState{Maintenance: true}, http.MethodPut, "/api/admin/modes", "bogus", http.StatusServiceUnavailable},
+		{"maintenance, viewer", ModeState{Maintenance: true}, http.MethodGet, "/api/planets", users.RoleViewer, http.StatusServiceUnavailable},
+		{"maintenance, curator", ModeState{Maintenance: true}, http.MethodPut, "/api/admin/planets/Mars", users.RoleCurator, http.StatusServiceUnavailable},
+		{"maintenance, admin session", ModeState{Maintenance: true}, http.MethodPut, "/api/admin/modes", users.RoleAdmin, http.StatusOK},
+		{"maintenance, static token", ModeState{Maintenance: true}, http.MethodPut, "/api/admin/modes", "static", http.StatusOK},
+		{"maintenance, admin on webhooks", ModeState{Maintenance: true}, http.MethodPost, "/api/webhooks", users.RoleAdmin, http.StatusOK},
+		{"maintenance, admin outside /api/admin", ModeState{Maintenance: true}, http.MethodGet, "/api/assets/signed-url", "static", http.StatusOK},
+
+		{"read-only, anonymous read", ModeState{ReadOnly: true}, http.MethodGet, "/api/planets", "", http.StatusOK},
+		{"read-only, anonymous change", ModeState{ReadOnly: true}, http.MethodPost, "/api/scenes", "", http.StatusServiceUnavailable},
+		{"read-only, exempt path", ModeState{ReadOnly: true}, http.MethodPost, "/api/status", "", http.StatusOK},
+		{"read-only, viewer change", ModeState{ReadOnly: true}, http.MethodPost, "/api/scenes", users.RoleViewer, http.StatusServiceUnavailable},
+		{"read-only, curator change", ModeState{ReadOnly: true}, http.MethodPut, "/api/admin/planets/Mars", users.RoleCurator, http.StatusServiceUnavailable},
+		{"read-only, admin change", ModeState{ReadOnly: true}, http.MethodPut, "/api/admin/planets/Mars", users.RoleAdmin, http.StatusOK},
+		{"read-only, admin on webhooks", ModeState{ReadOnly: true}, http.MethodPost, "/api/webhooks", "static", http.StatusOK},
+		{"read-only, admin switching back", ModeState{ReadOnly: true}, http.MethodPut, "/api/admin/modes", users.RoleAdmin, http.StatusOK},
+
+		{"off, anonymous change", ModeState{}, http.MethodPost, "/api/scenes", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modes.Set(tt.state, time.Now())
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.caller != "" {
+				req.Header.Set("Authorization", "Bearer "+tokens[tt.caller])
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			if w.Code == http.StatusServiceUnavailable && w.Header().Get("Retry-After") == "" {
+				t.Error("503 without Retry-After")
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/redis"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit lets each key through at most limit times per window, to slow
+// down password guessing and sign-up floods. Windows are fixed: a key's
+// count starts with its first request and resets a window later.
+type RateLimit struct {
+	limit  int
+	counts rateCounter
+}
+
+// rateCounter counts requests per key in windows, returning the count
+// including this one and how long until the key's window resets
+type rateCounter interface {
+	hit(ctx context.Context, key string, now time.Time) (int, time.Duration, error)
+	purge(now time.Time) int
+}
+
+// NewRateLimit allows limit requests per key per window, counted in this
+// process
+func NewRateLimit(limit int, window time.Duration) *RateLimit {
+	return &RateLimit{limit: limit, counts: &memoryCounts{window: window, hits: make(map[string]*rateWindow)}}
+}
+
+// NewSharedRateLimit allows limit requests per key per window, counted in
+// Redis under name so that every replica adds to the same count
+func NewSharedRateLimit(client *redis.Client, name string, limit int, window time.Duration) *RateLimit {
+	return &RateLimit{limit: limit, counts: redisCounts{client: client, name: name, window: window}}
+}
+
+// Handler returns the middleware, counting each request under the key
+// keyOf returns. Requests over the limit get 429 with Retry-After; those
+// keyOf returns no key for pass uncounted, unless keyOf aborted them.
+func (rl *RateLimit) Handler(keyOf func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := keyOf(c)
+		if c.IsAborted() {
+			return
+		}
+		if key == "" {
+			c.Next()
+			return
+		}
+		wait, ok, err := rl.allow(c.Request.Context(), key, time.Now())
+		if err != nil {
+			log.Printf("ratelimit: %v", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Try again later"})
+			return
+		}
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many attempts; try again later"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// allow counts a request for key, reporting whether it is within the
+// limit and, if not, how long until the window resets
+func (rl *RateLimit) allow(ctx context.Context, key string, now time.Time) (time.Duration, bool, error) {
+	n, reset, err := rl.counts.hit(ctx, key, now)
+	if err != nil || n <= rl.limit {
+		return 0, err == nil, err
+	}
+	return reset, false, nil
+}
+
+// Purge drops windows that have ended and returns how many. Counts kept
+// in Redis expire by themselves.
+func (rl *RateLimit) Purge(now time.Time) int {
+	return rl.counts.purge(now)
+}
+
+// memoryCounts keeps the counts in a map
+type memoryCounts struct {
+	mu     sync.Mutex
+	window time.Duration
+	hits   map[string]*rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	n     int
+}
+
+func (m *memoryCounts) hit(_ context.Context, key string, now time.Time) (int, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.hits[key]
+	if !ok || !now.Before(w.start.Add(m.window)) {
+		w = &rateWindow{start: now}
+		m.hits[key] = w
+	}
+	w.n++
+	return w.n, w.start.Add(m.window).Sub(now), nil
+}
+
+func (m *memoryCounts) purge(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for key, w := range m.hits {
+		if !now.Before(w.start.Add(m.window)) {
+			delete(m.hits, key)
+			n++
+		}
+	}
+	return n
+}
+
+// redisTimeout bounds each Redis round trip of a shared RateLimit or
+// Idempotency
+const redisTimeout = 2 * time.Second
+
+// redisCounts keeps each count in a key that expires with its window
+type redisCounts struct {
+	client *redis.Client
+	name   string
+	window time.Duration
+}
+
+// countScript increments a count, starting its window on the first
+// request, and returns it with the milliseconds left in the window. As
+// one script it can't leave a count without an expiry.
+const countScript = `local n = redis.call('INCR', KEYS[1])
+if n == 1 then redis.call('PEXPIRE', KEYS[1], ARGV[1]) end
+return {n, redis.call('PTTL', KEYS[1])}`
+
+func (r redisCounts) hit(ctx context.Context, key string, _ time.Time) (int, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	reply, err := r.client.Eval(ctx, countScript, []string{"ratelimit:" + r.name + ":" + key}, strconv.FormatInt(r.window.Milliseconds(), 10))
+	if err != nil {
+		return 0, 0, err
+	}
+	list, _ := reply.([]any)
+	if len(list) != 2 {
+		return 0, 0, fmt.Errorf("unexpected reply %v", reply)
+	}
+	n, _ := list[0].(int64)
+	ms, _ := list[1].(int64)
+	return int(n), time.Duration(max(ms, 0)) * time.Millisecond, nil
+}
+
+func (redisCounts) purge(time.Time) int { return 0 }
+
+// ByClientIP keys requests by the client's address
+func ByClientIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// maxKeyedBody caps the request body ByJSON reads
+const maxKeyedBody = 64 << 10
+
+// ByJSON keys requests by their JSON body, decoded into T the way the
+// handler binds it so that any spelling of a field the handler accepts is
+// counted too; key picks the key out of it. The body is put back for the
+// handler. Requests key returns no key for aren't counted, and fail the
+// handler's own validation instead; bodies over 64 KiB are refused with
+// 413 rather than let through uncounted.
+func ByJSON[T any](key func(T) string) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxKeyedBody+1))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Could not read the request body"})
+			return ""
+		}
+		if len(body) > maxKeyedBody {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+			return ""
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		// A Decoder, like gin's binding: it ignores what follows the first
+		// value, which Unmarshal would reject
+		var req T
+		if json.NewDecoder(bytes.NewReader(body)).Decode(&req) != nil {
+			return ""
+		}
+		return key(req)
+	}
+}
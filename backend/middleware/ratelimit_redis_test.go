@@ -0,0 +1,51 @@
+//go:build redis
+
+package middleware
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/internal/random"
+	"solar-system-explorer/backend/redis"
+)
+
+// TestSharedRateLimit runs against REDIS_TEST_URL: two replicas, each
+// with its own client, add to one count per key
+func TestSharedRateLimit(t *testing.T) {
+	url := os.Getenv("REDIS_TEST_URL")
+	if url == "" {
+		t.Skip("REDIS_TEST_URL is not set")
+	}
+	prefix := "solar-test:" + random.Hex(4) + ":"
+	replica := func() *RateLimit {
+		client, err := redis.Open(context.Background(), url, 2, prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { client.Close() })
+		return NewSharedRateLimit(client, "test", 3, 300*time.Millisecond)
+	}
+	a, b := replica(), replica()
+	ctx := context.Background()
+
+	for i, rl := range []*RateLimit{a, b, a} {
+		if _, ok, err := rl.allow(ctx, "ip:192.0.2.1", time.Now()); err != nil || !ok {
+			t.Fatalf("request %d: ok %v, err %v", i+1, ok, err)
+		}
+	}
+	wait, ok, err := b.allow(ctx, "ip:192.0.2.1", time.Now())
+	if err != nil || ok || wait <= 0 || wait > 300*time.Millisecond {
+		t.Errorf("fourth request: ok %v, wait %s, err %v; want refused until the window ends", ok, wait, err)
+	}
+	if _, ok, _ := a.allow(ctx, "ip:192.0.2.2", time.Now()); !ok {
+		t.Error("another key was refused")
+	}
+
+	time.Sleep(wait + 50*time.Millisecond)
+	if _, ok, err := a.allow(ctx, "ip:192.0.2.1", time.Now()); err != nil || !ok {
+		t.Errorf("after the window: ok %v, err %v", ok, err)
+	}
+}
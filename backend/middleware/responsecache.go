@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// revalidateTimeout bounds a background refresh of a stale response
+const revalidateTimeout = 30 * time.Second
+
+// revalidating marks the request a background refresh replays, so the
+// cache lets it through to the handler
+type revalidating struct{}
+
+// ResponseCache keeps successful GET responses of expensive endpoints in
+// memory. Within a route's TTL they are served as they are; for a while
+// after (stale-while-revalidate) the old response is still served, at
+// once, while one background request refreshes it. Requests with an
+// Authorization header are never cached, and Invalidate drops everything,
+// for the data layer to call when what the responses were computed from
+// changes.
+type ResponseCache struct {
+	engine http.Handler // replays requests to revalidate
+	max    int
+
+	mu         sync.Mutex
+	entries    map[string]*cachedResponse
+	generation int // bumped by Invalidate
+	stats      ResponseCacheStats
+}
+
+type cachedResponse struct {
+	status     int
+	header     http.Header
+	body       []byte
+	stored     time.Time
+	generation int
+	refreshing bool
+}
+
+// ResponseCacheStats counts how requests were served since the start
+type ResponseCacheStats struct {
+	Entries       int   `json:"entries"`
+	Hits          int64 `json:"hits"`
+	StaleHits     int64 `json:"stale_hits"`
+	Misses        int64 `json:"misses"`
+	Revalidations int64 `json:"revalidations"`
+	Invalidations int64 `json:"invalidations"`
+}
+
+// NewResponseCache keeps at most max responses. engine serves the
+// background refreshes, so they pass the same middleware as clients.
+func NewResponseCache(engine http.Handler, max int) *ResponseCache {
+	return &ResponseCache{engine: engine, max: max, entries: make(map[string]*cachedResponse)}
+}
+
+// Handler caches the route's responses for ttl, then serves them stale
+// for up to stale more while refreshing. A nil cache passes through.
+func (rc *ResponseCache) Handler(ttl, stale time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rc == nil || c.Request.Method != http.MethodGet || c.GetHeader("Authorization") != "" {
+			c.Next()
+			return
+		}
+		key := c.Request.URL.RequestURI() + "\x00" + c.GetHeader("Accept-Language") + "\x00" + c.GetHeader(SimulatedTimeHeader)
+		now := time.Now()
+		if c.Request.Context().Value(revalidating{}) == nil && c.GetHeader("Cache-Control") != "no-cache" {
+			if e, fresh, refresh := rc.lookup(key, now, ttl, stale); e != nil {
+				if refresh {
+					go rc.revalidate(c.Request)
+				}
+				for k, v := range e.header {
+					c.Writer.Header()[k] = v
+				}
+				c.Header("Age", strconv.Itoa(int(now.Sub(e.stored).Seconds())))
+				if fresh {
+					c.Header("X-Cache", "HIT")
+				} else {
+					c.Header("X-Cache", "STALE")
+				}
+				c.Data(e.status, e.header.Get("Content-Type"), e.body)
+				c.Abort()
+				return
+			}
+		}
+
+		rc.mu.Lock()
+		generation := rc.generation
+		rc.mu.Unlock()
+		c.Header("X-Cache", "MISS")
+		rec := &recorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		c.Next()
+		if rec.Status() != http.StatusOK || rec.overflow {
+			rc.release(key)
+			return
+		}
+		header := rec.Header().Clone()
+		header.Del("Set-Cookie")
+		header.Del("X-Cache")
+		header.Del("Age")
+		rc.store(key, &cachedResponse{status: rec.Status(), header: header, body: rec.buf.Bytes(), stored: time.Now(), generation: generation})
+	}
+}
+
+// lookup returns the entry for key if it is still usable, whether it is
+// fresh and whether the caller should refresh it (only one caller is told)
+func (rc *ResponseCache) lookup(key string, now time.Time, ttl, stale time.Duration) (e *cachedResponse, fresh, refresh bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	e = rc.entries[key]
+	switch {
+	case e == nil || now.Sub(e.stored) >= ttl+stale:
+		rc.stats.Misses++
+		return nil, false, false
+	case now.Sub(e.stored) < ttl:
+		rc.stats.Hits++
+		return e, true, false
+	}
+	rc.stats.StaleHits++
+	if !e.refreshing {
+		e.refreshing = true
+		rc.stats.Revalidations++
+		return e, false, true
+	}
+	return e, false, false
+}
+
+// revalidate replays req in the background to refresh its entry
+func (rc *ResponseCache) revalidate(req *http.Request) {
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), revalidating{}, true), revalidateTimeout)
+	defer cancel()
+	rc.engine.ServeHTTP(discard{header: http.Header{}}, req.Clone(ctx))
+}
+
+// store keeps e unless the cache was invalidated since its request began
+func (rc *ResponseCache) store(key string, e *cachedResponse) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if e.generation != rc.generation {
+		return
+	}
+	if _, ok := rc.entries[key]; !ok && len(rc.entries) >= rc.max {
+		var oldest string
+		for k, v := range rc.entries {
+			if oldest == "" || v.stored.Before(rc.entries[oldest].stored) {
+				oldest = k
+			}
+		}
+		delete(rc.entries, oldest)
+	}
+	rc.entries[key] = e
+}
+
+// release lets another request refresh key after a refresh that failed
+func (rc *ResponseCache) release(key string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if e, ok := rc.entries[key]; ok {
+		e.refreshing = false
+	}
+}
+
+// Invalidate drops every cached response, including those being computed
+func (rc *ResponseCache) Invalidate() {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	clear(rc.entries)
+	rc.generation++
+	rc.stats.Invalidations++
+}
+
+// Stats returns the counters and the number of entries
+func (rc *ResponseCache) Stats() ResponseCacheStats {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	s := rc.stats
+	s.Entries = len(rc.entries)
+	return s
+}
+
+// discard is the ResponseWriter of background refreshes; the cache
+// middleware records the body on its way through
+type discard struct{ header http.Header }
+
+func (d discard) Header() http.Header         { return d.header }
+func (d discard) Write(b []byte) (int, error) { return len(b), nil }
+func (d discard) WriteHeader(int)             {}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newCachedServer routes GET /data/* through a ResponseCache of max
+// entries, counting how often the handler runs. The handler answers with
+// the count, 500 for /data/fail, and invalidates the cache halfway
+// through for /data/changing.
+func newCachedServer(max int, ttl, stale time.Duration) (*gin.Engine, *ResponseCache, *atomic.Int32) {
+	gin.SetMode(gin.TestMode)
+	var runs atomic.Int32
+	r := gin.New()
+	rc := NewResponseCache(r, max)
+	r.GET("/data/:name", rc.Handler(ttl, stale), func(c *gin.Context) {
+		n := runs.Add(1)
+		switch c.Param("name") {
+		case "fail":
+			c.String(http.StatusInternalServerError, "down")
+			return
+		case "changing":
+			rc.Invalidate()
+		}
+		c.String(http.StatusOK, "run %d", n)
+	})
+	return r, rc, &runs
+}
+
+func expectServed(t *testing.T, w *httptest.ResponseRecorder, cache, body string) {
+	t.Helper()
+	if got := w.Header().Get("X-Cache"); got != cache || w.Body.String() != body {
+		t.Errorf("got %s %q, want %s %q", got, w.Body, cache, body)
+	}
+}
+
+func TestResponseCacheFresh(t *testing.T) {
+	r, rc, runs := newCachedServer(10, time.Hour, time.Hour)
+	expectServed(t, testutil.Do(r, http.MethodGet, "/data/a", nil), "MISS", "run 1")
+	expectServed(t, testutil.Do(r, http.MethodGet, "/data/a", nil), "HIT", "run 1")
+	expectServed(t, testutil.Do(r, http.MethodGet, "/data/a?x=1", nil), "MISS", "run 2")
+	expectServed(t, testutil.Do(r, http.MethodGet, "/data/a", map[string]string{"Accept-Language": "sr"}), "MISS", "run 3")
+	expectServed(t, testutil.Do(r, http.MethodGet, "/data/a", map[string]string{"Cache-Control": "no-cache"}), "MISS", "run 4")
+	if s := rc.Stats(); s.Hits != 1 || s.Entries != 3 {
+		t.Errorf("stats = %+v, want 1 hit and 3 entries", s)
+	}
+
+	// Signed-in requests and failures are never kept
+	for i := 0; i < 2; i++ {
+		expectServed(t, testutil.Do(r, http.MethodGet, "/data/b", map[string]string{"Authorization": "Bearer x"}), "", fmt.Sprintf("run %d", 5+i))
+	}
+	for i := 0; i < 2; i++ {
+		if w := testutil.Do(r, http.MethodGet, "/data/fail", nil); w.Header().Get("X-Cache") != "MISS" {
+			t.Errorf("a 500 was served from the cache")
+		}
+	}
+	if n := runs.Load(); n != 8 {
+		t.Errorf("handler ran %d times, want 8", n)
+	}
+}
+
+func TestResponseCacheStaleWhileRevalidate(t *testing.T) {
+	ttl, stale := 50*time.Millisecond, 300*time.Millisecond
+	r, rc, runs := newCachedServer(10, ttl, stale)
+	expectServed(t, testutil.Do(r, http.MethodGet, "/data/a", nil), "MISS", "run 1")
+
+	// Past the TTL the old response is served at once, and only the
+	// first such request starts a refresh
+	time.Sleep(ttl)
+	expectServed(t, testutil.Do(r, http.MethodGet, "/data/a", nil), "STALE", "run 1")
+	w := testutil.Do(r, http.MethodGet, "/data/a", nil)
+	for deadline := time.Now().Add(time.Second); w.Header().Get("X-Cache") == "STALE" && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+		w = testutil.Do(r, http.MethodGet, "/data/a", nil)
+	}
+	expectServed(t, w, "HIT", "run 2")
+	if s := rc.Stats(); s.Revalidations != 1 {
+		t.Errorf("%d revalidations, want 1", s.Revalidations)
+	}
+
+	// Past the stale window too, it's computed again in the request
+	time.Sleep(ttl + stale)
+	expectServed(t, testutil.Do(r, http.MethodGet, "/data/a", nil), "MISS", "run 3")
+	if n := runs.Load(); n != 3 {
+		t.Errorf("handler ran %d times, want 3", n)
+	}
+}
+
+func TestResponseCacheInvalidate(t *testing.T) {
+	r, rc, _ := newCachedServer(10, time.Hour, time.Hour)
+	testutil.Do(r, http.MethodGet, "/data/a", nil)
+	rc.Invalidate()
+	expectServed(t, testutil.Do(r, http.MethodGet, "/data/a", nil), "MISS", "run 2")
+
+	// A response computed across an invalidation isn't kept: it may
+	// have been built from the old data
+	testutil.Do(r, http.MethodGet, "/data/changing", nil)
+	expectServed(t, testutil.Do(r, http.MethodGet, "/data/changing", nil), "MISS", "run 4")
+	if s := rc.Stats(); s.Entries != 0 || s.Invalidations != 3 {
+		t.Errorf("stats = %+v, want no entries and 3 invalidations", s)
+	}
+}
+
+func TestResponseCacheEvictsOldest(t *testing.T) {
+	r, rc, _ := newCachedServer(2, time.Hour, time.Hour)
+	for _, name := range []string{"a", "b", "c"} {
+		testutil.Do(r, http.MethodGet, "/data/"+name, nil)
+		time.Sleep(time.Millisecond)
+	}
+	if s := rc.Stats(); s.Entries != 2 {
+		t.Errorf("%d entries, want 2", s.Entries)
+	}
+	expectServed(t, testutil.Do(r, http.MethodGet, "/data/c", nil), "HIT", "run 3")
+	expectServed(t, testutil.Do(r, http.MethodGet, "/data/a", nil), "MISS", "run 4")
+}
+
+func TestResponseCacheNil(t *testing.T) {
+	var rc *ResponseCache
+	rc.Invalidate()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/data", rc.Handler(time.Hour, time.Hour), func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	if w := testutil.Do(r, http.MethodGet, "/data", nil); w.Code != http.StatusOK || w.Header().Get("X-Cache") != "" {
+		t.Errorf("a nil cache got in the way: %d %q", w.Code, w.Header().Get("X-Cache"))
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/assets"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SignedURL lets requests for private assets through only with a valid,
+// unexpired ?expires=&sig= from signer. Public assets pass untouched.
+// Private responses may be cached, by browsers and a CDN alike, until the
+// URL expires.
+func SignedURL(signer *assets.Signer, private func(path string) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !private(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		if signer == nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Signed URLs are not enabled"})
+			return
+		}
+		expires, err := signer.Verify(c.Request.URL.Path, c.Query("expires"), c.Query("sig"), time.Now())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Invalid or expired link"})
+			return
+		}
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(time.Until(expires).Seconds())))
+		c.Next()
+	}
+}
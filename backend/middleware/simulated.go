@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"solar-system-explorer/backend/clock"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SimulatedTimeHeader asks for a response as of another moment
+const SimulatedTimeHeader = "X-Simulated-Time"
+
+// SimulatedTime lets a request set the time the time-dependent endpoints
+// answer for with an X-Simulated-Time header (RFC 3339), so a client can
+// look at the sky on another date across every endpoint at once. An
+// explicit ?time= still wins. The header is echoed back when honoured.
+// Sessions, tokens and other bookkeeping keep the real time; the header
+// only moves the sky, and is meant for development.
+func SimulatedTime() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", SimulatedTimeHeader)
+		v := c.GetHeader(SimulatedTimeHeader)
+		if v == "" {
+			c.Next()
+			return
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid request", "fields": []gin.H{
+				{"field": SimulatedTimeHeader, "message": "must be an RFC 3339 time"},
+			}})
+			return
+		}
+		t = t.UTC()
+		c.Request = c.Request.WithContext(clock.WithSimulated(c.Request.Context(), t))
+		c.Header(SimulatedTimeHeader, t.Format(time.RFC3339))
+		c.Next()
+	}
+}
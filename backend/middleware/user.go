@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userKey is the gin context key holding the signed-in user's claims
+const userKey = "auth.user"
+
+// UserAuth reads the session token from "Authorization: Bearer". With
+// required set, requests without a valid session get 401; otherwise they
+// continue anonymously and CurrentUser reports false.
+func UserAuth(sessions *users.Sessions, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if ok {
+			if claims, err := sessions.Verify(token, time.Now()); err == nil {
+				c.Set(userKey, claims)
+				c.Next()
+				return
+			}
+		}
+		if required || ok { // a bad token is an error even where optional
+			c.Header("WWW-Authenticate", `Bearer realm="user"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Sign in required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentUser returns the claims set by UserAuth
+func CurrentUser(c *gin.Context) (users.Claims, bool) {
+	v, ok := c.Get(userKey)
+	if !ok {
+		return users.Claims{}, false
+	}
+	claims, ok := v.(users.Claims)
+	return claims, ok
+}
@@ -0,0 +1,82 @@
+// Package middleware holds gin middleware shared across route groups.
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkPool bounds how many heavy computations run at once. Requests beyond
+// the worker count wait in a bounded queue; beyond that they are rejected
+// with 503 so a burst of simulation calls can't starve static file serving.
+type WorkPool struct {
+	workers    chan struct{}
+	queue      chan struct{}
+	timeout    time.Duration
+	retryAfter time.Duration
+}
+
+// NewWorkPool creates a pool running at most workers computations with up
+// to queue waiting. Each request gets a context deadline of timeout,
+// covering both the wait for a worker and the computation itself.
+func NewWorkPool(workers, queue int, timeout time.Duration) *WorkPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queue < 0 {
+		queue = 0
+	}
+	return &WorkPool{
+		workers:    make(chan struct{}, workers),
+		queue:      make(chan struct{}, workers+queue),
+		timeout:    timeout,
+		retryAfter: 2 * time.Second,
+	}
+}
+
+// Handler returns the middleware enforcing the pool
+func (p *WorkPool) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// The queue channel counts everyone admitted (running + waiting)
+		select {
+		case p.queue <- struct{}{}:
+		default:
+			p.reject(c, "Server busy, try again shortly")
+			return
+		}
+		defer func() { <-p.queue }()
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), p.timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		select {
+		case p.workers <- struct{}{}:
+		case <-ctx.Done():
+			p.reject(c, "Timed out waiting for a worker")
+			return
+		}
+		defer func() { <-p.workers }()
+
+		c.Next()
+
+		if ctx.Err() == context.DeadlineExceeded && !c.Writer.Written() {
+			p.reject(c, "Computation exceeded its time budget")
+		}
+	}
+}
+
+// Stats reports running and queued request counts
+func (p *WorkPool) Stats() (running, queued int) {
+	running = len(p.workers)
+	return running, len(p.queue) - running
+}
+
+func (p *WorkPool) reject(c *gin.Context, msg string) {
+	c.Header("Retry-After", strconv.Itoa(int(p.retryAfter.Seconds())))
+	c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": msg})
+}
@@ -0,0 +1,68 @@
+package models
+
+// Surface feature types, after the IAU descriptor terms
+const (
+	FeatureCrater   = "crater"
+	FeatureMons     = "mons" // mountain or range (montes)
+	FeatureMare     = "mare" // also oceanus and, on Titan, liquid seas
+	FeaturePlanitia = "planitia"
+	FeaturePlanum   = "planum"
+	FeatureVallis   = "vallis"
+	FeatureTerra    = "terra"
+)
+
+// SurfaceFeature is a named feature on a body's surface. Coordinates are
+// planetocentric with longitude positive east, −180–180°, so they map
+// straight onto GeoJSON and the equirectangular textures.
+type SurfaceFeature struct {
+	Body       string  `json:"body"` // English name of the body
+	Name       string  `json:"name"`
+	NameSR     string  `json:"name_sr"`
+	Type       string  `json:"type"`
+	Lat        float64 `json:"lat"` // degrees
+	Lon        float64 `json:"lon"` // degrees east
+	DiameterKm float64 `json:"diameter_km"`
+}
+
+// GetSurfaceFeatures returns notable features per body (IAU Gazetteer of
+// Planetary Nomenclature)
+func GetSurfaceFeatures() []SurfaceFeature {
+	return []SurfaceFeature{
+		{Body: "Mercury", Name: "Caloris Planitia", NameSR: "Kaloris ravnica", Type: FeaturePlanitia, Lat: 30.5, Lon: -170.2, DiameterKm: 1550},
+		{Body: "Mercury", Name: "Beethoven", NameSR: "Betoven", Type: FeatureCrater, Lat: -20.8, Lon: -123.6, DiameterKm: 630},
+		{Body: "Mercury", Name: "Rachmaninoff", NameSR: "Rahmanjinov", Type: FeatureCrater, Lat: -27.6, Lon: 57.6, DiameterKm: 290},
+
+		{Body: "Venus", Name: "Maxwell Montes", NameSR: "Maksvelove planine", Type: FeatureMons, Lat: 65.2, Lon: 3.3, DiameterKm: 797},
+		{Body: "Venus", Name: "Ishtar Terra", NameSR: "Ištar", Type: FeatureTerra, Lat: 70.4, Lon: 27.5, DiameterKm: 5610},
+		{Body: "Venus", Name: "Aphrodite Terra", NameSR: "Afrodita", Type: FeatureTerra, Lat: -5.8, Lon: 104.8, DiameterKm: 7999},
+		{Body: "Venus", Name: "Mead", NameSR: "Mid", Type: FeatureCrater, Lat: 12.5, Lon: 57.2, DiameterKm: 270},
+
+		{Body: "Moon", Name: "Oceanus Procellarum", NameSR: "Okean oluja", Type: FeatureMare, Lat: 18.4, Lon: -57.4, DiameterKm: 2592},
+		{Body: "Moon", Name: "Mare Imbrium", NameSR: "More kiša", Type: FeatureMare, Lat: 32.8, Lon: -15.6, DiameterKm: 1146},
+		{Body: "Moon", Name: "Mare Tranquillitatis", NameSR: "More tišine", Type: FeatureMare, Lat: 8.5, Lon: 31.4, DiameterKm: 873},
+		{Body: "Moon", Name: "Mare Serenitatis", NameSR: "More vedrine", Type: FeatureMare, Lat: 28.0, Lon: 17.5, DiameterKm: 674},
+		{Body: "Moon", Name: "Mare Nubium", NameSR: "More oblaka", Type: FeatureMare, Lat: -21.3, Lon: -16.6, DiameterKm: 715},
+		{Body: "Moon", Name: "Mare Crisium", NameSR: "More kriza", Type: FeatureMare, Lat: 17.0, Lon: 59.1, DiameterKm: 556},
+		{Body: "Moon", Name: "Tycho", NameSR: "Tiho", Type: FeatureCrater, Lat: -43.31, Lon: -11.36, DiameterKm: 85},
+		{Body: "Moon", Name: "Copernicus", NameSR: "Kopernik", Type: FeatureCrater, Lat: 9.62, Lon: -20.08, DiameterKm: 96},
+		{Body: "Moon", Name: "Plato", NameSR: "Platon", Type: FeatureCrater, Lat: 51.62, Lon: -9.38, DiameterKm: 101},
+		{Body: "Moon", Name: "Clavius", NameSR: "Klavijus", Type: FeatureCrater, Lat: -58.62, Lon: -14.73, DiameterKm: 231},
+		{Body: "Moon", Name: "Mons Huygens", NameSR: "Hajgensova planina", Type: FeatureMons, Lat: 19.92, Lon: -2.86, DiameterKm: 40},
+
+		{Body: "Mars", Name: "Olympus Mons", NameSR: "Olimp", Type: FeatureMons, Lat: 18.65, Lon: -133.8, DiameterKm: 624},
+		{Body: "Mars", Name: "Ascraeus Mons", NameSR: "Askrejska planina", Type: FeatureMons, Lat: 11.92, Lon: -104.08, DiameterKm: 460},
+		{Body: "Mars", Name: "Pavonis Mons", NameSR: "Pavonis", Type: FeatureMons, Lat: 1.48, Lon: -112.96, DiameterKm: 375},
+		{Body: "Mars", Name: "Arsia Mons", NameSR: "Arsija", Type: FeatureMons, Lat: -8.26, Lon: -121.27, DiameterKm: 435},
+		{Body: "Mars", Name: "Elysium Mons", NameSR: "Elizijum", Type: FeatureMons, Lat: 24.8, Lon: 146.9, DiameterKm: 401},
+		{Body: "Mars", Name: "Valles Marineris", NameSR: "Dolina Marinera", Type: FeatureVallis, Lat: -13.9, Lon: -59.2, DiameterKm: 4000},
+		{Body: "Mars", Name: "Hellas Planitia", NameSR: "Helas ravnica", Type: FeaturePlanitia, Lat: -42.4, Lon: 70.5, DiameterKm: 2300},
+		{Body: "Mars", Name: "Argyre Planitia", NameSR: "Argir ravnica", Type: FeaturePlanitia, Lat: -49.7, Lon: -44.0, DiameterKm: 1800},
+		{Body: "Mars", Name: "Utopia Planitia", NameSR: "Utopija ravnica", Type: FeaturePlanitia, Lat: 46.7, Lon: 117.5, DiameterKm: 3300},
+		{Body: "Mars", Name: "Syrtis Major Planum", NameSR: "Velika Sirta", Type: FeaturePlanum, Lat: 8.4, Lon: 69.5, DiameterKm: 1350},
+		{Body: "Mars", Name: "Gale", NameSR: "Gejl", Type: FeatureCrater, Lat: -5.4, Lon: 137.8, DiameterKm: 154},
+		{Body: "Mars", Name: "Jezero", NameSR: "Jezero", Type: FeatureCrater, Lat: 18.38, Lon: 77.58, DiameterKm: 49},
+
+		{Body: "Titan", Name: "Kraken Mare", NameSR: "More Kraken", Type: FeatureMare, Lat: 68.0, Lon: 50.0, DiameterKm: 1170},
+		{Body: "Titan", Name: "Ligeia Mare", NameSR: "More Ligeja", Type: FeatureMare, Lat: 79.0, Lon: 112.0, DiameterKm: 500},
+	}
+}
@@ -0,0 +1,114 @@
+package models
+
+// GlossaryTerm is an astronomical term explained for readers of the body
+// pages. Text is keyed by locale like a tour's narration; "sr" is
+// required. Aliases are the other forms the term takes in running text
+// (plurals, Serbian cases), so it can be found there for tooltips.
+type GlossaryTerm struct {
+	ID         string              `json:"id"` // lowercase slug, e.g. "perihelion"
+	Term       map[string]string   `json:"term"`
+	Definition map[string]string   `json:"definition"`
+	Aliases    map[string][]string `json:"aliases,omitempty"`
+	Bodies     []string            `json:"bodies,omitempty"`  // English names of bodies that show the term well
+	Related    []string            `json:"related,omitempty"` // IDs of related terms
+}
+
+// GetGlossary returns the built-in glossary in ID order
+func GetGlossary() []GlossaryTerm {
+	term := func(id, sr, en, srDef, enDef string) GlossaryTerm {
+		return GlossaryTerm{
+			ID:         id,
+			Term:       map[string]string{"sr": sr, "en": en},
+			Definition: map[string]string{"sr": srDef, "en": enDef},
+		}
+	}
+	with := func(t GlossaryTerm, srAliases, enAliases, bodies, related []string) GlossaryTerm {
+		t.Aliases = map[string][]string{}
+		if len(srAliases) > 0 {
+			t.Aliases["sr"] = srAliases
+		}
+		if len(enAliases) > 0 {
+			t.Aliases["en"] = enAliases
+		}
+		t.Bodies, t.Related = bodies, related
+		return t
+	}
+	return []GlossaryTerm{
+		with(term("albedo", "Albedo", "Albedo",
+			"Deo Sunčeve svetlosti koji telo odbija, od 0 (potpuno crno) do 1 (savršeno belo). Oblaci i led povećavaju albedo, a tamne stene ga smanjuju.",
+			"The share of sunlight a body reflects, from 0 (perfectly black) to 1 (perfectly white). Clouds and ice raise it; dark rock lowers it."),
+			[]string{"albeda", "albedom"}, nil, []string{"Venus", "Moon"}, nil),
+		with(term("aphelion", "Afel", "Aphelion",
+			"Tačka putanje u kojoj je telo najdalje od Sunca.",
+			"The point of an orbit where a body is farthest from the Sun."),
+			[]string{"afela", "afelu", "afelom"}, []string{"aphelia"}, []string{"Earth", "Mars"}, []string{"perihelion", "eccentricity"}),
+		with(term("astronomical-unit", "Astronomska jedinica", "Astronomical unit",
+			"Srednja udaljenost Zemlje od Sunca, oko 149,6 miliona km. Skraćeno AJ, služi za udaljenosti u Solarnom sistemu.",
+			"Earth's average distance from the Sun, about 149.6 million km. Written AU, it is the yardstick for distances in the Solar System."),
+			[]string{"astronomske jedinice", "astronomskih jedinica", "AJ"}, []string{"astronomical units", "AU"}, []string{"Earth"}, nil),
+		with(term("atmosphere", "Atmosfera", "Atmosphere",
+			"Omotač gasova koji gravitacija drži oko planete ili meseca.",
+			"The layer of gases held around a planet or moon by its gravity."),
+			[]string{"atmosfere", "atmosferi", "atmosferu", "atmosferom"}, []string{"atmospheres"}, []string{"Venus", "Earth", "Titan"}, []string{"density"}),
+		with(term("axial-tilt", "Nagib ose", "Axial tilt",
+			"Ugao između ose rotacije tela i normale na ravan njegove putanje. Zemljin nagib od oko 23,4° stvara godišnja doba.",
+			"The angle between a body's spin axis and the perpendicular to its orbit. Earth's tilt of about 23.4° gives it seasons."),
+			[]string{"nagiba ose", "osa rotacije", "ose rotacije"}, []string{"obliquity", "rotation axis"}, []string{"Earth", "Uranus"}, []string{"retrograde"}),
+		with(term("density", "Gustina", "Density",
+			"Masa po jedinici zapremine. Stenovite planete su gušće od vode, a Saturn je ređi od nje.",
+			"Mass per unit of volume. Rocky planets are denser than water; Saturn is less dense than it."),
+			[]string{"gustine", "gustini", "gustinu", "gustinom"}, []string{"dense"}, []string{"Earth", "Saturn"}, nil),
+		with(term("dwarf-planet", "Patuljasta planeta", "Dwarf planet",
+			"Telo koje obilazi Sunce i dovoljno je veliko da bude okruglo, ali nije očistilo okolinu svoje putanje od drugih tela.",
+			"A body that orbits the Sun and is massive enough to be round, but has not cleared the neighbourhood of its orbit."),
+			[]string{"patuljaste planete", "patuljastih planeta", "patuljastu planetu"}, []string{"dwarf planets"}, nil, []string{"orbit"}),
+		with(term("eccentricity", "Ekscentricitet", "Eccentricity",
+			"Koliko je putanja izdužena: 0 je krug, a što je bliže 1, elipsa je izduženija.",
+			"How stretched an orbit is: 0 is a circle, and the closer to 1, the longer the ellipse."),
+			[]string{"ekscentriciteta", "ekscentricitetom"}, nil, []string{"Mercury", "Mars"}, []string{"perihelion", "aphelion", "orbit"}),
+		with(term("ecliptic", "Ekliptika", "Ecliptic",
+			"Ravan Zemljine putanje oko Sunca, od koje se mere nagibi putanja ostalih tela. Na nebu je to put kojim Sunce prividno prolazi tokom godine.",
+			"The plane of Earth's orbit around the Sun, from which the tilts of other orbits are measured. In the sky it is the Sun's yearly path."),
+			[]string{"ekliptike", "ekliptici", "ekliptiku"}, nil, []string{"Earth"}, []string{"inclination"}),
+		with(term("escape-velocity", "Brzina oslobađanja", "Escape velocity",
+			"Najmanja brzina kojom telo mora da krene sa površine da bi se bez pogona oslobodilo gravitacije planete.",
+			"The lowest speed something must leave a surface with to break free of the body's gravity without further thrust."),
+			[]string{"brzine oslobađanja", "brzinu oslobađanja"}, nil, []string{"Earth", "Jupiter", "Deimos"}, nil),
+		with(term("gas-giant", "Gasni džin", "Gas giant",
+			"Velika planeta sastavljena uglavnom od vodonika i helijuma, bez čvrste površine.",
+			"A large planet made mostly of hydrogen and helium, with no solid surface."),
+			[]string{"gasnog džina", "gasni džinovi", "gasnih džinova"}, []string{"gas giants"}, []string{"Jupiter", "Saturn"}, []string{"ice-giant"}),
+		with(term("habitable-zone", "Nastanjiva zona", "Habitable zone",
+			"Pojas oko zvezde u kome planeta može da ima tečnu vodu na površini.",
+			"The band around a star where a planet could keep liquid water on its surface."),
+			[]string{"nastanjive zone", "nastanjivoj zoni", "nastanjivu zonu"}, nil, []string{"Earth"}, nil),
+		with(term("ice-giant", "Ledeni džin", "Ice giant",
+			"Planeta ispod čijeg omotača vodonika i helijuma je najviše „leda”: vode, amonijaka i metana.",
+			"A giant planet whose bulk, under a hydrogen and helium envelope, is mostly \"ices\": water, ammonia and methane."),
+			[]string{"ledenog džina", "ledeni džinovi", "ledenih džinova"}, []string{"ice giants"}, []string{"Uranus", "Neptune"}, []string{"gas-giant"}),
+		with(term("inclination", "Inklinacija", "Inclination",
+			"Ugao za koji je ravan putanje nagnuta prema ekliptici.",
+			"The angle by which the plane of an orbit is tilted to the ecliptic."),
+			[]string{"inklinacije", "inklinaciju", "nagib putanje"}, nil, []string{"Mercury"}, []string{"ecliptic", "orbit"}),
+		with(term("orbit", "Orbita", "Orbit",
+			"Putanja kojom jedno telo obilazi drugo pod dejstvom gravitacije.",
+			"The path one body follows around another under gravity."),
+			[]string{"orbite", "orbiti", "orbitu", "orbitom", "putanja", "putanje", "putanji", "putanju", "putanjom"}, []string{"orbits"}, nil, []string{"eccentricity", "inclination"}),
+		with(term("perihelion", "Perihel", "Perihelion",
+			"Tačka putanje u kojoj je telo najbliže Suncu.",
+			"The point of an orbit where a body is closest to the Sun."),
+			[]string{"perihela", "perihelu", "perihelom"}, []string{"perihelia"}, []string{"Mercury", "Earth"}, []string{"aphelion", "eccentricity"}),
+		with(term("plasma", "Plazma", "Plasma",
+			"Gas toliko vreo da su mu atomi izgubili elektrone, pa provodi struju. Od plazme su zvezde.",
+			"Gas so hot its atoms have lost electrons, so it conducts electricity. Stars are made of it."),
+			[]string{"plazme", "plazmi", "plazmu", "plazmom"}, nil, []string{"Sun"}, nil),
+		with(term("retrograde", "Retrogradno kretanje", "Retrograde motion",
+			"Kretanje ili okretanje u smeru suprotnom od uobičajenog u Solarnom sistemu.",
+			"Moving or spinning the opposite way to most of the Solar System."),
+			[]string{"retrogradno", "retrogradna", "retrogradnom", "retrogradnu"}, []string{"retrograde"}, []string{"Venus", "Uranus"}, []string{"axial-tilt"}),
+		with(term("roche-limit", "Rošova granica", "Roche limit",
+			"Najmanja udaljenost na kojoj mesec može da obilazi planetu a da ga plimske sile ne rastrgnu.",
+			"The closest a moon can orbit a planet without being torn apart by tidal forces."),
+			[]string{"Rošove granice", "Rošovoj granici", "Rošovu granicu"}, nil, []string{"Saturn", "Phobos"}, []string{"orbit"}),
+	}
+}
@@ -0,0 +1,223 @@
+package models
+
+import "time"
+
+// Kinds of HistoricalEvent
+const (
+	HistoryDiscovery = "discovery"
+	HistoryLaunch    = "launch"
+	HistoryLanding   = "landing"
+	HistoryFlyby     = "flyby"
+	HistoryOrbit     = "orbit"
+	HistoryCrewed    = "crewed"
+	HistoryMilestone = "milestone"
+)
+
+// HistoricalEvent is a day in the history of astronomy and spaceflight,
+// for "on this day" anniversaries. Dates are UTC, or the local date the
+// event is remembered by when it fell around midnight. Text is keyed by
+// locale like a tour's narration; "sr" is required.
+type HistoricalEvent struct {
+	Year  int               `json:"year"`
+	Month time.Month        `json:"month"`
+	Day   int               `json:"day"`
+	Kind  string            `json:"kind"`
+	Body  string            `json:"body,omitempty"` // English name of the body it concerns, if one
+	Text  map[string]string `json:"text"`
+}
+
+// GetHistoricalEvents returns the built-in events in calendar order
+func GetHistoricalEvents() []HistoricalEvent {
+	ev := func(y int, m time.Month, d int, kind, body, sr, en string) HistoricalEvent {
+		return HistoricalEvent{Year: y, Month: m, Day: d, Kind: kind, Body: body, Text: map[string]string{"sr": sr, "en": en}}
+	}
+	return []HistoricalEvent{
+		ev(1801, time.January, 1, HistoryDiscovery, "Ceres", "Đuzepe Pjaci otkriva Ceres, prvo telo asteroidnog pojasa.",
+			"Giuseppe Piazzi discovers Ceres, the first body found in the asteroid belt."),
+		ev(2019, time.January, 1, HistoryFlyby, "", "New Horizons proleće pored Arokota, najudaljenijeg tela koje je neka letelica posetila.",
+			"New Horizons flies past Arrokoth, the most distant object ever visited by a spacecraft."),
+		ev(1959, time.January, 2, HistoryLaunch, "Moon", "Lansirana je Luna 1, prva letelica koja je napustila Zemljinu gravitaciju i prošla pored Meseca.",
+			"Luna 1 launches, the first spacecraft to escape Earth's gravity and pass the Moon."),
+		ev(2019, time.January, 3, HistoryLanding, "Moon", "Čang'e 4 se spušta na daleku stranu Meseca, prvi put u istoriji.",
+			"Chang'e 4 makes the first ever landing on the far side of the Moon."),
+		ev(2004, time.January, 4, HistoryLanding, "Mars", "Rover Spirit se spušta u krater Gusev na Marsu.",
+			"The Spirit rover lands in Gusev crater on Mars."),
+		ev(1610, time.January, 7, HistoryDiscovery, "Jupiter", "Galileo Galilej durbinom uočava tri od četiri velika Jupiterova meseca.",
+			"Galileo Galilei spots three of Jupiter's four large moons through his telescope."),
+		ev(2005, time.January, 14, HistoryLanding, "Titan", "Sonda Hajgens se spušta na Titan, najudaljenije sletanje do danas.",
+			"The Huygens probe lands on Titan, still the most distant landing ever made."),
+		ev(2006, time.January, 19, HistoryLaunch, "Pluto", "Lansirana je letelica New Horizons, na putu ka Plutonu.",
+			"New Horizons launches on its way to Pluto."),
+		ev(1986, time.January, 24, HistoryFlyby, "Uranus", "Vojadžer 2 proleće pored Urana, jedina letelica koja ga je posetila.",
+			"Voyager 2 flies past Uranus, the only spacecraft ever to visit it."),
+		ev(2004, time.January, 25, HistoryLanding, "Mars", "Rover Opportunity se spušta na Meridijanijevu ravnicu; radiće skoro 15 godina.",
+			"The Opportunity rover lands on Meridiani Planum; it will keep working for almost 15 years."),
+		ev(1986, time.January, 28, HistoryCrewed, "", "Šatl Čelendžer se raspada 73 sekunde posle lansiranja; stradalo je sedam astronauta.",
+			"Space Shuttle Challenger breaks apart 73 seconds after launch, killing its crew of seven."),
+		ev(1958, time.January, 31, HistoryLaunch, "Earth", "Lansiran je Explorer 1, prvi američki satelit, koji otkriva Van Alenove pojaseve.",
+			"Explorer 1, the first American satellite, launches and discovers the Van Allen belts."),
+
+		ev(2003, time.February, 1, HistoryCrewed, "", "Šatl Kolumbija se raspada pri povratku u atmosferu; stradalo je sedam astronauta.",
+			"Space Shuttle Columbia breaks up on re-entry, killing its crew of seven."),
+		ev(1966, time.February, 3, HistoryLanding, "Moon", "Luna 9 izvodi prvo meko sletanje na Mesec i šalje prve fotografije sa površine.",
+			"Luna 9 makes the first soft landing on the Moon and sends back the first pictures from its surface."),
+		ev(2001, time.February, 12, HistoryLanding, "", "NEAR Šumejker se spušta na asteroid Eros, prvo sletanje na asteroid.",
+			"NEAR Shoemaker touches down on asteroid Eros, the first landing on an asteroid."),
+		ev(1990, time.February, 14, HistoryMilestone, "Earth", "Vojadžer 1 snima „Bledu plavu tačku”, Zemlju sa udaljenosti od šest milijardi kilometara.",
+			"Voyager 1 takes the \"Pale Blue Dot\" photograph of Earth from six billion kilometres away."),
+		ev(1930, time.February, 18, HistoryDiscovery, "Pluto", "Klajd Tombo otkriva Pluton na fotografskim pločama Louelove opservatorije.",
+			"Clyde Tombaugh discovers Pluto on photographic plates at Lowell Observatory."),
+		ev(2021, time.February, 18, HistoryLanding, "Mars", "Rover Perseverance se spušta u krater Jezero na Marsu, sa helikopterom Ingenuity.",
+			"The Perseverance rover lands in Jezero crater on Mars, carrying the Ingenuity helicopter."),
+		ev(1962, time.February, 20, HistoryCrewed, "Earth", "Džon Glen postaje prvi Amerikanac koji je obleteo Zemlju.",
+			"John Glenn becomes the first American to orbit Earth."),
+		ev(1986, time.February, 20, HistoryLaunch, "Earth", "Lansiran je osnovni modul stanice Mir.",
+			"The core module of the Mir space station launches."),
+
+		ev(1966, time.March, 1, HistoryMilestone, "Venus", "Venera 3 udara u Veneru, prva letelica koja je dospela na drugu planetu.",
+			"Venera 3 crashes on Venus, the first spacecraft to reach another planet."),
+		ev(1979, time.March, 5, HistoryFlyby, "Jupiter", "Vojadžer 1 prolazi najbliže Jupiteru i snima njegove mesece izbliza.",
+			"Voyager 1 makes its closest approach to Jupiter and images its moons up close."),
+		ev(2009, time.March, 7, HistoryLaunch, "", "Lansiran je teleskop Kepler, koji će otkriti hiljade egzoplaneta.",
+			"The Kepler space telescope launches; it will find thousands of exoplanets."),
+		ev(1979, time.March, 9, HistoryDiscovery, "Io", "Linda Morabito na snimku Vojadžera 1 otkriva vulkansku erupciju na Iu.",
+			"Linda Morabito spots a volcanic plume on Io in a Voyager 1 image."),
+		ev(1781, time.March, 13, HistoryDiscovery, "Uranus", "Vilijam Heršel otkriva Uran, prvu planetu pronađenu teleskopom.",
+			"William Herschel discovers Uranus, the first planet found with a telescope."),
+		ev(1926, time.March, 16, HistoryMilestone, "", "Robert Godard lansira prvu raketu na tečno gorivo.",
+			"Robert Goddard launches the first liquid-fuelled rocket."),
+		ev(1965, time.March, 18, HistoryCrewed, "", "Aleksej Leonov izvodi prvu šetnju svemirom.",
+			"Alexei Leonov makes the first spacewalk."),
+		ev(2011, time.March, 18, HistoryOrbit, "Mercury", "MESSENGER ulazi u orbitu oko Merkura, prva letelica koja ga je obilazila.",
+			"MESSENGER enters orbit around Mercury, the first spacecraft to do so."),
+		ev(1655, time.March, 25, HistoryDiscovery, "Titan", "Kristijan Hajgens otkriva Titan, najveći Saturnov mesec.",
+			"Christiaan Huygens discovers Titan, Saturn's largest moon."),
+		ev(1974, time.March, 29, HistoryFlyby, "Mercury", "Mariner 10 proleće pored Merkura, prvi put izbliza.",
+			"Mariner 10 makes the first close flyby of Mercury."),
+
+		ev(1961, time.April, 12, HistoryCrewed, "Earth", "Jurij Gagarin u Vostoku 1 postaje prvi čovek u svemiru.",
+			"Yuri Gagarin becomes the first human in space aboard Vostok 1."),
+		ev(1981, time.April, 12, HistoryLaunch, "", "Šatl Kolumbija poleće na STS-1, prvi let spejs-šatla.",
+			"Space Shuttle Columbia lifts off on STS-1, the first Space Shuttle flight."),
+		ev(1970, time.April, 13, HistoryCrewed, "Moon", "Na Apolu 13 eksplodira rezervoar kiseonika; posada se bezbedno vraća četiri dana kasnije.",
+			"An oxygen tank explodes on Apollo 13; the crew makes it home safely four days later."),
+		ev(1971, time.April, 19, HistoryLaunch, "Earth", "Lansiran je Saljut 1, prva svemirska stanica.",
+			"Salyut 1, the first space station, launches."),
+		ev(1990, time.April, 24, HistoryLaunch, "", "Šatl Diskaveri nosi u orbitu svemirski teleskop Habl.",
+			"Space Shuttle Discovery carries the Hubble Space Telescope into orbit."),
+
+		ev(1961, time.May, 5, HistoryCrewed, "", "Alan Šepard postaje prvi Amerikanac u svemiru.",
+			"Alan Shepard becomes the first American in space."),
+		ev(1973, time.May, 14, HistoryLaunch, "Earth", "Lansiran je Skajlab, prva američka svemirska stanica.",
+			"Skylab, the first American space station, launches."),
+		ev(2008, time.May, 25, HistoryLanding, "Mars", "Feniks se spušta blizu severnog pola Marsa i nalazi led ispod tla.",
+			"Phoenix lands near Mars' north pole and finds ice beneath the soil."),
+		ev(2020, time.May, 30, HistoryCrewed, "", "Krju Dragon Demo-2 nosi astronaute na Međunarodnu svemirsku stanicu, prvi let privatne letelice sa posadom u orbitu.",
+			"Crew Dragon Demo-2 carries astronauts to the International Space Station, the first crewed orbital flight of a commercial spacecraft."),
+
+		ev(1963, time.June, 16, HistoryCrewed, "", "Valentina Tereškova postaje prva žena u svemiru.",
+			"Valentina Tereshkova becomes the first woman in space."),
+		ev(1983, time.June, 18, HistoryCrewed, "", "Sali Rajd postaje prva Amerikanka u svemiru.",
+			"Sally Ride becomes the first American woman in space."),
+		ev(2018, time.June, 27, HistoryOrbit, "", "Hajabusa2 stiže do asteroida Rjugu.",
+			"Hayabusa2 arrives at asteroid Ryugu."),
+		ev(1908, time.June, 30, HistoryMilestone, "Earth", "Tunguski događaj: eksplozija nebeskog tela iznad Sibira ruši oko 80 miliona stabala.",
+			"The Tunguska event: an incoming space rock explodes over Siberia, flattening some 80 million trees."),
+
+		ev(2004, time.July, 1, HistoryOrbit, "Saturn", "Kasini ulazi u orbitu oko Saturna.",
+			"Cassini enters orbit around Saturn."),
+		ev(1997, time.July, 4, HistoryLanding, "Mars", "Mars Patfajnder se spušta na Mars, a rover Sodžurner postaje prvo vozilo na drugoj planeti.",
+			"Mars Pathfinder lands on Mars, and its Sojourner rover becomes the first to roam another planet."),
+		ev(2016, time.July, 5, HistoryOrbit, "Jupiter", "Juno ulazi u orbitu oko Jupitera.",
+			"Juno enters orbit around Jupiter."),
+		ev(2015, time.July, 14, HistoryFlyby, "Pluto", "New Horizons proleće pored Plutona i otkriva ledeno srce Tombove oblasti.",
+			"New Horizons flies past Pluto and reveals the icy heart of Tombaugh Regio."),
+		ev(1965, time.July, 15, HistoryFlyby, "Mars", "Mariner 4 šalje prve fotografije Marsa izbliza.",
+			"Mariner 4 returns the first close-up pictures of Mars."),
+		ev(1969, time.July, 16, HistoryLaunch, "Moon", "Saturn V lansira Apolo 11 ka Mesecu.",
+			"A Saturn V launches Apollo 11 towards the Moon."),
+		ev(1994, time.July, 16, HistoryMilestone, "Jupiter", "Prvi od 21 delića komete Šumejker–Levi 9 udara u Jupiter.",
+			"The first of 21 fragments of comet Shoemaker–Levy 9 slams into Jupiter."),
+		ev(1969, time.July, 20, HistoryCrewed, "Moon", "Nil Armstrong i Baz Oldrin sa Apola 11 prvi hodaju po Mesecu.",
+			"Neil Armstrong and Buzz Aldrin of Apollo 11 become the first people to walk on the Moon."),
+		ev(1976, time.July, 20, HistoryLanding, "Mars", "Viking 1 se spušta na Mars i šalje prvu fotografiju sa njegove površine.",
+			"Viking 1 lands on Mars and sends back the first picture from its surface."),
+
+		ev(2011, time.August, 5, HistoryLaunch, "Jupiter", "Lansirana je letelica Juno, na putu ka Jupiteru.",
+			"Juno launches on its way to Jupiter."),
+		ev(2012, time.August, 6, HistoryLanding, "Mars", "Rover Kjuriositi se spušta u krater Gejl pomoću „nebeske dizalice”.",
+			"The Curiosity rover lands in Gale crater, lowered by a \"sky crane\"."),
+		ev(1877, time.August, 12, HistoryDiscovery, "Deimos", "Asaf Hol otkriva Deimos, manji Marsov mesec.",
+			"Asaph Hall discovers Deimos, the smaller moon of Mars."),
+		ev(1877, time.August, 18, HistoryDiscovery, "Phobos", "Asaf Hol otkriva Fobos, šest dana posle Deimosa.",
+			"Asaph Hall discovers Phobos, six days after Deimos."),
+		ev(1977, time.August, 20, HistoryLaunch, "", "Lansiran je Vojadžer 2, jedina letelica koja je posetila Uran i Neptun.",
+			"Voyager 2 launches; it will be the only spacecraft to visit Uranus and Neptune."),
+		ev(2023, time.August, 23, HistoryLanding, "Moon", "Čandrajan-3 se spušta blizu Mesečevog južnog pola, prvi put u istoriji.",
+			"Chandrayaan-3 makes the first landing near the Moon's south pole."),
+		ev(2006, time.August, 24, HistoryMilestone, "Pluto", "Međunarodna astronomska unija Pluton svrstava u patuljaste planete.",
+			"The International Astronomical Union reclassifies Pluto as a dwarf planet."),
+		ev(1989, time.August, 25, HistoryFlyby, "Neptune", "Vojadžer 2 proleće pored Neptuna, jedina letelica koja ga je posetila.",
+			"Voyager 2 flies past Neptune, the only spacecraft ever to visit it."),
+
+		ev(1977, time.September, 5, HistoryLaunch, "", "Lansiran je Vojadžer 1, danas najudaljeniji predmet koji je napravio čovek.",
+			"Voyager 1 launches; today it is the most distant human-made object."),
+		ev(1959, time.September, 13, HistoryMilestone, "Moon", "Luna 2 udara u Mesec, prvi predmet napravljen ljudskom rukom koji je dospeo na drugo nebesko telo.",
+			"Luna 2 hits the Moon, the first human-made object to reach another celestial body."),
+		ev(2017, time.September, 15, HistoryMilestone, "Saturn", "Kasini završava misiju uranjanjem u Saturnovu atmosferu.",
+			"Cassini ends its mission with a plunge into Saturn's atmosphere."),
+		ev(1846, time.September, 23, HistoryDiscovery, "Neptune", "Johan Gale pronalazi Neptun tamo gde ga je Urben le Verje predvideo računom.",
+			"Johann Galle finds Neptune where Urbain Le Verrier's calculations predicted it."),
+		ev(2014, time.September, 24, HistoryOrbit, "Mars", "Indijski Mangaljan ulazi u orbitu oko Marsa iz prvog pokušaja.",
+			"India's Mangalyaan enters orbit around Mars on the country's first attempt."),
+		ev(2023, time.September, 24, HistoryLanding, "Earth", "Kapsula OSIRIS-REx spušta na Zemlju uzorak asteroida Benu.",
+			"OSIRIS-REx's capsule delivers a sample of asteroid Bennu to Earth."),
+		ev(2022, time.September, 26, HistoryMilestone, "", "DART namerno udara u asteroid Dimorf i skraćuje mu orbitu, prvi test planetarne odbrane.",
+			"DART deliberately crashes into asteroid Dimorphos and shortens its orbit, the first planetary defence test."),
+
+		ev(1957, time.October, 4, HistoryLaunch, "Earth", "Lansiran je Sputnjik 1, prvi veštački satelit; počinje svemirsko doba.",
+			"Sputnik 1, the first artificial satellite, launches and the space age begins."),
+		ev(1959, time.October, 7, HistoryFlyby, "Moon", "Luna 3 snima prve fotografije daleke strane Meseca.",
+			"Luna 3 takes the first pictures of the far side of the Moon."),
+		ev(1997, time.October, 15, HistoryLaunch, "Saturn", "Lansirana je letelica Kasini-Hajgens, na putu ka Saturnu.",
+			"Cassini–Huygens launches on its way to Saturn."),
+		ev(2003, time.October, 15, HistoryCrewed, "", "Jang Livej u Šenđouu 5 postaje prvi kineski astronaut.",
+			"Yang Liwei becomes China's first astronaut aboard Shenzhou 5."),
+		ev(2020, time.October, 20, HistoryLanding, "", "OSIRIS-REx dodiruje asteroid Benu i uzima uzorak.",
+			"OSIRIS-REx touches asteroid Bennu and collects a sample."),
+		ev(1975, time.October, 22, HistoryLanding, "Venus", "Venera 9 se spušta na Veneru i šalje prvu fotografiju sa površine druge planete.",
+			"Venera 9 lands on Venus and sends back the first picture from the surface of another planet."),
+
+		ev(2000, time.November, 2, HistoryCrewed, "Earth", "Prva posada stiže na Međunarodnu svemirsku stanicu, na kojoj od tada stalno borave ljudi.",
+			"The first crew arrives at the International Space Station, continuously occupied ever since."),
+		ev(1957, time.November, 3, HistoryLaunch, "Earth", "Sputnjik 2 nosi u orbitu kuju Lajku, prvo živo biće koje je obletelo Zemlju.",
+			"Sputnik 2 carries the dog Laika, the first living creature to orbit Earth."),
+		ev(1980, time.November, 12, HistoryFlyby, "Saturn", "Vojadžer 1 proleće pored Saturna i Titana.",
+			"Voyager 1 flies past Saturn and Titan."),
+		ev(2014, time.November, 12, HistoryLanding, "", "Lender Filae se spušta na kometu Čurjumov–Gerasimenko, prvo sletanje na kometu.",
+			"The Philae lander touches down on comet Churyumov–Gerasimenko, the first landing on a comet."),
+		ev(1971, time.November, 14, HistoryOrbit, "Mars", "Mariner 9 ulazi u orbitu oko Marsa, prva letelica koja je obilazila drugu planetu.",
+			"Mariner 9 enters orbit around Mars, the first spacecraft to orbit another planet."),
+		ev(1969, time.November, 19, HistoryCrewed, "Moon", "Apolo 12 se precizno spušta pored sonde Servejor 3 u Okeanu oluja.",
+			"Apollo 12 makes a pinpoint landing beside the Surveyor 3 probe in the Ocean of Storms."),
+		ev(1998, time.November, 20, HistoryLaunch, "Earth", "Lansiran je Zarja, prvi modul Međunarodne svemirske stanice.",
+			"Zarya, the first module of the International Space Station, launches."),
+		ev(2018, time.November, 26, HistoryLanding, "Mars", "InSight se spušta na Mars da bi osluškivao marsotrese.",
+			"InSight lands on Mars to listen for marsquakes."),
+
+		ev(1973, time.December, 3, HistoryFlyby, "Jupiter", "Pionir 10 proleće pored Jupitera, prvi put izbliza.",
+			"Pioneer 10 makes the first close flyby of Jupiter."),
+		ev(1995, time.December, 7, HistoryOrbit, "Jupiter", "Galileo ulazi u orbitu oko Jupitera, a njegova sonda u Jupiterovu atmosferu.",
+			"Galileo arrives at Jupiter as its probe plunges into the planet's atmosphere."),
+		ev(1962, time.December, 14, HistoryFlyby, "Venus", "Mariner 2 proleće pored Venere, prvi uspešan prelet druge planete.",
+			"Mariner 2 flies past Venus, the first successful flyby of another planet."),
+		ev(1972, time.December, 14, HistoryCrewed, "Moon", "Judžin Sernan sa Apola 17 ostavlja poslednje ljudske tragove na Mesecu.",
+			"Eugene Cernan of Apollo 17 leaves the last human footprints on the Moon."),
+		ev(1970, time.December, 15, HistoryLanding, "Venus", "Venera 7 se spušta na Veneru, prvo uspešno sletanje na drugu planetu.",
+			"Venera 7 lands on Venus, the first successful landing on another planet."),
+		ev(1968, time.December, 24, HistoryCrewed, "Moon", "Posada Apola 8 obilazi Mesec i snima „Izlazak Zemlje”.",
+			"The Apollo 8 crew orbit the Moon and photograph \"Earthrise\"."),
+		ev(2021, time.December, 25, HistoryLaunch, "", "Lansiran je svemirski teleskop Džejms Veb.",
+			"The James Webb Space Telescope launches."),
+	}
+}
@@ -0,0 +1,68 @@
+package models
+
+// HostStar is a nearby or well-known star with confirmed planets, used
+// to compare other planetary systems with ours
+type HostStar struct {
+	Name         string      `json:"name"`
+	NameSR       string      `json:"name_sr"`
+	SpectralType string      `json:"spectral_type"`
+	Luminosity   float64     `json:"luminosity"`  // solar luminosities
+	Temperature  float64     `json:"temperature"` // K, effective
+	Mass         float64     `json:"mass"`        // solar masses
+	DistanceLy   float64     `json:"distance_ly"` // light-years from the Sun
+	Planets      []Exoplanet `json:"planets"`
+}
+
+// Exoplanet is a planet orbiting a HostStar
+type Exoplanet struct {
+	Name          string  `json:"name"`
+	SemiMajorAxis float64 `json:"semi_major_axis"`  // AU
+	OrbitalPeriod float64 `json:"orbital_period"`   // Earth days
+	Radius        float64 `json:"radius,omitempty"` // Earth radii; 0 when only the minimum mass is known
+	Discovered    int     `json:"discovered"`       // year
+}
+
+// GetHostStars returns exoplanet host stars with published luminosities
+// and orbits (NASA Exoplanet Archive)
+func GetHostStars() []HostStar {
+	return []HostStar{
+		{Name: "Proxima Centauri", NameSR: "Proksima Kentaura", SpectralType: "M5.5V", Luminosity: 0.00155, Temperature: 3042, Mass: 0.122, DistanceLy: 4.24, Planets: []Exoplanet{
+			{Name: "Proxima Centauri d", SemiMajorAxis: 0.02885, OrbitalPeriod: 5.122, Discovered: 2022},
+			{Name: "Proxima Centauri b", SemiMajorAxis: 0.04857, OrbitalPeriod: 11.187, Discovered: 2016},
+		}},
+		{Name: "Tau Ceti", NameSR: "Tau Ceti", SpectralType: "G8V", Luminosity: 0.52, Temperature: 5344, Mass: 0.783, DistanceLy: 11.9, Planets: []Exoplanet{
+			{Name: "Tau Ceti g", SemiMajorAxis: 0.133, OrbitalPeriod: 20.0, Discovered: 2017},
+			{Name: "Tau Ceti h", SemiMajorAxis: 0.243, OrbitalPeriod: 49.41, Discovered: 2017},
+			{Name: "Tau Ceti e", SemiMajorAxis: 0.538, OrbitalPeriod: 162.87, Discovered: 2017},
+			{Name: "Tau Ceti f", SemiMajorAxis: 1.334, OrbitalPeriod: 636.13, Discovered: 2017},
+		}},
+		{Name: "TRAPPIST-1", NameSR: "TRAPPIST-1", SpectralType: "M8V", Luminosity: 0.000553, Temperature: 2566, Mass: 0.0898, DistanceLy: 40.7, Planets: []Exoplanet{
+			{Name: "TRAPPIST-1 b", SemiMajorAxis: 0.01154, OrbitalPeriod: 1.511, Radius: 1.116, Discovered: 2016},
+			{Name: "TRAPPIST-1 c", SemiMajorAxis: 0.01580, OrbitalPeriod: 2.422, Radius: 1.097, Discovered: 2016},
+			{Name: "TRAPPIST-1 d", SemiMajorAxis: 0.02227, OrbitalPeriod: 4.050, Radius: 0.788, Discovered: 2016},
+			{Name: "TRAPPIST-1 e", SemiMajorAxis: 0.02925, OrbitalPeriod: 6.100, Radius: 0.920, Discovered: 2017},
+			{Name: "TRAPPIST-1 f", SemiMajorAxis: 0.03849, OrbitalPeriod: 9.208, Radius: 1.045, Discovered: 2017},
+			{Name: "TRAPPIST-1 g", SemiMajorAxis: 0.04683, OrbitalPeriod: 12.352, Radius: 1.129, Discovered: 2017},
+			{Name: "TRAPPIST-1 h", SemiMajorAxis: 0.06189, OrbitalPeriod: 18.773, Radius: 0.755, Discovered: 2017},
+		}},
+		{Name: "TOI-700", NameSR: "TOI-700", SpectralType: "M2V", Luminosity: 0.0233, Temperature: 3480, Mass: 0.415, DistanceLy: 101.4, Planets: []Exoplanet{
+			{Name: "TOI-700 b", SemiMajorAxis: 0.0637, OrbitalPeriod: 9.977, Radius: 0.914, Discovered: 2020},
+			{Name: "TOI-700 c", SemiMajorAxis: 0.0925, OrbitalPeriod: 16.051, Radius: 2.63, Discovered: 2020},
+			{Name: "TOI-700 e", SemiMajorAxis: 0.134, OrbitalPeriod: 27.81, Radius: 0.953, Discovered: 2023},
+			{Name: "TOI-700 d", SemiMajorAxis: 0.1633, OrbitalPeriod: 37.426, Radius: 1.073, Discovered: 2020},
+		}},
+		{Name: "Kepler-186", NameSR: "Kepler-186", SpectralType: "M1V", Luminosity: 0.055, Temperature: 3755, Mass: 0.544, DistanceLy: 579, Planets: []Exoplanet{
+			{Name: "Kepler-186 b", SemiMajorAxis: 0.0378, OrbitalPeriod: 3.887, Radius: 1.07, Discovered: 2014},
+			{Name: "Kepler-186 c", SemiMajorAxis: 0.0574, OrbitalPeriod: 7.267, Radius: 1.25, Discovered: 2014},
+			{Name: "Kepler-186 d", SemiMajorAxis: 0.0861, OrbitalPeriod: 13.343, Radius: 1.40, Discovered: 2014},
+			{Name: "Kepler-186 e", SemiMajorAxis: 0.1216, OrbitalPeriod: 22.408, Radius: 1.27, Discovered: 2014},
+			{Name: "Kepler-186 f", SemiMajorAxis: 0.432, OrbitalPeriod: 129.944, Radius: 1.17, Discovered: 2014},
+		}},
+		{Name: "Kepler-22", NameSR: "Kepler-22", SpectralType: "G5V", Luminosity: 0.79, Temperature: 5518, Mass: 0.97, DistanceLy: 640, Planets: []Exoplanet{
+			{Name: "Kepler-22 b", SemiMajorAxis: 0.849, OrbitalPeriod: 289.86, Radius: 2.38, Discovered: 2011},
+		}},
+		{Name: "Kepler-452", NameSR: "Kepler-452", SpectralType: "G2V", Luminosity: 1.2, Temperature: 5757, Mass: 1.04, DistanceLy: 1800, Planets: []Exoplanet{
+			{Name: "Kepler-452 b", SemiMajorAxis: 1.046, OrbitalPeriod: 384.84, Radius: 1.63, Discovered: 2015},
+		}},
+	}
+}
@@ -0,0 +1,94 @@
+package models
+
+// Moon is one of the notable natural satellites the 3D scene draws
+type Moon struct {
+	Name          string     `json:"name"`
+	NameSR        string     `json:"name_sr"`
+	Parent        string     `json:"parent"`          // English name of the planet it orbits
+	Radius        float64    `json:"radius"`          // km, mean
+	SemiMajorAxis float64    `json:"semi_major_axis"` // km from the planet's centre
+	OrbitalPeriod float64    `json:"orbital_period"`  // Earth days, sidereal; negative is retrograde
+	Description   string     `json:"description"`     // Serbian Latin
+	Discovery     *Discovery `json:"discovery,omitempty"`
+	// Text in other locales, as on Planet
+	Translations map[string]Translation `json:"translations,omitempty"`
+}
+
+// GetMoons returns the moons shown in the scene, ordered by planet and
+// distance (NASA/JPL planetary satellite physical parameters)
+func GetMoons() []Moon {
+	galileo := &Discovery{Discoverer: "Galileo Galilei", Year: 1610, Method: DiscoveryTelescope, Observatory: "Padua"}
+	return []Moon{
+		{
+			Name: "Moon", NameSR: "Mesec", Parent: "Earth", Radius: 1737.4, SemiMajorAxis: 384400, OrbitalPeriod: 27.322,
+			Description:  "Mesec se od Zemlje udaljava za oko 3,8 cm godišnje.",
+			Translations: map[string]Translation{"en": {Description: "The Moon drifts about 3.8 cm farther from Earth every year."}},
+		},
+		{
+			Name: "Phobos", NameSR: "Fobos", Parent: "Mars", Radius: 11.27, SemiMajorAxis: 9376, OrbitalPeriod: 0.31891,
+			Discovery:    &Discovery{Discoverer: "Asaph Hall", Year: 1877, Method: DiscoveryTelescope, Observatory: "United States Naval Observatory"},
+			Description:  "Fobos obiđe Mars tri puta dnevno i polako mu se primiče; za nekoliko desetina miliona godina raspašće se u prsten.",
+			Translations: map[string]Translation{"en": {Description: "Phobos circles Mars three times a day and is slowly spiralling in; in a few tens of millions of years it will break up into a ring."}},
+		},
+		{
+			Name: "Deimos", NameSR: "Dejmos", Parent: "Mars", Radius: 6.2, SemiMajorAxis: 23463, OrbitalPeriod: 1.263,
+			Discovery:    &Discovery{Discoverer: "Asaph Hall", Year: 1877, Method: DiscoveryTelescope, Observatory: "United States Naval Observatory"},
+			Description:  "Brzina oslobađanja na Dejmosu je oko 5,6 m/s – koliko trči sprinter.",
+			Translations: map[string]Translation{"en": {Description: "The escape velocity of Deimos is about 5.6 m/s – a sprinter's pace."}},
+		},
+		{
+			Name: "Io", NameSR: "Io", Parent: "Jupiter", Radius: 1821.6, SemiMajorAxis: 421700, OrbitalPeriod: 1.769,
+			Discovery:    galileo,
+			Description:  "Io je vulkanski najaktivnije telo u Solarnom sistemu, sa više od 400 aktivnih vulkana.",
+			Translations: map[string]Translation{"en": {Description: "Io is the most volcanically active body in the Solar System, with more than 400 active volcanoes."}},
+		},
+		{
+			Name: "Europa", NameSR: "Evropa", Parent: "Jupiter", Radius: 1560.8, SemiMajorAxis: 671034, OrbitalPeriod: 3.551,
+			Discovery:    galileo,
+			Description:  "Ispod ledene kore Evrope krije se slani okean sa više vode nego svi okeani na Zemlji zajedno.",
+			Translations: map[string]Translation{"en": {Description: "Under Europa's icy crust lies a salty ocean holding more water than all of Earth's oceans combined."}},
+		},
+		{
+			Name: "Ganymede", NameSR: "Ganimede", Parent: "Jupiter", Radius: 2634.1, SemiMajorAxis: 1070412, OrbitalPeriod: 7.155,
+			Discovery:    galileo,
+			Description:  "Ganimede je najveći mesec u Solarnom sistemu, veći od Merkura, i jedini sa sopstvenim magnetnim poljem.",
+			Translations: map[string]Translation{"en": {Description: "Ganymede is the largest moon in the Solar System, bigger than Mercury, and the only one with its own magnetic field."}},
+		},
+		{
+			Name: "Callisto", NameSR: "Kalisto", Parent: "Jupiter", Radius: 2410.3, SemiMajorAxis: 1882709, OrbitalPeriod: 16.689,
+			Discovery:    galileo,
+			Description:  "Kalisto ima najgušće kraterisanu površinu u Solarnom sistemu, staru oko četiri milijarde godina.",
+			Translations: map[string]Translation{"en": {Description: "Callisto has the most heavily cratered surface in the Solar System, about four billion years old."}},
+		},
+		{
+			Name: "Enceladus", NameSR: "Enkelad", Parent: "Saturn", Radius: 252.1, SemiMajorAxis: 237948, OrbitalPeriod: 1.370,
+			Discovery:    &Discovery{Discoverer: "William Herschel", Year: 1789, Method: DiscoveryTelescope, Observatory: "Slough, England"},
+			Description:  "Gejziri vodene pare sa južnog pola Enkelada hrane Saturnov E prsten.",
+			Translations: map[string]Translation{"en": {Description: "Geysers of water vapour from Enceladus's south pole feed Saturn's E ring."}},
+		},
+		{
+			Name: "Titan", NameSR: "Titan", Parent: "Saturn", Radius: 2574.7, SemiMajorAxis: 1221870, OrbitalPeriod: 15.945,
+			Discovery:    &Discovery{Discoverer: "Christiaan Huygens", Year: 1655, Method: DiscoveryTelescope, Observatory: "The Hague"},
+			Description:  "Titan je jedini mesec sa gustom atmosferom i jedino telo osim Zemlje sa jezerima na površini – od tečnog metana i etana.",
+			Translations: map[string]Translation{"en": {Description: "Titan is the only moon with a thick atmosphere and the only body besides Earth with lakes on its surface – of liquid methane and ethane."}},
+		},
+		{
+			Name: "Titania", NameSR: "Titanija", Parent: "Uranus", Radius: 788.9, SemiMajorAxis: 435910, OrbitalPeriod: 8.706,
+			Discovery:    &Discovery{Discoverer: "William Herschel", Year: 1787, Method: DiscoveryTelescope, Observatory: "Slough, England"},
+			Description:  "Titanija je najveći Uranov mesec; kanjoni na njoj dugi su i do 1500 km.",
+			Translations: map[string]Translation{"en": {Description: "Titania is the largest moon of Uranus; its canyons run for up to 1500 km."}},
+		},
+		{
+			Name: "Oberon", NameSR: "Oberon", Parent: "Uranus", Radius: 761.4, SemiMajorAxis: 583520, OrbitalPeriod: 13.463,
+			Discovery:    &Discovery{Discoverer: "William Herschel", Year: 1787, Method: DiscoveryTelescope, Observatory: "Slough, England"},
+			Description:  "Oberon je izbliza snimljen samo jednom: Vojadžer 2 je 1986. video manje od polovine njegove površine.",
+			Translations: map[string]Translation{"en": {Description: "Oberon has been seen up close only once: Voyager 2 imaged less than half of its surface in 1986."}},
+		},
+		{
+			Name: "Triton", NameSR: "Triton", Parent: "Neptune", Radius: 1353.4, SemiMajorAxis: 354759, OrbitalPeriod: -5.877,
+			Discovery:    &Discovery{Discoverer: "William Lassell", Year: 1846, Method: DiscoveryTelescope, Observatory: "Liverpool, England"},
+			Description:  "Triton kruži oko Neptuna u smeru suprotnom od rotacije planete – verovatno je zarobljeno telo iz Kojperovog pojasa.",
+			Translations: map[string]Translation{"en": {Description: "Triton orbits Neptune against the planet's spin – it is probably a captured Kuiper belt object."}},
+		},
+	}
+}
@@ -1,182 +1,548 @@
 package models
 
+import "time"
+
 // Planet represents a celestial body in the solar system
 type Planet struct {
-	Name              string   `json:"name"`
-	NameSR            string   `json:"name_sr"`
-	Radius            float64  `json:"radius"`            // km
-	DistanceFromSun   float64  `json:"distance_from_sun"` // AU (semi-major axis)
-	OrbitalPeriod     float64  `json:"orbital_period"`    // Earth days
-	RotationPeriod    float64  `json:"rotation_period"`   // Earth days
-	Color             string   `json:"color"`             // hex color
-	Description       string   `json:"description"`
-	Satellites        int      `json:"satellites"`
-	NotableSatellites []string `json:"notable_satellites"`
-	IsStar            bool     `json:"is_star"`
+	Name            string  `json:"name"`
+	NameSR          string  `json:"name_sr"`
+	WikidataID      string  `json:"wikidata_id,omitempty"` // e.g. "Q111", used for enrichment
+	Radius          float64 `json:"radius"`                // km
+	Mass            float64 `json:"mass,omitempty"`        // kg
+	DistanceFromSun float64 `json:"distance_from_sun"`     // AU (semi-major axis)
+	OrbitalPeriod   float64 `json:"orbital_period"`        // Earth days
+	RotationPeriod  float64 `json:"rotation_period"`       // Earth days, sidereal; negative is retrograde
+	AxialTilt       float64 `json:"axial_tilt"`            // degrees, obliquity to orbital plane
+	Albedo          float64 `json:"albedo,omitempty"`      // Bond albedo
+	// GreenhouseFactor scales the equilibrium temperature to the mean
+	// surface (for the giants, 1-bar) temperature: greenhouse warming and,
+	// for the giants, internal heat. 1 for a bare rock.
+	GreenhouseFactor   float64           `json:"greenhouse_factor,omitempty"`
+	SurfaceTemperature *TemperatureRange `json:"surface_temperature,omitempty"` // measured
+	Color              string            `json:"color"`                         // hex color
+	Description        string            `json:"description"`
+	Satellites         int               `json:"satellites"`
+	NotableSatellites  []string          `json:"notable_satellites"`
+	Rings              *Rings            `json:"rings,omitempty"`
+	IsStar             bool              `json:"is_star"`
 	// Keplerian orbital elements (J2000 epoch)
-	Eccentricity  float64 `json:"eccentricity"`   // 0 = circle, 1 = parabola
-	Inclination   float64 `json:"inclination"`    // degrees, relative to ecliptic
-	AscendingNode float64 `json:"ascending_node"` // degrees, longitude of ascending node (Ω)
+	Eccentricity        float64 `json:"eccentricity"`         // 0 = circle, 1 = parabola
+	Inclination         float64 `json:"inclination"`          // degrees, relative to ecliptic
+	AscendingNode       float64 `json:"ascending_node"`       // degrees, longitude of ascending node (Ω)
+	LongitudePerihelion float64 `json:"longitude_perihelion"` // degrees, ϖ = Ω + ω
+	MeanLongitude       float64 `json:"mean_longitude"`       // degrees, L at the J2000 epoch
+	// Rates, when known, carry the elements away from J2000
+	Rates *OrbitalRates `json:"rates,omitempty"`
+	// Text in other locales, keyed by BCP 47 tag ("en", "sr-Cyrl", …).
+	// Name_sr and Description above are the Serbian Latin base text.
+	Translations map[string]Translation `json:"translations,omitempty"`
+	// Supplementary facts gathered from external sources (mass, images,
+	// discovery), each attributed to where it came from
+	Supplementary map[string]Sourced `json:"supplementary,omitempty"`
+	// Discovery is nil for bodies known since antiquity
+	Discovery *Discovery `json:"discovery,omitempty"`
+}
+
+// Ways a body was discovered
+const (
+	DiscoveryTelescope    = "telescope"    // seen at the eyepiece
+	DiscoveryPhotographic = "photographic" // found on photographic plates
+	DiscoveryCCD          = "ccd"          // electronic sky survey
+	DiscoverySpacecraft   = "spacecraft"   // in a probe's images
+)
+
+// Discovery records who found a body, when and how. Credit and year
+// follow the IAU Minor Planet Center where it rules on them.
+type Discovery struct {
+	Discoverer  string `json:"discoverer"`
+	Year        int    `json:"year"`
+	Method      string `json:"method,omitempty"` // a Discovery* constant
+	Observatory string `json:"observatory,omitempty"`
+}
+
+// TemperatureRange is a measured temperature spread in kelvin; Min and
+// Max are left out where the surface barely varies or isn't solid
+type TemperatureRange struct {
+	Min  float64 `json:"min,omitempty"`
+	Mean float64 `json:"mean"`
+	Max  float64 `json:"max,omitempty"`
+}
+
+// Rings is the radial extent of a ring system, in km from the body's
+// centre
+type Rings struct {
+	InnerRadius float64 `json:"inner_radius"`
+	OuterRadius float64 `json:"outer_radius"`
+}
+
+// OrbitalRates are the secular drifts of a body's orbital elements, per
+// Julian century from J2000, and the years they were fitted over. Positions
+// outside those years are extrapolations.
+type OrbitalRates struct {
+	SemiMajorAxis       float64 `json:"semi_major_axis"`      // AU/century
+	Eccentricity        float64 `json:"eccentricity"`         // per century
+	Inclination         float64 `json:"inclination"`          // degrees/century
+	MeanLongitude       float64 `json:"mean_longitude"`       // degrees/century; 0 takes the mean motion from orbital_period
+	LongitudePerihelion float64 `json:"longitude_perihelion"` // degrees/century
+	AscendingNode       float64 `json:"ascending_node"`       // degrees/century
+	ValidFrom           int     `json:"valid_from"`           // year
+	ValidTo             int     `json:"valid_to"`             // year
+	// Error bounds the heliocentric longitude error within those years,
+	// in arcseconds; 0 when unknown
+	Error float64 `json:"error,omitempty"`
+}
+
+// Sourced is a value with attribution
+type Sourced struct {
+	Value       any       `json:"value"`
+	Source      string    `json:"source"` // URL of the statement the value came from
+	RetrievedAt time.Time `json:"retrieved_at"`
+}
+
+// Translation is a body's text in one locale. Empty fields fall back along
+// the locale chain, so a locale may translate just the name. IPA and
+// Respelling say how Name is pronounced in that locale, for screen
+// readers and the accessibility mode.
+type Translation struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	IPA         string `json:"ipa,omitempty"`        // International Phonetic Alphabet, between slashes
+	Respelling  string `json:"respelling,omitempty"` // e.g. "JOO-pih-tur", stressed syllable in capitals
+	// Long descriptions for screen-reader users of what the 3D view shows:
+	// how the body looks, and how it moves
+	Appearance string `json:"appearance,omitempty"`
+	Orbit      string `json:"orbit,omitempty"`
+	// Simplified text for the kids audience
+	Kids *KidsText `json:"kids,omitempty"`
+}
+
+// Audiences content is written for
+const (
+	AudienceStandard = "standard"
+	AudienceKids     = "kids"
+)
+
+// KidsText is a body's text retold for young readers. Like Translation,
+// each field falls back along the locale chain on its own.
+type KidsText struct {
+	Description string   `json:"description,omitempty"`
+	Facts       []string `json:"facts,omitempty"` // one short sentence each
 }
 
 // GetSolarSystemBodies returns all planets and the Sun with real NASA/J2000 data
 func GetSolarSystemBodies() []Planet {
 	return []Planet{
 		{
-			Name:              "Sun",
-			NameSR:            "Sunce",
-			Radius:            696000,
-			DistanceFromSun:   0,
-			OrbitalPeriod:     0,
-			RotationPeriod:    25.38,
-			Color:             "#FDB813",
-			Description:       "Sunce je zvezda u centru Solarnog sistema. To je gotovo savršena sfera vruće plazme koja greje Zemlju i pruža energiju potrebnu za život.",
-			Satellites:        0,
-			NotableSatellites: []string{},
-			IsStar:            true,
-			Eccentricity:      0,
-			Inclination:       0,
-			AscendingNode:     0,
+			Name:            "Sun",
+			NameSR:          "Sunce",
+			WikidataID:      "Q525",
+			Radius:          696000,
+			Mass:            1.989e30,
+			DistanceFromSun: 0,
+			OrbitalPeriod:   0,
+			RotationPeriod:  25.38,
+			AxialTilt:       7.25,
+			Color:           "#FDB813",
+			Description:     "Sunce je zvezda u centru Solarnog sistema. To je gotovo savršena sfera vruće plazme koja greje Zemlju i pruža energiju potrebnu za život.",
+			Translations: map[string]Translation{
+				"en": {
+					IPA: "/sʌn/", Respelling: "SUN",
+					Description: "The Sun is the star at the centre of the Solar System. It is a nearly perfect sphere of hot plasma that heats the Earth and provides the energy life depends on.",
+					Appearance:  "A blinding white-yellow sphere of glowing gas with no solid surface. Darker sunspots come and go on its face, and its edge looks slightly dimmer than its centre.",
+					Kids: &KidsText{
+						Description: "The Sun is a star, a giant ball of hot glowing gas. It gives us light and keeps Earth warm.",
+						Facts:       []string{"About a million Earths could fit inside the Sun.", "Sunlight takes about 8 minutes to reach Earth."},
+					},
+				},
+				"sr": {
+					Appearance: "Zaslepljujuće belo-žuta lopta užarenog gasa bez čvrste površine. Na njoj se pojavljuju i nestaju tamnije Sunčeve pege, a rub joj je nešto tamniji od sredine.",
+					Kids: &KidsText{
+						Description: "Sunce je zvezda, ogromna lopta vrelog užarenog gasa. Daje nam svetlost i greje Zemlju.",
+						Facts:       []string{"U Sunce bi stalo oko milion Zemalja.", "Sunčevoj svetlosti treba oko 8 minuta do Zemlje."},
+					},
+				},
+			},
+			Satellites:          0,
+			NotableSatellites:   []string{},
+			IsStar:              true,
+			Eccentricity:        0,
+			Inclination:         0,
+			AscendingNode:       0,
+			LongitudePerihelion: 0,
+			MeanLongitude:       0,
 		},
 		{
-			Name:              "Mercury",
-			NameSR:            "Merkur",
-			Radius:            2439.7,
-			DistanceFromSun:   0.387,
-			OrbitalPeriod:     87.97,
-			RotationPeriod:    58.65,
-			Color:             "#B5B5B5",
-			Description:       "Merkur je najbliža planeta Suncu i najmanji planet u Solarnom sistemu. Nema atmosferu, pa su temperature ekstremne - od -180°C do 430°C.",
-			Satellites:        0,
-			NotableSatellites: []string{},
-			IsStar:            false,
-			Eccentricity:      0.2056,
-			Inclination:       7.005,
-			AscendingNode:     48.331,
+			Name:               "Mercury",
+			NameSR:             "Merkur",
+			WikidataID:         "Q308",
+			Radius:             2439.7,
+			Mass:               3.301e23,
+			DistanceFromSun:    0.387,
+			OrbitalPeriod:      87.97,
+			RotationPeriod:     58.65,
+			AxialTilt:          0.034,
+			Albedo:             0.088,
+			GreenhouseFactor:   1.00,
+			SurfaceTemperature: &TemperatureRange{Min: 100, Mean: 440, Max: 700},
+			Color:              "#B5B5B5",
+			Description:        "Merkur je najbliža planeta Suncu i najmanji planet u Solarnom sistemu. Nema atmosferu koja bi zadržala toplotu, pa je razlika između dnevne i noćne strane najveća u Solarnom sistemu.",
+			Translations: map[string]Translation{
+				"en": {
+					IPA: "/ˈmɜːrkjʊri/", Respelling: "MUR-kyuh-ree",
+					Description: "Mercury is the planet closest to the Sun and the smallest in the Solar System. With no atmosphere to hold heat, the gap between its day and night sides is the largest in the Solar System.",
+					Appearance:  "A small grey rocky ball covered in craters, much like Earth's Moon. It has no atmosphere, so its sky is black even in daylight.",
+					Kids: &KidsText{
+						Description: "Mercury is the smallest planet and the closest one to the Sun. Its days are burning hot and its nights are freezing cold.",
+						Facts:       []string{"A year on Mercury lasts only 88 days.", "Mercury has no moons."},
+					},
+				},
+				"sr": {
+					Appearance: "Mala siva kamena kugla prekrivena kraterima, slična Zemljinom Mesecu. Nema atmosferu, pa je nebo iznad nje crno i danju.",
+					Kids: &KidsText{
+						Description: "Merkur je najmanja planeta i najbliža Suncu. Danju je na njemu vrelo, a noću ledeno hladno.",
+						Facts:       []string{"Godina na Merkuru traje samo 88 dana.", "Merkur nema nijedan mesec."},
+					},
+				},
+			},
+			Satellites:          0,
+			NotableSatellites:   []string{},
+			IsStar:              false,
+			Eccentricity:        0.2056,
+			Inclination:         7.005,
+			AscendingNode:       48.331,
+			LongitudePerihelion: 77.458,
+			MeanLongitude:       252.250,
+			Rates:               jplRates(0.00000037, 0.00001906, -0.00594749, 149472.67411175, 0.16047689, -0.12534081, 40),
 		},
 		{
-			Name:              "Venus",
-			NameSR:            "Venera",
-			Radius:            6051.8,
-			DistanceFromSun:   0.723,
-			OrbitalPeriod:     224.70,
-			RotationPeriod:    -243.02,
-			Color:             "#E8CDa2",
-			Description:       "Venera je drugi planet od Sunca i najtopliji planet u Solarnom sistemu sa površinskom temperaturom od oko 465°C. Rotira u suprotnom smeru od većine planeta.",
-			Satellites:        0,
-			NotableSatellites: []string{},
-			IsStar:            false,
-			Eccentricity:      0.0068,
-			Inclination:       3.395,
-			AscendingNode:     76.680,
+			Name:               "Venus",
+			NameSR:             "Venera",
+			WikidataID:         "Q313",
+			Radius:             6051.8,
+			Mass:               4.867e24,
+			DistanceFromSun:    0.723,
+			OrbitalPeriod:      224.70,
+			RotationPeriod:     -243.02,
+			AxialTilt:          177.36,
+			Albedo:             0.76,
+			GreenhouseFactor:   3.22,
+			SurfaceTemperature: &TemperatureRange{Mean: 737},
+			Color:              "#E8CDa2",
+			Description:        "Venera je drugi planet od Sunca i najtopliji planet u Solarnom sistemu, jer gusta atmosfera ugljen-dioksida zadržava toplotu. Rotira u suprotnom smeru od većine planeta.",
+			Translations: map[string]Translation{
+				"en": {
+					IPA: "/ˈviːnəs/", Respelling: "VEE-nuhs",
+					Description: "Venus is the second planet from the Sun and the hottest in the Solar System, because its thick carbon dioxide atmosphere traps heat. It rotates in the opposite direction to most planets.",
+					Appearance:  "A smooth, pale yellow-white globe. Thick clouds of sulphuric acid hide the surface completely, so no features can be seen from space.",
+					Kids: &KidsText{
+						Description: "Venus is the hottest planet, even hotter than Mercury. Thick clouds cover it like a blanket and trap the heat.",
+						Facts:       []string{"Venus spins backwards compared with most planets.", "A day on Venus is longer than its year."},
+					},
+				},
+				"sr": {
+					Appearance: "Glatka, bledo žućkasto-bela kugla. Gusti oblaci sumporne kiseline potpuno skrivaju površinu, pa se iz svemira ne vidi nijedan detalj.",
+					Kids: &KidsText{
+						Description: "Venera je najtoplija planeta, toplija čak i od Merkura. Gusti oblaci je pokrivaju kao ćebe i zadržavaju toplotu.",
+						Facts:       []string{"Venera se okreće unazad u odnosu na većinu planeta.", "Dan na Veneri traje duže od njene godine."},
+					},
+				},
+			},
+			Satellites:          0,
+			NotableSatellites:   []string{},
+			IsStar:              false,
+			Eccentricity:        0.0068,
+			Inclination:         3.395,
+			AscendingNode:       76.680,
+			LongitudePerihelion: 131.602,
+			MeanLongitude:       181.979,
+			Rates:               jplRates(0.00000390, -0.00004107, -0.00078890, 58517.81538729, 0.00268329, -0.27769418, 30),
 		},
 		{
-			Name:              "Earth",
-			NameSR:            "Zemlja",
-			Radius:            6371,
-			DistanceFromSun:   1.000,
-			OrbitalPeriod:     365.25,
-			RotationPeriod:    1.00,
-			Color:             "#2E86AB",
-			Description:       "Zemlja je treći planet od Sunca i jedino poznato nebesko telo koje podržava život. 71% površine prekriva voda, a atmosfera je bogata kiseonikom.",
-			Satellites:        1,
-			NotableSatellites: []string{"Luna (Mesec)"},
-			IsStar:            false,
-			Eccentricity:      0.0167,
-			Inclination:       0.000,
-			AscendingNode:     174.873,
+			Name:               "Earth",
+			NameSR:             "Zemlja",
+			WikidataID:         "Q2",
+			Radius:             6371,
+			Mass:               5.972e24,
+			DistanceFromSun:    1.000,
+			OrbitalPeriod:      365.25,
+			RotationPeriod:     0.99727,
+			AxialTilt:          23.44,
+			Albedo:             0.306,
+			GreenhouseFactor:   1.13,
+			SurfaceTemperature: &TemperatureRange{Min: 184, Mean: 288, Max: 330},
+			Color:              "#2E86AB",
+			Description:        "Zemlja je treći planet od Sunca i jedino poznato nebesko telo koje podržava život. 71% površine prekriva voda, a atmosfera je bogata kiseonikom.",
+			Translations: map[string]Translation{
+				"en": {
+					IPA: "/ɜːrθ/", Respelling: "URTH",
+					Description: "Earth is the third planet from the Sun and the only known body that supports life. Water covers 71% of its surface and its atmosphere is rich in oxygen.",
+					Appearance:  "A blue planet with white swirls of cloud, brown and green continents and white polar ice caps. Oceans cover most of its surface.",
+					Kids: &KidsText{
+						Description: "Earth is our home. It is the only planet we know of with oceans of liquid water and living things.",
+						Facts:       []string{"Earth has one natural satellite, the Moon.", "Earth goes around the Sun once a year."},
+					},
+				},
+				"sr": {
+					Appearance: "Plava planeta sa belim vrtlozima oblaka, smeđim i zelenim kontinentima i belim polarnim kapama. Okeani pokrivaju najveći deo površine.",
+					Kids: &KidsText{
+						Description: "Zemlja je naš dom. To je jedina poznata planeta sa okeanima tečne vode i živim bićima.",
+						Facts:       []string{"Zemlja ima jedan prirodni satelit, Mesec.", "Zemlja obiđe Sunce jednom godišnje."},
+					},
+				},
+			},
+			Satellites:          1,
+			NotableSatellites:   []string{"Luna (Mesec)"},
+			IsStar:              false,
+			Eccentricity:        0.0167,
+			Inclination:         0.000,
+			AscendingNode:       174.873,
+			LongitudePerihelion: 102.938,
+			MeanLongitude:       100.465,
+			Rates:               jplRates(0.00000562, -0.00004392, -0.01294668, 35999.37244981, 0.32327364, 0, 30),
 		},
 		{
-			Name:              "Mars",
-			NameSR:            "Mars",
-			Radius:            3389.5,
-			DistanceFromSun:   1.524,
-			OrbitalPeriod:     686.97,
-			RotationPeriod:    1.03,
-			Color:             "#C1440E",
-			Description:       "Mars je četvrti planet od Sunca, poznat kao 'Crvena planeta'. Ima najvišu planinu u Solarnom sistemu - Olympus Mons (21 km visine).",
-			Satellites:        2,
-			NotableSatellites: []string{"Fobos", "Deimos"},
-			IsStar:            false,
-			Eccentricity:      0.0934,
-			Inclination:       1.850,
-			AscendingNode:     49.562,
+			Name:               "Mars",
+			NameSR:             "Mars",
+			WikidataID:         "Q111",
+			Radius:             3389.5,
+			Mass:               6.417e23,
+			DistanceFromSun:    1.524,
+			OrbitalPeriod:      686.97,
+			RotationPeriod:     1.02596,
+			AxialTilt:          25.19,
+			Albedo:             0.25,
+			GreenhouseFactor:   0.99,
+			SurfaceTemperature: &TemperatureRange{Min: 120, Mean: 208, Max: 293},
+			Color:              "#C1440E",
+			Description:        "Mars je četvrti planet od Sunca, poznat kao 'Crvena planeta'. Ima najvišu planinu u Solarnom sistemu - Olympus Mons (21 km visine).",
+			Translations: map[string]Translation{
+				"en": {
+					IPA: "/mɑːrz/", Respelling: "MARZ",
+					Description: "Mars is the fourth planet from the Sun, known as the 'Red Planet'. It has the highest mountain in the Solar System - Olympus Mons (21 km high).",
+					Appearance:  "A rusty red planet with darker patches, white ice caps at both poles and a long canyon, Valles Marineris, across its middle.",
+					Kids: &KidsText{
+						Description: "Mars is the red planet. Its red colour comes from rusty dust, and it has the tallest volcano we know of.",
+						Facts:       []string{"Robots called rovers drive around on Mars.", "Mars has two small moons, Phobos and Deimos."},
+					},
+				},
+				"sr": {
+					Appearance: "Rđasto crvena planeta sa tamnijim mrljama, belim ledenim kapama na oba pola i dugim kanjonom, Valles Marineris, preko sredine.",
+					Kids: &KidsText{
+						Description: "Mars je crvena planeta. Crvenu boju daje mu zarđala prašina, a na njemu je najviši poznati vulkan.",
+						Facts:       []string{"Po Marsu se voze roboti koje zovemo roveri.", "Mars ima dva mala meseca, Fobos i Deimos."},
+					},
+				},
+			},
+			Satellites:          2,
+			NotableSatellites:   []string{"Fobos", "Deimos"},
+			IsStar:              false,
+			Eccentricity:        0.0934,
+			Inclination:         1.850,
+			AscendingNode:       49.562,
+			LongitudePerihelion: 336.056,
+			MeanLongitude:       355.447,
+			Rates:               jplRates(0.00001847, 0.00007882, -0.00813131, 19140.30268499, 0.44441088, -0.29257343, 50),
 		},
 		{
-			Name:            "Jupiter",
-			NameSR:          "Jupiter",
-			Radius:          69911,
-			DistanceFromSun: 5.204,
-			OrbitalPeriod:   4332.59,
-			RotationPeriod:  0.41,
-			Color:           "#C88B3A",
-			Description:     "Jupiter je najveći planet u Solarnom sistemu. Čuvena Velika Crvena Mrlja je oluja koja traje više od 350 godina. Ima 4 velika Galilejeva meseca.",
-			Satellites:      95,
+			Name:               "Jupiter",
+			NameSR:             "Jupiter",
+			WikidataID:         "Q319",
+			Radius:             69911,
+			Mass:               1.898e27,
+			DistanceFromSun:    5.204,
+			OrbitalPeriod:      4332.59,
+			RotationPeriod:     0.41354,
+			AxialTilt:          3.13,
+			Albedo:             0.343,
+			GreenhouseFactor:   1.50,
+			SurfaceTemperature: &TemperatureRange{Mean: 165},
+			Color:              "#C88B3A",
+			Description:        "Jupiter je najveći planet u Solarnom sistemu. Čuvena Velika Crvena Mrlja je oluja koja traje više od 350 godina. Ima 4 velika Galilejeva meseca.",
+			Translations: map[string]Translation{
+				"en": {
+					IPA: "/ˈdʒuːpɪtər/", Respelling: "JOO-pih-tur",
+					Description: "Jupiter is the largest planet in the Solar System. Its famous Great Red Spot is a storm that has lasted more than 350 years. It has 4 large Galilean moons.",
+					Appearance:  "A huge striped globe of cream, tan and brown cloud bands running parallel to its equator, with the oval Great Red Spot south of the equator.",
+					Kids: &KidsText{
+						Description: "Jupiter is the biggest planet. It is made mostly of gas, so you could not stand on it.",
+						Facts:       []string{"More than 1,300 Earths could fit inside Jupiter.", "Its Great Red Spot is a storm bigger than Earth."},
+					},
+				},
+				"sr": {
+					Appearance: "Ogromna prugasta kugla od krem, svetlosmeđih i smeđih pojaseva oblaka paralelnih sa ekvatorom, sa ovalnom Velikom crvenom mrljom južno od ekvatora.",
+					Kids: &KidsText{
+						Description: "Jupiter je najveća planeta. Uglavnom je od gasa, pa na njemu ne bismo mogli da stojimo.",
+						Facts:       []string{"U Jupiter bi stalo više od 1.300 Zemalja.", "Njegova Velika crvena mrlja je oluja veća od Zemlje."},
+					},
+				},
+			},
+			Satellites: 95,
 			NotableSatellites: []string{
 				"Io", "Evropa", "Ganimed", "Kalisto",
 				"Amalthea", "Himalia",
 			},
-			IsStar:        false,
-			Eccentricity:  0.0490,
-			Inclination:   1.303,
-			AscendingNode: 100.556,
+			Rings:               &Rings{InnerRadius: 92000, OuterRadius: 226000},
+			IsStar:              false,
+			Eccentricity:        0.0490,
+			Inclination:         1.303,
+			AscendingNode:       100.556,
+			LongitudePerihelion: 14.728,
+			MeanLongitude:       34.396,
+			Rates:               jplRates(-0.00011607, -0.00013253, -0.00183714, 3034.74612775, 0.21252668, 0.20469106, 700),
 		},
 		{
-			Name:            "Saturn",
-			NameSR:          "Saturn",
-			Radius:          58232,
-			DistanceFromSun: 9.582,
-			OrbitalPeriod:   10759.22,
-			RotationPeriod:  0.44,
-			Color:           "#E4D191",
-			Description:     "Saturn je poznat po svom impresivnom sistemu prstenova koji se sastoje od leda i kamenja. Toliko je lak da bi plutao na vodi (gustina 0.69 g/cm³).",
-			Satellites:      146,
+			Name:               "Saturn",
+			NameSR:             "Saturn",
+			WikidataID:         "Q193",
+			Radius:             58232,
+			Mass:               5.683e26,
+			DistanceFromSun:    9.582,
+			OrbitalPeriod:      10759.22,
+			RotationPeriod:     0.44401,
+			AxialTilt:          26.73,
+			Albedo:             0.342,
+			GreenhouseFactor:   1.65,
+			SurfaceTemperature: &TemperatureRange{Mean: 134},
+			Color:              "#E4D191",
+			Description:        "Saturn je poznat po svom impresivnom sistemu prstenova koji se sastoje od leda i kamenja. Toliko je lak da bi plutao na vodi (gustina 0.69 g/cm³).",
+			Translations: map[string]Translation{
+				"en": {
+					IPA: "/ˈsætərn/", Respelling: "SAT-urn",
+					Description: "Saturn is known for its impressive ring system made of ice and rock. It is so light it would float on water (density 0.69 g/cm³).",
+					Appearance:  "A pale golden globe with faint bands, circled by wide, bright, flat rings of ice and rock that are far wider than the planet itself.",
+					Kids: &KidsText{
+						Description: "Saturn is the planet with the beautiful rings. The rings are made of billions of pieces of ice and rock.",
+						Facts:       []string{"Saturn is so light it could float in a giant bathtub.", "Saturn has more moons than any other planet."},
+					},
+				},
+				"sr": {
+					Appearance: "Bledozlatna kugla sa slabim pojasevima, okružena širokim, sjajnim, ravnim prstenovima od leda i stena, mnogo širim od same planete.",
+					Kids: &KidsText{
+						Description: "Saturn je planeta sa prelepim prstenovima. Prstenovi su od milijardi komadića leda i stena.",
+						Facts:       []string{"Saturn je toliko lak da bi plutao u ogromnoj kadi.", "Saturn ima više meseci nego ijedna druga planeta."},
+					},
+				},
+			},
+			Satellites: 146,
 			NotableSatellites: []string{
 				"Titan", "Enceladus", "Mimas", "Dione",
 				"Rhea", "Tethys", "Iapetus", "Hyperion",
 			},
-			IsStar:        false,
-			Eccentricity:  0.0565,
-			Inclination:   2.489,
-			AscendingNode: 113.715,
+			Rings:               &Rings{InnerRadius: 66900, OuterRadius: 136775},
+			IsStar:              false,
+			Eccentricity:        0.0565,
+			Inclination:         2.489,
+			AscendingNode:       113.715,
+			LongitudePerihelion: 92.599,
+			MeanLongitude:       49.954,
+			Rates:               jplRates(-0.00125060, -0.00050991, 0.00193609, 1222.49362201, -0.41897216, -0.28867794, 1700),
 		},
 		{
-			Name:            "Uranus",
-			NameSR:          "Uran",
-			Radius:          25362,
-			DistanceFromSun: 19.201,
-			OrbitalPeriod:   30688.5,
-			RotationPeriod:  -0.72,
-			Color:           "#7DE8E8",
-			Description:     "Uran je ledeni gigant koji rotira na boku - njegova osa rotacije je nagnuta za 98°. Sateliti su nazvani po Šekspirovim i Popovim likovima.",
-			Satellites:      27,
+			Name:               "Uranus",
+			NameSR:             "Uran",
+			WikidataID:         "Q324",
+			Radius:             25362,
+			Mass:               8.681e25,
+			DistanceFromSun:    19.201,
+			OrbitalPeriod:      30688.5,
+			RotationPeriod:     -0.71833,
+			AxialTilt:          97.77,
+			Albedo:             0.3,
+			GreenhouseFactor:   1.31,
+			SurfaceTemperature: &TemperatureRange{Mean: 76},
+			Color:              "#7DE8E8",
+			Description:        "Uran je ledeni gigant koji rotira na boku - njegova osa rotacije je nagnuta za 98°. Sateliti su nazvani po Šekspirovim i Popovim likovima.",
+			Translations: map[string]Translation{
+				"en": {
+					IPA: "/ˈjʊərənəs/", Respelling: "YOOR-uh-nuhs",
+					Description: "Uranus is an ice giant that rotates on its side - its rotation axis is tilted by 98°. Its moons are named after characters from Shakespeare and Pope.",
+					Appearance:  "A featureless pale cyan globe. Its faint thin rings stand almost upright because the planet is tipped on its side.",
+					Kids: &KidsText{
+						Description: "Uranus is an ice giant that goes around the Sun lying on its side, like a rolling ball.",
+						Facts:       []string{"Uranus looks blue-green because of a gas called methane.", "It was the first planet found with a telescope."},
+					},
+				},
+				"sr": {
+					Appearance: "Bledo tirkizna kugla bez vidljivih detalja. Njeni slabi, tanki prstenovi stoje gotovo uspravno jer je planeta nagnuta na bok.",
+					Kids: &KidsText{
+						Description: "Uran je ledeni džin koji obilazi Sunce ležeći na boku, kao lopta koja se kotrlja.",
+						Facts:       []string{"Uran je plavozelen zbog gasa koji se zove metan.", "To je prva planeta otkrivena teleskopom."},
+					},
+				},
+			},
+			Discovery:  &Discovery{Discoverer: "William Herschel", Year: 1781, Method: DiscoveryTelescope, Observatory: "Bath, England"},
+			Satellites: 27,
 			NotableSatellites: []string{
 				"Miranda", "Ariel", "Umbriel",
 				"Titania", "Oberon",
 			},
-			IsStar:        false,
-			Eccentricity:  0.0463,
-			Inclination:   0.773,
-			AscendingNode: 74.230,
+			Rings:               &Rings{InnerRadius: 41837, OuterRadius: 51149},
+			IsStar:              false,
+			Eccentricity:        0.0463,
+			Inclination:         0.773,
+			AscendingNode:       74.230,
+			LongitudePerihelion: 170.954,
+			MeanLongitude:       313.238,
+			Rates:               jplRates(-0.00196176, -0.00004397, -0.00242939, 428.48202785, 0.40805281, 0.04240589, 450),
 		},
 		{
-			Name:            "Neptune",
-			NameSR:          "Neptun",
-			Radius:          24622,
-			DistanceFromSun: 30.047,
-			OrbitalPeriod:   60182,
-			RotationPeriod:  0.67,
-			Color:           "#3F54BA",
-			Description:     "Neptun je najudaljeniji planet od Sunca. Ima najjače vetrove u Solarnom sistemu - do 2100 km/h. Jedan orbitalni period traje 165 Zemljinih godina.",
-			Satellites:      16,
+			Name:               "Neptune",
+			NameSR:             "Neptun",
+			WikidataID:         "Q332",
+			Radius:             24622,
+			Mass:               1.024e26,
+			DistanceFromSun:    30.047,
+			OrbitalPeriod:      60182,
+			RotationPeriod:     0.67125,
+			AxialTilt:          28.32,
+			Albedo:             0.29,
+			GreenhouseFactor:   1.55,
+			SurfaceTemperature: &TemperatureRange{Mean: 72},
+			Color:              "#3F54BA",
+			Description:        "Neptun je najudaljeniji planet od Sunca. Ima najjače vetrove u Solarnom sistemu - do 2100 km/h. Jedan orbitalni period traje 165 Zemljinih godina.",
+			Translations: map[string]Translation{
+				"en": {
+					IPA: "/ˈnɛptjuːn/", Respelling: "NEP-tewn",
+					Description: "Neptune is the planet farthest from the Sun. It has the strongest winds in the Solar System - up to 2100 km/h. One orbit takes 165 Earth years.",
+					Appearance:  "A deep blue globe with faint white streaks of high cloud and, at times, dark storm spots.",
+					Kids: &KidsText{
+						Description: "Neptune is the planet farthest from the Sun. It is dark, cold and very windy.",
+						Facts:       []string{"Neptune has the fastest winds in the Solar System.", "One year on Neptune lasts 165 Earth years."},
+					},
+				},
+				"sr": {
+					Appearance: "Tamnoplava kugla sa slabim belim trakama visokih oblaka i povremeno tamnim olujnim mrljama.",
+					Kids: &KidsText{
+						Description: "Neptun je planeta najudaljenija od Sunca. Na njemu je mračno, hladno i veoma vetrovito.",
+						Facts:       []string{"Na Neptunu duvaju najbrži vetrovi u Solarnom sistemu.", "Jedna godina na Neptunu traje 165 zemaljskih godina."},
+					},
+				},
+			},
+			Discovery:  &Discovery{Discoverer: "Johann Gottfried Galle, Heinrich d'Arrest (predicted by Urbain Le Verrier)", Year: 1846, Method: DiscoveryTelescope, Observatory: "Berlin Observatory"},
+			Satellites: 16,
 			NotableSatellites: []string{
 				"Triton", "Nereid", "Proteus",
 				"Larissa", "Galatea",
 			},
-			IsStar:        false,
-			Eccentricity:  0.0097,
-			Inclination:   1.770,
-			AscendingNode: 131.722,
+			Rings:               &Rings{InnerRadius: 41900, OuterRadius: 62932},
+			IsStar:              false,
+			Eccentricity:        0.0097,
+			Inclination:         1.770,
+			AscendingNode:       131.722,
+			LongitudePerihelion: 44.965,
+			MeanLongitude:       304.880,
+			Rates:               jplRates(0.00026291, 0.00005105, 0.00035372, 218.45945325, -0.32241464, -0.00508664, 500),
 		},
 	}
 }
+
+// jplRates are the rates of JPL's "Keplerian Elements for Approximate
+// Positions of the Major Planets" (Standish), table 1, fitted over
+// 1800–2050; err is the longitude error bound with the elements above
+func jplRates(a, e, inc, meanLon, perihelion, node, err float64) *OrbitalRates {
+	return &OrbitalRates{
+		SemiMajorAxis: a, Eccentricity: e, Inclination: inc, MeanLongitude: meanLon,
+		LongitudePerihelion: perihelion, AscendingNode: node,
+		ValidFrom: 1800, ValidTo: 2050, Error: err,
+	}
+}
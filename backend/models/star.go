@@ -0,0 +1,171 @@
+package models
+
+// Star represents a bright background star from the Hipparcos catalogue
+type Star struct {
+	HIP           int     `json:"hip"` // Hipparcos catalogue number
+	Name          string  `json:"name"`
+	NameSR        string  `json:"name_sr"`
+	RA            float64 `json:"ra"`            // degrees, right ascension (J2000)
+	Dec           float64 `json:"dec"`           // degrees, declination (J2000)
+	Magnitude     float64 `json:"magnitude"`     // apparent visual magnitude
+	ColorIndex    float64 `json:"color_index"`   // B−V, used for tinting
+	Constellation string  `json:"constellation"` // IAU abbreviation
+}
+
+// Constellation represents an IAU constellation with its stick-figure lines.
+// Each line is a polyline of Hipparcos numbers that must exist in the catalogue.
+type Constellation struct {
+	ID     string  `json:"id"` // IAU abbreviation
+	Name   string  `json:"name"`
+	NameSR string  `json:"name_sr"`
+	Lines  [][]int `json:"lines"`
+}
+
+// GetBrightStars returns a Hipparcos subset of the brightest and
+// constellation-defining stars (J2000 positions)
+func GetBrightStars() []Star {
+	return []Star{
+		{HIP: 32349, Name: "Sirius", NameSR: "Sirijus", RA: 101.287, Dec: -16.716, Magnitude: -1.46, ColorIndex: 0.00, Constellation: "CMa"},
+		{HIP: 30438, Name: "Canopus", NameSR: "Kanopus", RA: 95.988, Dec: -52.696, Magnitude: -0.74, ColorIndex: 0.15, Constellation: "Car"},
+		{HIP: 69673, Name: "Arcturus", NameSR: "Arktur", RA: 213.915, Dec: 19.182, Magnitude: -0.05, ColorIndex: 1.23, Constellation: "Boo"},
+		{HIP: 71683, Name: "Rigil Kentaurus", NameSR: "Alfa Kentaura", RA: 219.902, Dec: -60.834, Magnitude: -0.01, ColorIndex: 0.71, Constellation: "Cen"},
+		{HIP: 91262, Name: "Vega", NameSR: "Vega", RA: 279.235, Dec: 38.784, Magnitude: 0.03, ColorIndex: 0.00, Constellation: "Lyr"},
+		{HIP: 24608, Name: "Capella", NameSR: "Kapela", RA: 79.172, Dec: 45.998, Magnitude: 0.08, ColorIndex: 0.80, Constellation: "Aur"},
+		{HIP: 24436, Name: "Rigel", NameSR: "Rigel", RA: 78.634, Dec: -8.202, Magnitude: 0.13, ColorIndex: -0.03, Constellation: "Ori"},
+		{HIP: 37279, Name: "Procyon", NameSR: "Prokion", RA: 114.826, Dec: 5.225, Magnitude: 0.34, ColorIndex: 0.42, Constellation: "CMi"},
+		{HIP: 27989, Name: "Betelgeuse", NameSR: "Betelgez", RA: 88.793, Dec: 7.407, Magnitude: 0.42, ColorIndex: 1.85, Constellation: "Ori"},
+		{HIP: 7588, Name: "Achernar", NameSR: "Ahernar", RA: 24.429, Dec: -57.237, Magnitude: 0.46, ColorIndex: -0.16, Constellation: "Eri"},
+		{HIP: 68702, Name: "Hadar", NameSR: "Hadar", RA: 210.956, Dec: -60.373, Magnitude: 0.61, ColorIndex: -0.23, Constellation: "Cen"},
+		{HIP: 97649, Name: "Altair", NameSR: "Altair", RA: 297.696, Dec: 8.868, Magnitude: 0.76, ColorIndex: 0.22, Constellation: "Aql"},
+		{HIP: 60718, Name: "Acrux", NameSR: "Akruks", RA: 186.650, Dec: -63.099, Magnitude: 0.77, ColorIndex: -0.24, Constellation: "Cru"},
+		{HIP: 21421, Name: "Aldebaran", NameSR: "Aldebaran", RA: 68.980, Dec: 16.509, Magnitude: 0.86, ColorIndex: 1.54, Constellation: "Tau"},
+		{HIP: 80763, Name: "Antares", NameSR: "Antares", RA: 247.352, Dec: -26.432, Magnitude: 0.96, ColorIndex: 1.83, Constellation: "Sco"},
+		{HIP: 65474, Name: "Spica", NameSR: "Spika", RA: 201.298, Dec: -11.161, Magnitude: 0.97, ColorIndex: -0.24, Constellation: "Vir"},
+		{HIP: 37826, Name: "Pollux", NameSR: "Poluks", RA: 116.329, Dec: 28.026, Magnitude: 1.14, ColorIndex: 1.00, Constellation: "Gem"},
+		{HIP: 113368, Name: "Fomalhaut", NameSR: "Fomalhaut", RA: 344.413, Dec: -29.622, Magnitude: 1.16, ColorIndex: 0.09, Constellation: "PsA"},
+		{HIP: 102098, Name: "Deneb", NameSR: "Deneb", RA: 310.358, Dec: 45.280, Magnitude: 1.25, ColorIndex: 0.09, Constellation: "Cyg"},
+		{HIP: 62434, Name: "Mimosa", NameSR: "Mimoza", RA: 191.930, Dec: -59.689, Magnitude: 1.25, ColorIndex: -0.24, Constellation: "Cru"},
+		{HIP: 49669, Name: "Regulus", NameSR: "Regul", RA: 152.093, Dec: 11.967, Magnitude: 1.40, ColorIndex: -0.09, Constellation: "Leo"},
+		{HIP: 33579, Name: "Adhara", NameSR: "Adara", RA: 104.656, Dec: -28.972, Magnitude: 1.50, ColorIndex: -0.21, Constellation: "CMa"},
+		{HIP: 36850, Name: "Castor", NameSR: "Kastor", RA: 113.650, Dec: 31.888, Magnitude: 1.58, ColorIndex: 0.03, Constellation: "Gem"},
+		{HIP: 61084, Name: "Gacrux", NameSR: "Gakruks", RA: 187.791, Dec: -57.113, Magnitude: 1.59, ColorIndex: 1.60, Constellation: "Cru"},
+		{HIP: 85927, Name: "Shaula", NameSR: "Šaula", RA: 263.402, Dec: -37.104, Magnitude: 1.62, ColorIndex: -0.23, Constellation: "Sco"},
+		{HIP: 25336, Name: "Bellatrix", NameSR: "Belatriks", RA: 81.283, Dec: 6.350, Magnitude: 1.64, ColorIndex: -0.22, Constellation: "Ori"},
+		{HIP: 25428, Name: "Elnath", NameSR: "Elnat", RA: 81.573, Dec: 28.608, Magnitude: 1.65, ColorIndex: -0.13, Constellation: "Tau"},
+		{HIP: 26311, Name: "Alnilam", NameSR: "Alnilam", RA: 84.053, Dec: -1.202, Magnitude: 1.69, ColorIndex: -0.18, Constellation: "Ori"},
+		{HIP: 26727, Name: "Alnitak", NameSR: "Alnitak", RA: 85.190, Dec: -1.943, Magnitude: 1.74, ColorIndex: -0.21, Constellation: "Ori"},
+		{HIP: 62956, Name: "Alioth", NameSR: "Aliot", RA: 193.507, Dec: 55.960, Magnitude: 1.76, ColorIndex: -0.02, Constellation: "UMa"},
+		{HIP: 54061, Name: "Dubhe", NameSR: "Dubhe", RA: 165.932, Dec: 61.751, Magnitude: 1.81, ColorIndex: 1.06, Constellation: "UMa"},
+		{HIP: 67301, Name: "Alkaid", NameSR: "Alkaid", RA: 206.885, Dec: 49.313, Magnitude: 1.85, ColorIndex: -0.10, Constellation: "UMa"},
+		{HIP: 86228, Name: "Sargas", NameSR: "Sargas", RA: 264.330, Dec: -42.998, Magnitude: 1.86, ColorIndex: 0.40, Constellation: "Sco"},
+		{HIP: 11767, Name: "Polaris", NameSR: "Severnjača", RA: 37.955, Dec: 89.264, Magnitude: 1.97, ColorIndex: 0.64, Constellation: "UMi"},
+		{HIP: 50583, Name: "Algieba", NameSR: "Algieba", RA: 154.993, Dec: 19.842, Magnitude: 2.01, ColorIndex: 1.13, Constellation: "Leo"},
+		{HIP: 27366, Name: "Saiph", NameSR: "Saif", RA: 86.939, Dec: -9.670, Magnitude: 2.07, ColorIndex: -0.17, Constellation: "Ori"},
+		{HIP: 72607, Name: "Kochab", NameSR: "Kohab", RA: 222.676, Dec: 74.156, Magnitude: 2.07, ColorIndex: 1.47, Constellation: "UMi"},
+		{HIP: 57632, Name: "Denebola", NameSR: "Denebola", RA: 177.265, Dec: 14.572, Magnitude: 2.14, ColorIndex: 0.09, Constellation: "Leo"},
+		{HIP: 4427, Name: "Gamma Cassiopeiae", NameSR: "Gama Kasiopeje", RA: 14.177, Dec: 60.717, Magnitude: 2.15, ColorIndex: -0.05, Constellation: "Cas"},
+		{HIP: 65378, Name: "Mizar", NameSR: "Mizar", RA: 200.981, Dec: 54.925, Magnitude: 2.23, ColorIndex: 0.02, Constellation: "UMa"},
+		{HIP: 100453, Name: "Sadr", NameSR: "Sadr", RA: 305.557, Dec: 40.257, Magnitude: 2.23, ColorIndex: 0.67, Constellation: "Cyg"},
+		{HIP: 3179, Name: "Schedar", NameSR: "Šedar", RA: 10.127, Dec: 56.537, Magnitude: 2.24, ColorIndex: 1.17, Constellation: "Cas"},
+		{HIP: 25930, Name: "Mintaka", NameSR: "Mintaka", RA: 83.002, Dec: -0.299, Magnitude: 2.25, ColorIndex: -0.22, Constellation: "Ori"},
+		{HIP: 746, Name: "Caph", NameSR: "Kaf", RA: 2.295, Dec: 59.150, Magnitude: 2.28, ColorIndex: 0.38, Constellation: "Cas"},
+		{HIP: 78401, Name: "Dschubba", NameSR: "Džuba", RA: 240.083, Dec: -22.622, Magnitude: 2.29, ColorIndex: -0.12, Constellation: "Sco"},
+		{HIP: 82396, Name: "Larawag", NameSR: "Laravag", RA: 252.541, Dec: -34.293, Magnitude: 2.29, ColorIndex: 1.14, Constellation: "Sco"},
+		{HIP: 53910, Name: "Merak", NameSR: "Merak", RA: 165.460, Dec: 56.383, Magnitude: 2.34, ColorIndex: -0.02, Constellation: "UMa"},
+		{HIP: 58001, Name: "Phecda", NameSR: "Fekda", RA: 178.458, Dec: 53.695, Magnitude: 2.41, ColorIndex: 0.04, Constellation: "UMa"},
+		{HIP: 102488, Name: "Aljanah", NameSR: "Aldžana", RA: 311.553, Dec: 33.970, Magnitude: 2.48, ColorIndex: 1.03, Constellation: "Cyg"},
+		{HIP: 54872, Name: "Zosma", NameSR: "Zosma", RA: 168.527, Dec: 20.524, Magnitude: 2.56, ColorIndex: 0.13, Constellation: "Leo"},
+		{HIP: 78820, Name: "Acrab", NameSR: "Akrab", RA: 241.359, Dec: -19.806, Magnitude: 2.62, ColorIndex: -0.07, Constellation: "Sco"},
+		{HIP: 6686, Name: "Ruchbah", NameSR: "Rukba", RA: 21.454, Dec: 60.235, Magnitude: 2.66, ColorIndex: 0.13, Constellation: "Cas"},
+		{HIP: 85696, Name: "Lesath", NameSR: "Lesat", RA: 262.691, Dec: -37.296, Magnitude: 2.70, ColorIndex: -0.22, Constellation: "Sco"},
+		{HIP: 59747, Name: "Imai", NameSR: "Imai", RA: 183.786, Dec: -58.749, Magnitude: 2.79, ColorIndex: -0.23, Constellation: "Cru"},
+		{HIP: 81266, Name: "Paikauhale", NameSR: "Paikauhale", RA: 248.971, Dec: -28.216, Magnitude: 2.82, ColorIndex: -0.21, Constellation: "Sco"},
+		{HIP: 97165, Name: "Fawaris", NameSR: "Favaris", RA: 296.244, Dec: 45.131, Magnitude: 2.86, ColorIndex: -0.03, Constellation: "Cyg"},
+		{HIP: 95947, Name: "Albireo", NameSR: "Albireo", RA: 292.680, Dec: 27.960, Magnitude: 3.05, ColorIndex: 1.13, Constellation: "Cyg"},
+		{HIP: 93194, Name: "Sulafat", NameSR: "Sulafat", RA: 284.736, Dec: 32.690, Magnitude: 3.25, ColorIndex: -0.05, Constellation: "Lyr"},
+		{HIP: 54879, Name: "Chertan", NameSR: "Čertan", RA: 168.560, Dec: 15.430, Magnitude: 3.33, ColorIndex: 0.00, Constellation: "Leo"},
+		{HIP: 59774, Name: "Megrez", NameSR: "Megrez", RA: 183.857, Dec: 57.033, Magnitude: 3.32, ColorIndex: 0.08, Constellation: "UMa"},
+		{HIP: 8886, Name: "Segin", NameSR: "Segin", RA: 28.599, Dec: 63.670, Magnitude: 3.35, ColorIndex: -0.15, Constellation: "Cas"},
+		{HIP: 49583, Name: "Eta Leonis", NameSR: "Eta Lava", RA: 151.833, Dec: 16.763, Magnitude: 3.49, ColorIndex: -0.03, Constellation: "Leo"},
+		{HIP: 92420, Name: "Sheliak", NameSR: "Šeliak", RA: 282.520, Dec: 33.363, Magnitude: 3.52, ColorIndex: 0.00, Constellation: "Lyr"},
+		{HIP: 91971, Name: "Zeta Lyrae", NameSR: "Zeta Lire", RA: 281.193, Dec: 37.605, Magnitude: 4.34, ColorIndex: 0.19, Constellation: "Lyr"},
+	}
+}
+
+// GetConstellations returns stick-figure line data for the constellations
+// whose stars are present in GetBrightStars
+func GetConstellations() []Constellation {
+	return []Constellation{
+		{
+			ID: "Ori", Name: "Orion", NameSR: "Orion",
+			Lines: [][]int{
+				{27989, 25336, 25930, 24436},
+				{27989, 26727, 27366},
+				{25930, 26311, 26727},
+			},
+		},
+		{
+			ID: "UMa", Name: "Ursa Major", NameSR: "Veliki medved",
+			Lines: [][]int{
+				{54061, 53910, 58001, 59774, 54061},
+				{59774, 62956, 65378, 67301},
+			},
+		},
+		{
+			ID: "Cas", Name: "Cassiopeia", NameSR: "Kasiopeja",
+			Lines: [][]int{
+				{746, 3179, 4427, 6686, 8886},
+			},
+		},
+		{
+			ID: "Cyg", Name: "Cygnus", NameSR: "Labud",
+			Lines: [][]int{
+				{102098, 100453, 95947},
+				{97165, 100453, 102488},
+			},
+		},
+		{
+			ID: "Lyr", Name: "Lyra", NameSR: "Lira",
+			Lines: [][]int{
+				{91262, 91971, 92420, 93194, 91971},
+			},
+		},
+		{
+			ID: "Cru", Name: "Crux", NameSR: "Južni krst",
+			Lines: [][]int{
+				{61084, 60718},
+				{62434, 59747},
+			},
+		},
+		{
+			ID: "Sco", Name: "Scorpius", NameSR: "Škorpija",
+			Lines: [][]int{
+				{78820, 78401, 80763, 81266, 82396, 86228, 85927, 85696},
+			},
+		},
+		{
+			ID: "Leo", Name: "Leo", NameSR: "Lav",
+			Lines: [][]int{
+				{49669, 49583, 50583, 54872, 57632, 54879, 49669},
+			},
+		},
+		{
+			ID: "Gem", Name: "Gemini", NameSR: "Blizanci",
+			Lines: [][]int{
+				{36850, 37826},
+			},
+		},
+		{
+			ID: "Tau", Name: "Taurus", NameSR: "Bik",
+			Lines: [][]int{
+				{21421, 25428},
+			},
+		},
+		{
+			ID: "UMi", Name: "Ursa Minor", NameSR: "Mali medved",
+			Lines: [][]int{
+				{11767, 72607},
+			},
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Tour is a guided sequence of steps through the solar system. Title and
+// narration are keyed by locale; "sr" is required so every locale chain
+// ends in some text.
+type Tour struct {
+	ID        string            `json:"id"` // URL slug
+	Title     map[string]string `json:"title"`
+	Steps     []TourStep        `json:"steps"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// TourStep shows one body for Duration seconds while the narration plays
+type TourStep struct {
+	Body      string            `json:"body"` // English name of a body or moon
+	Camera    *CameraHint       `json:"camera,omitempty"`
+	Narration map[string]string `json:"narration"`
+	Duration  float64           `json:"duration"` // seconds
+}
+
+// CameraHint suggests where the camera looks at a step's body from.
+// Distance is in body radii; azimuth and elevation are in degrees around
+// the body, measured from the direction of the Sun.
+type CameraHint struct {
+	Distance  float64 `json:"distance"`
+	Azimuth   float64 `json:"azimuth"`
+	Elevation float64 `json:"elevation"`
+}
+
+// GetGrandTour returns the built-in tour from the Sun out to Neptune,
+// which the frontend's Grand Tour mode plays by default
+func GetGrandTour() Tour {
+	step := func(body, sr, en string, distance, duration float64) TourStep {
+		return TourStep{
+			Body:      body,
+			Camera:    &CameraHint{Distance: distance, Azimuth: 30, Elevation: 15},
+			Narration: map[string]string{"sr": sr, "en": en},
+			Duration:  duration,
+		}
+	}
+	return Tour{
+		ID:    "grand-tour",
+		Title: map[string]string{"sr": "Veliko putovanje", "en": "Grand Tour"},
+		Steps: []TourStep{
+			step("Sun", "Putovanje počinje od Sunca, zvezde koja drži ceo sistem na okupu i sadrži 99,8% njegove mase.",
+				"Our journey starts at the Sun, the star that holds the whole system together and carries 99.8% of its mass.", 4, 12),
+			step("Mercury", "Merkur je najbliži Suncu i najmanja planeta. Godina na njemu traje samo 88 dana.",
+				"Mercury is the closest planet to the Sun and the smallest. A year there lasts only 88 days.", 5, 10),
+			step("Venus", "Venera je slična Zemlji po veličini, ali je njena gusta atmosfera čini najtoplijom planetom.",
+				"Venus is close to Earth in size, but its thick atmosphere makes it the hottest planet.", 5, 10),
+			step("Earth", "Zemlja, naš dom — jedino mesto za koje znamo da na njemu postoji život.",
+				"Earth, our home — the only place we know of where life exists.", 5, 12),
+			step("Mars", "Mars je crvena planeta, sa najvišim vulkanom u Sunčevom sistemu, Olimpom.",
+				"Mars is the red planet, home to the tallest volcano in the Solar System, Olympus Mons.", 5, 10),
+			step("Jupiter", "Jupiter je najveća planeta; u njega bi stalo više od 1.300 Zemalja.",
+				"Jupiter is the largest planet; more than 1,300 Earths would fit inside it.", 4, 12),
+			step("Saturn", "Saturn je poznat po prstenovima od leda i kamenja, širokim stotinama hiljada kilometara.",
+				"Saturn is famous for its rings of ice and rock, hundreds of thousands of kilometres across.", 6, 12),
+			step("Uranus", "Uran se okreće gotovo ležeći na boku, pa mu godišnja doba traju po 21 godinu.",
+				"Uranus spins almost on its side, so each of its seasons lasts 21 years.", 5, 10),
+			step("Neptune", "Neptun je najudaljenija planeta, sa najjačim vetrovima u Sunčevom sistemu.",
+				"Neptune is the farthest planet, with the strongest winds in the Solar System.", 5, 12),
+		},
+	}
+}
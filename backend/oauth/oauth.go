@@ -0,0 +1,215 @@
+// Package oauth signs users in with external OAuth 2.0 providers (Google,
+// GitHub) using the authorization code flow with PKCE. It only finds out
+// who the user is; accounts and sessions stay with package users.
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/internal/random"
+	"solar-system-explorer/backend/tracing"
+)
+
+// ErrProvider is returned when the provider refuses the code or sends
+// something we can't use
+var ErrProvider = errors.New("oauth: provider error")
+
+// Identity is who the provider says the user is
+type Identity struct {
+	Provider      string
+	Subject       string // the provider's stable user ID
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider is one OAuth 2.0 identity provider
+type Provider struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	AuthURL      string
+	TokenURL     string
+	UserURL      string
+	Scopes       []string
+	HTTP         *http.Client
+	// identify reads the user from the provider's API
+	identify func(ctx context.Context, p *Provider, token string) (Identity, error)
+}
+
+// Google returns the Google provider
+func Google(clientID, clientSecret, redirectURL string) *Provider {
+	return &Provider{
+		Name:         "google",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		AuthURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:     "https://oauth2.googleapis.com/token",
+		UserURL:      "https://openidconnect.googleapis.com/v1/userinfo",
+		Scopes:       []string{"openid", "email", "profile"},
+		HTTP:         &http.Client{Timeout: 10 * time.Second, Transport: tracing.NewTransport(nil)},
+		identify:     identifyGoogle,
+	}
+}
+
+// GitHub returns the GitHub provider
+func GitHub(clientID, clientSecret, redirectURL string) *Provider {
+	return &Provider{
+		Name:         "github",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		AuthURL:      "https://github.com/login/oauth/authorize",
+		TokenURL:     "https://github.com/login/oauth/access_token",
+		UserURL:      "https://api.github.com/user",
+		Scopes:       []string{"read:user", "user:email"},
+		HTTP:         &http.Client{Timeout: 10 * time.Second, Transport: tracing.NewTransport(nil)},
+		identify:     identifyGitHub,
+	}
+}
+
+// NewVerifier returns a random PKCE code verifier
+func NewVerifier() string {
+	return base64.RawURLEncoding.EncodeToString(random.Bytes(32))
+}
+
+// AuthCodeURL is where to send the browser to sign in
+func (p *Provider) AuthCodeURL(state, verifier string) string {
+	challenge := sha256.Sum256([]byte(verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {strings.Join(p.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	return p.AuthURL + "?" + q.Encode()
+}
+
+// Identify exchanges the code from the callback for an access token and
+// asks the provider who it belongs to
+func (p *Provider) Identify(ctx context.Context, code, verifier string) (Identity, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Identity{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := p.do(req, &tok); err != nil {
+		return Identity{}, err
+	}
+	if tok.AccessToken == "" {
+		return Identity{}, fmt.Errorf("%w: %s: no access token (%s)", ErrProvider, p.Name, tok.Error)
+	}
+	id, err := p.identify(ctx, p, tok.AccessToken)
+	if err != nil {
+		return Identity{}, err
+	}
+	if id.Subject == "" {
+		return Identity{}, fmt.Errorf("%w: %s: no user ID", ErrProvider, p.Name)
+	}
+	id.Provider = p.Name
+	return id, nil
+}
+
+// get fetches an API URL with the access token into v
+func (p *Provider) get(ctx context.Context, u, token string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	return p.do(req, v)
+}
+
+func (p *Provider) do(req *http.Request, v any) error {
+	resp, err := p.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s: %s returned %d", ErrProvider, p.Name, req.URL.Path, resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrProvider, p.Name, err)
+	}
+	return nil
+}
+
+func identifyGoogle(ctx context.Context, p *Provider, token string) (Identity, error) {
+	var u struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := p.get(ctx, p.UserURL, token, &u); err != nil {
+		return Identity{}, err
+	}
+	return Identity{Subject: u.Sub, Email: u.Email, EmailVerified: u.EmailVerified, Name: u.Name}, nil
+}
+
+func identifyGitHub(ctx context.Context, p *Provider, token string) (Identity, error) {
+	var u struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := p.get(ctx, p.UserURL, token, &u); err != nil {
+		return Identity{}, err
+	}
+	id := Identity{Name: u.Name}
+	if u.ID != 0 {
+		id.Subject = fmt.Sprint(u.ID)
+	}
+	if id.Name == "" {
+		id.Name = u.Login
+	}
+	// The profile email is optional and unverified; ask for the primary one
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.get(ctx, p.UserURL+"/emails", token, &emails); err != nil {
+		return Identity{}, err
+	}
+	for _, e := range emails {
+		if e.Primary {
+			id.Email, id.EmailVerified = e.Email, e.Verified
+		}
+	}
+	return id, nil
+}
@@ -0,0 +1,70 @@
+package oauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// StateTTL is how long a user has to finish signing in at the provider
+const StateTTL = 10 * time.Minute
+
+// ErrState is returned for missing, forged and expired sign-in state
+var ErrState = errors.New("oauth: invalid or expired sign-in state")
+
+// State is what we remember between sending the browser to the provider
+// and its coming back. It travels in a signed cookie; only Nonce goes to
+// the provider, as the state parameter.
+type State struct {
+	Nonce    string `json:"n"`
+	Provider string `json:"p"`
+	Verifier string `json:"v"`           // PKCE code verifier
+	LinkUser string `json:"l,omitempty"` // link to this account instead of signing in
+	Return   string `json:"r,omitempty"` // SPA path to land on
+	Expires  int64  `json:"e"`
+}
+
+// States seals and opens sign-in state with an HMAC key
+type States struct {
+	key []byte
+}
+
+// NewStates returns states signed with key
+func NewStates(key []byte) *States {
+	return &States{key: key}
+}
+
+// Seal encodes and signs st, setting it to expire StateTTL from now
+func (s *States) Seal(st State, now time.Time) string {
+	st.Expires = now.Add(StateTTL).Unix()
+	payload, _ := json.Marshal(st)
+	enc := base64.RawURLEncoding.EncodeToString(payload)
+	return enc + "." + s.sign(enc)
+}
+
+// Open checks sealed's signature and expiry and decodes it
+func (s *States) Open(sealed string, now time.Time) (State, error) {
+	enc, sig, ok := strings.Cut(sealed, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(s.sign(enc))) {
+		return State{}, ErrState
+	}
+	data, err := base64.RawURLEncoding.DecodeString(enc)
+	if err != nil {
+		return State{}, ErrState
+	}
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil || now.Unix() >= st.Expires {
+		return State{}, ErrState
+	}
+	return st, nil
+}
+
+func (s *States) sign(enc string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte("oauth-state:" + enc)) // never valid as a session token
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,147 @@
+// Package offline builds the bundle the PWA downloads for full offline
+// use: a gzipped tar of the dataset, the moons, the tours, the published
+// translations and a low-resolution copy of every texture, with a
+// manifest of the SHA-256 of every file. The service worker compares the
+// manifest's version with the one it holds and fetches the bundle only
+// when they differ. The same contents always give byte-identical
+// bundles, so an interrupted download can be resumed with Range.
+package offline
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"solar-system-explorer/backend/models"
+)
+
+// Format is the bundle layout version; clients refuse others
+const Format = 1
+
+// Names of the files in a bundle; textures sit under AssetsDir at their
+// asset path, as /assets/ serves them
+const (
+	ManifestFile     = "manifest.json"
+	BodiesFile       = "bodies.json"
+	MoonsFile        = "moons.json"
+	ToursFile        = "tours.json"
+	TranslationsFile = "translations.json"
+	AssetsDir        = "assets/"
+)
+
+// Manifest describes a bundle. Version changes whenever any file does.
+// Dataset is the ETag of the dataset the bundle was built from, which
+// /api/sync takes to bring the bundle's bodies up to date.
+type Manifest struct {
+	Format  int             `json:"format"`
+	Version string          `json:"version"`
+	Dataset string          `json:"dataset"`
+	Files   map[string]File `json:"files"`
+}
+
+// File is one file of a bundle
+type File struct {
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+	Body   string `json:"body,omitempty"` // for textures, the body depicted
+}
+
+// Texture is an image file to include
+type Texture struct {
+	Path string // asset path
+	Body string
+	Data []byte
+}
+
+// Contents is what a bundle carries
+type Contents struct {
+	Dataset      string // ETag
+	Bodies       []models.Planet
+	Moons        []models.Moon
+	Tours        []models.Tour
+	Translations map[string]map[string]models.Translation
+	Textures     []Texture
+}
+
+// Build writes contents as a bundle and returns it with its manifest
+func Build(contents Contents) ([]byte, Manifest, error) {
+	m := Manifest{Format: Format, Dataset: contents.Dataset, Files: make(map[string]File)}
+	data := make(map[string][]byte)
+	add := func(name, body string, b []byte) {
+		sum := sha256.Sum256(b)
+		m.Files[name] = File{SHA256: hex.EncodeToString(sum[:]), Size: int64(len(b)), Body: body}
+		data[name] = b
+	}
+	for _, f := range []struct {
+		name string
+		v    any
+	}{
+		{BodiesFile, contents.Bodies},
+		{MoonsFile, contents.Moons},
+		{ToursFile, contents.Tours},
+		{TranslationsFile, contents.Translations},
+	} {
+		b, err := json.Marshal(f.v)
+		if err != nil {
+			return nil, m, fmt.Errorf("%s: %w", f.name, err)
+		}
+		add(f.name, "", b)
+	}
+	for _, t := range contents.Textures {
+		add(AssetsDir+t.Path, t.Body, t.Data)
+	}
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	m.Version = version(m, names)
+
+	manifest, err := json.Marshal(m)
+	if err != nil {
+		return nil, m, err
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	write := func(name string, b []byte) error {
+		// No modification time, so equal contents give equal bytes
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(b))}); err != nil {
+			return err
+		}
+		_, err := tw.Write(b)
+		return err
+	}
+	if err := write(ManifestFile, manifest); err != nil {
+		return nil, m, err
+	}
+	for _, name := range names {
+		if err := write(name, data[name]); err != nil {
+			return nil, m, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, m, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, m, err
+	}
+	return buf.Bytes(), m, nil
+}
+
+// version hashes the file names and hashes with the format and dataset
+func version(m Manifest, names []string) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "%d\n%s\n", m.Format, m.Dataset)
+	for _, name := range names {
+		fmt.Fprintf(&s, "%s %s\n", name, m.Files[name].SHA256)
+	}
+	sum := sha256.Sum256([]byte(s.String()))
+	return hex.EncodeToString(sum[:12])
+}
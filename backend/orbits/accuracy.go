@@ -0,0 +1,57 @@
+package orbits
+
+import (
+	"time"
+
+	"solar-system-explorer/backend/astro"
+	"solar-system-explorer/backend/models"
+)
+
+// extrapolationScale is the span, in years, over which an extrapolated
+// position's error grows by its bound again: roughly quadratically, as
+// the rates' own errors accumulate in the mean longitude
+const extrapolationScale = 100
+
+// Accuracy tells how far a computed position can be trusted
+type Accuracy struct {
+	// Secular is set when the elements drift with their rates; otherwise
+	// they stay as at J2000
+	Secular   bool `json:"secular"`
+	ValidFrom int  `json:"valid_from,omitempty"` // years the rates were fitted over
+	ValidTo   int  `json:"valid_to,omitempty"`
+	// Extrapolated is set outside those years, and always for fixed
+	// elements, which are only exact at J2000
+	Extrapolated bool `json:"extrapolated"`
+	// Error estimates the bound on the heliocentric longitude error, in
+	// arcseconds; 0 when unknown
+	Error float64 `json:"error_arcsec,omitempty"`
+}
+
+// AccuracyOf estimates the accuracy of p's position at t
+func AccuracyOf(p models.Planet, t time.Time) Accuracy {
+	r := p.Rates
+	if p.IsStar || p.OrbitalPeriod == 0 {
+		return Accuracy{}
+	}
+	if r == nil {
+		return Accuracy{Extrapolated: true}
+	}
+	acc := Accuracy{Secular: true, ValidFrom: r.ValidFrom, ValidTo: r.ValidTo, Error: r.Error}
+	if r.ValidFrom == 0 && r.ValidTo == 0 {
+		return acc // fitted over years nobody recorded
+	}
+	year := 2000 + astro.JulianCenturies(astro.JulianDay(t))*100
+	var beyond float64
+	switch {
+	case year < float64(r.ValidFrom):
+		beyond = float64(r.ValidFrom) - year
+	case year > float64(r.ValidTo):
+		beyond = year - float64(r.ValidTo)
+	}
+	if beyond > 0 {
+		acc.Extrapolated = true
+		f := beyond / extrapolationScale
+		acc.Error *= 1 + f*f
+	}
+	return acc
+}
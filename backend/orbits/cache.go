@@ -0,0 +1,297 @@
+package orbits
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Cache memoizes positions keyed by (body, time rounded to Resolution).
+// Concurrent misses for the same key are coalesced so the computation runs
+// once and every waiting request shares the result. Beyond max entries the
+// least recently used are evicted, so clients sweeping through time can't
+// grow it without bound.
+type Cache struct {
+	ttl        time.Duration
+	resolution time.Duration
+	max        int
+
+	// table, when set, answers requests inside its range by interpolation
+	table atomic.Pointer[Table]
+	// shared, when set, is consulted on a miss before computing
+	shared Shared
+
+	mu       sync.Mutex
+	entries  map[cacheKey]*list.Element
+	lru      *list.List // of *cacheEntry, most recent first
+	inflight map[cacheKey]*call
+	// orbits holds each body's prepared elements, so a miss only does the
+	// work that depends on the instant
+	orbits map[string]*Elements
+	// gen counts resets; a miss computed across one is returned but not
+	// stored, since it may come from the elements Reset dropped
+	gen uint64
+
+	hits, misses, coalesced, tableHits, sharedHits, evictions uint64
+}
+
+// sharedTimeout bounds a shared cache lookup; computing a position is
+// cheaper than waiting longer
+const sharedTimeout = 50 * time.Millisecond
+
+// Shared is a cache several replicas use, such as Redis. Errors are
+// treated as misses.
+type Shared interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+type cacheKey struct {
+	body string
+	unix int64
+}
+
+type cacheEntry struct {
+	key     cacheKey
+	pos     Position
+	expires time.Time
+}
+
+// call is an in-flight computation other requests can wait on
+type call struct {
+	wg  sync.WaitGroup
+	pos Position
+}
+
+// CacheStats is a snapshot of cache counters
+type CacheStats struct {
+	Entries   int    `json:"entries"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Coalesced uint64 `json:"coalesced"`
+	TableHits uint64 `json:"table_hits"`
+	// SharedHits are misses answered by the shared cache
+	SharedHits uint64 `json:"shared_hits"`
+	// Evictions are entries dropped to stay within the size limit
+	Evictions uint64 `json:"evictions"`
+}
+
+// NewCache creates a cache holding at most max entries for ttl, bucketing
+// timestamps to resolution (e.g. one minute — far below anything visible
+// in the 3D view)
+func NewCache(ttl, resolution time.Duration, max int) *Cache {
+	if resolution <= 0 {
+		resolution = time.Second
+	}
+	if max < 1 {
+		max = 1
+	}
+	return &Cache{
+		ttl:        ttl,
+		resolution: resolution,
+		max:        max,
+		entries:    make(map[cacheKey]*list.Element),
+		lru:        list.New(),
+		inflight:   make(map[cacheKey]*call),
+		orbits:     make(map[string]*Elements),
+	}
+}
+
+// SetShared makes the cache consult shared on a miss and store what it
+// computes there. Call it before the cache is used.
+func (c *Cache) SetShared(shared Shared) {
+	c.shared = shared
+}
+
+// SetTable installs (or, with nil, removes) a precomputed ephemeris table
+func (c *Cache) SetTable(t *Table) {
+	c.table.Store(t)
+}
+
+// Position returns the body's position at t. Instants covered by the
+// precomputed table are interpolated; anything else is computed at t rounded
+// to the cache resolution.
+func (c *Cache) Position(ctx context.Context, p models.Planet, t time.Time) Position {
+	_, span := tracing.Start(ctx, "orbits.Position")
+	defer span.End()
+	// Attributes only when traced, so untraced calls allocate nothing
+	source := func(s string) {
+		if span.IsRecording() {
+			span.SetAttributes(attribute.String("body", p.Name), attribute.String("ephemeris.source", s))
+		}
+	}
+
+	if table := c.table.Load(); table != nil {
+		if pos, ok := table.Position(p, t); ok {
+			source("table")
+			c.mu.Lock()
+			c.tableHits++
+			c.mu.Unlock()
+			return pos
+		}
+	}
+
+	t = t.UTC().Round(c.resolution)
+	key := cacheKey{body: p.Name, unix: t.Unix()}
+	now := time.Now()
+
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok && now.Before(el.Value.(*cacheEntry).expires) {
+		source("cache")
+		c.lru.MoveToFront(el)
+		c.hits++
+		c.mu.Unlock()
+		return el.Value.(*cacheEntry).pos
+	}
+	if cl, ok := c.inflight[key]; ok {
+		source("coalesced")
+		c.coalesced++
+		c.mu.Unlock()
+		cl.wg.Wait()
+		return cl.pos
+	}
+	cl := &call{}
+	cl.wg.Add(1)
+	c.inflight[key] = cl
+	c.misses++
+	gen := c.gen
+	c.mu.Unlock()
+
+	var shared string
+	fromShared := false
+	if c.shared != nil {
+		shared = sharedKey(p, key)
+		sctx, cancel := context.WithTimeout(ctx, sharedTimeout)
+		data, err := c.shared.Get(sctx, shared)
+		cancel()
+		if err == nil && json.Unmarshal(data, &cl.pos) == nil {
+			fromShared = true
+		}
+	}
+	if fromShared {
+		source("shared")
+	} else {
+		source("computed")
+		cl.pos = c.elements(p).At(t)
+		if c.shared != nil {
+			if data, err := json.Marshal(cl.pos); err == nil {
+				sctx, cancel := context.WithTimeout(ctx, sharedTimeout)
+				_ = c.shared.Set(sctx, shared, data, c.ttl) // best effort
+				cancel()
+			}
+		}
+	}
+
+	c.mu.Lock()
+	if fromShared {
+		c.sharedHits++
+	}
+	if c.inflight[key] == cl {
+		delete(c.inflight, key)
+	}
+	if c.gen == gen {
+		c.put(&cacheEntry{key: key, pos: cl.pos, expires: now.Add(c.ttl)})
+	}
+	c.mu.Unlock()
+	cl.wg.Done()
+
+	return cl.pos
+}
+
+// Reset drops every cached position and the precomputed table, for when
+// the underlying orbital elements change. Misses already being computed
+// are not cached, and later requests don't wait on them.
+func (c *Cache) Reset() {
+	c.table.Store(nil)
+	c.mu.Lock()
+	c.gen++
+	c.entries = make(map[cacheKey]*list.Element)
+	c.lru.Init()
+	c.inflight = make(map[cacheKey]*call)
+	c.orbits = make(map[string]*Elements)
+	c.mu.Unlock()
+}
+
+// put stores e, replacing an expired entry under the same key, and evicts
+// the least recently used entries beyond max. Callers hold c.mu.
+func (c *Cache) put(e *cacheEntry) {
+	if el, ok := c.entries[e.key]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[e.key] = c.lru.PushFront(e)
+	for c.lru.Len() > c.max {
+		c.remove(c.lru.Back())
+		c.evictions++
+	}
+}
+
+// remove drops one entry. Callers hold c.mu.
+func (c *Cache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry).key)
+}
+
+// elements returns p's prepared orbit, preparing it again when the body
+// has been edited since
+func (c *Cache) elements(p models.Planet) *Elements {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.orbits[p.Name]
+	if !ok || !el.Of(p) {
+		prepared := NewElements(p)
+		el = &prepared
+		c.orbits[p.Name] = el
+	}
+	return el
+}
+
+// PurgeExpired drops expired entries and reports how many were removed
+func (c *Cache) PurgeExpired() int {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, el := range c.entries {
+		if !now.Before(el.Value.(*cacheEntry).expires) {
+			c.remove(el)
+			n++
+		}
+	}
+	return n
+}
+
+// Stats returns the current counters
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Entries:    len(c.entries),
+		Hits:       c.hits,
+		Misses:     c.misses,
+		Coalesced:  c.coalesced,
+		TableHits:  c.tableHits,
+		SharedHits: c.sharedHits,
+		Evictions:  c.evictions,
+	}
+}
+
+// sharedKey names an entry in the shared cache. It includes a hash of
+// the body, so replicas whose datasets differ, for a while or for good,
+// don't hand each other positions from other orbital elements.
+func sharedKey(p models.Planet, k cacheKey) string {
+	h := fnv.New64a()
+	json.NewEncoder(h).Encode(p)
+	return "ephemeris:" + k.body + ":" + strconv.FormatInt(k.unix, 10) + ":" + strconv.FormatUint(h.Sum64(), 36)
+}
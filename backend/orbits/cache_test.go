@@ -0,0 +1,88 @@
+package orbits
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/models"
+)
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	earth := models.GetSolarSystemBodies()[3]
+	c := NewCache(time.Hour, time.Minute, 3)
+	start := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Minute) }
+
+	for i := 0; i < 3; i++ {
+		c.Position(ctx, earth, at(i))
+	}
+	c.Position(ctx, earth, at(0)) // now the most recent
+	c.Position(ctx, earth, at(3)) // evicts at(1)
+
+	s := c.Stats()
+	if s.Entries != 3 || s.Evictions != 1 {
+		t.Fatalf("entries = %d, evictions = %d, want 3 and 1", s.Entries, s.Evictions)
+	}
+	for _, tt := range []struct {
+		i      int
+		cached bool
+	}{{0, true}, {2, true}, {3, true}, {1, false}} {
+		before := c.Stats().Hits
+		c.Position(ctx, earth, at(tt.i))
+		if hit := c.Stats().Hits > before; hit != tt.cached {
+			t.Errorf("minute %d: hit = %v, want %v", tt.i, hit, tt.cached)
+		}
+	}
+	if s := c.Stats(); s.Entries > 3 {
+		t.Errorf("entries = %d, want at most 3", s.Entries)
+	}
+}
+
+func TestCachePurgeAndReset(t *testing.T) {
+	ctx := context.Background()
+	earth := models.GetSolarSystemBodies()[3]
+	c := NewCache(-time.Second, time.Minute, 10)
+	c.Position(ctx, earth, time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC))
+	if n := c.PurgeExpired(); n != 1 || c.Stats().Entries != 0 {
+		t.Fatalf("purged %d leaving %d, want 1 leaving 0", n, c.Stats().Entries)
+	}
+
+	c = NewCache(time.Hour, time.Minute, 10)
+	c.Position(ctx, earth, time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC))
+	c.Reset()
+	if s := c.Stats(); s.Entries != 0 {
+		t.Errorf("entries after Reset = %d, want 0", s.Entries)
+	}
+}
+
+// resetDuring resets the cache while a miss is being looked up
+type resetDuring struct{ c *Cache }
+
+func (r resetDuring) Get(context.Context, string) ([]byte, error) {
+	r.c.Reset()
+	return nil, context.Canceled
+}
+
+func (resetDuring) Set(context.Context, string, []byte, time.Duration) error { return nil }
+
+// A position computed across a Reset is returned but not cached, so the
+// next request computes it again from the new elements
+func TestCacheResetDuringMiss(t *testing.T) {
+	ctx := context.Background()
+	earth := models.GetSolarSystemBodies()[3]
+	at := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	c := NewCache(time.Hour, time.Minute, 10)
+	c.SetShared(resetDuring{c})
+	c.Position(ctx, earth, at)
+	if s := c.Stats(); s.Entries != 0 {
+		t.Fatalf("entries = %d after a Reset during the miss, want 0", s.Entries)
+	}
+
+	c.SetShared(nil)
+	c.Position(ctx, earth, at)
+	if s := c.Stats(); s.Entries != 1 || s.Misses != 2 || s.Hits != 0 {
+		t.Errorf("entries = %d, misses = %d, hits = %d, want 1, 2 and 0", s.Entries, s.Misses, s.Hits)
+	}
+}
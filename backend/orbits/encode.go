@@ -0,0 +1,149 @@
+package orbits
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// errUnencodable is returned for values encoding/json refuses as well
+var errUnencodable = errors.New("orbits: position has a NaN, an infinity or a year outside 0–9999")
+
+// AppendJSON appends p encoded exactly as json.Marshal encodes it (keys in
+// the same order, the same number formatting and escaping) without
+// allocating, for endpoints that serve many positions many times a second
+func (p Position) AppendJSON(b []byte) ([]byte, error) {
+	if y := p.Time.Year(); y < 0 || y > 9999 {
+		return b, errUnencodable
+	}
+	b = append(b, `{"name":`...)
+	b = appendString(b, p.Name)
+	b = append(b, `,"name_sr":`...)
+	b = appendString(b, p.NameSR)
+	b = append(b, `,"time":"`...)
+	b = p.Time.AppendFormat(b, time.RFC3339Nano)
+	b = append(b, '"')
+	for _, f := range [...]struct {
+		key string
+		v   float64
+	}{
+		{`,"x":`, p.X},
+		{`,"y":`, p.Y},
+		{`,"z":`, p.Z},
+		{`,"distance":`, p.Distance},
+		{`,"mean_anomaly":`, p.MeanAnomaly},
+		{`,"true_anomaly":`, p.TrueAnomaly},
+		{`,"ecliptic_lon":`, p.EclipticLon},
+		{`,"ecliptic_lat":`, p.EclipticLat},
+	} {
+		if math.IsNaN(f.v) || math.IsInf(f.v, 0) {
+			return b, errUnencodable
+		}
+		b = append(b, f.key...)
+		b = appendFloat(b, f.v)
+	}
+	return append(b, '}'), nil
+}
+
+// AppendJSON appends a encoded as json.Marshal encodes it, without
+// allocating
+func (a Accuracy) AppendJSON(b []byte) []byte {
+	b = append(b, `{"secular":`...)
+	b = strconv.AppendBool(b, a.Secular)
+	if a.ValidFrom != 0 {
+		b = append(b, `,"valid_from":`...)
+		b = strconv.AppendInt(b, int64(a.ValidFrom), 10)
+	}
+	if a.ValidTo != 0 {
+		b = append(b, `,"valid_to":`...)
+		b = strconv.AppendInt(b, int64(a.ValidTo), 10)
+	}
+	b = append(b, `,"extrapolated":`...)
+	b = strconv.AppendBool(b, a.Extrapolated)
+	if a.Error != 0 && !math.IsNaN(a.Error) && !math.IsInf(a.Error, 0) {
+		b = append(b, `,"error_arcsec":`...)
+		b = appendFloat(b, a.Error)
+	}
+	return append(b, '}')
+}
+
+// appendFloat formats f as encoding/json does: plain decimals, switching
+// to an exponent below 1e-6 and from 1e21
+func appendFloat(b []byte, f float64) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	b = strconv.AppendFloat(b, f, format, -1, 64)
+	if format == 'e' {
+		// 1e-07 becomes 1e-7
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
+
+// AppendJSONString appends s quoted as encoding/json quotes it, for
+// encoding the rest of a response by hand
+func AppendJSONString(b []byte, s string) []byte {
+	return appendString(b, s)
+}
+
+// appendString quotes s as encoding/json does, including its escaping of
+// <, > and & and of invalid UTF-8
+func appendString(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
@@ -0,0 +1,114 @@
+package orbits
+
+import (
+	"math"
+	"time"
+
+	"solar-system-explorer/backend/models"
+)
+
+// Reference frames positions can be given in. All share the axes of the
+// J2000 ecliptic; they differ in their origin.
+const (
+	FrameHeliocentric = "heliocentric" // the Sun's centre, as computed
+	FrameBarycentric  = "barycentric"  // the Solar System's centre of mass
+	FrameGeocentric   = "geocentric"   // the Earth's centre, for the sky view
+)
+
+// LightSpeed is the speed of light in AU per day
+const LightSpeed = 173.1446326846693
+
+// Origin is where a frame's origin sits in heliocentric coordinates, AU,
+// and how fast it moves, AU/day, for the aberration it sees
+type Origin struct {
+	X, Y, Z    float64
+	VX, VY, VZ float64
+}
+
+// EarthOrigin is the geocentric frame's origin at t, with the Earth's
+// orbital velocity
+func EarthOrigin(earth models.Planet, t time.Time) Origin {
+	el := NewElements(earth)
+	p := el.At(t)
+	before, after := el.At(t.Add(-time.Hour)), el.At(t.Add(time.Hour))
+	const span = 2.0 / 24 // days
+	return Origin{
+		X: p.X, Y: p.Y, Z: p.Z,
+		VX: (after.X - before.X) / span, VY: (after.Y - before.Y) / span, VZ: (after.Z - before.Z) / span,
+	}
+}
+
+// Barycentre returns the centre of mass of the Sun and the bodies at
+// their heliocentric positions, in the same order. Bodies without a mass
+// are left out; the built-in planets put it within about 0.01 AU of the
+// Sun, mostly Jupiter's pull.
+func Barycentre(bodies []models.Planet, positions []Position) Origin {
+	var o Origin
+	total := 0.0
+	for i, b := range bodies {
+		if b.Mass <= 0 {
+			continue
+		}
+		total += b.Mass
+		if b.IsStar {
+			continue // at the origin
+		}
+		o.X += b.Mass * positions[i].X
+		o.Y += b.Mass * positions[i].Y
+		o.Z += b.Mass * positions[i].Z
+	}
+	if total == 0 {
+		return Origin{}
+	}
+	return Origin{X: o.X / total, Y: o.Y / total, Z: o.Z / total}
+}
+
+// From returns p seen from o: its coordinates, distance and ecliptic
+// longitude and latitude relative to o. The anomalies stay those of the
+// heliocentric orbit.
+func (p Position) From(o Origin) Position {
+	if o.X == 0 && o.Y == 0 && o.Z == 0 {
+		return p
+	}
+	p.X, p.Y, p.Z = p.X-o.X, p.Y-o.Y, p.Z-o.Z
+	return p.located()
+}
+
+// located works out p's distance, longitude and latitude from its
+// coordinates
+func (p Position) located() Position {
+	p.Distance = math.Sqrt(p.X*p.X + p.Y*p.Y + p.Z*p.Z)
+	p.EclipticLon, p.EclipticLat = 0, 0
+	if p.Distance > 0 {
+		p.EclipticLon = math.Mod(math.Atan2(p.Y, p.X)/deg+360, 360)
+		p.EclipticLat = math.Asin(p.Z/p.Distance) / deg
+	}
+	return p
+}
+
+// Apparent returns p's apparent position from o at t: where the body was
+// when the light reaching o at t left it (light-time), displaced towards
+// o's direction of motion (annual aberration, up to 20.5″ from the
+// Earth). The time stays t; the anomalies are those at the light's
+// departure.
+func Apparent(p models.Planet, t time.Time, o Origin) Position {
+	el := NewElements(p)
+	pos := el.At(t).From(o)
+	// Three passes settle the light-time to well under a millisecond
+	for range 3 {
+		tau := time.Duration(pos.Distance / LightSpeed * 24 * float64(time.Hour))
+		pos = el.At(t.Add(-tau)).From(o)
+	}
+	pos.Time = t
+	if pos.Distance == 0 || (o.VX == 0 && o.VY == 0 && o.VZ == 0) {
+		return pos
+	}
+	// First order in v/c: u' = u + v/c − (u·v/c) u, renormalized
+	ux, uy, uz := pos.X/pos.Distance, pos.Y/pos.Distance, pos.Z/pos.Distance
+	bx, by, bz := o.VX/LightSpeed, o.VY/LightSpeed, o.VZ/LightSpeed
+	dot := ux*bx + uy*by + uz*bz
+	ux, uy, uz = ux+bx-dot*ux, uy+by-dot*uy, uz+bz-dot*uz
+	n := math.Sqrt(ux*ux + uy*uy + uz*uz)
+	pos.X, pos.Y, pos.Z = ux/n*pos.Distance, uy/n*pos.Distance, uz/n*pos.Distance
+	return pos.located()
+}
@@ -0,0 +1,176 @@
+// Package orbits computes heliocentric positions from the J2000 Keplerian
+// elements stored on each body, carried to the instant by the body's
+// secular rates where it has them. It mirrors the solver used by the 3D
+// view so the API and the frontend agree on where everything is.
+package orbits
+
+import (
+	"math"
+	"time"
+
+	"solar-system-explorer/backend/astro"
+	"solar-system-explorer/backend/models"
+)
+
+const deg = math.Pi / 180
+
+// Position is a body's heliocentric ecliptic (J2000) position at an
+// instant, or, moved with From, its position seen from another origin
+type Position struct {
+	Name        string    `json:"name"`
+	NameSR      string    `json:"name_sr"`
+	Time        time.Time `json:"time"`
+	X           float64   `json:"x"`            // AU
+	Y           float64   `json:"y"`            // AU
+	Z           float64   `json:"z"`            // AU
+	Distance    float64   `json:"distance"`     // AU from the Sun (the origin)
+	MeanAnomaly float64   `json:"mean_anomaly"` // degrees
+	TrueAnomaly float64   `json:"true_anomaly"` // degrees
+	EclipticLon float64   `json:"ecliptic_lon"` // degrees, from the origin
+	EclipticLat float64   `json:"ecliptic_lat"` // degrees, from the origin
+}
+
+// SolveKepler solves Kepler's equation M = E − e·sin(E) for the eccentric
+// anomaly E (radians) with Newton-Raphson. Fifteen iterations converge even
+// for the comets' e ≈ 0.97.
+func SolveKepler(m, e float64) float64 {
+	E := m
+	if e > 0.8 {
+		E = math.Pi
+	}
+	for i := 0; i < 15; i++ {
+		d := (E - e*math.Sin(E) - m) / (1 - e*math.Cos(E))
+		E -= d
+		if math.Abs(d) < 1e-12 {
+			break
+		}
+	}
+	return E
+}
+
+// Elements is a body's orbit with everything that doesn't depend on the
+// instant — mean motion, the orbit's orientation as sines and cosines —
+// worked out once, for evaluating the same orbit at many instants
+type Elements struct {
+	source  orbitSource
+	still   bool    // the Sun, or a body without an orbit
+	a, e, n float64 // AU, eccentricity, mean motion in degrees/day
+	m0      float64 // mean anomaly at J2000, degrees
+	b       float64 // semi-minor axis, AU
+	w       float64 // argument of perihelion, radians
+
+	cosNode, sinNode, cosInc, sinInc float64
+
+	// secular is set when the elements drift, and the shape and
+	// orientation are worked out again at every instant
+	secular bool
+}
+
+// orbitSource is what Elements were derived from, to tell when a body's
+// orbit has been edited
+type orbitSource struct {
+	name, nameSR                                 string
+	isStar                                       bool
+	period, a, e, meanLon, perihelion, node, inc float64
+	rates                                        models.OrbitalRates
+}
+
+func sourceOf(p models.Planet) orbitSource {
+	s := orbitSource{
+		name: p.Name, nameSR: p.NameSR, isStar: p.IsStar,
+		period: p.OrbitalPeriod, a: p.DistanceFromSun, e: p.Eccentricity,
+		meanLon: p.MeanLongitude, perihelion: p.LongitudePerihelion,
+		node: p.AscendingNode, inc: p.Inclination,
+	}
+	if p.Rates != nil {
+		s.rates = *p.Rates
+	}
+	return s
+}
+
+// NewElements prepares p's orbit
+func NewElements(p models.Planet) Elements {
+	el := Elements{source: sourceOf(p)}
+	if p.IsStar || p.OrbitalPeriod == 0 {
+		el.still = true
+		return el
+	}
+	el.n = 360 / p.OrbitalPeriod
+	el.m0 = p.MeanLongitude - p.LongitudePerihelion
+	if r := p.Rates; r != nil {
+		el.secular = r.SemiMajorAxis != 0 || r.Eccentricity != 0 || r.Inclination != 0 ||
+			r.LongitudePerihelion != 0 || r.AscendingNode != 0
+		if r.MeanLongitude != 0 {
+			el.n = r.MeanLongitude / 36525
+		}
+	}
+	el.shape(0)
+	return el
+}
+
+// shape works out the orbit's size, shape and orientation t Julian
+// centuries from J2000
+func (el *Elements) shape(t float64) {
+	s, r := el.source, el.source.rates
+	el.a, el.e = s.a+r.SemiMajorAxis*t, s.e+r.Eccentricity*t
+	el.e = min(max(el.e, 0), 0.9999)
+	el.b = el.a * math.Sqrt(1-el.e*el.e)
+	perihelion, node := s.perihelion+r.LongitudePerihelion*t, s.node+r.AscendingNode*t
+	el.w = (perihelion - node) * deg
+	node, inc := node*deg, (s.inc+r.Inclination*t)*deg
+	el.cosNode, el.sinNode = math.Cos(node), math.Sin(node)
+	el.cosInc, el.sinInc = math.Cos(inc), math.Sin(inc)
+}
+
+// Of reports whether el were prepared from p as it is now
+func (el *Elements) Of(p models.Planet) bool {
+	return el.source == sourceOf(p)
+}
+
+// At returns the position at t, as Compute does
+func (el *Elements) At(t time.Time) Position {
+	pos := Position{Name: el.source.name, NameSR: el.source.nameSR, Time: t}
+	if el.still {
+		return pos
+	}
+
+	jd := astro.JulianDay(t)
+	m0 := el.m0
+	if el.secular {
+		// A copy, so el stays shared between goroutines
+		cy := astro.JulianCenturies(jd)
+		at := *el
+		at.shape(cy)
+		el = &at
+		m0 -= el.source.rates.LongitudePerihelion * cy
+	}
+	m := math.Mod(m0+el.n*(jd-astro.J2000), 360)
+	if m < 0 {
+		m += 360
+	}
+
+	E := SolveKepler(m*deg, el.e)
+	xv := el.a * (math.Cos(E) - el.e)
+	yv := el.b * math.Sin(E)
+	v := math.Atan2(yv, xv)
+	r := math.Hypot(xv, yv)
+
+	u := v + el.w
+	cosU, sinU := math.Cos(u), math.Sin(u)
+	pos.X = r * (el.cosNode*cosU - el.sinNode*sinU*el.cosInc)
+	pos.Y = r * (el.sinNode*cosU + el.cosNode*sinU*el.cosInc)
+	pos.Z = r * sinU * el.sinInc
+	pos.Distance = r
+	pos.MeanAnomaly = m
+	pos.TrueAnomaly = math.Mod(v/deg+360, 360)
+	pos.EclipticLon = math.Mod(math.Atan2(pos.Y, pos.X)/deg+360, 360)
+	pos.EclipticLat = math.Asin(pos.Z/r) / deg
+	return pos
+}
+
+// Compute returns the body's position at t. The Sun (and anything without
+// an orbit) sits at the origin.
+func Compute(p models.Planet, t time.Time) Position {
+	el := NewElements(p)
+	return el.At(t)
+}
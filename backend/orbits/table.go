@@ -0,0 +1,94 @@
+package orbits
+
+import (
+	"math"
+	"time"
+
+	"solar-system-explorer/backend/models"
+)
+
+// Table holds precomputed daily positions for a fixed date range and
+// serves interpolated values in between, trading memory for latency.
+type Table struct {
+	start   time.Time
+	step    time.Duration
+	count   int
+	samples map[string][]Position
+}
+
+// BuildTable samples every body's position from `from` to `to` at `step`
+// intervals. Roughly 9 bodies × 10 years of daily samples is ~5 MB.
+func BuildTable(bodies []models.Planet, from, to time.Time, step time.Duration) *Table {
+	from, to = from.UTC(), to.UTC()
+	count := int(to.Sub(from)/step) + 1
+	t := &Table{
+		start:   from,
+		step:    step,
+		count:   count,
+		samples: make(map[string][]Position, len(bodies)),
+	}
+	for _, b := range bodies {
+		el := NewElements(b)
+		s := make([]Position, count)
+		for i := range s {
+			s[i] = el.At(from.Add(time.Duration(i) * step))
+		}
+		t.samples[b.Name] = s
+	}
+	return t
+}
+
+// Range returns the first and last sampled instants
+func (t *Table) Range() (time.Time, time.Time) {
+	return t.start, t.start.Add(time.Duration(t.count-1) * t.step)
+}
+
+// Position interpolates the body's position at at. It reports false when the
+// body is unknown or at falls outside the table (callers then compute directly).
+func (t *Table) Position(p models.Planet, at time.Time) (Position, bool) {
+	s, ok := t.samples[p.Name]
+	if !ok {
+		return Position{}, false
+	}
+	f := float64(at.Sub(t.start)) / float64(t.step)
+	i := int(math.Floor(f))
+	// Four-point Lagrange needs a sample on each side of the interval
+	if i < 1 || i+2 >= t.count {
+		return Position{}, false
+	}
+	u := f - float64(i)
+
+	p0, p1, p2, p3 := s[i-1], s[i], s[i+1], s[i+2]
+	lerp := func(a, b, c, d float64) float64 {
+		return lagrange4(a, b, c, d, u)
+	}
+
+	pos := Position{Name: p.Name, NameSR: p.NameSR, Time: at.UTC()}
+	if p.IsStar || p.OrbitalPeriod == 0 {
+		return pos, true
+	}
+	pos.X = lerp(p0.X, p1.X, p2.X, p3.X)
+	pos.Y = lerp(p0.Y, p1.Y, p2.Y, p3.Y)
+	pos.Z = lerp(p0.Z, p1.Z, p2.Z, p3.Z)
+	pos.Distance = math.Sqrt(pos.X*pos.X + pos.Y*pos.Y + pos.Z*pos.Z)
+	pos.EclipticLon = math.Mod(math.Atan2(pos.Y, pos.X)/deg+360, 360)
+	pos.EclipticLat = math.Asin(pos.Z/pos.Distance) / deg
+	pos.MeanAnomaly = lerpAngle(p1.MeanAnomaly, p2.MeanAnomaly, u)
+	pos.TrueAnomaly = lerpAngle(p1.TrueAnomaly, p2.TrueAnomaly, u)
+	return pos, true
+}
+
+// lagrange4 interpolates between the middle two of four equally spaced
+// samples (at −1, 0, 1, 2) at fraction u ∈ [0, 1)
+func lagrange4(y0, y1, y2, y3, u float64) float64 {
+	return -u*(u-1)*(u-2)/6*y0 +
+		(u+1)*(u-1)*(u-2)/2*y1 -
+		(u+1)*u*(u-2)/2*y2 +
+		(u+1)*u*(u-1)/6*y3
+}
+
+// lerpAngle interpolates degrees across the 360° → 0° wrap
+func lerpAngle(a, b, u float64) float64 {
+	d := math.Mod(b-a+540, 360) - 180
+	return math.Mod(a+d*u+360, 360)
+}
@@ -0,0 +1,45 @@
+package physics
+
+import "math"
+
+// SunTemperature is the Sun's effective temperature in kelvin (IAU 2015)
+const SunTemperature = 5772.0
+
+// HZLimit is one habitable-zone boundary from Kopparapu et al. (2014): the
+// stellar flux, relative to what Earth receives, at which a planet of one
+// Earth mass crosses it, as a polynomial in T = Teff − 5780 K
+type HZLimit struct {
+	Name             string
+	seff, a, b, c, d float64
+}
+
+// Habitable-zone limits, innermost first. The conservative zone runs from
+// RunawayGreenhouse to MaximumGreenhouse; the optimistic one widens it to
+// RecentVenus and EarlyMars, where Venus and Mars appear to have held
+// surface water in the past.
+var (
+	RecentVenus       = HZLimit{"recent_venus", 1.776, 2.136e-4, 2.533e-8, -1.332e-11, -3.097e-15}
+	RunawayGreenhouse = HZLimit{"runaway_greenhouse", 1.107, 1.332e-4, 1.580e-8, -8.308e-12, -1.931e-15}
+	MaximumGreenhouse = HZLimit{"maximum_greenhouse", 0.356, 6.171e-5, 1.698e-9, -3.198e-12, -5.575e-16}
+	EarlyMars         = HZLimit{"early_mars", 0.320, 5.547e-5, 1.526e-9, -2.874e-12, -5.011e-16}
+)
+
+// Flux returns the limit's effective flux for a star of effective
+// temperature teff (K). The fit holds for 2600–7200 K; temperatures
+// outside are clamped to that range.
+func (l HZLimit) Flux(teff float64) float64 {
+	t := math.Min(math.Max(teff, 2600), 7200) - 5780
+	return l.seff + t*(l.a+t*(l.b+t*(l.c+t*l.d)))
+}
+
+// Distance returns the limit's distance in AU from a star of the given
+// luminosity (solar units) and effective temperature (K)
+func (l HZLimit) Distance(luminosity, teff float64) float64 {
+	return math.Sqrt(luminosity / l.Flux(teff))
+}
+
+// Insolation returns the flux relative to Earth's at distance (AU) from a
+// star of the given luminosity (solar units)
+func Insolation(luminosity, distance float64) float64 {
+	return luminosity / (distance * distance)
+}
@@ -0,0 +1,109 @@
+// Package physics holds physical constants and the textbook formulas
+// behind the teaching endpoints: Kepler's third law, surface gravity and
+// the like. Everything is in SI units; handlers convert to AU, days and
+// kilometres at the edges.
+package physics
+
+import "math"
+
+// Constants (CODATA 2018, IAU 2015 nominal values)
+const (
+	G             = 6.6743e-11     // gravitational constant, m³ kg⁻¹ s⁻²
+	AU            = 1.495978707e11 // astronomical unit, m
+	SolarMass     = 1.98847e30     // kg
+	SunRadius     = 6.957e8        // m
+	SolarConstant = 1361.0         // W/m² at 1 AU
+	EarthGravity  = 9.80665        // standard gravity, m/s²
+	Day           = 86400.0        // s
+	JulianYear    = 365.25 * Day   // s
+	SpeedOfLight  = 299792458.0    // m/s
+)
+
+// OrbitalPeriod returns the period in seconds of an orbit with semi-major
+// axis a (m) around a central mass (kg), by Kepler's third law
+// T² = 4π²a³ / GM. The orbiting body's own mass is neglected.
+func OrbitalPeriod(a, mass float64) float64 {
+	return 2 * math.Pi * math.Sqrt(a*a*a/(G*mass))
+}
+
+// SemiMajorAxis inverts OrbitalPeriod: the semi-major axis in metres of an
+// orbit with the given period (s) around mass (kg)
+func SemiMajorAxis(period, mass float64) float64 {
+	return math.Cbrt(G * mass * period * period / (4 * math.Pi * math.Pi))
+}
+
+// MeanOrbitalSpeed returns the mean speed in m/s on an orbit of semi-major
+// axis a (m) and period (s), taking the orbit as a circle
+func MeanOrbitalSpeed(a, period float64) float64 {
+	return 2 * math.Pi * a / period
+}
+
+// SurfaceGravity returns the gravitational acceleration in m/s² at radius
+// r (m) from a body of the given mass (kg)
+func SurfaceGravity(mass, r float64) float64 {
+	return G * mass / (r * r)
+}
+
+// JumpHeight returns how high a jump goes under gravity g (m/s²) when the
+// same take-off speed reaches earthHeight (m) on Earth, and how long the
+// jumper stays in the air (s)
+func JumpHeight(earthHeight, g float64) (height, hangTime float64) {
+	v := math.Sqrt(2 * EarthGravity * earthHeight)
+	return v * v / (2 * g), 2 * v / g
+}
+
+// Density returns the mean density in kg/m³ of a sphere of the given mass
+// (kg) and radius (m)
+func Density(mass, r float64) float64 {
+	return mass / (4.0 / 3.0 * math.Pi * r * r * r)
+}
+
+// RocheRigid returns the Roche limit in metres for a rigid satellite of
+// density satDensity around a primary of radius r (m) and density
+// primaryDensity: d = R·(2ρM/ρm)^⅓
+func RocheRigid(r, primaryDensity, satDensity float64) float64 {
+	return r * math.Cbrt(2*primaryDensity/satDensity)
+}
+
+// RocheFluid returns the Roche limit in metres for a fluid satellite, which
+// deforms and so breaks up further out: d ≈ 2.44·R·(ρM/ρm)^⅓
+func RocheFluid(r, primaryDensity, satDensity float64) float64 {
+	return 2.44 * r * math.Cbrt(primaryDensity/satDensity)
+}
+
+// TidalAcceleration returns the difference in the primary's pull (m/s²)
+// across a satellite of radius r (m) at distance d (m) from a primary of
+// the given mass (kg), to first order: 2GMr/d³
+func TidalAcceleration(mass, r, d float64) float64 {
+	return 2 * G * mass * r / (d * d * d)
+}
+
+// EscapeVelocity returns the speed in m/s needed to escape a body of the
+// given mass (kg) from distance r (m) from its centre: √(2GM/r)
+func EscapeVelocity(mass, r float64) float64 {
+	return math.Sqrt(2 * G * mass / r)
+}
+
+// CircularVelocity returns the speed in m/s of a circular orbit of radius
+// r (m) around mass (kg): √(GM/r)
+func CircularVelocity(mass, r float64) float64 {
+	return math.Sqrt(G * mass / r)
+}
+
+// DeltaVToOrbit returns the ideal delta-v in m/s to go from rest on the
+// surface (radius surface, m) to a circular orbit of radius r: the speed
+// whose kinetic energy equals the orbit's energy gain, with no gravity or
+// drag losses. It is a lower bound; real launches need more.
+func DeltaVToOrbit(mass, surface, r float64) float64 {
+	return math.Sqrt(2 * G * mass * (1/surface - 1/(2*r)))
+}
+
+// StefanBoltzmann is the Stefan–Boltzmann constant, W m⁻² K⁻⁴
+const StefanBoltzmann = 5.670374419e-8
+
+// EquilibriumTemperature returns the temperature in kelvin of a fast
+// rotating body that absorbs stellar flux (W/m²) with the given Bond
+// albedo and reradiates it from its whole surface as a black body
+func EquilibriumTemperature(flux, albedo float64) float64 {
+	return math.Pow(flux*(1-albedo)/(4*StefanBoltzmann), 0.25)
+}
@@ -0,0 +1,202 @@
+// Package progress records what each user has done: the bodies they have
+// explored and how far they got in each tour. The SPA turns it into badges
+// and completion percentages; teachers see it per class assignment.
+package progress
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+)
+
+// ErrInvalid is returned for out-of-range tour steps
+var ErrInvalid = errors.New("invalid progress")
+
+// Tour is how far a user got in one tour
+type Tour struct {
+	Step        int        `json:"step"` // steps seen
+	Steps       int        `json:"steps"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Progress is one user's record
+type Progress struct {
+	UserID    string               `json:"user_id"`
+	Bodies    map[string]time.Time `json:"bodies"` // English name → first explored
+	Tours     map[string]Tour      `json:"tours"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// Store holds every user's progress, persisted to a JSON file when a path
+// is set
+type Store struct {
+	mu        sync.RWMutex
+	path      string
+	byUser    map[string]*Progress
+	listeners []func(Progress)
+}
+
+// Open loads the progress file at path, which may not exist yet. An empty
+// path keeps progress in memory only.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, byUser: make(map[string]*Progress)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*Progress
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	for _, p := range list {
+		s.byUser[p.UserID] = p
+	}
+	return s, nil
+}
+
+// Get returns userID's progress, empty if they have none yet
+func (s *Store) Get(userID string) Progress {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if p, ok := s.byUser[userID]; ok {
+		return clone(p)
+	}
+	return Progress{UserID: userID, Bodies: map[string]time.Time{}, Tours: map[string]Tour{}}
+}
+
+// ExploreBody records that userID has explored body. Exploring it again
+// keeps the first time.
+func (s *Store) ExploreBody(userID, body string, now time.Time) (Progress, error) {
+	return s.update(userID, now, func(p *Progress) error {
+		if _, ok := p.Bodies[body]; !ok {
+			p.Bodies[body] = now.UTC()
+		}
+		return nil
+	})
+}
+
+// TourStep records that userID has seen step of a tour with steps steps.
+// Progress never goes backwards, so replaying a tour keeps its completion.
+func (s *Store) TourStep(userID, tourID string, step, steps int, now time.Time) (Tour, error) {
+	if steps < 1 || step < 1 || step > steps {
+		return Tour{}, fmt.Errorf("%w: step must be 1 to %d", ErrInvalid, steps)
+	}
+	var out Tour
+	_, err := s.update(userID, now, func(p *Progress) error {
+		t := p.Tours[tourID]
+		t.Steps, t.UpdatedAt = steps, now.UTC()
+		if step > t.Step {
+			t.Step = step
+		}
+		if t.Step == steps && t.CompletedAt == nil {
+			at := now.UTC()
+			t.CompletedAt = &at
+		}
+		p.Tours[tourID] = t
+		out = t
+		return nil
+	})
+	return out, err
+}
+
+// OnChange registers fn to run with a user's progress after every change
+// to it; resets aren't reported
+func (s *Store) OnChange(fn func(Progress)) {
+	s.mu.Lock()
+	s.listeners = append(s.listeners, fn)
+	s.mu.Unlock()
+}
+
+// Reset forgets userID's progress
+func (s *Store) Reset(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.byUser[userID]
+	if !ok {
+		return nil
+	}
+	delete(s.byUser, userID)
+	if err := s.save(); err != nil {
+		s.byUser[userID] = prev
+		return err
+	}
+	return nil
+}
+
+// update applies fn to userID's progress and persists it, rolling back if
+// either fails, then notifies listeners
+func (s *Store) update(userID string, now time.Time, fn func(*Progress) error) (Progress, error) {
+	p, listeners, err := s.apply(userID, now, fn)
+	if err != nil {
+		return Progress{}, err
+	}
+	for _, l := range listeners {
+		l(clone(&p))
+	}
+	return p, nil
+}
+
+// apply is update under the lock; it returns the listeners to notify
+func (s *Store) apply(userID string, now time.Time, fn func(*Progress) error) (Progress, []func(Progress), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, existed := s.byUser[userID]
+	p := &Progress{UserID: userID, Bodies: map[string]time.Time{}, Tours: map[string]Tour{}}
+	if existed {
+		c := clone(prev)
+		p = &c
+	}
+	if err := fn(p); err != nil {
+		return Progress{}, nil, err
+	}
+	p.UpdatedAt = now.UTC()
+	s.byUser[userID] = p
+	if err := s.save(); err != nil {
+		if existed {
+			s.byUser[userID] = prev
+		} else {
+			delete(s.byUser, userID)
+		}
+		return Progress{}, nil, err
+	}
+	return clone(p), s.listeners, nil
+}
+
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	list := make([]*Progress, 0, len(s.byUser))
+	for _, p := range s.byUser {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].UserID < list[j].UserID })
+	return jsonfile.Save(s.path, list, 0o644)
+}
+
+func clone(p *Progress) Progress {
+	out := *p
+	out.Bodies = make(map[string]time.Time, len(p.Bodies))
+	for k, v := range p.Bodies {
+		out.Bodies[k] = v
+	}
+	out.Tours = make(map[string]Tour, len(p.Tours))
+	for k, v := range p.Tours {
+		out.Tours[k] = v
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package progress
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProgressPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	s.ExploreBody("u1", "Mars", first)
+	s.ExploreBody("u1", "Mars", first.Add(time.Hour))
+	s.TourStep("u1", "inner", 3, 3, first)
+	if _, err := s.TourStep("u1", "inner", 1, 3, first.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.TourStep("u1", "inner", 4, 3, first); !errors.Is(err, ErrInvalid) {
+		t.Errorf("step past the end: err = %v, want ErrInvalid", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := reopened.Get("u1")
+	if !p.Bodies["Mars"].Equal(first) {
+		t.Errorf("Mars first explored %s, want %s", p.Bodies["Mars"], first)
+	}
+	if tour := p.Tours["inner"]; tour.Step != 3 || tour.CompletedAt == nil || !tour.CompletedAt.Equal(first) {
+		t.Errorf("tour = %+v: replaying step 1 must not undo the completion", tour)
+	}
+
+	if err := reopened.Reset("u1"); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := Open(path); len(again.Get("u1").Bodies) != 0 {
+		t.Error("reset progress came back after reopening")
+	}
+}
+
+// A change that can't be saved isn't kept, and listeners don't hear of it
+func TestProgressRollsBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	s.ExploreBody("u1", "Mars", now)
+	var heard int
+	s.OnChange(func(Progress) { heard++ })
+
+	// A directory in the file's place makes every save fail
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ExploreBody("u1", "Venus", now); err == nil {
+		t.Fatal("saving over a directory succeeded")
+	}
+	if _, err := s.ExploreBody("u2", "Venus", now); err == nil {
+		t.Fatal("saving over a directory succeeded")
+	}
+	if p := s.Get("u1"); len(p.Bodies) != 1 {
+		t.Errorf("u1 explored %v after a failed save, want only Mars", p.Bodies)
+	}
+	if p := s.Get("u2"); len(p.Bodies) != 0 {
+		t.Errorf("u2 has progress after a failed save: %v", p.Bodies)
+	}
+	if heard != 0 {
+		t.Errorf("listeners heard of %d unsaved changes", heard)
+	}
+}
@@ -0,0 +1,366 @@
+// Package redis is a small Redis client speaking RESP2 over a pool of TCP
+// connections. It covers the commands the server uses to share state
+// between replicas: strings with expiry, sets, scripts and pub/sub.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNil is returned for a missing key
+var ErrNil = errors.New("redis: nil")
+
+// Error is an error reply from the server
+type Error string
+
+func (e Error) Error() string { return "redis: " + string(e) }
+
+// dialTimeout bounds connecting and the handshake
+const dialTimeout = 5 * time.Second
+
+// Client is a pool of connections to one server. Keys are prefixed so
+// several deployments can share a server.
+type Client struct {
+	addr     string
+	password string
+	db       int
+	prefix   string
+	idle     chan *conn
+	slots    chan struct{} // one per connection the pool may open
+}
+
+// conn is one connection with its buffered reader and writer
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// Open parses rawURL (redis://[:password@]host:port[/db]) and checks the
+// server answers. poolSize bounds the open connections; prefix is put
+// before every key.
+func Open(ctx context.Context, rawURL string, poolSize int, prefix string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "redis" || u.Host == "" {
+		return nil, fmt.Errorf("redis: invalid URL, want redis://[:password@]host:port[/db]")
+	}
+	c := &Client{
+		addr:   u.Host,
+		prefix: prefix,
+		idle:   make(chan *conn, poolSize),
+		slots:  make(chan struct{}, poolSize),
+	}
+	if _, _, err := net.SplitHostPort(c.addr); err != nil {
+		c.addr = net.JoinHostPort(c.addr, "6379")
+	}
+	if u.User != nil {
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("redis: invalid database %q", db)
+		}
+	}
+	if _, err := c.Do(ctx, "PING"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Key returns name with the client's prefix
+func (c *Client) Key(name string) string { return c.prefix + name }
+
+// Do sends one command and returns its reply: a string, an int64, a
+// []any of those, or nil for a nil reply. Error replies are returned as
+// Error.
+func (c *Client) Do(ctx context.Context, args ...string) (any, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		cn.SetDeadline(deadline)
+	} else {
+		cn.SetDeadline(time.Time{})
+	}
+	reply, err := cn.do(args...)
+	var rerr Error
+	if err != nil && !errors.As(err, &rerr) {
+		c.discard(cn) // the stream may be out of step
+		return nil, err
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// Get returns the value of key, or ErrNil
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	reply, err := c.Do(ctx, "GET", c.Key(key))
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, ErrNil
+	}
+	s, _ := reply.(string)
+	return []byte(s), nil
+}
+
+// GetDel returns the value of key and deletes it in one step, or ErrNil
+func (c *Client) GetDel(ctx context.Context, key string) ([]byte, error) {
+	reply, err := c.Do(ctx, "GETDEL", c.Key(key))
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, ErrNil
+	}
+	s, _ := reply.(string)
+	return []byte(s), nil
+}
+
+// Set stores value under key, expiring after ttl unless it is 0
+func (c *Client) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", c.Key(key), string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := c.Do(ctx, args...)
+	return err
+}
+
+// Del deletes keys and returns how many existed
+func (c *Client) Del(ctx context.Context, keys ...string) (int, error) {
+	args := []string{"DEL"}
+	for _, k := range keys {
+		args = append(args, c.Key(k))
+	}
+	reply, err := c.Do(ctx, args...)
+	n, _ := reply.(int64)
+	return int(n), err
+}
+
+// SAdd adds members to the set at key
+func (c *Client) SAdd(ctx context.Context, key string, members ...string) error {
+	_, err := c.Do(ctx, append([]string{"SADD", c.Key(key)}, members...)...)
+	return err
+}
+
+// SRem removes members from the set at key
+func (c *Client) SRem(ctx context.Context, key string, members ...string) error {
+	_, err := c.Do(ctx, append([]string{"SREM", c.Key(key)}, members...)...)
+	return err
+}
+
+// SMembers returns the members of the set at key
+func (c *Client) SMembers(ctx context.Context, key string) ([]string, error) {
+	reply, err := c.Do(ctx, "SMEMBERS", c.Key(key))
+	if err != nil {
+		return nil, err
+	}
+	list, _ := reply.([]any)
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+// Eval runs a Lua script on keys, which are prefixed, with args, and
+// returns its reply as Do does
+func (c *Client) Eval(ctx context.Context, script string, keys []string, args ...string) (any, error) {
+	cmd := []string{"EVAL", script, strconv.Itoa(len(keys))}
+	for _, k := range keys {
+		cmd = append(cmd, c.Key(k))
+	}
+	return c.Do(ctx, append(cmd, args...)...)
+}
+
+// Publish sends payload to the subscribers of channel
+func (c *Client) Publish(ctx context.Context, channel string, payload []byte) error {
+	_, err := c.Do(ctx, "PUBLISH", c.Key(channel), string(payload))
+	return err
+}
+
+// Subscribe listens on channel over a connection of its own, outside the
+// pool, calling fn with each message until ctx is done or the connection
+// fails. It returns nil once subscribed reading ends because of ctx.
+func (c *Client) Subscribe(ctx context.Context, channel string, fn func(payload []byte)) error {
+	cn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer cn.Close()
+	stop := context.AfterFunc(ctx, func() { cn.Close() })
+	defer stop()
+	cn.SetDeadline(time.Time{})
+
+	if err := cn.send("SUBSCRIBE", c.Key(channel)); err != nil {
+		return err
+	}
+	for {
+		reply, err := cn.read()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		// Pushes are ["subscribe", channel, count] and ["message", channel, payload]
+		push, _ := reply.([]any)
+		if len(push) != 3 || push[0] != "message" {
+			continue
+		}
+		if payload, ok := push[2].(string); ok {
+			fn([]byte(payload))
+		}
+	}
+}
+
+// get takes an idle connection or dials a new one while the pool has
+// room, otherwise waits for one to be returned
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	case c.slots <- struct{}{}:
+		cn, err := c.dial(ctx)
+		if err != nil {
+			<-c.slots
+			return nil, err
+		}
+		return cn, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *Client) put(cn *conn) {
+	select {
+	case c.idle <- cn:
+	default:
+		c.discard(cn)
+	}
+}
+
+func (c *Client) discard(cn *conn) {
+	cn.Close()
+	<-c.slots
+}
+
+// dial connects and authenticates a new connection
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	d := net.Dialer{Timeout: dialTimeout}
+	nc, err := d.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+	nc.SetDeadline(time.Now().Add(dialTimeout))
+	if c.password != "" {
+		if _, err := cn.do("AUTH", c.password); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+	if c.db != 0 {
+		if _, err := cn.do("SELECT", strconv.Itoa(c.db)); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+// Close closes the idle connections. Connections in use are closed when
+// they are returned.
+func (c *Client) Close() error {
+	for {
+		select {
+		case cn := <-c.idle:
+			c.discard(cn)
+		default:
+			return nil
+		}
+	}
+}
+
+// do writes a command and reads its reply
+func (cn *conn) do(args ...string) (any, error) {
+	if err := cn.send(args...); err != nil {
+		return nil, err
+	}
+	return cn.read()
+}
+
+// send writes a command as an array of bulk strings
+func (cn *conn) send(args ...string) error {
+	fmt.Fprintf(cn.w, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(cn.w, "$%d\r\n%s\r\n", len(a), a)
+	}
+	return cn.w.Flush()
+}
+
+// read parses one reply
+func (cn *conn) read() (any, error) {
+	line, err := cn.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, rest := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return rest, nil
+	case '-':
+		return nil, Error(rest)
+	case ':':
+		return strconv.ParseInt(rest, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(rest)
+		if err != nil || n < 0 {
+			return nil, err // $-1 is a nil reply
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(cn.r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(rest)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		list := make([]any, n)
+		for i := range list {
+			if list[i], err = cn.read(); err != nil {
+				var rerr Error
+				if !errors.As(err, &rerr) {
+					return nil, err
+				}
+				list[i] = err
+			}
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
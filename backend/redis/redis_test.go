@@ -0,0 +1,300 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer speaks RESP to the client under test. It answers the
+// handshake itself, records the other commands, and answers them with the
+// raw reply that reply returns.
+type fakeServer struct {
+	addr  string
+	reply func(args []string) string
+
+	mu    sync.Mutex
+	cmds  [][]string
+	dials int
+}
+
+func newFakeServer(t *testing.T, reply func(args []string) string) *fakeServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &fakeServer{addr: ln.Addr().String(), reply: reply}
+	go func() {
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.dials++
+			s.mu.Unlock()
+			go s.serve(nc)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) serve(nc net.Conn) {
+	defer nc.Close()
+	r := bufio.NewReader(nc)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.cmds = append(s.cmds, args)
+		s.mu.Unlock()
+		var out string
+		switch args[0] {
+		case "PING":
+			out = "+PONG\r\n"
+		case "AUTH", "SELECT":
+			out = "+OK\r\n"
+		default:
+			out = s.reply(args)
+		}
+		if _, err := io.WriteString(nc, out); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand parses an array of bulk strings, as clients send commands
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+// commands returns what the server received, and how many connections
+func (s *fakeServer) commands() ([][]string, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]string(nil), s.cmds...), s.dials
+}
+
+func TestOpenHandshake(t *testing.T) {
+	s := newFakeServer(t, func([]string) string { return "+OK\r\n" })
+	c, err := Open(context.Background(), "redis://:s3cret@"+s.addr+"/2", 2, "solar:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Set(context.Background(), "k", []byte("a b\r\nc"), 1500*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"AUTH", "s3cret"},
+		{"SELECT", "2"},
+		{"PING"},
+		{"SET", "solar:k", "a b\r\nc", "PX", "1500"},
+	}
+	if cmds, dials := s.commands(); !reflect.DeepEqual(cmds, want) || dials != 1 {
+		t.Errorf("server got %q over %d connections, want %q over one", cmds, dials, want)
+	}
+
+	if _, err := Open(context.Background(), "http://"+s.addr, 1, ""); err == nil {
+		t.Error("a URL that isn't redis:// was accepted")
+	}
+}
+
+func TestReplies(t *testing.T) {
+	replies := map[string]string{
+		"status":  "+OK\r\n",
+		"integer": ":-42\r\n",
+		"bulk":    "$7\r\nab\r\ncde\r\n",
+		"empty":   "$0\r\n\r\n",
+		"nil":     "$-1\r\n",
+		"array":   "*3\r\n$1\r\na\r\n:1\r\n*1\r\n$1\r\nb\r\n",
+		"nilarr":  "*-1\r\n",
+		"witherr": "*2\r\n-WRONGTYPE no\r\n:2\r\n",
+	}
+	s := newFakeServer(t, func(args []string) string { return replies[args[1]] })
+	c, err := Open(context.Background(), "redis://"+s.addr, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	tests := map[string]any{
+		"status":  "OK",
+		"integer": int64(-42),
+		"bulk":    "ab\r\ncde",
+		"empty":   "",
+		"nil":     nil,
+		"array":   []any{"a", int64(1), []any{"b"}},
+		"nilarr":  nil,
+		"witherr": []any{Error("WRONGTYPE no"), int64(2)},
+	}
+	for name, want := range tests {
+		got, err := c.Do(context.Background(), "ECHO", name)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %#v, %v; want %#v", name, got, err, want)
+		}
+	}
+	if _, err := c.Get(context.Background(), "nil"); !errors.Is(err, ErrNil) {
+		t.Errorf("GET of a missing key: err = %v, want ErrNil", err)
+	}
+}
+
+// Error replies leave the connection in step and it is reused; anything
+// the client can't parse costs the connection
+func TestConnectionReuse(t *testing.T) {
+	s := newFakeServer(t, func(args []string) string {
+		switch args[1] {
+		case "err":
+			return "-ERR unknown command\r\n"
+		case "garbled":
+			return "?what\r\n"
+		}
+		return "+OK\r\n"
+	})
+	c, err := Open(context.Background(), "redis://"+s.addr, 4, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	var rerr Error
+	if _, err := c.Do(ctx, "ECHO", "err"); !errors.As(err, &rerr) || string(rerr) != "ERR unknown command" {
+		t.Errorf("err = %v, want the server's error", err)
+	}
+	c.Do(ctx, "ECHO", "ok")
+	if _, dials := s.commands(); dials != 1 {
+		t.Errorf("%d connections after an error reply, want 1", dials)
+	}
+	if _, err := c.Do(ctx, "ECHO", "garbled"); err == nil || errors.As(err, &rerr) {
+		t.Errorf("garbled reply: err = %v", err)
+	}
+	if _, err := c.Do(ctx, "ECHO", "ok"); err != nil {
+		t.Fatal(err)
+	}
+	if _, dials := s.commands(); dials != 2 {
+		t.Errorf("%d connections after a garbled reply, want a new one", dials)
+	}
+}
+
+func TestPoolBound(t *testing.T) {
+	release := make(chan struct{})
+	s := newFakeServer(t, func(args []string) string {
+		if args[1] == "slow" {
+			<-release
+		}
+		return "+OK\r\n"
+	})
+	c, err := Open(context.Background(), "redis://"+s.addr, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	done := make(chan error)
+	go func() {
+		_, err := c.Do(context.Background(), "ECHO", "slow")
+		done <- err
+	}()
+	// The only connection is busy, so the next command waits for it
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	for {
+		if cmds, _ := s.commands(); len(cmds) == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := c.Do(ctx, "ECHO", "fast"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want to time out waiting for the pool", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if _, dials := s.commands(); dials != 1 {
+		t.Errorf("%d connections with a pool of 1", dials)
+	}
+}
+
+func TestEval(t *testing.T) {
+	s := newFakeServer(t, func([]string) string { return "*2\r\n:1\r\n:999\r\n" })
+	c, err := Open(context.Background(), "redis://"+s.addr, 1, "p:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	got, err := c.Eval(context.Background(), "return 1", []string{"a", "b"}, "60000")
+	if err != nil || !reflect.DeepEqual(got, []any{int64(1), int64(999)}) {
+		t.Errorf("got %#v, %v", got, err)
+	}
+	want := []string{"EVAL", "return 1", "2", "p:a", "p:b", "60000"}
+	if cmds, _ := s.commands(); !reflect.DeepEqual(cmds[len(cmds)-1], want) {
+		t.Errorf("sent %q, want %q", cmds[len(cmds)-1], want)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	s := newFakeServer(t, func(args []string) string {
+		return "*3\r\n$9\r\nsubscribe\r\n$8\r\np:events\r\n:1\r\n" +
+			"*3\r\n$7\r\nmessage\r\n$8\r\np:events\r\n$5\r\nhello\r\n"
+	})
+	c, err := Open(context.Background(), "redis://"+s.addr, 1, "p:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan string, 1)
+	done := make(chan error)
+	go func() {
+		done <- c.Subscribe(ctx, "events", func(payload []byte) { got <- string(payload) })
+	}()
+	if msg := <-got; msg != "hello" {
+		t.Errorf("message %q, want hello", msg)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Subscribe after cancel = %v, want nil", err)
+	}
+	cmds, _ := s.commands()
+	if last := cmds[len(cmds)-1]; !reflect.DeepEqual(last, []string{"SUBSCRIBE", "p:events"}) {
+		t.Errorf("sent %q", last)
+	}
+}
@@ -0,0 +1,232 @@
+// Package reports keeps user reports about content. Each user may report a
+// piece of content once; reports are grouped into cases per target for the
+// moderation queue, and a case reaching the threshold is flagged so the
+// caller can hide the content until a moderator looks at it.
+package reports
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+	"solar-system-explorer/backend/internal/random"
+)
+
+// Kinds of content that can be reported
+const (
+	KindComment = "comment"
+)
+
+// Reasons a report can give
+var Reasons = []string{"spam", "abuse", "off-topic", "other"}
+
+// Report states
+const (
+	StatusOpen      = "open"
+	StatusUpheld    = "upheld"    // a moderator hid the content
+	StatusDismissed = "dismissed" // a moderator kept the content
+)
+
+// MaxDetail is the length limit for a report's free-text detail
+const MaxDetail = 500
+
+// Errors returned by Reports
+var (
+	ErrInvalid   = errors.New("invalid report")
+	ErrDuplicate = errors.New("already reported")
+	ErrNotFound  = errors.New("no open reports")
+)
+
+// Report is one user's complaint about one piece of content
+type Report struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`
+	Target     string     `json:"target"` // ID of the reported content
+	ReporterID string     `json:"reporter_id"`
+	Reason     string     `json:"reason"`
+	Detail     string     `json:"detail,omitempty"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// Case is the open reports against one piece of content
+type Case struct {
+	Kind       string         `json:"kind"`
+	Target     string         `json:"target"`
+	Count      int            `json:"count"`
+	Reasons    map[string]int `json:"reasons"`
+	AutoHidden bool           `json:"auto_hidden"` // reached the threshold
+	FirstAt    time.Time      `json:"first_at"`
+	Reports    []Report       `json:"reports"`
+}
+
+// Reports holds all reports, persisted to a JSON file when a path is set
+type Reports struct {
+	mu        sync.RWMutex
+	path      string
+	threshold int // open reports that hide content, 0 never does
+	list      []*Report
+}
+
+// Open loads the reports file at path, which may not exist yet. An empty
+// path keeps reports in memory only.
+func Open(path string, threshold int) (*Reports, error) {
+	r := &Reports{path: path, threshold: threshold}
+	if path == "" {
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.list); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return r, nil
+}
+
+// File records reporterID's report against kind/target. hide is true when
+// this report brings the open reports to the threshold.
+func (r *Reports) File(kind, target, reporterID, reason, detail string, now time.Time) (rep Report, hide bool, err error) {
+	if kind != KindComment {
+		return Report{}, false, fmt.Errorf("%w: kind must be %s", ErrInvalid, KindComment)
+	}
+	if !validReason(reason) {
+		return Report{}, false, fmt.Errorf("%w: reason must be one of %s", ErrInvalid, strings.Join(Reasons, ", "))
+	}
+	detail = strings.TrimSpace(detail)
+	if utf8.RuneCountInString(detail) > MaxDetail {
+		return Report{}, false, fmt.Errorf("%w: detail must be at most %d characters", ErrInvalid, MaxDetail)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	open := 0
+	for _, p := range r.list {
+		if p.Kind != kind || p.Target != target {
+			continue
+		}
+		if p.ReporterID == reporterID && p.Status == StatusOpen {
+			return Report{}, false, ErrDuplicate
+		}
+		if p.Status == StatusOpen {
+			open++
+		}
+	}
+	p := &Report{
+		ID:         random.Hex(8),
+		Kind:       kind,
+		Target:     target,
+		ReporterID: reporterID,
+		Reason:     reason,
+		Detail:     detail,
+		Status:     StatusOpen,
+		CreatedAt:  now.UTC(),
+	}
+	r.list = append(r.list, p)
+	if err := r.save(); err != nil {
+		r.list = r.list[:len(r.list)-1]
+		return Report{}, false, err
+	}
+	return *p, r.threshold > 0 && open+1 == r.threshold, nil
+}
+
+// Queue returns the cases with open reports, most reported first
+func (r *Reports) Queue() []Case {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	byTarget := make(map[string]*Case)
+	var out []*Case
+	for _, p := range r.list {
+		if p.Status != StatusOpen {
+			continue
+		}
+		key := p.Kind + "/" + p.Target
+		cs, ok := byTarget[key]
+		if !ok {
+			cs = &Case{Kind: p.Kind, Target: p.Target, Reasons: make(map[string]int), FirstAt: p.CreatedAt}
+			byTarget[key] = cs
+			out = append(out, cs)
+		}
+		cs.Count++
+		cs.Reasons[p.Reason]++
+		cs.Reports = append(cs.Reports, *p)
+	}
+	cases := make([]Case, 0, len(out))
+	for _, cs := range out {
+		cs.AutoHidden = r.threshold > 0 && cs.Count >= r.threshold
+		cases = append(cases, *cs)
+	}
+	sort.SliceStable(cases, func(i, j int) bool {
+		if cases[i].Count != cases[j].Count {
+			return cases[i].Count > cases[j].Count
+		}
+		return cases[i].FirstAt.Before(cases[j].FirstAt)
+	})
+	return cases
+}
+
+// Resolve closes the open reports against kind/target as upheld or
+// dismissed and returns the case as it was
+func (r *Reports) Resolve(kind, target, status string, now time.Time) (Case, error) {
+	if status != StatusUpheld && status != StatusDismissed {
+		return Case{}, fmt.Errorf("%w: status must be %s or %s", ErrInvalid, StatusUpheld, StatusDismissed)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cs := Case{Kind: kind, Target: target, Reasons: make(map[string]int)}
+	var closed []*Report
+	for _, p := range r.list {
+		if p.Kind == kind && p.Target == target && p.Status == StatusOpen {
+			if cs.Count == 0 {
+				cs.FirstAt = p.CreatedAt
+			}
+			cs.Count++
+			cs.Reasons[p.Reason]++
+			cs.Reports = append(cs.Reports, *p)
+			closed = append(closed, p)
+		}
+	}
+	if len(closed) == 0 {
+		return Case{}, ErrNotFound
+	}
+	cs.AutoHidden = r.threshold > 0 && cs.Count >= r.threshold
+	at := now.UTC()
+	for _, p := range closed {
+		p.Status, p.ResolvedAt = status, &at
+	}
+	if err := r.save(); err != nil {
+		for _, p := range closed {
+			p.Status, p.ResolvedAt = StatusOpen, nil
+		}
+		return Case{}, err
+	}
+	return cs, nil
+}
+
+func validReason(reason string) bool {
+	for _, r := range Reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reports) save() error {
+	if r.path == "" {
+		return nil
+	}
+	return jsonfile.Save(r.path, r.list, 0o644)
+}
@@ -0,0 +1,318 @@
+// Package s3 is a small client for S3-compatible object storage (AWS S3,
+// MinIO) signing requests with AWS Signature Version 4. It covers what the
+// server stores there: putting, getting (in ranges), listing and deleting
+// objects in one bucket.
+package s3
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/tracing"
+)
+
+// ErrNotFound is returned for a missing object
+var ErrNotFound = errors.New("s3: object not found")
+
+// unsignedPayload lets a body be streamed without hashing it first; the
+// request is still signed, and TLS protects the body in transit
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// Error is an error response from the service
+type Error struct {
+	Status  int
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("s3: %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// Config locates a bucket and the credentials for it
+type Config struct {
+	// Endpoint is the service URL, e.g. http://minio:9000. Empty uses AWS
+	// S3 in Region. Buckets are addressed by path, which both accept.
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// Client reads and writes objects in one bucket
+type Client struct {
+	cfg  Config
+	base *url.URL
+	HTTP *http.Client
+}
+
+// New returns a client for cfg.Bucket whose requests are traced as
+// client spans
+func New(cfg Config) (*Client, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("s3: bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	base, err := url.Parse(strings.TrimRight(endpoint, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("s3: endpoint %q must be an http(s) URL", endpoint)
+	}
+	return &Client{cfg: cfg, base: base, HTTP: &http.Client{Transport: tracing.NewTransport(nil)}}, nil
+}
+
+// Bucket is the bucket the client works in
+func (c *Client) Bucket() string { return c.cfg.Bucket }
+
+// Object describes a stored object
+type Object struct {
+	Key          string    `xml:"Key" json:"key"`
+	Size         int64     `xml:"Size" json:"size"`
+	LastModified time.Time `xml:"LastModified" json:"last_modified"`
+	ETag         string    `xml:"ETag" json:"etag"`
+}
+
+// Put stores size bytes from body under key
+func (c *Client) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	req, err := c.request(ctx, http.MethodPut, key, nil, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get opens the object at key. A non-empty byteRange ("bytes=0-1023") asks
+// for part of it, answered with 206 and Content-Range as in HTTP. The
+// caller closes the body.
+func (c *Client) Get(ctx context.Context, key, byteRange string) (*http.Response, error) {
+	req, err := c.request(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+	}
+	return c.do(req)
+}
+
+// Head returns the size and modification time of the object at key
+func (c *Client) Head(ctx context.Context, key string) (Object, error) {
+	req, err := c.request(ctx, http.MethodHead, key, nil, nil)
+	if err != nil {
+		return Object{}, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return Object{}, err
+	}
+	resp.Body.Close()
+	modified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return Object{Key: key, Size: resp.ContentLength, LastModified: modified, ETag: resp.Header.Get("ETag")}, nil
+}
+
+// Delete removes the object at key; a missing object is not an error
+func (c *Client) Delete(ctx context.Context, key string) error {
+	req, err := c.request(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// List returns the objects whose keys start with prefix, in key order
+func (c *Client) List(ctx context.Context, prefix string) ([]Object, error) {
+	var (
+		out   []Object
+		token string
+	)
+	for {
+		q := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		req, err := c.request(ctx, http.MethodGet, "", q, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.do(req)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Contents  []Object `xml:"Contents"`
+			Truncated bool     `xml:"IsTruncated"`
+			NextToken string   `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("s3: listing %s: %w", prefix, err)
+		}
+		out = append(out, page.Contents...)
+		if !page.Truncated || page.NextToken == "" {
+			return out, nil
+		}
+		token = page.NextToken
+	}
+}
+
+// request builds a request for key in the bucket (the bucket
+// itself when key is empty)
+func (c *Client) request(ctx context.Context, method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	raw := c.base.EscapedPath() + "/" + escape(c.cfg.Bucket, true)
+	if key != "" {
+		raw += "/" + escape(key, false)
+	}
+	u := *c.base
+	u.Path, _ = url.PathUnescape(raw)
+	u.RawPath = raw
+	u.RawQuery = canonicalQuery(query)
+	return http.NewRequestWithContext(ctx, method, u.String(), body)
+}
+
+// do signs and sends req, turning error responses into *Error or
+// ErrNotFound
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	c.sign(req, time.Now().UTC())
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	e := &Error{Status: resp.StatusCode}
+	if data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10)); err == nil {
+		xml.Unmarshal(data, e)
+	}
+	if e.Code == "NoSuchKey" || e.Code == "" && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if e.Code == "" {
+		e.Code = http.StatusText(resp.StatusCode)
+	}
+	return nil, e
+}
+
+// sign adds the Signature Version 4 Authorization header
+func (c *Client) sign(req *http.Request, now time.Time) {
+	stamp := now.Format("20060102T150405Z")
+	day := stamp[:8]
+	req.Header.Set("X-Amz-Date", stamp)
+	payload := req.Header.Get("X-Amz-Content-Sha256")
+	if payload == "" {
+		payload = unsignedPayload
+		req.Header.Set("X-Amz-Content-Sha256", payload)
+	}
+
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if req.Header.Get("Range") != "" {
+		signed = append(signed, "range")
+	}
+	sort.Strings(signed)
+	var headers strings.Builder
+	for _, h := range signed {
+		v := req.Header.Get(h)
+		if h == "host" {
+			v = req.URL.Host
+		}
+		headers.WriteString(h + ":" + strings.TrimSpace(v) + "\n")
+	}
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		headers.String(),
+		strings.Join(signed, ";"),
+		payload,
+	}, "\n")
+	scope := day + "/" + c.cfg.Region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hexSHA256(canonical)
+
+	key := hmacSHA256([]byte("AWS4"+c.cfg.SecretAccessKey), day)
+	for _, part := range []string{c.cfg.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.cfg.AccessKeyID, scope, strings.Join(signed, ";"), hex.EncodeToString(hmacSHA256(key, toSign))))
+}
+
+// canonicalQuery encodes q sorted by key with %20 for spaces, as
+// Signature Version 4 requires
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, escape(k, true)+"="+escape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escape percent-encodes everything but the unreserved characters, and
+// '/' unless slash is set
+func escape(s string, slash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case 'A' <= ch && ch <= 'Z', 'a' <= ch && ch <= 'z', '0' <= ch && ch <= '9',
+			ch == '-', ch == '_', ch == '.', ch == '~', ch == '/' && !slash:
+			b.WriteByte(ch)
+		default:
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func hexSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
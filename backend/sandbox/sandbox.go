@@ -0,0 +1,466 @@
+// Package sandbox keeps user-made variants of the solar system for
+// classroom experiments: give Mars a ring, double Jupiter's mass, and
+// see what changes. A sandbox stores only the fields it overrides, so it
+// always sits on top of the current dataset. Sandboxes belong to the
+// account that created them; only its owner can change one. Shared
+// sandboxes can be read by anyone with the ID.
+package sandbox
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+	"solar-system-explorer/backend/internal/random"
+	"solar-system-explorer/backend/models"
+)
+
+// Errors returned by Sandboxes. The message after ErrInvalid starts with
+// the JSON path of the field at fault.
+var (
+	ErrNotFound     = errors.New("sandbox not found")
+	ErrUnauthorized = errors.New("not the sandbox's owner")
+	ErrQuota        = errors.New("sandbox quota exceeded")
+	ErrInvalid      = errors.New("invalid change")
+)
+
+// Limits are the quotas on sandboxes
+type Limits struct {
+	PerUser  int           // live sandboxes one user may own
+	Total    int           // live sandboxes overall
+	MaxEdits int           // overridden fields per sandbox
+	TTL      time.Duration // how long a sandbox lives after its last change
+}
+
+// Editable lists the body fields a sandbox may override, by JSON name.
+// Names, descriptions and translations stay those of the dataset.
+var Editable = []string{
+	"radius", "mass", "distance_from_sun", "orbital_period", "rotation_period",
+	"axial_tilt", "albedo", "greenhouse_factor", "color", "satellites", "rings",
+	"eccentricity", "inclination", "ascending_node", "longitude_perihelion", "mean_longitude",
+}
+
+// Sandbox is one variant: per body (English name), the overridden fields
+// and their raw JSON values
+type Sandbox struct {
+	ID        string                                `json:"id"`
+	Name      string                                `json:"name"`
+	Shared    bool                                  `json:"shared"`
+	Edits     map[string]map[string]json.RawMessage `json:"edits"`
+	CreatedAt time.Time                             `json:"created_at"`
+	UpdatedAt time.Time                             `json:"updated_at"`
+	ExpiresAt time.Time                             `json:"expires_at"`
+}
+
+// EditCount returns the number of overridden fields
+func (sb Sandbox) EditCount() int {
+	n := 0
+	for _, fields := range sb.Edits {
+		n += len(fields)
+	}
+	return n
+}
+
+// Apply returns bodies with the sandbox's overrides. Overrides of bodies
+// no longer in the dataset are skipped.
+func (sb Sandbox) Apply(bodies []models.Planet) []models.Planet {
+	out := make([]models.Planet, len(bodies))
+	for i, b := range bodies {
+		out[i] = b
+		if fields := sb.Edits[b.Name]; len(fields) > 0 {
+			if p, err := apply(b, fields); err == nil {
+				out[i] = p
+			}
+		}
+	}
+	return out
+}
+
+// record is a sandbox as persisted, with its owner
+type record struct {
+	Sandbox
+	// Owner is the creator's user ID. Sandboxes made anonymously before
+	// creating one took a session have none and can only be read.
+	Owner string `json:"owner,omitempty"`
+}
+
+// Sandboxes holds all sandboxes, persisted to a JSON file when a path is
+// set
+type Sandboxes struct {
+	mu     sync.Mutex
+	path   string
+	limits Limits
+	byID   map[string]*record
+}
+
+// Open loads the sandboxes file at path, which may not exist yet. An empty
+// path keeps sandboxes in memory only.
+func Open(path string, limits Limits) (*Sandboxes, error) {
+	s := &Sandboxes{path: path, limits: limits, byID: make(map[string]*record)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*record
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	for _, r := range list {
+		s.byID[r.ID] = r
+	}
+	return s, nil
+}
+
+// Create makes an empty sandbox owned by user, counted against PerUser
+func (s *Sandboxes) Create(name, user string, shared bool, now time.Time) (Sandbox, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > 100 {
+		return Sandbox{}, fmt.Errorf("%w: name must be at most 100 characters", ErrInvalid)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	live, mine := 0, 0
+	for _, r := range s.byID {
+		if now.Before(r.ExpiresAt) {
+			live++
+			if r.Owner == user {
+				mine++
+			}
+		}
+	}
+	if live >= s.limits.Total {
+		return Sandbox{}, fmt.Errorf("%w: the server holds its maximum of %d sandboxes", ErrQuota, s.limits.Total)
+	}
+	if mine >= s.limits.PerUser {
+		return Sandbox{}, fmt.Errorf("%w: at most %d sandboxes per user", ErrQuota, s.limits.PerUser)
+	}
+	now = now.UTC()
+	r := &record{
+		Sandbox: Sandbox{
+			ID:        random.Hex(8),
+			Name:      name,
+			Shared:    shared,
+			Edits:     map[string]map[string]json.RawMessage{},
+			CreatedAt: now,
+			UpdatedAt: now,
+			ExpiresAt: now.Add(s.limits.TTL),
+		},
+		Owner: user,
+	}
+	s.byID[r.ID] = r
+	if err := s.save(); err != nil {
+		delete(s.byID, r.ID)
+		return Sandbox{}, err
+	}
+	return r.copy(), nil
+}
+
+// Get returns sandbox id to user, who is empty for an anonymous caller.
+// Anyone may read shared sandboxes.
+func (s *Sandboxes) Get(id, user string, now time.Time) (Sandbox, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.find(id, now)
+	if err != nil {
+		return Sandbox{}, err
+	}
+	if !r.Shared && !r.owns(user) {
+		return Sandbox{}, ErrUnauthorized
+	}
+	return r.copy(), nil
+}
+
+// Edit overrides fields of base (the body as in the dataset) in sandbox
+// id. A field set to its dataset value drops the override. The changed
+// body is checked for physical sense before anything is stored.
+func (s *Sandboxes) Edit(id, user string, base models.Planet, fields map[string]json.RawMessage, now time.Time) (Sandbox, models.Planet, error) {
+	for k := range fields {
+		if !editable(k) {
+			return Sandbox{}, models.Planet{}, fmt.Errorf("%w: %s cannot be changed, only %s can", ErrInvalid, k, strings.Join(Editable, ", "))
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.find(id, now)
+	if err != nil {
+		return Sandbox{}, models.Planet{}, err
+	}
+	if !r.owns(user) {
+		return Sandbox{}, models.Planet{}, ErrUnauthorized
+	}
+
+	merged := make(map[string]json.RawMessage, len(r.Edits[base.Name])+len(fields))
+	for k, v := range r.Edits[base.Name] {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	body, err := apply(base, merged)
+	if err != nil {
+		return Sandbox{}, models.Planet{}, err
+	}
+	if err := validate(body); err != nil {
+		return Sandbox{}, models.Planet{}, err
+	}
+	original, _ := json.Marshal(base)
+	var orig map[string]json.RawMessage
+	_ = json.Unmarshal(original, &orig)
+	for k, v := range merged {
+		if sameJSON(orig[k], v) {
+			delete(merged, k)
+		}
+	}
+	if r.EditCount()-len(r.Edits[base.Name])+len(merged) > s.limits.MaxEdits {
+		return Sandbox{}, models.Planet{}, fmt.Errorf("%w: at most %d changed fields per sandbox", ErrQuota, s.limits.MaxEdits)
+	}
+
+	prev, hadPrev := r.Edits[base.Name]
+	prevUpdated, prevExpires := r.UpdatedAt, r.ExpiresAt
+	if len(merged) == 0 {
+		delete(r.Edits, base.Name)
+	} else {
+		r.Edits[base.Name] = merged
+	}
+	r.touch(now, s.limits.TTL)
+	if err := s.save(); err != nil {
+		if hadPrev {
+			r.Edits[base.Name] = prev
+		} else {
+			delete(r.Edits, base.Name)
+		}
+		r.UpdatedAt, r.ExpiresAt = prevUpdated, prevExpires
+		return Sandbox{}, models.Planet{}, err
+	}
+	return r.copy(), body, nil
+}
+
+// Reset drops every override of body in sandbox id
+func (s *Sandboxes) Reset(id, user, body string, now time.Time) (Sandbox, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.find(id, now)
+	if err != nil {
+		return Sandbox{}, err
+	}
+	if !r.owns(user) {
+		return Sandbox{}, ErrUnauthorized
+	}
+	prev, ok := r.Edits[body]
+	if !ok {
+		return r.copy(), nil
+	}
+	delete(r.Edits, body)
+	prevUpdated, prevExpires := r.UpdatedAt, r.ExpiresAt
+	r.touch(now, s.limits.TTL)
+	if err := s.save(); err != nil {
+		r.Edits[body] = prev
+		r.UpdatedAt, r.ExpiresAt = prevUpdated, prevExpires
+		return Sandbox{}, err
+	}
+	return r.copy(), nil
+}
+
+// Delete removes sandbox id
+func (s *Sandboxes) Delete(id, user string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := s.find(id, now)
+	if err != nil {
+		return err
+	}
+	if !r.owns(user) {
+		return ErrUnauthorized
+	}
+	delete(s.byID, id)
+	if err := s.save(); err != nil {
+		s.byID[id] = r
+		return err
+	}
+	return nil
+}
+
+// Purge removes expired sandboxes and returns how many there were. It is
+// meant to run periodically; expired sandboxes are unreachable anyway.
+func (s *Sandboxes) Purge(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for id, r := range s.byID {
+		if !now.Before(r.ExpiresAt) {
+			delete(s.byID, id)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	return n, s.save()
+}
+
+// find returns the live sandbox id. Callers hold s.mu.
+func (s *Sandboxes) find(id string, now time.Time) (*record, error) {
+	r, ok := s.byID[id]
+	if !ok || !now.Before(r.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+	return r, nil
+}
+
+func (s *Sandboxes) save() error {
+	if s.path == "" {
+		return nil
+	}
+	list := make([]*record, 0, len(s.byID))
+	for _, r := range s.byID {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return jsonfile.Save(s.path, list, 0o600)
+}
+
+// owns reports whether user may change r
+func (r *record) owns(user string) bool {
+	return user != "" && user == r.Owner
+}
+
+func (r *record) touch(now time.Time, ttl time.Duration) {
+	r.UpdatedAt = now.UTC()
+	r.ExpiresAt = r.UpdatedAt.Add(ttl)
+}
+
+// copy returns the sandbox with its own edit maps, safe to use after the
+// lock is released
+func (r *record) copy() Sandbox {
+	sb := r.Sandbox
+	sb.Edits = make(map[string]map[string]json.RawMessage, len(r.Edits))
+	for body, fields := range r.Edits {
+		m := make(map[string]json.RawMessage, len(fields))
+		for k, v := range fields {
+			m[k] = v
+		}
+		sb.Edits[body] = m
+	}
+	return sb
+}
+
+// apply overrides fields of p through its JSON form, so each field takes
+// the same shape it has in the API
+func apply(p models.Planet, fields map[string]json.RawMessage) (models.Planet, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return models.Planet{}, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return models.Planet{}, err
+	}
+	for k, v := range fields {
+		m[k] = v
+	}
+	if data, err = json.Marshal(m); err != nil {
+		return models.Planet{}, err
+	}
+	var out models.Planet
+	if err := json.Unmarshal(data, &out); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return models.Planet{}, fmt.Errorf("%w: %s must be %s", ErrInvalid, typeErr.Field, jsonType(typeErr.Type))
+		}
+		return models.Planet{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	for k, v := range fields {
+		if string(v) == "null" && k != "rings" {
+			return models.Planet{}, fmt.Errorf("%w: %s can't be removed, only rings can", ErrInvalid, k)
+		}
+	}
+	return out, nil
+}
+
+// validate rejects bodies the rest of the API can't compute with
+func validate(p models.Planet) error {
+	var errs []string
+	check := func(ok bool, msg string) {
+		if !ok {
+			errs = append(errs, msg)
+		}
+	}
+	check(p.Radius > 0, "radius must be positive")
+	check(p.Mass >= 0, "mass must not be negative")
+	check(p.Albedo >= 0 && p.Albedo <= 1, "albedo must be between 0 and 1")
+	check(p.GreenhouseFactor >= 0 && p.GreenhouseFactor <= 10, "greenhouse_factor must be between 0 and 10")
+	check(p.AxialTilt >= 0 && p.AxialTilt <= 180, "axial_tilt must be between 0 and 180")
+	check(p.Satellites >= 0, "satellites must not be negative")
+	check(len(p.Color) == 7 && p.Color[0] == '#' && isHex(p.Color[1:]), "color must be #rrggbb")
+	if !p.IsStar {
+		check(p.DistanceFromSun > 0, "distance_from_sun must be positive")
+		check(p.OrbitalPeriod > 0, "orbital_period must be positive")
+		check(p.Eccentricity >= 0 && p.Eccentricity < 1, "eccentricity must be at least 0 and below 1")
+		check(p.Inclination >= 0 && p.Inclination <= 180, "inclination must be between 0 and 180")
+	}
+	if p.Rings != nil {
+		check(p.Rings.InnerRadius > p.Radius && p.Rings.OuterRadius > p.Rings.InnerRadius,
+			"rings must lie outside the body, with outer_radius beyond inner_radius")
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalid, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// jsonType names the JSON type expected for a Go type
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64, reflect.Int, reflect.Int64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	}
+	return "an object"
+}
+
+func editable(field string) bool {
+	for _, f := range Editable {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// sameJSON compares two JSON values by meaning, not formatting
+func sameJSON(a, b json.RawMessage) bool {
+	if a == nil {
+		return string(b) == "null"
+	}
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	ax, _ := json.Marshal(x)
+	by, _ := json.Marshal(y)
+	return string(ax) == string(by)
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
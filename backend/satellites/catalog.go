@@ -0,0 +1,261 @@
+package satellites
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+)
+
+// ErrUnknownGroup is returned for groups the catalogue doesn't follow
+var ErrUnknownGroup = errors.New("unknown satellite group")
+
+// Group is one of the CelesTrak groups the catalogue follows ("stations",
+// "science", "starlink") with the state of its downloads
+type Group struct {
+	Name        string     `json:"name"`
+	Count       int        `json:"count"`
+	FetchedAt   *time.Time `json:"fetched_at"` // last successful download
+	LastAttempt *time.Time `json:"last_attempt,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	// Stale is set when the group hasn't been downloaded for longer than
+	// the catalogue's staleness limit
+	Stale bool `json:"stale"`
+
+	tles []TLE
+}
+
+// Entry is a satellite in the catalogue
+type Entry struct {
+	TLE
+	Groups    []string  `json:"groups"`
+	FetchedAt time.Time `json:"fetched_at"`
+	// Stale is set on elements whose epoch is older than the staleness
+	// limit; predictions from them may be minutes off
+	Stale bool `json:"stale"`
+}
+
+// catalogFile is the persisted catalogue
+type catalogFile struct {
+	Groups []groupFile `json:"groups"`
+}
+
+type groupFile struct {
+	Name        string     `json:"name"`
+	FetchedAt   *time.Time `json:"fetched_at,omitempty"`
+	LastAttempt *time.Time `json:"last_attempt,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	TLEs        []TLE      `json:"tles"`
+}
+
+// Catalog keeps the TLEs of whole CelesTrak groups, downloaded on a
+// schedule by Refresh and persisted to a JSON file when a path is set so
+// a restart doesn't have to wait for CelesTrak. It is where the pass
+// predictor looks first; satellites outside the groups are fetched one by
+// one through the client.
+type Catalog struct {
+	Client     *Client
+	StaleAfter time.Duration
+
+	mu     sync.RWMutex
+	path   string
+	groups map[string]*Group
+	byID   map[int]Entry
+}
+
+// OpenCatalog loads the catalogue file at path, which may not exist yet,
+// following groups. An empty path keeps the catalogue in memory only.
+func OpenCatalog(path string, client *Client, groups []string, staleAfter time.Duration) (*Catalog, error) {
+	c := &Catalog{Client: client, StaleAfter: staleAfter, path: path, groups: make(map[string]*Group)}
+	for _, name := range groups {
+		c.groups[name] = &Group{Name: name}
+	}
+	defer c.index()
+	if path == "" {
+		return c, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f catalogFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	for _, g := range f.Groups {
+		// Groups dropped from the configuration are forgotten
+		if cur, ok := c.groups[g.Name]; ok {
+			cur.FetchedAt, cur.LastAttempt, cur.LastError, cur.tles = g.FetchedAt, g.LastAttempt, g.LastError, g.TLEs
+		}
+	}
+	return c, nil
+}
+
+// minRefresh is CelesTrak's limit on downloading the same group again
+const minRefresh = 2 * time.Hour
+
+// Refresh downloads every group not downloaded within the last two hours,
+// so restarts and manual runs don't hammer CelesTrak. A group that fails
+// keeps its previous elements and records the error; the errors are
+// returned joined.
+func (c *Catalog) Refresh(ctx context.Context) error {
+	now := c.Client.Clock.Now()
+	c.mu.RLock()
+	names := make([]string, 0, len(c.groups))
+	for name, g := range c.groups {
+		if g.FetchedAt == nil || now.Sub(*g.FetchedAt) >= minRefresh {
+			names = append(names, name)
+		}
+	}
+	c.mu.RUnlock()
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		tles, err := c.Client.Group(ctx, name)
+		now = c.Client.Clock.Now()
+		c.mu.Lock()
+		g := c.groups[name]
+		g.LastAttempt = &now
+		if err != nil {
+			g.LastError = err.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		} else {
+			g.FetchedAt, g.LastError, g.tles = &now, "", tles
+		}
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.index()
+	if err := c.save(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// index rebuilds the lookup by catalogue number. Callers hold c.mu or
+// have the catalogue to themselves.
+func (c *Catalog) index() {
+	c.byID = make(map[int]Entry)
+	names := make([]string, 0, len(c.groups))
+	for name := range c.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g := c.groups[name]
+		g.Count = len(g.tles)
+		if g.FetchedAt == nil {
+			continue
+		}
+		for _, t := range g.tles {
+			e, ok := c.byID[t.NoradID]
+			if !ok || t.Epoch.After(e.Epoch) {
+				e.TLE, e.FetchedAt = t, *g.FetchedAt
+			}
+			e.Groups = append(e.Groups, name)
+			c.byID[t.NoradID] = e
+		}
+	}
+}
+
+// Groups returns the followed groups, by name, as of now
+func (c *Catalog) Groups(now time.Time) []Group {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.groupList(now)
+}
+
+// groupList is Groups for callers holding c.mu
+func (c *Catalog) groupList(now time.Time) []Group {
+	out := make([]Group, 0, len(c.groups))
+	for _, g := range c.groups {
+		s := *g
+		s.tles = nil
+		s.Stale = g.FetchedAt == nil || now.Sub(*g.FetchedAt) > c.StaleAfter
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// List returns the satellites of group, or of every group when it is
+// empty, by catalogue number and as of now
+func (c *Catalog) List(group string, now time.Time) ([]Entry, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var out []Entry
+	if group == "" {
+		out = make([]Entry, 0, len(c.byID))
+		for _, e := range c.byID {
+			out = append(out, e)
+		}
+	} else {
+		g, ok := c.groups[group]
+		if !ok {
+			return nil, ErrUnknownGroup
+		}
+		out = make([]Entry, 0, len(g.tles))
+		seen := make(map[int]bool, len(g.tles))
+		for _, t := range g.tles {
+			if !seen[t.NoradID] {
+				seen[t.NoradID] = true
+				out = append(out, c.byID[t.NoradID])
+			}
+		}
+	}
+	for i := range out {
+		out[i].Stale = out[i].Age(now) > c.StaleAfter
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].NoradID < out[j].NoradID })
+	return out, nil
+}
+
+// TLE returns the elements for the satellite with catalogue number id:
+// the catalogue's while they were downloaded within the client's MaxAge,
+// otherwise the client's. The catalogue's older ones stand in, marked
+// stale, when CelesTrak can't be reached.
+func (c *Catalog) TLE(ctx context.Context, id int) (Fetched, error) {
+	c.mu.RLock()
+	e, ok := c.byID[id]
+	c.mu.RUnlock()
+	if ok && c.Client.Clock.Now().Sub(e.FetchedAt) < c.Client.MaxAge {
+		return Fetched{TLE: e.TLE, FetchedAt: e.FetchedAt}, nil
+	}
+	f, err := c.Client.TLE(ctx, id)
+	if err != nil && ok && !errors.Is(err, ErrNotFound) && ctx.Err() == nil {
+		return Fetched{TLE: e.TLE, FetchedAt: e.FetchedAt, Stale: true}, nil
+	}
+	return f, err
+}
+
+// save writes the catalogue via a temp file and rename. Callers hold c.mu.
+func (c *Catalog) save() error {
+	if c.path == "" {
+		return nil
+	}
+	f := catalogFile{Groups: []groupFile{}}
+	for _, g := range c.groupList(time.Time{}) {
+		f.Groups = append(f.Groups, groupFile{Name: g.Name, FetchedAt: g.FetchedAt, LastAttempt: g.LastAttempt, LastError: g.LastError, TLEs: c.groups[g.Name].tles})
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return jsonfile.Write(c.path, data, 0o644)
+}
@@ -0,0 +1,152 @@
+package satellites
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/clock"
+	"solar-system-explorer/backend/upstream"
+)
+
+// DefaultBaseURL is CelesTrak's general perturbations API
+const DefaultBaseURL = "https://celestrak.org/NORAD/elements/gp.php"
+
+// ErrNotFound is returned for catalogue numbers CelesTrak has no elements
+// for
+var ErrNotFound = errors.New("celestrak: no elements for this satellite")
+
+// Client fetches TLEs from CelesTrak by NORAD catalogue number. Elements
+// are reused until MaxAge and, while CelesTrak is unreachable, served
+// after it marked Stale.
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+	MaxAge  time.Duration
+	Clock   clock.Clock // when elements were fetched; clock.System by default
+
+	mu    sync.Mutex
+	cache map[int]fetched
+}
+
+type fetched struct {
+	tle TLE
+	at  time.Time
+}
+
+// cacheMax caps the satellites whose elements are kept
+const cacheMax = 1000
+
+// NewClient returns a traced client for baseURL (DefaultBaseURL if empty)
+// that refetches elements older than maxAge and retries as policy says
+func NewClient(baseURL string, maxAge time.Duration, policy upstream.Policy) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL: baseURL,
+		HTTP:    upstream.Client("celestrak", 15*time.Second, policy),
+		MaxAge:  maxAge,
+		Clock:   clock.System{},
+		cache:   make(map[int]fetched),
+	}
+}
+
+// Fetched is a TLE with when it was downloaded
+type Fetched struct {
+	TLE
+	FetchedAt time.Time `json:"fetched_at"`
+	// Stale is set on elements older than MaxAge returned while CelesTrak
+	// is down
+	Stale bool `json:"stale"`
+}
+
+// TLE returns the current elements for the satellite with catalogue
+// number id
+func (c *Client) TLE(ctx context.Context, id int) (Fetched, error) {
+	c.mu.Lock()
+	hit, cached := c.cache[id]
+	c.mu.Unlock()
+	if cached && c.Clock.Now().Sub(hit.at) < c.MaxAge {
+		return Fetched{TLE: hit.tle, FetchedAt: hit.at}, nil
+	}
+	tle, err := c.fetch(ctx, id)
+	if err != nil {
+		if cached && !errors.Is(err, ErrNotFound) && ctx.Err() == nil {
+			return Fetched{TLE: hit.tle, FetchedAt: hit.at, Stale: true}, nil
+		}
+		return Fetched{}, err
+	}
+	now := c.Clock.Now()
+	c.mu.Lock()
+	if _, ok := c.cache[id]; ok || len(c.cache) < cacheMax {
+		c.cache[id] = fetched{tle: tle, at: now}
+	}
+	c.mu.Unlock()
+	return Fetched{TLE: tle, FetchedAt: now}, nil
+}
+
+// Group downloads every element set of a CelesTrak group ("stations",
+// "science", "starlink"; see celestrak.org/NORAD/elements). CelesTrak
+// asks that a group be fetched at most once every two hours.
+func (c *Client) Group(ctx context.Context, name string) ([]TLE, error) {
+	tles, err := c.get(ctx, url.Values{"GROUP": {name}, "FORMAT": {"TLE"}}, 32<<20)
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("%w %q", ErrUnknownGroup, name)
+	}
+	return tles, err
+}
+
+func (c *Client) fetch(ctx context.Context, id int) (TLE, error) {
+	tles, err := c.get(ctx, url.Values{"CATNR": {strconv.Itoa(id)}, "FORMAT": {"TLE"}}, 1<<16)
+	if err != nil {
+		return TLE{}, err
+	}
+	for _, t := range tles {
+		if t.NoradID == id {
+			return t, nil
+		}
+	}
+	return TLE{}, ErrNotFound
+}
+
+// get queries CelesTrak and parses the element sets in the response, read
+// up to limit bytes
+func (c *Client) get(ctx context.Context, q url.Values, limit int64) ([]TLE, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("celestrak: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("celestrak: upstream returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, fmt.Errorf("celestrak: reading response: %w", err)
+	}
+	// Unknown numbers and groups get 200 and "No GP data found"
+	text := strings.TrimSpace(string(body))
+	if text == "" || strings.HasPrefix(text, "No GP data") {
+		return nil, ErrNotFound
+	}
+	tles, err := ParseTLEs(text)
+	if err != nil {
+		return nil, fmt.Errorf("celestrak: %w", err)
+	}
+	return tles, nil
+}
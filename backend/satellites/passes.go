@@ -0,0 +1,256 @@
+package satellites
+
+import (
+	"math"
+	"time"
+
+	"solar-system-explorer/backend/astro"
+)
+
+// WGS-84 ellipsoid, for the observer
+const (
+	wgs84Radius     = 6378.137 // km
+	wgs84Flattening = 1 / 298.257223563
+)
+
+// kmPerAU converts the Sun's distance
+const kmPerAU = 149597870.7
+
+// Observer is a place on the Earth, degrees with longitude positive east,
+// and its height above the ellipsoid in metres
+type Observer struct {
+	Lat    float64 `json:"lat"`
+	Lon    float64 `json:"lon"`
+	Height float64 `json:"height"`
+}
+
+// Look is a satellite as the observer sees it
+type Look struct {
+	Time  time.Time `json:"time"`
+	Alt   float64   `json:"alt"`   // degrees above the horizon
+	Az    float64   `json:"az"`    // degrees from north through east
+	Range float64   `json:"range"` // km
+	// Sunlit is false while the satellite is in the Earth's shadow
+	Sunlit bool `json:"sunlit"`
+	// SunAlt is the Sun's altitude for the observer; a sunlit satellite
+	// shows against a sky darker than civil twilight (below -6°)
+	SunAlt float64 `json:"sun_alt"`
+	// Magnitude is the estimated visual magnitude, when the satellite's
+	// standard magnitude is known and it is sunlit
+	Magnitude *float64 `json:"magnitude,omitempty"`
+}
+
+// Visible reports whether the satellite can be seen with the naked eye
+// or binoculars: above the horizon, sunlit, the observer in darkness
+func (l Look) Visible() bool {
+	return l.Alt > 0 && l.Sunlit && l.SunAlt < -6
+}
+
+// Pass is one passage of a satellite above an observer's minimum altitude
+type Pass struct {
+	Start Look `json:"start"`
+	Max   Look `json:"max"`
+	End   Look `json:"end"`
+	// Visible is true when the satellite can be seen at some point of the
+	// pass; VisibleFrom and VisibleTo bound that stretch
+	Visible     bool       `json:"visible"`
+	VisibleFrom *time.Time `json:"visible_from,omitempty"`
+	VisibleTo   *time.Time `json:"visible_to,omitempty"`
+}
+
+// Look returns the satellite's place in the observer's sky at t.
+// stdMag is the satellite's visual magnitude at 1000 km and half phase,
+// 0 when unknown.
+func (p *Propagator) Look(t time.Time, obs Observer, stdMag float64) (Look, error) {
+	s, err := p.At(t)
+	if err != nil {
+		return Look{}, err
+	}
+	jd := astro.JulianDay(t)
+
+	// TEME to Earth-fixed by the sidereal angle; polar motion and the
+	// equation of the equinoxes move the result by metres
+	theta := astro.GreenwichSiderealTime(jd) * math.Pi / 180
+	st, ct := math.Sincos(theta)
+	r := s.Position
+	sat := [3]float64{ct*r[0] + st*r[1], -st*r[0] + ct*r[1], r[2]}
+	site := obs.ecef()
+	d := [3]float64{sat[0] - site[0], sat[1] - site[1], sat[2] - site[2]}
+
+	sinLat, cosLat := math.Sincos(obs.Lat * math.Pi / 180)
+	sinLon, cosLon := math.Sincos(obs.Lon * math.Pi / 180)
+	south := sinLat*cosLon*d[0] + sinLat*sinLon*d[1] - cosLat*d[2]
+	east := -sinLon*d[0] + cosLon*d[1]
+	zenith := cosLat*cosLon*d[0] + cosLat*sinLon*d[1] + sinLat*d[2]
+	rng := math.Sqrt(d[0]*d[0] + d[1]*d[1] + d[2]*d[2])
+
+	l := Look{
+		Time:  t,
+		Alt:   math.Asin(zenith/rng) * 180 / math.Pi,
+		Az:    math.Mod(math.Atan2(east, -south)*180/math.Pi+360, 360),
+		Range: rng,
+	}
+
+	// The Sun, in TEME as near as matters here
+	sun := astro.Sun(jd)
+	sinRA, cosRA := math.Sincos(sun.RightAscension * math.Pi / 180)
+	sinDec, cosDec := math.Sincos(sun.Declination * math.Pi / 180)
+	dir := [3]float64{cosDec * cosRA, cosDec * sinRA, sinDec}
+	l.SunAlt = astro.NewEquatorial(sun.RightAscension, sun.Declination).ToHorizontal(jd, astro.Observer{Lat: obs.Lat, Lon: obs.Lon}).Alt
+
+	// In the shadow when behind the Earth within its cylinder
+	along := r[0]*dir[0] + r[1]*dir[1] + r[2]*dir[2]
+	perp := math.Sqrt(max(0, r[0]*r[0]+r[1]*r[1]+r[2]*r[2]-along*along))
+	l.Sunlit = along > 0 || perp > earthRadius
+
+	if stdMag != 0 && l.Sunlit {
+		// Sun–satellite and satellite–observer directions, in TEME
+		dist := sun.Distance * kmPerAU
+		toSun := [3]float64{dir[0]*dist - r[0], dir[1]*dist - r[1], dir[2]*dist - r[2]}
+		toObs := [3]float64{ct*d[0] - st*d[1], st*d[0] + ct*d[1], d[2]}
+		dot := -(toSun[0]*toObs[0] + toSun[1]*toObs[1] + toSun[2]*toObs[2])
+		phase := math.Acos(max(-1, min(1, dot/(norm(toSun)*rng))))
+		m := magnitude(stdMag, rng, phase)
+		l.Magnitude = &m
+	}
+	return l, nil
+}
+
+// magnitude scales the standard magnitude (1000 km, 90° phase) to rng km
+// and the phase angle by a diffusely reflecting sphere
+func magnitude(stdMag, rng, phase float64) float64 {
+	f := (math.Sin(phase) + (math.Pi-phase)*math.Cos(phase)) / math.Pi
+	return stdMag + 5*math.Log10(rng/1000) - 2.5*math.Log10(max(f, 1e-6))
+}
+
+// ecef returns the observer's Earth-fixed position, km
+func (o Observer) ecef() [3]float64 {
+	sinLat, cosLat := math.Sincos(o.Lat * math.Pi / 180)
+	sinLon, cosLon := math.Sincos(o.Lon * math.Pi / 180)
+	e2 := wgs84Flattening * (2 - wgs84Flattening)
+	n := wgs84Radius / math.Sqrt(1-e2*sinLat*sinLat)
+	h := o.Height / 1000
+	return [3]float64{(n + h) * cosLat * cosLon, (n + h) * cosLat * sinLon, (n*(1-e2) + h) * sinLat}
+}
+
+func norm(v [3]float64) float64 { return math.Sqrt(v[0]*v[0] + v[1]*v[1] + v[2]*v[2]) }
+
+// StandardMagnitudes are the visual magnitudes of well-known satellites
+// at 1000 km and half phase, by NORAD catalogue number
+var StandardMagnitudes = map[int]float64{
+	25544: -1.8, // ISS
+	48274: -0.8, // Tiangong
+	20580: 2.2,  // Hubble Space Telescope
+}
+
+// passStep is how often the sky is sampled for passes; a low-orbit pass
+// above 10° lasts a few minutes
+const passStep = 30 * time.Second
+
+// Passes finds the passes above minAlt degrees between from and to, each
+// rise and set bisected to the second and the culmination found by
+// ternary search. A pass under way at from starts there. If the orbit
+// decays on the way, the passes before it are returned with ErrDecayed.
+func (p *Propagator) Passes(obs Observer, from, to time.Time, minAlt, stdMag float64) ([]Pass, error) {
+	look := func(t time.Time) (Look, error) { return p.Look(t, obs, stdMag) }
+	crossing := func(lo, hi time.Time, rising bool) (Look, error) {
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2)
+			l, err := look(mid)
+			if err != nil {
+				return Look{}, err
+			}
+			if (l.Alt >= minAlt) == rising {
+				hi = mid
+			} else {
+				lo = mid
+			}
+		}
+		return look(hi.Truncate(time.Second))
+	}
+
+	passes := []Pass{}
+	var cur *Pass
+	var samples []Look
+	prev, err := look(from)
+	if err != nil {
+		return passes, err
+	}
+	if prev.Alt >= minAlt {
+		cur, samples = &Pass{Start: prev}, []Look{prev}
+	}
+	for t := from.Add(passStep); !t.After(to); t = t.Add(passStep) {
+		l, err := look(t)
+		if err != nil {
+			return passes, err
+		}
+		switch {
+		case cur == nil && l.Alt >= minAlt:
+			start, err := crossing(prev.Time, t, true)
+			if err != nil {
+				return passes, err
+			}
+			cur, samples = &Pass{Start: start}, []Look{start, l}
+		case cur != nil && l.Alt >= minAlt:
+			samples = append(samples, l)
+		case cur != nil:
+			end, err := crossing(prev.Time, t, false)
+			if err != nil {
+				return passes, err
+			}
+			cur.End = end
+			if err := p.finish(cur, append(samples, end), look); err != nil {
+				return passes, err
+			}
+			passes, cur = append(passes, *cur), nil
+		}
+		prev = l
+	}
+	return passes, nil
+}
+
+// finish fills in a pass's culmination and visibility from its samples
+func (p *Propagator) finish(pass *Pass, samples []Look, look func(time.Time) (Look, error)) error {
+	top := 0
+	for i, s := range samples {
+		if s.Alt > samples[top].Alt {
+			top = i
+		}
+	}
+	lo, hi := samples[max(0, top-1)].Time, samples[min(len(samples)-1, top+1)].Time
+	for hi.Sub(lo) > 2*time.Second {
+		third := hi.Sub(lo) / 3
+		a, err := look(lo.Add(third))
+		if err != nil {
+			return err
+		}
+		b, err := look(hi.Add(-third))
+		if err != nil {
+			return err
+		}
+		if a.Alt < b.Alt {
+			lo = a.Time
+		} else {
+			hi = b.Time
+		}
+	}
+	culm, err := look(lo.Add(hi.Sub(lo) / 2).Truncate(time.Second))
+	if err != nil {
+		return err
+	}
+	pass.Max = culm
+
+	for _, s := range append(samples, culm) {
+		if !s.Visible() {
+			continue
+		}
+		pass.Visible = true
+		if pass.VisibleFrom == nil || s.Time.Before(*pass.VisibleFrom) {
+			pass.VisibleFrom = &s.Time
+		}
+		if pass.VisibleTo == nil || s.Time.After(*pass.VisibleTo) {
+			pass.VisibleTo = &s.Time
+		}
+	}
+	return nil
+}
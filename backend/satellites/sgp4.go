@@ -0,0 +1,263 @@
+package satellites
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// WGS-72 constants, which TLEs are fitted with
+const (
+	earthRadius = 6378.135 // km
+	xke         = 0.0743669161331734132
+	j2          = 0.001082616
+	j3          = -0.00000253881
+	j4          = -0.00000165597
+	j3oj2       = j3 / j2
+	x2o3        = 2.0 / 3
+	twoPi       = 2 * math.Pi
+)
+
+// ErrDeepSpace is returned for orbits of 225 minutes or longer, which
+// need the SDP4 lunisolar terms this package doesn't implement
+var ErrDeepSpace = errors.New("satellites: deep-space orbits (period of 225 minutes or more) are not supported")
+
+// ErrDecayed is returned once the propagated orbit has decayed
+var ErrDecayed = errors.New("satellites: orbit has decayed")
+
+// Propagator computes a satellite's position from its TLE with the SGP4
+// model (Hoots & Roehrich, Spacetrack Report #3, as revised by Vallado et
+// al. 2006), near-Earth branch
+type Propagator struct {
+	TLE TLE
+
+	epoch                                      time.Time
+	no, ecco, inclo, nodeo, argpo, mo, bstar   float64
+	isimp                                      bool
+	aycof, con41, cc1, cc4, cc5, d2, d3, d4    float64
+	delmo, eta, argpdot, omgcof, sinmao        float64
+	t2cof, t3cof, t4cof, t5cof, x1mth2, x7thm1 float64
+	mdot, nodedot, xlcof, xmcof, nodecf        float64
+}
+
+// NewPropagator initialises SGP4 for t
+func NewPropagator(t TLE) (*Propagator, error) {
+	p := &Propagator{
+		TLE:   t,
+		epoch: t.Epoch,
+		ecco:  t.Eccentricity,
+		inclo: t.Inclination * math.Pi / 180,
+		nodeo: t.AscendingNode * math.Pi / 180,
+		argpo: t.ArgPerigee * math.Pi / 180,
+		mo:    t.MeanAnomaly * math.Pi / 180,
+		bstar: t.BStar,
+	}
+	no := t.MeanMotion * twoPi / 1440 // rad/min
+
+	// Recover the original mean motion from the Kozai one
+	ak := math.Pow(xke/no, x2o3)
+	cosio := math.Cos(p.inclo)
+	cosio2 := cosio * cosio
+	omeosq := 1 - p.ecco*p.ecco
+	rteosq := math.Sqrt(omeosq)
+	d1 := 0.75 * j2 * (3*cosio2 - 1) / (rteosq * omeosq)
+	del := d1 / (ak * ak)
+	adel := ak * (1 - del*del - del*(1.0/3+134*del*del/81))
+	del = d1 / (adel * adel)
+	p.no = no / (1 + del)
+	if twoPi/p.no >= 225 {
+		return nil, ErrDeepSpace
+	}
+
+	ao := math.Pow(xke/p.no, x2o3)
+	sinio := math.Sin(p.inclo)
+	po := ao * omeosq
+	con42 := 1 - 5*cosio2
+	p.con41 = -con42 - 2*cosio2
+	posq := po * po
+	rp := ao * (1 - p.ecco)
+	if rp < 1 {
+		return nil, fmt.Errorf("%w: perigee is below the surface", ErrDecayed)
+	}
+
+	// Perigees under 220 km get the simplified drag model
+	p.isimp = rp < 220/earthRadius+1
+	sfour := 78/earthRadius + 1
+	qzms24 := math.Pow((120-78)/earthRadius, 4)
+	if perige := (rp - 1) * earthRadius; perige < 156 {
+		s := perige - 78
+		if perige < 98 {
+			s = 20
+		}
+		qzms24 = math.Pow((120-s)/earthRadius, 4)
+		sfour = s/earthRadius + 1
+	}
+	pinvsq := 1 / posq
+	tsi := 1 / (ao - sfour)
+	p.eta = ao * p.ecco * tsi
+	etasq := p.eta * p.eta
+	eeta := p.ecco * p.eta
+	psisq := math.Abs(1 - etasq)
+	coef := qzms24 * math.Pow(tsi, 4)
+	coef1 := coef / math.Pow(psisq, 3.5)
+	cc2 := coef1 * p.no * (ao*(1+1.5*etasq+eeta*(4+etasq)) + 0.375*j2*tsi/psisq*p.con41*(8+3*etasq*(8+etasq)))
+	p.cc1 = p.bstar * cc2
+	cc3 := 0.0
+	if p.ecco > 1e-4 {
+		cc3 = -2 * coef * tsi * j3oj2 * p.no * sinio / p.ecco
+	}
+	p.x1mth2 = 1 - cosio2
+	p.cc4 = 2 * p.no * coef1 * ao * omeosq * (p.eta*(2+0.5*etasq) + p.ecco*(0.5+2*etasq) -
+		j2*tsi/(ao*psisq)*(-3*p.con41*(1-2*eeta+etasq*(1.5-0.5*eeta))+0.75*p.x1mth2*(2*etasq-eeta*(1+etasq))*math.Cos(2*p.argpo)))
+	p.cc5 = 2 * coef1 * ao * omeosq * (1 + 2.75*(etasq+eeta) + eeta*etasq)
+
+	cosio4 := cosio2 * cosio2
+	temp1 := 1.5 * j2 * pinvsq * p.no
+	temp2 := 0.5 * temp1 * j2 * pinvsq
+	temp3 := -0.46875 * j4 * pinvsq * pinvsq * p.no
+	p.mdot = p.no + 0.5*temp1*rteosq*p.con41 + 0.0625*temp2*rteosq*(13-78*cosio2+137*cosio4)
+	p.argpdot = -0.5*temp1*con42 + 0.0625*temp2*(7-114*cosio2+395*cosio4) + temp3*(3-36*cosio2+49*cosio4)
+	xhdot1 := -temp1 * cosio
+	p.nodedot = xhdot1 + (0.5*temp2*(4-19*cosio2)+2*temp3*(3-7*cosio2))*cosio
+	p.omgcof = p.bstar * cc3 * math.Cos(p.argpo)
+	if p.ecco > 1e-4 {
+		p.xmcof = -x2o3 * coef * p.bstar / eeta
+	}
+	p.nodecf = 3.5 * omeosq * xhdot1 * p.cc1
+	p.t2cof = 1.5 * p.cc1
+	den := 1 + cosio
+	if math.Abs(den) < 1.5e-12 {
+		den = 1.5e-12
+	}
+	p.xlcof = -0.25 * j3oj2 * sinio * (3 + 5*cosio) / den
+	p.aycof = -0.5 * j3oj2 * sinio
+	p.delmo = math.Pow(1+p.eta*math.Cos(p.mo), 3)
+	p.sinmao = math.Sin(p.mo)
+	p.x7thm1 = 7*cosio2 - 1
+
+	if !p.isimp {
+		cc1sq := p.cc1 * p.cc1
+		p.d2 = 4 * ao * tsi * cc1sq
+		temp := p.d2 * tsi * p.cc1 / 3
+		p.d3 = (17*ao + sfour) * temp
+		p.d4 = 0.5 * temp * ao * tsi * (221*ao + 31*sfour) * p.cc1
+		p.t3cof = p.d2 + 2*cc1sq
+		p.t4cof = 0.25 * (3*p.d3 + p.cc1*(12*p.d2+10*cc1sq))
+		p.t5cof = 0.2 * (3*p.d4 + 12*p.cc1*p.d3 + 6*p.d2*p.d2 + 15*cc1sq*(2*p.d2+cc1sq))
+	}
+	return p, nil
+}
+
+// State is a satellite's position (km) and velocity (km/s) in the TEME
+// frame: Earth-centred, the true equator and mean equinox of date
+type State struct {
+	Position [3]float64
+	Velocity [3]float64
+}
+
+// At propagates to t
+func (p *Propagator) At(t time.Time) (State, error) {
+	tsince := t.Sub(p.epoch).Minutes()
+
+	xmdf := p.mo + p.mdot*tsince
+	argpdf := p.argpo + p.argpdot*tsince
+	nodedf := p.nodeo + p.nodedot*tsince
+	argpm, mm := argpdf, xmdf
+	t2 := tsince * tsince
+	nodem := nodedf + p.nodecf*t2
+	tempa := 1 - p.cc1*tsince
+	tempe := p.bstar * p.cc4 * tsince
+	templ := p.t2cof * t2
+	if !p.isimp {
+		delomg := p.omgcof * tsince
+		delm := p.xmcof * (math.Pow(1+p.eta*math.Cos(xmdf), 3) - p.delmo)
+		mm = xmdf + delomg + delm
+		argpm = argpdf - delomg - delm
+		t3 := t2 * tsince
+		t4 := t3 * tsince
+		tempa -= p.d2*t2 + p.d3*t3 + p.d4*t4
+		tempe += p.bstar * p.cc5 * (math.Sin(mm) - p.sinmao)
+		templ += p.t3cof*t3 + t4*(p.t4cof+tsince*p.t5cof)
+	}
+
+	am := math.Pow(xke/p.no, x2o3) * tempa * tempa
+	nm := xke / math.Pow(am, 1.5)
+	em := p.ecco - tempe
+	if em >= 1 || em < -0.001 || am < 0.95 {
+		return State{}, ErrDecayed
+	}
+	em = max(em, 1e-6)
+	mm += p.no * templ
+	xlm := mm + argpm + nodem
+	nodem = math.Mod(nodem, twoPi)
+	argpm = math.Mod(argpm, twoPi)
+	xlm = math.Mod(xlm, twoPi)
+	mm = math.Mod(xlm-argpm-nodem, twoPi)
+	sinim, cosim := math.Sincos(p.inclo)
+
+	// Long-period periodics
+	axnl := em * math.Cos(argpm)
+	temp := 1 / (am * (1 - em*em))
+	aynl := em*math.Sin(argpm) + temp*p.aycof
+	xl := mm + argpm + nodem + temp*p.xlcof*axnl
+
+	// Kepler's equation
+	u := math.Mod(xl-nodem, twoPi)
+	eo1 := u
+	var sineo1, coseo1 float64
+	for range 10 {
+		sineo1, coseo1 = math.Sincos(eo1)
+		step := (u - aynl*coseo1 + axnl*sineo1 - eo1) / (1 - coseo1*axnl - sineo1*aynl)
+		step = max(-0.95, min(0.95, step))
+		eo1 += step
+		if math.Abs(step) < 1e-12 {
+			break
+		}
+	}
+
+	// Short-period periodics
+	ecose := axnl*coseo1 + aynl*sineo1
+	esine := axnl*sineo1 - aynl*coseo1
+	el2 := axnl*axnl + aynl*aynl
+	pl := am * (1 - el2)
+	if pl < 0 {
+		return State{}, ErrDecayed
+	}
+	rl := am * (1 - ecose)
+	rdotl := math.Sqrt(am) * esine / rl
+	rvdotl := math.Sqrt(pl) / rl
+	betal := math.Sqrt(1 - el2)
+	temp = esine / (1 + betal)
+	sinu := am / rl * (sineo1 - aynl - axnl*temp)
+	cosu := am / rl * (coseo1 - axnl + aynl*temp)
+	su := math.Atan2(sinu, cosu)
+	sin2u := 2 * cosu * sinu
+	cos2u := 1 - 2*sinu*sinu
+	temp = 1 / pl
+	temp1 := 0.5 * j2 * temp
+	temp2 := temp1 * temp
+
+	mrt := rl*(1-1.5*temp2*betal*p.con41) + 0.5*temp1*p.x1mth2*cos2u
+	if mrt < 1 {
+		return State{}, ErrDecayed
+	}
+	su -= 0.25 * temp2 * p.x7thm1 * sin2u
+	xnode := nodem + 1.5*temp2*cosim*sin2u
+	xinc := p.inclo + 1.5*temp2*cosim*sinim*cos2u
+	mvt := rdotl - nm*temp1*p.x1mth2*sin2u/xke
+	rvdot := rvdotl + nm*temp1*(p.x1mth2*cos2u+1.5*p.con41)/xke
+
+	sinsu, cossu := math.Sincos(su)
+	snod, cnod := math.Sincos(xnode)
+	sini, cosi := math.Sincos(xinc)
+	xmx, xmy := -snod*cosi, cnod*cosi
+	ux, uy, uz := xmx*sinsu+cnod*cossu, xmy*sinsu+snod*cossu, sini*sinsu
+	vx, vy, vz := xmx*cossu-cnod*sinsu, xmy*cossu-snod*sinsu, sini*cossu
+
+	const vkmpersec = earthRadius * xke / 60
+	return State{
+		Position: [3]float64{mrt * ux * earthRadius, mrt * uy * earthRadius, mrt * uz * earthRadius},
+		Velocity: [3]float64{(mvt*ux + rvdot*vx) * vkmpersec, (mvt*uy + rvdot*vy) * vkmpersec, (mvt*uz + rvdot*vz) * vkmpersec},
+	}, nil
+}
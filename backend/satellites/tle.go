@@ -0,0 +1,157 @@
+// Package satellites predicts where Earth satellites are from two-line
+// element sets (TLEs): SGP4 propagation, passes over an observer with an
+// estimate of how bright they look, and a client fetching current TLEs
+// from CelesTrak.
+package satellites
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidTLE wraps every parse failure
+var ErrInvalidTLE = errors.New("invalid TLE")
+
+// TLE is a two-line element set with its fields parsed. Angles are in
+// degrees, the mean motion in revolutions a day.
+type TLE struct {
+	Name          string    `json:"name"`
+	NoradID       int       `json:"norad_id"`
+	Epoch         time.Time `json:"epoch"`
+	Inclination   float64   `json:"inclination"`
+	AscendingNode float64   `json:"ascending_node"`
+	Eccentricity  float64   `json:"eccentricity"`
+	ArgPerigee    float64   `json:"arg_perigee"`
+	MeanAnomaly   float64   `json:"mean_anomaly"`
+	MeanMotion    float64   `json:"mean_motion"`
+	BStar         float64   `json:"bstar"` // drag term, 1/Earth radii
+	Line1         string    `json:"line1"`
+	Line2         string    `json:"line2"`
+}
+
+// Period returns the orbital period
+func (t TLE) Period() time.Duration {
+	return time.Duration(float64(24*time.Hour) / t.MeanMotion)
+}
+
+// Age returns how old the elements are at now; SGP4 predictions drift by
+// kilometres a day for low orbits, so a few days is stale
+func (t TLE) Age(now time.Time) time.Duration {
+	return now.Sub(t.Epoch)
+}
+
+// ParseTLE parses a two-line element set, name being the optional title
+// line of the three-line format. Line checksums are verified.
+func ParseTLE(name, line1, line2 string) (TLE, error) {
+	line1, line2 = strings.TrimRight(line1, " \r"), strings.TrimRight(line2, " \r")
+	if len(line1) != 69 || len(line2) != 69 || line1[0] != '1' || line2[0] != '2' {
+		return TLE{}, fmt.Errorf("%w: lines must be 69 characters starting with 1 and 2", ErrInvalidTLE)
+	}
+	for _, l := range []string{line1, line2} {
+		if checksum(l[:68]) != int(l[68]-'0') {
+			return TLE{}, fmt.Errorf("%w: checksum mismatch on line %c", ErrInvalidTLE, l[0])
+		}
+	}
+	t := TLE{Name: strings.TrimSpace(strings.TrimPrefix(name, "0 ")), Line1: line1, Line2: line2}
+
+	var errs []error
+	num := func(s string) float64 {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return f
+	}
+	id1, id2 := num(line1[2:7]), num(line2[2:7])
+	if id1 != id2 {
+		return TLE{}, fmt.Errorf("%w: lines are for different satellites", ErrInvalidTLE)
+	}
+	t.NoradID = int(id1)
+	year, day := int(num(line1[18:20])), num(line1[20:32])
+	if year < 57 {
+		year += 2000
+	} else {
+		year += 1900
+	}
+	t.Epoch = time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration((day - 1) * float64(24*time.Hour))).Round(time.Microsecond)
+	t.BStar = exponent(line1[53:61], num)
+	t.Inclination = num(line2[8:16])
+	t.AscendingNode = num(line2[17:25])
+	t.Eccentricity = num("0." + strings.TrimSpace(line2[26:33]))
+	t.ArgPerigee = num(line2[34:42])
+	t.MeanAnomaly = num(line2[43:51])
+	t.MeanMotion = num(line2[52:63])
+	if len(errs) > 0 {
+		return TLE{}, fmt.Errorf("%w: %v", ErrInvalidTLE, errs[0])
+	}
+	if t.MeanMotion <= 0 || t.Eccentricity >= 1 {
+		return TLE{}, fmt.Errorf("%w: not a bound orbit", ErrInvalidTLE)
+	}
+	if t.Name == "" {
+		t.Name = strconv.Itoa(t.NoradID)
+	}
+	return t, nil
+}
+
+// ParseTLEs parses a list in the three-line format CelesTrak serves (a
+// name line before each pair); bare pairs are accepted too
+func ParseTLEs(text string) ([]TLE, error) {
+	var lines []string
+	for _, l := range strings.Split(text, "\n") {
+		if l = strings.TrimRight(l, " \r"); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	var out []TLE
+	for i := 0; i < len(lines); {
+		name := ""
+		if !strings.HasPrefix(lines[i], "1 ") {
+			name, i = lines[i], i+1
+		}
+		if i+1 >= len(lines) {
+			return nil, fmt.Errorf("%w: set %d is incomplete", ErrInvalidTLE, len(out)+1)
+		}
+		t, err := ParseTLE(name, lines[i], lines[i+1])
+		if err != nil {
+			return nil, err
+		}
+		out, i = append(out, t), i+2
+	}
+	return out, nil
+}
+
+// checksum is the sum of a line's digits, minus signs counting 1, mod 10
+func checksum(l string) int {
+	sum := 0
+	for _, c := range l {
+		switch {
+		case c >= '0' && c <= '9':
+			sum += int(c - '0')
+		case c == '-':
+			sum++
+		}
+	}
+	return sum % 10
+}
+
+// exponent reads the TLE's implied-decimal notation: " 66816-4" is
+// 0.66816e-4
+func exponent(s string, num func(string) float64) float64 {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return 0
+	}
+	sign := 1.0
+	switch s[0] {
+	case '-':
+		sign, s = -1, s[1:]
+	case '+':
+		s = s[1:]
+	}
+	mant, exp := s[:len(s)-2], s[len(s)-2:]
+	return sign * num("0."+mant) * math.Pow(10, num(exp))
+}
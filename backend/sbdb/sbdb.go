@@ -0,0 +1,267 @@
+// Package sbdb is a client for the JPL Small-Body Database API
+// (https://ssd-api.jpl.nasa.gov/doc/sbdb.html). It fetches asteroids and
+// comets by designation and maps their orbit and physical parameters onto
+// models.Planet so they can be imported into the dataset.
+package sbdb
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/models"
+	"solar-system-explorer/backend/upstream"
+)
+
+// DefaultBaseURL is the public SBDB endpoint
+const DefaultBaseURL = "https://ssd-api.jpl.nasa.gov/sbdb.api"
+
+// j2000 is the Julian Day of the J2000.0 epoch our elements are given at
+const j2000 = 2451545.0
+
+// assumedAlbedo is the geometric albedo a body's size is estimated with
+// when SBDB has neither its diameter nor its albedo
+const assumedAlbedo = 0.14
+
+// ErrNotFound is returned for designations SBDB doesn't know or can't
+// resolve to a single object
+var ErrNotFound = errors.New("sbdb: object not found")
+
+// Client queries SBDB. The last object fetched for each designation is
+// kept and returned, marked Stale, while SBDB is unreachable.
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+
+	mu   sync.Mutex
+	last map[string]Object
+}
+
+// lastMax caps the designations remembered for the fallback
+const lastMax = 1000
+
+// NewClient returns a client for baseURL (DefaultBaseURL if empty) whose
+// requests are traced as client spans and retried as policy says
+func NewClient(baseURL string, policy upstream.Policy) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL: baseURL,
+		HTTP:    upstream.Client("sbdb", 15*time.Second, policy),
+		last:    make(map[string]Object),
+	}
+}
+
+// Object is the subset of an SBDB response we use
+type Object struct {
+	Object struct {
+		FullName   string `json:"fullname"`
+		ShortName  string `json:"shortname"`
+		Des        string `json:"des"`
+		Kind       string `json:"kind"` // an/au numbered/unnumbered asteroid, cn/cu comet
+		OrbitClass struct {
+			Code string `json:"code"`
+			Name string `json:"name"`
+		} `json:"orbit_class"`
+	} `json:"object"`
+	Orbit struct {
+		Epoch    string  `json:"epoch"` // JD (TDB)
+		Elements []param `json:"elements"`
+	} `json:"orbit"`
+	PhysPar []param `json:"phys_par"`
+	// Discovery is missing for objects SBDB has no circumstances for
+	Discovery *struct {
+		Who      string `json:"who"`
+		Date     string `json:"date"` // "1801-Jan-01"
+		Location string `json:"location"`
+		Site     string `json:"site"`
+	} `json:"discovery"`
+
+	// Stale is set on an earlier response returned while SBDB is down
+	Stale bool `json:"-"`
+}
+
+type param struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"` // SBDB sends numbers as strings, sometimes null
+}
+
+// Fetch looks up one object by designation, name or SPK-ID ("433",
+// "Eros", "2023 DW")
+func (c *Client) Fetch(ctx context.Context, designation string) (*Object, error) {
+	key := strings.ToLower(strings.TrimSpace(designation))
+	obj, err := c.fetch(ctx, designation)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case err == nil:
+		if _, ok := c.last[key]; ok || len(c.last) < lastMax {
+			c.last[key] = *obj
+		}
+	case !errors.Is(err, ErrNotFound) && ctx.Err() == nil:
+		if prev, ok := c.last[key]; ok {
+			prev.Stale = true
+			return &prev, nil
+		}
+	}
+	return obj, err
+}
+
+func (c *Client) fetch(ctx context.Context, designation string) (*Object, error) {
+	q := url.Values{"sstr": {designation}, "phys-par": {"1"}, "discovery": {"1"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sbdb: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("sbdb: upstream returned %s", resp.Status)
+	}
+	var raw struct {
+		Object
+		Message string `json:"message"` // "specified object was not found"
+		List    []any  `json:"list"`    // ambiguous search
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("sbdb: decoding response: %w", err)
+	}
+	if raw.Message != "" || len(raw.List) > 0 || raw.Object.Object.FullName == "" {
+		return nil, ErrNotFound
+	}
+	return &raw.Object, nil
+}
+
+// Body maps an SBDB object onto our model. SBDB gives osculating elements
+// at a recent epoch; the mean longitude is propagated back to J2000 with
+// the mean motion so the Kepler solver can use the object like a planet.
+// Objects without a measured diameter are sized from their absolute
+// magnitude.
+func (o *Object) Body() (models.Planet, error) {
+	el := func(name string) (float64, bool) { return lookup(o.Orbit.Elements, name) }
+	e, okE := el("e")
+	a, okA := el("a")
+	i, okI := el("i")
+	om, okOm := el("om")
+	w, okW := el("w")
+	ma, okMa := el("ma")
+	epoch, err := strconv.ParseFloat(o.Orbit.Epoch, 64)
+	if !okE || !okA || !okI || !okOm || !okW || !okMa || err != nil {
+		return models.Planet{}, fmt.Errorf("sbdb: %s has incomplete orbital elements", o.Object.FullName)
+	}
+	if e >= 1 {
+		return models.Planet{}, fmt.Errorf("sbdb: %s has an open orbit (e=%.3f)", o.Object.FullName, e)
+	}
+	per, ok := el("per")
+	if !ok {
+		per = 365.25 * math.Pow(a, 1.5)
+	}
+
+	varpi := normDeg(om + w)
+	n := 360 / per // deg/day
+	meanLon := normDeg(varpi + ma - n*(epoch-j2000))
+
+	name := displayName(o)
+	p := models.Planet{
+		Name:                name,
+		NameSR:              name,
+		DistanceFromSun:     round(a, 4),
+		OrbitalPeriod:       round(per, 2),
+		Color:               "#9E9E9E",
+		Description:         fmt.Sprintf("%s – malo telo iz JPL baze malih tela (klasa %s).", o.Object.FullName, o.Object.OrbitClass.Name),
+		NotableSatellites:   []string{},
+		Eccentricity:        round(e, 4),
+		Inclination:         round(i, 3),
+		AscendingNode:       round(om, 3),
+		LongitudePerihelion: round(varpi, 3),
+		MeanLongitude:       round(meanLon, 3),
+		Translations: map[string]models.Translation{
+			"en": {Description: fmt.Sprintf("%s, a small body of the %s class from the JPL Small-Body Database.", o.Object.FullName, o.Object.OrbitClass.Name)},
+		},
+	}
+	d, ok := lookup(o.PhysPar, "diameter")
+	if !ok || d <= 0 {
+		// Most small bodies were never measured; size them from their
+		// brightness, as SBDB's own estimates do
+		h, ok := lookup(o.PhysPar, "H")
+		if !ok {
+			return models.Planet{}, fmt.Errorf("sbdb: %s has neither a diameter nor an absolute magnitude", o.Object.FullName)
+		}
+		albedo, ok := lookup(o.PhysPar, "albedo")
+		if !ok || albedo <= 0 {
+			albedo = assumedAlbedo
+		}
+		d = 1329 / math.Sqrt(albedo) * math.Pow(10, -h/5)
+	}
+	p.Radius = round(d/2, 2)
+	if rot, ok := lookup(o.PhysPar, "rot_per"); ok {
+		p.RotationPeriod = round(rot/24, 4) // hours → days
+	}
+	if d := o.Discovery; d != nil && d.Who != "" {
+		if year, err := strconv.Atoi(strings.SplitN(d.Date, "-", 2)[0]); err == nil {
+			p.Discovery = &models.Discovery{Discoverer: d.Who, Year: year, Observatory: cmp.Or(d.Site, d.Location)}
+		}
+	}
+	return p, nil
+}
+
+// displayName turns "1 Ceres" into "Ceres" and leaves provisional
+// designations and comets ("2023 DW", "1P/Halley") alone
+func displayName(o *Object) string {
+	short := strings.TrimSpace(o.Object.ShortName)
+	if num, rest, ok := strings.Cut(short, " "); ok && strings.HasPrefix(o.Object.Kind, "a") {
+		if _, err := strconv.Atoi(num); err == nil {
+			return rest
+		}
+	}
+	if short == "" {
+		return o.Object.FullName
+	}
+	return short
+}
+
+func lookup(params []param, name string) (float64, bool) {
+	for _, p := range params {
+		if p.Name != name {
+			continue
+		}
+		switch v := p.Value.(type) {
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			return f, err == nil
+		case float64:
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+func normDeg(a float64) float64 {
+	a = math.Mod(a, 360)
+	if a < 0 {
+		a += 360
+	}
+	return a
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,49 @@
+package sbdb
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// object returns an asteroid on a Ceres-like orbit with the given
+// physical parameters
+func object(phys ...param) *Object {
+	o := &Object{PhysPar: phys}
+	o.Object.FullName = "1 Ceres (A801 AA)"
+	o.Object.ShortName = "1 Ceres"
+	o.Object.Kind = "an"
+	o.Orbit.Epoch = "2460600.5"
+	o.Orbit.Elements = []param{{"e", "0.0796"}, {"a", "2.77"}, {"i", "10.59"}, {"om", "80.25"}, {"w", "73.3"}, {"ma", "145.8"}}
+	return o
+}
+
+func TestBodyRadius(t *testing.T) {
+	tests := []struct {
+		name string
+		phys []param
+		want float64 // km; 0 for an error
+	}{
+		{"measured", []param{{"diameter", "939.4"}, {"H", "3.34"}}, 469.7},
+		{"from H and albedo", []param{{"H", "3.34"}, {"albedo", "0.09"}}, 1329 / math.Sqrt(0.09) * math.Pow(10, -3.34/5) / 2},
+		{"from H alone", []param{{"H", "18"}, {"diameter", nil}}, 1329 / math.Sqrt(assumedAlbedo) * math.Pow(10, -18.0/5) / 2},
+		{"unsized", []param{{"diameter", nil}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := object(tt.phys...).Body()
+			if tt.want == 0 {
+				if err == nil || !strings.Contains(err.Error(), "absolute magnitude") {
+					t.Errorf("Body() = radius %g, err %v; want an error", p.Radius, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(p.Radius-tt.want) > 0.01 {
+				t.Errorf("radius = %g km, want %.2f", p.Radius, tt.want)
+			}
+		})
+	}
+}
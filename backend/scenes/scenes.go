@@ -0,0 +1,226 @@
+// Package scenes stores snapshots of the 3D view — camera, simulated time,
+// selected bodies, speed — under short IDs for share links. Scenes expire
+// and count how often their link was opened.
+package scenes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+	"solar-system-explorer/backend/internal/random"
+)
+
+// Limits on the shared state
+const (
+	MaxSelected = 20
+	MaxSpeed    = 1e6 // simulation speed multiplier, either direction
+	idLength    = 7
+	idAlphabet  = "23456789abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ" // no 0/O, 1/l/I
+)
+
+// Errors returned by Scenes
+var (
+	ErrNotFound = errors.New("scene not found")
+	ErrInvalid  = errors.New("invalid scene")
+	ErrFull     = errors.New("scene storage is full")
+)
+
+// State is the view as the frontend restores it
+type State struct {
+	Camera   Camera    `json:"camera"`
+	Time     time.Time `json:"time"`     // simulated instant
+	Selected []string  `json:"selected"` // body names, the first one focused
+	Speed    float64   `json:"speed"`    // simulation speed multiplier, negative runs backwards
+}
+
+// Camera is a camera pose in scene units
+type Camera struct {
+	Position [3]float64 `json:"position"`
+	Target   [3]float64 `json:"target"`
+	FOV      float64    `json:"fov,omitempty"` // vertical, degrees
+}
+
+// Scene is a stored State
+type Scene struct {
+	ID        string    `json:"id"`
+	State     State     `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Views     int64     `json:"views"`
+}
+
+// Scenes holds scenes by ID, persisted to a JSON file when a path is set.
+// View counts are kept in memory and written by Flush.
+type Scenes struct {
+	mu    sync.Mutex
+	path  string
+	max   int
+	byID  map[string]*Scene
+	dirty bool
+}
+
+// Open loads the scenes file at path, which may not exist yet. An empty
+// path keeps scenes in memory only. At most max scenes are kept.
+func Open(path string, max int) (*Scenes, error) {
+	s := &Scenes{path: path, max: max, byID: make(map[string]*Scene)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*Scene
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	for _, sc := range list {
+		s.byID[sc.ID] = sc
+	}
+	return s, nil
+}
+
+// Create validates and stores st for ttl and returns the scene
+func (s *Scenes) Create(st State, ttl time.Duration, now time.Time) (Scene, error) {
+	if err := st.validate(); err != nil {
+		return Scene{}, err
+	}
+	st.Time = st.Time.UTC()
+	if st.Selected == nil {
+		st.Selected = []string{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.byID) >= s.max {
+		s.purge(now)
+		if len(s.byID) >= s.max {
+			return Scene{}, ErrFull
+		}
+	}
+	id := newID()
+	for s.byID[id] != nil {
+		id = newID()
+	}
+	now = now.UTC()
+	sc := &Scene{ID: id, State: st, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+	s.byID[id] = sc
+	if err := s.save(); err != nil {
+		delete(s.byID, id)
+		return Scene{}, err
+	}
+	return *sc, nil
+}
+
+// Get returns the live scene id
+func (s *Scenes) Get(id string, now time.Time) (Scene, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sc, ok := s.byID[id]
+	if !ok || !now.Before(sc.ExpiresAt) {
+		return Scene{}, ErrNotFound
+	}
+	return *sc, nil
+}
+
+// View counts an opening of scene id's share link and returns the scene
+func (s *Scenes) View(id string, now time.Time) (Scene, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sc, ok := s.byID[id]
+	if !ok || !now.Before(sc.ExpiresAt) {
+		return Scene{}, ErrNotFound
+	}
+	sc.Views++
+	s.dirty = true
+	return *sc, nil
+}
+
+// Flush removes expired scenes and writes view counts changed since the
+// last save. It is meant to run periodically and at shutdown.
+func (s *Scenes) Flush(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purge(now)
+	if !s.dirty {
+		return nil
+	}
+	return s.save()
+}
+
+// purge drops expired scenes. Callers hold s.mu.
+func (s *Scenes) purge(now time.Time) {
+	for id, sc := range s.byID {
+		if !now.Before(sc.ExpiresAt) {
+			delete(s.byID, id)
+			s.dirty = true
+		}
+	}
+}
+
+func (s *Scenes) save() error {
+	if s.path == "" {
+		s.dirty = false
+		return nil
+	}
+	list := make([]*Scene, 0, len(s.byID))
+	for _, sc := range s.byID {
+		list = append(list, sc)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	if err := jsonfile.Write(s.path, data, 0o644); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+func (st State) validate() error {
+	finite := func(vs ...float64) bool {
+		for _, v := range vs {
+			if math.IsNaN(v) || math.IsInf(v, 0) || math.Abs(v) > 1e12 {
+				return false
+			}
+		}
+		return true
+	}
+	c := st.Camera
+	switch {
+	case !finite(c.Position[:]...) || !finite(c.Target[:]...):
+		return fmt.Errorf("%w: camera coordinates must be finite numbers", ErrInvalid)
+	case c.Position == c.Target:
+		return fmt.Errorf("%w: camera position and target must differ", ErrInvalid)
+	case c.FOV < 0 || c.FOV >= 180:
+		return fmt.Errorf("%w: camera fov must be between 0 and 180 degrees", ErrInvalid)
+	case st.Time.IsZero() || st.Time.Year() < 1000 || st.Time.Year() > 3000:
+		return fmt.Errorf("%w: time must be set, between the years 1000 and 3000", ErrInvalid)
+	case math.IsNaN(st.Speed) || math.Abs(st.Speed) > MaxSpeed:
+		return fmt.Errorf("%w: speed must be between %g and %g", ErrInvalid, -MaxSpeed, MaxSpeed)
+	case len(st.Selected) > MaxSelected:
+		return fmt.Errorf("%w: at most %d selected bodies", ErrInvalid, MaxSelected)
+	}
+	for _, name := range st.Selected {
+		if name == "" || len(name) > 64 {
+			return fmt.Errorf("%w: selected body names must be 1 to 64 bytes", ErrInvalid)
+		}
+	}
+	return nil
+}
+
+func newID() string {
+	return random.String(idAlphabet, idLength)
+}
@@ -0,0 +1,106 @@
+package scenes
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testState() State {
+	return State{
+		Camera:   Camera{Position: [3]float64{0, 50, 120}, Target: [3]float64{0, 0, 0}, FOV: 45},
+		Time:     time.Date(2024, 4, 8, 18, 0, 0, 0, time.UTC),
+		Selected: []string{"Earth", "Moon"},
+		Speed:    3600,
+	}
+}
+
+func TestScenesPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenes.json")
+	s, err := Open(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	sc, err := s.Create(testState(), time.Hour, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.ID) != idLength {
+		t.Errorf("id %q, want %d characters", sc.ID, idLength)
+	}
+	s.View(sc.ID, now)
+	s.View(sc.ID, now)
+
+	// Views are only written by Flush
+	if reopened, _ := Open(path, 10); mustGet(t, reopened, sc.ID, now).Views != 0 {
+		t.Error("views were written before Flush")
+	}
+	if err := s.Flush(now); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := Open(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := mustGet(t, reopened, sc.ID, now)
+	if got.Views != 2 || got.State.Selected[1] != "Moon" || got.State.Camera != testState().Camera {
+		t.Errorf("reopened scene = %+v", got)
+	}
+
+	// Expired scenes are gone, and Flush drops them from the file
+	later := now.Add(time.Hour)
+	if _, err := reopened.Get(sc.ID, later); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expired scene: err = %v, want ErrNotFound", err)
+	}
+	if err := reopened.Flush(later); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := Open(path, 10); len(again.byID) != 0 {
+		t.Errorf("%d scenes in the file after Flush dropped the expired one", len(again.byID))
+	}
+}
+
+func TestScenesFull(t *testing.T) {
+	s, _ := Open("", 2)
+	now := time.Now()
+	s.Create(testState(), time.Minute, now)
+	s.Create(testState(), time.Hour, now)
+	if _, err := s.Create(testState(), time.Hour, now); !errors.Is(err, ErrFull) {
+		t.Errorf("third scene: err = %v, want ErrFull", err)
+	}
+	// Once one has expired there is room again
+	if _, err := s.Create(testState(), time.Hour, now.Add(time.Minute)); err != nil {
+		t.Errorf("after one expired: %v", err)
+	}
+}
+
+func TestScenesValidate(t *testing.T) {
+	tests := map[string]func(*State){
+		"NaN camera":       func(st *State) { st.Camera.Position[0] = math.NaN() },
+		"camera on target": func(st *State) { st.Camera.Target = st.Camera.Position },
+		"fov":              func(st *State) { st.Camera.FOV = 180 },
+		"no time":          func(st *State) { st.Time = time.Time{} },
+		"speed":            func(st *State) { st.Speed = -2 * MaxSpeed },
+		"empty body":       func(st *State) { st.Selected = []string{""} },
+	}
+	s, _ := Open("", 10)
+	for name, edit := range tests {
+		st := testState()
+		edit(&st)
+		if _, err := s.Create(st, time.Hour, time.Now()); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: err = %v, want ErrInvalid", name, err)
+		}
+	}
+}
+
+func mustGet(t *testing.T, s *Scenes, id string, now time.Time) Scene {
+	t.Helper()
+	sc, err := s.Get(id, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sc
+}
@@ -0,0 +1,45 @@
+// Package seeds holds curated bodies for populating the imports
+// repository of a new environment or an integration test run. Each
+// scenario is an embedded JSON file of bodies orbiting the Sun, on top of
+// the built-in planets.
+package seeds
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"solar-system-explorer/backend/models"
+)
+
+//go:embed *.json
+var files embed.FS
+
+// Scenarios returns the names of the available scenarios:
+//
+//   - minimal: Ceres and Halley's Comet, one of each kind of small body
+//   - full: the dwarf planets, the largest asteroids and two periodic comets
+func Scenarios() []string {
+	entries, _ := files.ReadDir(".")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load returns the bodies of scenario
+func Load(scenario string) ([]models.Planet, error) {
+	data, err := files.ReadFile(scenario + ".json")
+	if err != nil || strings.ContainsAny(scenario, "/.") {
+		return nil, fmt.Errorf("unknown seed scenario %q (have %s)", scenario, strings.Join(Scenarios(), ", "))
+	}
+	var bodies []models.Planet
+	if err := json.Unmarshal(data, &bodies); err != nil {
+		return nil, fmt.Errorf("seed scenario %s: %w", scenario, err)
+	}
+	return bodies, nil
+}
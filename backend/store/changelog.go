@@ -0,0 +1,144 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"solar-system-explorer/backend/audit"
+	"solar-system-explorer/backend/models"
+)
+
+// historySize bounds how many versions the changelog remembers
+const historySize = 200
+
+// Version is one dataset revision and what changed in it
+type Version struct {
+	Version int64        `json:"version"`
+	ETag    string       `json:"etag"`
+	Time    time.Time    `json:"time"`
+	Bodies  int          `json:"bodies"`
+	Changes []BodyChange `json:"changes,omitempty"`
+}
+
+// BodyChange describes how one body differs from the previous version
+type BodyChange struct {
+	Body   string                  `json:"body"`
+	Kind   string                  `json:"kind"` // added, removed, modified
+	Fields map[string]audit.Change `json:"fields,omitempty"`
+}
+
+// Version returns the current dataset version. Versions count up from 1
+// at startup; the ETag identifies content across restarts.
+func (s *Store) Version() Version {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v := s.history[len(s.history)-1]
+	v.Changes = nil
+	return v
+}
+
+// Changelog returns the versions newer than since, oldest first. Versions
+// that fell out of the bounded history are simply absent.
+func (s *Store) Changelog(since int64) []Version {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := []Version{}
+	for _, v := range s.history {
+		if v.Version > since {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Delta lists the bodies that differ between version since and snap, a
+// snapshot taken from this store: changed are in snap (added or
+// modified), removed are not. It reports false when the changes since
+// that version are no longer all in the history, or since wasn't a
+// version of this process at all (versions restart at startup), and with
+// etag set also when since had a different ETag. The caller then sends
+// everything. With since 0, the latest version that had etag is used.
+func (s *Store) Delta(since int64, etag string, snap *Snapshot) (changed []models.Planet, removed []string, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if since == 0 && etag != "" {
+		for i := len(s.history) - 1; i >= 0; i-- {
+			if v := s.history[i]; v.ETag == etag && v.Version <= snap.Version {
+				since = v.Version
+				break
+			}
+		}
+	}
+	if since < 1 || since > snap.Version || len(s.history) == 0 || s.history[0].Version > since+1 {
+		return nil, nil, false
+	}
+	touched := make(map[string]bool)
+	for _, v := range s.history {
+		switch {
+		case v.Version == since && etag != "" && v.ETag != etag:
+			return nil, nil, false
+		case v.Version > since && v.Version <= snap.Version:
+			for _, ch := range v.Changes {
+				touched[ch.Body] = true
+			}
+		}
+	}
+	changed, removed = []models.Planet{}, []string{}
+	for _, b := range snap.Bodies {
+		if touched[b.Name] {
+			changed = append(changed, b)
+			delete(touched, b.Name)
+		}
+	}
+	for name := range touched {
+		removed = append(removed, name)
+	}
+	sort.Strings(removed)
+	return changed, removed, true
+}
+
+// record appends a version for next, which replaces prev, and returns its
+// number. Callers hold s.mu.
+func (s *Store) record(prev []models.Planet, next *Snapshot) int64 {
+	v := Version{
+		Version: 1,
+		ETag:    next.ETag,
+		Time:    next.LoadedAt,
+		Bodies:  len(next.Bodies),
+		Changes: diffBodies(prev, next.Bodies),
+	}
+	if n := len(s.history); n > 0 {
+		v.Version = s.history[n-1].Version + 1
+	}
+	s.history = append(s.history, v)
+	if len(s.history) > historySize {
+		s.history = s.history[len(s.history)-historySize:]
+	}
+	return v.Version
+}
+
+// diffBodies compares two datasets body by body, matching on name
+func diffBodies(prev, next []models.Planet) []BodyChange {
+	old := make(map[string]models.Planet, len(prev))
+	for _, b := range prev {
+		old[b.Name] = b
+	}
+	changes := []BodyChange{}
+	for _, b := range next {
+		p, ok := old[b.Name]
+		if !ok {
+			changes = append(changes, BodyChange{Body: b.Name, Kind: "added"})
+			continue
+		}
+		delete(old, b.Name)
+		if fields, _ := audit.Diff(p, b); len(fields) > 0 {
+			changes = append(changes, BodyChange{Body: b.Name, Kind: "modified", Fields: fields})
+		}
+	}
+	for _, b := range prev {
+		if _, gone := old[b.Name]; gone {
+			changes = append(changes, BodyChange{Body: b.Name, Kind: "removed"})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+	"solar-system-explorer/backend/models"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// LoadDir reads every *.json file in dir, in name order. A file holds either
+// a single body object or an array of bodies. Duplicate names are an error
+// so a stray copy can't silently shadow the curated file.
+func LoadDir(dir string) ([]models.Planet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var bodies []models.Planet
+	seen := make(map[string]string)
+	for _, path := range paths {
+		batch, err := LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range batch {
+			if b.Name == "" {
+				return nil, fmt.Errorf("%s: body without a name", filepath.Base(path))
+			}
+			key := strings.ToLower(b.Name)
+			if prev, dup := seen[key]; dup {
+				return nil, fmt.Errorf("%s: %s already defined in %s", filepath.Base(path), b.Name, prev)
+			}
+			seen[key] = filepath.Base(path)
+			bodies = append(bodies, b)
+		}
+	}
+	if len(bodies) == 0 {
+		return nil, fmt.Errorf("no bodies found in %s", dir)
+	}
+	return bodies, nil
+}
+
+// LoadFile reads one JSON file holding a body object or an array of bodies
+func LoadFile(path string) ([]models.Planet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var batch []models.Planet
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(data, &batch)
+	} else {
+		var one models.Planet
+		err = json.Unmarshal(data, &one)
+		batch = []models.Planet{one}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return batch, nil
+}
+
+// SaveFile writes bodies to path as a JSON array, via a temp file and
+// rename so readers never see a partial file
+func SaveFile(path string, bodies []models.Planet) error {
+	return jsonfile.Save(path, bodies, 0o644)
+}
+
+// Watch reloads the dataset from dir whenever a JSON file in it changes,
+// until ctx is cancelled. Bursts of events (editors often write a temp file
+// and rename it) are debounced; a reload that fails to parse is logged and
+// the previous dataset stays in place.
+func (s *Store) Watch(ctx context.Context, dir string) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := w.Add(dir); err != nil {
+		w.Close()
+		return err
+	}
+
+	go func() {
+		defer w.Close()
+		const debounce = 250 * time.Millisecond
+		timer := time.NewTimer(debounce)
+		timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if strings.HasSuffix(ev.Name, ".json") {
+					timer.Reset(debounce)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("store: watch %s: %v", dir, err)
+			case <-timer.C:
+				bodies, err := LoadDir(dir)
+				if err != nil {
+					log.Printf("store: reload rejected, keeping previous dataset: %v", err)
+					continue
+				}
+				s.Replace(bodies)
+				log.Printf("store: reloaded %d bodies from %s (etag %s)", len(bodies), dir, s.ETag())
+			}
+		}
+	}()
+	return nil
+}
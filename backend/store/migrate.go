@@ -0,0 +1,255 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoMigration is returned by Rollback when nothing is applied
+var ErrNoMigration = errors.New("no migration to roll back")
+
+// Migration is one schema change: the embedded <version>.up.sql, with an
+// optional <version>.down.sql that undoes it
+type Migration struct {
+	Version   string     `json:"version"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	// Reversible migrations have a down script
+	Reversible bool `json:"reversible"`
+	// Unknown migrations are recorded in the database but not built into
+	// this server, typically applied by a newer release
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+// migrationLock is the pg_advisory_lock key a Migrator holds while it
+// reads or changes the schema, so replicas starting together, or --migrate
+// run during a rolling deploy, take turns instead of applying a script
+// twice
+const migrationLock int64 = 0x736f6c6172 // "solar"
+
+// Migrator applies and rolls back the embedded Postgres migrations,
+// recording the applied ones in schema_migrations. Each runs in its own
+// transaction, under a session-wide advisory lock shared by every
+// replica.
+type Migrator struct {
+	db *sql.DB
+}
+
+// NewMigrator returns a migrator for db
+func NewMigrator(db *sql.DB) *Migrator {
+	return &Migrator{db: db}
+}
+
+// Status lists the embedded migrations in order, followed by any applied
+// ones this build doesn't know
+func (m *Migrator) Status(ctx context.Context) ([]Migration, error) {
+	var out []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) (err error) {
+		out, err = status(ctx, conn)
+		return err
+	})
+	return out, err
+}
+
+func status(ctx context.Context, conn *sql.Conn) ([]Migration, error) {
+	applied, err := applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := migrationVersions()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(versions))
+	out := make([]Migration, 0, len(versions))
+	for _, v := range versions {
+		known[v] = true
+		mig := Migration{Version: v, Reversible: hasMigrationScript(v, "down")}
+		if at, ok := applied[v]; ok {
+			mig.Applied, mig.AppliedAt = true, &at
+		}
+		out = append(out, mig)
+	}
+	var unknown []string
+	for v := range applied {
+		if !known[v] {
+			unknown = append(unknown, v)
+		}
+	}
+	sort.Strings(unknown)
+	for _, v := range unknown {
+		at := applied[v]
+		out = append(out, Migration{Version: v, Applied: true, AppliedAt: &at, Unknown: true})
+	}
+	return out, nil
+}
+
+// Pending returns the versions not applied yet
+func (m *Migrator) Pending(ctx context.Context) ([]string, error) {
+	var out []string
+	err := m.locked(ctx, func(conn *sql.Conn) (err error) {
+		out, err = pending(ctx, conn)
+		return err
+	})
+	return out, err
+}
+
+func pending(ctx context.Context, conn *sql.Conn) ([]string, error) {
+	status, err := status(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	var pending []string
+	for _, mig := range status {
+		if !mig.Applied {
+			pending = append(pending, mig.Version)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies the pending migrations in version order and returns
+// the versions it applied. Another replica migrating at the same time
+// finishes first; what it applied is then no longer pending here.
+func (m *Migrator) Migrate(ctx context.Context) ([]string, error) {
+	var done []string
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		pending, err := pending(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, v := range pending {
+			err := run(ctx, conn, v, "up", `INSERT INTO schema_migrations (version) VALUES ($1)`)
+			if err != nil {
+				return err
+			}
+			done = append(done, v)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Rollback undoes the most recently applied migration with its down
+// script and returns its version
+func (m *Migrator) Rollback(ctx context.Context) (string, error) {
+	var version string
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		err := conn.QueryRowContext(ctx, `SELECT version FROM schema_migrations ORDER BY version DESC LIMIT 1`).Scan(&version)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoMigration
+		}
+		if err != nil {
+			return err
+		}
+		if !hasMigrationScript(version, "down") {
+			return fmt.Errorf("%s can't be rolled back: it has no down script in this build", version)
+		}
+		return run(ctx, conn, version, "down", `DELETE FROM schema_migrations WHERE version = $1`)
+	})
+	if err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// locked runs fn on a connection of its own holding migrationLock,
+// waiting for any other holder to finish. The lock belongs to the
+// session, so fn must use conn rather than the pool.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLock); err != nil {
+		return fmt.Errorf("waiting for the migration lock: %w", err)
+	}
+	err = fn(conn)
+	// Unlock even when ctx has ended; if that fails the session is
+	// closed rather than returned to the pool still holding the lock
+	if _, uerr := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLock); uerr != nil {
+		conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+	return err
+}
+
+// run executes version's script in the given direction and records it
+// with record, in one transaction
+func run(ctx context.Context, conn *sql.Conn, version, direction, record string) error {
+	script, err := postgresMigrations.ReadFile(migrationPath(version, direction))
+	if err != nil {
+		return err
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+		return fmt.Errorf("%s %s: %w", version, direction, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, version); err != nil {
+		return fmt.Errorf("%s %s: %w", version, direction, err)
+	}
+	return tx.Commit()
+}
+
+// applied returns the recorded migrations with when they ran, creating
+// the table on first use
+func applied(ctx context.Context, conn *sql.Conn) (map[string]time.Time, error) {
+	if _, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    text PRIMARY KEY,
+			applied_at timestamptz NOT NULL DEFAULT now()
+		)`); err != nil {
+		return nil, err
+	}
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			v  string
+			at time.Time
+		)
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+		out[v] = at
+	}
+	return out, rows.Err()
+}
+
+// migrationVersions returns the embedded versions in order
+func migrationVersions() ([]string, error) {
+	names, err := fs.Glob(postgresMigrations, migrationPath("*", "up"))
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]string, len(names))
+	for i, name := range names {
+		versions[i] = strings.TrimSuffix(name[strings.LastIndex(name, "/")+1:], ".up.sql")
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+func migrationPath(version, direction string) string {
+	return "migrations/postgres/" + version + "." + direction + ".sql"
+}
+
+func hasMigrationScript(version, direction string) bool {
+	_, err := fs.Stat(postgresMigrations, migrationPath(version, direction))
+	return err == nil
+}
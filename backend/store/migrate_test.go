@@ -0,0 +1,45 @@
+package store
+
+import (
+	"io/fs"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Versions sort in the order they're applied, and every script belongs
+// to one with an up script
+func TestEmbeddedMigrations(t *testing.T) {
+	versions, err := migrationVersions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	numbered := regexp.MustCompile(`^\d{4}_[a-z0-9_]+$`)
+	known := make(map[string]bool)
+	for i, v := range versions {
+		if !numbered.MatchString(v) {
+			t.Errorf("version %q isn't NNNN_name", v)
+		}
+		if i > 0 && v[:4] == versions[i-1][:4] {
+			t.Errorf("%s and %s share a number", versions[i-1], v)
+		}
+		known[v] = true
+	}
+	names, err := fs.Glob(postgresMigrations, migrationPath("*", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		base := name[strings.LastIndex(name, "/")+1:]
+		v, ok := strings.CutSuffix(base, ".down.sql")
+		if !ok {
+			v, ok = strings.CutSuffix(base, ".up.sql")
+		}
+		if !ok || !known[v] {
+			t.Errorf("%s isn't an up or down script of a known version", base)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+-- Bodies added through admin imports, keyed by lower-cased English name
+CREATE TABLE bodies (
+    key        text PRIMARY KEY,
+    data       jsonb NOT NULL,
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
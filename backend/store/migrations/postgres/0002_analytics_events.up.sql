@@ -0,0 +1,10 @@
+-- Anonymous usage events from POST /api/analytics/events; purged past the
+-- configured retention
+CREATE TABLE analytics_events (
+    id   bigserial PRIMARY KEY,
+    at   timestamptz NOT NULL,
+    type text NOT NULL,
+    data jsonb NOT NULL
+);
+
+CREATE INDEX analytics_events_at ON analytics_events (at);
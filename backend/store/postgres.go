@@ -0,0 +1,161 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/models"
+)
+
+// PostgresDriver is the database/sql driver name Postgres connects with,
+// registered by github.com/jackc/pgx/v5/stdlib in builds with the
+// postgres tag
+const PostgresDriver = "pgx"
+
+// ErrNoPostgres is returned by OpenPostgres from a build without the
+// Postgres driver
+var ErrNoPostgres = errors.New("this build has no Postgres driver; rebuild with -tags postgres")
+
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+// Pool bounds the connections a Postgres repository keeps
+type Pool struct {
+	MaxConns        int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// Postgres keeps imported bodies in PostgreSQL, so every replica of a
+// hosted deployment sees the same ones. Bodies are stored as JSON
+// documents; the statements are prepared once when it opens.
+type Postgres struct {
+	db       *sql.DB
+	migrator *Migrator
+	load     *sql.Stmt
+	upsert   *sql.Stmt
+}
+
+// ConnectPostgres opens a connection pool to dsn and checks the server
+// answers
+func ConnectPostgres(ctx context.Context, dsn string, pool Pool) (*sql.DB, error) {
+	if !slices.Contains(sql.Drivers(), PostgresDriver) {
+		return nil, ErrNoPostgres
+	}
+	db, err := sql.Open(PostgresDriver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(pool.MaxConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// OpenPostgres connects to dsn and prepares the statements. With migrate
+// it first applies pending migrations; without, pending ones are an
+// error, so a server never runs against a schema it doesn't expect.
+func OpenPostgres(ctx context.Context, dsn string, pool Pool, migrate bool) (*Postgres, error) {
+	db, err := ConnectPostgres(ctx, dsn, pool)
+	if err != nil {
+		return nil, err
+	}
+	p := &Postgres{db: db, migrator: NewMigrator(db)}
+	if err := p.open(ctx, migrate); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Postgres) open(ctx context.Context, migrate bool) error {
+	if migrate {
+		if _, err := p.migrator.Migrate(ctx); err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+	} else {
+		pending, err := p.migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d pending migrations (%s); run with --migrate", len(pending), strings.Join(pending, ", "))
+		}
+	}
+	var err error
+	if p.load, err = p.db.PrepareContext(ctx, `SELECT data FROM bodies ORDER BY key`); err != nil {
+		return err
+	}
+	p.upsert, err = p.db.PrepareContext(ctx, `
+		INSERT INTO bodies (key, data, updated_at) VALUES ($1, $2, now())
+		ON CONFLICT (key) DO UPDATE SET data = EXCLUDED.data, updated_at = now()`)
+	return err
+}
+
+// Load returns all stored bodies in name order
+func (p *Postgres) Load(ctx context.Context) ([]models.Planet, error) {
+	rows, err := p.load.QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var bodies []models.Planet
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var b models.Planet
+		if err := json.Unmarshal(data, &b); err != nil {
+			return nil, err
+		}
+		bodies = append(bodies, b)
+	}
+	return bodies, rows.Err()
+}
+
+// Save creates or replaces bodies in one transaction
+func (p *Postgres) Save(ctx context.Context, bodies []models.Planet) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	upsert := tx.StmtContext(ctx, p.upsert)
+	for _, b := range bodies {
+		data, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		if _, err := upsert.ExecContext(ctx, strings.ToLower(b.Name), data); err != nil {
+			return fmt.Errorf("%s: %w", b.Name, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// DB returns p's connection pool, for other tables of the same database
+func (p *Postgres) DB() *sql.DB { return p.db }
+
+// Migrator returns the migrator of p's database
+func (p *Postgres) Migrator() *Migrator { return p.migrator }
+
+// Close closes the statements and the connection pool
+func (p *Postgres) Close() error {
+	p.load.Close()
+	p.upsert.Close()
+	return p.db.Close()
+}
@@ -0,0 +1,8 @@
+//go:build postgres
+
+package store
+
+// The Postgres driver is linked in only with the postgres build tag, so
+// the default build stays free of it for deployments that keep imports in
+// IMPORTS_FILE. The Docker image is built with the tag.
+import _ "github.com/jackc/pgx/v5/stdlib"
@@ -0,0 +1,157 @@
+//go:build postgres
+
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/models"
+)
+
+// openTestPostgres opens a repository on POSTGRES_TEST_DSN, migrated up,
+// and rolls every migration back when the test ends. The database should
+// be a scratch one: the tables are dropped.
+func openTestPostgres(t *testing.T) *Postgres {
+	t.Helper()
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN is not set")
+	}
+	ctx := context.Background()
+	p, err := OpenPostgres(ctx, dsn, Pool{MaxConns: 4, MaxIdleConns: 2, ConnMaxLifetime: time.Minute}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		for {
+			if _, err := p.Migrator().Rollback(ctx); err != nil {
+				if !errors.Is(err, ErrNoMigration) {
+					t.Error(err)
+				}
+				break
+			}
+		}
+		p.Close()
+	})
+	return p
+}
+
+func TestPostgresSaveLoad(t *testing.T) {
+	p := openTestPostgres(t)
+	ctx := context.Background()
+
+	if err := p.Save(ctx, []models.Planet{{Name: "Vesta", Mass: 2.59e20}, {Name: "Ceres", Mass: 9.38e20}}); err != nil {
+		t.Fatal(err)
+	}
+	// Saving a body again replaces it rather than adding a row
+	if err := p.Save(ctx, []models.Planet{{Name: "VESTA", Mass: 2.6e20}}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Name != "Ceres" || got[1].Name != "VESTA" || got[1].Mass != 2.6e20 {
+		t.Errorf("Load = %+v, want Ceres and the updated Vesta", got)
+	}
+}
+
+func TestPostgresRefusesPendingMigrations(t *testing.T) {
+	p := openTestPostgres(t)
+	ctx := context.Background()
+	if _, err := p.Migrator().Rollback(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenPostgres(ctx, os.Getenv("POSTGRES_TEST_DSN"), Pool{MaxConns: 1}, false); err == nil {
+		t.Error("OpenPostgres with a pending migration succeeded without migrate")
+	}
+}
+
+// Replicas starting together each try to migrate; the lock has them take
+// turns, so every script runs once and none of them fails
+func TestMigrateConcurrently(t *testing.T) {
+	p := openTestPostgres(t)
+	ctx := context.Background()
+	for {
+		if _, err := p.Migrator().Rollback(ctx); errors.Is(err, ErrNoMigration) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const replicas = 4
+	applied := make([][]string, replicas)
+	errs := make([]error, replicas)
+	var wg sync.WaitGroup
+	for i := range replicas {
+		db, err := ConnectPostgres(ctx, os.Getenv("POSTGRES_TEST_DSN"), Pool{MaxConns: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			applied[i], errs[i] = NewMigrator(db).Migrate(ctx)
+		}()
+	}
+	wg.Wait()
+
+	versions, _ := migrationVersions()
+	runs := make(map[string]int)
+	for i := range replicas {
+		if errs[i] != nil {
+			t.Errorf("replica %d: %v", i, errs[i])
+		}
+		for _, v := range applied[i] {
+			runs[v]++
+		}
+	}
+	for _, v := range versions {
+		if runs[v] != 1 {
+			t.Errorf("%s applied %d times, want once", v, runs[v])
+		}
+	}
+}
+
+func TestMigrationStatus(t *testing.T) {
+	p := openTestPostgres(t)
+	ctx := context.Background()
+	m := p.Migrator()
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions, _ := migrationVersions()
+	if len(status) != len(versions) {
+		t.Fatalf("%d migrations listed, want %d", len(status), len(versions))
+	}
+	for i, mig := range status {
+		if mig.Version != versions[i] || !mig.Applied || mig.AppliedAt == nil || !mig.Reversible || mig.Unknown {
+			t.Errorf("status[%d] = %+v", i, mig)
+		}
+	}
+
+	// A newer release's migration is listed but can't be undone here
+	if _, err := p.db.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ('9999_future')`); err != nil {
+		t.Fatal(err)
+	}
+	defer p.db.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = '9999_future'`)
+	status, err = m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := status[len(status)-1]; last.Version != "9999_future" || !last.Unknown || !last.Applied {
+		t.Errorf("last = %+v, want the unknown version", last)
+	}
+	if _, err := m.Rollback(ctx); err == nil {
+		t.Error("rolled back a migration with no down script")
+	}
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"solar-system-explorer/backend/models"
+)
+
+// BodyRepository keeps the bodies added through admin imports, which sit
+// on top of the base dataset (see Store.Upsert)
+type BodyRepository interface {
+	// Load returns all stored bodies
+	Load(ctx context.Context) ([]models.Planet, error)
+	// Save creates or replaces bodies, all or none of them
+	Save(ctx context.Context, bodies []models.Planet) error
+	Close() error
+}
+
+// FileRepository keeps imported bodies in one JSON file, rewritten on
+// every save
+type FileRepository struct {
+	path string
+}
+
+// NewFileRepository returns a repository for the file at path, which may
+// not exist yet
+func NewFileRepository(path string) *FileRepository {
+	return &FileRepository{path: path}
+}
+
+// Load reads the file; a missing file holds no bodies
+func (r *FileRepository) Load(context.Context) ([]models.Planet, error) {
+	bodies, err := LoadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return bodies, err
+}
+
+// Save merges bodies into the file's contents and rewrites it
+func (r *FileRepository) Save(ctx context.Context, bodies []models.Planet) error {
+	stored, err := r.Load(ctx)
+	if err != nil {
+		return err
+	}
+	return SaveFile(r.path, upsertInto(stored, bodies))
+}
+
+// Close does nothing; the file is only open while reading or writing
+func (r *FileRepository) Close() error { return nil }
@@ -0,0 +1,270 @@
+// Package store holds the in-memory dataset served by the API. The dataset
+// is either the built-in NASA/J2000 bodies or JSON files loaded from a data
+// directory, and can be swapped atomically while requests are in flight:
+// every change publishes a new immutable Snapshot, and readers never wait
+// for writers.
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"solar-system-explorer/backend/models"
+)
+
+// Store is the current dataset plus an ETag derived from its content
+type Store struct {
+	// current is read without locking; mu serializes the writers that
+	// build the next one
+	current atomic.Pointer[Snapshot]
+
+	mu          sync.RWMutex
+	base        []models.Planet
+	upserts     []models.Planet
+	overlay     map[string]map[string]models.Translation
+	supplements map[string]map[string]models.Sourced
+
+	history   []Version
+	listeners []func()
+}
+
+// Snapshot is one version of the dataset. It is never modified once
+// published, so a handler that takes one snapshot per request serves
+// bodies, ETag and version that belong together even while an admin edit
+// or a reload installs the next. The bodies, and the maps inside them,
+// must be treated as read-only.
+type Snapshot struct {
+	Bodies   []models.Planet
+	ETag     string
+	Version  int64
+	LoadedAt time.Time
+}
+
+// New creates a store holding bodies
+func New(bodies []models.Planet) *Store {
+	s := &Store{}
+	s.Replace(bodies)
+	return s
+}
+
+// Snapshot returns the current version of the dataset
+func (s *Store) Snapshot() *Snapshot {
+	return s.current.Load()
+}
+
+// Bodies returns the current dataset. The slice is shared between requests
+// and must be treated as read-only. Use Snapshot to read it together with
+// its ETag.
+func (s *Store) Bodies() []models.Planet {
+	return s.Snapshot().Bodies
+}
+
+// ETag returns a strong validator for the current dataset
+func (s *Store) ETag() string {
+	return s.Snapshot().ETag
+}
+
+// LoadedAt returns when the current dataset was installed
+func (s *Store) LoadedAt() time.Time {
+	return s.Snapshot().LoadedAt
+}
+
+// Replace atomically swaps in a new dataset, recomputes the ETag and
+// notifies OnChange listeners. The store keeps its own copy of bodies.
+func (s *Store) Replace(bodies []models.Planet) {
+	bodies = slices.Clone(bodies)
+	s.update(func() { s.base = bodies })
+}
+
+// SetOverlay installs translations (body name → locale → text) on top of
+// the dataset's own, e.g. the published ones from the admin API. It
+// survives Replace and notifies OnChange listeners like a reload.
+func (s *Store) SetOverlay(overlay map[string]map[string]models.Translation) {
+	s.update(func() { s.overlay = overlay })
+}
+
+// SetSupplements installs attributed facts from external sources (body
+// name → key → value), replacing the previous set. Like the overlay they
+// survive Replace.
+func (s *Store) SetSupplements(supplements map[string]map[string]models.Sourced) {
+	s.update(func() { s.supplements = supplements })
+}
+
+// Upsert adds bodies to the dataset, replacing any existing body of the
+// same name. Upserted bodies sit on top of the base dataset, so they
+// survive a reload of the data directory.
+func (s *Store) Upsert(bodies ...models.Planet) {
+	s.update(func() { s.upserts = upsertInto(s.upserts, bodies) })
+}
+
+// upsertInto replaces the bodies in list with those of the same name in
+// bodies and appends the rest
+func upsertInto(list, bodies []models.Planet) []models.Planet {
+	for _, b := range bodies {
+		replaced := false
+		for i, u := range list {
+			if strings.EqualFold(u.Name, b.Name) {
+				list[i], replaced = b, true
+				break
+			}
+		}
+		if !replaced {
+			list = append(list, b)
+		}
+	}
+	return list
+}
+
+// Upserted returns the bodies added with Upsert, e.g. to persist them
+func (s *Store) Upserted() []models.Planet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.Planet(nil), s.upserts...)
+}
+
+// Sources returns the dataset as loaded and upserted, without the
+// translation overlay and supplements, which have their own stores
+func (s *Store) Sources() []models.Planet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.merged()
+}
+
+// update applies fn under the lock, rebuilds the dataset and, if its
+// content changed, notifies OnChange listeners
+func (s *Store) update(fn func()) {
+	s.mu.Lock()
+	fn()
+	changed := s.rebuild()
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	if changed {
+		for _, fn := range listeners {
+			fn()
+		}
+	}
+}
+
+// rebuild layers upserts, the translation overlay and supplements onto
+// base and publishes a new snapshot if the result differs, reporting
+// whether it did. Callers hold s.mu.
+func (s *Store) rebuild() bool {
+	prev := s.current.Load()
+	bodies := s.merged()
+	if len(s.overlay) > 0 || len(s.supplements) > 0 {
+		src := bodies
+		bodies = make([]models.Planet, len(src))
+		for i, b := range src {
+			if extra := s.overlay[b.Name]; len(extra) > 0 {
+				b.Translations = mergeTranslations(b.Translations, extra)
+			}
+			if extra := s.supplements[b.Name]; len(extra) > 0 {
+				merged := make(map[string]models.Sourced, len(b.Supplementary)+len(extra))
+				for k, v := range b.Supplementary {
+					merged[k] = v
+				}
+				for k, v := range extra {
+					merged[k] = v
+				}
+				b.Supplementary = merged
+			}
+			bodies[i] = b
+		}
+	}
+	next := &Snapshot{Bodies: bodies, ETag: computeETag(bodies), LoadedAt: time.Now().UTC()}
+	if prev != nil && next.ETag == prev.ETag {
+		return false
+	}
+	var before []models.Planet
+	if prev != nil {
+		before = prev.Bodies
+	}
+	next.Version = s.record(before, next)
+	s.current.Store(next)
+	return true
+}
+
+// merged returns base with the upserts applied, as a new slice whenever
+// it differs from base. Callers hold s.mu.
+func (s *Store) merged() []models.Planet {
+	if len(s.upserts) == 0 {
+		return s.base
+	}
+	bodies := append([]models.Planet(nil), s.base...)
+next:
+	for _, u := range s.upserts {
+		for i := range bodies {
+			if strings.EqualFold(bodies[i].Name, u.Name) {
+				bodies[i] = u
+				continue next
+			}
+		}
+		bodies = append(bodies, u)
+	}
+	return bodies
+}
+
+// mergeTranslations copies base and overlays extra field by field
+func mergeTranslations(base, extra map[string]models.Translation) map[string]models.Translation {
+	merged := make(map[string]models.Translation, len(base)+len(extra))
+	for locale, t := range base {
+		merged[locale] = t
+	}
+	for locale, t := range extra {
+		cur := merged[locale]
+		if t.Name != "" {
+			cur.Name = t.Name
+		}
+		if t.Description != "" {
+			cur.Description = t.Description
+		}
+		if t.IPA != "" {
+			cur.IPA = t.IPA
+		}
+		if t.Respelling != "" {
+			cur.Respelling = t.Respelling
+		}
+		if t.Appearance != "" {
+			cur.Appearance = t.Appearance
+		}
+		if t.Orbit != "" {
+			cur.Orbit = t.Orbit
+		}
+		if t.Kids != nil {
+			kids := models.KidsText{}
+			if cur.Kids != nil {
+				kids = *cur.Kids
+			}
+			if t.Kids.Description != "" {
+				kids.Description = t.Kids.Description
+			}
+			if len(t.Kids.Facts) > 0 {
+				kids.Facts = t.Kids.Facts
+			}
+			cur.Kids = &kids
+		}
+		merged[locale] = cur
+	}
+	return merged
+}
+
+// OnChange registers fn to run whenever the dataset's content changes,
+// e.g. to drop caches derived from the previous dataset
+func (s *Store) OnChange(fn func()) {
+	s.mu.Lock()
+	s.listeners = append(s.listeners, fn)
+	s.mu.Unlock()
+}
+
+func computeETag(bodies []models.Planet) string {
+	data, _ := json.Marshal(bodies)
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
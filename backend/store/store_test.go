@@ -0,0 +1,85 @@
+package store
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"solar-system-explorer/backend/models"
+)
+
+func TestSnapshotsDontChange(t *testing.T) {
+	bodies := models.GetSolarSystemBodies()
+	s := New(bodies)
+	var changes atomic.Int32
+	s.OnChange(func() { changes.Add(1) })
+
+	before := s.Snapshot()
+	mass := before.Bodies[3].Mass
+	bodies[3].Mass = 0 // the store keeps its own copy
+	mars := before.Bodies[4]
+	mars.Mass *= 2
+	s.Upsert(mars)
+	s.SetOverlay(map[string]map[string]models.Translation{"Mars": {"de": {Name: "Mars (de)"}}})
+
+	after := s.Snapshot()
+	if before.Bodies[3].Mass != mass || before.Bodies[4].Mass == mars.Mass || len(before.Bodies[4].Translations["de"].Name) != 0 {
+		t.Error("a published snapshot changed")
+	}
+	if before.ETag != computeETag(before.Bodies) {
+		t.Error("the old snapshot's ETag no longer matches its bodies")
+	}
+	if after.Version <= before.Version || after.ETag == before.ETag || after.Bodies[4].Mass != mars.Mass {
+		t.Errorf("new snapshot = version %d, mass %g; old = version %d", after.Version, after.Bodies[4].Mass, before.Version)
+	}
+	if n := changes.Load(); n != 2 {
+		t.Errorf("listeners heard %d changes, want 2", n)
+	}
+
+	// Installing the same content again publishes nothing
+	s.Upsert(mars)
+	if s.Snapshot() != after || changes.Load() != 2 {
+		t.Error("an unchanged dataset was published as a new version")
+	}
+}
+
+// Run with -race: readers never wait for writers nor see half an update
+func TestSnapshotsUnderConcurrentWrites(t *testing.T) {
+	s := New(models.GetSolarSystemBodies())
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var last int64
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				snap := s.Snapshot()
+				if snap.Version < last {
+					t.Errorf("version went back from %d to %d", last, snap.Version)
+					return
+				}
+				last = snap.Version
+				if snap.ETag != computeETag(snap.Bodies) {
+					t.Error("a snapshot's ETag doesn't match its bodies")
+					return
+				}
+			}
+		}()
+	}
+	mars := models.GetSolarSystemBodies()[4]
+	for i := 0; i < 50; i++ {
+		mars.Mass++
+		s.Upsert(mars)
+	}
+	close(stop)
+	wg.Wait()
+	if got := s.Snapshot().Bodies[4].Mass; got != mars.Mass {
+		t.Errorf("final mass %g, want %g", got, mars.Mass)
+	}
+}
@@ -0,0 +1,195 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+	"solar-system-explorer/backend/models"
+)
+
+// Limits on a tour
+const (
+	MaxTourSteps    = 100
+	MaxStepDuration = 600 // seconds
+)
+
+// ErrInvalidTour wraps validation failures from Put. The message after
+// it starts with the JSON path of the field at fault.
+var ErrInvalidTour = errors.New("invalid tour")
+
+var tourID = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Tours holds the guided tours managed through the admin API. A new file
+// starts with the built-in Grand Tour; with a path set every change is
+// persisted to that JSON file.
+type Tours struct {
+	mu    sync.RWMutex
+	path  string
+	tours map[string]models.Tour
+}
+
+// OpenTours loads the tours file at path, which may not exist yet. An
+// empty path keeps tours in memory only.
+func OpenTours(path string) (*Tours, error) {
+	grand := models.GetGrandTour()
+	t := &Tours{path: path, tours: map[string]models.Tour{grand.ID: grand}}
+	if path == "" {
+		return t, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []models.Tour
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	t.tours = make(map[string]models.Tour, len(list))
+	for _, tour := range list {
+		t.tours[tour.ID] = tour
+	}
+	return t, nil
+}
+
+// List returns all tours sorted by ID
+func (t *Tours) List() []models.Tour {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.sorted()
+}
+
+// sorted returns the tours by ID. Callers hold t.mu.
+func (t *Tours) sorted() []models.Tour {
+	out := make([]models.Tour, 0, len(t.tours))
+	for _, tour := range t.tours {
+		out = append(out, tour)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Get returns the tour with id
+func (t *Tours) Get(id string) (models.Tour, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	tour, ok := t.tours[id]
+	return tour, ok
+}
+
+// Put validates and creates or replaces a tour, returning the stored tour
+// and the one it replaced (nil on create). Step bodies are expected to be
+// resolved to English names by the caller.
+func (t *Tours) Put(tour models.Tour) (models.Tour, *models.Tour, error) {
+	if err := ValidateTour(tour); err != nil {
+		return tour, nil, err
+	}
+	tour.UpdatedAt = time.Now().UTC()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prev, had := t.tours[tour.ID]
+	t.tours[tour.ID] = tour
+	if err := t.save(); err != nil {
+		if had {
+			t.tours[tour.ID] = prev
+		} else {
+			delete(t.tours, tour.ID)
+		}
+		return tour, nil, err
+	}
+	if !had {
+		return tour, nil, nil
+	}
+	return tour, &prev, nil
+}
+
+// Replace validates tours and installs them in place of all current ones,
+// e.g. when importing an archive or undoing that. Tours keep their
+// UpdatedAt unless it is zero.
+func (t *Tours) Replace(tours []models.Tour) error {
+	next := make(map[string]models.Tour, len(tours))
+	for _, tour := range tours {
+		if err := ValidateTour(tour); err != nil {
+			return fmt.Errorf("tour %q: %w", tour.ID, err)
+		}
+		if tour.UpdatedAt.IsZero() {
+			tour.UpdatedAt = time.Now().UTC()
+		}
+		next[tour.ID] = tour
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prev := t.tours
+	t.tours = next
+	if err := t.save(); err != nil {
+		t.tours = prev
+		return err
+	}
+	return nil
+}
+
+// Delete removes a tour, returning it, or nil if there was none
+func (t *Tours) Delete(id string) (*models.Tour, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prev, had := t.tours[id]
+	if !had {
+		return nil, nil
+	}
+	delete(t.tours, id)
+	if err := t.save(); err != nil {
+		t.tours[id] = prev
+		return nil, err
+	}
+	return &prev, nil
+}
+
+// save writes all tours via a temp file and rename. Callers hold t.mu.
+func (t *Tours) save() error {
+	if t.path == "" {
+		return nil
+	}
+	return jsonfile.Save(t.path, t.sorted(), 0o644)
+}
+
+// ValidateTour checks a tour the way Put does, for callers validating a
+// batch before storing any of it
+func ValidateTour(tour models.Tour) error {
+	switch {
+	case len(tour.ID) > 64 || !tourID.MatchString(tour.ID):
+		return fmt.Errorf("%w: id must be a lowercase slug of at most 64 characters", ErrInvalidTour)
+	case tour.Title["sr"] == "":
+		return fmt.Errorf("%w: title needs Serbian (sr) text", ErrInvalidTour)
+	case len(tour.Steps) == 0 || len(tour.Steps) > MaxTourSteps:
+		return fmt.Errorf("%w: steps must number 1 to %d", ErrInvalidTour, MaxTourSteps)
+	}
+	for i, s := range tour.Steps {
+		switch {
+		case s.Body == "":
+			return fmt.Errorf("%w: steps[%d].body is required", ErrInvalidTour, i)
+		case s.Narration["sr"] == "":
+			return fmt.Errorf("%w: steps[%d].narration needs Serbian (sr) text", ErrInvalidTour, i)
+		case !(s.Duration > 0 && s.Duration <= MaxStepDuration):
+			return fmt.Errorf("%w: steps[%d].duration must be between 0 and %d seconds", ErrInvalidTour, i, MaxStepDuration)
+		}
+		if c := s.Camera; c != nil {
+			if !(c.Distance > 1 && c.Distance <= 1e6) || math.Abs(c.Elevation) > 90 || math.IsNaN(c.Azimuth) || math.Abs(c.Azimuth) > 360 {
+				return fmt.Errorf("%w: steps[%d].camera must lie beyond 1 body radius, with elevation within ±90° and azimuth within ±360°", ErrInvalidTour, i)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+	"solar-system-explorer/backend/models"
+)
+
+// Translation states. Drafts are visible only through the admin API;
+// published text is merged into the dataset.
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+)
+
+// ErrInvalidTranslation wraps validation failures from Put
+var ErrInvalidTranslation = errors.New("invalid translation")
+
+// TranslatableFields are the body fields translators may submit
+var TranslatableFields = []string{"name", "description", "ipa", "respelling", "appearance", "orbit"}
+
+// KidsFields are the fields of the kids audience. Facts are one per line.
+var KidsFields = []string{"description", "facts"}
+
+// TranslationEntry is one translated field of one body, for the standard
+// audience unless Audience says otherwise
+type TranslationEntry struct {
+	Body      string    `json:"body"`
+	Locale    string    `json:"locale"`
+	Audience  string    `json:"audience,omitempty"` // empty for models.AudienceStandard
+	Field     string    `json:"field"`
+	Text      string    `json:"text"`
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// forAudience reports whether e is written for audience; empty matches all
+func (e TranslationEntry) forAudience(audience string) bool {
+	switch audience {
+	case "":
+		return true
+	case models.AudienceStandard:
+		return e.Audience == ""
+	}
+	return e.Audience == audience
+}
+
+func (e TranslationEntry) key() string {
+	if e.Audience != "" {
+		return e.Body + "|" + e.Locale + "|" + e.Audience + "|" + e.Field
+	}
+	return e.Body + "|" + e.Locale + "|" + e.Field
+}
+
+// Translations holds translations submitted through the admin API. They
+// live outside the data directory so translators don't need repo access;
+// with a path set every change is persisted to that JSON file.
+type Translations struct {
+	mu      sync.RWMutex
+	path    string
+	entries map[string]TranslationEntry
+
+	listeners []func()
+}
+
+// OpenTranslations loads the translations file at path, which may not
+// exist yet. An empty path keeps translations in memory only.
+func OpenTranslations(path string) (*Translations, error) {
+	t := &Translations{path: path, entries: make(map[string]TranslationEntry)}
+	if path == "" {
+		return t, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []TranslationEntry
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	for _, e := range list {
+		t.entries[e.key()] = e
+	}
+	return t, nil
+}
+
+// List returns entries filtered by locale and status (empty matches all),
+// sorted by body, locale, audience and field
+func (t *Translations) List(locale, status string) []TranslationEntry {
+	return t.ListAudience(locale, status, "")
+}
+
+// ListAudience is List for one audience: models.AudienceStandard or
+// models.AudienceKids, or empty for all
+func (t *Translations) ListAudience(locale, status, audience string) []TranslationEntry {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make([]TranslationEntry, 0, len(t.entries))
+	for _, e := range t.entries {
+		if (locale == "" || e.Locale == locale) && (status == "" || e.Status == status) && e.forAudience(audience) {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].key() < out[j].key() })
+	return out
+}
+
+// Put creates or replaces the entry for e's body, locale and field and
+// persists the result, returning the stored entry and the one it replaced
+// (nil on create). Changing published text notifies OnChange listeners.
+func (t *Translations) Put(e TranslationEntry) (TranslationEntry, *TranslationEntry, error) {
+	if err := ValidateTranslation(e); err != nil {
+		return e, nil, err
+	}
+	e.UpdatedAt = time.Now().UTC()
+
+	t.mu.Lock()
+	prev, had := t.entries[e.key()]
+	t.entries[e.key()] = e
+	err := t.save()
+	if err != nil {
+		if had {
+			t.entries[e.key()] = prev
+		} else {
+			delete(t.entries, e.key())
+		}
+	}
+	listeners := t.listeners
+	t.mu.Unlock()
+
+	if err != nil {
+		return e, nil, err
+	}
+	if e.Status == StatusPublished || prev.Status == StatusPublished {
+		for _, fn := range listeners {
+			fn()
+		}
+	}
+	if !had {
+		return e, nil, nil
+	}
+	return e, &prev, nil
+}
+
+// Delete removes an entry, returning it, or nil if there was none.
+// audience is empty for the standard text.
+func (t *Translations) Delete(body, locale, audience, field string) (*TranslationEntry, error) {
+	key := TranslationEntry{Body: body, Locale: locale, Audience: audience, Field: field}.key()
+
+	t.mu.Lock()
+	prev, had := t.entries[key]
+	if !had {
+		t.mu.Unlock()
+		return nil, nil
+	}
+	delete(t.entries, key)
+	err := t.save()
+	if err != nil {
+		t.entries[key] = prev
+	}
+	listeners := t.listeners
+	t.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	if prev.Status == StatusPublished {
+		for _, fn := range listeners {
+			fn()
+		}
+	}
+	return &prev, nil
+}
+
+// Replace validates entries and installs them in place of all current
+// ones, e.g. when importing an archive or undoing that. Entries keep their
+// UpdatedAt unless it is zero.
+func (t *Translations) Replace(entries []TranslationEntry) error {
+	next := make(map[string]TranslationEntry, len(entries))
+	for _, e := range entries {
+		if err := ValidateTranslation(e); err != nil {
+			return err
+		}
+		if e.UpdatedAt.IsZero() {
+			e.UpdatedAt = time.Now().UTC()
+		}
+		next[e.key()] = e
+	}
+
+	t.mu.Lock()
+	prev := t.entries
+	t.entries = next
+	err := t.save()
+	if err != nil {
+		t.entries = prev
+	}
+	listeners := t.listeners
+	t.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	for _, fn := range listeners {
+		fn()
+	}
+	return nil
+}
+
+// Published returns the published text as body name → locale → text
+func (t *Translations) Published() map[string]map[string]models.Translation {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make(map[string]map[string]models.Translation)
+	for _, e := range t.entries {
+		if e.Status != StatusPublished {
+			continue
+		}
+		if out[e.Body] == nil {
+			out[e.Body] = make(map[string]models.Translation)
+		}
+		tr := out[e.Body][e.Locale]
+		if e.Audience == models.AudienceKids {
+			kids := models.KidsText{}
+			if tr.Kids != nil {
+				kids = *tr.Kids
+			}
+			switch e.Field {
+			case "description":
+				kids.Description = e.Text
+			case "facts":
+				kids.Facts = splitFacts(e.Text)
+			}
+			tr.Kids = &kids
+			out[e.Body][e.Locale] = tr
+			continue
+		}
+		switch e.Field {
+		case "name":
+			tr.Name = e.Text
+		case "description":
+			tr.Description = e.Text
+		case "ipa":
+			tr.IPA = e.Text
+		case "respelling":
+			tr.Respelling = e.Text
+		case "appearance":
+			tr.Appearance = e.Text
+		case "orbit":
+			tr.Orbit = e.Text
+		}
+		out[e.Body][e.Locale] = tr
+	}
+	return out
+}
+
+// OnChange registers fn to run whenever the published text changes
+func (t *Translations) OnChange(fn func()) {
+	t.mu.Lock()
+	t.listeners = append(t.listeners, fn)
+	t.mu.Unlock()
+}
+
+// save writes all entries to the file via a temp file and rename, so a
+// crash mid-write never leaves a truncated file. Callers hold t.mu.
+func (t *Translations) save() error {
+	if t.path == "" {
+		return nil
+	}
+	list := make([]TranslationEntry, 0, len(t.entries))
+	for _, e := range t.entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].key() < list[j].key() })
+	return jsonfile.Save(t.path, list, 0o644)
+}
+
+// splitFacts reads one fact per non-blank line
+func splitFacts(text string) []string {
+	var facts []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			facts = append(facts, line)
+		}
+	}
+	return facts
+}
+
+// ValidateTranslation checks an entry the way Put does
+func ValidateTranslation(e TranslationEntry) error {
+	switch e.Audience {
+	case "":
+		if !slices.Contains(TranslatableFields, e.Field) {
+			return fmt.Errorf("%w: unknown field %q", ErrInvalidTranslation, e.Field)
+		}
+	case models.AudienceKids:
+		if !slices.Contains(KidsFields, e.Field) {
+			return fmt.Errorf("%w: unknown field %q for the %s audience", ErrInvalidTranslation, e.Field, e.Audience)
+		}
+	default:
+		return fmt.Errorf("%w: audience must be empty or %q", ErrInvalidTranslation, models.AudienceKids)
+	}
+	if e.Status != StatusDraft && e.Status != StatusPublished {
+		return fmt.Errorf("%w: status must be %q or %q", ErrInvalidTranslation, StatusDraft, StatusPublished)
+	}
+	return nil
+}
@@ -0,0 +1,383 @@
+// Package swpc is a client for the NOAA Space Weather Prediction Center's
+// public JSON feeds (https://services.swpc.noaa.gov). It boils the Kp
+// index, its 3-day forecast, the real-time solar wind and the OVATION
+// aurora forecast down to a small report for an "aurora tonight?" banner.
+package swpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/clock"
+	"solar-system-explorer/backend/upstream"
+)
+
+// DefaultBaseURL is SWPC's services host
+const DefaultBaseURL = "https://services.swpc.noaa.gov"
+
+// Feeds, relative to the base URL
+const (
+	kpPath         = "/products/noaa-planetary-k-index.json"
+	kpForecastPath = "/products/noaa-planetary-k-index-forecast.json"
+	plasmaPath     = "/products/solar-wind/plasma-1-day.json"
+	magPath        = "/products/solar-wind/mag-1-day.json"
+	auroraPath     = "/json/ovation_aurora_latest.json"
+)
+
+// Report is the space weather as of FetchedAt. A section whose feed
+// failed is nil and its error listed in Errors.
+type Report struct {
+	Kp        *Kp        `json:"kp"`
+	SolarWind *SolarWind `json:"solar_wind"`
+	Aurora    *Aurora    `json:"aurora"`
+	FetchedAt time.Time  `json:"fetched_at"`
+	// Stale is set on a report older than the cache TTL served while SWPC
+	// can't be reached
+	Stale  bool     `json:"stale"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Kp is the planetary K index, 0 (quiet) to 9 (extreme storm), over
+// three-hour periods
+type Kp struct {
+	Time     time.Time `json:"time"` // start of the latest observed period
+	Value    float64   `json:"value"`
+	Scale    string    `json:"scale"` // NOAA geomagnetic storm scale, G0 to G5
+	Max24h   float64   `json:"max_24h"`
+	Forecast []KpPoint `json:"forecast"` // predicted periods to come
+}
+
+// KpPoint is a forecast Kp for the three hours from Time
+type KpPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+	Scale string    `json:"scale"`
+}
+
+// SolarWind is the latest real-time solar wind at L1
+type SolarWind struct {
+	Time    time.Time `json:"time"`
+	Speed   *float64  `json:"speed"`   // km/s
+	Density *float64  `json:"density"` // protons/cm³
+	// Bz is the north–south interplanetary magnetic field, nT; strongly
+	// negative (southward) Bz is what lets the solar wind drive aurora
+	Bz *float64 `json:"bz"`
+	Bt *float64 `json:"bt"` // total field, nT
+}
+
+// Aurora is the OVATION model's short-term aurora forecast
+type Aurora struct {
+	ObservationTime time.Time `json:"observation_time"`
+	ForecastTime    time.Time `json:"forecast_time"`
+	// The highest probability of visible aurora, percent, in each
+	// hemisphere
+	MaxNorth int `json:"max_north"`
+	MaxSouth int `json:"max_south"`
+
+	grid [360][181]uint8 // longitude 0–359, latitude -90–90
+}
+
+// At returns the probability of visible aurora overhead at a place,
+// percent, from the nearest cell of the model's one-degree grid
+func (a *Aurora) At(lat, lon float64) int {
+	i := int(math.Round(math.Mod(lon+360, 360))) % 360
+	j := int(math.Round(lat)) + 90
+	return int(a.grid[i][max(0, min(180, j))])
+}
+
+// Scale returns the NOAA G scale for a Kp value: G1 from Kp 5 (5- counts)
+// to G5 at Kp 9
+func Scale(kp float64) string {
+	return "G" + strconv.Itoa(max(0, min(5, int(math.Round(kp))-4)))
+}
+
+// Client fetches the feeds and caches the report for CacheTTL. While SWPC
+// is unreachable the last report is served, marked Stale.
+type Client struct {
+	BaseURL  string
+	HTTP     *http.Client
+	CacheTTL time.Duration
+	Clock    clock.Clock
+
+	refresh sync.Mutex // one fetch at a time
+	mu      sync.Mutex
+	last    *Report
+}
+
+// NewClient returns a traced client for baseURL (DefaultBaseURL if empty)
+// that retries and breaks its circuit as policy says
+func NewClient(baseURL string, cacheTTL time.Duration, policy upstream.Policy) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL:  strings.TrimSuffix(baseURL, "/"),
+		HTTP:     upstream.Client("swpc", 20*time.Second, policy),
+		CacheTTL: cacheTTL,
+		Clock:    clock.System{},
+	}
+}
+
+// Report returns the current space weather, from the cache when fresh
+func (c *Client) Report(ctx context.Context) (Report, error) {
+	if r, ok := c.cached(); ok {
+		return r, nil
+	}
+	c.refresh.Lock()
+	defer c.refresh.Unlock()
+	if r, ok := c.cached(); ok {
+		return r, nil // fetched while we waited
+	}
+
+	r := c.fetch(ctx)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r.Kp == nil && r.SolarWind == nil && r.Aurora == nil {
+		err := fmt.Errorf("swpc: %s", strings.Join(r.Errors, "; "))
+		if c.last != nil && ctx.Err() == nil {
+			stale := *c.last
+			stale.Stale = true
+			return stale, nil
+		}
+		return Report{}, err
+	}
+	c.last = &r
+	return r, nil
+}
+
+// cached returns the last report while it is fresh
+func (c *Client) cached() (Report, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last == nil || c.Clock.Now().Sub(c.last.FetchedAt) >= c.CacheTTL {
+		return Report{}, false
+	}
+	return *c.last, true
+}
+
+// fetch reads every feed at once
+func (c *Client) fetch(ctx context.Context) Report {
+	var (
+		wg                  sync.WaitGroup
+		kp, forecast        [][]*string
+		plasma, mag         [][]*string
+		aurora              *Aurora
+		errKp, errFc, errPl error
+		errMag, errAur      error
+	)
+	wg.Add(5)
+	go func() { defer wg.Done(); errKp = c.get(ctx, kpPath, 1<<20, &kp) }()
+	go func() { defer wg.Done(); errFc = c.get(ctx, kpForecastPath, 1<<20, &forecast) }()
+	go func() { defer wg.Done(); errPl = c.get(ctx, plasmaPath, 1<<20, &plasma) }()
+	go func() { defer wg.Done(); errMag = c.get(ctx, magPath, 1<<20, &mag) }()
+	go func() { defer wg.Done(); aurora, errAur = c.aurora(ctx) }()
+	wg.Wait()
+
+	r := Report{FetchedAt: c.Clock.Now().UTC()}
+	fail := func(feed string, err error) { r.Errors = append(r.Errors, feed+": "+err.Error()) }
+	if errKp == nil {
+		r.Kp, errKp = parseKp(kp, forecast, errFc == nil, r.FetchedAt)
+	}
+	if errKp != nil {
+		fail("kp", errKp)
+	}
+	if errFc != nil {
+		fail("kp forecast", errFc)
+	}
+	switch {
+	case errPl != nil:
+		fail("solar wind plasma", errPl)
+	case errMag != nil:
+		fail("solar wind field", errMag)
+	default:
+		if r.SolarWind, errPl = parseSolarWind(plasma, mag); errPl != nil {
+			fail("solar wind", errPl)
+		}
+	}
+	if errAur != nil {
+		fail("aurora", errAur)
+	} else {
+		r.Aurora = aurora
+	}
+	return r
+}
+
+// get decodes the JSON at path, read up to limit bytes, into v
+func (c *Client) get(ctx context.Context, path string, limit int64, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upstream returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, limit)).Decode(v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) aurora(ctx context.Context) (*Aurora, error) {
+	var raw struct {
+		Observation string       `json:"Observation Time"`
+		Forecast    string       `json:"Forecast Time"`
+		Coordinates [][3]float64 `json:"coordinates"` // longitude, latitude, probability
+	}
+	if err := c.get(ctx, auroraPath, 8<<20, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw.Coordinates) == 0 {
+		return nil, errors.New("no forecast grid")
+	}
+	a := &Aurora{}
+	a.ObservationTime, _ = time.Parse(time.RFC3339, raw.Observation)
+	a.ForecastTime, _ = time.Parse(time.RFC3339, raw.Forecast)
+	for _, p := range raw.Coordinates {
+		lon, lat, prob := int(p[0]), int(p[1]), int(max(0, min(100, p[2])))
+		if lon < 0 || lon > 359 || lat < -90 || lat > 90 {
+			continue
+		}
+		a.grid[lon][lat+90] = uint8(prob)
+		if lat >= 0 {
+			a.MaxNorth = max(a.MaxNorth, prob)
+		} else {
+			a.MaxSouth = max(a.MaxSouth, prob)
+		}
+	}
+	return a, nil
+}
+
+// table is SWPC's "products" format: a header row of column names, then
+// rows of strings (or nulls)
+type table [][]*string
+
+// column returns the index of the named column, -1 when missing
+func (t table) column(name string) int {
+	if len(t) == 0 {
+		return -1
+	}
+	for i, h := range t[0] {
+		if h != nil && *h == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// float reads row's cell i as a number, nil when empty or missing
+func float(row []*string, i int) *float64 {
+	if i < 0 || i >= len(row) || row[i] == nil {
+		return nil
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(*row[i]), 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
+
+// stamp reads row's cell i as a UTC time
+func stamp(row []*string, i int) (time.Time, bool) {
+	if i < 0 || i >= len(row) || row[i] == nil {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05.000", "2006-01-02 15:04:05", time.RFC3339} {
+		if t, err := time.Parse(layout, *row[i]); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func parseKp(observed, forecast table, hasForecast bool, now time.Time) (*Kp, error) {
+	tc, kc := observed.column("time_tag"), observed.column("Kp")
+	if tc < 0 || kc < 0 || len(observed) < 2 {
+		return nil, errors.New("unexpected Kp table")
+	}
+	kp := &Kp{Forecast: []KpPoint{}}
+	found := false
+	for _, row := range observed[1:] {
+		t, ok := stamp(row, tc)
+		v := float(row, kc)
+		if !ok || v == nil {
+			continue
+		}
+		kp.Time, kp.Value, found = t, *v, true
+		if now.Sub(t) <= 24*time.Hour {
+			kp.Max24h = max(kp.Max24h, *v)
+		}
+	}
+	if !found {
+		return nil, errors.New("no Kp values")
+	}
+	kp.Scale = Scale(kp.Value)
+
+	if hasForecast {
+		tc, kc, oc := forecast.column("time_tag"), forecast.column("kp"), forecast.column("observed")
+		for _, row := range forecast[min(1, len(forecast)):] {
+			t, ok := stamp(row, tc)
+			v := float(row, kc)
+			if !ok || v == nil || !t.After(kp.Time) || (oc >= 0 && oc < len(row) && row[oc] != nil && *row[oc] != "predicted") {
+				continue
+			}
+			kp.Forecast = append(kp.Forecast, KpPoint{Time: t, Value: *v, Scale: Scale(*v)})
+		}
+	}
+	return kp, nil
+}
+
+func parseSolarWind(plasma, mag table) (*SolarWind, error) {
+	latest := func(t table, cols ...string) ([]*float64, time.Time, bool) {
+		tc := t.column("time_tag")
+		idx := make([]int, len(cols))
+		for i, name := range cols {
+			idx[i] = t.column(name)
+		}
+		for r := len(t) - 1; r >= 1; r-- {
+			at, ok := stamp(t[r], tc)
+			if !ok {
+				continue
+			}
+			vals := make([]*float64, len(cols))
+			found := false
+			for i, ci := range idx {
+				vals[i] = float(t[r], ci)
+				found = found || vals[i] != nil
+			}
+			if found {
+				return vals, at, true
+			}
+		}
+		return nil, time.Time{}, false
+	}
+	p, pt, okP := latest(plasma, "speed", "density")
+	m, mt, okM := latest(mag, "bz_gsm", "bt")
+	if !okP && !okM {
+		return nil, errors.New("no solar wind readings")
+	}
+	sw := &SolarWind{}
+	if okP {
+		sw.Time, sw.Speed, sw.Density = pt, p[0], p[1]
+	}
+	if okM {
+		sw.Bz, sw.Bt = m[0], m[1]
+		if mt.After(sw.Time) {
+			sw.Time = mt
+		}
+	}
+	return sw, nil
+}
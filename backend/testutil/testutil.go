@@ -0,0 +1,158 @@
+// Package testutil holds helpers shared by the HTTP tests: a settable
+// clock, request shortcuts and golden-file comparison of JSON responses.
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// update rewrites golden files with the responses the tests got:
+// go test ./handlers -update
+var update = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+// Epoch is the time tests pin their clock to unless they need another
+var Epoch = time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+
+// Clock is a clock.Clock that only moves when told to
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a clock stopped at t
+func NewClock(t time.Time) *Clock { return &Clock{now: t} }
+
+// Now returns the time the clock is set to
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set moves the clock to t
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// Advance moves the clock forward by d
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// RemoteAddr is a pseudo-header for Do and Send: its value becomes the
+// request's RemoteAddr, the client address rate limits and the like key
+// on, rather than a header
+const RemoteAddr = ":remote-addr"
+
+// Do serves one request through h with the given headers
+func Do(h http.Handler, method, target string, header map[string]string) *httptest.ResponseRecorder {
+	return Send(h, method, target, header, "")
+}
+
+// Send serves one request with body through h. A body goes as JSON
+// unless header gives another Content-Type.
+func Send(h http.Handler, method, target string, header map[string]string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range header {
+		if k == RemoteAddr {
+			req.RemoteAddr = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// Golden compares the JSON body got with testdata/golden/<name>.json in
+// the test's package, ignoring formatting. With -update it writes the
+// file instead.
+func Golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal(got, &v); err != nil {
+		t.Fatalf("response is not JSON: %v\n%s", err, got)
+	}
+	pretty, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pretty = append(pretty, '\n')
+
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, pretty, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(pretty, want) {
+		t.Errorf("response differs from %s (run with -update to accept it)\ngot:\n%s", path, truncate(pretty, 2000))
+	}
+}
+
+// Scrub replaces the string values of keys, at any depth of the JSON
+// body, with "{key}", so that a golden file can hold a response with
+// fresh IDs, tokens or times
+func Scrub(body []byte, keys ...string) []byte {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body // Golden reports it
+	}
+	scrub(v, keys)
+	out, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+func scrub(v any, keys []string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, x := range v {
+			if _, ok := x.(string); ok && slices.Contains(keys, k) {
+				v[k] = "{" + k + "}"
+				continue
+			}
+			scrub(x, keys)
+		}
+	case []any:
+		for _, x := range v {
+			scrub(x, keys)
+		}
+	}
+}
+
+func truncate(b []byte, n int) []byte {
+	if len(b) > n {
+		return append(b[:n:n], "…"...)
+	}
+	return b
+}
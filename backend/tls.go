@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"solar-system-explorer/backend/config"
+
+	"github.com/quic-go/quic-go/http3"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// tlsServers returns the HTTPS server for handler and what the plain
+// HTTP port serves instead of handler: Let's Encrypt's HTTP-01 challenge
+// when certificates are automatic, and a redirect to HTTPS unless the
+// config keeps serving the app over HTTP as well.
+func tlsServers(cfg config.TLS, handler http.Handler) (*http.Server, http.Handler) {
+	plain := handler
+	if cfg.Redirect {
+		plain = redirectToHTTPS(cfg.Port)
+	}
+	srv := &http.Server{
+		Addr:      ":" + cfg.Port,
+		Handler:   handler,
+		TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+	if cfg.Domains == "" {
+		return srv, plain
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.DomainList()...),
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		Email:      cfg.Email,
+	}
+	srv.TLSConfig = m.TLSConfig()
+	srv.TLSConfig.MinVersion = tls.VersionTLS12
+	return srv, m.HTTPHandler(plain)
+}
+
+// newHTTP3Server returns the HTTP/3 server for cfg, on the UDP port with
+// the number of the HTTPS one, or nil when HTTP/3 is off. serveHTTP3
+// starts it once the HTTPS server exists.
+func newHTTP3Server(cfg config.TLS) *http3.Server {
+	if !cfg.HTTP3 {
+		return nil
+	}
+	return &http3.Server{Addr: ":" + cfg.Port}
+}
+
+// serveHTTP3 serves handler over QUIC with the certificates of secure:
+// the certificate files, or autocert's certificates through its
+// TLSConfig
+func serveHTTP3(h3 *http3.Server, secure *http.Server, cfg config.TLS, handler http.Handler) error {
+	h3.Handler = handler
+	if cfg.CertFile != "" {
+		return h3.ListenAndServeTLS(cfg.CertFile, cfg.KeyFile)
+	}
+	h3.TLSConfig = secure.TLSConfig
+	return h3.ListenAndServe()
+}
+
+// redirectToHTTPS sends every request to the same URL on the HTTPS port.
+// Only GET and HEAD are redirected; anything else would be resent without
+// its body, so it's refused instead.
+func redirectToHTTPS(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Use HTTPS", http.StatusBadRequest)
+			return
+		}
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
@@ -0,0 +1,28 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Middleware starts a server span per request, named after its route and
+// continuing any incoming W3C traceparent, and stores it in the request
+// context for handlers and the data layer to hang child spans off.
+func Middleware() gin.HandlerFunc {
+	if provider == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return otelgin.Middleware(service)
+}
+
+// NewTransport wraps base (http.DefaultTransport when nil) with client
+// spans and traceparent propagation. Upstream API clients should use it
+// as their transport.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base, otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		return r.Method + " " + r.URL.Host
+	}))
+}
@@ -0,0 +1,119 @@
+// Package tracing sets up OpenTelemetry. Spans are exported over OTLP/HTTP,
+// so any OpenTelemetry collector (Jaeger, Tempo, Honeycomb, …) can receive
+// them, and requests in and out carry the W3C traceparent header.
+//
+// Sampling is parent-based: a trace started here is sampled at the
+// configured ratio, and every span below it, in this process or a service
+// it calls, follows that decision. Incoming traces keep the caller's.
+//
+// With no endpoint configured tracing is disabled and spans are no-ops.
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// instrumentation names the tracer spans started here come from
+const instrumentation = "solar-system-explorer/backend"
+
+// provider and tracer are installed by Init; nil while tracing is
+// disabled
+var (
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+	service  string
+)
+
+// disabled is the span Start hands out while tracing is disabled
+var disabled trace.Span = noop.Span{}
+
+// Options configures the exporter; the fields mirror the standard OTel
+// environment variables
+type Options struct {
+	Endpoint       string  // OTLP/HTTP base URL, "/v1/traces" is appended
+	TracesEndpoint string  // full traces URL, overrides Endpoint
+	Headers        string  // "key=value,key2=value2"
+	ServiceName    string  // service.name resource attribute
+	SampleRatio    float64 // fraction of new traces sampled, 0–1
+}
+
+// Init enables tracing. It returns false (and leaves tracing disabled) when
+// no OTLP endpoint is configured.
+func Init(o Options) (bool, error) {
+	endpoint := o.TracesEndpoint
+	if endpoint == "" {
+		if o.Endpoint == "" {
+			return false, nil
+		}
+		endpoint = strings.TrimSuffix(o.Endpoint, "/") + "/v1/traces"
+	}
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(endpoint),
+		otlptracehttp.WithHeaders(parseHeaders(o.Headers)),
+	)
+	if err != nil {
+		return false, err
+	}
+	install(sdktrace.NewBatchSpanProcessor(exporter), o.ServiceName, o.SampleRatio)
+	return true, nil
+}
+
+// install makes a provider sending spans to processor the global one
+func install(processor sdktrace.SpanProcessor, serviceName string, ratio float64) {
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	tracer = provider.Tracer(instrumentation)
+	service = serviceName
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+}
+
+// Shutdown flushes pending spans, waiting at most until ctx is done
+func Shutdown(ctx context.Context) {
+	if provider != nil {
+		provider.Shutdown(ctx)
+	}
+}
+
+// Start begins a span as a child of whatever span ctx carries. The span
+// records nothing when tracing is disabled or the trace isn't sampled;
+// check IsRecording before computing costly attributes.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if tracer == nil {
+		return ctx, disabled
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Fail marks span as failed with err, if there is one
+func Fail(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+func parseHeaders(v string) map[string]string {
+	h := make(map[string]string)
+	for _, kv := range strings.Split(v, ",") {
+		if k, val, ok := strings.Cut(kv, "="); ok {
+			h[strings.TrimSpace(k)] = strings.TrimSpace(val)
+		}
+	}
+	return h
+}
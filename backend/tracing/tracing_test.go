@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentID = "00f067aa0ba902b7"
+)
+
+// tracedServer installs a provider sampling no new traces and recording
+// to the returned recorder, and routes a handler that calls upstream
+func tracedServer(t *testing.T, upstream string) (*gin.Engine, *tracetest.SpanRecorder) {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	install(rec, "test", 0)
+	p := provider
+	t.Cleanup(func() { p.Shutdown(context.Background()); provider, tracer = nil, nil })
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/api/planets/:name", func(c *gin.Context) {
+		ctx, span := Start(c.Request.Context(), "store.Bodies")
+		defer span.End()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, upstream, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			c.Status(http.StatusBadGateway)
+			return
+		}
+		resp.Body.Close()
+		c.Status(http.StatusOK)
+	})
+	return r, rec
+}
+
+// echoTraceParent records the traceparent header of the last request
+func echoTraceParent(t *testing.T) (*httptest.Server, *string) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("traceparent")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+// An unsampled root's children stay in its trace, unsampled, rather than
+// starting traces of their own
+func TestChildrenFollowTheRoot(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	install(rec, "test", 0)
+	defer func() { provider, tracer = nil, nil }()
+
+	ctx, root := Start(context.Background(), "job nightly")
+	_, child := Start(ctx, "orbits.Position")
+	child.End()
+	root.End()
+	rootSC, childSC := root.SpanContext(), child.SpanContext()
+	if !childSC.IsValid() || childSC.TraceID() != rootSC.TraceID() {
+		t.Errorf("child trace %s, want the root's %s", childSC.TraceID(), rootSC.TraceID())
+	}
+	if rootSC.IsSampled() || childSC.IsSampled() || len(rec.Ended()) != 0 {
+		t.Errorf("%d spans recorded from an unsampled trace", len(rec.Ended()))
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	tests := []struct {
+		flags    string
+		recorded int // server, store.Bodies and client span
+	}{
+		{"00", 0},
+		{"01", 3},
+	}
+	for _, tt := range tests {
+		upstream, got := echoTraceParent(t)
+		r, rec := tracedServer(t, upstream.URL)
+		req := httptest.NewRequest(http.MethodGet, "/api/planets/Mars", nil)
+		req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-"+tt.flags)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d", w.Code)
+		}
+
+		// The caller's decision travels on, whichever it was
+		parts := strings.Split(*got, "-")
+		if len(parts) != 4 || parts[1] != traceID || parts[2] == parentID || parts[3] != tt.flags {
+			t.Errorf("flags %s: upstream got traceparent %q, want trace %s with flags %s", tt.flags, *got, traceID, tt.flags)
+		}
+		if n := len(rec.Ended()); n != tt.recorded {
+			t.Errorf("flags %s: %d spans recorded, want %d", tt.flags, n, tt.recorded)
+		}
+		for _, s := range rec.Ended() {
+			if s.SpanContext().TraceID().String() != traceID {
+				t.Errorf("span %q in trace %s", s.Name(), s.SpanContext().TraceID())
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package translit normalizes names for lookup and search so Serbian
+// Cyrillic, Serbian Latin with or without diacritics, and English spellings
+// of a name all compare equal ("Меркур" = "Merkur", "Šaula" = "saula").
+// It also renders Serbian Latin text in Cyrillic for the sr-Cyrl locale.
+package translit
+
+import (
+	"strings"
+	"unicode"
+)
+
+// cyrillic maps Serbian (and the common Russian) Cyrillic letters to
+// Serbian Latin without diacritics
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'ђ': "dj", 'е': "e",
+	'ж': "z", 'з': "z", 'и': "i", 'ј': "j", 'к': "k", 'л': "l", 'љ': "lj",
+	'м': "m", 'н': "n", 'њ': "nj", 'о': "o", 'п': "p", 'р': "r", 'с': "s",
+	'т': "t", 'ћ': "c", 'у': "u", 'ф': "f", 'х': "h", 'ц': "c", 'ч': "c",
+	'џ': "dz", 'ш': "s",
+	// Russian letters users may type on a Russian layout
+	'й': "j", 'ы': "i", 'э': "e", 'ю': "ju", 'я': "ja", 'щ': "s", 'ё': "e",
+	'ь': "", 'ъ': "",
+}
+
+// latin folds accented Latin letters to their base letter
+var latin = map[rune]string{
+	'č': "c", 'ć': "c", 'š': "s", 'ž': "z", 'đ': "dj",
+	'á': "a", 'à': "a", 'â': "a", 'ä': "a", 'ã': "a", 'å': "a",
+	'é': "e", 'è': "e", 'ê': "e", 'ë': "e",
+	'í': "i", 'ì': "i", 'î': "i", 'ï': "i",
+	'ó': "o", 'ò': "o", 'ô': "o", 'ö': "o", 'õ': "o", 'ø': "o",
+	'ú': "u", 'ù': "u", 'û': "u", 'ü': "u",
+	'ý': "y", 'ñ': "n", 'ç': "c", 'ß': "ss",
+}
+
+// Fold lowercases s, transliterates Cyrillic to Latin, strips diacritics
+// and collapses whitespace and punctuation to single spaces
+func Fold(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if t, ok := cyrillic[r]; ok {
+			b.WriteString(t)
+			space = false
+			continue
+		}
+		if t, ok := latin[r]; ok {
+			b.WriteString(t)
+			space = false
+			continue
+		}
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// stray combining marks from decomposed input
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			space = false
+		default:
+			if !space && b.Len() > 0 {
+				b.WriteByte(' ')
+				space = true
+			}
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Equal reports whether two names are the same after folding
+func Equal(a, b string) bool {
+	return Fold(a) == Fold(b)
+}
+
+// serbianLatin maps Serbian Latin to Cyrillic; digraphs are handled in
+// ToCyrillic before single letters
+var serbianLatin = map[rune]rune{
+	'a': 'а', 'b': 'б', 'v': 'в', 'g': 'г', 'd': 'д', 'đ': 'ђ', 'e': 'е',
+	'ž': 'ж', 'z': 'з', 'i': 'и', 'j': 'ј', 'k': 'к', 'l': 'л', 'm': 'м',
+	'n': 'н', 'o': 'о', 'p': 'п', 'r': 'р', 's': 'с', 't': 'т', 'ć': 'ћ',
+	'u': 'у', 'f': 'ф', 'h': 'х', 'c': 'ц', 'č': 'ч', 'š': 'ш',
+}
+
+// serbianDigraphs are the Latin letter pairs that are one Cyrillic letter
+var serbianDigraphs = map[string]rune{"lj": 'љ', "nj": 'њ', "dž": 'џ'}
+
+// ToCyrillic converts Serbian Latin text to Serbian Cyrillic, keeping case.
+// Letters outside the Serbian alphabet (x, y, w, q) and everything that
+// isn't a letter pass through unchanged.
+func ToCyrillic(s string) string {
+	rs := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s) * 2)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		lower := unicode.ToLower(r)
+		if i+1 < len(rs) {
+			if c, ok := serbianDigraphs[string([]rune{lower, unicode.ToLower(rs[i+1])})]; ok {
+				if unicode.IsUpper(r) {
+					c = unicode.ToUpper(c)
+				}
+				b.WriteRune(c)
+				i++
+				continue
+			}
+		}
+		if c, ok := serbianLatin[lower]; ok {
+			if unicode.IsUpper(r) {
+				c = unicode.ToUpper(c)
+			}
+			b.WriteRune(c)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
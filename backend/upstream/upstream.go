@@ -0,0 +1,270 @@
+// Package upstream makes calls to external APIs resilient: idempotent
+// requests that fail with a network error, 429 or a 5xx are retried with
+// jittered exponential backoff, and after enough consecutive failures a
+// circuit breaker fails calls fast for a while instead of waiting on an
+// upstream that is down, letting a single probe through to see whether
+// it is back. Clients then fall back to what they have cached. Every
+// upstream keeps counters for /api/admin/upstreams.
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/tracing"
+)
+
+// ErrOpen is returned without calling the upstream while its circuit is
+// open
+var ErrOpen = errors.New("upstream unavailable (circuit open)")
+
+// Circuit states
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half_open"
+)
+
+// Policy is how an upstream's calls are retried and when its circuit opens
+type Policy struct {
+	Retries   int           // extra attempts for GET and HEAD
+	BaseDelay time.Duration // backoff before the first retry, doubled after each
+	MaxDelay  time.Duration // cap on one backoff, and on a Retry-After honoured
+	Threshold int           // consecutive failures that open the circuit
+	Cooldown  time.Duration // how long the circuit stays open before a probe
+}
+
+// DefaultPolicy retries twice and opens after five failures for a minute
+func DefaultPolicy() Policy {
+	return Policy{Retries: 2, BaseDelay: 200 * time.Millisecond, MaxDelay: 5 * time.Second, Threshold: 5, Cooldown: time.Minute}
+}
+
+// Stats are an upstream's counters since the server started
+type Stats struct {
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	Requests  int64      `json:"requests"` // calls, not attempts
+	Failures  int64      `json:"failures"` // calls that failed after retries
+	Retries   int64      `json:"retries"`
+	Rejected  int64      `json:"rejected"` // failed fast while open
+	AvgMillis float64    `json:"avg_ms"`   // per call, retries included
+	LastError string     `json:"last_error,omitempty"`
+	LastFail  *time.Time `json:"last_failure,omitempty"`
+	OpenedAt  *time.Time `json:"opened_at,omitempty"`
+}
+
+// Transport is an http.RoundTripper applying a Policy to one upstream
+type Transport struct {
+	Name   string
+	Base   http.RoundTripper // default: tracing.NewTransport(nil)
+	Policy Policy
+
+	mu       sync.Mutex
+	state    string
+	failures int // consecutive
+	openedAt time.Time
+	probing  bool
+	stats    Stats
+	elapsed  time.Duration
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*Transport{}
+)
+
+// New returns the transport of the named upstream, registering it for
+// All. Clients of the same upstream share one transport and circuit.
+func New(name string, p Policy) *Transport {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if t, ok := registry[name]; ok {
+		return t
+	}
+	t := &Transport{Name: name, Base: tracing.NewTransport(nil), Policy: p, state: StateClosed}
+	registry[name] = t
+	return t
+}
+
+// Client returns an HTTP client for the named upstream with the given
+// overall timeout, retries included
+func Client(name string, timeout time.Duration, p Policy) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: New(name, p)}
+}
+
+// All returns the stats of every registered upstream, by name
+func All() []Stats {
+	registryMu.Lock()
+	list := make([]*Transport, 0, len(registry))
+	for _, t := range registry {
+		list = append(list, t)
+	}
+	registryMu.Unlock()
+	out := make([]Stats, 0, len(list))
+	for _, t := range list {
+		out = append(out, t.Stats())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Stats returns t's counters
+func (t *Transport) Stats() Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.stats
+	s.Name, s.State = t.Name, t.state
+	if t.state == StateOpen && time.Since(t.openedAt) >= t.Policy.Cooldown {
+		s.State = StateHalfOpen
+	}
+	if s.Requests > 0 {
+		s.AvgMillis = float64(t.elapsed.Microseconds()) / 1000 / float64(s.Requests)
+	}
+	return s
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.allow() {
+		t.mu.Lock()
+		t.stats.Rejected++
+		t.mu.Unlock()
+		return nil, fmt.Errorf("%s: %w", t.Name, ErrOpen)
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	retries := 0
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		retries = t.Policy.Retries
+	}
+
+	start := time.Now()
+	var (
+		resp *http.Response
+		err  error
+	)
+	for attempt := 0; ; attempt++ {
+		resp, err = base.RoundTrip(req)
+		if !retryable(resp, err) || attempt == retries {
+			break
+		}
+		delay := t.backoff(attempt, resp)
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		t.mu.Lock()
+		t.stats.Retries++
+		t.mu.Unlock()
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			if errors.Is(req.Context().Err(), context.Canceled) {
+				t.abandon(start)
+			} else {
+				t.done(start, req.Context().Err())
+			}
+			return nil, req.Context().Err()
+		}
+	}
+	switch {
+	case err != nil && errors.Is(req.Context().Err(), context.Canceled):
+		t.abandon(start) // the caller gave up; says nothing about the upstream
+	case err != nil:
+		t.done(start, err)
+	case retryable(resp, nil):
+		t.done(start, fmt.Errorf("upstream returned %s", resp.Status))
+	default:
+		t.done(start, nil)
+	}
+	return resp, err
+}
+
+// retryable reports whether an attempt failed in a way worth retrying
+// and counting against the circuit
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoff is the wait before retry attempt+1: the upstream's Retry-After
+// when it sends seconds, otherwise a random delay up to BaseDelay·2^attempt
+// (full jitter), both capped at MaxDelay
+func (t *Transport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s >= 0 {
+			return min(time.Duration(s)*time.Second, t.Policy.MaxDelay)
+		}
+	}
+	ceiling := min(t.Policy.BaseDelay<<attempt, t.Policy.MaxDelay)
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling) + 1
+}
+
+// allow reports whether a call may go out: always while closed, never
+// while open, and once the cooldown is over a single probe at a time
+func (t *Transport) allow() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch t.state {
+	case StateClosed:
+		return true
+	case StateOpen:
+		if time.Since(t.openedAt) < t.Policy.Cooldown {
+			return false
+		}
+		t.state = StateHalfOpen
+	}
+	if t.probing {
+		return false
+	}
+	t.probing = true
+	return true
+}
+
+// abandon records a call the caller cancelled
+func (t *Transport) abandon(start time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats.Requests++
+	t.elapsed += time.Since(start)
+	t.probing = false
+}
+
+// done records the outcome of a call started at start
+func (t *Transport) done(start time.Time, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats.Requests++
+	t.elapsed += time.Since(start)
+	t.probing = false
+	if err == nil {
+		t.failures = 0
+		t.state = StateClosed
+		t.stats.OpenedAt = nil
+		return
+	}
+	now := time.Now().UTC()
+	t.stats.Failures++
+	t.stats.LastError = err.Error()
+	t.stats.LastFail = &now
+	t.failures++
+	if t.state == StateHalfOpen || t.failures >= t.Policy.Threshold {
+		t.state, t.openedAt = StateOpen, now
+		t.stats.OpenedAt = &now
+	}
+}
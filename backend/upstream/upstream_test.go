@@ -0,0 +1,167 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scripted answers attempts with the statuses in order, repeating the
+// last one, and counts the attempts; status 0 is a network error
+type scripted struct {
+	statuses []int
+	calls    atomic.Int32
+}
+
+func (s *scripted) RoundTrip(req *http.Request) (*http.Response, error) {
+	n := int(s.calls.Add(1)) - 1
+	status := s.statuses[min(n, len(s.statuses)-1)]
+	if status == 0 {
+		return nil, errors.New("connection refused")
+	}
+	return &http.Response{StatusCode: status, Status: http.StatusText(status), Header: http.Header{}, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func newTestTransport(base http.RoundTripper, p Policy) *Transport {
+	return &Transport{Name: "test", Base: base, Policy: p, state: StateClosed}
+}
+
+var fastPolicy = Policy{Retries: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, Threshold: 3, Cooldown: 30 * time.Millisecond}
+
+func call(t *testing.T, tr *Transport, method string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequest(method, "http://upstream.test/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tr.RoundTrip(req)
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		statuses []int
+		attempts int32
+		status   int // of the response returned, 0 for an error
+	}{
+		{"recovers after 503", http.MethodGet, []int{503, 0, 200}, 3, 200},
+		{"gives up after the retries", http.MethodGet, []int{503}, 3, 503},
+		{"429 is retried", http.MethodGet, []int{429, 200}, 2, 200},
+		{"404 is not retried", http.MethodGet, []int{404, 200}, 1, 404},
+		{"POST is not retried", http.MethodPost, []int{503, 200}, 1, 503},
+		{"network error", http.MethodHead, []int{0}, 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &scripted{statuses: tt.statuses}
+			tr := newTestTransport(base, fastPolicy)
+			resp, err := call(t, tr, tt.method)
+			if n := base.calls.Load(); n != tt.attempts {
+				t.Errorf("attempts = %d, want %d", n, tt.attempts)
+			}
+			switch {
+			case tt.status == 0 && err == nil:
+				t.Errorf("got %d, want an error", resp.StatusCode)
+			case tt.status != 0 && (err != nil || resp.StatusCode != tt.status):
+				t.Errorf("got %v %v, want %d", resp, err, tt.status)
+			}
+			if s := tr.Stats(); s.Requests != 1 || s.Retries != int64(tt.attempts-1) {
+				t.Errorf("stats = %d requests, %d retries", s.Requests, s.Retries)
+			}
+		})
+	}
+}
+
+func TestBackoffHonoursRetryAfter(t *testing.T) {
+	tr := newTestTransport(nil, Policy{BaseDelay: 100 * time.Millisecond, MaxDelay: 2 * time.Second})
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"1"}}}
+	if d := tr.backoff(0, resp); d != time.Second {
+		t.Errorf("Retry-After: 1 → %s, want 1s", d)
+	}
+	resp.Header.Set("Retry-After", "3600")
+	if d := tr.backoff(0, resp); d != 2*time.Second {
+		t.Errorf("Retry-After: 3600 → %s, want MaxDelay", d)
+	}
+	for attempt := 0; attempt < 8; attempt++ {
+		ceiling := min(100*time.Millisecond<<attempt, 2*time.Second)
+		if d := tr.backoff(attempt, nil); d <= 0 || d > ceiling {
+			t.Errorf("attempt %d: backoff %s, want within (0, %s]", attempt, d, ceiling)
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	base := &scripted{statuses: []int{503}}
+	tr := newTestTransport(base, Policy{Threshold: 3, Cooldown: 30 * time.Millisecond})
+
+	for i := 0; i < 3; i++ {
+		call(t, tr, http.MethodGet)
+	}
+	if s := tr.Stats(); s.State != StateOpen || s.OpenedAt == nil {
+		t.Fatalf("after 3 failures state = %s, want open", s.State)
+	}
+	if _, err := call(t, tr, http.MethodGet); !errors.Is(err, ErrOpen) {
+		t.Errorf("while open err = %v, want ErrOpen", err)
+	}
+	if n := base.calls.Load(); n != 3 {
+		t.Errorf("upstream called %d times, want 3: an open circuit must not call it", n)
+	}
+	if s := tr.Stats(); s.Rejected != 1 {
+		t.Errorf("rejected = %d, want 1", s.Rejected)
+	}
+
+	// After the cooldown one probe goes out; failing, it opens the
+	// circuit again at once
+	time.Sleep(40 * time.Millisecond)
+	if s := tr.Stats(); s.State != StateHalfOpen {
+		t.Errorf("after the cooldown state = %s, want half_open", s.State)
+	}
+	call(t, tr, http.MethodGet)
+	if n := base.calls.Load(); n != 4 {
+		t.Errorf("upstream called %d times, want 4 (one probe)", n)
+	}
+	if _, err := call(t, tr, http.MethodGet); !errors.Is(err, ErrOpen) {
+		t.Errorf("after a failed probe err = %v, want ErrOpen", err)
+	}
+
+	// A successful probe closes it
+	time.Sleep(40 * time.Millisecond)
+	base.statuses = []int{200}
+	base.calls.Store(0)
+	if resp, err := call(t, tr, http.MethodGet); err != nil || resp.StatusCode != 200 {
+		t.Fatalf("probe = %v %v, want 200", resp, err)
+	}
+	if s := tr.Stats(); s.State != StateClosed || s.OpenedAt != nil {
+		t.Errorf("after a good probe state = %s, want closed", s.State)
+	}
+}
+
+func TestHalfOpenAllowsOneProbe(t *testing.T) {
+	tr := newTestTransport(nil, Policy{Threshold: 1, Cooldown: time.Millisecond})
+	tr.done(time.Now(), errors.New("down"))
+	time.Sleep(5 * time.Millisecond)
+	if !tr.allow() {
+		t.Fatal("the first call after the cooldown wasn't let through")
+	}
+	if tr.allow() {
+		t.Error("a second call went out while the probe is in flight")
+	}
+}
+
+func TestCancelledCallsDontOpenTheCircuit(t *testing.T) {
+	base := &scripted{statuses: []int{0}}
+	tr := newTestTransport(base, Policy{Threshold: 1, Cooldown: time.Minute})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "http://upstream.test/", nil)
+	tr.RoundTrip(req)
+	if s := tr.Stats(); s.State != StateClosed || s.Failures != 0 {
+		t.Errorf("after a cancelled call state = %s with %d failures, want closed with 0", s.State, s.Failures)
+	}
+}
@@ -0,0 +1,168 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+	"solar-system-explorer/backend/internal/random"
+)
+
+// Purpose is what an emailed token is good for
+type Purpose string
+
+// Purposes of emailed tokens
+const (
+	PurposeVerify Purpose = "verify" // confirms the account's email address
+	PurposeReset  Purpose = "reset"  // sets a new password
+)
+
+// How long emailed links work, and how often one can be resent
+const (
+	VerifyTTL    = 48 * time.Hour
+	ResetTTL     = time.Hour
+	ResendPeriod = time.Minute
+)
+
+// Errors returned by EmailTokens
+var (
+	ErrToken   = errors.New("invalid or expired link")
+	ErrTooSoon = errors.New("a link was sent moments ago")
+)
+
+// emailToken is an issued token as persisted
+type emailToken struct {
+	Hash      string    `json:"hash"`
+	UserID    string    `json:"user_id"`
+	Purpose   Purpose   `json:"purpose"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// EmailTokens holds the single-use tokens in verification and password
+// reset links, persisted to a JSON file when a path is set. Like refresh
+// tokens they are stored only as SHA-256 hashes.
+type EmailTokens struct {
+	mu     sync.Mutex
+	path   string
+	byHash map[string]*emailToken
+}
+
+// OpenEmailTokens loads the tokens file at path, which may not exist yet.
+// An empty path keeps tokens in memory, so a restart voids sent links.
+func OpenEmailTokens(path string) (*EmailTokens, error) {
+	t := &EmailTokens{path: path, byHash: make(map[string]*emailToken)}
+	if path == "" {
+		return t, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*emailToken
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	for _, tok := range list {
+		t.byHash[tok.Hash] = tok
+	}
+	return t, nil
+}
+
+// Issue returns a new token for userID, voiding the user's earlier ones
+// for the same purpose so only the latest link works. It refuses with
+// ErrTooSoon within ResendPeriod of the previous one.
+func (t *EmailTokens) Issue(userID string, purpose Purpose, now time.Time) (string, error) {
+	ttl := VerifyTTL
+	if purpose == PurposeReset {
+		ttl = ResetTTL
+	}
+	now = now.UTC()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	replaced := make(map[string]*emailToken)
+	for h, tok := range t.byHash {
+		if tok.UserID != userID || tok.Purpose != purpose {
+			continue
+		}
+		if now.Sub(tok.IssuedAt) < ResendPeriod {
+			return "", ErrTooSoon
+		}
+		replaced[h] = tok
+	}
+	for h := range replaced {
+		delete(t.byHash, h)
+	}
+	token := random.Hex(32)
+	tok := &emailToken{Hash: hashToken(token), UserID: userID, Purpose: purpose, IssuedAt: now, ExpiresAt: now.Add(ttl)}
+	t.byHash[tok.Hash] = tok
+	if err := t.save(); err != nil {
+		delete(t.byHash, tok.Hash)
+		for h, old := range replaced {
+			t.byHash[h] = old
+		}
+		return "", err
+	}
+	return token, nil
+}
+
+// Consume uses up token and returns the user it was issued to. Unknown,
+// expired and already used tokens, and tokens for another purpose, give
+// ErrToken.
+func (t *EmailTokens) Consume(token string, purpose Purpose, now time.Time) (string, error) {
+	h := hashToken(token)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tok, ok := t.byHash[h]
+	if !ok || tok.Purpose != purpose {
+		return "", ErrToken
+	}
+	delete(t.byHash, h)
+	if err := t.save(); err != nil {
+		t.byHash[h] = tok
+		return "", err
+	}
+	if !now.Before(tok.ExpiresAt) {
+		return "", ErrToken
+	}
+	return tok.UserID, nil
+}
+
+// Purge drops expired tokens and returns how many
+func (t *EmailTokens) Purge(now time.Time) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for h, tok := range t.byHash {
+		if !now.Before(tok.ExpiresAt) {
+			delete(t.byHash, h)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	return n, t.save()
+}
+
+// save writes all tokens via a temp file and rename. Callers hold t.mu.
+func (t *EmailTokens) save() error {
+	if t.path == "" {
+		return nil
+	}
+	list := make([]*emailToken, 0, len(t.byHash))
+	for _, tok := range t.byHash {
+		list = append(list, tok)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].IssuedAt.Before(list[j].IssuedAt) })
+	return jsonfile.Save(t.path, list, 0o600)
+}
@@ -0,0 +1,176 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"solar-system-explorer/backend/internal/random"
+)
+
+// ErrLinked is returned when an external identity already belongs to
+// another account
+var ErrLinked = errors.New("identity linked to another account")
+
+// Identity is an external sign-in (OAuth provider account) linked to a
+// user
+type Identity struct {
+	Provider string    `json:"provider"`
+	Subject  string    `json:"subject"` // the provider's user ID
+	Email    string    `json:"email,omitempty"`
+	LinkedAt time.Time `json:"linked_at"`
+}
+
+func (id Identity) key() string { return id.Provider + ":" + id.Subject }
+
+// SignInExternal returns the account for an external identity, creating
+// one if needed. An identity not linked yet joins the account with the
+// same email when the provider has verified that email; created reports
+// whether a new account was made. An account whose email wasn't verified
+// may have been registered by someone who doesn't own the address, ahead
+// of its owner: joining it clears its password, and claimed tells the
+// caller to end its sessions.
+func (u *Users) SignInExternal(id Identity, emailVerified bool, name string) (user User, created, claimed bool, err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if r, ok := u.byIdentity[id.key()]; ok {
+		return r.clone(), false, false, nil
+	}
+	id.LinkedAt = time.Now().UTC()
+	key := NormalizeEmail(id.Email)
+	if r, ok := u.byEmail[key]; ok {
+		if !emailVerified {
+			return User{}, false, false, fmt.Errorf("%w: sign in with your password, then link %s", ErrExists, id.Provider)
+		}
+		wasVerified, prevHash := r.EmailVerified, r.PasswordHash
+		if !wasVerified {
+			r.PasswordHash = ""
+		}
+		r.EmailVerified = true
+		user, _, err := u.link(r, id)
+		if err != nil {
+			r.EmailVerified, r.PasswordHash = wasVerified, prevHash
+			return User{}, false, false, err
+		}
+		return user, false, !wasVerified, nil
+	}
+	if key == "" || !strings.Contains(key, "@") {
+		return User{}, false, false, fmt.Errorf("%w: %s did not share an email address", ErrInvalid, id.Provider)
+	}
+	r := &record{User: User{
+		ID:            random.Hex(8),
+		Email:         strings.TrimSpace(id.Email),
+		EmailVerified: emailVerified,
+		Name:          displayName(name, key),
+		Role:          RoleViewer,
+		Identities:    []Identity{id},
+		CreatedAt:     id.LinkedAt,
+	}}
+	u.byID[r.ID], u.byEmail[key], u.byIdentity[id.key()] = r, r, r
+	if err := u.save(); err != nil {
+		delete(u.byID, r.ID)
+		delete(u.byEmail, key)
+		delete(u.byIdentity, id.key())
+		return User{}, false, false, err
+	}
+	return r.clone(), true, false, nil
+}
+
+// Link adds an external identity to a signed-in user's account, replacing
+// any earlier one from the same provider
+func (u *Users) Link(userID string, id Identity) (User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	r, ok := u.byID[userID]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	if other, ok := u.byIdentity[id.key()]; ok && other != r {
+		return User{}, ErrLinked
+	}
+	id.LinkedAt = time.Now().UTC()
+	user, _, err := u.link(r, id)
+	return user, err
+}
+
+// Unlink removes the account's identity from provider. The last way to
+// sign in can't be removed.
+func (u *Users) Unlink(userID, provider string) (User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	r, ok := u.byID[userID]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	prev := r.Identities
+	kept := make([]Identity, 0, len(prev))
+	var removed *Identity
+	for i, id := range prev {
+		if id.Provider == provider {
+			removed = &prev[i]
+			continue
+		}
+		kept = append(kept, id)
+	}
+	if removed == nil {
+		return User{}, ErrNotFound
+	}
+	if len(kept) == 0 && r.PasswordHash == "" {
+		return User{}, fmt.Errorf("%w: set a password or link another provider first", ErrInvalid)
+	}
+	r.Identities = kept
+	delete(u.byIdentity, removed.key())
+	if err := u.save(); err != nil {
+		r.Identities = prev
+		u.byIdentity[removed.key()] = r
+		return User{}, err
+	}
+	return r.clone(), nil
+}
+
+// link attaches id to r, replacing r's identity from the same provider.
+// Callers hold u.mu.
+func (u *Users) link(r *record, id Identity) (User, bool, error) {
+	prev := r.Identities
+	next := make([]Identity, 0, len(prev)+1)
+	var replaced []Identity
+	for _, old := range prev {
+		if old.Provider == id.Provider {
+			replaced = append(replaced, old)
+			continue
+		}
+		next = append(next, old)
+	}
+	r.Identities = append(next, id)
+	for _, old := range replaced {
+		delete(u.byIdentity, old.key())
+	}
+	u.byIdentity[id.key()] = r
+	if err := u.save(); err != nil {
+		r.Identities = prev
+		delete(u.byIdentity, id.key())
+		for _, old := range replaced {
+			u.byIdentity[old.key()] = r
+		}
+		return User{}, false, err
+	}
+	return r.clone(), false, nil
+}
+
+// displayName is the provider's name for the user, or failing that the
+// email's local part, fitted to the 2 to 50 characters names allow
+func displayName(name, email string) string {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) < 2 {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	if utf8.RuneCountInString(name) < 2 {
+		name = "Explorer"
+	}
+	if r := []rune(name); len(r) > 50 {
+		name = string(r[:50])
+	}
+	return name
+}
@@ -0,0 +1,229 @@
+package users
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+	"solar-system-explorer/backend/internal/random"
+)
+
+// Login is one signed-in device: the server side of a session. Access
+// tokens name it in their sid claim and stop working once it is revoked;
+// its refresh token is rotated on every use.
+type Login struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"` // last refresh
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// loginRecord is a login as persisted
+type loginRecord struct {
+	Login
+	RefreshHash string `json:"refresh_hash"`
+	// PrevHash is the refresh token this one replaced. Seeing it again
+	// means a copy of the old token is in someone else's hands.
+	PrevHash string `json:"prev_hash,omitempty"`
+}
+
+// Logins holds the active logins, persisted to a JSON file when a path is
+// set. Refresh tokens are stored only as SHA-256 hashes.
+type Logins struct {
+	mu   sync.RWMutex
+	path string
+	ttl  time.Duration // refresh token lifetime, extended on each refresh
+	byID map[string]*loginRecord
+}
+
+// OpenLogins loads the logins file at path, which may not exist yet. An
+// empty path keeps logins in memory, so a restart signs everyone out.
+func OpenLogins(path string, ttl time.Duration) (*Logins, error) {
+	l := &Logins{path: path, ttl: ttl, byID: make(map[string]*loginRecord)}
+	if path == "" {
+		return l, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*loginRecord
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	for _, r := range list {
+		l.byID[r.ID] = r
+	}
+	return l, nil
+}
+
+// Start records a new login for userID and returns it with its refresh
+// token
+func (l *Logins) Start(userID, userAgent, ip string, now time.Time) (Login, string, error) {
+	if len(userAgent) > 200 {
+		userAgent = userAgent[:200]
+	}
+	now = now.UTC()
+	refresh := random.Hex(32)
+	r := &loginRecord{
+		Login: Login{
+			ID:         random.Hex(8),
+			UserID:     userID,
+			UserAgent:  userAgent,
+			IP:         ip,
+			CreatedAt:  now,
+			LastUsedAt: now,
+			ExpiresAt:  now.Add(l.ttl),
+		},
+		RefreshHash: hashToken(refresh),
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.byID[r.ID] = r
+	if err := l.save(); err != nil {
+		delete(l.byID, r.ID)
+		return Login{}, "", err
+	}
+	return r.Login, refresh, nil
+}
+
+// Rotate exchanges a refresh token for a new one, extending the login.
+// Presenting a token that was already rotated away revokes the login,
+// since either the client or a thief is replaying it.
+func (l *Logins) Rotate(refresh, ip string, now time.Time) (Login, string, error) {
+	h := hashToken(refresh)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, r := range l.byID {
+		switch {
+		case r.RefreshHash == h && now.Before(r.ExpiresAt):
+			prev := *r
+			next := random.Hex(32)
+			r.PrevHash, r.RefreshHash = r.RefreshHash, hashToken(next)
+			r.LastUsedAt, r.ExpiresAt = now.UTC(), now.UTC().Add(l.ttl)
+			if ip != "" {
+				r.IP = ip
+			}
+			if err := l.save(); err != nil {
+				*r = prev
+				return Login{}, "", err
+			}
+			return r.Login, next, nil
+		case r.PrevHash == h:
+			delete(l.byID, r.ID)
+			_ = l.save() // the token is refused either way
+			return Login{}, "", ErrSession
+		}
+	}
+	return Login{}, "", ErrSession
+}
+
+// Active reports whether login id exists and hasn't expired
+func (l *Logins) Active(id string, now time.Time) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	r, ok := l.byID[id]
+	return ok && now.Before(r.ExpiresAt)
+}
+
+// List returns userID's active logins, most recently used first
+func (l *Logins) List(userID string, now time.Time) []Login {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := []Login{}
+	for _, r := range l.byID {
+		if r.UserID == userID && now.Before(r.ExpiresAt) {
+			out = append(out, r.Login)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].LastUsedAt.After(out[j].LastUsedAt) })
+	return out
+}
+
+// Revoke ends one of userID's logins
+func (l *Logins) Revoke(userID, id string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r, ok := l.byID[id]
+	if !ok || r.UserID != userID {
+		return ErrNotFound
+	}
+	delete(l.byID, id)
+	if err := l.save(); err != nil {
+		l.byID[id] = r
+		return err
+	}
+	return nil
+}
+
+// RevokeAll ends every login of userID and returns how many there were
+func (l *Logins) RevokeAll(userID string) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	removed := make(map[string]*loginRecord)
+	for id, r := range l.byID {
+		if r.UserID == userID {
+			removed[id] = r
+			delete(l.byID, id)
+		}
+	}
+	if len(removed) == 0 {
+		return 0, nil
+	}
+	if err := l.save(); err != nil {
+		for id, r := range removed {
+			l.byID[id] = r
+		}
+		return 0, err
+	}
+	return len(removed), nil
+}
+
+// Purge drops expired logins and returns how many
+func (l *Logins) Purge(now time.Time) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for id, r := range l.byID {
+		if !now.Before(r.ExpiresAt) {
+			delete(l.byID, id)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	return n, l.save()
+}
+
+// save writes all logins via a temp file and rename. Callers hold l.mu.
+func (l *Logins) save() error {
+	if l.path == "" {
+		return nil
+	}
+	list := make([]*loginRecord, 0, len(l.byID))
+	for _, r := range l.byID {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return jsonfile.Save(l.path, list, 0o600)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,117 @@
+package users
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testLoginStore runs the LoginStore contract. Each call of open returns
+// a store over the same logins: the file reopened after a restart, or
+// another replica's connection to Redis.
+func testLoginStore(t *testing.T, open func(t *testing.T) LoginStore) {
+	a, b := open(t), open(t)
+	now := time.Now()
+
+	login, refresh, err := a.Start("u1", "Firefox", "192.0.2.1", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := a.Start("u1", "Safari", "192.0.2.2", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Active(login.ID, now) || len(b.List("u1", now)) != 2 {
+		t.Fatalf("the other store doesn't see the logins: %+v", b.List("u1", now))
+	}
+
+	rotated, next, err := b.Rotate(refresh, "192.0.2.3", now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.ID != login.ID || rotated.IP != "192.0.2.3" || next == refresh {
+		t.Errorf("rotated = %+v", rotated)
+	}
+	if _, _, err := a.Rotate(next, "", now.Add(2*time.Minute)); err != nil {
+		t.Errorf("rotating the new token through the first store: %v", err)
+	}
+
+	// Replaying the token just rotated away ends that login, not the
+	// others
+	if _, _, err := b.Rotate(next, "", now.Add(3*time.Minute)); !errors.Is(err, ErrSession) {
+		t.Errorf("replayed token: err = %v, want ErrSession", err)
+	}
+	if b.Active(login.ID, now) || !b.Active(other.ID, now) {
+		t.Error("a replayed token didn't revoke just its own login")
+	}
+
+	if err := b.Revoke("u2", other.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("revoking someone else's login: err = %v, want ErrNotFound", err)
+	}
+	if _, _, err := a.Start("u1", "Chrome", "", now); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := b.RevokeAll("u1"); err != nil || n != 2 {
+		t.Errorf("RevokeAll = %d, %v; want 2", n, err)
+	}
+	if list := a.List("u1", now); len(list) != 0 {
+		t.Errorf("%d logins left after RevokeAll", len(list))
+	}
+}
+
+func TestLogins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logins.json")
+	testLoginStore(t, func(t *testing.T) LoginStore {
+		return reopening{t: t, path: path}
+	})
+}
+
+func TestLoginsExpire(t *testing.T) {
+	l, err := OpenLogins("", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	login, refresh, err := l.Start("u1", "", "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	later := now.Add(time.Hour)
+	if l.Active(login.ID, later) {
+		t.Error("a login outlived its refresh token")
+	}
+	if _, _, err := l.Rotate(refresh, "", later); !errors.Is(err, ErrSession) {
+		t.Errorf("expired token: err = %v, want ErrSession", err)
+	}
+	if n, err := l.Purge(later); err != nil || n != 1 {
+		t.Errorf("Purge = %d, %v; want 1", n, err)
+	}
+}
+
+// reopening opens the logins file afresh for every call, as a server
+// restarted between requests would
+type reopening struct {
+	t    *testing.T
+	path string
+}
+
+func (r reopening) open() *Logins {
+	l, err := OpenLogins(r.path, time.Hour)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return l
+}
+
+func (r reopening) Start(userID, userAgent, ip string, now time.Time) (Login, string, error) {
+	return r.open().Start(userID, userAgent, ip, now)
+}
+func (r reopening) Rotate(refresh, ip string, now time.Time) (Login, string, error) {
+	return r.open().Rotate(refresh, ip, now)
+}
+func (r reopening) Active(id string, now time.Time) bool      { return r.open().Active(id, now) }
+func (r reopening) List(userID string, now time.Time) []Login { return r.open().List(userID, now) }
+func (r reopening) Revoke(userID, id string) error            { return r.open().Revoke(userID, id) }
+func (r reopening) RevokeAll(userID string) (int, error)      { return r.open().RevokeAll(userID) }
+func (r reopening) Purge(now time.Time) (int, error)          { return r.open().Purge(now) }
@@ -0,0 +1,234 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"solar-system-explorer/backend/internal/random"
+	"solar-system-explorer/backend/redis"
+)
+
+// LoginStore keeps the signed-in devices. Logins keeps them in this
+// process (and a file); RedisLogins shares them between replicas.
+type LoginStore interface {
+	Start(userID, userAgent, ip string, now time.Time) (Login, string, error)
+	Rotate(refresh, ip string, now time.Time) (Login, string, error)
+	Active(id string, now time.Time) bool
+	List(userID string, now time.Time) []Login
+	Revoke(userID, id string) error
+	RevokeAll(userID string) (int, error)
+	Purge(now time.Time) (int, error)
+}
+
+// redisTimeout bounds each Redis round trip of RedisLogins
+const redisTimeout = 2 * time.Second
+
+// RedisLogins keeps logins in Redis, so a device signed in through one
+// replica is recognized, refreshed and revoked through any of them. Each
+// login is a key expiring with its refresh token, found by its refresh
+// token hash through a second key; a set per user lists their logins.
+type RedisLogins struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisLogins returns logins kept through client whose refresh tokens
+// last ttl
+func NewRedisLogins(client *redis.Client, ttl time.Duration) *RedisLogins {
+	return &RedisLogins{client: client, ttl: ttl}
+}
+
+func loginKey(id string) string          { return "login:" + id }
+func refreshKey(hash string) string      { return "login:refresh:" + hash }
+func prevRefreshKey(hash string) string  { return "login:prev:" + hash }
+func userLoginsKey(userID string) string { return "logins:" + userID }
+
+// Start records a new login for userID and returns it with its refresh
+// token
+func (l *RedisLogins) Start(userID, userAgent, ip string, now time.Time) (Login, string, error) {
+	if len(userAgent) > 200 {
+		userAgent = userAgent[:200]
+	}
+	now = now.UTC()
+	refresh := random.Hex(32)
+	r := loginRecord{
+		Login: Login{
+			ID:         random.Hex(8),
+			UserID:     userID,
+			UserAgent:  userAgent,
+			IP:         ip,
+			CreatedAt:  now,
+			LastUsedAt: now,
+			ExpiresAt:  now.Add(l.ttl),
+		},
+		RefreshHash: hashToken(refresh),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := l.store(ctx, r); err != nil {
+		return Login{}, "", err
+	}
+	if err := l.client.SAdd(ctx, userLoginsKey(userID), r.ID); err != nil {
+		return Login{}, "", err
+	}
+	return r.Login, refresh, nil
+}
+
+// Rotate exchanges a refresh token for a new one, extending the login.
+// The old token is claimed with GETDEL, so of two replicas rotating the
+// same token at once only one succeeds. Presenting a token that was
+// already rotated away revokes the login.
+func (l *RedisLogins) Rotate(refresh, ip string, now time.Time) (Login, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	h := hashToken(refresh)
+	id, err := l.client.GetDel(ctx, refreshKey(h))
+	if errors.Is(err, redis.ErrNil) {
+		if id, err := l.client.Get(ctx, prevRefreshKey(h)); err == nil {
+			if r, err := l.load(ctx, string(id)); err == nil {
+				_ = l.revoke(ctx, r) // the token is refused either way
+			}
+		}
+		return Login{}, "", ErrSession
+	}
+	if err != nil {
+		return Login{}, "", err
+	}
+	r, err := l.load(ctx, string(id))
+	if err != nil || !now.Before(r.ExpiresAt) {
+		return Login{}, "", ErrSession
+	}
+	next := random.Hex(32)
+	r.PrevHash, r.RefreshHash = r.RefreshHash, hashToken(next)
+	r.LastUsedAt, r.ExpiresAt = now.UTC(), now.UTC().Add(l.ttl)
+	if ip != "" {
+		r.IP = ip
+	}
+	if err := l.store(ctx, r); err != nil {
+		return Login{}, "", err
+	}
+	return r.Login, next, nil
+}
+
+// Active reports whether login id exists and hasn't expired. Without an
+// answer from Redis it reports false, signing the request out rather than
+// trusting a possibly revoked login.
+func (l *RedisLogins) Active(id string, now time.Time) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	r, err := l.load(ctx, id)
+	return err == nil && now.Before(r.ExpiresAt)
+}
+
+// List returns userID's active logins, most recently used first
+func (l *RedisLogins) List(userID string, now time.Time) []Login {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	out := []Login{}
+	ids, err := l.client.SMembers(ctx, userLoginsKey(userID))
+	if err != nil {
+		return out
+	}
+	var gone []string
+	for _, id := range ids {
+		r, err := l.load(ctx, id)
+		switch {
+		case errors.Is(err, redis.ErrNil):
+			gone = append(gone, id)
+		case err == nil && now.Before(r.ExpiresAt):
+			out = append(out, r.Login)
+		}
+	}
+	if len(gone) > 0 {
+		_ = l.client.SRem(ctx, userLoginsKey(userID), gone...)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].LastUsedAt.After(out[j].LastUsedAt) })
+	return out
+}
+
+// Revoke ends one of userID's logins
+func (l *RedisLogins) Revoke(userID, id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	r, err := l.load(ctx, id)
+	if errors.Is(err, redis.ErrNil) || err == nil && r.UserID != userID {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return l.revoke(ctx, r)
+}
+
+// RevokeAll ends every login of userID and returns how many there were
+func (l *RedisLogins) RevokeAll(userID string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	ids, err := l.client.SMembers(ctx, userLoginsKey(userID))
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, id := range ids {
+		r, err := l.load(ctx, id)
+		if errors.Is(err, redis.ErrNil) {
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+		if err := l.revoke(ctx, r); err != nil {
+			return n, err
+		}
+		n++
+	}
+	_, err = l.client.Del(ctx, userLoginsKey(userID))
+	return n, err
+}
+
+// Purge does nothing: Redis expires logins by itself
+func (l *RedisLogins) Purge(time.Time) (int, error) { return 0, nil }
+
+// store writes r and its refresh token keys, all expiring with it
+func (l *RedisLogins) store(ctx context.Context, r loginRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(r.ExpiresAt)
+	if err := l.client.Set(ctx, loginKey(r.ID), data, ttl); err != nil {
+		return err
+	}
+	if err := l.client.Set(ctx, refreshKey(r.RefreshHash), []byte(r.ID), ttl); err != nil {
+		return err
+	}
+	if r.PrevHash != "" {
+		return l.client.Set(ctx, prevRefreshKey(r.PrevHash), []byte(r.ID), ttl)
+	}
+	return nil
+}
+
+// load reads login id, or returns redis.ErrNil
+func (l *RedisLogins) load(ctx context.Context, id string) (loginRecord, error) {
+	var r loginRecord
+	data, err := l.client.Get(ctx, loginKey(id))
+	if err != nil {
+		return r, err
+	}
+	return r, json.Unmarshal(data, &r)
+}
+
+// revoke deletes r and its keys
+func (l *RedisLogins) revoke(ctx context.Context, r loginRecord) error {
+	keys := []string{loginKey(r.ID), refreshKey(r.RefreshHash)}
+	if r.PrevHash != "" {
+		keys = append(keys, prevRefreshKey(r.PrevHash))
+	}
+	if _, err := l.client.Del(ctx, keys...); err != nil {
+		return err
+	}
+	return l.client.SRem(ctx, userLoginsKey(r.UserID), r.ID)
+}
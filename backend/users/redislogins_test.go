@@ -0,0 +1,31 @@
+//go:build redis
+
+package users
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"solar-system-explorer/backend/internal/random"
+	"solar-system-explorer/backend/redis"
+)
+
+// TestRedisLogins runs against REDIS_TEST_URL, each replica with its own
+// client, under a prefix of its own so runs don't see each other's keys
+func TestRedisLogins(t *testing.T) {
+	url := os.Getenv("REDIS_TEST_URL")
+	if url == "" {
+		t.Skip("REDIS_TEST_URL is not set")
+	}
+	prefix := "solar-test:" + random.Hex(4) + ":"
+	testLoginStore(t, func(t *testing.T) LoginStore {
+		client, err := redis.Open(context.Background(), url, 2, prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { client.Close() })
+		return NewRedisLogins(client, time.Hour)
+	})
+}
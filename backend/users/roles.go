@@ -0,0 +1,60 @@
+package users
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Roles, from least to most trusted. New accounts are viewers.
+const (
+	RoleViewer  = "viewer"
+	RoleTeacher = "teacher"
+	RoleCurator = "curator"
+	RoleAdmin   = "admin"
+)
+
+// Roles lists every role, least trusted first
+var Roles = []string{RoleViewer, RoleTeacher, RoleCurator, RoleAdmin}
+
+// Permission is something a role may do
+type Permission string
+
+// Permissions checked by middleware.Require
+const (
+	PermClasses   Permission = "classes"   // run classes
+	PermContent   Permission = "content"   // edit tours and the dataset
+	PermTranslate Permission = "translate" // manage translations
+	PermModerate  Permission = "moderate"  // comments and reports
+	PermAdmin     Permission = "admin"     // config, jobs, audit, webhooks, accounts
+)
+
+// rolePermissions is what each role may do; admins may do everything
+var rolePermissions = map[string][]Permission{
+	RoleViewer:  nil,
+	RoleTeacher: {PermClasses},
+	RoleCurator: {PermContent, PermTranslate, PermModerate},
+	RoleAdmin:   {PermClasses, PermContent, PermTranslate, PermModerate, PermAdmin},
+}
+
+// Can reports whether role has permission p
+func Can(role string, p Permission) bool {
+	for _, have := range rolePermissions[role] {
+		if have == p {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionsOf lists role's permissions
+func PermissionsOf(role string) []Permission {
+	return append([]Permission{}, rolePermissions[role]...)
+}
+
+// ValidRole checks role is one of Roles
+func ValidRole(role string) error {
+	if _, ok := rolePermissions[role]; !ok {
+		return fmt.Errorf("%w: role must be one of %s", ErrInvalid, strings.Join(Roles, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package users
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrSession is returned for missing, forged and expired session tokens
+var ErrSession = errors.New("invalid or expired session")
+
+// Claims is what a session token says about its bearer
+type Claims struct {
+	Subject  string `json:"sub"` // user ID
+	Session  string `json:"sid"` // Login ID
+	Name     string `json:"name"`
+	IssuedAt int64  `json:"iat"`
+	Expires  int64  `json:"exp"`
+}
+
+// Tokens is what a client gets on signing in or refreshing: a short-lived
+// access token and the refresh token to get the next one with
+type Tokens struct {
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	Session          string    `json:"session"` // Login ID
+}
+
+// Sessions issues and verifies JWTs (HS256) for signed-in users. Each
+// token belongs to a Login and is refused once that login is revoked.
+type Sessions struct {
+	key    []byte
+	ttl    time.Duration // access token lifetime
+	logins LoginStore
+}
+
+// NewSessions returns sessions signed with key whose access tokens last
+// ttl
+func NewSessions(key []byte, ttl time.Duration, logins LoginStore) *Sessions {
+	return &Sessions{key: key, ttl: ttl, logins: logins}
+}
+
+// Logins returns the store of signed-in devices
+func (s *Sessions) Logins() LoginStore {
+	return s.logins
+}
+
+// Start signs user in on a new device
+func (s *Sessions) Start(user User, userAgent, ip string, now time.Time) (Tokens, error) {
+	login, refresh, err := s.logins.Start(user.ID, userAgent, ip, now)
+	if err != nil {
+		return Tokens{}, err
+	}
+	return s.tokens(user, login, refresh, now), nil
+}
+
+// Refresh rotates a refresh token and issues a new access token. lookup
+// finds the login's user, so name changes reach the new token.
+func (s *Sessions) Refresh(refresh, ip string, now time.Time, lookup func(id string) (User, error)) (Tokens, error) {
+	login, next, err := s.logins.Rotate(refresh, ip, now)
+	if err != nil {
+		return Tokens{}, err
+	}
+	user, err := lookup(login.UserID)
+	if err != nil {
+		_ = s.logins.Revoke(login.UserID, login.ID)
+		return Tokens{}, ErrSession
+	}
+	return s.tokens(user, login, next, now), nil
+}
+
+func (s *Sessions) tokens(user User, login Login, refresh string, now time.Time) Tokens {
+	token, expires := s.Issue(user, login.ID, now)
+	return Tokens{Token: token, ExpiresAt: expires, RefreshToken: refresh, RefreshExpiresAt: login.ExpiresAt, Session: login.ID}
+}
+
+// jwtHeader is the fixed, pre-encoded JOSE header
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Issue returns an access token for user on login sid and when it
+// expires
+func (s *Sessions) Issue(user User, sid string, now time.Time) (string, time.Time) {
+	expires := now.Add(s.ttl).Truncate(time.Second)
+	payload, _ := json.Marshal(Claims{Subject: user.ID, Session: sid, Name: user.Name, IssuedAt: now.Unix(), Expires: expires.Unix()})
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + s.sign(unsigned), expires
+}
+
+// Verify checks token's signature, expiry and login and returns its
+// claims
+func (s *Sessions) Verify(token string, now time.Time) (Claims, error) {
+	header, rest, ok := strings.Cut(token, ".")
+	if !ok || header != jwtHeader {
+		return Claims{}, ErrSession
+	}
+	payload, sig, ok := strings.Cut(rest, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(s.sign(header+"."+payload))) {
+		return Claims{}, ErrSession
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return Claims{}, ErrSession
+	}
+	var c Claims
+	if err := json.Unmarshal(data, &c); err != nil || c.Subject == "" || now.Unix() >= c.Expires {
+		return Claims{}, ErrSession
+	}
+	if !s.logins.Active(c.Session, now) {
+		return Claims{}, ErrSession
+	}
+	return c, nil
+}
+
+func (s *Sessions) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,289 @@
+// Package users keeps accounts for the features that need to know who is
+// asking: comments, and the staff roles that open the admin API
+// (roles.go). Passwords are stored as bcrypt hashes; sessions are signed
+// access tokens backed by revocable logins (session.go, logins.go), and
+// emailed links carry single-use tokens (emailtokens.go).
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+	"solar-system-explorer/backend/internal/random"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password length limits; bcrypt ignores bytes past 72
+const (
+	MinPassword = 10
+	MaxPassword = 72
+)
+
+// Errors returned by Users
+var (
+	ErrInvalid     = errors.New("invalid account")
+	ErrExists      = errors.New("email already registered")
+	ErrCredentials = errors.New("wrong email or password")
+	ErrNotFound    = errors.New("user not found")
+)
+
+// User is an account as the API shows it
+type User struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	// EmailVerified is set once the user follows an emailed link or signs
+	// in with a provider that vouches for the address
+	EmailVerified bool       `json:"email_verified"`
+	Name          string     `json:"name"` // shown next to comments
+	Role          string     `json:"role"`
+	Identities    []Identity `json:"identities,omitempty"` // linked OAuth sign-ins
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// record is an account as persisted
+type record struct {
+	User
+	PasswordHash string `json:"password_hash,omitempty"` // empty for OAuth-only accounts
+}
+
+// clone returns r's user with its own copy of the identities
+func (r *record) clone() User {
+	out := r.User
+	out.Identities = append([]Identity(nil), r.Identities...)
+	return out
+}
+
+// Users holds accounts by ID, persisted to a JSON file when a path is set
+type Users struct {
+	mu         sync.RWMutex
+	path       string
+	byID       map[string]*record
+	byEmail    map[string]*record // lowercased
+	byIdentity map[string]*record // provider:subject
+}
+
+// Open loads the accounts file at path, which may not exist yet. An empty
+// path keeps accounts in memory only.
+func Open(path string) (*Users, error) {
+	u := &Users{path: path, byID: make(map[string]*record), byEmail: make(map[string]*record), byIdentity: make(map[string]*record)}
+	if path == "" {
+		return u, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return u, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*record
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	for _, r := range list {
+		if r.Role == "" { // accounts from before roles
+			r.Role = RoleViewer
+		}
+		u.byID[r.ID] = r
+		u.byEmail[NormalizeEmail(r.Email)] = r
+		for _, id := range r.Identities {
+			u.byIdentity[id.key()] = r
+		}
+	}
+	return u, nil
+}
+
+// NormalizeEmail returns the form accounts are looked up by: addresses
+// are compared without case or surrounding space
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Register creates an account
+func (u *Users) Register(email, name, password string) (User, error) {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != strings.TrimSpace(email) || len(email) > 254 {
+		return User{}, fmt.Errorf("%w: invalid email address", ErrInvalid)
+	}
+	name = strings.TrimSpace(name)
+	if n := utf8.RuneCountInString(name); n < 2 || n > 50 {
+		return User{}, fmt.Errorf("%w: name must be 2 to 50 characters", ErrInvalid)
+	}
+	if err := ValidPassword(password); err != nil {
+		return User{}, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return User{}, err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	key := NormalizeEmail(addr.Address)
+	if _, ok := u.byEmail[key]; ok {
+		return User{}, ErrExists
+	}
+	r := &record{
+		User:         User{ID: random.Hex(8), Email: addr.Address, Name: name, Role: RoleViewer, CreatedAt: time.Now().UTC()},
+		PasswordHash: string(hash),
+	}
+	u.byID[r.ID], u.byEmail[key] = r, r
+	if err := u.save(); err != nil {
+		delete(u.byID, r.ID)
+		delete(u.byEmail, key)
+		return User{}, err
+	}
+	return r.clone(), nil
+}
+
+// Authenticate returns the account for email if password matches
+func (u *Users) Authenticate(email, password string) (User, error) {
+	u.mu.RLock()
+	r, ok := u.byEmail[NormalizeEmail(email)]
+	u.mu.RUnlock()
+	if !ok {
+		// Spend the same time as a real check so timing doesn't reveal
+		// which addresses have accounts
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return User{}, ErrCredentials
+	}
+	if bcrypt.CompareHashAndPassword([]byte(r.PasswordHash), []byte(password)) != nil {
+		return User{}, ErrCredentials
+	}
+	return r.clone(), nil
+}
+
+// Get returns the account with id
+func (u *Users) Get(id string) (User, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	r, ok := u.byID[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	return r.clone(), nil
+}
+
+// List returns all accounts, oldest first, optionally only those with role
+func (u *Users) List(role string) []User {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	out := []User{}
+	for _, r := range u.byID {
+		if role == "" || r.Role == role {
+			out = append(out, r.clone())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// SetRole changes an account's role and returns it before and after
+func (u *Users) SetRole(id, role string) (before, after User, err error) {
+	if err := ValidRole(role); err != nil {
+		return User{}, User{}, err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	r, ok := u.byID[id]
+	if !ok {
+		return User{}, User{}, ErrNotFound
+	}
+	before = r.clone()
+	r.Role = role
+	if err := u.save(); err != nil {
+		r.Role = before.Role
+		return User{}, User{}, err
+	}
+	return before, r.clone(), nil
+}
+
+// SetPassword replaces an account's password, also giving a password to
+// an account that only signed in with OAuth so far
+func (u *Users) SetPassword(id, password string) (User, error) {
+	if err := ValidPassword(password); err != nil {
+		return User{}, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return User{}, err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	r, ok := u.byID[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	prev := r.PasswordHash
+	r.PasswordHash = string(hash)
+	if err := u.save(); err != nil {
+		r.PasswordHash = prev
+		return User{}, err
+	}
+	return r.clone(), nil
+}
+
+// MarkVerified records that the account's email address is confirmed
+func (u *Users) MarkVerified(id string) (User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	r, ok := u.byID[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	if r.EmailVerified {
+		return r.clone(), nil
+	}
+	r.EmailVerified = true
+	if err := u.save(); err != nil {
+		r.EmailVerified = false
+		return User{}, err
+	}
+	return r.clone(), nil
+}
+
+// ByEmail returns the account with email
+func (u *Users) ByEmail(email string) (User, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	r, ok := u.byEmail[NormalizeEmail(email)]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	return r.clone(), nil
+}
+
+// save writes all accounts via a temp file and rename. Callers hold u.mu.
+func (u *Users) save() error {
+	if u.path == "" {
+		return nil
+	}
+	list := make([]*record, 0, len(u.byID))
+	for _, r := range u.byID {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return jsonfile.Save(u.path, list, 0o600) // holds password hashes
+}
+
+// ValidPassword checks password's length
+func ValidPassword(password string) error {
+	if len(password) < MinPassword || len(password) > MaxPassword {
+		return fmt.Errorf("%w: password must be %d to %d bytes", ErrInvalid, MinPassword, MaxPassword)
+	}
+	return nil
+}
+
+// dummyHash is compared against for unknown emails
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
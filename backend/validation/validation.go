@@ -0,0 +1,177 @@
+// Package validation checks the dataset for values that are malformed or
+// physically implausible. The server runs it at boot and on demand for
+// the admin report; strict deployments refuse to start on errors.
+package validation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/i18n"
+	"solar-system-explorer/backend/models"
+)
+
+// Severity of an issue: errors are values the API can't compute with or
+// that can't be right, warnings are ones worth a second look
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// keplerTolerance is how far, relative, a body's orbital period may be
+// from what Kepler's third law gives for its distance
+const keplerTolerance = 0.05
+
+// yearDays is the sidereal year in days, the period at 1 AU
+const yearDays = 365.256
+
+// Issue is one problem found with a body's field
+type Issue struct {
+	Body     string   `json:"body"`
+	Field    string   `json:"field"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Report is the outcome of checking a dataset
+type Report struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Bodies    int       `json:"bodies"`
+	Errors    int       `json:"errors"`
+	Warnings  int       `json:"warnings"`
+	// Locales are the languages checked for complete translations
+	Locales []string `json:"locales"`
+	Issues  []Issue  `json:"issues"`
+}
+
+// OK reports whether the dataset has no errors
+func (r Report) OK() bool { return r.Errors == 0 }
+
+// String summarizes the report in one line for the log
+func (r Report) String() string {
+	return fmt.Sprintf("%d bodies checked, %d errors, %d warnings", r.Bodies, r.Errors, r.Warnings)
+}
+
+// Check validates bodies, as served with their translations merged in
+func Check(bodies []models.Planet, now time.Time) Report {
+	r := Report{CheckedAt: now.UTC(), Bodies: len(bodies), Locales: locales(bodies), Issues: []Issue{}}
+	for _, p := range bodies {
+		r.Issues = append(r.Issues, checkBody(p, r.Locales, now)...)
+	}
+	for _, is := range r.Issues {
+		if is.Severity == SeverityError {
+			r.Errors++
+		} else {
+			r.Warnings++
+		}
+	}
+	return r
+}
+
+// checkBody returns the issues with one body
+func checkBody(p models.Planet, locales []string, now time.Time) []Issue {
+	var issues []Issue
+	check := func(ok bool, sev Severity, field, format string, args ...any) {
+		if !ok {
+			issues = append(issues, Issue{Body: p.Name, Field: field, Severity: sev, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+	check(strings.TrimSpace(p.Name) != "", SeverityError, "name", "name is empty")
+	check(strings.TrimSpace(p.NameSR) != "", SeverityError, "name_sr", "name_sr is empty")
+	check(p.Radius > 0, SeverityError, "radius", "radius %g must be positive", p.Radius)
+	check(p.Mass >= 0, SeverityError, "mass", "mass %g must not be negative", p.Mass)
+	check(p.Albedo >= 0 && p.Albedo <= 1, SeverityError, "albedo", "albedo %g must be between 0 and 1", p.Albedo)
+	check(p.AxialTilt >= 0 && p.AxialTilt <= 180, SeverityError, "axial_tilt", "axial_tilt %g must be between 0 and 180", p.AxialTilt)
+	check(validColor(p.Color), SeverityError, "color", "color %q must be #rgb or #rrggbb", p.Color)
+	check(p.Satellites >= 0, SeverityError, "satellites", "satellites %d must not be negative", p.Satellites)
+	check(p.Satellites >= len(p.NotableSatellites), SeverityError, "notable_satellites",
+		"%d notable satellites listed but satellites is %d", len(p.NotableSatellites), p.Satellites)
+
+	if !p.IsStar {
+		check(p.DistanceFromSun > 0, SeverityError, "distance_from_sun", "distance_from_sun %g must be positive", p.DistanceFromSun)
+		check(p.OrbitalPeriod > 0, SeverityError, "orbital_period", "orbital_period %g must be positive", p.OrbitalPeriod)
+		check(p.Eccentricity >= 0 && p.Eccentricity < 1, SeverityError, "eccentricity",
+			"eccentricity %g must be at least 0 and below 1 for a bound orbit", p.Eccentricity)
+		check(p.Inclination >= 0 && p.Inclination <= 180, SeverityError, "inclination", "inclination %g must be between 0 and 180", p.Inclination)
+		if p.DistanceFromSun > 0 && p.OrbitalPeriod > 0 {
+			want := yearDays * math.Pow(p.DistanceFromSun, 1.5)
+			check(math.Abs(p.OrbitalPeriod-want) <= keplerTolerance*want, SeverityWarning, "orbital_period",
+				"orbital_period %g days is far from the %.0f days Kepler's third law gives for %g AU", p.OrbitalPeriod, want, p.DistanceFromSun)
+		}
+		if r := p.Rates; r != nil {
+			check(r.ValidFrom <= r.ValidTo, SeverityError, "rates", "rates valid_from %d is after valid_to %d", r.ValidFrom, r.ValidTo)
+			// Over the years the rates hold, the orbit must stay an ellipse
+			for _, year := range []int{r.ValidFrom, r.ValidTo} {
+				e := p.Eccentricity + r.Eccentricity*float64(year-2000)/100
+				check(e >= 0 && e < 1, SeverityWarning, "rates", "eccentricity drifts to %g by %d", e, year)
+			}
+		}
+	}
+	if p.Rings != nil {
+		check(p.Rings.InnerRadius > p.Radius, SeverityError, "rings", "rings start at %g km, inside the body's %g km radius", p.Rings.InnerRadius, p.Radius)
+		check(p.Rings.OuterRadius > p.Rings.InnerRadius, SeverityError, "rings", "rings outer_radius must be beyond inner_radius")
+	}
+	if t := p.SurfaceTemperature; t != nil {
+		check(t.Mean > 0, SeverityError, "surface_temperature", "mean temperature %g K must be above absolute zero", t.Mean)
+		check(t.Min == 0 || t.Min <= t.Mean, SeverityError, "surface_temperature", "min temperature %g K is above the mean %g K", t.Min, t.Mean)
+		check(t.Max == 0 || t.Max >= t.Mean, SeverityError, "surface_temperature", "max temperature %g K is below the mean %g K", t.Max, t.Mean)
+	}
+
+	if d := p.Discovery; d != nil {
+		check(strings.TrimSpace(d.Discoverer) != "", SeverityError, "discovery.discoverer", "discoverer is empty")
+		check(d.Year > 0 && d.Year <= now.Year(), SeverityError, "discovery.year", "discovery year %d must be between 1 and this year", d.Year)
+		switch d.Method {
+		case "", models.DiscoveryTelescope, models.DiscoveryPhotographic, models.DiscoveryCCD, models.DiscoverySpacecraft:
+		default:
+			check(false, SeverityError, "discovery.method", "discovery method %q must be telescope, photographic, ccd or spacecraft", d.Method)
+		}
+	}
+	check(strings.TrimSpace(p.Description) != "", SeverityWarning, "description", "description is empty")
+	for _, locale := range locales {
+		t := p.Translations[locale]
+		if locale == i18n.Default && t.Name == "" {
+			t.Name = p.Name // the English name is the body's name
+		}
+		check(t.Name != "", SeverityWarning, "translations."+locale+".name", "no %s name", locale)
+		check(t.Description != "", SeverityWarning, "translations."+locale+".description", "no %s description", locale)
+	}
+	return issues
+}
+
+// locales returns the languages any body is translated into, always
+// including i18n.Default. Serbian is left out: its text is the base the
+// other fields come from, and both scripts are derived from it.
+func locales(bodies []models.Planet) []string {
+	seen := map[string]bool{i18n.Default: true}
+	for _, p := range bodies {
+		for l := range p.Translations {
+			if l != "sr" && !strings.HasPrefix(l, "sr-") {
+				seen[l] = true
+			}
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for l := range seen {
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// validColor reports whether s is a #rgb or #rrggbb hex color
+func validColor(s string) bool {
+	if len(s) != 4 && len(s) != 7 || s[0] != '#' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,230 @@
+// Package views counts how often each body's detail page is opened and
+// which bodies are opened together, for "people also viewed" suggestions.
+// Visitors stay anonymous: they are told apart by a hash of their address
+// and user agent salted with a random key that changes every day and is
+// never stored, only their last few views are held, in memory, for half
+// an hour, and only the totals are written to disk.
+package views
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/internal/jsonfile"
+)
+
+const (
+	// window is how long views by one visitor count as viewed together
+	window = 30 * time.Minute
+	// recentMax is how many of a visitor's views are paired with the next
+	recentMax = 5
+	// visitorsMax caps the visitors held, so a flood can't grow memory
+	visitorsMax = 100_000
+	// saltTTL is how long one salt tells visitors apart
+	saltTTL = 24 * time.Hour
+)
+
+// Totals is what is persisted: views per body and, per pair of bodies
+// viewed by the same visitor within the window, how often that happened
+type Totals struct {
+	Views    map[string]int64            `json:"views"`
+	CoViews  map[string]map[string]int64 `json:"co_views"`
+	Since    time.Time                   `json:"since"`
+	Modified time.Time                   `json:"modified"`
+}
+
+// CoView is a body viewed along with another
+type CoView struct {
+	Body  string  `json:"body"`
+	Count int64   `json:"count"` // visits that viewed both
+	Share float64 `json:"share"` // of the views of the body it was viewed with
+}
+
+type visit struct {
+	bodies []string
+	last   time.Time
+}
+
+// Stats collects views. The zero value is not usable; call Open.
+type Stats struct {
+	mu     sync.Mutex
+	path   string
+	totals Totals
+	dirty  bool
+
+	salt      []byte
+	saltSince time.Time
+	visitors  map[string]*visit
+}
+
+// Open loads the totals file at path, which may not exist yet. An empty
+// path keeps the totals in memory only.
+func Open(path string) (*Stats, error) {
+	s := &Stats{
+		path:     path,
+		totals:   Totals{Views: map[string]int64{}, CoViews: map[string]map[string]int64{}},
+		visitors: make(map[string]*visit),
+	}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.totals); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if s.totals.Views == nil {
+		s.totals.Views = map[string]int64{}
+	}
+	if s.totals.CoViews == nil {
+		s.totals.CoViews = map[string]map[string]int64{}
+	}
+	return s, nil
+}
+
+// Record counts a view of body by the visitor identified by who (address
+// and user agent). A body the visitor viewed within the window isn't
+// counted again; otherwise it is paired with their other recent views.
+func (s *Stats) Record(who, body string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.totals.Since.IsZero() {
+		s.totals.Since = now.UTC()
+	}
+	key := s.visitor(who, now)
+	v := s.visitors[key]
+	if v == nil || now.Sub(v.last) > window {
+		if v == nil && len(s.visitors) >= visitorsMax {
+			s.expire(now)
+		}
+		_, known := s.visitors[key]
+		v = &visit{}
+		if known || len(s.visitors) < visitorsMax {
+			s.visitors[key] = v
+		}
+	}
+	v.last = now
+	for _, b := range v.bodies {
+		if b == body {
+			return
+		}
+	}
+	s.totals.Views[body]++
+	for _, b := range v.bodies {
+		s.pair(b, body)
+		s.pair(body, b)
+	}
+	v.bodies = append(v.bodies, body)
+	if len(v.bodies) > recentMax {
+		v.bodies = v.bodies[1:]
+	}
+	s.totals.Modified = now.UTC()
+	s.dirty = true
+}
+
+func (s *Stats) pair(a, b string) {
+	m := s.totals.CoViews[a]
+	if m == nil {
+		m = make(map[string]int64)
+		s.totals.CoViews[a] = m
+	}
+	m[b]++
+}
+
+// visitor hashes who with the current salt, replacing the salt once it
+// is a day old so no key links a visitor across days. Callers hold s.mu.
+func (s *Stats) visitor(who string, now time.Time) string {
+	if s.salt == nil || now.Sub(s.saltSince) > saltTTL {
+		s.salt = make([]byte, 32)
+		rand.Read(s.salt)
+		s.saltSince = now
+		clear(s.visitors) // their keys can't be produced again
+	}
+	mac := hmac.New(sha256.New, s.salt)
+	mac.Write([]byte(who))
+	return string(mac.Sum(nil)[:16])
+}
+
+// expire forgets visitors outside the window. Callers hold s.mu.
+func (s *Stats) expire(now time.Time) {
+	for k, v := range s.visitors {
+		if now.Sub(v.last) > window {
+			delete(s.visitors, k)
+		}
+	}
+}
+
+// Views returns how often body was viewed
+func (s *Stats) Views(body string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.totals.Views[body]
+}
+
+// AlsoViewed returns the bodies most often viewed with body, at most n
+func (s *Stats) AlsoViewed(body string, n int) []CoView {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]CoView, 0, len(s.totals.CoViews[body]))
+	views := s.totals.Views[body]
+	for b, count := range s.totals.CoViews[body] {
+		cv := CoView{Body: b, Count: count}
+		if views > 0 {
+			cv.Share = float64(count) / float64(views)
+		}
+		out = append(out, cv)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Body < out[j].Body
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// Flush forgets visitors outside the window and writes the totals if they
+// changed since the last save. It is meant to run periodically and at
+// shutdown.
+func (s *Stats) Flush(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(now)
+	if !s.dirty {
+		return nil
+	}
+	return s.save()
+}
+
+func (s *Stats) save() error {
+	if s.path == "" {
+		s.dirty = false
+		return nil
+	}
+	data, err := json.Marshal(s.totals)
+	if err != nil {
+		return err
+	}
+	if err := jsonfile.Write(s.path, data, 0o644); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
@@ -0,0 +1,319 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/events"
+	"solar-system-explorer/backend/internal/random"
+	"solar-system-explorer/backend/tracing"
+)
+
+// logSize bounds the delivery log kept per subscription
+const logSize = 50
+
+// queueSize bounds deliveries waiting for a worker
+const queueSize = 256
+
+// workers is the number of concurrent deliveries
+const workers = 4
+
+// Payload is the JSON body POSTed to subscribers
+type Payload struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Delivery is one attempt to deliver a payload
+type Delivery struct {
+	Payload   string     `json:"payload_id"`
+	Event     string     `json:"event"`
+	Attempt   int        `json:"attempt"`
+	Time      time.Time  `json:"time"`
+	Status    int        `json:"status,omitempty"` // HTTP status, 0 if no response
+	Error     string     `json:"error,omitempty"`
+	Duration  string     `json:"duration"`
+	Succeeded bool       `json:"succeeded"`
+	NextRetry *time.Time `json:"next_retry,omitempty"`
+}
+
+// Deliveries returns a subscription's recent delivery attempts, newest
+// first
+func (h *Hooks) Deliveries(id string) ([]Delivery, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[id]; !ok {
+		return nil, ErrNotFound
+	}
+	entries := h.deliveries[id]
+	out := make([]Delivery, len(entries))
+	for i, d := range entries {
+		out[len(entries)-1-i] = d
+	}
+	return out, nil
+}
+
+// Publish sends data as an event of type typ to every subscription that
+// wants it
+func (h *Hooks) Publish(typ string, data any) {
+	p := Payload{ID: random.Hex(8), Type: typ, CreatedAt: time.Now().UTC(), Data: data}
+	h.mu.Lock()
+	var ids []string
+	for id, r := range h.subs {
+		if r.Wants(typ) {
+			ids = append(ids, id)
+		}
+	}
+	h.mu.Unlock()
+	for _, id := range ids {
+		h.enqueue(task{sub: id, payload: p, attempt: 1})
+	}
+}
+
+// NotifyUpcoming announces astronomical events that have come within each
+// subscription's notice period and weren't announced before. It is meant
+// to run periodically; announced event IDs are persisted so restarts
+// don't repeat them.
+func (h *Hooks) NotifyUpcoming(now time.Time) error {
+	h.mu.Lock()
+	horizon := 0
+	for _, r := range h.subs {
+		if r.Wants(events.TypeSeason) || r.Wants(events.TypeMoonPhase) || r.Wants(events.TypeShower) {
+			horizon = max(horizon, r.DaysBefore)
+		}
+	}
+	h.mu.Unlock()
+	if horizon == 0 {
+		return nil
+	}
+	upcoming := events.Upcoming(now, now.AddDate(0, 0, horizon))
+
+	h.mu.Lock()
+	var tasks []task
+	changed := false
+	for id, r := range h.subs {
+		for key, at := range r.Notified { // forget events that have passed
+			if at.Before(now) {
+				delete(r.Notified, key)
+				changed = true
+			}
+		}
+		limit := now.AddDate(0, 0, r.DaysBefore)
+		for _, e := range upcoming {
+			if !r.Wants(e.Type) || e.Time.After(limit) {
+				continue
+			}
+			if _, done := r.Notified[e.ID]; done {
+				continue
+			}
+			if r.Notified == nil {
+				r.Notified = make(map[string]time.Time)
+			}
+			r.Notified[e.ID] = e.Time
+			changed = true
+			data := struct {
+				events.Event
+				DaysAway float64 `json:"days_away"`
+			}{e, roundDays(e.Time.Sub(now))}
+			tasks = append(tasks, task{
+				sub:     id,
+				payload: Payload{ID: random.Hex(8), Type: e.Type, CreatedAt: now.UTC(), Data: data},
+				attempt: 1,
+			})
+		}
+	}
+	var err error
+	if changed {
+		err = h.save()
+	}
+	h.mu.Unlock()
+
+	for _, t := range tasks {
+		h.enqueue(t)
+	}
+	return err
+}
+
+func roundDays(d time.Duration) float64 {
+	return float64(d.Round(time.Hour)) / float64(24*time.Hour)
+}
+
+// task is one pending delivery attempt
+type task struct {
+	sub     string
+	payload Payload
+	attempt int
+}
+
+// dispatcher delivers tasks on a few workers. A failed attempt is retried
+// after 30s, 1m, 2m, … up to maxAttempts.
+type dispatcher struct {
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+
+	queue  chan task
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newDispatcher(maxAttempts int) dispatcher {
+	return dispatcher{
+		client:      &http.Client{Timeout: 10 * time.Second, Transport: tracing.NewTransport(nil)},
+		maxAttempts: max(maxAttempts, 1),
+		backoff:     30 * time.Second,
+		queue:       make(chan task, queueSize),
+	}
+}
+
+// Start launches the delivery workers. Payloads published before Start
+// wait in the queue.
+func (h *Hooks) Start() {
+	h.ctx, h.cancel = context.WithCancel(context.Background())
+	for i := 0; i < workers; i++ {
+		h.wg.Add(1)
+		go func() {
+			defer h.wg.Done()
+			for {
+				select {
+				case <-h.ctx.Done():
+					return
+				case t := <-h.queue:
+					h.deliver(t)
+				}
+			}
+		}()
+	}
+}
+
+// Stop ends the workers, waiting for in-flight deliveries at most until
+// ctx is done. Queued deliveries and pending retries are dropped.
+func (h *Hooks) Stop(ctx context.Context) {
+	if h.cancel == nil {
+		return
+	}
+	h.cancel()
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+func (h *Hooks) enqueue(t task) {
+	select {
+	case h.queue <- t:
+	default:
+		log.Printf("webhooks: queue full, dropping %s for %s", t.payload.Type, t.sub)
+	}
+}
+
+// deliver makes one attempt and schedules the next on failure
+func (h *Hooks) deliver(t task) {
+	h.mu.Lock()
+	r, ok := h.subs[t.sub]
+	var url, secret string
+	if ok {
+		url, secret = r.URL, r.Secret
+	}
+	h.mu.Unlock()
+	if !ok {
+		return // deleted meanwhile
+	}
+
+	start := time.Now()
+	status, err := h.post(url, secret, t.payload)
+	d := Delivery{
+		Payload:   t.payload.ID,
+		Event:     t.payload.Type,
+		Attempt:   t.attempt,
+		Time:      start.UTC(),
+		Status:    status,
+		Duration:  time.Since(start).Round(time.Millisecond).String(),
+		Succeeded: err == nil,
+	}
+	if err != nil {
+		d.Error = err.Error()
+		if t.attempt < h.maxAttempts {
+			wait := h.backoff << (t.attempt - 1)
+			next := start.Add(wait).UTC()
+			d.NextRetry = &next
+			t.attempt++
+			time.AfterFunc(wait, func() {
+				if h.ctx.Err() == nil {
+					h.enqueue(t)
+				}
+			})
+		}
+	}
+
+	h.mu.Lock()
+	if _, ok := h.subs[t.sub]; ok {
+		entries := append(h.deliveries[t.sub], d)
+		if len(entries) > logSize {
+			entries = entries[len(entries)-logSize:]
+		}
+		h.deliveries[t.sub] = entries
+	}
+	h.mu.Unlock()
+}
+
+// post sends the signed payload. Receivers verify X-Webhook-Signature,
+// "sha256=" + hex HMAC-SHA256 of "<X-Webhook-Timestamp>.<body>" keyed with
+// the subscription secret, and should reject stale timestamps.
+func (h *Hooks) post(url, secret string, p Payload) (int, error) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return 0, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(h.ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "solar-system-explorer-webhooks/1.0")
+	req.Header.Set("X-Webhook-Id", p.ID)
+	req.Header.Set("X-Webhook-Event", p.Type)
+	req.Header.Set("X-Webhook-Timestamp", ts)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(secret, ts, body))
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with
+// secret
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,221 @@
+// Package webhooks delivers signed event notifications to subscribed URLs:
+// dataset changes as they happen and astronomical events a configurable
+// number of days ahead. Failed deliveries are retried with exponential
+// backoff and every attempt is kept in a per-subscription delivery log.
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/events"
+	"solar-system-explorer/backend/internal/jsonfile"
+	"solar-system-explorer/backend/internal/random"
+)
+
+// EventDatasetChanged is published whenever the body dataset changes
+const EventDatasetChanged = "dataset.changed"
+
+// EventTypes are the values a subscription may filter on
+var EventTypes = []string{EventDatasetChanged, events.TypeSeason, events.TypeMoonPhase, events.TypeShower}
+
+// Limits on a subscription's notice period
+const (
+	MinDaysBefore = 1
+	MaxDaysBefore = 90
+)
+
+// Errors returned by Hooks
+var (
+	ErrInvalid  = errors.New("invalid subscription")
+	ErrNotFound = errors.New("subscription not found")
+)
+
+// Subscription is a registered webhook. The signing secret is kept apart
+// and only ever shown once, when the subscription is created.
+type Subscription struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	Events     []string  `json:"events"` // empty means all
+	DaysBefore int       `json:"days_before"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Wants reports whether the subscription receives events of type typ
+func (s Subscription) Wants(typ string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// record is a subscription as persisted: with its secret and the IDs of
+// astronomical events already announced (ID → event time)
+type record struct {
+	Subscription
+	Secret   string               `json:"secret"`
+	Notified map[string]time.Time `json:"notified,omitempty"`
+}
+
+// Hooks holds the subscriptions and their delivery logs. With a path set
+// subscriptions are persisted to that JSON file; delivery logs are kept in
+// memory only.
+type Hooks struct {
+	mu         sync.Mutex
+	path       string
+	subs       map[string]*record
+	deliveries map[string][]Delivery
+
+	dispatcher
+}
+
+// Open loads the subscriptions file at path, which may not exist yet. An
+// empty path keeps subscriptions in memory only. Deliveries start once
+// Start is called.
+func Open(path string, maxAttempts int) (*Hooks, error) {
+	h := &Hooks{
+		path:       path,
+		subs:       make(map[string]*record),
+		deliveries: make(map[string][]Delivery),
+	}
+	h.dispatcher = newDispatcher(maxAttempts)
+	if path == "" {
+		return h, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*record
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	for _, r := range list {
+		h.subs[r.ID] = r
+	}
+	return h, nil
+}
+
+// Create validates and stores a subscription, generating a secret when
+// none is given, and returns it with the secret
+func (h *Hooks) Create(rawURL, secret string, types []string, daysBefore int) (Subscription, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Subscription{}, "", fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalid)
+	}
+	for _, t := range types {
+		if !validType(t) {
+			return Subscription{}, "", fmt.Errorf("%w: unknown event %q", ErrInvalid, t)
+		}
+	}
+	if daysBefore == 0 {
+		daysBefore = MinDaysBefore
+	}
+	if daysBefore < MinDaysBefore || daysBefore > MaxDaysBefore {
+		return Subscription{}, "", fmt.Errorf("%w: days_before must be between %d and %d", ErrInvalid, MinDaysBefore, MaxDaysBefore)
+	}
+	if secret == "" {
+		secret = random.Hex(32)
+	}
+	if types == nil {
+		types = []string{}
+	}
+
+	r := &record{
+		Subscription: Subscription{
+			ID:         random.Hex(8),
+			URL:        u.String(),
+			Events:     types,
+			DaysBefore: daysBefore,
+			CreatedAt:  time.Now().UTC(),
+		},
+		Secret: secret,
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[r.ID] = r
+	if err := h.save(); err != nil {
+		delete(h.subs, r.ID)
+		return Subscription{}, "", err
+	}
+	return r.Subscription, secret, nil
+}
+
+// List returns every subscription, oldest first
+func (h *Hooks) List() []Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]Subscription, 0, len(h.subs))
+	for _, r := range h.subs {
+		out = append(out, r.Subscription)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// Get returns one subscription
+func (h *Hooks) Get(id string) (Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, ok := h.subs[id]
+	if !ok {
+		return Subscription{}, ErrNotFound
+	}
+	return r.Subscription, nil
+}
+
+// Delete removes a subscription and its delivery log, returning it.
+// Retries already scheduled for it are dropped.
+func (h *Hooks) Delete(id string) (Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, ok := h.subs[id]
+	if !ok {
+		return Subscription{}, ErrNotFound
+	}
+	delete(h.subs, id)
+	if err := h.save(); err != nil {
+		h.subs[id] = r
+		return Subscription{}, err
+	}
+	delete(h.deliveries, id)
+	return r.Subscription, nil
+}
+
+// save writes all subscriptions via a temp file and rename. Callers hold
+// h.mu.
+func (h *Hooks) save() error {
+	if h.path == "" {
+		return nil
+	}
+	list := make([]*record, 0, len(h.subs))
+	for _, r := range h.subs {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return jsonfile.Save(h.path, list, 0o600) // holds secrets
+}
+
+func validType(t string) bool {
+	for _, v := range EventTypes {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+// Package wikidata enriches bodies with facts from Wikidata: mass, an
+// image and discovery details. Every value keeps the URL of the statement
+// it came from. Requests are rate limited and responses cached, since the
+// job revisits the same items on every run.
+package wikidata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"solar-system-explorer/backend/upstream"
+)
+
+// DefaultBaseURL is the Wikidata action API
+const DefaultBaseURL = "https://www.wikidata.org/w/api.php"
+
+// userAgent identifies us as Wikimedia's API etiquette asks
+const userAgent = "solar-system-explorer/1.0 (https://github.com/gaciksasa/solar-system-explorer)"
+
+// Client fetches Wikidata entities with a minimum interval between
+// requests and a TTL cache of responses. While Wikidata is unreachable,
+// expired entries are served rather than failing.
+type Client struct {
+	BaseURL  string
+	HTTP     *http.Client
+	Interval time.Duration // minimum time between requests
+	CacheTTL time.Duration
+
+	mu    sync.Mutex
+	next  time.Time
+	cache map[string]cached
+}
+
+type cached struct {
+	entities map[string]Entity
+	fetched  time.Time
+}
+
+// NewClient returns a traced client for baseURL (DefaultBaseURL if empty)
+// that retries and breaks its circuit as policy says
+func NewClient(baseURL string, interval, cacheTTL time.Duration, policy upstream.Policy) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL:  baseURL,
+		HTTP:     upstream.Client("wikidata", 20*time.Second, policy),
+		Interval: interval,
+		CacheTTL: cacheTTL,
+		cache:    make(map[string]cached),
+	}
+}
+
+// Entity is the part of a Wikidata item we read
+type Entity struct {
+	ID     string                            `json:"id"`
+	Labels map[string]struct{ Value string } `json:"labels"`
+	Claims map[string][]Claim                `json:"claims"`
+}
+
+// Label returns the entity's label in the first of langs that has one
+func (e Entity) Label(langs ...string) string {
+	for _, l := range langs {
+		if v, ok := e.Labels[l]; ok {
+			return v.Value
+		}
+	}
+	return e.ID
+}
+
+// Claim is one statement; Rank "deprecated" ones are skipped by callers
+type Claim struct {
+	ID       string `json:"id"`
+	Rank     string `json:"rank"`
+	Mainsnak struct {
+		Datavalue struct {
+			Value json.RawMessage `json:"value"`
+			Type  string          `json:"type"`
+		} `json:"datavalue"`
+	} `json:"mainsnak"`
+}
+
+// Entities fetches items by ID (at most 50, the API limit) with their
+// labels and claims, and reports when the response was fetched
+func (c *Client) Entities(ctx context.Context, ids ...string) (map[string]Entity, time.Time, error) {
+	key := strings.Join(ids, "|")
+	c.mu.Lock()
+	hit, cached := c.cache[key]
+	c.mu.Unlock()
+	if cached && time.Since(hit.fetched) < c.CacheTTL {
+		return hit.entities, hit.fetched, nil
+	}
+	entities, fetched, err := c.fetch(ctx, key)
+	if err != nil && cached && ctx.Err() == nil {
+		return hit.entities, hit.fetched, nil // stale beats nothing
+	}
+	return entities, fetched, err
+}
+
+func (c *Client) fetch(ctx context.Context, key string) (map[string]Entity, time.Time, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, time.Time{}, err
+	}
+	q := url.Values{
+		"action":    {"wbgetentities"},
+		"ids":       {key},
+		"props":     {"labels|claims"},
+		"languages": {"en|sr"},
+		"format":    {"json"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("wikidata: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, fmt.Errorf("wikidata: upstream returned %s", resp.Status)
+	}
+	var body struct {
+		Entities map[string]Entity      `json:"entities"`
+		Error    *struct{ Info string } `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, time.Time{}, fmt.Errorf("wikidata: decoding response: %w", err)
+	}
+	if body.Error != nil {
+		return nil, time.Time{}, fmt.Errorf("wikidata: %s", body.Error.Info)
+	}
+
+	fetched := time.Now().UTC()
+	c.mu.Lock()
+	c.cache[key] = cached{entities: body.Entities, fetched: fetched}
+	c.mu.Unlock()
+	return body.Entities, fetched, nil
+}
+
+// wait blocks until the next request slot, or ctx is done
+func (c *Client) wait(ctx context.Context) error {
+	c.mu.Lock()
+	now := time.Now()
+	at := c.next
+	if at.Before(now) {
+		at = now
+	}
+	c.next = at.Add(c.Interval)
+	c.mu.Unlock()
+
+	select {
+	case <-time.After(time.Until(at)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PurgeExpired drops cache entries older than the TTL
+func (c *Client) PurgeExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, v := range c.cache {
+		if time.Since(v.fetched) >= c.CacheTTL {
+			delete(c.cache, k)
+		}
+	}
+}
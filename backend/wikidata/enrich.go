@@ -0,0 +1,165 @@
+package wikidata
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"solar-system-explorer/backend/models"
+)
+
+// Properties read from each item
+const (
+	propImage      = "P18"
+	propMass       = "P2067"
+	propDiscoverer = "P61"
+	propDiscovered = "P575"
+)
+
+// kilogram is the unit masses must be given in to be used
+const kilogram = "http://www.wikidata.org/entity/Q11570"
+
+// batchSize is the wbgetentities limit on IDs per request
+const batchSize = 50
+
+// Enrich fetches facts for every body that has a Wikidata ID and returns
+// them keyed by body name, ready for store.SetSupplements. Keys are
+// "mass_kg", "image", "discovered" and "discoverer"; bodies Wikidata has
+// nothing on are left out.
+func Enrich(ctx context.Context, c *Client, bodies []models.Planet) (map[string]map[string]models.Sourced, error) {
+	byID := make(map[string]string)
+	var ids []string
+	for _, b := range bodies {
+		if b.WikidataID != "" {
+			byID[b.WikidataID] = b.Name
+			ids = append(ids, b.WikidataID)
+		}
+	}
+	c.PurgeExpired()
+	items, retrieved, err := fetchAll(ctx, c, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]map[string]models.Sourced)
+	discoverers := make(map[string][]string) // person QID → bodies
+	for id, e := range items {
+		name, ok := byID[id]
+		if !ok {
+			continue
+		}
+		facts := make(map[string]models.Sourced)
+		set := func(key, prop string, v any) {
+			facts[key] = models.Sourced{Value: v, Source: "https://www.wikidata.org/wiki/" + id + "#" + prop, RetrievedAt: retrieved[id]}
+		}
+
+		if v, ok := best(e, propMass); ok {
+			var q struct{ Amount, Unit string }
+			if json.Unmarshal(v, &q) == nil && q.Unit == kilogram {
+				if kg, err := strconv.ParseFloat(strings.TrimPrefix(q.Amount, "+"), 64); err == nil {
+					set("mass_kg", propMass, kg)
+				}
+			}
+		}
+		if v, ok := best(e, propImage); ok {
+			var file string
+			if json.Unmarshal(v, &file) == nil && file != "" {
+				set("image", propImage, "https://commons.wikimedia.org/wiki/Special:FilePath/"+url.PathEscape(strings.ReplaceAll(file, " ", "_")))
+			}
+		}
+		if v, ok := best(e, propDiscovered); ok {
+			var t struct {
+				Time      string
+				Precision int
+			}
+			if json.Unmarshal(v, &t) == nil {
+				if d := formatTime(t.Time, t.Precision); d != "" {
+					set("discovered", propDiscovered, d)
+				}
+			}
+		}
+		if v, ok := best(e, propDiscoverer); ok {
+			var ref struct{ ID string }
+			if json.Unmarshal(v, &ref) == nil && ref.ID != "" {
+				discoverers[ref.ID] = append(discoverers[ref.ID], name)
+				set("discoverer", propDiscoverer, ref.ID) // replaced by the label below
+			}
+		}
+		if len(facts) > 0 {
+			out[name] = facts
+		}
+	}
+
+	// Resolve discoverer QIDs to names in one more round of requests
+	var people []string
+	for id := range discoverers {
+		people = append(people, id)
+	}
+	labels, _, err := fetchAll(ctx, c, people)
+	if err != nil {
+		return nil, err
+	}
+	for id, names := range discoverers {
+		if p, ok := labels[id]; ok {
+			for _, name := range names {
+				f := out[name]["discoverer"]
+				f.Value = p.Label("en", "sr")
+				out[name]["discoverer"] = f
+			}
+		}
+	}
+	return out, nil
+}
+
+// fetchAll requests ids in API-sized batches, returning the entities and
+// when each was retrieved
+func fetchAll(ctx context.Context, c *Client, ids []string) (map[string]Entity, map[string]time.Time, error) {
+	all := make(map[string]Entity)
+	retrieved := make(map[string]time.Time)
+	for start := 0; start < len(ids); start += batchSize {
+		end := min(start+batchSize, len(ids))
+		items, fetched, err := c.Entities(ctx, ids[start:end]...)
+		if err != nil {
+			return nil, nil, err
+		}
+		for id, e := range items {
+			all[id], retrieved[id] = e, fetched
+		}
+	}
+	return all, retrieved, nil
+}
+
+// best returns the value of the highest-ranked, non-deprecated claim
+func best(e Entity, prop string) (json.RawMessage, bool) {
+	var found json.RawMessage
+	for _, cl := range e.Claims[prop] {
+		v := cl.Mainsnak.Datavalue.Value
+		switch {
+		case len(v) == 0 || cl.Rank == "deprecated":
+		case cl.Rank == "preferred":
+			return v, true
+		case found == nil:
+			found = v
+		}
+	}
+	return found, found != nil
+}
+
+// formatTime renders a Wikidata time value at its precision: 11 is a day,
+// 10 a month, 9 a year
+func formatTime(t string, precision int) string {
+	t = strings.TrimPrefix(t, "+")
+	date, _, _ := strings.Cut(t, "T")
+	switch {
+	case precision >= 11:
+		return date
+	case precision == 10 && len(date) >= 7:
+		return date[:7]
+	case precision == 9 && len(date) >= 4:
+		return date[:4]
+	}
+	return ""
+}
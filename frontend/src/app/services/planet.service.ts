@@ -13,15 +13,17 @@ interface ApiResponse {
 export class PlanetService {
   private http = inject(HttpClient);
   private apiUrl = '/api';
+  // The UI is Serbian; pin it so the browser's Accept-Language doesn't switch descriptions
+  private params = { lang: 'sr' };
 
   getPlanets(): Observable<Planet[]> {
-    return this.http.get<ApiResponse>(`${this.apiUrl}/planets`).pipe(
+    return this.http.get<ApiResponse>(`${this.apiUrl}/planets`, { params: this.params }).pipe(
       map(response => response.data)
     );
   }
 
   getPlanet(name: string): Observable<Planet> {
-    return this.http.get<{ data: Planet }>(`${this.apiUrl}/planets/${name}`).pipe(
+    return this.http.get<{ data: Planet }>(`${this.apiUrl}/planets/${name}`, { params: this.params }).pipe(
       map(response => response.data)
     );
   }